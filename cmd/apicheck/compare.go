@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Violation is a single backward-incompatible change.
+type Violation struct {
+	Element string // fully qualified element, e.g. helloworld.v1.HelloRequest.name
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Element, v.Message)
+}
+
+// index holds descriptors of a file set keyed by fully qualified name.
+type index struct {
+	messages map[string]*descriptorpb.DescriptorProto
+	enums    map[string]*descriptorpb.EnumDescriptorProto
+	services map[string]*descriptorpb.ServiceDescriptorProto
+}
+
+func newIndex(set *descriptorpb.FileDescriptorSet) *index {
+	idx := &index{
+		messages: map[string]*descriptorpb.DescriptorProto{},
+		enums:    map[string]*descriptorpb.EnumDescriptorProto{},
+		services: map[string]*descriptorpb.ServiceDescriptorProto{},
+	}
+	for _, f := range set.GetFile() {
+		prefix := f.GetPackage()
+		for _, m := range f.GetMessageType() {
+			idx.addMessage(prefix, m)
+		}
+		for _, e := range f.GetEnumType() {
+			idx.enums[join(prefix, e.GetName())] = e
+		}
+		for _, s := range f.GetService() {
+			idx.services[join(prefix, s.GetName())] = s
+		}
+	}
+	return idx
+}
+
+func (idx *index) addMessage(prefix string, m *descriptorpb.DescriptorProto) {
+	name := join(prefix, m.GetName())
+	idx.messages[name] = m
+	for _, nested := range m.GetNestedType() {
+		idx.addMessage(name, nested)
+	}
+	for _, e := range m.GetEnumType() {
+		idx.enums[join(name, e.GetName())] = e
+	}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Compare returns the breaking changes from old to cur, sorted by element.
+func Compare(old, cur *descriptorpb.FileDescriptorSet) []Violation {
+	o, c := newIndex(old), newIndex(cur)
+	var vs []Violation
+
+	for name, om := range o.messages {
+		cm, ok := c.messages[name]
+		if !ok {
+			vs = append(vs, Violation{name, "message removed"})
+			continue
+		}
+		vs = append(vs, compareMessage(name, om, cm)...)
+	}
+
+	for name, oe := range o.enums {
+		ce, ok := c.enums[name]
+		if !ok {
+			vs = append(vs, Violation{name, "enum removed"})
+			continue
+		}
+		vs = append(vs, compareEnum(name, oe, ce)...)
+	}
+
+	for name, ov := range o.services {
+		cs, ok := c.services[name]
+		if !ok {
+			vs = append(vs, Violation{name, "service removed"})
+			continue
+		}
+		vs = append(vs, compareService(name, ov, cs)...)
+	}
+
+	sort.Slice(vs, func(i, j int) bool {
+		if vs[i].Element != vs[j].Element {
+			return vs[i].Element < vs[j].Element
+		}
+		return vs[i].Message < vs[j].Message
+	})
+	return vs
+}
+
+func compareMessage(name string, om, cm *descriptorpb.DescriptorProto) []Violation {
+	var vs []Violation
+	cur := make(map[int32]*descriptorpb.FieldDescriptorProto, len(cm.GetField()))
+	for _, f := range cm.GetField() {
+		cur[f.GetNumber()] = f
+	}
+	for _, of := range om.GetField() {
+		element := join(name, of.GetName())
+		cf, ok := cur[of.GetNumber()]
+		if !ok {
+			if !isReserved(cm, of.GetNumber()) {
+				vs = append(vs, Violation{element, fmt.Sprintf("field %d removed without being reserved", of.GetNumber())})
+			}
+			continue
+		}
+		if cf.GetName() != of.GetName() {
+			vs = append(vs, Violation{element, fmt.Sprintf("field %d renamed to %q (breaks JSON)", of.GetNumber(), cf.GetName())})
+		}
+		if cf.GetType() != of.GetType() || cf.GetTypeName() != of.GetTypeName() {
+			vs = append(vs, Violation{element, fmt.Sprintf("type changed from %s to %s", fieldType(of), fieldType(cf))})
+		}
+		if cf.GetLabel() != of.GetLabel() {
+			vs = append(vs, Violation{element, fmt.Sprintf("label changed from %s to %s", of.GetLabel(), cf.GetLabel())})
+		}
+		if (cf.OneofIndex == nil) != (of.OneofIndex == nil) {
+			vs = append(vs, Violation{element, "moved into or out of a oneof"})
+		}
+	}
+	return vs
+}
+
+func isReserved(m *descriptorpb.DescriptorProto, number int32) bool {
+	for _, r := range m.GetReservedRange() {
+		// End is exclusive.
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldType(f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetTypeName() != "" {
+		return strings.TrimPrefix(f.GetTypeName(), ".")
+	}
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+func compareEnum(name string, oe, ce *descriptorpb.EnumDescriptorProto) []Violation {
+	var vs []Violation
+	cur := make(map[int32]string, len(ce.GetValue()))
+	for _, v := range ce.GetValue() {
+		cur[v.GetNumber()] = v.GetName()
+	}
+	for _, ov := range oe.GetValue() {
+		element := join(name, ov.GetName())
+		cn, ok := cur[ov.GetNumber()]
+		if !ok {
+			if !isEnumReserved(ce, ov.GetNumber()) {
+				vs = append(vs, Violation{element, fmt.Sprintf("enum value %d removed without being reserved", ov.GetNumber())})
+			}
+			continue
+		}
+		if cn != ov.GetName() {
+			vs = append(vs, Violation{element, fmt.Sprintf("enum value %d renamed to %q (breaks JSON)", ov.GetNumber(), cn)})
+		}
+	}
+	return vs
+}
+
+func isEnumReserved(e *descriptorpb.EnumDescriptorProto, number int32) bool {
+	for _, r := range e.GetReservedRange() {
+		// End is inclusive for enums.
+		if number >= r.GetStart() && number <= r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func compareService(name string, ov, cs *descriptorpb.ServiceDescriptorProto) []Violation {
+	var vs []Violation
+	cur := make(map[string]*descriptorpb.MethodDescriptorProto, len(cs.GetMethod()))
+	for _, m := range cs.GetMethod() {
+		cur[m.GetName()] = m
+	}
+	for _, om := range ov.GetMethod() {
+		element := join(name, om.GetName())
+		cm, ok := cur[om.GetName()]
+		if !ok {
+			vs = append(vs, Violation{element, "method removed"})
+			continue
+		}
+		if cm.GetInputType() != om.GetInputType() {
+			vs = append(vs, Violation{element, fmt.Sprintf("request type changed from %s to %s", om.GetInputType(), cm.GetInputType())})
+		}
+		if cm.GetOutputType() != om.GetOutputType() {
+			vs = append(vs, Violation{element, fmt.Sprintf("response type changed from %s to %s", om.GetOutputType(), cm.GetOutputType())})
+		}
+		if cm.GetClientStreaming() != om.GetClientStreaming() || cm.GetServerStreaming() != om.GetServerStreaming() {
+			vs = append(vs, Violation{element, "streaming mode changed"})
+		}
+	}
+	return vs
+}
+
+// Allowlist holds intentionally accepted breaking changes.
+// Each entry is a fully qualified element name; a trailing ".*" matches
+// everything below that element.
+type Allowlist []string
+
+// Allows reports whether the violation is intentionally accepted.
+func (a Allowlist) Allows(v Violation) bool {
+	for _, entry := range a {
+		if prefix, ok := strings.CutSuffix(entry, ".*"); ok {
+			if v.Element == prefix || strings.HasPrefix(v.Element, prefix+".") {
+				return true
+			}
+			continue
+		}
+		if v.Element == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAllowlist parses allowlist file content.
+// Blank lines and lines starting with '#' are ignored.
+func parseAllowlist(content string) Allowlist {
+	var a Allowlist
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		a = append(a, line)
+	}
+	return a
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
+
+func fileSet(fields []*descriptorpb.FieldDescriptorProto, reserved []int32, methods ...string) *descriptorpb.FileDescriptorSet {
+	msg := &descriptorpb.DescriptorProto{Name: proto.String("HelloRequest"), Field: fields}
+	for _, n := range reserved {
+		msg.ReservedRange = append(msg.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(n), End: proto.Int32(n + 1)})
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Greeter")}
+	for _, m := range methods {
+		svc.Method = append(svc.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(m),
+			InputType:  proto.String(".helloworld.v1.HelloRequest"),
+			OutputType: proto.String(".helloworld.v1.HelloRequest"),
+		})
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("helloworld/v1/greeter.proto"),
+		Package:     proto.String("helloworld.v1"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+		Service:     []*descriptorpb.ServiceDescriptorProto{svc},
+	}}}
+}
+
+func TestCompare(t *testing.T) {
+	name := field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	age := field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32)
+	old := fileSet([]*descriptorpb.FieldDescriptorProto{name, age}, nil, "SayHello")
+
+	tests := []struct {
+		name     string
+		cur      *descriptorpb.FileDescriptorSet
+		expected []Violation
+	}{
+		{
+			name: "unchanged",
+			cur:  old,
+		},
+		{
+			name: "field added and method added",
+			cur: fileSet([]*descriptorpb.FieldDescriptorProto{
+				name, age, field("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}, nil, "SayHello", "SayBye"),
+		},
+		{
+			name: "field removed and reserved",
+			cur:  fileSet([]*descriptorpb.FieldDescriptorProto{name}, []int32{2}, "SayHello"),
+		},
+		{
+			name: "field removed",
+			cur:  fileSet([]*descriptorpb.FieldDescriptorProto{name}, nil, "SayHello"),
+			expected: []Violation{
+				{"helloworld.v1.HelloRequest.age", "field 2 removed without being reserved"},
+			},
+		},
+		{
+			name: "type changed and renamed",
+			cur: fileSet([]*descriptorpb.FieldDescriptorProto{
+				name, field("years", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}, nil, "SayHello"),
+			expected: []Violation{
+				{"helloworld.v1.HelloRequest.age", `field 2 renamed to "years" (breaks JSON)`},
+				{"helloworld.v1.HelloRequest.age", "type changed from int32 to string"},
+			},
+		},
+		{
+			name: "method removed",
+			cur:  fileSet([]*descriptorpb.FieldDescriptorProto{name, age}, nil),
+			expected: []Violation{
+				{"helloworld.v1.Greeter.SayHello", "method removed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Compare(old, tt.cur))
+		})
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	allow := parseAllowlist("# comment\n\nhelloworld.v1.HelloRequest.age\nhelloworld.v1.Greeter.*\n")
+
+	assert.True(t, allow.Allows(Violation{Element: "helloworld.v1.HelloRequest.age"}))
+	assert.False(t, allow.Allows(Violation{Element: "helloworld.v1.HelloRequest.name"}))
+	assert.True(t, allow.Allows(Violation{Element: "helloworld.v1.Greeter.SayHello"}))
+	assert.True(t, allow.Allows(Violation{Element: "helloworld.v1.Greeter"}))
+	assert.False(t, allow.Allows(Violation{Element: "helloworld.v1.GreeterV2"}))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Register API descriptors. Add new API packages here.
+	_ "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+// apiGoPackagePrefix selects which registered proto files belong to the API surface.
+const apiGoPackagePrefix = "github.com/go-kratos/kratos-layout/api/"
+
+var (
+	flagBaseline  string
+	flagAllowlist string
+	flagUpdate    bool
+)
+
+func main() {
+	flag.StringVar(&flagBaseline, "baseline", "api/baseline.binpb", "path to the baseline FileDescriptorSet")
+	flag.StringVar(&flagAllowlist, "allowlist", "api/apicheck.allow", "path to the allowlist of intentional breaking changes")
+	flag.BoolVar(&flagUpdate, "update", false, "overwrite the baseline with the current descriptors")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cur := currentDescriptors()
+
+	if flagUpdate {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(cur)
+		if err != nil {
+			return fmt.Errorf("marshal descriptors: %w", err)
+		}
+		if err := os.WriteFile(flagBaseline, data, 0o600); err != nil {
+			return fmt.Errorf("write baseline: %w", err)
+		}
+		fmt.Printf("baseline updated: %s (%d files)\n", flagBaseline, len(cur.GetFile()))
+		return nil
+	}
+
+	data, err := os.ReadFile(flagBaseline)
+	if err != nil {
+		return fmt.Errorf("read baseline (run with -update to create it): %w", err)
+	}
+	var old descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &old); err != nil {
+		return fmt.Errorf("unmarshal baseline: %w", err)
+	}
+
+	var allow Allowlist
+	if content, err := os.ReadFile(flagAllowlist); err == nil {
+		allow = parseAllowlist(string(content))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read allowlist: %w", err)
+	}
+
+	var breaking int
+	for _, v := range Compare(&old, cur) {
+		if allow.Allows(v) {
+			fmt.Printf("allowed: %s\n", v)
+			continue
+		}
+		fmt.Printf("BREAKING: %s\n", v)
+		breaking++
+	}
+	if breaking > 0 {
+		return fmt.Errorf("%d breaking change(s) found", breaking)
+	}
+	fmt.Println("no breaking changes")
+	return nil
+}
+
+// currentDescriptors collects the registered API proto files.
+func currentDescriptors() *descriptorpb.FileDescriptorSet {
+	var files []*descriptorpb.FileDescriptorProto
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		fdp := protodesc.ToFileDescriptorProto(fd)
+		if strings.HasPrefix(fdp.GetOptions().GetGoPackage(), apiGoPackagePrefix) {
+			files = append(files, fdp)
+		}
+		return true
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].GetName() < files[j].GetName() })
+	return &descriptorpb.FileDescriptorSet{File: files}
+}
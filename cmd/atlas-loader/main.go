@@ -5,10 +5,14 @@ import (
 	"os"
 
 	"ariga.io/atlas-provider-gorm/gormschema"
+
+	"github.com/go-kratos/kratos-layout/internal/data/models"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
 )
 
 func main() {
-	stmts, err := gormschema.New("mysql").Load()
+	models.RegisterAll()
+	stmts, err := gormschema.New("mysql").Load(orm.Models()...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
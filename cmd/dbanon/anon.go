@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strategies of Table.Columns.
+const (
+	StrategyEmail   = "email"   // user-<hash>@example.com
+	StrategyPhone   = "phone"   // keeps the format and the first 3 digits
+	StrategyName    = "name"    // user-<hash>
+	StrategyHash    = "hash"    // 16 hex characters
+	StrategyNullify = "nullify" // NULL
+)
+
+// Config lists the tables to copy, parents before children.
+type Config struct {
+	Tables []Table `yaml:"tables"`
+}
+
+// Table is one table to copy.
+type Table struct {
+	Name string `yaml:"name"`
+	// Where filters the copied rows, e.g. "created_at > NOW() - INTERVAL 30 DAY".
+	Where string `yaml:"where"`
+	// Limit caps the copied rows; zero copies all.
+	Limit int `yaml:"limit"`
+	// Columns maps the PII columns to their strategy.
+	Columns map[string]string `yaml:"columns"`
+}
+
+// ParseConfig parses a YAML config:
+//
+//	tables:
+//	  - name: users
+//	    where: deleted_at IS NULL
+//	    columns:
+//	      email: email
+//	      phone: phone
+//	  - name: orders
+//	    limit: 10000
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if len(c.Tables) == 0 {
+		return nil, errors.New("config lists no tables")
+	}
+	seen := make(map[string]bool)
+	for _, t := range c.Tables {
+		if t.Name == "" {
+			return nil, errors.New("table without name")
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("table %s listed twice", t.Name)
+		}
+		seen[t.Name] = true
+		for col, s := range t.Columns {
+			switch s {
+			case StrategyEmail, StrategyPhone, StrategyName, StrategyHash, StrategyNullify:
+			default:
+				return nil, fmt.Errorf("table %s column %s: unknown strategy %q", t.Name, col, s)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// Anonymizer pseudonymizes values consistently: the same value gets the same
+// pseudonym in every table and run with the same secret, so joins on
+// anonymized columns still match, and without the secret pseudonyms cannot
+// be reversed by hashing guessed values.
+type Anonymizer struct {
+	secret []byte
+}
+
+// NewAnonymizer creates an Anonymizer keyed with secret.
+func NewAnonymizer(secret string) (*Anonymizer, error) {
+	if len(secret) < 16 {
+		return nil, errors.New("secret must be at least 16 characters")
+	}
+	return &Anonymizer{secret: []byte(secret)}, nil
+}
+
+func (a *Anonymizer) digest(strategy, value string) []byte {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(strategy))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// Anonymize returns the pseudonym of v, a value scanned from the source
+// database. NULLs stay NULL.
+func (a *Anonymizer) Anonymize(strategy string, v any) any {
+	if v == nil || strategy == StrategyNullify {
+		return nil
+	}
+	var s string
+	switch v := v.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		s = fmt.Sprint(v)
+	}
+	sum := a.digest(strategy, s)
+	switch strategy {
+	case StrategyEmail:
+		return "user-" + hex.EncodeToString(sum[:6]) + "@example.com"
+	case StrategyPhone:
+		return a.phone(s, sum)
+	case StrategyName:
+		return "user-" + hex.EncodeToString(sum[:5])
+	default:
+		return hex.EncodeToString(sum[:8])
+	}
+}
+
+// phone replaces the digits of s after the first 3 with digits of sum,
+// keeping separators and the length.
+func (a *Anonymizer) phone(s string, sum []byte) string {
+	var b strings.Builder
+	digits := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			b.WriteRune(c)
+			continue
+		}
+		if digits >= 3 {
+			c = rune('0' + sum[digits%len(sum)]%10)
+		}
+		b.WriteRune(c)
+		digits++
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+const testSecret = "0123456789abcdef"
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+tables:
+  - name: users
+    where: deleted_at IS NULL
+    columns:
+      email: email
+      phone: phone
+  - name: orders
+    limit: 10
+`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Tables, 2)
+	assert.Equal(t, "deleted_at IS NULL", cfg.Tables[0].Where)
+	assert.Equal(t, map[string]string{"email": StrategyEmail, "phone": StrategyPhone}, cfg.Tables[0].Columns)
+	assert.Equal(t, 10, cfg.Tables[1].Limit)
+
+	for _, bad := range []string{
+		"tables: []",
+		"tables:\n  - where: x",
+		"tables:\n  - name: a\n  - name: a",
+		"tables:\n  - name: a\n    columns:\n      email: mask",
+		"tables: [",
+	} {
+		_, err := ParseConfig([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestAnonymizer(t *testing.T) {
+	_, err := NewAnonymizer("short")
+	require.Error(t, err)
+	a, err := NewAnonymizer(testSecret)
+	require.NoError(t, err)
+
+	email := a.Anonymize(StrategyEmail, []byte("alice@corp.com"))
+	assert.Regexp(t, regexp.MustCompile(`^user-[0-9a-f]{12}@example\.com$`), email)
+	assert.Equal(t, email, a.Anonymize(StrategyEmail, "alice@corp.com"), "consistent across types")
+	assert.NotEqual(t, email, a.Anonymize(StrategyEmail, "bob@corp.com"))
+
+	other, err := NewAnonymizer("fedcba9876543210")
+	require.NoError(t, err)
+	assert.NotEqual(t, email, other.Anonymize(StrategyEmail, "alice@corp.com"), "keyed by the secret")
+
+	phone := a.Anonymize(StrategyPhone, "+86 138-0013-8000").(string)
+	assert.Regexp(t, regexp.MustCompile(`^\+86 1\d\d-\d{4}-\d{4}$`), phone)
+	assert.NotEqual(t, "+86 138-0013-8000", phone)
+
+	assert.Regexp(t, regexp.MustCompile(`^user-[0-9a-f]{10}$`), a.Anonymize(StrategyName, "Alice"))
+	assert.Len(t, a.Anonymize(StrategyHash, int64(42)), 16)
+	assert.Nil(t, a.Anonymize(StrategyNullify, "secret"))
+	assert.Nil(t, a.Anonymize(StrategyEmail, nil))
+}
+
+func openSQLite(t *testing.T, name string) *sql.DB {
+	sqlDB, err := ormtest.Open(t, ormtest.WithName(name), ormtest.WithSharedCache()).DB()
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, note TEXT)")
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), email TEXT)")
+	require.NoError(t, err)
+	return sqlDB
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	src, dst := openSQLite(t, "src"), openSQLite(t, "dst")
+	_, err := src.Exec("INSERT INTO users VALUES (1, 'alice@corp.com', 'vip'), (2, NULL, 'x'), (3, 'carol@corp.com', 'deleted')")
+	require.NoError(t, err)
+	_, err = src.Exec("INSERT INTO orders VALUES (10, 1, 'alice@corp.com'), (11, 2, NULL)")
+	require.NoError(t, err)
+	_, err = dst.Exec("INSERT INTO users VALUES (99, 'stale', '')")
+	require.NoError(t, err)
+
+	cfg := &Config{Tables: []Table{
+		{Name: "users", Where: "note <> 'deleted'", Columns: map[string]string{"email": StrategyEmail, "note": StrategyNullify}},
+		{Name: "orders", Columns: map[string]string{"email": StrategyEmail}},
+	}}
+	a, err := NewAnonymizer(testSecret)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, Copy(ctx, src, dst, cfg, a, 1, &out))
+	assert.Equal(t, "users: 2 rows\norders: 2 rows\n", out.String())
+
+	var userEmail, orderEmail string
+	require.NoError(t, dst.QueryRow("SELECT u.email, o.email FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = 1").Scan(&userEmail, &orderEmail))
+	assert.Equal(t, a.Anonymize(StrategyEmail, "alice@corp.com"), userEmail)
+	assert.Equal(t, userEmail, orderEmail, "pseudonyms are consistent across tables")
+
+	var count int
+	require.NoError(t, dst.QueryRow("SELECT COUNT(*) FROM users WHERE note IS NOT NULL OR id IN (3, 99)").Scan(&count))
+	assert.Zero(t, count)
+
+	cfg.Tables[0].Columns = map[string]string{"mail": StrategyEmail}
+	assert.ErrorContains(t, Copy(ctx, src, dst, cfg, a, 1, &out), "column mail not found")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// maxPlaceholders bounds the parameters of one INSERT (SQLite's limit, half
+// of MySQL's).
+const maxPlaceholders = 32766
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Copy empties the configured tables of dst, children first, then copies
+// them from src, anonymizing their PII columns, and reports the row counts
+// to out.
+func Copy(ctx context.Context, src, dst *sql.DB, cfg *Config, anon *Anonymizer, batch int, out io.Writer) error {
+	for _, t := range slices.Backward(cfg.Tables) {
+		if _, err := dst.ExecContext(ctx, "DELETE FROM "+quoteIdentifier(t.Name)); err != nil {
+			return fmt.Errorf("clear %s: %w", t.Name, err)
+		}
+	}
+	for _, t := range cfg.Tables {
+		n, err := copyTable(ctx, src, dst, t, anon, batch)
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", t.Name, err)
+		}
+		fmt.Fprintf(out, "%s: %d rows\n", t.Name, n)
+	}
+	return nil
+}
+
+func copyTable(ctx context.Context, src, dst *sql.DB, t Table, anon *Anonymizer, batch int) (int, error) {
+	query := "SELECT * FROM " + quoteIdentifier(t.Name)
+	if t.Where != "" {
+		query += " WHERE " + t.Where
+	}
+	if t.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", t.Limit)
+	}
+	rows, err := src.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	// A misspelled column would silently copy PII.
+	strategies := make([]string, len(columns))
+	for col, s := range t.Columns {
+		i := slices.Index(columns, col)
+		if i < 0 {
+			return 0, fmt.Errorf("column %s not found", col)
+		}
+		strategies[i] = s
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdentifier(c)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quoteIdentifier(t.Name), strings.Join(quoted, ", "))
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	batch = min(batch, maxPlaceholders/len(columns))
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var (
+		args []any
+		n    int
+	)
+	flush := func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		count := len(args) / len(columns)
+		stmt := insert + strings.TrimSuffix(strings.Repeat(tuple+", ", count), ", ")
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+		n += count
+		args = args[:0]
+		return nil
+	}
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		for i, v := range values {
+			if strategies[i] != "" {
+				v = anon.Anonymize(strategies[i], v)
+			}
+			args = append(args, v)
+		}
+		if len(args)/len(columns) >= batch {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	if err := flush(); err != nil {
+		return n, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,101 @@
+// Command dbanon copies production tables into a dev or staging database,
+// pseudonymizing their PII columns, so realistic datasets can be used
+// outside production:
+//
+//	DBANON_SECRET=... go run ./cmd/dbanon -config dbanon.yaml \
+//		-source 'reader:pw@tcp(prod-replica:3306)/app' \
+//		-target 'root:pw@tcp(127.0.0.1:3306)/app_dev'
+//
+// See ParseConfig for the config format. Pseudonyms are keyed HMACs of the
+// original values (see Anonymizer): keep the secret out of the target
+// environment. The copied tables of the target are emptied first, and the
+// target database name must contain dev, test or staging unless -force is
+// set.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	flagConfig string
+	flagSource string
+	flagTarget string
+	flagBatch  int
+	flagForce  bool
+)
+
+func main() {
+	flag.StringVar(&flagConfig, "config", "dbanon.yaml", "path to the tables and columns to copy")
+	flag.StringVar(&flagSource, "source", "", "MySQL DSN of the source database (read only access is enough)")
+	flag.StringVar(&flagTarget, "target", "", "MySQL DSN of the target database")
+	flag.IntVar(&flagBatch, "batch", 500, "rows per INSERT")
+	flag.BoolVar(&flagForce, "force", false, "allow a target database name without dev, test or staging")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "dbanon: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if flagSource == "" || flagTarget == "" {
+		return errors.New("-source and -target are required")
+	}
+	if flagBatch <= 0 {
+		return errors.New("-batch must be positive")
+	}
+	target, err := mysql.ParseDSN(flagTarget)
+	if err != nil {
+		return fmt.Errorf("parse -target: %w", err)
+	}
+	if !flagForce && !isNonProduction(target.DBName) {
+		return fmt.Errorf("target database %q is not a dev, test or staging database (use -force)", target.DBName)
+	}
+	data, err := os.ReadFile(flagConfig)
+	if err != nil {
+		return err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return err
+	}
+	anon, err := NewAnonymizer(os.Getenv("DBANON_SECRET"))
+	if err != nil {
+		return fmt.Errorf("DBANON_SECRET: %w", err)
+	}
+
+	src, err := sql.Open("mysql", flagSource)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := sql.Open("mysql", flagTarget)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return Copy(ctx, src, dst, cfg, anon, flagBatch, os.Stdout)
+}
+
+func isNonProduction(dbName string) bool {
+	for _, s := range []string{"dev", "test", "staging"} {
+		if strings.Contains(dbName, s) {
+			return true
+		}
+	}
+	return false
+}
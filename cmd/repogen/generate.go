@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// recorderImport is the runtime package of the generated decorators.
+const recorderImport = "github.com/go-kratos/kratos-layout/pkg/instrument"
+
+// Config describes one generation run.
+type Config struct {
+	// Source is the Go file declaring the interfaces.
+	Source     []byte
+	SourceName string
+	// ImportPath is the import path of the source package.
+	ImportPath string
+	// Package is the name of the generated package.
+	Package string
+	// Types lists the interfaces to wrap. Empty wraps every interface
+	// whose name ends in Repo.
+	Types []string
+}
+
+type repo struct {
+	Name    string
+	Type    string
+	Methods []method
+}
+
+type method struct {
+	Name string
+	// Params and Results are the signature, e.g. "ctx context.Context, p1 int64".
+	Params  string
+	Results string
+	// Args are the call arguments, e.g. "ctx, p1".
+	Args string
+	// Ctx is the context parameter, "" when the method takes none and is
+	// passed through uninstrumented.
+	Ctx string
+	// Err is the named error result, "" when the method returns no error.
+	Err string
+}
+
+// Generate returns the formatted decorator source.
+func Generate(c Config) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, c.SourceName, c.Source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		srcPkg:  file.Name.Name,
+		imports: make(map[string]string),
+		used:    map[string]bool{c.ImportPath: true, recorderImport: true},
+	}
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		g.imports[name] = p
+	}
+
+	var repos []repo
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || !selected(ts.Name.Name, c.Types) {
+				continue
+			}
+			r, err := g.repo(ts.Name.Name, it)
+			if err != nil {
+				return nil, err
+			}
+			repos = append(repos, r)
+		}
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repository interfaces in %s", c.SourceName)
+	}
+
+	// Standard library imports go first, as goimports groups them.
+	var std, other []string
+	for p := range g.used {
+		if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			other = append(other, strconv.Quote(p))
+		} else {
+			std = append(std, strconv.Quote(p))
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(other)
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]any{
+		"Source":  c.SourceName,
+		"Package": c.Package,
+		"Std":     std,
+		"Imports": other,
+		"Repos":   repos,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.Bytes())
+	}
+	return out, nil
+}
+
+func selected(name string, types []string) bool {
+	if len(types) == 0 {
+		return strings.HasSuffix(name, "Repo")
+	}
+	return slices.Contains(types, name)
+}
+
+type generator struct {
+	srcPkg string
+	// imports maps the source file's import names to paths; used collects
+	// the paths referenced by the generated code.
+	imports map[string]string
+	used    map[string]bool
+}
+
+func (g *generator) repo(name string, it *ast.InterfaceType) (repo, error) {
+	r := repo{Name: name, Type: g.srcPkg + "." + name}
+	for _, m := range it.Methods.List {
+		ft, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return repo{}, fmt.Errorf("%s: embedded interfaces are not supported", name)
+		}
+		meth, err := g.method(m.Names[0].Name, ft)
+		if err != nil {
+			return repo{}, fmt.Errorf("%s.%s: %w", name, m.Names[0].Name, err)
+		}
+		r.Methods = append(r.Methods, meth)
+	}
+	return r, nil
+}
+
+func (g *generator) method(name string, ft *ast.FuncType) (method, error) {
+	m := method{Name: name}
+	var params, args []string
+	i := 0
+	for _, f := range ft.Params.List {
+		typ, err := g.typeString(f.Type)
+		if err != nil {
+			return m, err
+		}
+		for n := max(len(f.Names), 1); n > 0; n-- {
+			p := fmt.Sprintf("p%d", i)
+			if i == 0 && typ == "context.Context" {
+				p, m.Ctx = "ctx", "ctx"
+			}
+			params = append(params, p+" "+typ)
+			if _, variadic := f.Type.(*ast.Ellipsis); variadic {
+				p += "..."
+			}
+			args = append(args, p)
+			i++
+		}
+	}
+
+	var results []string
+	if ft.Results != nil {
+		i = 0
+		for _, f := range ft.Results.List {
+			typ, err := g.typeString(f.Type)
+			if err != nil {
+				return m, err
+			}
+			for n := max(len(f.Names), 1); n > 0; n-- {
+				results = append(results, fmt.Sprintf("r%d %s", i, typ))
+				i++
+			}
+		}
+		if last := len(results) - 1; last >= 0 && strings.HasSuffix(results[last], " error") {
+			results[last] = "err error"
+			m.Err = "err"
+		}
+	}
+	m.Params = strings.Join(params, ", ")
+	m.Args = strings.Join(args, ", ")
+	if len(results) > 0 {
+		m.Results = "(" + strings.Join(results, ", ") + ")"
+	}
+	return m, nil
+}
+
+// typeString prints expr as seen from the generated package: identifiers
+// declared in the source package are qualified with its name.
+func (g *generator) typeString(expr ast.Expr) (string, error) {
+	var err error
+	expr = g.qualify(expr, &err)
+	if err != nil {
+		return "", err
+	}
+	return types.ExprString(expr), nil
+}
+
+func (g *generator) qualify(expr ast.Expr, err *error) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if !e.IsExported() {
+			if !builtin[e.Name] {
+				*err = fmt.Errorf("unexported type %s", e.Name)
+			}
+			return e
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(g.srcPkg), Sel: ast.NewIdent(e.Name)}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			p, ok := g.imports[x.Name]
+			if !ok {
+				*err = fmt.Errorf("unknown package %s", x.Name)
+				return e
+			}
+			g.used[p] = true
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.qualify(e.X, err)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: g.qualify(e.Elt, err)}
+	case *ast.MapType:
+		return &ast.MapType{Key: g.qualify(e.Key, err), Value: g.qualify(e.Value, err)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: g.qualify(e.Value, err)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.qualify(e.Elt, err)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.qualify(e.X, err), Index: g.qualify(e.Index, err)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(e.Indices))
+		for i, ix := range e.Indices {
+			indices[i] = g.qualify(ix, err)
+		}
+		return &ast.IndexListExpr{X: g.qualify(e.X, err), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.qualifyFields(e.Params, err), Results: g.qualifyFields(e.Results, err)}
+	case *ast.InterfaceType:
+		if len(e.Methods.List) > 0 {
+			*err = fmt.Errorf("inline interface types are not supported")
+		}
+		return e
+	default:
+		*err = fmt.Errorf("unsupported type %T", expr)
+		return expr
+	}
+}
+
+func (g *generator) qualifyFields(fl *ast.FieldList, err *error) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	out := &ast.FieldList{}
+	for _, f := range fl.List {
+		out.List = append(out.List, &ast.Field{Names: f.Names, Type: g.qualify(f.Type, err)})
+	}
+	return out
+}
+
+var builtin = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+var tmpl = template.Must(template.New("repogen").Parse(`// Code generated by repogen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Std}}
+	{{.}}
+{{- end}}
+{{if .Std}}
+{{end}}
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{range $r := .Repos}}
+type instrumented{{$r.Name}} struct {
+	next {{$r.Type}}
+	rec  *instrument.Recorder
+}
+
+// NewInstrumented{{$r.Name}} wraps next with spans and metrics named after
+// {{$r.Name}} and the called method.
+func NewInstrumented{{$r.Name}}(next {{$r.Type}}, rec *instrument.Recorder) {{$r.Type}} {
+	return &instrumented{{$r.Name}}{next: next, rec: rec}
+}
+{{range $r.Methods}}
+func (r *instrumented{{$r.Name}}) {{.Name}}({{.Params}}) {{.Results}} {
+{{- if .Ctx}}
+	ctx, call := r.rec.Start(ctx, "{{$r.Name}}", "{{.Name}}")
+	defer func() { call.End({{if .Err}}{{.Err}}{{else}}nil{{end}}) }()
+{{- end}}
+	{{if .Results}}return {{end}}r.next.{{.Name}}({{.Args}})
+}
+{{end}}
+{{- end}}
+`))
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `package biz
+
+import (
+	"context"
+	"time"
+)
+
+type User struct{}
+
+type UserRepo interface {
+	Save(context.Context, *User) (*User, error)
+	Touch(ctx context.Context, ids ...int64) error
+	Since(ctx context.Context, t time.Time) (map[string][]*User, int, error)
+	Name(id int64) string
+}
+
+type Clock interface {
+	Now() time.Time
+}
+`
+
+func TestGenerate(t *testing.T) {
+	code, err := Generate(Config{
+		Source:     []byte(source),
+		SourceName: "user.go",
+		ImportPath: "example.com/app/internal/biz",
+		Package:    "data",
+	})
+	require.NoError(t, err)
+	out := string(code)
+
+	assert.Contains(t, out, "package data")
+	assert.Contains(t, out, `"time"`)
+	assert.Contains(t, out, `"example.com/app/internal/biz"`)
+	assert.Contains(t, out, "func NewInstrumentedUserRepo(next biz.UserRepo, rec *instrument.Recorder) biz.UserRepo")
+	assert.Contains(t, out, "func (r *instrumentedUserRepo) Save(ctx context.Context, p1 *biz.User) (r0 *biz.User, err error) {\n"+
+		"\tctx, call := r.rec.Start(ctx, \"UserRepo\", \"Save\")\n"+
+		"\tdefer func() { call.End(err) }()\n"+
+		"\treturn r.next.Save(ctx, p1)\n}")
+	assert.Contains(t, out, "Touch(ctx context.Context, p1 ...int64) (err error)")
+	assert.Contains(t, out, "r.next.Touch(ctx, p1...)")
+	assert.Contains(t, out, "Since(ctx context.Context, p1 time.Time) (r0 map[string][]*biz.User, r1 int, err error)")
+	// Methods without a context are passed through.
+	assert.Contains(t, out, "func (r *instrumentedUserRepo) Name(p0 int64) (r0 string) {\n\treturn r.next.Name(p0)\n}")
+	assert.NotContains(t, out, "Clock")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate(Config{Source: []byte("package biz\n\ntype Clock interface{ Now() int }\n"), SourceName: "c.go", Package: "data"})
+	assert.ErrorContains(t, err, "no repository interfaces")
+
+	_, err = Generate(Config{Source: []byte("package biz\n\ntype userRepo interface{}\ntype UserRepo interface{ Get() user }\n"), SourceName: "u.go", Package: "data"})
+	assert.ErrorContains(t, err, "unexported type user")
+}
@@ -0,0 +1,110 @@
+// Command repogen generates decorators wrapping repository interfaces with
+// spans and metrics (see pkg/instrument), so data-layer observability does
+// not depend on hand-written instrumentation.
+//
+// For every interface named *Repo in -source it writes
+//
+//	type instrumentedGreeterRepo struct{ ... }
+//	func NewInstrumentedGreeterRepo(next biz.GreeterRepo, rec *instrument.Recorder) biz.GreeterRepo
+//
+// whose methods taking a context.Context record a span and metrics named
+// after the repo and method. Use it from a go:generate directive in the
+// package holding the implementations:
+//
+//	//go:generate go run ../../cmd/repogen -source=../biz/greeter.go -destination=greeter_instrumented.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	flagSource      string
+	flagDestination string
+	flagPackage     string
+	flagTypes       string
+)
+
+func main() {
+	flag.StringVar(&flagSource, "source", "", "Go file declaring the repository interfaces")
+	flag.StringVar(&flagDestination, "destination", "", "output file (default stdout)")
+	flag.StringVar(&flagPackage, "package", "", "output package name (default: the package of the destination directory)")
+	flag.StringVar(&flagTypes, "types", "", "comma-separated interfaces to wrap (default: all interfaces named *Repo)")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "repogen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if flagSource == "" {
+		return fmt.Errorf("-source is required")
+	}
+	src, err := os.ReadFile(flagSource)
+	if err != nil {
+		return err
+	}
+	importPath, err := packagePath(filepath.Dir(flagSource))
+	if err != nil {
+		return fmt.Errorf("resolve import path of %s: %w", flagSource, err)
+	}
+	pkg := flagPackage
+	if pkg == "" {
+		out := "."
+		if flagDestination != "" {
+			out = filepath.Dir(flagDestination)
+		}
+		if pkg, err = packageName(out); err != nil {
+			return fmt.Errorf("resolve package of %s: %w", out, err)
+		}
+	}
+	var types []string
+	if flagTypes != "" {
+		types = strings.Split(flagTypes, ",")
+	}
+
+	code, err := Generate(Config{
+		Source:     src,
+		SourceName: filepath.Base(flagSource),
+		ImportPath: importPath,
+		Package:    pkg,
+		Types:      types,
+	})
+	if err != nil {
+		return err
+	}
+	if flagDestination == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(flagDestination, code, 0o644)
+}
+
+func packagePath(dir string) (string, error) {
+	return goList(dir, "{{.ImportPath}}")
+}
+
+func packageName(dir string) (string, error) {
+	return goList(dir, "{{.Name}}")
+}
+
+func goList(dir, format string) (string, error) {
+	cmd := exec.Command("go", "list", "-e", "-f", format, ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return "", fmt.Errorf("no Go package in %s", dir)
+	}
+	return s, nil
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Config describes one generation run.
+type Config struct {
+	// Module is the module path of the generated Go SDK.
+	Module string
+	// Package is the name of the generated Go package. Empty derives it
+	// from the last element of Module.
+	Package string
+	// Files are the proto files whose services the SDK calls.
+	Files []protoreflect.FileDescriptor
+	// TypeScript also generates client.ts, calling the HTTP routes of the
+	// services with fetch.
+	TypeScript bool
+}
+
+type service struct {
+	Name     string // Greeter
+	FullName string // helloworld.v1.Greeter
+	Alias    string // import alias of the Go package, e.g. helloworldv1
+	Methods  []rpc
+}
+
+type rpc struct {
+	Name       string // SayHello
+	FullMethod string // /helloworld.v1.Greeter/SayHello
+	Idempotent bool
+	Streaming  bool
+	Input      string // TypeScript type names
+	Output     string
+	HTTP       *httpRoute // nil without a google.api.http rule
+}
+
+type httpRoute struct {
+	Method string
+	// Path is a TypeScript template literal body, e.g.
+	// /helloworld/${encodeURIComponent(String(req.name ?? ""))}.
+	Path string
+	// PathFields are the JSON names of the top-level fields bound by Path.
+	PathFields []string
+	// Body is "" (query parameters), "*" (the request) or the JSON name of
+	// the field sent as body.
+	Body string
+}
+
+type goImport struct {
+	Alias string
+	Path  string
+}
+
+// Generate returns the SDK files by name: go.mod and client.go, plus
+// client.ts when c.TypeScript is set.
+func Generate(c Config) (map[string][]byte, error) {
+	if c.Module == "" {
+		return nil, fmt.Errorf("module path is required")
+	}
+	pkg := c.Package
+	if pkg == "" {
+		pkg = packageName(c.Module)
+	}
+	if !isIdent(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+
+	var (
+		services []service
+		imports  []goImport
+	)
+	for _, fd := range c.Files {
+		if fd.Services().Len() == 0 {
+			continue
+		}
+		importPath, _, _ := strings.Cut(fd.Options().(*descriptorpb.FileOptions).GetGoPackage(), ";")
+		if importPath == "" {
+			return nil, fmt.Errorf("%s: no go_package option", fd.Path())
+		}
+		alias := importAlias(importPath)
+		if !slices.Contains(imports, goImport{alias, importPath}) {
+			imports = append(imports, goImport{alias, importPath})
+		}
+		for i := range fd.Services().Len() {
+			sd := fd.Services().Get(i)
+			if j := slices.IndexFunc(services, func(s service) bool { return s.Name == string(sd.Name()) }); j >= 0 {
+				return nil, fmt.Errorf("services %s and %s have the same name, generate them into separate SDKs", services[j].FullName, sd.FullName())
+			}
+			s := service{Name: string(sd.Name()), FullName: string(sd.FullName()), Alias: alias}
+			for k := range sd.Methods().Len() {
+				m, err := newRPC(sd.Methods().Get(k))
+				if err != nil {
+					return nil, err
+				}
+				s.Methods = append(s.Methods, m)
+			}
+			services = append(services, s)
+		}
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no services found")
+	}
+
+	var buf bytes.Buffer
+	data := map[string]any{"Package": pkg, "Imports": imports, "Services": services}
+	if err := goTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format client.go: %w\n%s", err, buf.Bytes())
+	}
+	files := map[string][]byte{
+		"go.mod":    fmt.Appendf(nil, "module %s\n\ngo 1.24\n", c.Module),
+		"client.go": code,
+	}
+	if c.TypeScript {
+		ts, err := generateTypeScript(c.Files, services)
+		if err != nil {
+			return nil, err
+		}
+		files["client.ts"] = ts
+	}
+	return files, nil
+}
+
+func newRPC(md protoreflect.MethodDescriptor) (rpc, error) {
+	m := rpc{
+		Name:       string(md.Name()),
+		FullMethod: fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()),
+		Streaming:  md.IsStreamingClient() || md.IsStreamingServer(),
+	}
+	opts, _ := md.Options().(*descriptorpb.MethodOptions)
+	switch opts.GetIdempotencyLevel() {
+	case descriptorpb.MethodOptions_NO_SIDE_EFFECTS, descriptorpb.MethodOptions_IDEMPOTENT:
+		m.Idempotent = true
+	}
+	rule, _ := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil || m.Streaming || rule.GetResponseBody() != "" {
+		return m, nil
+	}
+	var route httpRoute
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		route.Method, route.Path = "GET", p.Get
+		m.Idempotent = true
+	case *annotations.HttpRule_Put:
+		route.Method, route.Path = "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		route.Method, route.Path = "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		route.Method, route.Path = "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		route.Method, route.Path = "PATCH", p.Patch
+	default:
+		return m, nil
+	}
+	var err error
+	if route.Path, route.PathFields, err = tsPath(md.Input(), route.Path); err != nil {
+		return rpc{}, fmt.Errorf("%s: %w", m.FullMethod, err)
+	}
+	switch body := rule.GetBody(); body {
+	case "", "*":
+		route.Body = body
+	default:
+		f := md.Input().Fields().ByName(protoreflect.Name(body))
+		if f == nil {
+			return rpc{}, fmt.Errorf("%s: no body field %s", m.FullMethod, body)
+		}
+		route.Body = f.JSONName()
+	}
+	m.HTTP = &route
+	return m, nil
+}
+
+// tsPath converts a google.api.http path template into a TypeScript
+// template literal reading the bound fields of req.
+func tsPath(input protoreflect.MessageDescriptor, tmpl string) (string, []string, error) {
+	var (
+		b      strings.Builder
+		fields []string
+	)
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			b.WriteString(tmpl)
+			return b.String(), fields, nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated variable in path %s", tmpl)
+		}
+		b.WriteString(tmpl[:start])
+		name, pattern, _ := strings.Cut(tmpl[start+1:start+end], "=")
+		expr := "req"
+		md := input
+		for i, part := range strings.Split(name, ".") {
+			if md == nil {
+				return "", nil, fmt.Errorf("path variable %s: %s is not a message", name, part)
+			}
+			f := md.Fields().ByName(protoreflect.Name(part))
+			if f == nil {
+				return "", nil, fmt.Errorf("path variable %s: no field %s in %s", name, part, md.FullName())
+			}
+			if i == 0 {
+				fields = append(fields, f.JSONName())
+				expr += "." + f.JSONName()
+			} else {
+				expr += "?." + f.JSONName()
+			}
+			md = f.Message()
+		}
+		// Patterns such as {name=shelves/*} bind values holding slashes.
+		encode := "encodeURIComponent"
+		if pattern != "" && pattern != "*" {
+			encode = "encodeURI"
+		}
+		fmt.Fprintf(&b, `${%s(String(%s ?? ""))}`, encode, expr)
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+// importAlias names a Go package after its last two path elements, e.g.
+// helloworldv1 for .../api/helloworld/v1.
+func importAlias(importPath string) string {
+	dir, base := path.Split(importPath)
+	return sanitize(path.Base(dir) + base)
+}
+
+// packageName derives a package name from a module path, skipping a major
+// version suffix, e.g. greetersdk for example.com/greeter-sdk/v2.
+func packageName(module string) string {
+	base := path.Base(module)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(module))
+	}
+	return sanitize(base)
+}
+
+func sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	if s != "" && unicode.IsDigit(rune(s[0])) {
+		s = "v" + s
+	}
+	return s
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+var goTemplate = template.Must(template.New("client.go").Parse(`// Code generated by sdkgen. DO NOT EDIT.
+
+// Package {{.Package}} is the client SDK of{{range $i, $s := .Services}}{{if $i}},{{end}} {{$s.FullName}}{{end}}.
+//
+//	c, err := {{.Package}}.Dial("dns:///service.example.com:9000", {{.Package}}.WithToken(token))
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+// Failed calls return *Error, decoded from the kratos error model, so
+// errors.Reason, errors.Code and the IsXxx helpers generated from the error
+// enums work on them. Idempotent calls, GET routes and methods with an
+// idempotency_level, are retried when the service is unavailable or asks to
+// back off. Run go mod tidy after generating.
+package {{.Package}}
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+{{range .Imports}}
+	{{.Alias}} "{{.Path}}"
+{{- end}}
+)
+
+// Client holds a connection and the clients of its services.
+type Client struct {
+	conn *grpc.ClientConn
+{{range .Services}}
+	{{.Name}} {{.Alias}}.{{.Name}}Client
+{{- end}}
+}
+
+// idempotent lists the methods that are safe to retry.
+var idempotent = map[string]bool{
+{{- range .Services}}{{range .Methods}}{{if .Idempotent}}
+	"{{.FullMethod}}": true,
+{{- end}}{{end}}{{end}}
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	token    func(context.Context) (string, error)
+	headers  map[string]string
+	retries  int
+	backoff  time.Duration
+	timeout  time.Duration
+	creds    credentials.TransportCredentials
+	dialOpts []grpc.DialOption
+}
+
+// WithToken sends token as "authorization: Bearer <token>" on every call.
+func WithToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) { return token, nil })
+}
+
+// WithTokenSource sends the token returned by fn on every call, for tokens
+// that expire. An error of fn fails the call.
+func WithTokenSource(fn func(context.Context) (string, error)) Option {
+	return func(o *options) { o.token = fn }
+}
+
+// WithHeader sends the metadata key on every call, e.g. x-md-tenant.
+func WithHeader(key, value string) Option {
+	return func(o *options) { o.headers[key] = value }
+}
+
+// WithRetry retries idempotent calls up to retries times, waiting backoff,
+// doubled on every retry, or the delay the service asked for. Defaults to
+// 3 retries from 100ms; 0 disables retries.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(o *options) { o.retries, o.backoff = retries, backoff }
+}
+
+// WithTimeout bounds calls whose context has no deadline.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithTLS connects with TLS. Without it the connection is plaintext.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) { o.creds = credentials.NewTLS(cfg) }
+}
+
+// WithDialOptions adds gRPC dial options, e.g. interceptors or a resolver.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOpts = append(o.dialOpts, opts...) }
+}
+
+// Dial creates a client of the services at target, e.g.
+// dns:///service.example.com:9000. The connection is established lazily.
+func Dial(target string, opts ...Option) (*Client, error) {
+	o := &options{
+		headers: make(map[string]string),
+		retries: 3,
+		backoff: 100 * time.Millisecond,
+		creds:   insecure.NewCredentials(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithChainUnaryInterceptor(o.unary),
+		grpc.WithChainStreamInterceptor(o.stream),
+	}, o.dialOpts...)
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn: conn,
+{{- range .Services}}
+		{{.Name}}: {{.Alias}}.New{{.Name}}Client(conn),
+{{- end}}
+	}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// outgoing adds the token and headers to the outgoing metadata of ctx.
+func (o *options) outgoing(ctx context.Context) (context.Context, error) {
+	kv := make([]string, 0, 2*len(o.headers)+2)
+	for k, v := range o.headers {
+		kv = append(kv, k, v)
+	}
+	if o.token != nil {
+		token, err := o.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kv = append(kv, "authorization", "Bearer "+token)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...), nil
+}
+
+func (o *options) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, err := o.outgoing(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok && o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	attempts := 1
+	if idempotent[method] {
+		attempts += o.retries
+	}
+	for i := 0; ; i++ {
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || i+1 >= attempts || !retryable(err) {
+			return decode(err)
+		}
+		delay := o.backoff << i
+		if d, ok := retryDelay(err); ok {
+			delay = d
+		}
+		select {
+		case <-ctx.Done():
+			return decode(err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (o *options) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, err := o.outgoing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, decode(err)
+	}
+	return clientStream{s}, nil
+}
+
+// clientStream decodes the errors of a stream.
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		return err
+	}
+	return decode(err)
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns the delay of the google.rpc.RetryInfo detail of err.
+func retryDelay(err error) (time.Duration, bool) {
+	for _, d := range status.Convert(err).Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+			return ri.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// Error is a failed call: the kratos error, with its code, reason, message
+// and metadata, and the google.rpc details of the status, e.g. field
+// violations or quota failures.
+type Error struct {
+	err *errors.Error
+	st  *status.Status
+}
+
+func decode(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &Error{err: errors.FromError(err), st: st}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.err.Error() }
+
+// Unwrap returns the kratos error.
+func (e *Error) Unwrap() error { return e.err }
+
+// GRPCStatus returns the status of the call.
+func (e *Error) GRPCStatus() *status.Status { return e.st }
+
+// Details returns the google.rpc details of the status.
+func (e *Error) Details() []proto.Message {
+	var details []proto.Message
+	for _, d := range e.st.Details() {
+		if m, ok := d.(proto.Message); ok {
+			details = append(details, m)
+		}
+	}
+	return details
+}
+
+// RetryDelay returns the delay the service asked to wait before retrying.
+func (e *Error) RetryDelay() (time.Duration, bool) {
+	return retryDelay(e)
+}
+`))
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+func TestGenerate(t *testing.T) {
+	files := []protoreflect.FileDescriptor{v1.File_helloworld_v1_error_reason_proto, v1.File_helloworld_v1_greeter_proto}
+	out, err := Generate(Config{Module: "github.com/acme/greeter-sdk/v2", Files: files, TypeScript: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, "module github.com/acme/greeter-sdk/v2\n\ngo 1.24\n", string(out["go.mod"]))
+	code := string(out["client.go"])
+	assert.Contains(t, code, "package greetersdk")
+	assert.Contains(t, code, `helloworldv1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"`)
+	assert.Contains(t, code, "Greeter helloworldv1.GreeterClient")
+	assert.Contains(t, code, "Greeter: helloworldv1.NewGreeterClient(conn),")
+	assert.Contains(t, code, `"/helloworld.v1.Greeter/SayHello": true,`, "GET routes are retried")
+
+	ts := string(out["client.ts"])
+	assert.Contains(t, ts, `export type ErrorReason = "GREETER_UNSPECIFIED" | "USER_NOT_FOUND";`)
+	assert.Contains(t, ts, "export interface HelloRequest {\n  name?: string;\n}")
+	assert.Contains(t, ts, "export class GreeterClient {")
+	assert.Contains(t, ts, "sayHello(req: HelloRequest): Promise<HelloReply> {\n"+
+		"    return call(this.opts, \"GET\", `/helloworld/${encodeURIComponent(String(req.name ?? \"\"))}` + query(req, [\"name\"]), true);\n  }")
+}
+
+// shelfFile builds a library API exercising routes with bodies, nested
+// messages, maps and well-known types.
+func shelfFile(t *testing.T) protoreflect.FileDescriptor {
+	route := func(rule *annotations.HttpRule, level descriptorpb.MethodOptions_IdempotencyLevel) *descriptorpb.MethodOptions {
+		opts := &descriptorpb.MethodOptions{IdempotencyLevel: level.Enum()}
+		if rule != nil {
+			proto.SetExtension(opts, annotations.E_Http, rule)
+		}
+		return opts
+	}
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		str = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		i64 = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		msg = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		enm = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("library/v1/shelf.proto"),
+		Package:    proto.String("library.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/empty.proto", "google/api/annotations.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/app/api/library/v1;v1")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name:  proto.String("Genre"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{{Name: proto.String("GENRE_UNSPECIFIED"), Number: proto.Int32(0)}, {Name: proto.String("FICTION"), Number: proto.Int32(1)}},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Shelf"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, "", false),
+					field("book_count", 2, i64, "", false),
+					field("genres", 3, enm, ".library.v1.Genre", true),
+					field("create_time", 4, msg, ".google.protobuf.Timestamp", false),
+					field("labels", 5, msg, ".library.v1.Shelf.LabelsEntry", true),
+					field("location", 6, msg, ".library.v1.Shelf.Location", false),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name:    proto.String("LabelsEntry"),
+						Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, str, "", false), field("value", 2, str, "", false)},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+					{Name: proto.String("Location"), Field: []*descriptorpb.FieldDescriptorProto{field("room", 1, str, "", false)}},
+				},
+			},
+			{Name: proto.String("UpdateShelfRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("shelf", 1, msg, ".library.v1.Shelf", false)}},
+			{Name: proto.String("DeleteShelfRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, str, "", false), field("force", 2, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", false)}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Library"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name: proto.String("UpdateShelf"), InputType: proto.String(".library.v1.UpdateShelfRequest"), OutputType: proto.String(".library.v1.Shelf"),
+					Options: route(&annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{shelf.name=shelves/*}"}, Body: "shelf"}, descriptorpb.MethodOptions_IDEMPOTENT),
+				},
+				{
+					Name: proto.String("CreateShelf"), InputType: proto.String(".library.v1.Shelf"), OutputType: proto.String(".library.v1.Shelf"),
+					Options: route(&annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/shelves"}, Body: "*"}, descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN),
+				},
+				{
+					Name: proto.String("DeleteShelf"), InputType: proto.String(".library.v1.DeleteShelfRequest"), OutputType: proto.String(".google.protobuf.Empty"),
+					Options: route(&annotations.HttpRule{Pattern: &annotations.HttpRule_Delete{Delete: "/v1/{name}"}}, descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN),
+				},
+				{
+					Name: proto.String("WatchShelf"), InputType: proto.String(".library.v1.Shelf"), OutputType: proto.String(".library.v1.Shelf"),
+					ServerStreaming: proto.Bool(true), Options: route(nil, descriptorpb.MethodOptions_NO_SIDE_EFFECTS),
+				},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd
+}
+
+func TestGenerate_Routes(t *testing.T) {
+	out, err := Generate(Config{Module: "example.com/library-sdk", Package: "library", Files: []protoreflect.FileDescriptor{shelfFile(t)}, TypeScript: true})
+	require.NoError(t, err)
+
+	code := string(out["client.go"])
+	assert.Contains(t, code, `libraryv1 "example.com/app/api/library/v1"`)
+	assert.Contains(t, code, "var idempotent = map[string]bool{\n"+
+		"\t\"/library.v1.Library/UpdateShelf\": true,\n"+
+		"\t\"/library.v1.Library/WatchShelf\":  true,\n}")
+
+	ts := string(out["client.ts"])
+	assert.Contains(t, ts, `export type Genre = "GENRE_UNSPECIFIED" | "FICTION";`)
+	assert.Contains(t, ts, "export interface Shelf {\n"+
+		"  name?: string;\n"+
+		"  bookCount?: string;\n"+
+		"  genres?: Genre[];\n"+
+		"  createTime?: string;\n"+
+		"  labels?: Record<string, string>;\n"+
+		"  location?: Shelf_Location;\n}")
+	assert.Contains(t, ts, "export interface Shelf_Location {")
+	assert.NotContains(t, ts, "LabelsEntry")
+	assert.Contains(t, ts, "updateShelf(req: UpdateShelfRequest): Promise<Shelf> {\n"+
+		"    return call(this.opts, \"PATCH\", `/v1/${encodeURI(String(req.shelf?.name ?? \"\"))}`, true, req.shelf ?? {});")
+	assert.Contains(t, ts, "return call(this.opts, \"POST\", `/v1/shelves`, false, req);")
+	assert.Contains(t, ts, "deleteShelf(req: DeleteShelfRequest): Promise<Record<string, never>> {\n"+
+		"    return call(this.opts, \"DELETE\", `/v1/${encodeURIComponent(String(req.name ?? \"\"))}` + query(req, [\"name\"]), false);")
+	assert.NotContains(t, ts, "watchShelf", "streaming methods have no route")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate(Config{Files: []protoreflect.FileDescriptor{v1.File_helloworld_v1_greeter_proto}})
+	assert.ErrorContains(t, err, "module path is required")
+
+	_, err = Generate(Config{Module: "example.com/sdk", Files: []protoreflect.FileDescriptor{v1.File_helloworld_v1_error_reason_proto}})
+	assert.ErrorContains(t, err, "no services found")
+
+	_, err = Generate(Config{Module: "example.com/sdk", Package: "my-sdk", Files: []protoreflect.FileDescriptor{v1.File_helloworld_v1_greeter_proto}})
+	assert.ErrorContains(t, err, `invalid package name "my-sdk"`)
+}
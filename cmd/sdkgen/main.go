@@ -0,0 +1,105 @@
+// Command sdkgen generates client SDKs of the API services for their
+// consumers, with the conventions of this layout built in: kratos error
+// decoding, bearer token and metadata injection, and retries of idempotent
+// calls honoring the service's retry delay.
+//
+// It writes a Go module wrapping the generated gRPC clients and, with -ts, a
+// TypeScript client of the HTTP routes:
+//
+//	go run ./cmd/sdkgen -module=github.com/acme/greeter-sdk -out=sdk/go -ts
+//
+// The services are read from the registered API descriptors; new API
+// packages must be blank-imported below.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Register API descriptors. Add new API packages here.
+	_ "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+// apiGoPackagePrefix selects which registered proto files belong to the API surface.
+const apiGoPackagePrefix = "github.com/go-kratos/kratos-layout/api/"
+
+var (
+	flagOut        string
+	flagModule     string
+	flagPackage    string
+	flagProtos     string
+	flagTSOut      string
+	flagTypeScript bool
+)
+
+func main() {
+	flag.StringVar(&flagOut, "out", "sdk/go", "output directory of the Go module")
+	flag.StringVar(&flagModule, "module", "", "module path of the Go SDK, e.g. github.com/acme/greeter-sdk")
+	flag.StringVar(&flagPackage, "package", "", "package name of the Go SDK (default: derived from -module)")
+	flag.StringVar(&flagProtos, "protos", "", "comma-separated proto packages to include, e.g. helloworld.v1 (default: all API packages)")
+	flag.BoolVar(&flagTypeScript, "ts", false, "also generate a TypeScript client of the HTTP routes")
+	flag.StringVar(&flagTSOut, "ts-out", "sdk/ts", "output directory of the TypeScript client")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "sdkgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if flagModule == "" {
+		return fmt.Errorf("-module is required")
+	}
+	var protos []string
+	if flagProtos != "" {
+		protos = strings.Split(flagProtos, ",")
+	}
+	files := apiFiles(protos)
+	if len(files) == 0 {
+		return fmt.Errorf("no API proto files match %q", flagProtos)
+	}
+
+	out, err := Generate(Config{Module: flagModule, Package: flagPackage, Files: files, TypeScript: flagTypeScript})
+	if err != nil {
+		return err
+	}
+	for name, data := range out {
+		dir := flagOut
+		if filepath.Ext(name) == ".ts" {
+			dir = flagTSOut
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(filepath.Join(dir, name))
+	}
+	return nil
+}
+
+// apiFiles returns the registered API proto files, of the given proto
+// packages when any, ordered by path.
+func apiFiles(protos []string) []protoreflect.FileDescriptor {
+	var files []protoreflect.FileDescriptor
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		goPackage := protodesc.ToFileDescriptorProto(fd).GetOptions().GetGoPackage()
+		if strings.HasPrefix(goPackage, apiGoPackagePrefix) && (len(protos) == 0 || slices.Contains(protos, string(fd.Package()))) {
+			files = append(files, fd)
+		}
+		return true
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Path() < files[j].Path() })
+	return files
+}
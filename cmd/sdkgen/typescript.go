@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wellKnownTypes maps the well-known types to their protojson form.
+var wellKnownTypes = map[protoreflect.FullName]string{
+	"google.protobuf.Any":         `{ "@type": string; [key: string]: unknown }`,
+	"google.protobuf.BoolValue":   "boolean",
+	"google.protobuf.BytesValue":  "string",
+	"google.protobuf.DoubleValue": "number",
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.Empty":       "Record<string, never>",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.FloatValue":  "number",
+	"google.protobuf.Int32Value":  "number",
+	"google.protobuf.Int64Value":  "string",
+	"google.protobuf.ListValue":   "unknown[]",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.Struct":      "Record<string, unknown>",
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.UInt32Value": "number",
+	"google.protobuf.UInt64Value": "string",
+	"google.protobuf.Value":       "unknown",
+}
+
+type tsField struct {
+	Name string
+	Type string
+}
+
+type tsMessage struct {
+	Name     string
+	FullName string
+	Fields   []tsField
+}
+
+type tsEnum struct {
+	Name     string
+	FullName string
+	Values   []string
+}
+
+// tsTypes collects the messages and enums of the files and the ones they
+// reference, named after their full name without the proto package.
+type tsTypes struct {
+	names    map[string]protoreflect.FullName
+	messages []tsMessage
+	enums    []tsEnum
+	err      error
+}
+
+func (t *tsTypes) name(d protoreflect.Descriptor) string {
+	name := strings.ReplaceAll(strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package())+"."), ".", "_")
+	if prev, ok := t.names[name]; ok {
+		if prev != d.FullName() && t.err == nil {
+			t.err = fmt.Errorf("types %s and %s have the same name, generate them into separate SDKs", prev, d.FullName())
+		}
+		return name
+	}
+	t.names[name] = d.FullName()
+	switch d := d.(type) {
+	case protoreflect.EnumDescriptor:
+		e := tsEnum{Name: name, FullName: string(d.FullName())}
+		for i := range d.Values().Len() {
+			e.Values = append(e.Values, fmt.Sprintf("%q", d.Values().Get(i).Name()))
+		}
+		t.enums = append(t.enums, e)
+	case protoreflect.MessageDescriptor:
+		i := len(t.messages)
+		t.messages = append(t.messages, tsMessage{Name: name, FullName: string(d.FullName())})
+		var fields []tsField
+		for j := range d.Fields().Len() {
+			f := d.Fields().Get(j)
+			fields = append(fields, tsField{Name: f.JSONName(), Type: t.fieldType(f)})
+		}
+		t.messages[i].Fields = fields
+	}
+	return name
+}
+
+func (t *tsTypes) fieldType(f protoreflect.FieldDescriptor) string {
+	if f.IsMap() {
+		return fmt.Sprintf("Record<string, %s>", t.singularType(f.MapValue()))
+	}
+	typ := t.singularType(f)
+	if f.IsList() {
+		if strings.ContainsAny(typ, " |") {
+			typ = "(" + typ + ")"
+		}
+		return typ + "[]"
+	}
+	return typ
+}
+
+func (t *tsTypes) singularType(f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "string"
+	// protojson writes 64-bit integers as strings.
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "string"
+	case protoreflect.EnumKind:
+		if f.Enum().FullName() == "google.protobuf.NullValue" {
+			return "null"
+		}
+		return t.message(f.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return t.message(f.Message())
+	default:
+		return "number"
+	}
+}
+
+func (t *tsTypes) message(d protoreflect.Descriptor) string {
+	if wkt, ok := wellKnownTypes[d.FullName()]; ok {
+		return wkt
+	}
+	return t.name(d)
+}
+
+func (t *tsTypes) addFile(fd protoreflect.FileDescriptor) {
+	var walk func(protoreflect.MessageDescriptors, protoreflect.EnumDescriptors)
+	walk = func(msgs protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors) {
+		for i := range enums.Len() {
+			t.name(enums.Get(i))
+		}
+		for i := range msgs.Len() {
+			if md := msgs.Get(i); !md.IsMapEntry() {
+				t.name(md)
+				walk(md.Messages(), md.Enums())
+			}
+		}
+	}
+	walk(fd.Messages(), fd.Enums())
+}
+
+// generateTypeScript returns a fetch client of the HTTP routes of services.
+// Methods without a google.api.http rule, and streaming ones, are skipped.
+func generateTypeScript(files []protoreflect.FileDescriptor, services []service) ([]byte, error) {
+	t := &tsTypes{names: make(map[string]protoreflect.FullName)}
+	for _, fd := range files {
+		t.addFile(fd)
+	}
+	for i, s := range services {
+		for j, m := range s.Methods {
+			if m.HTTP == nil {
+				continue
+			}
+			sd := findService(files, s.FullName)
+			md := sd.Methods().ByName(protoreflect.Name(m.Name))
+			services[i].Methods[j].Input = t.message(md.Input())
+			services[i].Methods[j].Output = t.message(md.Output())
+		}
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	var buf bytes.Buffer
+	err := tsTemplate.Execute(&buf, map[string]any{"Enums": t.enums, "Messages": t.messages, "Services": services})
+	return buf.Bytes(), err
+}
+
+func findService(files []protoreflect.FileDescriptor, name string) protoreflect.ServiceDescriptor {
+	for _, fd := range files {
+		for i := range fd.Services().Len() {
+			if sd := fd.Services().Get(i); string(sd.FullName()) == name {
+				return sd
+			}
+		}
+	}
+	return nil
+}
+
+var tsTemplate = template.Must(template.New("client.ts").Funcs(template.FuncMap{
+	"lowerFirst": func(s string) string { return strings.ToLower(s[:1]) + s[1:] },
+	"quoteAll": func(ss []string) string {
+		q := make([]string, len(ss))
+		for i, s := range ss {
+			q[i] = fmt.Sprintf("%q", s)
+		}
+		return strings.Join(q, ", ")
+	},
+}).Parse(`// Code generated by sdkgen. DO NOT EDIT.
+
+/** Options of the service clients. */
+export interface ClientOptions {
+  /** Base URL of the HTTP server, e.g. https://api.example.com. */
+  baseUrl: string;
+  /** Bearer token sent as the Authorization header, or a function returning it. */
+  token?: string | (() => string | Promise<string>);
+  /** Headers sent on every call, e.g. x-md-tenant. */
+  headers?: Record<string, string>;
+  /** Retries of idempotent calls answered with 429, 502, 503 or 504 (default 3). */
+  retries?: number;
+  /** Delay before the first retry in milliseconds, doubled on every retry (default 100). */
+  backoffMs?: number;
+  /** fetch implementation, for runtimes without a global one. */
+  fetch?: typeof fetch;
+}
+
+/** ApiError is a failed call, decoded from the kratos error model. */
+export class ApiError extends Error {
+  constructor(
+    readonly code: number,
+    readonly reason: string,
+    message: string,
+    readonly metadata: Record<string, string> = {},
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+const retryStatus = [429, 502, 503, 504];
+
+function sleep(ms: number): Promise<void> {
+  return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+/** query encodes the scalar fields of req, except the path ones. */
+function query(req: object, exclude: string[]): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(req)) {
+    if (exclude.includes(key) || value === undefined || value === null) continue;
+    if (typeof value === "object" && !Array.isArray(value)) continue;
+    for (const v of Array.isArray(value) ? value : [value]) params.append(key, String(v));
+  }
+  const s = params.toString();
+  return s ? "?" + s : "";
+}
+
+async function call<T>(opts: ClientOptions, method: string, path: string, idempotent: boolean, body?: unknown): Promise<T> {
+  const doFetch = opts.fetch ?? fetch;
+  const headers: Record<string, string> = { Accept: "application/json", ...opts.headers };
+  if (body !== undefined) headers["Content-Type"] = "application/json";
+  if (opts.token) {
+    headers["Authorization"] = "Bearer " + (typeof opts.token === "string" ? opts.token : await opts.token());
+  }
+  const attempts = 1 + (idempotent ? opts.retries ?? 3 : 0);
+  for (let i = 0; ; i++) {
+    const res = await doFetch(opts.baseUrl.replace(/\/$/, "") + path, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (res.ok) return (await res.json()) as T;
+    if (i + 1 < attempts && retryStatus.includes(res.status)) {
+      const after = Number(res.headers.get("Retry-After"));
+      await sleep(after > 0 ? after * 1000 : (opts.backoffMs ?? 100) * 2 ** i);
+      continue;
+    }
+    const e = await res.json().catch(() => ({}));
+    throw new ApiError(e.code ?? res.status, e.reason ?? "", e.message ?? res.statusText, e.metadata ?? {});
+  }
+}
+{{range .Enums}}
+/** {{.FullName}} */
+export type {{.Name}} = {{range $i, $v := .Values}}{{if $i}} | {{end}}{{$v}}{{end}};
+{{end}}
+{{- range .Messages}}
+/** {{.FullName}} */
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}?: {{.Type}};
+{{- end}}
+}
+{{end}}
+{{- range .Services}}
+/** Client of {{.FullName}}. */
+export class {{.Name}}Client {
+  constructor(private readonly opts: ClientOptions) {}
+{{range .Methods}}{{if .HTTP}}
+  /** Calls {{.FullMethod}}. */
+  {{lowerFirst .Name}}(req: {{.Input}}): Promise<{{.Output}}> {
+    return call(this.opts, "{{.HTTP.Method}}", ` + "`{{.HTTP.Path}}`" + `
+{{- if eq .HTTP.Body ""}} + query(req, [{{quoteAll .HTTP.PathFields}}]), {{.Idempotent}});
+{{- else if eq .HTTP.Body "*"}}, {{.Idempotent}}, req);
+{{- else}}, {{.Idempotent}}, req.{{.HTTP.Body}} ?? {});
+{{- end}}
+  }
+{{end}}{{end -}}
+}
+{{end -}}
+`))
@@ -0,0 +1,21 @@
+//go:build !noapollo
+
+package main
+
+import (
+	"github.com/go-kratos/kratos/contrib/config/apollo/v2"
+	"github.com/go-kratos/kratos/v2/config"
+
+	"github.com/go-kratos/kratos-layout/pkg/env"
+)
+
+// remoteConfigSource returns the Apollo source used when no config file is given.
+func remoteConfigSource() (config.Source, error) {
+	return apollo.NewSource(
+		apollo.WithAppID(env.GetOrDefault("APOLLO_APP_ID", Name)),
+		apollo.WithCluster(env.GetOrDefault("APOLLO_CLUSTER", "dev")),
+		apollo.WithEndpoint(env.GetOrDefault("APOLLO_ENDPOINT", "http://localhost:8080")),
+		apollo.WithNamespace(env.GetOrDefault("APOLLO_NAMESPACE", "application,bootstrap.yaml")),
+		apollo.WithSecret(env.GetOrDefault("APOLLO_SECRET", "fc4cacadc4cb486b91419d67f6d7918b")),
+	), nil
+}
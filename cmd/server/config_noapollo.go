@@ -0,0 +1,14 @@
+//go:build noapollo
+
+package main
+
+import (
+	"errors"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+// remoteConfigSource fails: the binary was built without Apollo.
+func remoteConfigSource() (config.Source, error) {
+	return nil, errors.New("built with noapollo: pass -conf or set CONFIG_FILE")
+}
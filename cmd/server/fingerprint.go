@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/fingerprint"
+)
+
+const (
+	defaultFingerprintPath = "data/config.fingerprint.json"
+	fingerprintTimeout     = 3 * time.Second
+)
+
+// checkConfigChange logs the configuration changes since the previous run,
+// see conf.Fingerprint. It never fails the startup.
+func checkConfigChange(bc *conf.Bootstrap, logger log.Logger) {
+	logHelper := log.NewHelper(logger)
+	store, closeStore, err := fingerprintStore(bc.GetFingerprint(), bc.GetData().GetRedis())
+	if err != nil {
+		logHelper.Warnf("config fingerprint: %v", err)
+		return
+	}
+	if store == nil {
+		return
+	}
+	defer closeStore()
+
+	snap, err := fingerprint.New(bc, Version)
+	if err != nil {
+		logHelper.Warnf("config fingerprint: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fingerprintTimeout)
+	defer cancel()
+	if _, err := fingerprint.Check(ctx, store, snap, logger); err != nil {
+		logHelper.Warnf("config fingerprint: %v", err)
+	}
+}
+
+// fingerprintStore returns the store of c, nil when it is off.
+func fingerprintStore(c *conf.Fingerprint, rc *conf.Data_Redis) (fingerprint.Store, func(), error) {
+	switch c.GetStore() {
+	case "", "file":
+		path := c.GetPath()
+		if path == "" {
+			path = defaultFingerprintPath
+		}
+		return fingerprint.NewFileStore(path), func() {}, nil
+	case "redis":
+		if rc.GetAddr() == "" {
+			return nil, nil, fmt.Errorf("redis store needs data.redis")
+		}
+		rdb := redis.NewClient(&redis.Options{
+			Addr:        rc.GetAddr(),
+			Password:    rc.GetPassword(),
+			DB:          int(rc.GetDb()),
+			DialTimeout: fingerprintTimeout,
+		})
+		key := c.GetKey()
+		if key == "" {
+			key = "config:fingerprint:" + Name
+		}
+		return fingerprint.NewRedisStore(func() redis.Cmdable { return rdb }, key), func() { _ = rdb.Close() }, nil
+	case "off":
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("unknown store %q: want file, redis or off", c.GetStore())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/env"
+)
+
+const (
+	// shutdownMargin is the part of the termination grace period left to
+	// the cleanups after the servers stopped, such as flushing producers
+	// and closing databases, before the kubelet kills the process.
+	shutdownMargin = 5 * time.Second
+	// minStopTimeout bounds the server stop of a too short grace period.
+	minStopTimeout = time.Second
+)
+
+// instanceMetadata is the metadata the instance registers with: where it
+// runs on Kubernetes.
+func instanceMetadata(d env.Deployment) map[string]string {
+	md := make(map[string]string)
+	for k, v := range map[string]string{"pod": d.Pod, "namespace": d.Namespace, "node": d.Node} {
+		if d.Kubernetes && v != "" {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+// shutdownOptions fit the stop of the app into the termination grace period
+// of the pod. SHUTDOWN_DELAY (e.g. 5s) keeps serving that long after
+// SIGTERM, until Kubernetes and the registry subscribers stopped routing
+// requests to the pod.
+func shutdownOptions(d env.Deployment, logger log.Logger) []kratos.Option {
+	logHelper := log.NewHelper(logger)
+	var opts []kratos.Option
+	delay, err := time.ParseDuration(env.GetOrDefault("SHUTDOWN_DELAY", "0s"))
+	if err != nil {
+		logHelper.Warnf("ignoring SHUTDOWN_DELAY: %v", err)
+		delay = 0
+	}
+	if delay > 0 {
+		opts = append(opts, kratos.BeforeStop(func(context.Context) error {
+			logHelper.Infof("shutting down in %s", delay)
+			time.Sleep(delay)
+			return nil
+		}))
+	}
+	if grace := d.TerminationGracePeriod(); grace > 0 {
+		opts = append(opts, kratos.StopTimeout(max(grace-delay-shutdownMargin, minStopTimeout)))
+	}
+	return opts
+}
@@ -2,10 +2,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/go-kratos/kratos/contrib/config/apollo/v2"
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/config"
 	"github.com/go-kratos/kratos/v2/config/file"
@@ -20,10 +21,17 @@ import (
 
 	"github.com/go-kratos/kratos-layout/internal/conf"
 	"github.com/go-kratos/kratos-layout/internal/job"
+	"github.com/go-kratos/kratos-layout/pkg/admin"
 	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/gctune"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
 	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/metering"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
 	"github.com/go-kratos/kratos-layout/pkg/registry"
 	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+	"github.com/go-kratos/kratos-layout/pkg/support"
 )
 
 // go build -ldflags "-X main.Version=x.y.z"
@@ -34,6 +42,8 @@ var (
 	Version string
 	// id is the service instance id.
 	id string
+	// deployment is where the instance runs.
+	deployment env.Deployment
 	// Command line flags
 	flagConf string
 )
@@ -44,11 +54,8 @@ func init() {
 		UseProtoNames:   true,
 	}
 
-	var err error
-	id, err = os.Hostname()
-	if err != nil {
-		id = "unknown"
-	}
+	deployment = env.CurrentDeployment()
+	id = deployment.InstanceID()
 
 	if Name == "" {
 		Name = env.GetOrDefault("SERVICE_NAME", "xxx-service")
@@ -59,23 +66,34 @@ func init() {
 	}
 }
 
-func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, r *nacos.Registry, jobs *job.Registry) *kratos.App {
-	servers := []transport.Server{gs, hs}
+func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, as *admin.Server, bus eventbus.Bus, ob *outbox.Outbox, meter *metering.Meter, r *nacos.Registry, jobs *job.Registry) *kratos.App {
+	servers := []transport.Server{gs, hs, bus, ob, meter}
+	servers = append(servers, as.Servers()...)
 	servers = append(servers, jobs.Servers()...)
-	return kratos.New(
+	opts := []kratos.Option{
 		kratos.ID(id),
 		kratos.Name(Name),
 		kratos.Version(Version),
-		kratos.Metadata(map[string]string{}),
+		kratos.Metadata(instanceMetadata(deployment)),
 		kratos.Logger(logger),
 		kratos.Server(servers...),
 		kratos.Registrar(r),
-	)
+	}
+	return kratos.New(append(opts, shutdownOptions(deployment, logger)...)...)
 }
 
 func main() {
 	flag.StringVar(&flagConf, "conf", "", "config file path (e.g., ./configs/config.yaml)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [self-test [-timeout 10s] [-topic selftest] | migrate [-dir scripts/sql/migration] up|down [n]|status | seed [status]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	if cmd := flag.Arg(0); cmd != "" && cmd != "self-test" && cmd != "migrate" && cmd != "seed" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	if err := run(); err != nil {
 		os.Exit(1)
@@ -83,8 +101,17 @@ func main() {
 }
 
 func run() error {
-	logger := zapLog.InitDefaultLogger(parseLogLevel())
+	// Recent logs and lifecycle events are kept in memory for support bundles.
+	logs := zapLog.NewRing(1000)
+	zl := zapLog.InitDefaultLogger(parseLogLevel(""), zapLog.InstanceFields(Name, Version, deployment))
+	logger := logs.Tee(zl)
 	logHelper := log.NewHelper(logger)
+	history := lifecycle.NewHistory(200)
+
+	if err := subscribeLifecycle(logger, history); err != nil {
+		logHelper.Errorf("failed to subscribe lifecycle events: %v", err)
+		return err
+	}
 
 	// Load configuration
 	bc, cleanup, err := loadConfig()
@@ -93,6 +120,31 @@ func run() error {
 		return err
 	}
 	defer cleanup()
+	if lvl := bc.GetLog().GetLevel(); lvl != "" {
+		zl.SetLevel(parseLogLevel(lvl))
+	}
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(bc.Data, flag.Args()[1:], logger); err != nil {
+			logHelper.Errorf("migrate failed: %v", err)
+			return err
+		}
+		return nil
+	}
+	if flag.Arg(0) == "seed" {
+		if err := runSeed(bc.Data, flag.Args()[1:], logger); err != nil {
+			logHelper.Errorf("seed failed: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	tuner := gctune.Apply(gcConfig(bc.Runtime))
+	defer tuner.Close()
+	if err := registerRuntimeMetrics(tuner); err != nil {
+		logHelper.Errorf("failed to register runtime metrics: %v", err)
+		return err
+	}
 
 	r, err := registry.NewNacosRegistryFromEnv()
 	if err != nil {
@@ -100,13 +152,51 @@ func run() error {
 		return err
 	}
 
-	app, appCleanup, err := wireApp(bc.Server, bc.Data, bc.Rocketmq, r, logger)
+	repoRecorder, err := newRepoRecorder()
+	if err != nil {
+		logHelper.Errorf("failed to create repo recorder: %v", err)
+		return err
+	}
+
+	statementMetrics, err := newStatementMetrics()
+	if err != nil {
+		logHelper.Errorf("failed to create statement metrics: %v", err)
+		return err
+	}
+
+	outboxMetrics, err := newOutboxMetrics()
+	if err != nil {
+		logHelper.Errorf("failed to create outbox metrics: %v", err)
+		return err
+	}
+
+	bundle := newSupportBundle(bc, logs, history, logger)
+	if flag.Arg(0) == "self-test" {
+		st, stCleanup, err := wireSelfTest(bc.Server, bc.Data, bc.Rocketmq, bc.Nats, bc.Region, bc.Client, bc.Alert, r, bundle, repoRecorder, statementMetrics, outboxMetrics, logger)
+		if err != nil {
+			logHelper.Errorf("failed to wire app: %v", err)
+			return err
+		}
+		defer stCleanup()
+		return st.run(flag.Args()[1:])
+	}
+
+	checkConfigChange(bc, logger)
+
+	app, appCleanup, err := wireApp(bc.Server, bc.Data, bc.Rocketmq, bc.Nats, bc.Region, bc.Client, bc.Alert, r, bundle, repoRecorder, statementMetrics, outboxMetrics, logger)
 	if err != nil {
 		logHelper.Errorf("failed to wire app: %v", err)
 		return err
 	}
 	defer appCleanup()
 
+	stopReload, err := watchReload(bc, zl, logger)
+	if err != nil {
+		logHelper.Errorf("failed to watch config reloads: %v", err)
+		return err
+	}
+	defer stopReload()
+
 	// start and wait for stop signal
 	if err := app.Run(); err != nil {
 		logHelper.Errorf("app exited with error: %v", err)
@@ -115,14 +205,38 @@ func run() error {
 	return nil
 }
 
+// subscribeLifecycle routes lifecycle events to logs, metrics and history.
+func subscribeLifecycle(logger log.Logger, history *lifecycle.History) error {
+	lifecycle.Subscribe(lifecycle.LogSubscriber(logger))
+	lifecycle.Subscribe(history.Subscriber())
+	return subscribeMetrics()
+}
+
+// gcConfig maps conf.Runtime to garbage collector settings.
+func gcConfig(c *conf.Runtime) gctune.Config {
+	return gctune.Config{
+		GOGC:        int(c.GetGogc()),
+		MemoryLimit: c.GetMemoryLimit(),
+		Ballast:     c.GetBallast(),
+	}
+}
+
+// newSupportBundle collects the runtime state served at /admin/support-bundle.
+func newSupportBundle(bc *conf.Bootstrap, logs *zapLog.Ring, history *lifecycle.History, logger log.Logger) *support.Bundle {
+	b := support.New(Name, 10*time.Second, logger)
+	b.Add("runtime.json", support.BuildInfo(support.Runtime{Service: Name, Version: Version, Instance: id, Started: time.Now()}))
+	b.Add("config.json", support.Config(bc))
+	b.Add("events.json", support.Events(history))
+	addMetricsCollector(b)
+	b.Add("logs.txt", support.Logs(logs))
+	b.Add("goroutines.txt", support.Goroutines())
+	return b
+}
+
 // loadConfig loads configuration from file or Apollo.
 // Priority: -conf flag > CONFIG_FILE env > Apollo
 func loadConfig() (*conf.Bootstrap, func(), error) {
-	confFile := flagConf
-	if confFile == "" {
-		confFile = env.GetOrDefault("CONFIG_FILE", "")
-	}
-
+	confFile := configFile()
 	var bc conf.Bootstrap
 
 	// Use file config if specified
@@ -145,17 +259,11 @@ func loadConfig() (*conf.Bootstrap, func(), error) {
 	}
 
 	// Fall back to Apollo
-	c := config.New(
-		config.WithSource(
-			apollo.NewSource(
-				apollo.WithAppID(env.GetOrDefault("APOLLO_APP_ID", Name)),
-				apollo.WithCluster(env.GetOrDefault("APOLLO_CLUSTER", "dev")),
-				apollo.WithEndpoint(env.GetOrDefault("APOLLO_ENDPOINT", "http://localhost:8080")),
-				apollo.WithNamespace(env.GetOrDefault("APOLLO_NAMESPACE", "application,bootstrap.yaml")),
-				apollo.WithSecret(env.GetOrDefault("APOLLO_SECRET", "fc4cacadc4cb486b91419d67f6d7918b")),
-			),
-		),
-	)
+	source, err := remoteConfigSource()
+	if err != nil {
+		return nil, nil, err
+	}
+	c := config.New(config.WithSource(source))
 
 	if err := c.Load(); err != nil {
 		return nil, nil, err
@@ -168,10 +276,23 @@ func loadConfig() (*conf.Bootstrap, func(), error) {
 	return &bc, func() { c.Close() }, nil
 }
 
-// parseLogLevel parses the LOG_LEVEL environment variable to a zapcore.Level.
-// Defaults to InfoLevel for production safety.
-func parseLogLevel() zapcore.Level {
-	switch strings.ToLower(env.GetOrDefault("LOG_LEVEL", "info")) {
+// configFile returns the config file path, empty when config is loaded
+// from Apollo.
+func configFile() string {
+	if flagConf != "" {
+		return flagConf
+	}
+	return env.GetOrDefault("CONFIG_FILE", "")
+}
+
+// parseLogLevel parses lvl, log.level, to a zapcore.Level. When empty, the
+// LOG_LEVEL environment variable is used. Defaults to InfoLevel for
+// production safety.
+func parseLogLevel(lvl string) zapcore.Level {
+	if lvl == "" {
+		lvl = env.GetOrDefault("LOG_LEVEL", "info")
+	}
+	switch strings.ToLower(lvl) {
 	case "debug":
 		return zapcore.DebugLevel
 	case "info":
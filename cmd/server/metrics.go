@@ -0,0 +1,49 @@
+//go:build !nometrics
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-kratos/kratos-layout/pkg/gctune"
+	"github.com/go-kratos/kratos-layout/pkg/instrument"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+	"github.com/go-kratos/kratos-layout/pkg/support"
+)
+
+// subscribeMetrics records lifecycle events as Prometheus metrics.
+func subscribeMetrics() error {
+	metrics, err := lifecycle.MetricsSubscriber(prometheus.DefaultRegisterer)
+	if err != nil {
+		return err
+	}
+	lifecycle.Subscribe(metrics)
+	return nil
+}
+
+// newRepoRecorder creates the recorder of the instrumented repositories.
+func newRepoRecorder() (*instrument.Recorder, error) {
+	return instrument.NewRecorder(prometheus.DefaultRegisterer)
+}
+
+// newStatementMetrics creates the SQL statement metrics of the databases.
+func newStatementMetrics() (*orm.StatementMetrics, error) {
+	return orm.NewStatementMetrics(prometheus.DefaultRegisterer)
+}
+
+// newOutboxMetrics creates the delivery and backlog metrics of the outbox.
+func newOutboxMetrics() (*outbox.Metrics, error) {
+	return outbox.NewMetrics(prometheus.DefaultRegisterer)
+}
+
+// addMetricsCollector adds the metrics snapshot to support bundles.
+func addMetricsCollector(b *support.Bundle) {
+	b.Add("metrics.txt", support.Metrics(prometheus.DefaultGatherer))
+}
+
+// registerRuntimeMetrics exports GC pause, heap and ballast metrics.
+func registerRuntimeMetrics(t *gctune.Tuner) error {
+	return gctune.RegisterMetrics(prometheus.DefaultRegisterer, t)
+}
@@ -0,0 +1,29 @@
+//go:build nometrics
+
+package main
+
+import (
+	"github.com/go-kratos/kratos-layout/pkg/gctune"
+	"github.com/go-kratos/kratos-layout/pkg/instrument"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+	"github.com/go-kratos/kratos-layout/pkg/support"
+)
+
+// subscribeMetrics is a no-op: the binary was built without metrics.
+func subscribeMetrics() error { return nil }
+
+// newRepoRecorder returns a recorder emitting spans only.
+func newRepoRecorder() (*instrument.Recorder, error) { return instrument.NewTracingRecorder(), nil }
+
+// newStatementMetrics returns nil: statements are not timed.
+func newStatementMetrics() (*orm.StatementMetrics, error) { return nil, nil }
+
+// newOutboxMetrics returns nil: the outbox is not measured.
+func newOutboxMetrics() (*outbox.Metrics, error) { return nil, nil }
+
+// addMetricsCollector is a no-op: the binary was built without metrics.
+func addMetricsCollector(*support.Bundle) {}
+
+// registerRuntimeMetrics is a no-op: the binary was built without metrics.
+func registerRuntimeMetrics(*gctune.Tuner) error { return nil }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+)
+
+// runMigrate applies the versioned SQL migrations of a directory to the
+// configured database, so hosts need no atlas CLI:
+// `server -conf config.yaml migrate [-dir scripts/sql/migration] up|down [n]|status`.
+func runMigrate(c *conf.Data, args []string, logger log.Logger) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", "scripts/sql/migration", "directory of the <version>_<name>.sql migrations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	steps := 1
+	switch fs.Arg(0) {
+	case "up", "status":
+		if fs.NArg() > 1 {
+			return fmt.Errorf("migrate %s takes no arguments", fs.Arg(0))
+		}
+	case "down":
+		if fs.NArg() > 2 {
+			return errors.New("migrate down takes at most a number of steps")
+		}
+		if fs.NArg() == 2 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", fs.Arg(1))
+			}
+			steps = n
+		}
+	default:
+		return fmt.Errorf("migrate: want up, down [n] or status, got %q", fs.Arg(0))
+	}
+
+	m, cleanup, err := data.NewMigrator(c.GetDatabase(), os.DirFS(*dir), logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied %s_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Printf("reverted %s_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	default:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "-"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Version, s.Name, s.State, applied)
+		}
+		return w.Flush()
+	}
+}
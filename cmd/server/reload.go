@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/reload"
+)
+
+const (
+	reloadTimeout = 30 * time.Second
+	// watchDebounce coalesces the events of one config update: the kubelet
+	// swaps the symlinks of a ConfigMap volume in several steps.
+	watchDebounce = time.Second
+)
+
+// watchReload re-reads the config file on SIGHUP and applies the settings
+// the subsystems registered with pkg/reload, logging what was reloaded and
+// which changes need a restart. bc is the startup config. With
+// CONFIG_WATCH=true the config file is also reloaded when it changes, e.g.
+// when the ConfigMap mounted on Kubernetes is updated. Config from Apollo is
+// not reloaded.
+func watchReload(bc *conf.Bootstrap, zl *zapLog.ZapLogger, logger log.Logger) (stop func(), err error) {
+	reload.Register("log", []string{"log.level"}, func(_ context.Context, m proto.Message) error {
+		zl.SetLevel(parseLogLevel(m.(*conf.Bootstrap).GetLog().GetLevel()))
+		return nil
+	})
+	if err := reload.Start(bc); err != nil {
+		return nil, err
+	}
+
+	logHelper := zapLog.NewScoped(logger).For("reload").Helper
+	changed, stopWatch, err := watchConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sig:
+				reloadConfig(logHelper, "sighup")
+			case <-changed:
+				reloadConfig(logHelper, "file")
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		stopWatch()
+		close(done)
+	}, nil
+}
+
+// watchConfigFile notifies changed, debounced, when the directory of the
+// config file changes and CONFIG_WATCH=true. The directory is watched
+// rather than the file, which a ConfigMap update replaces.
+func watchConfigFile() (changed <-chan struct{}, stop func(), err error) {
+	file := configFile()
+	if env.GetOrDefault("CONFIG_WATCH", "false") != "true" || file == "" {
+		return nil, func() {}, nil
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := w.Add(filepath.Dir(file)); err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+	ch := make(chan struct{}, 1)
+	go func() {
+		var debounce <-chan time.Time
+		for {
+			select {
+			case _, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				debounce = time.After(watchDebounce)
+			case <-debounce:
+				debounce = nil
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			case _, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return ch, func() { w.Close() }, nil
+}
+
+// reloadConfig reloads the config file on behalf of source, sighup or file.
+func reloadConfig(logHelper *log.Helper, source string) {
+	if configFile() == "" {
+		logHelper.Warn("SIGHUP ignored: config is loaded from Apollo")
+		return
+	}
+	next, cleanup, err := loadConfig()
+	if err != nil {
+		logHelper.Errorf("reload config: %v", err)
+		return
+	}
+	cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+	report, err := reload.Reload(ctx, next)
+	if err != nil {
+		logHelper.Errorf("reload config: %v", err)
+		return
+	}
+	logHelper.Infow("msg", "config reloaded", "source", source, "reloaded", report.Reloaded)
+	lifecycle.Emit(ctx, lifecycle.ConfigReloaded{Source: source})
+	if len(report.Failed) > 0 {
+		errs := make(map[string]string, len(report.Failed))
+		for name, err := range report.Failed {
+			errs[name] = err.Error()
+		}
+		logHelper.Errorw("msg", "config reload failed, previous settings kept", "failed", errs)
+	}
+	if len(report.Restart) > 0 {
+		logHelper.Warnw("msg", "config changes take effect on restart", "keys", report.Restart)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// runSeed runs the seeders registered with orm.RegisterSeeder that have not
+// run on the configured database, or lists them with their state:
+// `server -conf config.yaml seed [status]`.
+func runSeed(c *conf.Data, args []string, logger log.Logger) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "status") {
+		return fmt.Errorf("seed: want no argument or status, got %q", args)
+	}
+	db, cleanup, err := data.OpenDB(c.GetDatabase(), logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	ctx := context.Background()
+	if len(args) == 0 {
+		done, err := orm.Seed(ctx, db)
+		for _, name := range done {
+			fmt.Printf("seeded %s\n", name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("no pending seeders")
+		}
+		return err
+	}
+
+	statuses, err := orm.SeedHistoryOf(ctx, db)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		state, applied := "pending", "-"
+		if !s.AppliedAt.IsZero() {
+			state, applied = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, state, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+	"github.com/go-kratos/kratos-layout/pkg/selftest"
+)
+
+// selfTest exercises the dependencies of a fully wired app without serving
+// traffic: `server -conf config.yaml self-test [-timeout 10s] [-topic selftest]`.
+//
+// The canary is published on, and consumed from, the self-test topic, which
+// must exist on the broker. Starting the bus also starts the app's own
+// subscriptions for the duration of the check.
+type selfTest struct {
+	data *data.Data
+	bus  eventbus.Bus
+	reg  *nacos.Registry
+
+	topic string
+}
+
+// newSelfTest takes the app so that the self-test wires, and thus
+// validates, the same graph as the server.
+func newSelfTest(_ *kratos.App, d *data.Data, bus eventbus.Bus, r *nacos.Registry) *selfTest {
+	return &selfTest{data: d, bus: bus, reg: r}
+}
+
+// run runs the checks, prints the report and fails when a check failed.
+func (s *selfTest) run(args []string) error {
+	fs := flag.NewFlagSet("self-test", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each check")
+	fs.StringVar(&s.topic, "topic", "selftest", "topic of the canary message")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	results := selftest.Run(context.Background(), *timeout,
+		selftest.Check{Name: "database", Run: s.database},
+		selftest.Check{Name: "analytics", Run: s.analytics},
+		selftest.Check{Name: "redis", Run: s.redis},
+		selftest.Check{Name: "mq", Run: s.mq},
+		selftest.Check{Name: "nacos", Run: s.nacos},
+	)
+	if failed := selftest.Report(os.Stdout, results); failed > 0 {
+		return fmt.Errorf("%d self-test checks failed", failed)
+	}
+	return nil
+}
+
+func nonce() string {
+	return id + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+func (s *selfTest) database(ctx context.Context) error {
+	var one int
+	if err := s.data.DB(ctx).Raw("SELECT 1").Scan(&one).Error; err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("SELECT 1 returned %d", one)
+	}
+	return nil
+}
+
+func (s *selfTest) analytics(ctx context.Context) error {
+	db := s.data.Analytics(ctx)
+	if db == nil {
+		return selftest.Skip("data.analytics is not configured")
+	}
+	var one int
+	if err := db.Raw("SELECT 1").Scan(&one).Error; err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("SELECT 1 returned %d", one)
+	}
+	return nil
+}
+
+func (s *selfTest) redis(ctx context.Context) error {
+	rdb := s.data.Redis()
+	key, value := "selftest:"+nonce(), nonce()
+	if err := rdb.Set(ctx, key, value, time.Minute).Err(); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	defer rdb.Del(context.WithoutCancel(ctx), key)
+	got, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if got != value {
+		return fmt.Errorf("get returned %q, want %q", got, value)
+	}
+	return nil
+}
+
+func (s *selfTest) mq(ctx context.Context) error {
+	canary := nonce()
+	received := make(chan struct{})
+	var once sync.Once
+	err := s.bus.Subscribe(s.topic, func(_ context.Context, e *eventbus.Event) error {
+		// Canaries left over from earlier runs are acknowledged and ignored.
+		if string(e.Body) == canary {
+			once.Do(func() { close(received) })
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	if err := s.bus.Start(context.WithoutCancel(ctx)); err != nil {
+		return fmt.Errorf("start consumer: %w", err)
+	}
+	defer s.bus.Stop(context.WithoutCancel(ctx))
+	if err := s.bus.Publish(ctx, &eventbus.Event{Topic: s.topic, Key: "selftest", Body: []byte(canary)}); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	select {
+	case <-received:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("canary not consumed: %w", ctx.Err())
+	}
+}
+
+func (s *selfTest) nacos(ctx context.Context) error {
+	// A separate service, so clients of the real one never dial it.
+	si := &registry.ServiceInstance{
+		ID:        id + "-selftest",
+		Name:      Name + "-selftest",
+		Version:   Version,
+		Endpoints: []string{"http://127.0.0.1:9"},
+	}
+	if err := s.reg.Register(ctx, si); err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	defer s.reg.Deregister(context.WithoutCancel(ctx), si)
+	for {
+		instances, err := s.reg.GetService(ctx, si.Name+".http")
+		switch {
+		case errors.Is(err, nacos.ErrDisabled):
+			return selftest.Skip("nacos is compiled out")
+		case err == nil && len(instances) > 0:
+			return nil
+		}
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("lookup: %w", err)
+			}
+			return fmt.Errorf("registered instance not found: %w", ctx.Err())
+		}
+	}
+}
@@ -12,7 +12,12 @@ import (
 	"github.com/go-kratos/kratos-layout/internal/job"
 	"github.com/go-kratos/kratos-layout/internal/server"
 	"github.com/go-kratos/kratos-layout/internal/service"
+	"github.com/go-kratos/kratos-layout/pkg/instrument"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
 	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+	"github.com/go-kratos/kratos-layout/pkg/support"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/log"
@@ -20,6 +25,13 @@ import (
 )
 
 // wireApp init kratos application.
-func wireApp(*conf.Server, *conf.Data, *conf.RocketMQ, *nacos.Registry, log.Logger) (*kratos.App, func(), error) {
-	panic(wire.Build(server.ProviderSet, data.ProviderSet, biz.ProviderSet, service.ProviderSet, job.ProviderSet, newApp))
+func wireApp(*conf.Server, *conf.Data, *conf.RocketMQ, *conf.Nats, *conf.Region, *conf.Client, *conf.Alert, *nacos.Registry, *support.Bundle, *instrument.Recorder, *orm.StatementMetrics, *outbox.Metrics, log.Logger) (*kratos.App, func(), error) {
+	panic(wire.Build(server.ProviderSet, data.ProviderSet, biz.ProviderSet, service.ProviderSet, job.ProviderSet,
+		zapLog.NewScoped, wire.Bind(new(server.Maintainer), new(*data.Data)), wire.Bind(new(server.AuditSearcher), new(*data.Data)), newApp))
+}
+
+// wireSelfTest wires the app like wireApp for the self-test command.
+func wireSelfTest(*conf.Server, *conf.Data, *conf.RocketMQ, *conf.Nats, *conf.Region, *conf.Client, *conf.Alert, *nacos.Registry, *support.Bundle, *instrument.Recorder, *orm.StatementMetrics, *outbox.Metrics, log.Logger) (*selfTest, func(), error) {
+	panic(wire.Build(server.ProviderSet, data.ProviderSet, biz.ProviderSet, service.ProviderSet, job.ProviderSet,
+		zapLog.NewScoped, wire.Bind(new(server.Maintainer), new(*data.Data)), wire.Bind(new(server.AuditSearcher), new(*data.Data)), newApp, newSelfTest))
 }
@@ -13,7 +13,12 @@ import (
 	"github.com/go-kratos/kratos-layout/internal/job"
 	"github.com/go-kratos/kratos-layout/internal/server"
 	"github.com/go-kratos/kratos-layout/internal/service"
+	"github.com/go-kratos/kratos-layout/pkg/instrument"
+	log2 "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
 	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+	"github.com/go-kratos/kratos-layout/pkg/support"
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/log"
 
@@ -23,19 +28,270 @@ import (
 // Injectors from wire.go:
 
 // wireApp init kratos application.
-func wireApp(confServer *conf.Server, confData *conf.Data, rocketMQ *conf.RocketMQ, registry *nacos.Registry, logger log.Logger) (*kratos.App, func(), error) {
-	dataData, cleanup, err := data.NewData(confData, logger)
+func wireApp(confServer *conf.Server, confData *conf.Data, rocketMQ *conf.RocketMQ, nats *conf.Nats, region *conf.Region, client *conf.Client, alert *conf.Alert, registry *nacos.Registry, bundle *support.Bundle, recorder *instrument.Recorder, statementMetrics *orm.StatementMetrics, metrics *outbox.Metrics, logger log.Logger) (*kratos.App, func(), error) {
+	scoped := log2.NewScoped(logger)
+	dataData, cleanup, err := data.NewData(confData, statementMetrics, scoped, logger)
 	if err != nil {
 		return nil, nil, err
 	}
-	greeterRepo := data.NewGreeterRepo(dataData, logger)
-	greeterUsecase := biz.NewGreeterUsecase(greeterRepo, logger)
+	greeterRepo := data.NewGreeterRepo(dataData, recorder, scoped)
+	greeterUsecase := biz.NewGreeterUsecase(greeterRepo, scoped)
 	greeterService := service.NewGreeterService(greeterUsecase)
-	grpcServer := server.NewGRPCServer(confServer, greeterService, logger)
-	httpServer := server.NewHTTPServer(confServer, greeterService, logger)
-	jobRegistry := &job.Registry{}
-	app := newApp(logger, grpcServer, httpServer, registry, jobRegistry)
+	errorRate := server.NewErrorRate()
+	alerter, cleanup2, err := server.NewAlerter(alert, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	store := data.NewQuotaStore(dataData)
+	quota := server.NewQuota(confServer, store)
+	controller, err := data.NewDegradation(confData)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	bus, cleanup3, err := data.NewEventBus(rocketMQ, nats, region, confData, controller, scoped, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	outboxOutbox := data.NewOutbox(confData, dataData, bus, metrics, logger)
+	meter := server.NewMeter(confServer, outboxOutbox, logger)
+	profiler := server.NewProfiler()
+	debugtraceStore := data.NewDebugTraceStore(dataData)
+	middlewareRegistry := server.NewMiddlewareRegistry(confServer, alerter, quota, meter, profiler, debugtraceStore, logger)
+	grpcServer, err := server.NewGRPCServer(confServer, greeterService, errorRate, middlewareRegistry, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	selector, err := data.NewCodecs(confData)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	factory := data.NewClientFactory(client, confServer, dataData, registry, selector, controller, logger)
+	gateway, cleanup4, err := server.NewGateway(confServer, factory, errorRate, middlewareRegistry, scoped)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	httpServer, err := server.NewHTTPServer(confServer, grpcServer, greeterService, gateway, errorRate, middlewareRegistry, logger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	auditStore := data.NewAdminAuditStore(dataData)
+	adminServer := server.NewAdminServer(confServer, grpcServer, httpServer, dataData, registry, quota, bundle, profiler, debugtraceStore, auditStore, dataData, outboxOutbox, logger)
+	schedule, err := job.NewSchedule(confData, dataData)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	weightJob := job.NewWeightJob(registry, errorRate, dataData, scoped)
+	reconcileJob := job.NewReconcileJob(registry, scoped)
+	maintenanceJob := job.NewMaintenanceJob(confData, dataData, scoped)
+	heartbeatJob, err := job.NewHeartbeatJob(confData, dataData, bus, scoped)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	retentionJob := job.NewRetentionJob(confData, dataData, scoped)
+	statemachineRegistry := data.NewStateMachines(dataData)
+	stateTimeoutJob := job.NewStateTimeoutJob(confData, statemachineRegistry, scoped)
+	timerService, cleanup5, err := data.NewTimers(rocketMQ, nats, region, confData, dataData, bus, logger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	timerJob := job.NewTimerJob(confData, timerService, scoped)
+	counters := data.NewCounters(dataData)
+	counterFlushJob := job.NewCounterFlushJob(confData, counters, scoped)
+	set, err := data.NewRules(confData, dataData)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	rulesReloadJob := job.NewRulesReloadJob(confData, set, scoped)
+	dependencyJob := job.NewDependencyJob(confData, dataData, scoped)
+	jobRegistry := &job.Registry{
+		Schedule:     schedule,
+		Weight:       weightJob,
+		Reconcile:    reconcileJob,
+		Maintenance:  maintenanceJob,
+		Heartbeat:    heartbeatJob,
+		Retention:    retentionJob,
+		StateTimeout: stateTimeoutJob,
+		Timer:        timerJob,
+		CounterFlush: counterFlushJob,
+		RulesReload:  rulesReloadJob,
+		Dependency:   dependencyJob,
+	}
+	app := newApp(logger, grpcServer, httpServer, adminServer, bus, outboxOutbox, meter, registry, jobRegistry)
 	return app, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+// wireSelfTest wires the app like wireApp for the self-test command.
+func wireSelfTest(confServer *conf.Server, confData *conf.Data, rocketMQ *conf.RocketMQ, nats *conf.Nats, region *conf.Region, client *conf.Client, alert *conf.Alert, registry *nacos.Registry, bundle *support.Bundle, recorder *instrument.Recorder, statementMetrics *orm.StatementMetrics, metrics *outbox.Metrics, logger log.Logger) (*selfTest, func(), error) {
+	scoped := log2.NewScoped(logger)
+	dataData, cleanup, err := data.NewData(confData, statementMetrics, scoped, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	greeterRepo := data.NewGreeterRepo(dataData, recorder, scoped)
+	greeterUsecase := biz.NewGreeterUsecase(greeterRepo, scoped)
+	greeterService := service.NewGreeterService(greeterUsecase)
+	errorRate := server.NewErrorRate()
+	alerter, cleanup2, err := server.NewAlerter(alert, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	store := data.NewQuotaStore(dataData)
+	quota := server.NewQuota(confServer, store)
+	controller, err := data.NewDegradation(confData)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	bus, cleanup3, err := data.NewEventBus(rocketMQ, nats, region, confData, controller, scoped, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	outboxOutbox := data.NewOutbox(confData, dataData, bus, metrics, logger)
+	meter := server.NewMeter(confServer, outboxOutbox, logger)
+	profiler := server.NewProfiler()
+	debugtraceStore := data.NewDebugTraceStore(dataData)
+	middlewareRegistry := server.NewMiddlewareRegistry(confServer, alerter, quota, meter, profiler, debugtraceStore, logger)
+	grpcServer, err := server.NewGRPCServer(confServer, greeterService, errorRate, middlewareRegistry, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	selector, err := data.NewCodecs(confData)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	factory := data.NewClientFactory(client, confServer, dataData, registry, selector, controller, logger)
+	gateway, cleanup4, err := server.NewGateway(confServer, factory, errorRate, middlewareRegistry, scoped)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	httpServer, err := server.NewHTTPServer(confServer, grpcServer, greeterService, gateway, errorRate, middlewareRegistry, logger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	auditStore := data.NewAdminAuditStore(dataData)
+	adminServer := server.NewAdminServer(confServer, grpcServer, httpServer, dataData, registry, quota, bundle, profiler, debugtraceStore, auditStore, dataData, outboxOutbox, logger)
+	schedule, err := job.NewSchedule(confData, dataData)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	weightJob := job.NewWeightJob(registry, errorRate, dataData, scoped)
+	reconcileJob := job.NewReconcileJob(registry, scoped)
+	maintenanceJob := job.NewMaintenanceJob(confData, dataData, scoped)
+	heartbeatJob, err := job.NewHeartbeatJob(confData, dataData, bus, scoped)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	retentionJob := job.NewRetentionJob(confData, dataData, scoped)
+	statemachineRegistry := data.NewStateMachines(dataData)
+	stateTimeoutJob := job.NewStateTimeoutJob(confData, statemachineRegistry, scoped)
+	timerService, cleanup5, err := data.NewTimers(rocketMQ, nats, region, confData, dataData, bus, logger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	timerJob := job.NewTimerJob(confData, timerService, scoped)
+	counters := data.NewCounters(dataData)
+	counterFlushJob := job.NewCounterFlushJob(confData, counters, scoped)
+	set, err := data.NewRules(confData, dataData)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	rulesReloadJob := job.NewRulesReloadJob(confData, set, scoped)
+	dependencyJob := job.NewDependencyJob(confData, dataData, scoped)
+	jobRegistry := &job.Registry{
+		Schedule:     schedule,
+		Weight:       weightJob,
+		Reconcile:    reconcileJob,
+		Maintenance:  maintenanceJob,
+		Heartbeat:    heartbeatJob,
+		Retention:    retentionJob,
+		StateTimeout: stateTimeoutJob,
+		Timer:        timerJob,
+		CounterFlush: counterFlushJob,
+		RulesReload:  rulesReloadJob,
+		Dependency:   dependencyJob,
+	}
+	app := newApp(logger, grpcServer, httpServer, adminServer, bus, outboxOutbox, meter, registry, jobRegistry)
+	mainSelfTest := newSelfTest(app, dataData, bus, registry)
+	return mainSelfTest, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
 		cleanup()
 	}, nil
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Resolver finds the descriptors of stubbed methods.
+type Resolver interface {
+	FindDescriptorByName(protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+type grpcStub struct {
+	match any
+	reply proto.Message
+	err   error
+	delay time.Duration
+}
+
+type grpcMethod struct {
+	service string
+	desc    protoreflect.MethodDescriptor
+	stubs   []grpcStub
+}
+
+// GRPCStubs serves the gRPC stubs of a config as an unknown service
+// handler, encoding messages with the descriptors of the resolver.
+type GRPCStubs struct {
+	methods map[string]*grpcMethod
+}
+
+// NewGRPCStubs resolves the stubbed methods and prepares their replies.
+// Only unary methods can be stubbed.
+func NewGRPCStubs(c *Config, r Resolver) (*GRPCStubs, error) {
+	s := &GRPCStubs{methods: make(map[string]*grpcMethod)}
+	for _, svc := range c.Services {
+		for _, g := range svc.GRPC {
+			m, err := s.method(svc.Name, g.Method, r)
+			if err != nil {
+				return nil, err
+			}
+			stub := grpcStub{delay: g.Delay}
+			if stub.match, err = normalize(g.Match); err != nil {
+				return nil, fmt.Errorf("%s: match: %w", g.Method, err)
+			}
+			if g.Error != nil {
+				stub.err = errors.New(int(g.Error.Code), g.Error.Reason, g.Error.Message)
+			} else if stub.reply, err = newReply(m.desc.Output(), g.Response); err != nil {
+				return nil, fmt.Errorf("%s: response: %w", g.Method, err)
+			}
+			m.stubs = append(m.stubs, stub)
+		}
+	}
+	return s, nil
+}
+
+func (s *GRPCStubs) method(service, method string, r Resolver) (*grpcMethod, error) {
+	if m, ok := s.methods[method]; ok {
+		if m.service != service {
+			return nil, fmt.Errorf("%s is stubbed by both %s and %s", method, m.service, service)
+		}
+		return m, nil
+	}
+	svc, name, _ := splitMethod(method)
+	d, err := r.FindDescriptorByName(protoreflect.FullName(svc + "." + name))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w (import its Go package or pass -descriptors)", method, err)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a method", method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("%s: streaming methods cannot be stubbed", method)
+	}
+	m := &grpcMethod{service: service, desc: md}
+	s.methods[method] = m
+	return m, nil
+}
+
+func newReply(desc protoreflect.MessageDescriptor, response any) (proto.Message, error) {
+	reply := dynamicpb.NewMessage(desc)
+	if response == nil {
+		return reply, nil
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return reply, protojson.Unmarshal(data, reply)
+}
+
+// Handler is the grpc.UnknownServiceHandler answering the stubbed methods.
+func (s *GRPCStubs) Handler(_ any, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	m, ok := s.methods[method]
+	if !ok {
+		log.Printf("grpc %s: no stub", method)
+		return status.Errorf(codes.Unimplemented, "stubserver: no stub for %s", method)
+	}
+	req := dynamicpb.NewMessage(m.desc.Input())
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var got any
+	if err := json.Unmarshal(data, &got); err != nil {
+		return err
+	}
+	for i, stub := range m.stubs {
+		if stub.match != nil && !matches(stub.match, got) {
+			continue
+		}
+		log.Printf("grpc %s: stub %d of %s", method, i+1, m.service)
+		if err := sleep(stream.Context(), stub.delay); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if stub.err != nil {
+			return stub.err
+		}
+		return stream.SendMsg(stub.reply)
+	}
+	log.Printf("grpc %s: no stub matches %s", method, data)
+	return status.Errorf(codes.NotFound, "stubserver: no stub of %s matches the request", method)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resolvers tries each resolver in turn.
+type resolvers []Resolver
+
+func (rs resolvers) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, r := range rs {
+		if d, err := r.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// HTTPStubs serves the HTTP stubs of a config.
+type HTTPStubs struct {
+	stubs    []HTTPStub
+	services []string
+}
+
+// NewHTTPStubs collects the HTTP stubs of c in order.
+func NewHTTPStubs(c *Config) *HTTPStubs {
+	s := &HTTPStubs{}
+	for _, svc := range c.Services {
+		for _, h := range svc.HTTP {
+			s.stubs = append(s.stubs, h)
+			s.services = append(s.services, svc.Name)
+		}
+	}
+	return s
+}
+
+func (h HTTPStub) matches(r *http.Request) bool {
+	if h.Method != "" && !strings.EqualFold(h.Method, r.Method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(h.Path, "*"); ok {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+	return r.URL.Path == h.Path
+}
+
+// ServeHTTP implements http.Handler.
+func (s *HTTPStubs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for i, stub := range s.stubs {
+		if !stub.matches(r) {
+			continue
+		}
+		log.Printf("http %s %s: %s stub %s", r.Method, r.URL.Path, s.services[i], stub.Path)
+		if err := sleep(r.Context(), stub.Delay); err != nil {
+			return
+		}
+		for k, v := range stub.Headers {
+			w.Header().Set(k, v)
+		}
+		if stub.Error != nil {
+			writeError(w, errors.New(int(stub.Error.Code), stub.Error.Reason, stub.Error.Message), stub.Status)
+			return
+		}
+		writeBody(w, stub.Status, stub.Body)
+		return
+	}
+	log.Printf("http %s %s: no stub", r.Method, r.URL.Path)
+	writeError(w, errors.NotFound("STUB_NOT_FOUND", "stubserver: no stub for "+r.Method+" "+r.URL.Path), 0)
+}
+
+// writeError renders err like the kratos HTTP server's error encoder.
+func writeError(w http.ResponseWriter, err *errors.Error, code int) {
+	if code == 0 {
+		code = int(err.Code)
+	}
+	body, _ := json.Marshal(&err.Status)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+func writeBody(w http.ResponseWriter, code int, body any) {
+	if code == 0 {
+		code = http.StatusOK
+	}
+	var data []byte
+	if s, ok := body.(string); ok {
+		data = []byte(s)
+	} else if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+	w.WriteHeader(code)
+	w.Write(data)
+}
@@ -0,0 +1,129 @@
+// Command stubserver serves canned gRPC and HTTP responses for downstream
+// services, so the service can run locally without the rest of the mesh:
+//
+//	go run ./cmd/stubserver -config stubs.yaml -grpc 127.0.0.1:9100 -http 127.0.0.1:8100
+//
+// Point the stubbed services at it with client.endpoints in the service
+// config:
+//
+//	client:
+//	  endpoints:
+//	    user-service: {grpc: 127.0.0.1:9100, http: 127.0.0.1:8100}
+//
+// See ParseConfig for the stub format. gRPC messages are encoded with the
+// descriptors of the api packages imported below, or of a descriptor set
+// (buf build -o stubs.binpb, or protoc --include_imports
+// --descriptor_set_out) passed with -descriptors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Descriptors of the stubbable services; add the api packages of the
+	// downstream services here.
+	_ "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+var (
+	flagConfig      string
+	flagGRPC        string
+	flagHTTP        string
+	flagDescriptors string
+)
+
+func main() {
+	flag.StringVar(&flagConfig, "config", "stubs.yaml", "path to the stubbed services")
+	flag.StringVar(&flagGRPC, "grpc", "127.0.0.1:9100", "gRPC listen address, empty to disable")
+	flag.StringVar(&flagHTTP, "http", "127.0.0.1:8100", "HTTP listen address, empty to disable")
+	flag.StringVar(&flagDescriptors, "descriptors", "", "binary FileDescriptorSet of services not imported by the binary")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "stubserver: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if flagGRPC == "" && flagHTTP == "" {
+		return errors.New("-grpc and -http are both disabled")
+	}
+	data, err := os.ReadFile(flagConfig)
+	if err != nil {
+		return err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return err
+	}
+	r := resolvers{protoregistry.GlobalFiles}
+	if flagDescriptors != "" {
+		files, err := loadDescriptors(flagDescriptors)
+		if err != nil {
+			return err
+		}
+		r = resolvers{files, protoregistry.GlobalFiles}
+	}
+	grpcStubs, err := NewGRPCStubs(cfg, r)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	errc := make(chan error, 2)
+	if flagGRPC != "" {
+		lis, err := net.Listen("tcp", flagGRPC)
+		if err != nil {
+			return err
+		}
+		srv := grpc.NewServer(grpc.UnknownServiceHandler(grpcStubs.Handler))
+		defer srv.Stop()
+		go func() { errc <- srv.Serve(lis) }()
+		log.Printf("serving gRPC stubs on %s", lis.Addr())
+	}
+	if flagHTTP != "" {
+		srv := &http.Server{Addr: flagHTTP, Handler: NewHTTPStubs(cfg), ReadHeaderTimeout: 10 * time.Second}
+		defer srv.Close()
+		go func() { errc <- srv.ListenAndServe() }()
+		log.Printf("serving HTTP stubs on %s", flagHTTP)
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
+
+// loadDescriptors reads a binary FileDescriptorSet.
+func loadDescriptors(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return files, nil
+}
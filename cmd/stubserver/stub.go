@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config declares the stubbed downstream services.
+type Config struct {
+	Services []Service `yaml:"services"`
+}
+
+// Service is one stubbed downstream service. Stubs of all services share
+// the stub server's ports, so gRPC methods and HTTP routes must not clash.
+type Service struct {
+	Name string     `yaml:"name"`
+	GRPC []GRPCStub `yaml:"grpc"`
+	HTTP []HTTPStub `yaml:"http"`
+}
+
+// GRPCStub answers a unary gRPC method. Stubs are tried in order; the
+// first whose Match fields equal those of the request answers.
+type GRPCStub struct {
+	// Method is the full method name, e.g. /user.v1.User/GetUser.
+	Method string `yaml:"method"`
+	// Match holds request fields, by proto name, the request must have.
+	Match map[string]any `yaml:"match"`
+	// Response is the reply in protojson form; ignored when Error is set.
+	Response any           `yaml:"response"`
+	Error    *StubError    `yaml:"error"`
+	Delay    time.Duration `yaml:"delay"`
+}
+
+// HTTPStub answers HTTP requests. Stubs are tried in order; the first
+// whose method and path match answers.
+type HTTPStub struct {
+	// Method is the HTTP method; empty matches any.
+	Method string `yaml:"method"`
+	// Path is matched exactly, or as a prefix when it ends with *.
+	Path string `yaml:"path"`
+	// Status defaults to 200, or to the error's code.
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	// Body is written as JSON, or as is when it is a string.
+	Body  any           `yaml:"body"`
+	Error *StubError    `yaml:"error"`
+	Delay time.Duration `yaml:"delay"`
+}
+
+// StubError is a kratos error: gRPC stubs return it as a status, HTTP
+// stubs render it like the kratos HTTP server does.
+type StubError struct {
+	Code    int32  `yaml:"code"` // HTTP status code, e.g. 404
+	Reason  string `yaml:"reason"`
+	Message string `yaml:"message"`
+}
+
+// ParseConfig parses a YAML config:
+//
+//	services:
+//	  - name: user-service
+//	    grpc:
+//	      - method: /user.v1.User/GetUser
+//	        match: {id: 42}
+//	        response: {id: 42, name: alice}
+//	      - method: /user.v1.User/GetUser
+//	        error: {code: 404, reason: USER_NOT_FOUND, message: user not found}
+//	    http:
+//	      - method: GET
+//	        path: /v1/users/*
+//	        body: {id: 42, name: alice}
+//	        delay: 50ms
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if len(c.Services) == 0 {
+		return nil, errors.New("config declares no services")
+	}
+	for _, s := range c.Services {
+		if s.Name == "" {
+			return nil, errors.New("service without name")
+		}
+		for _, g := range s.GRPC {
+			if _, _, ok := splitMethod(g.Method); !ok {
+				return nil, fmt.Errorf("service %s: invalid gRPC method %q, want /package.Service/Method", s.Name, g.Method)
+			}
+		}
+		for _, h := range s.HTTP {
+			if !strings.HasPrefix(h.Path, "/") {
+				return nil, fmt.Errorf("service %s: invalid HTTP path %q", s.Name, h.Path)
+			}
+			if h.Status != 0 && http.StatusText(h.Status) == "" {
+				return nil, fmt.Errorf("service %s: invalid HTTP status %d for %s", s.Name, h.Status, h.Path)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// splitMethod splits /package.Service/Method.
+func splitMethod(method string) (service, name string, ok bool) {
+	service, name, ok = strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return service, name, ok && strings.HasPrefix(method, "/") && service != "" && name != "" && !strings.Contains(name, "/")
+}
+
+// matches reports whether got has the fields of want. Both are decoded
+// JSON, and scalars are compared in text form since protojson renders
+// 64-bit integers as strings.
+func matches(want, got any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			if !matches(v, g[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !matches(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return fmt.Sprint(want) == fmt.Sprint(got)
+	}
+}
+
+// normalize round-trips v through JSON, so YAML and protojson values
+// compare alike.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	return out, json.Unmarshal(data, &out)
+}
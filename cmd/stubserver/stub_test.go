@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+const testConfig = `
+services:
+  - name: greeter
+    grpc:
+      - method: /helloworld.v1.Greeter/SayHello
+        match: {name: alice}
+        response: {message: hi alice}
+      - method: /helloworld.v1.Greeter/SayHello
+        error: {code: 404, reason: USER_NOT_FOUND, message: no such user}
+    http:
+      - method: GET
+        path: /helloworld/*
+        headers: {X-Stub: "1"}
+        body: {message: hi}
+      - path: /health
+        body: ok
+      - method: POST
+        path: /users
+        error: {code: 409, reason: CONFLICT, message: exists}
+`
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	require.Len(t, c.Services, 1)
+	assert.Len(t, c.Services[0].GRPC, 2)
+	assert.Len(t, c.Services[0].HTTP, 3)
+	for _, bad := range []string{
+		``,
+		`services: [{grpc: []}]`,
+		`services: [{name: a, grpc: [{method: helloworld.v1.Greeter/SayHello}]}]`,
+		`services: [{name: a, grpc: [{method: /helloworld.v1.Greeter}]}]`,
+		`services: [{name: a, http: [{path: users}]}]`,
+		`services: [{name: a, http: [{path: /users, status: 999}]}]`,
+	} {
+		_, err := ParseConfig([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	got := map[string]any{"id": "42", "name": "alice", "tags": []any{"a"}, "page": map[string]any{"size": 10.0}}
+	for _, tt := range []struct {
+		want any
+		ok   bool
+	}{
+		{map[string]any{"id": 42.0}, true},
+		{map[string]any{"name": "alice", "page": map[string]any{"size": 10.0}}, true},
+		{map[string]any{"tags": []any{"a"}}, true},
+		{map[string]any{"name": "bob"}, false},
+		{map[string]any{"missing": "x"}, false},
+		{map[string]any{"tags": []any{"a", "b"}}, false},
+	} {
+		assert.Equal(t, tt.ok, matches(tt.want, got), "%v", tt.want)
+	}
+}
+
+func TestGRPCStubs(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	_, err = NewGRPCStubs(&Config{Services: []Service{{Name: "a", GRPC: []GRPCStub{{Method: "/unknown.v1.Svc/Call"}}}}}, protoregistry.GlobalFiles)
+	assert.Error(t, err, "unknown method")
+	_, err = NewGRPCStubs(&Config{Services: []Service{{Name: "a", GRPC: []GRPCStub{{Method: "/helloworld.v1.Greeter/SayHello", Response: map[string]any{"nope": 1}}}}}}, protoregistry.GlobalFiles)
+	assert.Error(t, err, "response with an unknown field")
+	stubs, err := NewGRPCStubs(c, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(stubs.Handler))
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := v1.NewGreeterClient(conn)
+	ctx := context.Background()
+
+	reply, err := client.SayHello(ctx, &v1.HelloRequest{Name: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "hi alice", reply.GetMessage())
+	_, err = client.SayHello(ctx, &v1.HelloRequest{Name: "bob"})
+	e := errors.FromError(err)
+	assert.Equal(t, "USER_NOT_FOUND", e.Reason)
+	assert.EqualValues(t, 404, e.Code)
+	err = conn.Invoke(ctx, "/helloworld.v1.Greeter/Other", &v1.HelloRequest{}, &v1.HelloReply{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err), "unstubbed method")
+}
+
+func TestHTTPStubs(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewHTTPStubs(c))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{http.MethodGet, "/helloworld/alice", 200, `{"message":"hi"}`},
+		{http.MethodPost, "/helloworld/alice", 404, `{"code":404,"reason":"STUB_NOT_FOUND","message":"stubserver: no stub for POST /helloworld/alice"}`},
+		{http.MethodPut, "/health", 200, `ok`},
+		{http.MethodPost, "/users", 409, `{"code":409,"reason":"CONFLICT","message":"exists"}`},
+	} {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, tt.code, resp.StatusCode, "%s %s", tt.method, tt.path)
+		assert.Equal(t, tt.body, string(body), "%s %s", tt.method, tt.path)
+		if tt.code == http.StatusOK && tt.path == "/helloworld/alice" {
+			assert.Equal(t, "1", resp.Header.Get("X-Stub"))
+		}
+	}
+}
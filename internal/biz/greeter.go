@@ -4,9 +4,9 @@ import (
 	"context"
 
 	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
 
 	"github.com/go-kratos/kratos/v2/errors"
-	"github.com/go-kratos/kratos/v2/log"
 )
 
 //go:generate mockgen -source=greeter.go -destination=mock_greeter_test.go -package=biz
@@ -33,19 +33,19 @@ type GreeterRepo interface {
 // GreeterUsecase is a Greeter usecase.
 type GreeterUsecase struct {
 	repo GreeterRepo
-	log  *log.Helper
+	log  *zapLog.Helper
 }
 
 // NewGreeterUsecase new a Greeter usecase.
-func NewGreeterUsecase(repo GreeterRepo, logger log.Logger) *GreeterUsecase {
+func NewGreeterUsecase(repo GreeterRepo, logs *zapLog.Scoped) *GreeterUsecase {
 	return &GreeterUsecase{
 		repo: repo,
-		log:  log.NewHelper(log.With(logger, "module", "biz/greeter")),
+		log:  logs.For("biz/greeter"),
 	}
 }
 
 // CreateGreeter creates a Greeter, and returns the new Greeter.
 func (uc *GreeterUsecase) CreateGreeter(ctx context.Context, g *Greeter) (*Greeter, error) {
-	uc.log.WithContext(ctx).Infof("CreateGreeter: %v", g.Hello)
+	uc.log.FromContext(ctx).Infof("CreateGreeter: %v", g.Hello)
 	return uc.repo.Save(ctx, g)
 }
@@ -5,5 +5,18 @@ import "context"
 // Transaction is the interface for managing database transactions.
 // Defined in biz layer, implemented by data/infra layer.
 type Transaction interface {
+	// InTx runs fn in a transaction. Called within another InTx, fn runs in
+	// a savepoint of the outer transaction: its error rolls back only fn's
+	// changes, and the caller may handle it and go on.
 	InTx(context.Context, func(ctx context.Context) error) error
+	// InTxRetry is InTx re-running the whole transaction on deadlocks, lock
+	// wait timeouts and dropped connections. fn must be safe to re-run.
+	// Nested in InTx it runs in a savepoint without retries, as those
+	// errors abort the outer transaction.
+	InTxRetry(context.Context, func(ctx context.Context) error) error
+	// InReadTx runs fn in a read-only transaction: its reads see one
+	// consistent snapshot and take no locks, e.g. for reports. Writes in fn
+	// fail. Called within InTx, fn runs in the outer read-write
+	// transaction as is: no snapshot of its own, and writes succeed.
+	InReadTx(context.Context, func(ctx context.Context) error) error
 }
@@ -28,6 +28,13 @@ type Bootstrap struct {
 	Server        *Server                `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
 	Data          *Data                  `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
 	Rocketmq      *RocketMQ              `protobuf:"bytes,3,opt,name=rocketmq,proto3" json:"rocketmq,omitempty"`
+	Nats          *Nats                  `protobuf:"bytes,4,opt,name=nats,proto3" json:"nats,omitempty"`
+	Client        *Client                `protobuf:"bytes,5,opt,name=client,proto3" json:"client,omitempty"`
+	Alert         *Alert                 `protobuf:"bytes,6,opt,name=alert,proto3" json:"alert,omitempty"`
+	Runtime       *Runtime               `protobuf:"bytes,7,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	Region        *Region                `protobuf:"bytes,8,opt,name=region,proto3" json:"region,omitempty"`
+	Fingerprint   *Fingerprint           `protobuf:"bytes,9,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Log           *Log                   `protobuf:"bytes,10,opt,name=log,proto3" json:"log,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -83,34 +90,79 @@ func (x *Bootstrap) GetRocketmq() *RocketMQ {
 	return nil
 }
 
-// RocketMQ 消息队列配置 (v5 SDK)
-type RocketMQ struct {
+func (x *Bootstrap) GetNats() *Nats {
+	if x != nil {
+		return x.Nats
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetAlert() *Alert {
+	if x != nil {
+		return x.Alert
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetRuntime() *Runtime {
+	if x != nil {
+		return x.Runtime
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetRegion() *Region {
+	if x != nil {
+		return x.Region
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetFingerprint() *Fingerprint {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return nil
+}
+
+func (x *Bootstrap) GetLog() *Log {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+// Fingerprint 启动时记录生效配置的指纹，与上次运行对比并记录变更的配置项 (敏感值脱敏)
+type Fingerprint struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NameServers   string                 `protobuf:"bytes,1,opt,name=name_servers,json=nameServers,proto3" json:"name_servers,omitempty"`       // gRPC Proxy 端点地址 (如 127.0.0.1:8081)
-	ProducerGroup string                 `protobuf:"bytes,2,opt,name=producer_group,json=producerGroup,proto3" json:"producer_group,omitempty"` // Producer/Consumer 组名
-	SendTimeout   *durationpb.Duration   `protobuf:"bytes,3,opt,name=send_timeout,json=sendTimeout,proto3" json:"send_timeout,omitempty"`       // 发送超时时间
-	RetryTimes    int32                  `protobuf:"varint,4,opt,name=retry_times,json=retryTimes,proto3" json:"retry_times,omitempty"`         // 重试次数
-	AccessKey     string                 `protobuf:"bytes,5,opt,name=access_key,json=accessKey,proto3" json:"access_key,omitempty"`             // 访问密钥（可选）
-	SecretKey     string                 `protobuf:"bytes,6,opt,name=secret_key,json=secretKey,proto3" json:"secret_key,omitempty"`             // 密钥（可选）
-	Env           string                 `protobuf:"bytes,7,opt,name=env,proto3" json:"env,omitempty"`                                          // 环境标识，非空时作为 topic 后缀 (如 "dev" → topic_dev)
+	Store         string                 `protobuf:"bytes,1,opt,name=store,proto3" json:"store,omitempty"` // 存储方式: file (默认) 或 redis (data.redis，多实例共享，由每次部署的首个实例报告变更)；off 关闭
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`   // file 存储路径，默认 data/config.fingerprint.json
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`     // redis 存储键，默认 config:fingerprint:<服务名>
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RocketMQ) Reset() {
-	*x = RocketMQ{}
+func (x *Fingerprint) Reset() {
+	*x = Fingerprint{}
 	mi := &file_conf_conf_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RocketMQ) String() string {
+func (x *Fingerprint) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RocketMQ) ProtoMessage() {}
+func (*Fingerprint) ProtoMessage() {}
 
-func (x *RocketMQ) ProtoReflect() protoreflect.Message {
+func (x *Fingerprint) ProtoReflect() protoreflect.Message {
 	mi := &file_conf_conf_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -122,83 +174,3187 @@ func (x *RocketMQ) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RocketMQ.ProtoReflect.Descriptor instead.
-func (*RocketMQ) Descriptor() ([]byte, []int) {
+// Deprecated: Use Fingerprint.ProtoReflect.Descriptor instead.
+func (*Fingerprint) Descriptor() ([]byte, []int) {
 	return file_conf_conf_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *RocketMQ) GetNameServers() string {
+func (x *Fingerprint) GetStore() string {
 	if x != nil {
-		return x.NameServers
+		return x.Store
 	}
 	return ""
 }
 
-func (x *RocketMQ) GetProducerGroup() string {
+func (x *Fingerprint) GetPath() string {
 	if x != nil {
-		return x.ProducerGroup
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Fingerprint) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// Log 日志配置，SIGHUP 时热更新
+type Log struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"` // debug | info | warn | error，为空时读取 LOG_LEVEL 环境变量 (默认 info)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Log) Reset() {
+	*x = Log{}
+	mi := &file_conf_conf_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Log) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Log) ProtoMessage() {}
+
+func (x *Log) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Log.ProtoReflect.Descriptor instead.
+func (*Log) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Log) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+// Region 多地域双活部署配置
+type Region struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                             // 本实例所在地域 (如 cn-east)，为空时读取 REGION 环境变量；作为 ID 前缀和事件的地域标签
+	ScopeTopics         bool                   `protobuf:"varint,2,opt,name=scope_topics,json=scopeTopics,proto3" json:"scope_topics,omitempty"`                           // topic 加地域后缀 (如 orders → orders_cn-east)，事件只在本地域内投递
+	ScopeConsumerGroups bool                   `protobuf:"varint,3,opt,name=scope_consumer_groups,json=scopeConsumerGroups,proto3" json:"scope_consumer_groups,omitempty"` // 消费组 / NATS durable 加地域后缀，各地域独立消费跨地域复制的 topic
+	GlobalTopics        []string               `protobuf:"bytes,4,rep,name=global_topics,json=globalTopics,proto3" json:"global_topics,omitempty"`                         // 不加后缀、各地域共用的 topic
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Region) Reset() {
+	*x = Region{}
+	mi := &file_conf_conf_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Region) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Region) ProtoMessage() {}
+
+func (x *Region) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Region.ProtoReflect.Descriptor instead.
+func (*Region) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Region) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (x *RocketMQ) GetSendTimeout() *durationpb.Duration {
-	if x != nil {
-		return x.SendTimeout
-	}
-	return nil
+func (x *Region) GetScopeTopics() bool {
+	if x != nil {
+		return x.ScopeTopics
+	}
+	return false
+}
+
+func (x *Region) GetScopeConsumerGroups() bool {
+	if x != nil {
+		return x.ScopeConsumerGroups
+	}
+	return false
+}
+
+func (x *Region) GetGlobalTopics() []string {
+	if x != nil {
+		return x.GlobalTopics
+	}
+	return nil
+}
+
+// Runtime Go 运行时 GC 调优，各项为 0 时保持默认行为
+type Runtime struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gogc          int32                  `protobuf:"varint,1,opt,name=gogc,proto3" json:"gogc,omitempty"`                                  // GC 目标百分比，0 时沿用 GOGC 环境变量 (默认 100)，负数关闭按比例触发 (需配合 memory_limit)
+	MemoryLimit   int64                  `protobuf:"varint,2,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"` // 软内存上限 (字节)，0 时沿用 GOMEMLIMIT
+	Ballast       int64                  `protobuf:"varint,3,opt,name=ballast,proto3" json:"ballast,omitempty"`                            // ballast 大小 (字节)，抬高堆目标以降低小堆服务的 GC 频率，不占用物理内存
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Runtime) Reset() {
+	*x = Runtime{}
+	mi := &file_conf_conf_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Runtime) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Runtime) ProtoMessage() {}
+
+func (x *Runtime) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Runtime.ProtoReflect.Descriptor instead.
+func (*Runtime) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Runtime) GetGogc() int32 {
+	if x != nil {
+		return x.Gogc
+	}
+	return 0
+}
+
+func (x *Runtime) GetMemoryLimit() int64 {
+	if x != nil {
+		return x.MemoryLimit
+	}
+	return 0
+}
+
+func (x *Runtime) GetBallast() int64 {
+	if x != nil {
+		return x.Ballast
+	}
+	return 0
+}
+
+// Alert 告警通知配置
+type Alert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notifiers     []*Alert_Notifier      `protobuf:"bytes,1,rep,name=notifiers,proto3" json:"notifiers,omitempty"`                              // 为空时不发送告警
+	DedupWindow   *durationpb.Duration   `protobuf:"bytes,2,opt,name=dedup_window,json=dedupWindow,proto3" json:"dedup_window,omitempty"`       // 相同告警的去重窗口，默认 5m
+	MaxPerMinute  int32                  `protobuf:"varint,3,opt,name=max_per_minute,json=maxPerMinute,proto3" json:"max_per_minute,omitempty"` // 每分钟最多发送条数，默认 20
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_conf_conf_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert) ProtoMessage() {}
+
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Alert) GetNotifiers() []*Alert_Notifier {
+	if x != nil {
+		return x.Notifiers
+	}
+	return nil
+}
+
+func (x *Alert) GetDedupWindow() *durationpb.Duration {
+	if x != nil {
+		return x.DedupWindow
+	}
+	return nil
+}
+
+func (x *Alert) GetMaxPerMinute() int32 {
+	if x != nil {
+		return x.MaxPerMinute
+	}
+	return 0
+}
+
+// Client 下游服务客户端配置 (通过注册中心发现)
+type Client struct {
+	state             protoimpl.MessageState      `protogen:"open.v1"`
+	Timeout           *durationpb.Duration        `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`                                                // 请求超时，默认 2s
+	DiscoveryStaleTtl *durationpb.Duration        `protobuf:"bytes,2,opt,name=discovery_stale_ttl,json=discoveryStaleTtl,proto3" json:"discovery_stale_ttl,omitempty"` // 注册中心返回零实例时继续使用上次实例列表的时长，为空时关闭
+	Cache             []*Client_CacheRule         `protobuf:"bytes,3,rep,name=cache,proto3" json:"cache,omitempty"`
+	CacheStore        string                      `protobuf:"bytes,4,opt,name=cache_store,json=cacheStore,proto3" json:"cache_store,omitempty"` // memory (默认，进程内) | redis (实例间共享)
+	CacheSize         int32                       `protobuf:"varint,5,opt,name=cache_size,json=cacheSize,proto3" json:"cache_size,omitempty"`   // memory 模式最大条目数，默认 10000
+	Hedging           []*Client_HedgeRule         `protobuf:"bytes,6,rep,name=hedging,proto3" json:"hedging,omitempty"`
+	HedgeBudget       float64                     `protobuf:"fixed64,7,opt,name=hedge_budget,json=hedgeBudget,proto3" json:"hedge_budget,omitempty"`                                                  // 对冲请求占总请求数的上限比例，默认 0.1
+	Endpoints         map[string]*Client_Endpoint `protobuf:"bytes,8,rep,name=endpoints,proto3" json:"endpoints,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 按服务名配置
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Client) Reset() {
+	*x = Client{}
+	mi := &file_conf_conf_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client) ProtoMessage() {}
+
+func (x *Client) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client.ProtoReflect.Descriptor instead.
+func (*Client) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Client) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *Client) GetDiscoveryStaleTtl() *durationpb.Duration {
+	if x != nil {
+		return x.DiscoveryStaleTtl
+	}
+	return nil
+}
+
+func (x *Client) GetCache() []*Client_CacheRule {
+	if x != nil {
+		return x.Cache
+	}
+	return nil
+}
+
+func (x *Client) GetCacheStore() string {
+	if x != nil {
+		return x.CacheStore
+	}
+	return ""
+}
+
+func (x *Client) GetCacheSize() int32 {
+	if x != nil {
+		return x.CacheSize
+	}
+	return 0
+}
+
+func (x *Client) GetHedging() []*Client_HedgeRule {
+	if x != nil {
+		return x.Hedging
+	}
+	return nil
+}
+
+func (x *Client) GetHedgeBudget() float64 {
+	if x != nil {
+		return x.HedgeBudget
+	}
+	return 0
+}
+
+func (x *Client) GetEndpoints() map[string]*Client_Endpoint {
+	if x != nil {
+		return x.Endpoints
+	}
+	return nil
+}
+
+// RocketMQ 消息队列配置 (v5 SDK)
+type RocketMQ struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	NameServers         string                 `protobuf:"bytes,1,opt,name=name_servers,json=nameServers,proto3" json:"name_servers,omitempty"`                            // gRPC Proxy 端点地址, 多个以 ";" 或 "," 分隔故障转移 (如 127.0.0.1:8081;127.0.0.2:8081)
+	ProducerGroup       string                 `protobuf:"bytes,2,opt,name=producer_group,json=producerGroup,proto3" json:"producer_group,omitempty"`                      // Producer/Consumer 组名
+	SendTimeout         *durationpb.Duration   `protobuf:"bytes,3,opt,name=send_timeout,json=sendTimeout,proto3" json:"send_timeout,omitempty"`                            // 发送超时时间
+	RetryTimes          int32                  `protobuf:"varint,4,opt,name=retry_times,json=retryTimes,proto3" json:"retry_times,omitempty"`                              // 重试次数
+	AccessKey           string                 `protobuf:"bytes,5,opt,name=access_key,json=accessKey,proto3" json:"access_key,omitempty"`                                  // 访问密钥（可选）
+	SecretKey           string                 `protobuf:"bytes,6,opt,name=secret_key,json=secretKey,proto3" json:"secret_key,omitempty"`                                  // 密钥（可选）
+	Env                 string                 `protobuf:"bytes,7,opt,name=env,proto3" json:"env,omitempty"`                                                               // 环境标识，非空时作为 topic 后缀 (如 "dev" → topic_dev)
+	MaxDeliveryAttempts int32                  `protobuf:"varint,8,opt,name=max_delivery_attempts,json=maxDeliveryAttempts,proto3" json:"max_delivery_attempts,omitempty"` // 事件总线消费失败的最大投递次数，达到后转入死信 topic，0 时交由 broker 重试 (应小于 broker 的最大重试次数)
+	DeadLetterTopic     string                 `protobuf:"bytes,9,opt,name=dead_letter_topic,json=deadLetterTopic,proto3" json:"dead_letter_topic,omitempty"`              // 死信 topic，默认 %DLQ%<producer_group>
+	// 事件总线按积压和处理耗时在 [min_workers, max_workers] 间自动调整并发处理数，max_workers 为 0 时固定 20 个消费线程
+	MinWorkers    int32                      `protobuf:"varint,10,opt,name=min_workers,json=minWorkers,proto3" json:"min_workers,omitempty"`
+	MaxWorkers    int32                      `protobuf:"varint,11,opt,name=max_workers,json=maxWorkers,proto3" json:"max_workers,omitempty"`
+	TargetLatency *durationpb.Duration       `protobuf:"bytes,12,opt,name=target_latency,json=targetLatency,proto3" json:"target_latency,omitempty"` // 平均处理耗时超过该值时减半并发，为空时不考虑耗时
+	Retry         *RocketMQ_Retry            `protobuf:"bytes,13,opt,name=retry,proto3" json:"retry,omitempty"`
+	AsyncQueue    *RocketMQ_AsyncQueue       `protobuf:"bytes,14,opt,name=async_queue,json=asyncQueue,proto3" json:"async_queue,omitempty"`                                                 // 为空时 SendAsync 直接交给 SDK
+	RateLimit     *RocketMQ_RateLimit        `protobuf:"bytes,15,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`                                                    // 消费限流，为空时不限
+	Topics        map[string]*RocketMQ_Topic `protobuf:"bytes,16,rep,name=topics,proto3" json:"topics,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 按 topic 单独设置消费线程数与本地缓存上限 (重负载的报表 topic 与轻量的通知 topic 并发不同)，未列出的 topic 使用默认值
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RocketMQ) Reset() {
+	*x = RocketMQ{}
+	mi := &file_conf_conf_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RocketMQ) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RocketMQ) ProtoMessage() {}
+
+func (x *RocketMQ) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RocketMQ.ProtoReflect.Descriptor instead.
+func (*RocketMQ) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RocketMQ) GetNameServers() string {
+	if x != nil {
+		return x.NameServers
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetProducerGroup() string {
+	if x != nil {
+		return x.ProducerGroup
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetSendTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.SendTimeout
+	}
+	return nil
+}
+
+func (x *RocketMQ) GetRetryTimes() int32 {
+	if x != nil {
+		return x.RetryTimes
+	}
+	return 0
+}
+
+func (x *RocketMQ) GetAccessKey() string {
+	if x != nil {
+		return x.AccessKey
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetSecretKey() string {
+	if x != nil {
+		return x.SecretKey
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetMaxDeliveryAttempts() int32 {
+	if x != nil {
+		return x.MaxDeliveryAttempts
+	}
+	return 0
+}
+
+func (x *RocketMQ) GetDeadLetterTopic() string {
+	if x != nil {
+		return x.DeadLetterTopic
+	}
+	return ""
+}
+
+func (x *RocketMQ) GetMinWorkers() int32 {
+	if x != nil {
+		return x.MinWorkers
+	}
+	return 0
+}
+
+func (x *RocketMQ) GetMaxWorkers() int32 {
+	if x != nil {
+		return x.MaxWorkers
+	}
+	return 0
+}
+
+func (x *RocketMQ) GetTargetLatency() *durationpb.Duration {
+	if x != nil {
+		return x.TargetLatency
+	}
+	return nil
+}
+
+func (x *RocketMQ) GetRetry() *RocketMQ_Retry {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
+}
+
+func (x *RocketMQ) GetAsyncQueue() *RocketMQ_AsyncQueue {
+	if x != nil {
+		return x.AsyncQueue
+	}
+	return nil
+}
+
+func (x *RocketMQ) GetRateLimit() *RocketMQ_RateLimit {
+	if x != nil {
+		return x.RateLimit
+	}
+	return nil
+}
+
+func (x *RocketMQ) GetTopics() map[string]*RocketMQ_Topic {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+// Nats NATS JetStream 消息配置，配置 url 或 embedded 后替代 RocketMQ 作为事件总线
+type Nats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`                                  // 服务地址 (如 nats://127.0.0.1:4222)，embedded 时忽略
+	Embedded      bool                   `protobuf:"varint,2,opt,name=embedded,proto3" json:"embedded,omitempty"`                       // 在进程内启动 NATS 服务 (本地开发/单机部署)
+	StoreDir      string                 `protobuf:"bytes,3,opt,name=store_dir,json=storeDir,proto3" json:"store_dir,omitempty"`        // embedded 模式的 JetStream 存储目录，为空时使用临时目录
+	Durable       string                 `protobuf:"bytes,4,opt,name=durable,proto3" json:"durable,omitempty"`                          // 持久化消费者名前缀，同名实例共享消费进度
+	MaxDeliver    int32                  `protobuf:"varint,5,opt,name=max_deliver,json=maxDeliver,proto3" json:"max_deliver,omitempty"` // 最大投递次数，默认 16
+	AckWait       *durationpb.Duration   `protobuf:"bytes,6,opt,name=ack_wait,json=ackWait,proto3" json:"ack_wait,omitempty"`           // ack 超时，超时未确认则重投，默认 30s
+	Streams       []*Nats_Stream         `protobuf:"bytes,7,rep,name=streams,proto3" json:"streams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nats) Reset() {
+	*x = Nats{}
+	mi := &file_conf_conf_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nats) ProtoMessage() {}
+
+func (x *Nats) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nats.ProtoReflect.Descriptor instead.
+func (*Nats) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Nats) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Nats) GetEmbedded() bool {
+	if x != nil {
+		return x.Embedded
+	}
+	return false
+}
+
+func (x *Nats) GetStoreDir() string {
+	if x != nil {
+		return x.StoreDir
+	}
+	return ""
+}
+
+func (x *Nats) GetDurable() string {
+	if x != nil {
+		return x.Durable
+	}
+	return ""
+}
+
+func (x *Nats) GetMaxDeliver() int32 {
+	if x != nil {
+		return x.MaxDeliver
+	}
+	return 0
+}
+
+func (x *Nats) GetAckWait() *durationpb.Duration {
+	if x != nil {
+		return x.AckWait
+	}
+	return nil
+}
+
+func (x *Nats) GetStreams() []*Nats_Stream {
+	if x != nil {
+		return x.Streams
+	}
+	return nil
+}
+
+type Server struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Http          *Server_HTTP           `protobuf:"bytes,1,opt,name=http,proto3" json:"http,omitempty"`
+	Grpc          *Server_GRPC           `protobuf:"bytes,2,opt,name=grpc,proto3" json:"grpc,omitempty"`
+	Metadata      *Server_Metadata       `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Admin         *Server_Admin          `protobuf:"bytes,4,opt,name=admin,proto3" json:"admin,omitempty"`
+	Middlewares   []*Server_Middleware   `protobuf:"bytes,5,rep,name=middlewares,proto3" json:"middlewares,omitempty"` // 为空时使用默认链: recovery, metadata, logfields
+	Quota         *Server_Quota          `protobuf:"bytes,6,opt,name=quota,proto3" json:"quota,omitempty"`
+	Metering      *Server_Metering       `protobuf:"bytes,7,opt,name=metering,proto3" json:"metering,omitempty"`
+	Concurrency   *Server_Concurrency    `protobuf:"bytes,8,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	Gateway       *Server_Gateway        `protobuf:"bytes,9,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server) Reset() {
+	*x = Server{}
+	mi := &file_conf_conf_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server) ProtoMessage() {}
+
+func (x *Server) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server.ProtoReflect.Descriptor instead.
+func (*Server) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Server) GetHttp() *Server_HTTP {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *Server) GetGrpc() *Server_GRPC {
+	if x != nil {
+		return x.Grpc
+	}
+	return nil
+}
+
+func (x *Server) GetMetadata() *Server_Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Server) GetAdmin() *Server_Admin {
+	if x != nil {
+		return x.Admin
+	}
+	return nil
+}
+
+func (x *Server) GetMiddlewares() []*Server_Middleware {
+	if x != nil {
+		return x.Middlewares
+	}
+	return nil
+}
+
+func (x *Server) GetQuota() *Server_Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *Server) GetMetering() *Server_Metering {
+	if x != nil {
+		return x.Metering
+	}
+	return nil
+}
+
+func (x *Server) GetConcurrency() *Server_Concurrency {
+	if x != nil {
+		return x.Concurrency
+	}
+	return nil
+}
+
+func (x *Server) GetGateway() *Server_Gateway {
+	if x != nil {
+		return x.Gateway
+	}
+	return nil
+}
+
+type Data struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Database      *Data_Database         `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Redis         *Data_Redis            `protobuf:"bytes,2,opt,name=redis,proto3" json:"redis,omitempty"`
+	Maintenance   *Data_Maintenance      `protobuf:"bytes,3,opt,name=maintenance,proto3" json:"maintenance,omitempty"`
+	Analytics     *Data_Database         `protobuf:"bytes,4,opt,name=analytics,proto3" json:"analytics,omitempty"` // 可选的分析库 (通常 driver: clickhouse)，写入事件/统计数据，不参与事务与租户路由
+	MqHeartbeat   *Data_MQHeartbeat      `protobuf:"bytes,5,opt,name=mq_heartbeat,json=mqHeartbeat,proto3" json:"mq_heartbeat,omitempty"`
+	Retention     *Data_Retention        `protobuf:"bytes,6,opt,name=retention,proto3" json:"retention,omitempty"`
+	StateMachine  *Data_StateMachine     `protobuf:"bytes,7,opt,name=state_machine,json=stateMachine,proto3" json:"state_machine,omitempty"`
+	Counter       *Data_Counter          `protobuf:"bytes,8,opt,name=counter,proto3" json:"counter,omitempty"`
+	Jobs          *Data_Jobs             `protobuf:"bytes,9,opt,name=jobs,proto3" json:"jobs,omitempty"`
+	Codecs        map[string]string      `protobuf:"bytes,10,rep,name=codecs,proto3" json:"codecs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 按用途选择序列化格式 (pkg/codec)：cache (下游响应缓存，默认 protobuf)、eventbus (事件载荷，默认 json)、taskqueue (timer.Tasks 任务载荷，默认 json)、session (session.Store 会话，默认 json) → json | protobuf | gob | msgpack | avro | 自行注册的 codec
+	Outbox        *Data_Outbox           `protobuf:"bytes,11,opt,name=outbox,proto3" json:"outbox,omitempty"`
+	Rules         *Data_Rules            `protobuf:"bytes,12,opt,name=rules,proto3" json:"rules,omitempty"`
+	Degradation   *Data_Degradation      `protobuf:"bytes,13,opt,name=degradation,proto3" json:"degradation,omitempty"`
+	Timer         *Data_Timer            `protobuf:"bytes,14,opt,name=timer,proto3" json:"timer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data) Reset() {
+	*x = Data{}
+	mi := &file_conf_conf_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data) ProtoMessage() {}
+
+func (x *Data) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data.ProtoReflect.Descriptor instead.
+func (*Data) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Data) GetDatabase() *Data_Database {
+	if x != nil {
+		return x.Database
+	}
+	return nil
+}
+
+func (x *Data) GetRedis() *Data_Redis {
+	if x != nil {
+		return x.Redis
+	}
+	return nil
+}
+
+func (x *Data) GetMaintenance() *Data_Maintenance {
+	if x != nil {
+		return x.Maintenance
+	}
+	return nil
+}
+
+func (x *Data) GetAnalytics() *Data_Database {
+	if x != nil {
+		return x.Analytics
+	}
+	return nil
+}
+
+func (x *Data) GetMqHeartbeat() *Data_MQHeartbeat {
+	if x != nil {
+		return x.MqHeartbeat
+	}
+	return nil
+}
+
+func (x *Data) GetRetention() *Data_Retention {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *Data) GetStateMachine() *Data_StateMachine {
+	if x != nil {
+		return x.StateMachine
+	}
+	return nil
+}
+
+func (x *Data) GetCounter() *Data_Counter {
+	if x != nil {
+		return x.Counter
+	}
+	return nil
+}
+
+func (x *Data) GetJobs() *Data_Jobs {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *Data) GetCodecs() map[string]string {
+	if x != nil {
+		return x.Codecs
+	}
+	return nil
+}
+
+func (x *Data) GetOutbox() *Data_Outbox {
+	if x != nil {
+		return x.Outbox
+	}
+	return nil
+}
+
+func (x *Data) GetRules() *Data_Rules {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *Data) GetDegradation() *Data_Degradation {
+	if x != nil {
+		return x.Degradation
+	}
+	return nil
+}
+
+func (x *Data) GetTimer() *Data_Timer {
+	if x != nil {
+		return x.Timer
+	}
+	return nil
+}
+
+// Notifier 通知渠道
+type Alert_Notifier struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`     // webhook, dingtalk, feishu
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`       // Webhook 地址
+	Secret        string                 `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"` // 加签密钥 (dingtalk/feishu，可选)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alert_Notifier) Reset() {
+	*x = Alert_Notifier{}
+	mi := &file_conf_conf_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert_Notifier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert_Notifier) ProtoMessage() {}
+
+func (x *Alert_Notifier) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alert_Notifier.ProtoReflect.Descriptor instead.
+func (*Alert_Notifier) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{5, 0}
+}
+
+func (x *Alert_Notifier) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Alert_Notifier) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Alert_Notifier) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+// CacheRule 下游 gRPC 方法的响应缓存，仅用于幂等读接口
+type Client_CacheRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"` // 完整方法名，如 /user.v1.User/GetUser
+	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	KeyFields     []string               `protobuf:"bytes,3,rep,name=key_fields,json=keyFields,proto3" json:"key_fields,omitempty"` // 组成缓存键的请求字段，为空时使用整个请求
+	Vary          []string               `protobuf:"bytes,4,rep,name=vary,proto3" json:"vary,omitempty"`                            // 区分缓存的 metadata 键，如 x-md-tenant
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client_CacheRule) Reset() {
+	*x = Client_CacheRule{}
+	mi := &file_conf_conf_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client_CacheRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client_CacheRule) ProtoMessage() {}
+
+func (x *Client_CacheRule) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client_CacheRule.ProtoReflect.Descriptor instead.
+func (*Client_CacheRule) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *Client_CacheRule) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Client_CacheRule) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *Client_CacheRule) GetKeyFields() []string {
+	if x != nil {
+		return x.KeyFields
+	}
+	return nil
+}
+
+func (x *Client_CacheRule) GetVary() []string {
+	if x != nil {
+		return x.Vary
+	}
+	return nil
+}
+
+// HedgeRule 幂等读接口的对冲请求: 首次请求超过延迟未返回时再发一次，取先返回者
+type Client_HedgeRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`           // 完整方法名
+	Delay         *durationpb.Duration   `protobuf:"bytes,2,opt,name=delay,proto3" json:"delay,omitempty"`             // 固定延迟，同时作为 percentile 延迟的下限
+	Percentile    float64                `protobuf:"fixed64,3,opt,name=percentile,proto3" json:"percentile,omitempty"` // 按近期延迟分位数 (如 0.95) 触发，0 表示只用 delay
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client_HedgeRule) Reset() {
+	*x = Client_HedgeRule{}
+	mi := &file_conf_conf_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client_HedgeRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client_HedgeRule) ProtoMessage() {}
+
+func (x *Client_HedgeRule) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client_HedgeRule.ProtoReflect.Descriptor instead.
+func (*Client_HedgeRule) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{6, 1}
+}
+
+func (x *Client_HedgeRule) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Client_HedgeRule) GetDelay() *durationpb.Duration {
+	if x != nil {
+		return x.Delay
+	}
+	return nil
+}
+
+func (x *Client_HedgeRule) GetPercentile() float64 {
+	if x != nil {
+		return x.Percentile
+	}
+	return 0
+}
+
+// Endpoint 下游服务的固定地址，跳过服务发现，如本地开发时指向 cmd/stubserver
+type Client_Endpoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Grpc          string                 `protobuf:"bytes,1,opt,name=grpc,proto3" json:"grpc,omitempty"` // host:port，为空时仍走服务发现
+	Http          string                 `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"` // host:port 或 http(s)://host:port
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client_Endpoint) Reset() {
+	*x = Client_Endpoint{}
+	mi := &file_conf_conf_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client_Endpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client_Endpoint) ProtoMessage() {}
+
+func (x *Client_Endpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client_Endpoint.ProtoReflect.Descriptor instead.
+func (*Client_Endpoint) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{6, 2}
+}
+
+func (x *Client_Endpoint) GetGrpc() string {
+	if x != nil {
+		return x.Grpc
+	}
+	return ""
+}
+
+func (x *Client_Endpoint) GetHttp() string {
+	if x != nil {
+		return x.Http
+	}
+	return ""
+}
+
+// Retry 消费失败后按指数退避延迟重新投递 (ChangeInvisibleDuration)，避免下游短暂故障时立即重试
+type RocketMQ_Retry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MaxAttempts    int32                  `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`         // 按退避延迟的投递次数，之后交由 broker 重试策略，0 不限
+	InitialBackoff *durationpb.Duration   `protobuf:"bytes,2,opt,name=initial_backoff,json=initialBackoff,proto3" json:"initial_backoff,omitempty"` // 首次失败后的延迟，默认 1s
+	MaxBackoff     *durationpb.Duration   `protobuf:"bytes,3,opt,name=max_backoff,json=maxBackoff,proto3" json:"max_backoff,omitempty"`             // 延迟上限，默认 5m
+	Multiplier     float64                `protobuf:"fixed64,4,opt,name=multiplier,proto3" json:"multiplier,omitempty"`                             // 每次失败后延迟的倍数，默认 2
+	Jitter         float64                `protobuf:"fixed64,5,opt,name=jitter,proto3" json:"jitter,omitempty"`                                     // 延迟的随机浮动比例，如 0.2 为 ±20%
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RocketMQ_Retry) Reset() {
+	*x = RocketMQ_Retry{}
+	mi := &file_conf_conf_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RocketMQ_Retry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RocketMQ_Retry) ProtoMessage() {}
+
+func (x *RocketMQ_Retry) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RocketMQ_Retry.ProtoReflect.Descriptor instead.
+func (*RocketMQ_Retry) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{7, 0}
+}
+
+func (x *RocketMQ_Retry) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RocketMQ_Retry) GetInitialBackoff() *durationpb.Duration {
+	if x != nil {
+		return x.InitialBackoff
+	}
+	return nil
+}
+
+func (x *RocketMQ_Retry) GetMaxBackoff() *durationpb.Duration {
+	if x != nil {
+		return x.MaxBackoff
+	}
+	return nil
+}
+
+func (x *RocketMQ_Retry) GetMultiplier() float64 {
+	if x != nil {
+		return x.Multiplier
+	}
+	return 0
+}
+
+func (x *RocketMQ_Retry) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+// AsyncQueue SendAsync 的有界发送队列，由固定数量的 worker 发送，平滑突发流量
+type RocketMQ_AsyncQueue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int32                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`                                    // 队列容量，默认 1024
+	Workers       int32                  `protobuf:"varint,2,opt,name=workers,proto3" json:"workers,omitempty"`                              // 发送 worker 数，默认 4
+	Overflow      string                 `protobuf:"bytes,3,opt,name=overflow,proto3" json:"overflow,omitempty"`                             // 队列满时: block (默认，等待至 ctx 结束) | drop (丢弃最早排队的消息) | error (立即回调 ErrQueueFull)
+	FlushTimeout  *durationpb.Duration   `protobuf:"bytes,4,opt,name=flush_timeout,json=flushTimeout,proto3" json:"flush_timeout,omitempty"` // 关闭时发送剩余消息的最长时间，默认 10s，之后的消息回调 ErrProducerClosed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RocketMQ_AsyncQueue) Reset() {
+	*x = RocketMQ_AsyncQueue{}
+	mi := &file_conf_conf_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RocketMQ_AsyncQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RocketMQ_AsyncQueue) ProtoMessage() {}
+
+func (x *RocketMQ_AsyncQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RocketMQ_AsyncQueue.ProtoReflect.Descriptor instead.
+func (*RocketMQ_AsyncQueue) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{7, 1}
+}
+
+func (x *RocketMQ_AsyncQueue) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RocketMQ_AsyncQueue) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *RocketMQ_AsyncQueue) GetOverflow() string {
+	if x != nil {
+		return x.Overflow
+	}
+	return ""
+}
+
+func (x *RocketMQ_AsyncQueue) GetFlushTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.FlushTimeout
+	}
+	return nil
+}
+
+// RateLimit 消费限流 (令牌桶)，积压回放时保护下游数据库
+type RocketMQ_RateLimit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PerSecond     float64                `protobuf:"fixed64,1,opt,name=per_second,json=perSecond,proto3" json:"per_second,omitempty"` // 每秒处理的消息数，0 不限
+	Burst         int32                  `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`                           // 可突发处理的消息数，默认 1
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RocketMQ_RateLimit) Reset() {
+	*x = RocketMQ_RateLimit{}
+	mi := &file_conf_conf_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RocketMQ_RateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RocketMQ_RateLimit) ProtoMessage() {}
+
+func (x *RocketMQ_RateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RocketMQ_RateLimit.ProtoReflect.Descriptor instead.
+func (*RocketMQ_RateLimit) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{7, 2}
+}
+
+func (x *RocketMQ_RateLimit) GetPerSecond() float64 {
+	if x != nil {
+		return x.PerSecond
+	}
+	return 0
+}
+
+func (x *RocketMQ_RateLimit) GetBurst() int32 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+// Topic 单个 topic 的推送消费设置，SDK 按客户端生效，因此每个列出的 topic 由独立的客户端在独立的消费组 <producer_group>-<topic> 中消费 (同一消费组的客户端必须订阅相同的 topic)
+type RocketMQ_Topic struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ConsumptionThreads int32                  `protobuf:"varint,1,opt,name=consumption_threads,json=consumptionThreads,proto3" json:"consumption_threads,omitempty"` // 消费线程数，默认 20
+	MaxCacheMessages   int32                  `protobuf:"varint,2,opt,name=max_cache_messages,json=maxCacheMessages,proto3" json:"max_cache_messages,omitempty"`     // 本地缓存的消息数上限，默认 1024
+	MaxCacheBytes      int64                  `protobuf:"varint,3,opt,name=max_cache_bytes,json=maxCacheBytes,proto3" json:"max_cache_bytes,omitempty"`              // 本地缓存的消息字节数上限，默认 64MiB
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RocketMQ_Topic) Reset() {
+	*x = RocketMQ_Topic{}
+	mi := &file_conf_conf_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RocketMQ_Topic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RocketMQ_Topic) ProtoMessage() {}
+
+func (x *RocketMQ_Topic) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RocketMQ_Topic.ProtoReflect.Descriptor instead.
+func (*RocketMQ_Topic) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{7, 3}
+}
+
+func (x *RocketMQ_Topic) GetConsumptionThreads() int32 {
+	if x != nil {
+		return x.ConsumptionThreads
+	}
+	return 0
+}
+
+func (x *RocketMQ_Topic) GetMaxCacheMessages() int32 {
+	if x != nil {
+		return x.MaxCacheMessages
+	}
+	return 0
+}
+
+func (x *RocketMQ_Topic) GetMaxCacheBytes() int64 {
+	if x != nil {
+		return x.MaxCacheBytes
+	}
+	return 0
+}
+
+// Stream JetStream 流定义，启动时创建或更新
+type Nats_Stream struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                   // 流名称
+	Subjects      []string               `protobuf:"bytes,2,rep,name=subjects,proto3" json:"subjects,omitempty"`           // 绑定的 subject (支持 * 和 > 通配符)
+	MaxAge        *durationpb.Duration   `protobuf:"bytes,3,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"` // 消息保留时长，为空时不限
+	Replicas      int32                  `protobuf:"varint,4,opt,name=replicas,proto3" json:"replicas,omitempty"`          // 副本数，默认 1
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nats_Stream) Reset() {
+	*x = Nats_Stream{}
+	mi := &file_conf_conf_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nats_Stream) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nats_Stream) ProtoMessage() {}
+
+func (x *Nats_Stream) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nats_Stream.ProtoReflect.Descriptor instead.
+func (*Nats_Stream) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{8, 0}
+}
+
+func (x *Nats_Stream) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Nats_Stream) GetSubjects() []string {
+	if x != nil {
+		return x.Subjects
+	}
+	return nil
+}
+
+func (x *Nats_Stream) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+func (x *Nats_Stream) GetReplicas() int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return 0
+}
+
+// Metadata 跨协议透传的请求头/metadata 白名单
+type Server_Metadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PropagateKeys []string               `protobuf:"bytes,1,rep,name=propagate_keys,json=propagateKeys,proto3" json:"propagate_keys,omitempty"` // 透传键 (前缀匹配，不区分大小写)，为空时使用默认白名单
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Metadata) Reset() {
+	*x = Server_Metadata{}
+	mi := &file_conf_conf_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Metadata) ProtoMessage() {}
+
+func (x *Server_Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Metadata.ProtoReflect.Descriptor instead.
+func (*Server_Metadata) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 0}
+}
+
+func (x *Server_Metadata) GetPropagateKeys() []string {
+	if x != nil {
+		return x.PropagateKeys
+	}
+	return nil
+}
+
+type Server_HTTP struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
+	Timeout       *durationpb.Duration   `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	GrpcWeb       *Server_HTTP_GRPCWeb   `protobuf:"bytes,4,opt,name=grpc_web,json=grpcWeb,proto3" json:"grpc_web,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_HTTP) Reset() {
+	*x = Server_HTTP{}
+	mi := &file_conf_conf_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_HTTP) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_HTTP) ProtoMessage() {}
+
+func (x *Server_HTTP) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_HTTP.ProtoReflect.Descriptor instead.
+func (*Server_HTTP) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 1}
+}
+
+func (x *Server_HTTP) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *Server_HTTP) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Server_HTTP) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *Server_HTTP) GetGrpcWeb() *Server_HTTP_GRPCWeb {
+	if x != nil {
+		return x.GrpcWeb
+	}
+	return nil
+}
+
+type Server_GRPC struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
+	Timeout       *durationpb.Duration   `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_GRPC) Reset() {
+	*x = Server_GRPC{}
+	mi := &file_conf_conf_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_GRPC) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_GRPC) ProtoMessage() {}
+
+func (x *Server_GRPC) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_GRPC.ProtoReflect.Descriptor instead.
+func (*Server_GRPC) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 2}
+}
+
+func (x *Server_GRPC) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *Server_GRPC) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Server_GRPC) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+// Admin 运维管理端口，独立于业务 HTTP 端口
+type Server_Admin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`           // 为空时不启动 (建议仅监听内网，如 127.0.0.1:8001)
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`         // 访问令牌 (Authorization: Bearer <token>)，为空时读取 ADMIN_TOKEN 环境变量
+	Operators     []*Server_Operator     `protobuf:"bytes,4,rep,name=operators,proto3" json:"operators,omitempty"` // 受限运维账号，token 拥有全部权限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Admin) Reset() {
+	*x = Server_Admin{}
+	mi := &file_conf_conf_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Admin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Admin) ProtoMessage() {}
+
+func (x *Server_Admin) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Admin.ProtoReflect.Descriptor instead.
+func (*Server_Admin) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 3}
+}
+
+func (x *Server_Admin) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *Server_Admin) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Server_Admin) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Server_Admin) GetOperators() []*Server_Operator {
+	if x != nil {
+		return x.Operators
+	}
+	return nil
+}
+
+// Operator 运维账号，permissions 为 runbook 动作名 (cache.flush)、分组通配 (cache.*) 或 *
+type Server_Operator struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Permissions   []string               `protobuf:"bytes,3,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Operator) Reset() {
+	*x = Server_Operator{}
+	mi := &file_conf_conf_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Operator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Operator) ProtoMessage() {}
+
+func (x *Server_Operator) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Operator.ProtoReflect.Descriptor instead.
+func (*Server_Operator) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 4}
+}
+
+func (x *Server_Operator) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Server_Operator) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Server_Operator) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+// Middleware 中间件声明，按顺序组装；selectors 为空时作用于所有路由
+type Server_Middleware struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                                 // 中间件注册名 (recovery, metadata, logging, ratelimit ...)
+	Selectors     []string               `protobuf:"bytes,2,rep,name=selectors,proto3" json:"selectors,omitempty"`                                                                       // 路由选择器: "/pkg.Svc/Method" 精确, "/pkg.Svc/*" 前缀, "re:<regex>" 正则
+	Options       map[string]string      `protobuf:"bytes,3,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 中间件参数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Middleware) Reset() {
+	*x = Server_Middleware{}
+	mi := &file_conf_conf_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Middleware) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Middleware) ProtoMessage() {}
+
+func (x *Server_Middleware) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Middleware.ProtoReflect.Descriptor instead.
+func (*Server_Middleware) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 5}
+}
+
+func (x *Server_Middleware) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Server_Middleware) GetSelectors() []string {
+	if x != nil {
+		return x.Selectors
+	}
+	return nil
+}
+
+func (x *Server_Middleware) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// Quota 按租户 (x-md-tenant) 或 API key (X-Api-Key) 统计请求数/字节数，需在 middlewares 中声明 quota
+type Server_Quota struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	DefaultLimits []*Server_Quota_Limit   `protobuf:"bytes,1,rep,name=default_limits,json=defaultLimits,proto3" json:"default_limits,omitempty"`
+	Subjects      []*Server_Quota_Subject `protobuf:"bytes,2,rep,name=subjects,proto3" json:"subjects,omitempty"`                      // 覆盖 default_limits
+	WarnRatio     float64                 `protobuf:"fixed64,3,opt,name=warn_ratio,json=warnRatio,proto3" json:"warn_ratio,omitempty"` // 用量达到上限的该比例 (如 0.8) 时记录 warn 日志、发出告警，每个周期一次；0 表示关闭
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Quota) Reset() {
+	*x = Server_Quota{}
+	mi := &file_conf_conf_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Quota) ProtoMessage() {}
+
+func (x *Server_Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Quota.ProtoReflect.Descriptor instead.
+func (*Server_Quota) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 6}
+}
+
+func (x *Server_Quota) GetDefaultLimits() []*Server_Quota_Limit {
+	if x != nil {
+		return x.DefaultLimits
+	}
+	return nil
+}
+
+func (x *Server_Quota) GetSubjects() []*Server_Quota_Subject {
+	if x != nil {
+		return x.Subjects
+	}
+	return nil
+}
+
+func (x *Server_Quota) GetWarnRatio() float64 {
+	if x != nil {
+		return x.WarnRatio
+	}
+	return 0
+}
+
+// Metering 计费用量事件，经 outbox 发布；需在 middlewares 中声明 metering
+type Server_Metering struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Topic           string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`                                            // 默认 billing.usage
+	SampleRate      float64                `protobuf:"fixed64,2,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`              // (0,1] 采样率，数量按 1/rate 放大，默认 1
+	AggregateWindow *durationpb.Duration   `protobuf:"bytes,3,opt,name=aggregate_window,json=aggregateWindow,proto3" json:"aggregate_window,omitempty"` // 大于 0 时按 subject+meter 聚合后定期发布
+	Jobs            bool                   `protobuf:"varint,4,opt,name=jobs,proto3" json:"jobs,omitempty"`                                             // 记录后台任务运行时长 (job.seconds)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Server_Metering) Reset() {
+	*x = Server_Metering{}
+	mi := &file_conf_conf_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Metering) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Metering) ProtoMessage() {}
+
+func (x *Server_Metering) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Metering.ProtoReflect.Descriptor instead.
+func (*Server_Metering) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 7}
+}
+
+func (x *Server_Metering) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Server_Metering) GetSampleRate() float64 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *Server_Metering) GetAggregateWindow() *durationpb.Duration {
+	if x != nil {
+		return x.AggregateWindow
+	}
+	return nil
+}
+
+func (x *Server_Metering) GetJobs() bool {
+	if x != nil {
+		return x.Jobs
+	}
+	return false
+}
+
+// Concurrency 高开销路由的并发上限，需在 middlewares 中声明 concurrency
+type Server_Concurrency struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Limits        []*Server_Concurrency_Limit `protobuf:"bytes,1,rep,name=limits,proto3" json:"limits,omitempty"` // 按顺序匹配，首个命中的生效
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Concurrency) Reset() {
+	*x = Server_Concurrency{}
+	mi := &file_conf_conf_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Concurrency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Concurrency) ProtoMessage() {}
+
+func (x *Server_Concurrency) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Concurrency.ProtoReflect.Descriptor instead.
+func (*Server_Concurrency) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 8}
+}
+
+func (x *Server_Concurrency) GetLimits() []*Server_Concurrency_Limit {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+// Gateway 网关模式：按路径前缀反向代理其他已注册 (Nacos) 服务的 HTTP 路由，经过同一中间件链；
+// 供不单独部署网关的小规模部署使用
+type Server_Gateway struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Routes        []*Server_Gateway_Route `protobuf:"bytes,1,rep,name=routes,proto3" json:"routes,omitempty"` // 为空时关闭网关模式
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Gateway) Reset() {
+	*x = Server_Gateway{}
+	mi := &file_conf_conf_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Gateway) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Gateway) ProtoMessage() {}
+
+func (x *Server_Gateway) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Gateway.ProtoReflect.Descriptor instead.
+func (*Server_Gateway) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 9}
+}
+
+func (x *Server_Gateway) GetRoutes() []*Server_Gateway_Route {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+// GRPCWeb 在 HTTP 端口上接收浏览器的 gRPC-Web 请求，转交 gRPC 服务 (共用其中间件)，无需 Envoy 转换
+type Server_HTTP_GRPCWeb struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Enabled        bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	AllowedOrigins []string               `protobuf:"bytes,2,rep,name=allowed_origins,json=allowedOrigins,proto3" json:"allowed_origins,omitempty"` // 允许跨域调用的页面源，如 https://app.example.com，* 表示任意；为空时仅同源
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Server_HTTP_GRPCWeb) Reset() {
+	*x = Server_HTTP_GRPCWeb{}
+	mi := &file_conf_conf_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_HTTP_GRPCWeb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_HTTP_GRPCWeb) ProtoMessage() {}
+
+func (x *Server_HTTP_GRPCWeb) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_HTTP_GRPCWeb.ProtoReflect.Descriptor instead.
+func (*Server_HTTP_GRPCWeb) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 1, 0}
+}
+
+func (x *Server_HTTP_GRPCWeb) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Server_HTTP_GRPCWeb) GetAllowedOrigins() []string {
+	if x != nil {
+		return x.AllowedOrigins
+	}
+	return nil
+}
+
+type Server_Quota_Limit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Window        string                 `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`      // daily | monthly (UTC 自然日/月)
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"` // 0 表示不限
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`       // 请求+响应 protobuf 字节数，0 表示不限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Quota_Limit) Reset() {
+	*x = Server_Quota_Limit{}
+	mi := &file_conf_conf_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Quota_Limit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Quota_Limit) ProtoMessage() {}
+
+func (x *Server_Quota_Limit) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Quota_Limit.ProtoReflect.Descriptor instead.
+func (*Server_Quota_Limit) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 6, 0}
+}
+
+func (x *Server_Quota_Limit) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *Server_Quota_Limit) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *Server_Quota_Limit) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+type Server_Quota_Subject struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // tenant:<id> 或 key:<api key>
+	Limits        []*Server_Quota_Limit  `protobuf:"bytes,2,rep,name=limits,proto3" json:"limits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Quota_Subject) Reset() {
+	*x = Server_Quota_Subject{}
+	mi := &file_conf_conf_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Quota_Subject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Quota_Subject) ProtoMessage() {}
+
+func (x *Server_Quota_Subject) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Quota_Subject.ProtoReflect.Descriptor instead.
+func (*Server_Quota_Subject) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 6, 1}
+}
+
+func (x *Server_Quota_Subject) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Server_Quota_Subject) GetLimits() []*Server_Quota_Limit {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type Server_Concurrency_Limit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Selectors     []string               `protobuf:"bytes,1,rep,name=selectors,proto3" json:"selectors,omitempty"`                           // 路由选择器，语法同 Middleware.selectors；每个路由独立计数
+	Max           int32                  `protobuf:"varint,2,opt,name=max,proto3" json:"max,omitempty"`                                      // 同时处理的请求数上限
+	QueueTimeout  *durationpb.Duration   `protobuf:"bytes,3,opt,name=queue_timeout,json=queueTimeout,proto3" json:"queue_timeout,omitempty"` // 排队等待时长，超时返回 429，为空时立即拒绝
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Concurrency_Limit) Reset() {
+	*x = Server_Concurrency_Limit{}
+	mi := &file_conf_conf_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Concurrency_Limit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Concurrency_Limit) ProtoMessage() {}
+
+func (x *Server_Concurrency_Limit) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Concurrency_Limit.ProtoReflect.Descriptor instead.
+func (*Server_Concurrency_Limit) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 8, 0}
+}
+
+func (x *Server_Concurrency_Limit) GetSelectors() []string {
+	if x != nil {
+		return x.Selectors
+	}
+	return nil
+}
+
+func (x *Server_Concurrency_Limit) GetMax() int32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Server_Concurrency_Limit) GetQueueTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.QueueTimeout
+	}
+	return nil
+}
+
+type Server_Gateway_Route struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`                               // 路径前缀，如 /orders；最长前缀优先
+	Service       string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`                             // 注册的服务名，如 order-service，也可使用 client.endpoints 固定地址
+	StripPrefix   bool                   `protobuf:"varint,3,opt,name=strip_prefix,json=stripPrefix,proto3" json:"strip_prefix,omitempty"` // 转发前去掉前缀，/orders/1 转发为 /1
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server_Gateway_Route) Reset() {
+	*x = Server_Gateway_Route{}
+	mi := &file_conf_conf_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server_Gateway_Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server_Gateway_Route) ProtoMessage() {}
+
+func (x *Server_Gateway_Route) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server_Gateway_Route.ProtoReflect.Descriptor instead.
+func (*Server_Gateway_Route) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{9, 9, 0}
+}
+
+func (x *Server_Gateway_Route) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *Server_Gateway_Route) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Server_Gateway_Route) GetStripPrefix() bool {
+	if x != nil {
+		return x.StripPrefix
+	}
+	return false
+}
+
+type Data_Database struct {
+	state              protoimpl.MessageState  `protogen:"open.v1"`
+	Username           string                  `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password           string                  `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Host               string                  `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
+	Port               int64                   `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	DbName             string                  `protobuf:"bytes,5,opt,name=db_name,json=dbName,proto3" json:"db_name,omitempty"`
+	MaxIdleConns       int64                   `protobuf:"varint,6,opt,name=max_idle_conns,json=maxIdleConns,proto3" json:"max_idle_conns,omitempty"`
+	MaxOpenConns       int64                   `protobuf:"varint,7,opt,name=max_open_conns,json=maxOpenConns,proto3" json:"max_open_conns,omitempty"`
+	DbCharset          string                  `protobuf:"bytes,8,opt,name=db_charset,json=dbCharset,proto3" json:"db_charset,omitempty"`
+	ConnMaxLifetime    *durationpb.Duration    `protobuf:"bytes,9,opt,name=conn_max_lifetime,json=connMaxLifetime,proto3" json:"conn_max_lifetime,omitempty"`
+	ConnMaxIdleTime    *durationpb.Duration    `protobuf:"bytes,10,opt,name=conn_max_idle_time,json=connMaxIdleTime,proto3" json:"conn_max_idle_time,omitempty"`
+	LogLevel           string                  `protobuf:"bytes,11,opt,name=log_level,json=logLevel,proto3" json:"log_level,omitempty"`                              // gorm 日志级别: silent | error | warn | info，默认 warn
+	SlowThreshold      *durationpb.Duration    `protobuf:"bytes,12,opt,name=slow_threshold,json=slowThreshold,proto3" json:"slow_threshold,omitempty"`               // 慢查询阈值，默认 200ms，超过时以 warn 级别记录 SQL
+	AutoMigrate        bool                    `protobuf:"varint,13,opt,name=auto_migrate,json=autoMigrate,proto3" json:"auto_migrate,omitempty"`                    // 启动时对 internal/data/models 中的模型执行 AutoMigrate (无需 atlas 的小服务)
+	DevAutoMigrate     bool                    `protobuf:"varint,14,opt,name=dev_auto_migrate,json=devAutoMigrate,proto3" json:"dev_auto_migrate,omitempty"`         // 仅 RUN_MODE=dev 时执行 AutoMigrate，本地调整模型无需每次运行 atlas
+	Tenants            []*Data_Database_Tenant `protobuf:"bytes,15,rep,name=tenants,proto3" json:"tenants,omitempty"`                                                // 为空时不做租户路由
+	TenantIdleTimeout  *durationpb.Duration    `protobuf:"bytes,16,opt,name=tenant_idle_timeout,json=tenantIdleTimeout,proto3" json:"tenant_idle_timeout,omitempty"` // 租户连接空闲超过该时长后关闭，默认 10m
+	Tls                *Data_Database_TLS      `protobuf:"bytes,17,opt,name=tls,proto3" json:"tls,omitempty"`
+	QueryTimeout       *durationpb.Duration    `protobuf:"bytes,18,opt,name=query_timeout,json=queryTimeout,proto3" json:"query_timeout,omitempty"`                        // 调用方未设置 deadline 时每条 SQL 的超时，为空时不限制
+	Driver             string                  `protobuf:"bytes,19,opt,name=driver,proto3" json:"driver,omitempty"`                                                        // mysql | clickhouse，默认 mysql
+	EncryptionKeys     string                  `protobuf:"bytes,20,opt,name=encryption_keys,json=encryptionKeys,proto3" json:"encryption_keys,omitempty"`                  // 字段加密密钥 "id:base64key,..."，第一个用于加密，其余仅解密 (轮换)；为空时读取环境变量 ORM_ENCRYPTION_KEYS
+	Audit              bool                    `protobuf:"varint,21,opt,name=audit,proto3" json:"audit,omitempty"`                                                         // 将 create/update/delete 的前后差异写入 audit_logs，操作人取自 context
+	AuditTables        []string                `protobuf:"bytes,22,rep,name=audit_tables,json=auditTables,proto3" json:"audit_tables,omitempty"`                           // 审计的表，为空时审计所有表
+	DryRun             bool                    `protobuf:"varint,23,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                         // 只记录写操作 (create/update/delete/Exec) 的 SQL 而不执行，读操作照常执行；用于在预发分析任务会写入什么
+	PrepareStmt        bool                    `protobuf:"varint,24,opt,name=prepare_stmt,json=prepareStmt,proto3" json:"prepare_stmt,omitempty"`                          // 缓存预编译语句，每个连接只解析一次热点 SQL (仅 MySQL)
+	PrepareStmtMaxSize int64                   `protobuf:"varint,25,opt,name=prepare_stmt_max_size,json=prepareStmtMaxSize,proto3" json:"prepare_stmt_max_size,omitempty"` // 缓存的语句数上限，按 LRU 淘汰，为空时不限制
+	PrepareStmtTtl     *durationpb.Duration    `protobuf:"bytes,26,opt,name=prepare_stmt_ttl,json=prepareStmtTtl,proto3" json:"prepare_stmt_ttl,omitempty"`                // 语句闲置超过该时长后关闭，为空时保留到连接关闭
+	DevSeed            bool                    `protobuf:"varint,27,opt,name=dev_seed,json=devSeed,proto3" json:"dev_seed,omitempty"`                                      // 仅 RUN_MODE=dev 时启动时执行 orm.RegisterSeeder 注册的种子数据，每个数据库只执行一次
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Data_Database) Reset() {
+	*x = Data_Database{}
+	mi := &file_conf_conf_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Database) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Database) ProtoMessage() {}
+
+func (x *Data_Database) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Database.ProtoReflect.Descriptor instead.
+func (*Data_Database) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 0}
+}
+
+func (x *Data_Database) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Data_Database) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Data_Database) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Data_Database) GetPort() int64 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Data_Database) GetDbName() string {
+	if x != nil {
+		return x.DbName
+	}
+	return ""
+}
+
+func (x *Data_Database) GetMaxIdleConns() int64 {
+	if x != nil {
+		return x.MaxIdleConns
+	}
+	return 0
+}
+
+func (x *Data_Database) GetMaxOpenConns() int64 {
+	if x != nil {
+		return x.MaxOpenConns
+	}
+	return 0
+}
+
+func (x *Data_Database) GetDbCharset() string {
+	if x != nil {
+		return x.DbCharset
+	}
+	return ""
+}
+
+func (x *Data_Database) GetConnMaxLifetime() *durationpb.Duration {
+	if x != nil {
+		return x.ConnMaxLifetime
+	}
+	return nil
+}
+
+func (x *Data_Database) GetConnMaxIdleTime() *durationpb.Duration {
+	if x != nil {
+		return x.ConnMaxIdleTime
+	}
+	return nil
+}
+
+func (x *Data_Database) GetLogLevel() string {
+	if x != nil {
+		return x.LogLevel
+	}
+	return ""
+}
+
+func (x *Data_Database) GetSlowThreshold() *durationpb.Duration {
+	if x != nil {
+		return x.SlowThreshold
+	}
+	return nil
+}
+
+func (x *Data_Database) GetAutoMigrate() bool {
+	if x != nil {
+		return x.AutoMigrate
+	}
+	return false
+}
+
+func (x *Data_Database) GetDevAutoMigrate() bool {
+	if x != nil {
+		return x.DevAutoMigrate
+	}
+	return false
+}
+
+func (x *Data_Database) GetTenants() []*Data_Database_Tenant {
+	if x != nil {
+		return x.Tenants
+	}
+	return nil
+}
+
+func (x *Data_Database) GetTenantIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.TenantIdleTimeout
+	}
+	return nil
+}
+
+func (x *Data_Database) GetTls() *Data_Database_TLS {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *Data_Database) GetQueryTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.QueryTimeout
+	}
+	return nil
+}
+
+func (x *Data_Database) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+func (x *Data_Database) GetEncryptionKeys() string {
+	if x != nil {
+		return x.EncryptionKeys
+	}
+	return ""
+}
+
+func (x *Data_Database) GetAudit() bool {
+	if x != nil {
+		return x.Audit
+	}
+	return false
+}
+
+func (x *Data_Database) GetAuditTables() []string {
+	if x != nil {
+		return x.AuditTables
+	}
+	return nil
+}
+
+func (x *Data_Database) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *Data_Database) GetPrepareStmt() bool {
+	if x != nil {
+		return x.PrepareStmt
+	}
+	return false
+}
+
+func (x *Data_Database) GetPrepareStmtMaxSize() int64 {
+	if x != nil {
+		return x.PrepareStmtMaxSize
+	}
+	return 0
+}
+
+func (x *Data_Database) GetPrepareStmtTtl() *durationpb.Duration {
+	if x != nil {
+		return x.PrepareStmtTtl
+	}
+	return nil
+}
+
+func (x *Data_Database) GetDevSeed() bool {
+	if x != nil {
+		return x.DevSeed
+	}
+	return false
+}
+
+type Data_Redis struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
+	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	Db            int32                  `protobuf:"varint,4,opt,name=db,proto3" json:"db,omitempty"`
+	DialTimeout   *durationpb.Duration   `protobuf:"bytes,5,opt,name=dial_timeout,json=dialTimeout,proto3" json:"dial_timeout,omitempty"`
+	ReadTimeout   *durationpb.Duration   `protobuf:"bytes,6,opt,name=read_timeout,json=readTimeout,proto3" json:"read_timeout,omitempty"`
+	WriteTimeout  *durationpb.Duration   `protobuf:"bytes,7,opt,name=write_timeout,json=writeTimeout,proto3" json:"write_timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_Redis) Reset() {
+	*x = Data_Redis{}
+	mi := &file_conf_conf_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Redis) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Redis) ProtoMessage() {}
+
+func (x *Data_Redis) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Redis.ProtoReflect.Descriptor instead.
+func (*Data_Redis) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 1}
+}
+
+func (x *Data_Redis) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *Data_Redis) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Data_Redis) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Data_Redis) GetDb() int32 {
+	if x != nil {
+		return x.Db
+	}
+	return 0
+}
+
+func (x *Data_Redis) GetDialTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.DialTimeout
+	}
+	return nil
+}
+
+func (x *Data_Redis) GetReadTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.ReadTimeout
+	}
+	return nil
+}
+
+func (x *Data_Redis) GetWriteTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.WriteTimeout
+	}
+	return nil
+}
+
+// Maintenance 数据层维护任务，各项独立开启，结果写入日志与 maintenance_findings 指标
+type Data_Maintenance struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RedisTtlAudit   *Data_Maintenance_Task `protobuf:"bytes,1,opt,name=redis_ttl_audit,json=redisTtlAudit,proto3" json:"redis_ttl_audit,omitempty"`       // 统计未设置 TTL 的 key
+	RedisBigKeys    *Data_Maintenance_Task `protobuf:"bytes,2,opt,name=redis_big_keys,json=redisBigKeys,proto3" json:"redis_big_keys,omitempty"`          // 报告 MEMORY USAGE 超过 big_key_bytes 的 key
+	MysqlAnalyze    *Data_Maintenance_Task `protobuf:"bytes,3,opt,name=mysql_analyze,json=mysqlAnalyze,proto3" json:"mysql_analyze,omitempty"`            // ANALYZE TABLE 刷新索引统计信息
+	MysqlLongTx     *Data_Maintenance_Task `protobuf:"bytes,4,opt,name=mysql_long_tx,json=mysqlLongTx,proto3" json:"mysql_long_tx,omitempty"`             // 报告执行时间超过 long_tx_threshold 的事务
+	RedisMatch      string                 `protobuf:"bytes,5,opt,name=redis_match,json=redisMatch,proto3" json:"redis_match,omitempty"`                  // SCAN 匹配模式，默认 *
+	RedisScanLimit  int64                  `protobuf:"varint,6,opt,name=redis_scan_limit,json=redisScanLimit,proto3" json:"redis_scan_limit,omitempty"`   // 每次最多扫描的 key 数，默认 100000
+	BigKeyBytes     int64                  `protobuf:"varint,7,opt,name=big_key_bytes,json=bigKeyBytes,proto3" json:"big_key_bytes,omitempty"`            // 默认 1MB
+	AnalyzeTables   []string               `protobuf:"bytes,8,rep,name=analyze_tables,json=analyzeTables,proto3" json:"analyze_tables,omitempty"`         // 为空时分析当前库所有表
+	LongTxThreshold *durationpb.Duration   `protobuf:"bytes,9,opt,name=long_tx_threshold,json=longTxThreshold,proto3" json:"long_tx_threshold,omitempty"` // 默认 60s
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Data_Maintenance) Reset() {
+	*x = Data_Maintenance{}
+	mi := &file_conf_conf_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Maintenance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Maintenance) ProtoMessage() {}
+
+func (x *Data_Maintenance) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Maintenance.ProtoReflect.Descriptor instead.
+func (*Data_Maintenance) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 2}
+}
+
+func (x *Data_Maintenance) GetRedisTtlAudit() *Data_Maintenance_Task {
+	if x != nil {
+		return x.RedisTtlAudit
+	}
+	return nil
+}
+
+func (x *Data_Maintenance) GetRedisBigKeys() *Data_Maintenance_Task {
+	if x != nil {
+		return x.RedisBigKeys
+	}
+	return nil
+}
+
+func (x *Data_Maintenance) GetMysqlAnalyze() *Data_Maintenance_Task {
+	if x != nil {
+		return x.MysqlAnalyze
+	}
+	return nil
+}
+
+func (x *Data_Maintenance) GetMysqlLongTx() *Data_Maintenance_Task {
+	if x != nil {
+		return x.MysqlLongTx
+	}
+	return nil
+}
+
+func (x *Data_Maintenance) GetRedisMatch() string {
+	if x != nil {
+		return x.RedisMatch
+	}
+	return ""
+}
+
+func (x *Data_Maintenance) GetRedisScanLimit() int64 {
+	if x != nil {
+		return x.RedisScanLimit
+	}
+	return 0
+}
+
+func (x *Data_Maintenance) GetBigKeyBytes() int64 {
+	if x != nil {
+		return x.BigKeyBytes
+	}
+	return 0
+}
+
+func (x *Data_Maintenance) GetAnalyzeTables() []string {
+	if x != nil {
+		return x.AnalyzeTables
+	}
+	return nil
+}
+
+func (x *Data_Maintenance) GetLongTxThreshold() *durationpb.Duration {
+	if x != nil {
+		return x.LongTxThreshold
+	}
+	return nil
+}
+
+// MQHeartbeat 定期发布 canary 消息并确认本服务的消费者在 threshold 内收到，结果写入 mq_end_to_end_healthy 指标
+type Data_MQHeartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`         // canary topic，需在 broker / NATS stream 中存在，默认 mq_heartbeat
+	Interval      *durationpb.Duration   `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`   // 发布间隔，默认 1m
+	Threshold     *durationpb.Duration   `protobuf:"bytes,4,opt,name=threshold,proto3" json:"threshold,omitempty"` // 从发布到消费的最长时间，默认 10s
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_MQHeartbeat) Reset() {
+	*x = Data_MQHeartbeat{}
+	mi := &file_conf_conf_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_MQHeartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_MQHeartbeat) ProtoMessage() {}
+
+func (x *Data_MQHeartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_MQHeartbeat.ProtoReflect.Descriptor instead.
+func (*Data_MQHeartbeat) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 3}
+}
+
+func (x *Data_MQHeartbeat) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Data_MQHeartbeat) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Data_MQHeartbeat) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Data_MQHeartbeat) GetThreshold() *durationpb.Duration {
+	if x != nil {
+		return x.Threshold
+	}
+	return nil
+}
+
+// Retention 按模型声明的保留期限删除或匿名化过期数据，处于 legal hold 的记录除外，每项操作写入 audit_logs
+type Data_Retention struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Interval      *durationpb.Duration   `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`                     // 执行间隔，默认 24h
+	BatchSize     int64                  `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"` // 每个事务处理的行数，默认 500
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_Retention) Reset() {
+	*x = Data_Retention{}
+	mi := &file_conf_conf_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Retention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Retention) ProtoMessage() {}
+
+func (x *Data_Retention) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Retention.ProtoReflect.Descriptor instead.
+func (*Data_Retention) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 4}
+}
+
+func (x *Data_Retention) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Data_Retention) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Data_Retention) GetBatchSize() int64 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+// StateMachine 触发 state_machines 中超时实例的超时事件 (pkg/statemachine)
+type Data_StateMachine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Interval      *durationpb.Duration   `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`                     // 扫描间隔，默认 10s
+	BatchSize     int64                  `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"` // 每个状态机每次最多触发的超时数，默认 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_StateMachine) Reset() {
+	*x = Data_StateMachine{}
+	mi := &file_conf_conf_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_StateMachine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_StateMachine) ProtoMessage() {}
+
+func (x *Data_StateMachine) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_StateMachine.ProtoReflect.Descriptor instead.
+func (*Data_StateMachine) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 5}
+}
+
+func (x *Data_StateMachine) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Data_StateMachine) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Data_StateMachine) GetBatchSize() int64 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+// Counter 将 Redis 中缓冲的计数器增量批量写入 counters 表 (pkg/counter)
+type Data_Counter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	FlushInterval *durationpb.Duration   `protobuf:"bytes,2,opt,name=flush_interval,json=flushInterval,proto3" json:"flush_interval,omitempty"` // 刷新间隔，默认 10s
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_Counter) Reset() {
+	*x = Data_Counter{}
+	mi := &file_conf_conf_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Counter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Counter) ProtoMessage() {}
+
+func (x *Data_Counter) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Counter.ProtoReflect.Descriptor instead.
+func (*Data_Counter) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 6}
+}
+
+func (x *Data_Counter) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Data_Counter) GetFlushInterval() *durationpb.Duration {
+	if x != nil {
+		return x.FlushInterval
+	}
+	return nil
+}
+
+// Jobs 在 Redis 中记录各后台任务上次成功执行的时间，重启后按原计划继续，并按 catch_up 处理停机期间错过的执行
+type Data_Jobs struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PersistRuns    bool                   `protobuf:"varint,1,opt,name=persist_runs,json=persistRuns,proto3" json:"persist_runs,omitempty"`
+	CatchUp        string                 `protobuf:"bytes,2,opt,name=catch_up,json=catchUp,proto3" json:"catch_up,omitempty"`                                                                                              // skip | run_once | run_all，默认 skip
+	CatchUpByJob   map[string]string      `protobuf:"bytes,3,rep,name=catch_up_by_job,json=catchUpByJob,proto3" json:"catch_up_by_job,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 按任务名覆盖 catch_up，如 RetentionJob: run_once
+	MaxCatchUpRuns int64                  `protobuf:"varint,4,opt,name=max_catch_up_runs,json=maxCatchUpRuns,proto3" json:"max_catch_up_runs,omitempty"`                                                                    // run_all 最多补执行的次数，默认 10
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Data_Jobs) Reset() {
+	*x = Data_Jobs{}
+	mi := &file_conf_conf_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Jobs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Jobs) ProtoMessage() {}
+
+func (x *Data_Jobs) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Jobs.ProtoReflect.Descriptor instead.
+func (*Data_Jobs) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 7}
+}
+
+func (x *Data_Jobs) GetPersistRuns() bool {
+	if x != nil {
+		return x.PersistRuns
+	}
+	return false
+}
+
+func (x *Data_Jobs) GetCatchUp() string {
+	if x != nil {
+		return x.CatchUp
+	}
+	return ""
+}
+
+func (x *Data_Jobs) GetCatchUpByJob() map[string]string {
+	if x != nil {
+		return x.CatchUpByJob
+	}
+	return nil
+}
+
+func (x *Data_Jobs) GetMaxCatchUpRuns() int64 {
+	if x != nil {
+		return x.MaxCatchUpRuns
+	}
+	return 0
+}
+
+// Outbox 事务性 outbox 的投递、清理与隔离 (pkg/outbox)
+type Data_Outbox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interval      *durationpb.Duration   `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`                           // 轮询间隔，默认 1s
+	BatchSize     int64                  `protobuf:"varint,2,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`       // 每次投递/清理的条数，默认 100
+	Retention     *durationpb.Duration   `protobuf:"bytes,3,opt,name=retention,proto3" json:"retention,omitempty"`                         // 已投递消息的保留时长，超过后删除；为空时不删除
+	MaxAttempts   int32                  `protobuf:"varint,4,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"` // 发布失败该次数后隔离消息，不再阻塞后续消息，可在 /admin/outbox 重新入队或丢弃；0 表示一直重试
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_Outbox) Reset() {
+	*x = Data_Outbox{}
+	mi := &file_conf_conf_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Outbox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Outbox) ProtoMessage() {}
+
+func (x *Data_Outbox) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_Outbox.ProtoReflect.Descriptor instead.
+func (*Data_Outbox) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 8}
 }
 
-func (x *RocketMQ) GetRetryTimes() int32 {
+func (x *Data_Outbox) GetInterval() *durationpb.Duration {
 	if x != nil {
-		return x.RetryTimes
+		return x.Interval
 	}
-	return 0
+	return nil
 }
 
-func (x *RocketMQ) GetAccessKey() string {
+func (x *Data_Outbox) GetBatchSize() int64 {
 	if x != nil {
-		return x.AccessKey
+		return x.BatchSize
 	}
-	return ""
+	return 0
 }
 
-func (x *RocketMQ) GetSecretKey() string {
+func (x *Data_Outbox) GetRetention() *durationpb.Duration {
 	if x != nil {
-		return x.SecretKey
+		return x.Retention
 	}
-	return ""
+	return nil
 }
 
-func (x *RocketMQ) GetEnv() string {
+func (x *Data_Outbox) GetMaxAttempts() int32 {
 	if x != nil {
-		return x.Env
+		return x.MaxAttempts
 	}
-	return ""
+	return 0
 }
 
-type Server struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Http          *Server_HTTP           `protobuf:"bytes,1,opt,name=http,proto3" json:"http,omitempty"`
-	Grpc          *Server_GRPC           `protobuf:"bytes,2,opt,name=grpc,proto3" json:"grpc,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Rules 业务规则 (pkg/rules，CEL 表达式)，配置中的规则可被 rules 表中同名规则覆盖，并定期热加载
+type Data_Rules struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Rules          []*Data_Rules_Rule     `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	Db             bool                   `protobuf:"varint,2,opt,name=db,proto3" json:"db,omitempty"`                                              // 同时从 rules 表加载
+	ReloadInterval *durationpb.Duration   `protobuf:"bytes,3,opt,name=reload_interval,json=reloadInterval,proto3" json:"reload_interval,omitempty"` // 重新加载间隔，默认 30s
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *Server) Reset() {
-	*x = Server{}
-	mi := &file_conf_conf_proto_msgTypes[2]
+func (x *Data_Rules) Reset() {
+	*x = Data_Rules{}
+	mi := &file_conf_conf_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Server) String() string {
+func (x *Data_Rules) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Server) ProtoMessage() {}
+func (*Data_Rules) ProtoMessage() {}
 
-func (x *Server) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[2]
+func (x *Data_Rules) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -209,48 +3365,57 @@ func (x *Server) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Server.ProtoReflect.Descriptor instead.
-func (*Server) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use Data_Rules.ProtoReflect.Descriptor instead.
+func (*Data_Rules) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 9}
 }
 
-func (x *Server) GetHttp() *Server_HTTP {
+func (x *Data_Rules) GetRules() []*Data_Rules_Rule {
 	if x != nil {
-		return x.Http
+		return x.Rules
 	}
 	return nil
 }
 
-func (x *Server) GetGrpc() *Server_GRPC {
+func (x *Data_Rules) GetDb() bool {
 	if x != nil {
-		return x.Grpc
+		return x.Db
+	}
+	return false
+}
+
+func (x *Data_Rules) GetReloadInterval() *durationpb.Duration {
+	if x != nil {
+		return x.ReloadInterval
 	}
 	return nil
 }
 
-type Data struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Database      *Data_Database         `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
-	Redis         *Data_Redis            `protobuf:"bytes,2,opt,name=redis,proto3" json:"redis,omitempty"`
+// Degradation 依赖不可用时的降级策略 (pkg/degrade)，随健康检查的 DependencyDown/Up 事件生效和解除，降级的响应带 X-Degraded 头
+type Data_Degradation struct {
+	state         protoimpl.MessageState              `protogen:"open.v1"`
+	Policies      map[string]*Data_Degradation_Policy `protobuf:"bytes,1,rep,name=policies,proto3" json:"policies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 依赖名 → 策略：redis、eventbus 或下游服务名 (如 user-service)
+	WalDir        string                              `protobuf:"bytes,2,opt,name=wal_dir,json=walDir,proto3" json:"wal_dir,omitempty"`                                                                 // buffer 的 WAL 目录，默认 data/wal
+	ProbeInterval *durationpb.Duration                `protobuf:"bytes,3,opt,name=probe_interval,json=probeInterval,proto3" json:"probe_interval,omitempty"`                                            // redis 健康检查间隔，默认 5s
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Data) Reset() {
-	*x = Data{}
-	mi := &file_conf_conf_proto_msgTypes[3]
+func (x *Data_Degradation) Reset() {
+	*x = Data_Degradation{}
+	mi := &file_conf_conf_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Data) String() string {
+func (x *Data_Degradation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Data) ProtoMessage() {}
+func (*Data_Degradation) ProtoMessage() {}
 
-func (x *Data) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[3]
+func (x *Data_Degradation) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -261,49 +3426,59 @@ func (x *Data) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Data.ProtoReflect.Descriptor instead.
-func (*Data) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use Data_Degradation.ProtoReflect.Descriptor instead.
+func (*Data_Degradation) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 10}
 }
 
-func (x *Data) GetDatabase() *Data_Database {
+func (x *Data_Degradation) GetPolicies() map[string]*Data_Degradation_Policy {
 	if x != nil {
-		return x.Database
+		return x.Policies
 	}
 	return nil
 }
 
-func (x *Data) GetRedis() *Data_Redis {
+func (x *Data_Degradation) GetWalDir() string {
 	if x != nil {
-		return x.Redis
+		return x.WalDir
+	}
+	return ""
+}
+
+func (x *Data_Degradation) GetProbeInterval() *durationpb.Duration {
+	if x != nil {
+		return x.ProbeInterval
 	}
 	return nil
 }
 
-type Server_HTTP struct {
+// Timer 业务流程定时器 (pkg/timer)：定时器存于 timers 表，通过 RocketMQ 延时消息按时触发，超出最长延时或未使用 RocketMQ 时由轮询触发
+type Data_Timer struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
-	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
-	Timeout       *durationpb.Duration   `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`                           // RocketMQ DELAY 类型的 topic，默认 timers
+	MaxDelay      *durationpb.Duration   `protobuf:"bytes,3,opt,name=max_delay,json=maxDelay,proto3" json:"max_delay,omitempty"`     // broker 支持的最长延时 (timerMaxDelaySec)，默认 72h，更晚的定时器到期前再发送
+	Interval      *durationpb.Duration   `protobuf:"bytes,4,opt,name=interval,proto3" json:"interval,omitempty"`                     // 扫描间隔，默认 10s
+	BatchSize     int64                  `protobuf:"varint,5,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"` // 每次最多发送/触发的定时器数，默认 100
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Server_HTTP) Reset() {
-	*x = Server_HTTP{}
-	mi := &file_conf_conf_proto_msgTypes[4]
+func (x *Data_Timer) Reset() {
+	*x = Data_Timer{}
+	mi := &file_conf_conf_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Server_HTTP) String() string {
+func (x *Data_Timer) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Server_HTTP) ProtoMessage() {}
+func (*Data_Timer) ProtoMessage() {}
 
-func (x *Server_HTTP) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[4]
+func (x *Data_Timer) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -314,56 +3489,71 @@ func (x *Server_HTTP) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Server_HTTP.ProtoReflect.Descriptor instead.
-func (*Server_HTTP) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{2, 0}
+// Deprecated: Use Data_Timer.ProtoReflect.Descriptor instead.
+func (*Data_Timer) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 12}
 }
 
-func (x *Server_HTTP) GetNetwork() string {
+func (x *Data_Timer) GetEnabled() bool {
 	if x != nil {
-		return x.Network
+		return x.Enabled
 	}
-	return ""
+	return false
 }
 
-func (x *Server_HTTP) GetAddr() string {
+func (x *Data_Timer) GetTopic() string {
 	if x != nil {
-		return x.Addr
+		return x.Topic
 	}
 	return ""
 }
 
-func (x *Server_HTTP) GetTimeout() *durationpb.Duration {
+func (x *Data_Timer) GetMaxDelay() *durationpb.Duration {
 	if x != nil {
-		return x.Timeout
+		return x.MaxDelay
 	}
 	return nil
 }
 
-type Server_GRPC struct {
+func (x *Data_Timer) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Data_Timer) GetBatchSize() int64 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+// Tenant 多租户路由: 已认证的租户 (metadata.WithTenantClaim) 使用独立的库，x-md-tenant 须与之一致
+type Data_Database_Tenant struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
-	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
-	Timeout       *durationpb.Duration   `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DbName        string                 `protobuf:"bytes,2,opt,name=db_name,json=dbName,proto3" json:"db_name,omitempty"` // 同一实例上的独立 schema
+	Dsn           string                 `protobuf:"bytes,3,opt,name=dsn,proto3" json:"dsn,omitempty"`                     // 独立实例的完整 MySQL DSN，优先于 db_name
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Server_GRPC) Reset() {
-	*x = Server_GRPC{}
-	mi := &file_conf_conf_proto_msgTypes[5]
+func (x *Data_Database_Tenant) Reset() {
+	*x = Data_Database_Tenant{}
+	mi := &file_conf_conf_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Server_GRPC) String() string {
+func (x *Data_Database_Tenant) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Server_GRPC) ProtoMessage() {}
+func (*Data_Database_Tenant) ProtoMessage() {}
 
-func (x *Server_GRPC) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[5]
+func (x *Data_Database_Tenant) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -374,63 +3564,60 @@ func (x *Server_GRPC) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Server_GRPC.ProtoReflect.Descriptor instead.
-func (*Server_GRPC) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{2, 1}
+// Deprecated: Use Data_Database_Tenant.ProtoReflect.Descriptor instead.
+func (*Data_Database_Tenant) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 0, 0}
 }
 
-func (x *Server_GRPC) GetNetwork() string {
+func (x *Data_Database_Tenant) GetId() string {
 	if x != nil {
-		return x.Network
+		return x.Id
 	}
 	return ""
 }
 
-func (x *Server_GRPC) GetAddr() string {
+func (x *Data_Database_Tenant) GetDbName() string {
 	if x != nil {
-		return x.Addr
+		return x.DbName
 	}
 	return ""
 }
 
-func (x *Server_GRPC) GetTimeout() *durationpb.Duration {
+func (x *Data_Database_Tenant) GetDsn() string {
 	if x != nil {
-		return x.Timeout
+		return x.Dsn
 	}
-	return nil
+	return ""
 }
 
-type Data_Database struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Username        string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password        string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	Host            string                 `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
-	Port            int64                  `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
-	DbName          string                 `protobuf:"bytes,5,opt,name=db_name,json=dbName,proto3" json:"db_name,omitempty"`
-	MaxIdleConns    int64                  `protobuf:"varint,6,opt,name=max_idle_conns,json=maxIdleConns,proto3" json:"max_idle_conns,omitempty"`
-	MaxOpenConns    int64                  `protobuf:"varint,7,opt,name=max_open_conns,json=maxOpenConns,proto3" json:"max_open_conns,omitempty"`
-	DbCharset       string                 `protobuf:"bytes,8,opt,name=db_charset,json=dbCharset,proto3" json:"db_charset,omitempty"`
-	ConnMaxLifetime *durationpb.Duration   `protobuf:"bytes,9,opt,name=conn_max_lifetime,json=connMaxLifetime,proto3" json:"conn_max_lifetime,omitempty"`
-	ConnMaxIdleTime *durationpb.Duration   `protobuf:"bytes,10,opt,name=conn_max_idle_time,json=connMaxIdleTime,proto3" json:"conn_max_idle_time,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+// TLS 加密连接 (RDS/PolarDB 等强制 TLS 的托管 MySQL)，以 tls=custom 注册到驱动
+type Data_Database_TLS struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Enabled            bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	CaFile             string                 `protobuf:"bytes,2,opt,name=ca_file,json=caFile,proto3" json:"ca_file,omitempty"`       // 服务端证书的 CA (PEM)，为空时使用系统根证书
+	CertFile           string                 `protobuf:"bytes,3,opt,name=cert_file,json=certFile,proto3" json:"cert_file,omitempty"` // 客户端证书，双向 TLS 时配置
+	KeyFile            string                 `protobuf:"bytes,4,opt,name=key_file,json=keyFile,proto3" json:"key_file,omitempty"`
+	ServerName         string                 `protobuf:"bytes,5,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`                            // 校验的服务端证书名，经 IP 或代理连接时配置
+	InsecureSkipVerify bool                   `protobuf:"varint,6,opt,name=insecure_skip_verify,json=insecureSkipVerify,proto3" json:"insecure_skip_verify,omitempty"` // 仅加密不校验，只用于本地测试
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
-func (x *Data_Database) Reset() {
-	*x = Data_Database{}
-	mi := &file_conf_conf_proto_msgTypes[6]
+func (x *Data_Database_TLS) Reset() {
+	*x = Data_Database_TLS{}
+	mi := &file_conf_conf_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Data_Database) String() string {
+func (x *Data_Database_TLS) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Data_Database) ProtoMessage() {}
+func (*Data_Database_TLS) ProtoMessage() {}
 
-func (x *Data_Database) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[6]
+func (x *Data_Database_TLS) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -441,109 +3628,128 @@ func (x *Data_Database) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Data_Database.ProtoReflect.Descriptor instead.
-func (*Data_Database) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{3, 0}
+// Deprecated: Use Data_Database_TLS.ProtoReflect.Descriptor instead.
+func (*Data_Database_TLS) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 0, 1}
 }
 
-func (x *Data_Database) GetUsername() string {
+func (x *Data_Database_TLS) GetEnabled() bool {
 	if x != nil {
-		return x.Username
+		return x.Enabled
 	}
-	return ""
+	return false
 }
 
-func (x *Data_Database) GetPassword() string {
+func (x *Data_Database_TLS) GetCaFile() string {
 	if x != nil {
-		return x.Password
+		return x.CaFile
 	}
 	return ""
 }
 
-func (x *Data_Database) GetHost() string {
+func (x *Data_Database_TLS) GetCertFile() string {
 	if x != nil {
-		return x.Host
+		return x.CertFile
 	}
 	return ""
 }
 
-func (x *Data_Database) GetPort() int64 {
+func (x *Data_Database_TLS) GetKeyFile() string {
 	if x != nil {
-		return x.Port
+		return x.KeyFile
 	}
-	return 0
+	return ""
 }
 
-func (x *Data_Database) GetDbName() string {
+func (x *Data_Database_TLS) GetServerName() string {
 	if x != nil {
-		return x.DbName
+		return x.ServerName
 	}
 	return ""
 }
 
-func (x *Data_Database) GetMaxIdleConns() int64 {
+func (x *Data_Database_TLS) GetInsecureSkipVerify() bool {
 	if x != nil {
-		return x.MaxIdleConns
+		return x.InsecureSkipVerify
 	}
-	return 0
+	return false
 }
 
-func (x *Data_Database) GetMaxOpenConns() int64 {
-	if x != nil {
-		return x.MaxOpenConns
-	}
-	return 0
+type Data_Maintenance_Task struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Interval      *durationpb.Duration   `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"` // 执行间隔，默认 1h
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Data_Database) GetDbCharset() string {
+func (x *Data_Maintenance_Task) Reset() {
+	*x = Data_Maintenance_Task{}
+	mi := &file_conf_conf_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_Maintenance_Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Maintenance_Task) ProtoMessage() {}
+
+func (x *Data_Maintenance_Task) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[53]
 	if x != nil {
-		return x.DbCharset
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Data_Database) GetConnMaxLifetime() *durationpb.Duration {
+// Deprecated: Use Data_Maintenance_Task.ProtoReflect.Descriptor instead.
+func (*Data_Maintenance_Task) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 2, 0}
+}
+
+func (x *Data_Maintenance_Task) GetEnabled() bool {
 	if x != nil {
-		return x.ConnMaxLifetime
+		return x.Enabled
 	}
-	return nil
+	return false
 }
 
-func (x *Data_Database) GetConnMaxIdleTime() *durationpb.Duration {
+func (x *Data_Maintenance_Task) GetInterval() *durationpb.Duration {
 	if x != nil {
-		return x.ConnMaxIdleTime
+		return x.Interval
 	}
 	return nil
 }
 
-type Data_Redis struct {
+type Data_Rules_Rule struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Network       string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
-	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
-	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
-	Db            int32                  `protobuf:"varint,4,opt,name=db,proto3" json:"db,omitempty"`
-	DialTimeout   *durationpb.Duration   `protobuf:"bytes,5,opt,name=dial_timeout,json=dialTimeout,proto3" json:"dial_timeout,omitempty"`
-	ReadTimeout   *durationpb.Duration   `protobuf:"bytes,6,opt,name=read_timeout,json=readTimeout,proto3" json:"read_timeout,omitempty"`
-	WriteTimeout  *durationpb.Duration   `protobuf:"bytes,7,opt,name=write_timeout,json=writeTimeout,proto3" json:"write_timeout,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Expression    string                 `protobuf:"bytes,2,opt,name=expression,proto3" json:"expression,omitempty"` // CEL 表达式，如 order.total >= 100.0
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Data_Redis) Reset() {
-	*x = Data_Redis{}
-	mi := &file_conf_conf_proto_msgTypes[7]
+func (x *Data_Rules_Rule) Reset() {
+	*x = Data_Rules_Rule{}
+	mi := &file_conf_conf_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Data_Redis) String() string {
+func (x *Data_Rules_Rule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Data_Redis) ProtoMessage() {}
+func (*Data_Rules_Rule) ProtoMessage() {}
 
-func (x *Data_Redis) ProtoReflect() protoreflect.Message {
-	mi := &file_conf_conf_proto_msgTypes[7]
+func (x *Data_Rules_Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -554,56 +3760,73 @@ func (x *Data_Redis) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Data_Redis.ProtoReflect.Descriptor instead.
-func (*Data_Redis) Descriptor() ([]byte, []int) {
-	return file_conf_conf_proto_rawDescGZIP(), []int{3, 1}
+// Deprecated: Use Data_Rules_Rule.ProtoReflect.Descriptor instead.
+func (*Data_Rules_Rule) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 9, 0}
 }
 
-func (x *Data_Redis) GetNetwork() string {
+func (x *Data_Rules_Rule) GetName() string {
 	if x != nil {
-		return x.Network
+		return x.Name
 	}
 	return ""
 }
 
-func (x *Data_Redis) GetAddr() string {
+func (x *Data_Rules_Rule) GetExpression() string {
 	if x != nil {
-		return x.Addr
+		return x.Expression
 	}
 	return ""
 }
 
-func (x *Data_Redis) GetPassword() string {
-	if x != nil {
-		return x.Password
-	}
-	return ""
+type Data_Degradation_Policy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`                         // fail (默认，返回错误) | bypass (跳过，用于 redis：不走缓存) | buffer (写入本地 WAL，恢复后重放，用于 eventbus) | stale (返回过期的缓存响应，用于下游服务)
+	MaxStale      *durationpb.Duration   `protobuf:"bytes,2,opt,name=max_stale,json=maxStale,proto3" json:"max_stale,omitempty"` // stale：缓存响应过期后仍可返回的时长，默认 10m
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Data_Redis) GetDb() int32 {
-	if x != nil {
-		return x.Db
-	}
-	return 0
+func (x *Data_Degradation_Policy) Reset() {
+	*x = Data_Degradation_Policy{}
+	mi := &file_conf_conf_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Data_Redis) GetDialTimeout() *durationpb.Duration {
+func (x *Data_Degradation_Policy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_Degradation_Policy) ProtoMessage() {}
+
+func (x *Data_Degradation_Policy) ProtoReflect() protoreflect.Message {
+	mi := &file_conf_conf_proto_msgTypes[56]
 	if x != nil {
-		return x.DialTimeout
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *Data_Redis) GetReadTimeout() *durationpb.Duration {
+// Deprecated: Use Data_Degradation_Policy.ProtoReflect.Descriptor instead.
+func (*Data_Degradation_Policy) Descriptor() ([]byte, []int) {
+	return file_conf_conf_proto_rawDescGZIP(), []int{10, 10, 0}
+}
+
+func (x *Data_Degradation_Policy) GetMode() string {
 	if x != nil {
-		return x.ReadTimeout
+		return x.Mode
 	}
-	return nil
+	return ""
 }
 
-func (x *Data_Redis) GetWriteTimeout() *durationpb.Duration {
+func (x *Data_Degradation_Policy) GetMaxStale() *durationpb.Duration {
 	if x != nil {
-		return x.WriteTimeout
+		return x.MaxStale
 	}
 	return nil
 }
@@ -613,11 +3836,72 @@ var File_conf_conf_proto protoreflect.FileDescriptor
 const file_conf_conf_proto_rawDesc = "" +
 	"\n" +
 	"\x0fconf/conf.proto\x12\n" +
-	"kratos.api\x1a\x1egoogle/protobuf/duration.proto\"\x8f\x01\n" +
+	"kratos.api\x1a\x1egoogle/protobuf/duration.proto\"\xc3\x03\n" +
 	"\tBootstrap\x12*\n" +
 	"\x06server\x18\x01 \x01(\v2\x12.kratos.api.ServerR\x06server\x12$\n" +
 	"\x04data\x18\x02 \x01(\v2\x10.kratos.api.DataR\x04data\x120\n" +
-	"\brocketmq\x18\x03 \x01(\v2\x14.kratos.api.RocketMQR\brocketmq\"\x83\x02\n" +
+	"\brocketmq\x18\x03 \x01(\v2\x14.kratos.api.RocketMQR\brocketmq\x12$\n" +
+	"\x04nats\x18\x04 \x01(\v2\x10.kratos.api.NatsR\x04nats\x12*\n" +
+	"\x06client\x18\x05 \x01(\v2\x12.kratos.api.ClientR\x06client\x12'\n" +
+	"\x05alert\x18\x06 \x01(\v2\x11.kratos.api.AlertR\x05alert\x12-\n" +
+	"\aruntime\x18\a \x01(\v2\x13.kratos.api.RuntimeR\aruntime\x12*\n" +
+	"\x06region\x18\b \x01(\v2\x12.kratos.api.RegionR\x06region\x129\n" +
+	"\vfingerprint\x18\t \x01(\v2\x17.kratos.api.FingerprintR\vfingerprint\x12!\n" +
+	"\x03log\x18\n" +
+	" \x01(\v2\x0f.kratos.api.LogR\x03log\"I\n" +
+	"\vFingerprint\x12\x14\n" +
+	"\x05store\x18\x01 \x01(\tR\x05store\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\"\x1b\n" +
+	"\x03Log\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\"\x98\x01\n" +
+	"\x06Region\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fscope_topics\x18\x02 \x01(\bR\vscopeTopics\x122\n" +
+	"\x15scope_consumer_groups\x18\x03 \x01(\bR\x13scopeConsumerGroups\x12#\n" +
+	"\rglobal_topics\x18\x04 \x03(\tR\fglobalTopics\"Z\n" +
+	"\aRuntime\x12\x12\n" +
+	"\x04gogc\x18\x01 \x01(\x05R\x04gogc\x12!\n" +
+	"\fmemory_limit\x18\x02 \x01(\x03R\vmemoryLimit\x12\x18\n" +
+	"\aballast\x18\x03 \x01(\x03R\aballast\"\xef\x01\n" +
+	"\x05Alert\x128\n" +
+	"\tnotifiers\x18\x01 \x03(\v2\x1a.kratos.api.Alert.NotifierR\tnotifiers\x12<\n" +
+	"\fdedup_window\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vdedupWindow\x12$\n" +
+	"\x0emax_per_minute\x18\x03 \x01(\x05R\fmaxPerMinute\x1aH\n" +
+	"\bNotifier\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06secret\x18\x03 \x01(\tR\x06secret\"\xa3\x06\n" +
+	"\x06Client\x123\n" +
+	"\atimeout\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12I\n" +
+	"\x13discovery_stale_ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x11discoveryStaleTtl\x122\n" +
+	"\x05cache\x18\x03 \x03(\v2\x1c.kratos.api.Client.CacheRuleR\x05cache\x12\x1f\n" +
+	"\vcache_store\x18\x04 \x01(\tR\n" +
+	"cacheStore\x12\x1d\n" +
+	"\n" +
+	"cache_size\x18\x05 \x01(\x05R\tcacheSize\x126\n" +
+	"\ahedging\x18\x06 \x03(\v2\x1c.kratos.api.Client.HedgeRuleR\ahedging\x12!\n" +
+	"\fhedge_budget\x18\a \x01(\x01R\vhedgeBudget\x12?\n" +
+	"\tendpoints\x18\b \x03(\v2!.kratos.api.Client.EndpointsEntryR\tendpoints\x1a\x83\x01\n" +
+	"\tCacheRule\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12\x1d\n" +
+	"\n" +
+	"key_fields\x18\x03 \x03(\tR\tkeyFields\x12\x12\n" +
+	"\x04vary\x18\x04 \x03(\tR\x04vary\x1at\n" +
+	"\tHedgeRule\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12/\n" +
+	"\x05delay\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x05delay\x12\x1e\n" +
+	"\n" +
+	"percentile\x18\x03 \x01(\x01R\n" +
+	"percentile\x1a2\n" +
+	"\bEndpoint\x12\x12\n" +
+	"\x04grpc\x18\x01 \x01(\tR\x04grpc\x12\x12\n" +
+	"\x04http\x18\x02 \x01(\tR\x04http\x1aY\n" +
+	"\x0eEndpointsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\x05value\x18\x02 \x01(\v2\x1b.kratos.api.Client.EndpointR\x05value:\x028\x01\"\xfc\n" +
+	"\n" +
 	"\bRocketMQ\x12!\n" +
 	"\fname_servers\x18\x01 \x01(\tR\vnameServers\x12%\n" +
 	"\x0eproducer_group\x18\x02 \x01(\tR\rproducerGroup\x12<\n" +
@@ -628,21 +3912,149 @@ const file_conf_conf_proto_rawDesc = "" +
 	"access_key\x18\x05 \x01(\tR\taccessKey\x12\x1d\n" +
 	"\n" +
 	"secret_key\x18\x06 \x01(\tR\tsecretKey\x12\x10\n" +
-	"\x03env\x18\a \x01(\tR\x03env\"\xb8\x02\n" +
+	"\x03env\x18\a \x01(\tR\x03env\x122\n" +
+	"\x15max_delivery_attempts\x18\b \x01(\x05R\x13maxDeliveryAttempts\x12*\n" +
+	"\x11dead_letter_topic\x18\t \x01(\tR\x0fdeadLetterTopic\x12\x1f\n" +
+	"\vmin_workers\x18\n" +
+	" \x01(\x05R\n" +
+	"minWorkers\x12\x1f\n" +
+	"\vmax_workers\x18\v \x01(\x05R\n" +
+	"maxWorkers\x12@\n" +
+	"\x0etarget_latency\x18\f \x01(\v2\x19.google.protobuf.DurationR\rtargetLatency\x120\n" +
+	"\x05retry\x18\r \x01(\v2\x1a.kratos.api.RocketMQ.RetryR\x05retry\x12@\n" +
+	"\vasync_queue\x18\x0e \x01(\v2\x1f.kratos.api.RocketMQ.AsyncQueueR\n" +
+	"asyncQueue\x12=\n" +
+	"\n" +
+	"rate_limit\x18\x0f \x01(\v2\x1e.kratos.api.RocketMQ.RateLimitR\trateLimit\x128\n" +
+	"\x06topics\x18\x10 \x03(\v2 .kratos.api.RocketMQ.TopicsEntryR\x06topics\x1a\xe2\x01\n" +
+	"\x05Retry\x12!\n" +
+	"\fmax_attempts\x18\x01 \x01(\x05R\vmaxAttempts\x12B\n" +
+	"\x0finitial_backoff\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x0einitialBackoff\x12:\n" +
+	"\vmax_backoff\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"maxBackoff\x12\x1e\n" +
+	"\n" +
+	"multiplier\x18\x04 \x01(\x01R\n" +
+	"multiplier\x12\x16\n" +
+	"\x06jitter\x18\x05 \x01(\x01R\x06jitter\x1a\x96\x01\n" +
+	"\n" +
+	"AsyncQueue\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x05R\x04size\x12\x18\n" +
+	"\aworkers\x18\x02 \x01(\x05R\aworkers\x12\x1a\n" +
+	"\boverflow\x18\x03 \x01(\tR\boverflow\x12>\n" +
+	"\rflush_timeout\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\fflushTimeout\x1a@\n" +
+	"\tRateLimit\x12\x1d\n" +
+	"\n" +
+	"per_second\x18\x01 \x01(\x01R\tperSecond\x12\x14\n" +
+	"\x05burst\x18\x02 \x01(\x05R\x05burst\x1a\x8e\x01\n" +
+	"\x05Topic\x12/\n" +
+	"\x13consumption_threads\x18\x01 \x01(\x05R\x12consumptionThreads\x12,\n" +
+	"\x12max_cache_messages\x18\x02 \x01(\x05R\x10maxCacheMessages\x12&\n" +
+	"\x0fmax_cache_bytes\x18\x03 \x01(\x03R\rmaxCacheBytes\x1aU\n" +
+	"\vTopicsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.kratos.api.RocketMQ.TopicR\x05value:\x028\x01\"\x80\x03\n" +
+	"\x04Nats\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1a\n" +
+	"\bembedded\x18\x02 \x01(\bR\bembedded\x12\x1b\n" +
+	"\tstore_dir\x18\x03 \x01(\tR\bstoreDir\x12\x18\n" +
+	"\adurable\x18\x04 \x01(\tR\adurable\x12\x1f\n" +
+	"\vmax_deliver\x18\x05 \x01(\x05R\n" +
+	"maxDeliver\x124\n" +
+	"\back_wait\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\aackWait\x121\n" +
+	"\astreams\x18\a \x03(\v2\x17.kratos.api.Nats.StreamR\astreams\x1a\x88\x01\n" +
+	"\x06Stream\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bsubjects\x18\x02 \x03(\tR\bsubjects\x122\n" +
+	"\amax_age\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x06maxAge\x12\x1a\n" +
+	"\breplicas\x18\x04 \x01(\x05R\breplicas\"\x86\x11\n" +
 	"\x06Server\x12+\n" +
 	"\x04http\x18\x01 \x01(\v2\x17.kratos.api.Server.HTTPR\x04http\x12+\n" +
-	"\x04grpc\x18\x02 \x01(\v2\x17.kratos.api.Server.GRPCR\x04grpc\x1ai\n" +
+	"\x04grpc\x18\x02 \x01(\v2\x17.kratos.api.Server.GRPCR\x04grpc\x127\n" +
+	"\bmetadata\x18\x03 \x01(\v2\x1b.kratos.api.Server.MetadataR\bmetadata\x12.\n" +
+	"\x05admin\x18\x04 \x01(\v2\x18.kratos.api.Server.AdminR\x05admin\x12?\n" +
+	"\vmiddlewares\x18\x05 \x03(\v2\x1d.kratos.api.Server.MiddlewareR\vmiddlewares\x12.\n" +
+	"\x05quota\x18\x06 \x01(\v2\x18.kratos.api.Server.QuotaR\x05quota\x127\n" +
+	"\bmetering\x18\a \x01(\v2\x1b.kratos.api.Server.MeteringR\bmetering\x12@\n" +
+	"\vconcurrency\x18\b \x01(\v2\x1e.kratos.api.Server.ConcurrencyR\vconcurrency\x124\n" +
+	"\agateway\x18\t \x01(\v2\x1a.kratos.api.Server.GatewayR\agateway\x1a1\n" +
+	"\bMetadata\x12%\n" +
+	"\x0epropagate_keys\x18\x01 \x03(\tR\rpropagateKeys\x1a\xf3\x01\n" +
 	"\x04HTTP\x12\x18\n" +
 	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
 	"\x04addr\x18\x02 \x01(\tR\x04addr\x123\n" +
-	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x1ai\n" +
+	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12:\n" +
+	"\bgrpc_web\x18\x04 \x01(\v2\x1f.kratos.api.Server.HTTP.GRPCWebR\agrpcWeb\x1aL\n" +
+	"\aGRPCWeb\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12'\n" +
+	"\x0fallowed_origins\x18\x02 \x03(\tR\x0eallowedOrigins\x1ai\n" +
 	"\x04GRPC\x12\x18\n" +
 	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
 	"\x04addr\x18\x02 \x01(\tR\x04addr\x123\n" +
-	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\x8b\x06\n" +
+	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x1a\x86\x01\n" +
+	"\x05Admin\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
+	"\x04addr\x18\x02 \x01(\tR\x04addr\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x129\n" +
+	"\toperators\x18\x04 \x03(\v2\x1b.kratos.api.Server.OperatorR\toperators\x1aV\n" +
+	"\bOperator\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12 \n" +
+	"\vpermissions\x18\x03 \x03(\tR\vpermissions\x1a\xc0\x01\n" +
+	"\n" +
+	"Middleware\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tselectors\x18\x02 \x03(\tR\tselectors\x12D\n" +
+	"\aoptions\x18\x03 \x03(\v2*.kratos.api.Server.Middleware.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a\xd5\x02\n" +
+	"\x05Quota\x12E\n" +
+	"\x0edefault_limits\x18\x01 \x03(\v2\x1e.kratos.api.Server.Quota.LimitR\rdefaultLimits\x12<\n" +
+	"\bsubjects\x18\x02 \x03(\v2 .kratos.api.Server.Quota.SubjectR\bsubjects\x12\x1d\n" +
+	"\n" +
+	"warn_ratio\x18\x03 \x01(\x01R\twarnRatio\x1aQ\n" +
+	"\x05Limit\x12\x16\n" +
+	"\x06window\x18\x01 \x01(\tR\x06window\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x1aU\n" +
+	"\aSubject\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x126\n" +
+	"\x06limits\x18\x02 \x03(\v2\x1e.kratos.api.Server.Quota.LimitR\x06limits\x1a\x9b\x01\n" +
+	"\bMetering\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1f\n" +
+	"\vsample_rate\x18\x02 \x01(\x01R\n" +
+	"sampleRate\x12D\n" +
+	"\x10aggregate_window\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x0faggregateWindow\x12\x12\n" +
+	"\x04jobs\x18\x04 \x01(\bR\x04jobs\x1a\xc4\x01\n" +
+	"\vConcurrency\x12<\n" +
+	"\x06limits\x18\x01 \x03(\v2$.kratos.api.Server.Concurrency.LimitR\x06limits\x1aw\n" +
+	"\x05Limit\x12\x1c\n" +
+	"\tselectors\x18\x01 \x03(\tR\tselectors\x12\x10\n" +
+	"\x03max\x18\x02 \x01(\x05R\x03max\x12>\n" +
+	"\rqueue_timeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\fqueueTimeout\x1a\xa1\x01\n" +
+	"\aGateway\x128\n" +
+	"\x06routes\x18\x01 \x03(\v2 .kratos.api.Server.Gateway.RouteR\x06routes\x1a\\\n" +
+	"\x05Route\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12!\n" +
+	"\fstrip_prefix\x18\x03 \x01(\bR\vstripPrefix\"\x95&\n" +
 	"\x04Data\x125\n" +
 	"\bdatabase\x18\x01 \x01(\v2\x19.kratos.api.Data.DatabaseR\bdatabase\x12,\n" +
-	"\x05redis\x18\x02 \x01(\v2\x16.kratos.api.Data.RedisR\x05redis\x1a\xfd\x02\n" +
+	"\x05redis\x18\x02 \x01(\v2\x16.kratos.api.Data.RedisR\x05redis\x12>\n" +
+	"\vmaintenance\x18\x03 \x01(\v2\x1c.kratos.api.Data.MaintenanceR\vmaintenance\x127\n" +
+	"\tanalytics\x18\x04 \x01(\v2\x19.kratos.api.Data.DatabaseR\tanalytics\x12?\n" +
+	"\fmq_heartbeat\x18\x05 \x01(\v2\x1c.kratos.api.Data.MQHeartbeatR\vmqHeartbeat\x128\n" +
+	"\tretention\x18\x06 \x01(\v2\x1a.kratos.api.Data.RetentionR\tretention\x12B\n" +
+	"\rstate_machine\x18\a \x01(\v2\x1d.kratos.api.Data.StateMachineR\fstateMachine\x122\n" +
+	"\acounter\x18\b \x01(\v2\x18.kratos.api.Data.CounterR\acounter\x12)\n" +
+	"\x04jobs\x18\t \x01(\v2\x15.kratos.api.Data.JobsR\x04jobs\x124\n" +
+	"\x06codecs\x18\n" +
+	" \x03(\v2\x1c.kratos.api.Data.CodecsEntryR\x06codecs\x12/\n" +
+	"\x06outbox\x18\v \x01(\v2\x17.kratos.api.Data.OutboxR\x06outbox\x12,\n" +
+	"\x05rules\x18\f \x01(\v2\x16.kratos.api.Data.RulesR\x05rules\x12>\n" +
+	"\vdegradation\x18\r \x01(\v2\x1c.kratos.api.Data.DegradationR\vdegradation\x12,\n" +
+	"\x05timer\x18\x0e \x01(\v2\x16.kratos.api.Data.TimerR\x05timer\x1a\xf5\n" +
+	"\n" +
 	"\bDatabase\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
 	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x12\n" +
@@ -655,7 +4067,36 @@ const file_conf_conf_proto_rawDesc = "" +
 	"db_charset\x18\b \x01(\tR\tdbCharset\x12E\n" +
 	"\x11conn_max_lifetime\x18\t \x01(\v2\x19.google.protobuf.DurationR\x0fconnMaxLifetime\x12F\n" +
 	"\x12conn_max_idle_time\x18\n" +
-	" \x01(\v2\x19.google.protobuf.DurationR\x0fconnMaxIdleTime\x1a\x9d\x02\n" +
+	" \x01(\v2\x19.google.protobuf.DurationR\x0fconnMaxIdleTime\x12\x1b\n" +
+	"\tlog_level\x18\v \x01(\tR\blogLevel\x12@\n" +
+	"\x0eslow_threshold\x18\f \x01(\v2\x19.google.protobuf.DurationR\rslowThreshold\x12!\n" +
+	"\fauto_migrate\x18\r \x01(\bR\vautoMigrate\x12(\n" +
+	"\x10dev_auto_migrate\x18\x0e \x01(\bR\x0edevAutoMigrate\x12:\n" +
+	"\atenants\x18\x0f \x03(\v2 .kratos.api.Data.Database.TenantR\atenants\x12I\n" +
+	"\x13tenant_idle_timeout\x18\x10 \x01(\v2\x19.google.protobuf.DurationR\x11tenantIdleTimeout\x12/\n" +
+	"\x03tls\x18\x11 \x01(\v2\x1d.kratos.api.Data.Database.TLSR\x03tls\x12>\n" +
+	"\rquery_timeout\x18\x12 \x01(\v2\x19.google.protobuf.DurationR\fqueryTimeout\x12\x16\n" +
+	"\x06driver\x18\x13 \x01(\tR\x06driver\x12'\n" +
+	"\x0fencryption_keys\x18\x14 \x01(\tR\x0eencryptionKeys\x12\x14\n" +
+	"\x05audit\x18\x15 \x01(\bR\x05audit\x12!\n" +
+	"\faudit_tables\x18\x16 \x03(\tR\vauditTables\x12\x17\n" +
+	"\adry_run\x18\x17 \x01(\bR\x06dryRun\x12!\n" +
+	"\fprepare_stmt\x18\x18 \x01(\bR\vprepareStmt\x121\n" +
+	"\x15prepare_stmt_max_size\x18\x19 \x01(\x03R\x12prepareStmtMaxSize\x12C\n" +
+	"\x10prepare_stmt_ttl\x18\x1a \x01(\v2\x19.google.protobuf.DurationR\x0eprepareStmtTtl\x12\x19\n" +
+	"\bdev_seed\x18\x1b \x01(\bR\adevSeed\x1aC\n" +
+	"\x06Tenant\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\adb_name\x18\x02 \x01(\tR\x06dbName\x12\x10\n" +
+	"\x03dsn\x18\x03 \x01(\tR\x03dsn\x1a\xc3\x01\n" +
+	"\x03TLS\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x17\n" +
+	"\aca_file\x18\x02 \x01(\tR\x06caFile\x12\x1b\n" +
+	"\tcert_file\x18\x03 \x01(\tR\bcertFile\x12\x19\n" +
+	"\bkey_file\x18\x04 \x01(\tR\akeyFile\x12\x1f\n" +
+	"\vserver_name\x18\x05 \x01(\tR\n" +
+	"serverName\x120\n" +
+	"\x14insecure_skip_verify\x18\x06 \x01(\bR\x12insecureSkipVerify\x1a\x9d\x02\n" +
 	"\x05Redis\x12\x18\n" +
 	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x12\n" +
 	"\x04addr\x18\x02 \x01(\tR\x04addr\x12\x1a\n" +
@@ -663,7 +4104,82 @@ const file_conf_conf_proto_rawDesc = "" +
 	"\x02db\x18\x04 \x01(\x05R\x02db\x12<\n" +
 	"\fdial_timeout\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\vdialTimeout\x12<\n" +
 	"\fread_timeout\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\vreadTimeout\x12>\n" +
-	"\rwrite_timeout\x18\a \x01(\v2\x19.google.protobuf.DurationR\fwriteTimeoutB7Z5github.com/go-kratos/kratos-layout/internal/conf;confb\x06proto3"
+	"\rwrite_timeout\x18\a \x01(\v2\x19.google.protobuf.DurationR\fwriteTimeout\x1a\xe6\x04\n" +
+	"\vMaintenance\x12I\n" +
+	"\x0fredis_ttl_audit\x18\x01 \x01(\v2!.kratos.api.Data.Maintenance.TaskR\rredisTtlAudit\x12G\n" +
+	"\x0eredis_big_keys\x18\x02 \x01(\v2!.kratos.api.Data.Maintenance.TaskR\fredisBigKeys\x12F\n" +
+	"\rmysql_analyze\x18\x03 \x01(\v2!.kratos.api.Data.Maintenance.TaskR\fmysqlAnalyze\x12E\n" +
+	"\rmysql_long_tx\x18\x04 \x01(\v2!.kratos.api.Data.Maintenance.TaskR\vmysqlLongTx\x12\x1f\n" +
+	"\vredis_match\x18\x05 \x01(\tR\n" +
+	"redisMatch\x12(\n" +
+	"\x10redis_scan_limit\x18\x06 \x01(\x03R\x0eredisScanLimit\x12\"\n" +
+	"\rbig_key_bytes\x18\a \x01(\x03R\vbigKeyBytes\x12%\n" +
+	"\x0eanalyze_tables\x18\b \x03(\tR\ranalyzeTables\x12E\n" +
+	"\x11long_tx_threshold\x18\t \x01(\v2\x19.google.protobuf.DurationR\x0flongTxThreshold\x1aW\n" +
+	"\x04Task\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\x1a\xad\x01\n" +
+	"\vMQHeartbeat\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x125\n" +
+	"\binterval\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\binterval\x127\n" +
+	"\tthreshold\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\tthreshold\x1a{\n" +
+	"\tRetention\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x03 \x01(\x03R\tbatchSize\x1a~\n" +
+	"\fStateMachine\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x03 \x01(\x03R\tbatchSize\x1ae\n" +
+	"\aCounter\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12@\n" +
+	"\x0eflush_interval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\rflushInterval\x1a\x80\x02\n" +
+	"\x04Jobs\x12!\n" +
+	"\fpersist_runs\x18\x01 \x01(\bR\vpersistRuns\x12\x19\n" +
+	"\bcatch_up\x18\x02 \x01(\tR\acatchUp\x12N\n" +
+	"\x0fcatch_up_by_job\x18\x03 \x03(\v2'.kratos.api.Data.Jobs.CatchUpByJobEntryR\fcatchUpByJob\x12)\n" +
+	"\x11max_catch_up_runs\x18\x04 \x01(\x03R\x0emaxCatchUpRuns\x1a?\n" +
+	"\x11CatchUpByJobEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a\xba\x01\n" +
+	"\x06Outbox\x125\n" +
+	"\binterval\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x02 \x01(\x03R\tbatchSize\x127\n" +
+	"\tretention\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\tretention\x12!\n" +
+	"\fmax_attempts\x18\x04 \x01(\x05R\vmaxAttempts\x1a\xca\x01\n" +
+	"\x05Rules\x121\n" +
+	"\x05rules\x18\x01 \x03(\v2\x1b.kratos.api.Data.Rules.RuleR\x05rules\x12\x0e\n" +
+	"\x02db\x18\x02 \x01(\bR\x02db\x12B\n" +
+	"\x0freload_interval\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x0ereloadInterval\x1a:\n" +
+	"\x04Rule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"expression\x18\x02 \x01(\tR\n" +
+	"expression\x1a\xe8\x02\n" +
+	"\vDegradation\x12F\n" +
+	"\bpolicies\x18\x01 \x03(\v2*.kratos.api.Data.Degradation.PoliciesEntryR\bpolicies\x12\x17\n" +
+	"\awal_dir\x18\x02 \x01(\tR\x06walDir\x12@\n" +
+	"\x0eprobe_interval\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\rprobeInterval\x1aT\n" +
+	"\x06Policy\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x126\n" +
+	"\tmax_stale\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bmaxStale\x1a`\n" +
+	"\rPoliciesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x129\n" +
+	"\x05value\x18\x02 \x01(\v2#.kratos.api.Data.Degradation.PolicyR\x05value:\x028\x01\x1a9\n" +
+	"\vCodecsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a\xc5\x01\n" +
+	"\x05Timer\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x126\n" +
+	"\tmax_delay\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bmaxDelay\x125\n" +
+	"\binterval\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x05 \x01(\x03R\tbatchSizeB7Z5github.com/go-kratos/kratos-layout/internal/conf;confb\x06proto3"
 
 var (
 	file_conf_conf_proto_rawDescOnce sync.Once
@@ -677,39 +4193,175 @@ func file_conf_conf_proto_rawDescGZIP() []byte {
 	return file_conf_conf_proto_rawDescData
 }
 
-var file_conf_conf_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_conf_conf_proto_msgTypes = make([]protoimpl.MessageInfo, 58)
 var file_conf_conf_proto_goTypes = []any{
-	(*Bootstrap)(nil),           // 0: kratos.api.Bootstrap
-	(*RocketMQ)(nil),            // 1: kratos.api.RocketMQ
-	(*Server)(nil),              // 2: kratos.api.Server
-	(*Data)(nil),                // 3: kratos.api.Data
-	(*Server_HTTP)(nil),         // 4: kratos.api.Server.HTTP
-	(*Server_GRPC)(nil),         // 5: kratos.api.Server.GRPC
-	(*Data_Database)(nil),       // 6: kratos.api.Data.Database
-	(*Data_Redis)(nil),          // 7: kratos.api.Data.Redis
-	(*durationpb.Duration)(nil), // 8: google.protobuf.Duration
+	(*Bootstrap)(nil),                // 0: kratos.api.Bootstrap
+	(*Fingerprint)(nil),              // 1: kratos.api.Fingerprint
+	(*Log)(nil),                      // 2: kratos.api.Log
+	(*Region)(nil),                   // 3: kratos.api.Region
+	(*Runtime)(nil),                  // 4: kratos.api.Runtime
+	(*Alert)(nil),                    // 5: kratos.api.Alert
+	(*Client)(nil),                   // 6: kratos.api.Client
+	(*RocketMQ)(nil),                 // 7: kratos.api.RocketMQ
+	(*Nats)(nil),                     // 8: kratos.api.Nats
+	(*Server)(nil),                   // 9: kratos.api.Server
+	(*Data)(nil),                     // 10: kratos.api.Data
+	(*Alert_Notifier)(nil),           // 11: kratos.api.Alert.Notifier
+	(*Client_CacheRule)(nil),         // 12: kratos.api.Client.CacheRule
+	(*Client_HedgeRule)(nil),         // 13: kratos.api.Client.HedgeRule
+	(*Client_Endpoint)(nil),          // 14: kratos.api.Client.Endpoint
+	nil,                              // 15: kratos.api.Client.EndpointsEntry
+	(*RocketMQ_Retry)(nil),           // 16: kratos.api.RocketMQ.Retry
+	(*RocketMQ_AsyncQueue)(nil),      // 17: kratos.api.RocketMQ.AsyncQueue
+	(*RocketMQ_RateLimit)(nil),       // 18: kratos.api.RocketMQ.RateLimit
+	(*RocketMQ_Topic)(nil),           // 19: kratos.api.RocketMQ.Topic
+	nil,                              // 20: kratos.api.RocketMQ.TopicsEntry
+	(*Nats_Stream)(nil),              // 21: kratos.api.Nats.Stream
+	(*Server_Metadata)(nil),          // 22: kratos.api.Server.Metadata
+	(*Server_HTTP)(nil),              // 23: kratos.api.Server.HTTP
+	(*Server_GRPC)(nil),              // 24: kratos.api.Server.GRPC
+	(*Server_Admin)(nil),             // 25: kratos.api.Server.Admin
+	(*Server_Operator)(nil),          // 26: kratos.api.Server.Operator
+	(*Server_Middleware)(nil),        // 27: kratos.api.Server.Middleware
+	(*Server_Quota)(nil),             // 28: kratos.api.Server.Quota
+	(*Server_Metering)(nil),          // 29: kratos.api.Server.Metering
+	(*Server_Concurrency)(nil),       // 30: kratos.api.Server.Concurrency
+	(*Server_Gateway)(nil),           // 31: kratos.api.Server.Gateway
+	(*Server_HTTP_GRPCWeb)(nil),      // 32: kratos.api.Server.HTTP.GRPCWeb
+	nil,                              // 33: kratos.api.Server.Middleware.OptionsEntry
+	(*Server_Quota_Limit)(nil),       // 34: kratos.api.Server.Quota.Limit
+	(*Server_Quota_Subject)(nil),     // 35: kratos.api.Server.Quota.Subject
+	(*Server_Concurrency_Limit)(nil), // 36: kratos.api.Server.Concurrency.Limit
+	(*Server_Gateway_Route)(nil),     // 37: kratos.api.Server.Gateway.Route
+	(*Data_Database)(nil),            // 38: kratos.api.Data.Database
+	(*Data_Redis)(nil),               // 39: kratos.api.Data.Redis
+	(*Data_Maintenance)(nil),         // 40: kratos.api.Data.Maintenance
+	(*Data_MQHeartbeat)(nil),         // 41: kratos.api.Data.MQHeartbeat
+	(*Data_Retention)(nil),           // 42: kratos.api.Data.Retention
+	(*Data_StateMachine)(nil),        // 43: kratos.api.Data.StateMachine
+	(*Data_Counter)(nil),             // 44: kratos.api.Data.Counter
+	(*Data_Jobs)(nil),                // 45: kratos.api.Data.Jobs
+	(*Data_Outbox)(nil),              // 46: kratos.api.Data.Outbox
+	(*Data_Rules)(nil),               // 47: kratos.api.Data.Rules
+	(*Data_Degradation)(nil),         // 48: kratos.api.Data.Degradation
+	nil,                              // 49: kratos.api.Data.CodecsEntry
+	(*Data_Timer)(nil),               // 50: kratos.api.Data.Timer
+	(*Data_Database_Tenant)(nil),     // 51: kratos.api.Data.Database.Tenant
+	(*Data_Database_TLS)(nil),        // 52: kratos.api.Data.Database.TLS
+	(*Data_Maintenance_Task)(nil),    // 53: kratos.api.Data.Maintenance.Task
+	nil,                              // 54: kratos.api.Data.Jobs.CatchUpByJobEntry
+	(*Data_Rules_Rule)(nil),          // 55: kratos.api.Data.Rules.Rule
+	(*Data_Degradation_Policy)(nil),  // 56: kratos.api.Data.Degradation.Policy
+	nil,                              // 57: kratos.api.Data.Degradation.PoliciesEntry
+	(*durationpb.Duration)(nil),      // 58: google.protobuf.Duration
 }
 var file_conf_conf_proto_depIdxs = []int32{
-	2,  // 0: kratos.api.Bootstrap.server:type_name -> kratos.api.Server
-	3,  // 1: kratos.api.Bootstrap.data:type_name -> kratos.api.Data
-	1,  // 2: kratos.api.Bootstrap.rocketmq:type_name -> kratos.api.RocketMQ
-	8,  // 3: kratos.api.RocketMQ.send_timeout:type_name -> google.protobuf.Duration
-	4,  // 4: kratos.api.Server.http:type_name -> kratos.api.Server.HTTP
-	5,  // 5: kratos.api.Server.grpc:type_name -> kratos.api.Server.GRPC
-	6,  // 6: kratos.api.Data.database:type_name -> kratos.api.Data.Database
-	7,  // 7: kratos.api.Data.redis:type_name -> kratos.api.Data.Redis
-	8,  // 8: kratos.api.Server.HTTP.timeout:type_name -> google.protobuf.Duration
-	8,  // 9: kratos.api.Server.GRPC.timeout:type_name -> google.protobuf.Duration
-	8,  // 10: kratos.api.Data.Database.conn_max_lifetime:type_name -> google.protobuf.Duration
-	8,  // 11: kratos.api.Data.Database.conn_max_idle_time:type_name -> google.protobuf.Duration
-	8,  // 12: kratos.api.Data.Redis.dial_timeout:type_name -> google.protobuf.Duration
-	8,  // 13: kratos.api.Data.Redis.read_timeout:type_name -> google.protobuf.Duration
-	8,  // 14: kratos.api.Data.Redis.write_timeout:type_name -> google.protobuf.Duration
-	15, // [15:15] is the sub-list for method output_type
-	15, // [15:15] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	9,   // 0: kratos.api.Bootstrap.server:type_name -> kratos.api.Server
+	10,  // 1: kratos.api.Bootstrap.data:type_name -> kratos.api.Data
+	7,   // 2: kratos.api.Bootstrap.rocketmq:type_name -> kratos.api.RocketMQ
+	8,   // 3: kratos.api.Bootstrap.nats:type_name -> kratos.api.Nats
+	6,   // 4: kratos.api.Bootstrap.client:type_name -> kratos.api.Client
+	5,   // 5: kratos.api.Bootstrap.alert:type_name -> kratos.api.Alert
+	4,   // 6: kratos.api.Bootstrap.runtime:type_name -> kratos.api.Runtime
+	3,   // 7: kratos.api.Bootstrap.region:type_name -> kratos.api.Region
+	1,   // 8: kratos.api.Bootstrap.fingerprint:type_name -> kratos.api.Fingerprint
+	2,   // 9: kratos.api.Bootstrap.log:type_name -> kratos.api.Log
+	11,  // 10: kratos.api.Alert.notifiers:type_name -> kratos.api.Alert.Notifier
+	58,  // 11: kratos.api.Alert.dedup_window:type_name -> google.protobuf.Duration
+	58,  // 12: kratos.api.Client.timeout:type_name -> google.protobuf.Duration
+	58,  // 13: kratos.api.Client.discovery_stale_ttl:type_name -> google.protobuf.Duration
+	12,  // 14: kratos.api.Client.cache:type_name -> kratos.api.Client.CacheRule
+	13,  // 15: kratos.api.Client.hedging:type_name -> kratos.api.Client.HedgeRule
+	15,  // 16: kratos.api.Client.endpoints:type_name -> kratos.api.Client.EndpointsEntry
+	58,  // 17: kratos.api.RocketMQ.send_timeout:type_name -> google.protobuf.Duration
+	58,  // 18: kratos.api.RocketMQ.target_latency:type_name -> google.protobuf.Duration
+	16,  // 19: kratos.api.RocketMQ.retry:type_name -> kratos.api.RocketMQ.Retry
+	17,  // 20: kratos.api.RocketMQ.async_queue:type_name -> kratos.api.RocketMQ.AsyncQueue
+	18,  // 21: kratos.api.RocketMQ.rate_limit:type_name -> kratos.api.RocketMQ.RateLimit
+	20,  // 22: kratos.api.RocketMQ.topics:type_name -> kratos.api.RocketMQ.TopicsEntry
+	58,  // 23: kratos.api.Nats.ack_wait:type_name -> google.protobuf.Duration
+	21,  // 24: kratos.api.Nats.streams:type_name -> kratos.api.Nats.Stream
+	23,  // 25: kratos.api.Server.http:type_name -> kratos.api.Server.HTTP
+	24,  // 26: kratos.api.Server.grpc:type_name -> kratos.api.Server.GRPC
+	22,  // 27: kratos.api.Server.metadata:type_name -> kratos.api.Server.Metadata
+	25,  // 28: kratos.api.Server.admin:type_name -> kratos.api.Server.Admin
+	27,  // 29: kratos.api.Server.middlewares:type_name -> kratos.api.Server.Middleware
+	28,  // 30: kratos.api.Server.quota:type_name -> kratos.api.Server.Quota
+	29,  // 31: kratos.api.Server.metering:type_name -> kratos.api.Server.Metering
+	30,  // 32: kratos.api.Server.concurrency:type_name -> kratos.api.Server.Concurrency
+	31,  // 33: kratos.api.Server.gateway:type_name -> kratos.api.Server.Gateway
+	38,  // 34: kratos.api.Data.database:type_name -> kratos.api.Data.Database
+	39,  // 35: kratos.api.Data.redis:type_name -> kratos.api.Data.Redis
+	40,  // 36: kratos.api.Data.maintenance:type_name -> kratos.api.Data.Maintenance
+	38,  // 37: kratos.api.Data.analytics:type_name -> kratos.api.Data.Database
+	41,  // 38: kratos.api.Data.mq_heartbeat:type_name -> kratos.api.Data.MQHeartbeat
+	42,  // 39: kratos.api.Data.retention:type_name -> kratos.api.Data.Retention
+	43,  // 40: kratos.api.Data.state_machine:type_name -> kratos.api.Data.StateMachine
+	44,  // 41: kratos.api.Data.counter:type_name -> kratos.api.Data.Counter
+	45,  // 42: kratos.api.Data.jobs:type_name -> kratos.api.Data.Jobs
+	49,  // 43: kratos.api.Data.codecs:type_name -> kratos.api.Data.CodecsEntry
+	46,  // 44: kratos.api.Data.outbox:type_name -> kratos.api.Data.Outbox
+	47,  // 45: kratos.api.Data.rules:type_name -> kratos.api.Data.Rules
+	48,  // 46: kratos.api.Data.degradation:type_name -> kratos.api.Data.Degradation
+	50,  // 47: kratos.api.Data.timer:type_name -> kratos.api.Data.Timer
+	58,  // 48: kratos.api.Client.CacheRule.ttl:type_name -> google.protobuf.Duration
+	58,  // 49: kratos.api.Client.HedgeRule.delay:type_name -> google.protobuf.Duration
+	14,  // 50: kratos.api.Client.EndpointsEntry.value:type_name -> kratos.api.Client.Endpoint
+	58,  // 51: kratos.api.RocketMQ.Retry.initial_backoff:type_name -> google.protobuf.Duration
+	58,  // 52: kratos.api.RocketMQ.Retry.max_backoff:type_name -> google.protobuf.Duration
+	58,  // 53: kratos.api.RocketMQ.AsyncQueue.flush_timeout:type_name -> google.protobuf.Duration
+	19,  // 54: kratos.api.RocketMQ.TopicsEntry.value:type_name -> kratos.api.RocketMQ.Topic
+	58,  // 55: kratos.api.Nats.Stream.max_age:type_name -> google.protobuf.Duration
+	58,  // 56: kratos.api.Server.HTTP.timeout:type_name -> google.protobuf.Duration
+	32,  // 57: kratos.api.Server.HTTP.grpc_web:type_name -> kratos.api.Server.HTTP.GRPCWeb
+	58,  // 58: kratos.api.Server.GRPC.timeout:type_name -> google.protobuf.Duration
+	26,  // 59: kratos.api.Server.Admin.operators:type_name -> kratos.api.Server.Operator
+	33,  // 60: kratos.api.Server.Middleware.options:type_name -> kratos.api.Server.Middleware.OptionsEntry
+	34,  // 61: kratos.api.Server.Quota.default_limits:type_name -> kratos.api.Server.Quota.Limit
+	35,  // 62: kratos.api.Server.Quota.subjects:type_name -> kratos.api.Server.Quota.Subject
+	58,  // 63: kratos.api.Server.Metering.aggregate_window:type_name -> google.protobuf.Duration
+	36,  // 64: kratos.api.Server.Concurrency.limits:type_name -> kratos.api.Server.Concurrency.Limit
+	37,  // 65: kratos.api.Server.Gateway.routes:type_name -> kratos.api.Server.Gateway.Route
+	34,  // 66: kratos.api.Server.Quota.Subject.limits:type_name -> kratos.api.Server.Quota.Limit
+	58,  // 67: kratos.api.Server.Concurrency.Limit.queue_timeout:type_name -> google.protobuf.Duration
+	58,  // 68: kratos.api.Data.Database.conn_max_lifetime:type_name -> google.protobuf.Duration
+	58,  // 69: kratos.api.Data.Database.conn_max_idle_time:type_name -> google.protobuf.Duration
+	58,  // 70: kratos.api.Data.Database.slow_threshold:type_name -> google.protobuf.Duration
+	51,  // 71: kratos.api.Data.Database.tenants:type_name -> kratos.api.Data.Database.Tenant
+	58,  // 72: kratos.api.Data.Database.tenant_idle_timeout:type_name -> google.protobuf.Duration
+	52,  // 73: kratos.api.Data.Database.tls:type_name -> kratos.api.Data.Database.TLS
+	58,  // 74: kratos.api.Data.Database.query_timeout:type_name -> google.protobuf.Duration
+	58,  // 75: kratos.api.Data.Database.prepare_stmt_ttl:type_name -> google.protobuf.Duration
+	58,  // 76: kratos.api.Data.Redis.dial_timeout:type_name -> google.protobuf.Duration
+	58,  // 77: kratos.api.Data.Redis.read_timeout:type_name -> google.protobuf.Duration
+	58,  // 78: kratos.api.Data.Redis.write_timeout:type_name -> google.protobuf.Duration
+	53,  // 79: kratos.api.Data.Maintenance.redis_ttl_audit:type_name -> kratos.api.Data.Maintenance.Task
+	53,  // 80: kratos.api.Data.Maintenance.redis_big_keys:type_name -> kratos.api.Data.Maintenance.Task
+	53,  // 81: kratos.api.Data.Maintenance.mysql_analyze:type_name -> kratos.api.Data.Maintenance.Task
+	53,  // 82: kratos.api.Data.Maintenance.mysql_long_tx:type_name -> kratos.api.Data.Maintenance.Task
+	58,  // 83: kratos.api.Data.Maintenance.long_tx_threshold:type_name -> google.protobuf.Duration
+	58,  // 84: kratos.api.Data.MQHeartbeat.interval:type_name -> google.protobuf.Duration
+	58,  // 85: kratos.api.Data.MQHeartbeat.threshold:type_name -> google.protobuf.Duration
+	58,  // 86: kratos.api.Data.Retention.interval:type_name -> google.protobuf.Duration
+	58,  // 87: kratos.api.Data.StateMachine.interval:type_name -> google.protobuf.Duration
+	58,  // 88: kratos.api.Data.Counter.flush_interval:type_name -> google.protobuf.Duration
+	54,  // 89: kratos.api.Data.Jobs.catch_up_by_job:type_name -> kratos.api.Data.Jobs.CatchUpByJobEntry
+	58,  // 90: kratos.api.Data.Outbox.interval:type_name -> google.protobuf.Duration
+	58,  // 91: kratos.api.Data.Outbox.retention:type_name -> google.protobuf.Duration
+	55,  // 92: kratos.api.Data.Rules.rules:type_name -> kratos.api.Data.Rules.Rule
+	58,  // 93: kratos.api.Data.Rules.reload_interval:type_name -> google.protobuf.Duration
+	57,  // 94: kratos.api.Data.Degradation.policies:type_name -> kratos.api.Data.Degradation.PoliciesEntry
+	58,  // 95: kratos.api.Data.Degradation.probe_interval:type_name -> google.protobuf.Duration
+	58,  // 96: kratos.api.Data.Timer.max_delay:type_name -> google.protobuf.Duration
+	58,  // 97: kratos.api.Data.Timer.interval:type_name -> google.protobuf.Duration
+	58,  // 98: kratos.api.Data.Maintenance.Task.interval:type_name -> google.protobuf.Duration
+	58,  // 99: kratos.api.Data.Degradation.Policy.max_stale:type_name -> google.protobuf.Duration
+	56,  // 100: kratos.api.Data.Degradation.PoliciesEntry.value:type_name -> kratos.api.Data.Degradation.Policy
+	101, // [101:101] is the sub-list for method output_type
+	101, // [101:101] is the sub-list for method input_type
+	101, // [101:101] is the sub-list for extension type_name
+	101, // [101:101] is the sub-list for extension extendee
+	0,   // [0:101] is the sub-list for field type_name
 }
 
 func init() { file_conf_conf_proto_init() }
@@ -723,7 +4375,7 @@ func file_conf_conf_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_conf_conf_proto_rawDesc), len(file_conf_conf_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   58,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"strings"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// adminAuditAction is the audit_logs action of admin server requests.
+const adminAuditAction = "admin"
+
+// adminAuditDetail is the After column of admin audit rows.
+type adminAuditDetail struct {
+	Params     map[string]string `json:"params,omitempty"`
+	Status     int               `json:"status"`
+	DurationMS int64             `json:"duration_ms"`
+}
+
+type adminAuditStore struct {
+	data *Data
+}
+
+// NewAdminAuditStore keeps admin audit events in audit_logs next to the row
+// changes of orm.AuditTrail, with action "admin", the operator as actor
+// (admin:<name>) and the request as entity.
+func NewAdminAuditStore(d *Data) admin.AuditStore {
+	return &adminAuditStore{data: d}
+}
+
+func (s *adminAuditStore) Record(ctx context.Context, e admin.AuditEvent) error {
+	after, err := json.Marshal(adminAuditDetail{Params: e.Params, Status: e.Status, DurationMS: e.DurationMS})
+	if err != nil {
+		return err
+	}
+	return s.data.DB(ctx).Create(&orm.AuditLog{
+		CreatedAt: e.Time,
+		Actor:     "admin:" + e.Operator,
+		Action:    adminAuditAction,
+		Entity:    e.Action,
+		After:     after,
+	}).Error
+}
+
+func (s *adminAuditStore) List(ctx context.Context, q admin.AuditQuery) ([]admin.AuditEvent, error) {
+	db := s.data.DB(ctx).Where("action = ?", adminAuditAction)
+	if q.Operator != "" {
+		db = db.Where("actor = ?", "admin:"+q.Operator)
+	}
+	if q.Action != "" {
+		db = db.Where("entity = ?", q.Action)
+	}
+	if !q.Since.IsZero() {
+		db = db.Where("created_at >= ?", q.Since)
+	}
+	var rows []orm.AuditLog
+	if err := db.Order("id DESC").Limit(q.Limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	events := make([]admin.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		var detail adminAuditDetail
+		_ = json.Unmarshal(row.After, &detail)
+		events = append(events, admin.AuditEvent{
+			Time:       row.CreatedAt,
+			Operator:   strings.TrimPrefix(row.Actor, "admin:"),
+			Action:     row.Entity,
+			Params:     detail.Params,
+			Status:     detail.Status,
+			DurationMS: detail.DurationMS,
+		})
+	}
+	return events, nil
+}
+
+// SearchAuditLogs searches the audit logs of the request's tenant, newest
+// first, with the indexes of audit_logs.
+func (d *Data) SearchAuditLogs(ctx context.Context, f orm.AuditFilter, cursor string, size int) (orm.Page[orm.AuditLog], error) {
+	return orm.SearchAuditLogs(d.DB(ctx), f, cursor, size)
+}
+
+// StreamAuditLogs iterates the audit logs of the request's tenant matching
+// f, oldest first, for exports.
+func (d *Data) StreamAuditLogs(ctx context.Context, f orm.AuditFilter) iter.Seq2[orm.AuditLog, error] {
+	return orm.StreamAuditLogs(d.DB(ctx), f)
+}
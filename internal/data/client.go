@@ -0,0 +1,83 @@
+package data
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/client"
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+)
+
+// NewClientFactory creates the factory for downstream service clients.
+// Repos dial other services through it, e.g. f.GRPC(ctx, "user-service").
+// Services with a stale degradation policy are answered from the cache
+// while they are down. The metadata keys propagated are those the server
+// accepts, server.metadata.propagate_keys.
+func NewClientFactory(c *conf.Client, s *conf.Server, d *Data, r *nacos.Registry, codecs *codec.Selector, ctl *degrade.Controller, logger log.Logger) *client.Factory {
+	opts := []client.Option{
+		client.WithMiddleware(debugtrace.Client()),
+		client.WithDegradation(ctl),
+		client.WithPropagateKeys(s.GetMetadata().GetPropagateKeys()...),
+	}
+	if c.GetTimeout() != nil {
+		opts = append(opts, client.WithTimeout(c.GetTimeout().AsDuration()))
+	}
+	if c.GetDiscoveryStaleTtl() != nil {
+		opts = append(opts, client.WithStaleTTL(c.GetDiscoveryStaleTtl().AsDuration()))
+	}
+	if len(c.GetCache()) > 0 {
+		opts = append(opts, client.WithResponseCache(newResponseCache(c, d, ctl), cacheRules(c.GetCache())))
+		if cdc, ok := codecs.Lookup(codec.UseCache); ok {
+			opts = append(opts, client.WithCacheCodec(cdc))
+		}
+	}
+	if len(c.GetHedging()) > 0 {
+		opts = append(opts, client.WithHedging(hedgeRules(c.GetHedging()), c.GetHedgeBudget()))
+	}
+	if len(c.GetEndpoints()) > 0 {
+		endpoints := make(map[string]client.Endpoint, len(c.GetEndpoints()))
+		for service, e := range c.GetEndpoints() {
+			endpoints[service] = client.Endpoint{GRPC: e.GetGrpc(), HTTP: e.GetHttp()}
+		}
+		opts = append(opts, client.WithEndpoints(endpoints))
+	}
+	return client.NewFactory(r, logger, opts...)
+}
+
+func newResponseCache(c *conf.Client, d *Data, ctl *degrade.Controller) client.Cache {
+	if c.GetCacheStore() == "redis" {
+		return bypassCache{Cache: client.NewRedisCache(func() redis.Cmdable { return d.Redis() }, "grpccache"), ctl: ctl}
+	}
+	size := int(c.GetCacheSize())
+	if size <= 0 {
+		size = 10000
+	}
+	return client.NewMemoryCache(size)
+}
+
+func cacheRules(rules []*conf.Client_CacheRule) map[string]client.CacheRule {
+	result := make(map[string]client.CacheRule, len(rules))
+	for _, r := range rules {
+		result[r.GetMethod()] = client.CacheRule{
+			TTL:       r.GetTtl().AsDuration(),
+			KeyFields: r.GetKeyFields(),
+			Vary:      r.GetVary(),
+		}
+	}
+	return result
+}
+
+func hedgeRules(rules []*conf.Client_HedgeRule) map[string]client.HedgeRule {
+	result := make(map[string]client.HedgeRule, len(rules))
+	for _, r := range rules {
+		result[r.GetMethod()] = client.HedgeRule{
+			Delay:      r.GetDelay().AsDuration(),
+			Percentile: r.GetPercentile(),
+		}
+	}
+	return result
+}
@@ -0,0 +1,15 @@
+package data
+
+import (
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+// NewCodecs resolves the codecs configured per use case in data.codecs, so
+// an unknown codec fails at startup. Event payloads use theirs with
+// eventbus.NewSchema[T](v).WithCodec(codecs.For(codec.UseEventBus)), queued
+// tasks with timer.NewTasks[T](timers, kind, codecs.For(codec.UseTaskQueue))
+// and sessions through NewSessions.
+func NewCodecs(c *conf.Data) (*codec.Selector, error) {
+	return codec.NewSelector(c.GetCodecs())
+}
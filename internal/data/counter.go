@@ -0,0 +1,19 @@
+package data
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/pkg/counter"
+)
+
+// NewCounters buffers counter increments in Redis under "{counter}:" and
+// stores them in the default database, which CounterFlushJob flushes to.
+// Counters are not routed by tenant: put the tenant in the counter ID when
+// they must be kept apart.
+func NewCounters(d *Data) *counter.Counters {
+	buf := counter.NewRedisBuffer(func() redis.Cmdable { return d.Redis() }, "counter")
+	return counter.New(buf, func(ctx context.Context) *gorm.DB { return d.db.WithContext(ctx) })
+}
@@ -2,22 +2,34 @@ package data
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
 
 	"github.com/go-kratos/kratos-layout/internal/biz"
 	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data/models"
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
 	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+	"github.com/go-kratos/kratos-layout/pkg/reload"
 )
 
 // ProviderSet is data providers.
 var ProviderSet = wire.NewSet(
-	NewData, NewTransaction,
+	NewData, NewTransaction, NewEventBus, NewOutbox, NewClientFactory, NewQuotaStore, NewAdminAuditStore, NewStateMachines, NewCounters, NewDebugTraceStore, NewCodecs, NewRules, NewDegradation, NewTimers,
 	NewGreeterRepo,
 )
 
@@ -26,59 +38,399 @@ type contextTxKey struct{}
 
 // Data is the data layer dependency container.
 type Data struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db    *gorm.DB
+	ormDB orm.DB
+	// analytics is the optional event/analytics database; nil when not configured.
+	analytics orm.DB
+	// tenants routes requests of authenticated tenants; nil when no tenants are configured.
+	tenants      *orm.TenantRouter
+	rdb          atomic.Pointer[redis.Client]
+	maxIdleConns int
+	log          *log.Helper
 }
 
 // DB returns a context-aware *gorm.DB.
 // If a transaction was started via InTx, returns the transaction;
-// otherwise returns the default database session with the given context.
+// otherwise returns the database session of the request's tenant, or the
+// default one, with the given context. Row locks (orm.LockForUpdate,
+// orm.LockShare) only work on the former and are held until InTx returns.
 func (d *Data) DB(ctx context.Context) *gorm.DB {
 	if tx, ok := ctx.Value(contextTxKey{}).(*gorm.DB); ok {
 		return tx
 	}
-	return d.db.WithContext(ctx)
+	return d.session(ctx)
 }
 
+// inTx reports whether ctx carries a transaction started by InTx.
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(contextTxKey{}).(*gorm.DB)
+	return ok
+}
+
+// session routes ctx to its tenant's database when tenants are configured.
+// The tenant is the authenticated one (metadata.AuthenticatedTenant), so a
+// client cannot pick another tenant's database with x-md-tenant. An
+// unregistered or mismatched tenant never falls back to the default
+// database: the returned session fails every statement with
+// orm.ErrUnknownTenant or the metadata error.
+func (d *Data) session(ctx context.Context) *gorm.DB {
+	db, _ := d.route(ctx, false)
+	return db
+}
+
+// acquire is session holding the tenant's pool open until release, so that
+// the idle janitor cannot close it under a transaction.
+func (d *Data) acquire(ctx context.Context) (*gorm.DB, func()) {
+	return d.route(ctx, true)
+}
+
+func (d *Data) route(ctx context.Context, hold bool) (*gorm.DB, func()) {
+	release := func() {}
+	if d.tenants == nil {
+		return d.db.WithContext(ctx), release
+	}
+	id, err := metadata.AuthenticatedTenant(ctx)
+	if err == nil && id == "" {
+		return d.db.WithContext(ctx), release
+	}
+	var db *gorm.DB
+	if err == nil {
+		if hold {
+			db, release, err = d.tenants.Acquire(id)
+		} else {
+			db, err = d.tenants.DB(id)
+		}
+	}
+	if err != nil {
+		tx := d.db.WithContext(ctx)
+		_ = tx.AddError(err)
+		return tx, func() {}
+	}
+	return db.WithContext(ctx), release
+}
+
+// RegisterTenant adds or moves a tenant at runtime, e.g. when one is
+// provisioned. It fails when no tenants are configured, as routing is off.
+func (d *Data) RegisterTenant(t orm.Tenant) error {
+	if d.tenants == nil {
+		return errTenantRoutingDisabled
+	}
+	return d.tenants.Register(t)
+}
+
+// UnregisterTenant removes a tenant and closes its connections.
+func (d *Data) UnregisterTenant(id string) error {
+	if d.tenants == nil {
+		return errTenantRoutingDisabled
+	}
+	return d.tenants.Unregister(id)
+}
+
+var errTenantRoutingDisabled = errors.New("tenant routing is disabled: no database tenants configured")
+
 // InTx executes fn within a database transaction.
 // The transaction is stored in context so that all repos using DB(ctx) share it.
+// Within another InTx, fn runs in a savepoint of the outer transaction, which
+// is rolled back when fn fails without aborting the outer one.
 func (d *Data) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	if inTx(ctx) {
+		return d.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, contextTxKey{}, tx))
+		})
+	}
+	db, release := d.acquire(ctx)
+	defer release()
+	return db.Transaction(func(tx *gorm.DB) error {
 		return fn(context.WithValue(ctx, contextTxKey{}, tx))
 	})
 }
 
+// InTxRetry executes fn within a database transaction like InTx and
+// re-runs the whole transaction on transient errors (orm.IsTransient).
+// Within another InTx it is InTx: a deadlock rolls back the outer
+// transaction, so only its own InTxRetry can re-run it.
+func (d *Data) InTxRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return d.InTx(ctx, fn)
+	}
+	return orm.Retry(ctx, orm.DefaultRetryPolicy, func() error {
+		return d.InTx(ctx, fn)
+	})
+}
+
+// readTxOptions start a REPEATABLE READ, READ ONLY transaction, whose reads
+// share the snapshot taken by the first one.
+var readTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// InReadTx executes fn within a read-only transaction stored in context like
+// InTx. Within another InTx, fn runs in the outer read-write transaction,
+// which is not made read-only.
+func (d *Data) InReadTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+	db, release := d.acquire(ctx)
+	defer release()
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, contextTxKey{}, tx))
+	}, readTxOptions)
+}
+
 // NewTransaction returns a shard.Transaction backed by Data.
 func NewTransaction(d *Data) biz.Transaction {
 	return d
 }
 
+// Ping checks the database connection within the deadline of ctx.
+func (d *Data) Ping(ctx context.Context) error {
+	return d.ormDB.Ping(ctx)
+}
+
+// DBStats returns the connection pool statistics of the default database.
+func (d *Data) DBStats() sql.DBStats {
+	return d.ormDB.Stats()
+}
+
+// Analytics returns a *gorm.DB on the analytics database, or nil when
+// data.analytics is not configured. It never joins InTx transactions.
+func (d *Data) Analytics(ctx context.Context) *gorm.DB {
+	if d.analytics == nil {
+		return nil
+	}
+	return d.analytics.GetDB().WithContext(ctx)
+}
+
 // Redis returns the redis.Client instance.
 func (d *Data) Redis() *redis.Client {
-	return d.rdb
+	return d.rdb.Load()
+}
+
+// autoMigrate reports whether the schema is migrated at startup: always with
+// auto_migrate, or with dev_auto_migrate when RUN_MODE=dev.
+func autoMigrate(c *conf.Data_Database) bool {
+	return c.GetAutoMigrate() || (c.GetDevAutoMigrate() && env.IsDev())
+}
+
+// migrate runs AutoMigrate over the models of driver and logs the DDL applied.
+func migrate(db orm.DB, driver string, logHelper *log.Helper) error {
+	models.RegisterAll()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ddl, err := db.Migrate(ctx)
+	for _, stmt := range ddl {
+		logHelper.Infof("auto migrate: %s", stmt)
+	}
+	if err != nil {
+		return err
+	}
+	logHelper.Infof("auto migrated %d models, %d statements applied", len(orm.ModelsFor(driver)), len(ddl))
+	return nil
+}
+
+// seed runs the seeders registered with orm.RegisterSeeder not yet run on
+// db and logs them, when dev_seed is set and RUN_MODE=dev. An instance
+// starting while another seeds waits for it within the timeout, then fails
+// naming the seed lock.
+func seed(c *conf.Data_Database, db orm.DB, logHelper *log.Helper) error {
+	if !c.GetDevSeed() || !env.IsDev() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	done, err := orm.Seed(ctx, db.GetDB())
+	for _, name := range done {
+		logHelper.Infof("seeded %s", name)
+	}
+	return err
+}
+
+// dbConfig converts a database config section to an orm.DBConfig.
+func dbConfig(c *conf.Data_Database, logger log.Logger) (*orm.DBConfig, error) {
+	cfg := &orm.DBConfig{
+		Driver:              c.GetDriver(),
+		Username:            c.GetUsername(),
+		Password:            c.GetPassword(),
+		Host:                c.GetHost(),
+		Port:                fmt.Sprintf("%d", c.GetPort()),
+		DBName:              c.GetDbName(),
+		MaxIdleConns:        int(c.GetMaxIdleConns()),
+		MaxOpenConns:        int(c.GetMaxOpenConns()),
+		DBCharset:           c.GetDbCharset(),
+		ConnMaxLifetime:     c.GetConnMaxLifetime().AsDuration(),
+		ConnMaxIdleTime:     c.GetConnMaxIdleTime().AsDuration(),
+		Logger:              newGormLogger(c, logger),
+		TLS:                 tlsConfig(c.GetTls()),
+		DefaultQueryTimeout: c.GetQueryTimeout().AsDuration(),
+		PrepareStmt:         c.GetPrepareStmt(),
+		PrepareStmtMaxSize:  int(c.GetPrepareStmtMaxSize()),
+		PrepareStmtTTL:      c.GetPrepareStmtTtl().AsDuration(),
+	}
+	keys := c.GetEncryptionKeys()
+	if keys == "" {
+		keys = env.Get("ORM_ENCRYPTION_KEYS")
+	}
+	if keys != "" {
+		keyring, err := orm.ParseKeyring(keys)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Encryption = keyring
+	}
+	if c.GetAudit() {
+		cfg.Audit = &orm.AuditTrail{Tables: c.GetAuditTables(), Actor: auditActor}
+	}
+	if c.GetDryRun() {
+		cfg.DryRun = &orm.DryRun{Logger: logger}
+	}
+	return cfg, nil
+}
+
+// auditActor attributes changes to the actor set with orm.WithActor or, on
+// the admin server, to the operator.
+func auditActor(ctx context.Context) string {
+	if actor := orm.ActorFromContext(ctx); actor != "" {
+		return actor
+	}
+	if op, ok := admin.OperatorFromContext(ctx); ok {
+		return "admin:" + op.Name
+	}
+	return ""
+}
+
+// newAnalytics opens the analytics database, or returns nil when it is not
+// configured.
+func newAnalytics(c *conf.Data_Database, metrics *orm.StatementMetrics, logger log.Logger, logHelper *log.Helper) (orm.DB, error) {
+	if c == nil {
+		return nil, nil
+	}
+	cfg, err := dbConfig(c, logger)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Metrics = metrics
+	db, err := orm.MakeDB(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open analytics database: %w", err)
+	}
+	if autoMigrate(c) {
+		driver := cfg.Driver
+		if driver == "" {
+			driver = orm.DriverMySQL
+		}
+		if err := migrate(db, driver, logHelper); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// newGormLogger routes gorm's logs through the service logger.
+func newGormLogger(c *conf.Data_Database, logger log.Logger) *orm.Logger {
+	slow := orm.DefaultSlowThreshold
+	if c.GetSlowThreshold() != nil {
+		slow = c.GetSlowThreshold().AsDuration()
+	}
+	return orm.NewLogger(logger, orm.ParseLogLevel(c.GetLogLevel()), slow)
+}
+
+// tlsConfig maps the database TLS config, nil when disabled.
+func tlsConfig(c *conf.Data_Database_TLS) *orm.TLSConfig {
+	if !c.GetEnabled() {
+		return nil
+	}
+	return &orm.TLSConfig{
+		CAFile:             c.GetCaFile(),
+		CertFile:           c.GetCertFile(),
+		KeyFile:            c.GetKeyFile(),
+		ServerName:         c.GetServerName(),
+		InsecureSkipVerify: c.GetInsecureSkipVerify(),
+	}
+}
+
+// reloadTLS re-reads the certificates of data.database.tls on every reload,
+// for the connections opened from then on. Turning TLS off needs a restart,
+// as the DSNs reference it.
+func reloadTLS() {
+	reload.RegisterFiles("database-tls", []string{"data.database.tls"}, func(_ context.Context, m proto.Message) error {
+		tc := tlsConfig(m.(*conf.Bootstrap).GetData().GetDatabase().GetTls())
+		if tc == nil {
+			return errors.New("turning database TLS off needs a restart")
+		}
+		return tc.Reload()
+	})
+}
+
+// newTenantRouter registers the configured tenants. Their databases are
+// opened on first use and migrated like the default one.
+func newTenantRouter(c *conf.Data_Database, base *orm.DBConfig, logHelper *log.Helper) (*orm.TenantRouter, error) {
+	idle := 10 * time.Minute
+	if c.GetTenantIdleTimeout() != nil {
+		idle = c.GetTenantIdleTimeout().AsDuration()
+	}
+	r := orm.NewTenantRouter(func(t orm.Tenant) (orm.DB, error) {
+		db, err := orm.MakeDB(orm.TenantDBConfig(base, t))
+		if err != nil {
+			return nil, err
+		}
+		if autoMigrate(c) {
+			if err := migrate(db, orm.DriverMySQL, logHelper); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+		return db, nil
+	}, idle)
+	for _, t := range c.GetTenants() {
+		if t.GetId() == "" || (t.GetDbName() == "" && t.GetDsn() == "") {
+			r.Close()
+			return nil, fmt.Errorf("database tenant %q needs an id and a db_name or dsn", t.GetId())
+		}
+		if err := r.Register(orm.Tenant{ID: t.GetId(), DBName: t.GetDbName(), DSN: t.GetDsn()}); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
 }
 
 // NewData creates a new Data instance and returns a cleanup function.
-func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
-	logHelper := log.NewHelper(logger)
-
-	dbConf := &orm.DBConfig{
-		Username:        c.Database.Username,
-		Password:        c.Database.Password,
-		Host:            c.Database.Host,
-		Port:            fmt.Sprintf("%d", c.Database.Port),
-		DBName:          c.Database.DbName,
-		MaxIdleConns:    int(c.Database.MaxIdleConns),
-		MaxOpenConns:    int(c.Database.MaxOpenConns),
-		DBCharset:       c.Database.DbCharset,
-		ConnMaxLifetime: c.Database.ConnMaxLifetime.AsDuration(),
-		ConnMaxIdleTime: c.Database.ConnMaxIdleTime.AsDuration(),
+// Statements of every database are timed with metrics unless it is nil.
+// logger is handed to the packages Data builds on, which scope it themselves.
+func NewData(c *conf.Data, metrics *orm.StatementMetrics, logs *zapLog.Scoped, logger log.Logger) (*Data, func(), error) {
+	logHelper := logs.For("data").Helper
+
+	dbConf, err := dbConfig(c.Database, logger)
+	if err != nil {
+		return nil, nil, err
 	}
+	dbConf.Metrics = metrics
 
 	ormDB, err := orm.MakeDB(dbConf)
 	if err != nil {
 		return nil, nil, err
 	}
+	if dbConf.DryRun != nil {
+		logHelper.Warn("database dry run: writes are logged, not executed")
+	}
+	if dbConf.TLS != nil {
+		reloadTLS()
+	}
+	if autoMigrate(c.Database) {
+		if err := migrate(ormDB, orm.DriverMySQL, logHelper); err != nil {
+			ormDB.Close()
+			return nil, nil, err
+		}
+	}
+	if err := seed(c.Database, ormDB, logHelper); err != nil {
+		ormDB.Close()
+		return nil, nil, err
+	}
+	analytics, err := newAnalytics(c.GetAnalytics(), metrics, logger, logHelper)
+	if err != nil {
+		ormDB.Close()
+		return nil, nil, err
+	}
 
 	rdb := redis.NewClient(&redis.Options{
 		Addr:         c.Redis.Addr,
@@ -88,29 +440,63 @@ func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
 		WriteTimeout: c.Redis.WriteTimeout.AsDuration(),
 		ReadTimeout:  c.Redis.ReadTimeout.AsDuration(),
 	})
+	rdb.AddHook(profile.RedisHook{})
+	rdb.AddHook(debugtrace.RedisHook{})
 
 	// add redis ping check
 	pingTimeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if _, err := rdb.Ping(pingTimeoutCtx).Result(); err != nil {
 		logHelper.Errorf("failed to ping redis: %v", err)
+		if analytics != nil {
+			analytics.Close()
+		}
+		ormDB.Close()
 		return nil, nil, err
 	}
 
+	d := &Data{
+		db:           ormDB.GetDB(),
+		ormDB:        ormDB,
+		analytics:    analytics,
+		maxIdleConns: dbConf.MaxIdleConns,
+		log:          logHelper,
+	}
+	d.rdb.Store(rdb)
+	if len(c.Database.GetTenants()) > 0 {
+		if d.tenants, err = newTenantRouter(c.Database, dbConf, logHelper); err != nil {
+			rdb.Close()
+			if analytics != nil {
+				analytics.Close()
+			}
+			ormDB.Close()
+			return nil, nil, err
+		}
+	}
+
 	cleanup := func() {
 		logHelper.Info("closing the data resources")
 
-		if err := rdb.Close(); err != nil {
+		if d.tenants != nil {
+			if err := d.tenants.Close(); err != nil {
+				logHelper.Errorf("failed to close tenant databases: %v", err)
+			}
+		}
+
+		if err := d.Redis().Close(); err != nil {
 			logHelper.Errorf("failed to close redis data resources: %v", err)
 		}
 
+		if analytics != nil {
+			if err := analytics.Close(); err != nil {
+				logHelper.Errorf("failed to close analytics database: %v", err)
+			}
+		}
+
 		if err := ormDB.Close(); err != nil {
 			logHelper.Errorf("failed to close database data resources: %v", err)
 		}
 	}
 
-	return &Data{
-		db:  ormDB.GetDB(),
-		rdb: rdb,
-	}, cleanup, nil
+	return d, cleanup, nil
 }
@@ -0,0 +1,145 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type txRow struct {
+	ID   uint
+	Name string
+}
+
+// txPool records the options of the transactions begun on a *sql.DB.
+type txPool struct {
+	*sql.DB
+	opts []*sql.TxOptions
+}
+
+func (p *txPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	p.opts = append(p.opts, opts)
+	return p.DB.BeginTx(ctx, opts)
+}
+
+// newTxData returns a Data on a sqlite file and the pool its transactions
+// are begun on.
+func newTxData(t *testing.T) (*Data, *txPool) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	// One connection, so the transactions see the tables.
+	sqlDB.SetMaxOpenConns(1)
+	pool := &txPool{DB: sqlDB}
+	db, err := gorm.Open(sqlite.Dialector{Conn: pool}, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&txRow{}))
+	return &Data{db: db, log: log.NewHelper(log.DefaultLogger)}, pool
+}
+
+// rowNames returns the names of the committed rows.
+func rowNames(t *testing.T, d *Data) []string {
+	var names []string
+	require.NoError(t, d.db.Model(&txRow{}).Order("id").Pluck("name", &names).Error)
+	return names
+}
+
+func TestData_InTx_Nested(t *testing.T) {
+	d, _ := newTxData(t)
+	errInner := errors.New("inner failed")
+	err := d.InTx(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, d.DB(ctx).Create(&txRow{Name: "outer-1"}).Error)
+		err := d.InTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, d.DB(ctx).Create(&txRow{Name: "inner"}).Error)
+			return errInner
+		})
+		assert.ErrorIs(t, err, errInner)
+		// The outer transaction goes on after handling the inner failure.
+		return d.DB(ctx).Create(&txRow{Name: "outer-2"}).Error
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer-1", "outer-2"}, rowNames(t, d), "only the inner writes are rolled back")
+
+	err = d.InTx(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, d.InTx(ctx, func(ctx context.Context) error {
+			return d.DB(ctx).Create(&txRow{Name: "committed-inner"}).Error
+		}))
+		return errInner
+	})
+	assert.ErrorIs(t, err, errInner)
+	assert.Equal(t, []string{"outer-1", "outer-2"}, rowNames(t, d), "the outer failure rolls back the inner writes")
+}
+
+func TestData_InTxRetry_Nested(t *testing.T) {
+	d, _ := newTxData(t)
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+
+	calls := 0
+	err := d.InTx(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, d.DB(ctx).Create(&txRow{Name: "outer"}).Error)
+		err := d.InTxRetry(ctx, func(ctx context.Context) error {
+			calls++
+			require.NoError(t, d.DB(ctx).Create(&txRow{Name: "inner"}).Error)
+			return deadlock
+		})
+		assert.ErrorIs(t, err, deadlock)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "nested InTxRetry does not retry")
+	assert.Equal(t, []string{"outer"}, rowNames(t, d))
+
+	calls = 0
+	err = d.InTxRetry(context.Background(), func(ctx context.Context) error {
+		calls++
+		if err := d.DB(ctx).Create(&txRow{Name: "retried"}).Error; err != nil {
+			return err
+		}
+		if calls == 1 {
+			return deadlock
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"outer", "retried"}, rowNames(t, d), "the failed attempt is rolled back")
+}
+
+func TestData_InReadTx(t *testing.T) {
+	d, pool := newTxData(t)
+	require.NoError(t, d.db.Create(&txRow{Name: "a"}).Error)
+	pool.opts = nil
+
+	var names []string
+	err := d.InReadTx(context.Background(), func(ctx context.Context) error {
+		assert.True(t, inTx(ctx))
+		return d.DB(ctx).Model(&txRow{}).Pluck("name", &names).Error
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, names)
+	require.Len(t, pool.opts, 1)
+	assert.Same(t, readTxOptions, pool.opts[0], "a top-level call begins a read-only transaction")
+
+	pool.opts = nil
+	err = d.InTx(context.Background(), func(ctx context.Context) error {
+		outer := d.DB(ctx)
+		return d.InReadTx(ctx, func(ctx context.Context) error {
+			assert.Same(t, outer, d.DB(ctx), "a nested call reuses the outer transaction")
+			return d.DB(ctx).Create(&txRow{Name: "b"}).Error
+		})
+	})
+	require.NoError(t, err)
+	require.Len(t, pool.opts, 1)
+	assert.False(t, pool.opts[0] != nil && pool.opts[0].ReadOnly, "only the outer read-write transaction is begun")
+	assert.Equal(t, []string{"a", "b"}, rowNames(t, d))
+}
@@ -0,0 +1,13 @@
+package data
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+)
+
+// NewDebugTraceStore keeps debug tokens and traces in Redis under the
+// "debugtrace:" prefix, shared by the instances of the service.
+func NewDebugTraceStore(d *Data) debugtrace.Store {
+	return debugtrace.NewRedisStore(func() redis.Cmdable { return d.Redis() }, "debugtrace")
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/client"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// RedisDependency is the name Redis is reported as to the lifecycle bus, the
+// key of its degradation policy.
+const RedisDependency = "redis"
+
+// defaultWALDir is where events are buffered under the buffer policy.
+const defaultWALDir = "data/wal"
+
+// NewDegradation creates the controller of the data.degradation policies,
+// following the dependency events of the health checks.
+func NewDegradation(c *conf.Data) (*degrade.Controller, error) {
+	policies := make(map[string]degrade.Policy, len(c.GetDegradation().GetPolicies()))
+	for dep, p := range c.GetDegradation().GetPolicies() {
+		mode, err := degrade.ParseMode(p.GetMode())
+		if err != nil {
+			return nil, fmt.Errorf("degradation policy of %s: %w", dep, err)
+		}
+		policies[dep] = degrade.Policy{Mode: mode, MaxStale: p.GetMaxStale().AsDuration()}
+	}
+	ctl := degrade.New(lifecycle.Default(), policies)
+	lifecycle.Subscribe(ctl.Subscriber())
+	return ctl, nil
+}
+
+// walDir returns the directory of the event WAL.
+func walDir(c *conf.Data) string {
+	if dir := c.GetDegradation().GetWalDir(); dir != "" {
+		return dir
+	}
+	return defaultWALDir
+}
+
+// PingRedis checks the Redis connection within the deadline of ctx.
+func (d *Data) PingRedis(ctx context.Context) error {
+	return d.Redis().Ping(ctx).Err()
+}
+
+// bypassCache skips a Redis cache while the controller degrades Redis, so
+// calls do not wait for its timeouts.
+type bypassCache struct {
+	client.Cache
+	ctl *degrade.Controller
+}
+
+// Get implements client.Cache.
+func (c bypassCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if c.ctl.Active(RedisDependency) {
+		degrade.Mark(ctx, RedisDependency)
+		return nil, false, nil
+	}
+	return c.Cache.Get(ctx, key)
+}
+
+// Set implements client.Cache.
+func (c bypassCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.ctl.Active(RedisDependency) {
+		return nil
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
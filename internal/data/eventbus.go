@@ -0,0 +1,64 @@
+package data
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+)
+
+// NewEventBus creates the event bus.
+// With LOCAL_MQ=true events are queued in process, so the service runs
+// without a broker. Otherwise NATS JetStream is used when configured
+// (url or embedded), falling back to RocketMQ. Events are tagged with the
+// region, and topics and consumer groups scoped to it as configured. With
+// the buffer degradation policy for eventbus, events the broker does not
+// take are buffered to a local WAL until it is back.
+func NewEventBus(c *conf.RocketMQ, nc *conf.Nats, rc *conf.Region, dc *conf.Data, ctl *degrade.Controller, logs *zapLog.Scoped, logger log.Logger) (eventbus.Bus, func(), error) {
+	scope := region.NewScopeFromProto(rc)
+	if local, _ := strconv.ParseBool(env.Get("LOCAL_MQ")); local {
+		logs.For("data/eventbus").Warn("LOCAL_MQ enabled, events stay in process")
+		return eventbus.WithRegion(eventbus.NewLocal(logger), scope), func() {}, nil
+	}
+	if nc.GetUrl() != "" || nc.GetEmbedded() {
+		cfg := eventbus.NewNatsConfigFromProto(nc)
+		cfg.Durable = scope.Group(cfg.Durable)
+		nats, cleanup, err := eventbus.NewNats(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		bus, err := withWAL(nats, dc, ctl, logger)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return eventbus.WithRegion(bus, scope), cleanup, nil
+	}
+	if c == nil {
+		return nil, nil, errors.New("rocketmq config is required, set LOCAL_MQ=true to run without a broker")
+	}
+	bus, cleanup, err := newRocketMQBus(c, scope, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bus, err = withWAL(bus, dc, ctl, logger); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return eventbus.WithRegion(bus, scope), cleanup, nil
+}
+
+// withWAL buffers the events of bus to the WAL under the buffer policy.
+func withWAL(bus eventbus.Bus, dc *conf.Data, ctl *degrade.Controller, logger log.Logger) (eventbus.Bus, error) {
+	if ctl.Policy(eventbus.Dependency).Mode != degrade.Buffer {
+		return bus, nil
+	}
+	return eventbus.WithWAL(bus, walDir(dc), logger)
+}
@@ -0,0 +1,17 @@
+//go:build norocketmq
+
+package data
+
+import (
+	"errors"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+)
+
+func newRocketMQBus(*conf.RocketMQ, *region.Scope, log.Logger) (eventbus.Bus, func(), error) {
+	return nil, nil, errors.New("rocketmq is compiled out by the norocketmq build tag, configure nats or set LOCAL_MQ=true")
+}
@@ -0,0 +1,29 @@
+//go:build !norocketmq
+
+package data
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+	"github.com/go-kratos/kratos-layout/pkg/rocketmq"
+)
+
+func newRocketMQBus(c *conf.RocketMQ, scope *region.Scope, logger log.Logger) (eventbus.Bus, func(), error) {
+	cfg := rocketmq.NewConfigFromProto(c)
+	cfg.ConsumerGroup = scope.Group(cfg.ConsumerGroup)
+	if len(cfg.Topics) > 0 {
+		topics := make(map[string]rocketmq.TopicSettings, len(cfg.Topics))
+		for topic, s := range cfg.Topics {
+			topics[scope.Topic(topic)] = s
+		}
+		cfg.Topics = topics
+	}
+	bus, cleanup, err := eventbus.NewRocketMQ(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bus, cleanup, nil
+}
@@ -3,29 +3,31 @@ package data
 import (
 	"context"
 
-	"github.com/go-kratos/kratos/v2/log"
-
 	"github.com/go-kratos/kratos-layout/internal/biz"
+	"github.com/go-kratos/kratos-layout/pkg/instrument"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
 )
 
+//go:generate go run ../../cmd/repogen -source=../biz/greeter.go -destination=greeter_instrumented.go
+
 type greeterRepo struct {
 	data *Data
-	log  *log.Helper
+	log  *zapLog.Helper
 }
 
-// NewGreeterRepo creates a new greeter repository.
-func NewGreeterRepo(data *Data, logger log.Logger) biz.GreeterRepo {
-	return &greeterRepo{
+// NewGreeterRepo creates a new greeter repository instrumented with rec.
+func NewGreeterRepo(data *Data, rec *instrument.Recorder, logs *zapLog.Scoped) biz.GreeterRepo {
+	return NewInstrumentedGreeterRepo(&greeterRepo{
 		data: data,
-		log:  log.NewHelper(logger),
-	}
+		log:  logs.For("data/greeter"),
+	}, rec)
 }
 
 func (r *greeterRepo) Save(ctx context.Context, g *biz.Greeter) (*biz.Greeter, error) {
 	// TODO: Implement database save logic
 	// Example:
-	// model := &GreeterModel{Name: g.Hello}
-	// if err := r.data.DB().WithContext(ctx).Create(model).Error; err != nil {
+	// model := &models.Greeter{Name: g.Hello}
+	// if err := r.data.DB(ctx).Create(model).Error; err != nil {
 	//     return nil, err
 	// }
 	// return &biz.Greeter{Hello: model.Name}, nil
@@ -40,12 +42,12 @@ func (r *greeterRepo) Update(ctx context.Context, g *biz.Greeter) (*biz.Greeter,
 func (r *greeterRepo) FindByID(ctx context.Context, id int64) (*biz.Greeter, error) {
 	// TODO: Implement database find logic
 	// Example:
-	// var model GreeterModel
-	// if err := r.data.DB().WithContext(ctx).First(&model, id).Error; err != nil {
+	// var model models.Greeter
+	// if err := r.data.DB(ctx).First(&model, id).Error; err != nil {
 	//     if errors.Is(err, gorm.ErrRecordNotFound) {
 	//         return nil, biz.ErrGreeterNotFound
 	//     }
-	//     return nil, err
+	//     return nil, errdetail.WithStack(err)
 	// }
 	// return &biz.Greeter{Hello: model.Name}, nil
 	return nil, nil
@@ -53,6 +55,8 @@ func (r *greeterRepo) FindByID(ctx context.Context, id int64) (*biz.Greeter, err
 
 func (r *greeterRepo) ListByHello(ctx context.Context, hello string) ([]*biz.Greeter, error) {
 	// TODO: Implement database list by hello logic
+	// Soft-deleted greeters are skipped; add .Scopes(orm.WithDeleted) to
+	// include them.
 	return nil, nil
 }
 
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	canaryKeyPrefix = "mq:heartbeat:"
+	// canaryTTL keeps receipts long enough for the publisher to find them.
+	canaryTTL = 10 * time.Minute
+)
+
+// MarkCanary records that the canary message id was consumed at t. Receipts
+// are shared through Redis because any instance of the consumer group may
+// consume the canary published by another.
+func (d *Data) MarkCanary(ctx context.Context, id string, t time.Time) error {
+	return d.Redis().Set(ctx, canaryKeyPrefix+id, t.UnixNano(), canaryTTL).Err()
+}
+
+// CanaryConsumed returns when the canary message id was consumed, or the zero
+// time when it was not yet.
+func (d *Data) CanaryConsumed(ctx context.Context, id string) (time.Time, error) {
+	v, err := d.Redis().Get(ctx, canaryKeyPrefix+id).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	ns, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ns), nil
+}
@@ -0,0 +1,34 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const jobRunKeyPrefix = "job:last_run:"
+
+// LastJobRun returns when the background job last ran successfully on any
+// instance, or the zero time when it never did.
+func (d *Data) LastJobRun(ctx context.Context, job string) (time.Time, error) {
+	v, err := d.Redis().Get(ctx, jobRunKeyPrefix+job).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	ns, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ns), nil
+}
+
+// SetLastJobRun records that the background job ran successfully at t.
+func (d *Data) SetLastJobRun(ctx context.Context, job string, t time.Time) error {
+	return d.Redis().Set(ctx, jobRunKeyPrefix+job, t.UnixNano(), 0).Err()
+}
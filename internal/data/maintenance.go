@@ -0,0 +1,142 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxReportedKeys caps the number of keys returned by the Redis audits.
+const maxReportedKeys = 20
+
+// scanKeys calls fn with batches of keys matching match until limit keys were
+// scanned and returns the number of keys scanned.
+func (d *Data) scanKeys(ctx context.Context, match string, limit int, fn func(rdb *redis.Client, keys []string) error) (int, error) {
+	rdb := d.Redis()
+	var (
+		scanned int
+		cursor  uint64
+	)
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, match, 500).Result()
+		if err != nil {
+			return scanned, fmt.Errorf("scan %s: %w", match, err)
+		}
+		if len(keys) > 0 {
+			if err := fn(rdb, keys); err != nil {
+				return scanned, err
+			}
+		}
+		scanned += len(keys)
+		if next == 0 || (limit > 0 && scanned >= limit) {
+			return scanned, nil
+		}
+		cursor = next
+	}
+}
+
+// RedisKeysWithoutTTL scans up to limit keys matching match and returns how
+// many were scanned, how many never expire and a sample of those keys.
+func (d *Data) RedisKeysWithoutTTL(ctx context.Context, match string, limit int) (scanned, persistent int, sample []string, err error) {
+	scanned, err = d.scanKeys(ctx, match, limit, func(rdb *redis.Client, keys []string) error {
+		cmds, err := rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for _, k := range keys {
+				p.TTL(ctx, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ttl: %w", err)
+		}
+		for i, cmd := range cmds {
+			// -1 means no expiry; -2 means the key disappeared since SCAN.
+			if cmd.(*redis.DurationCmd).Val() == -1 {
+				persistent++
+				if len(sample) < maxReportedKeys {
+					sample = append(sample, keys[i])
+				}
+			}
+		}
+		return nil
+	})
+	return scanned, persistent, sample, err
+}
+
+// BigKey is a Redis key whose memory usage exceeds the audit threshold.
+type BigKey struct {
+	Key   string
+	Bytes int64
+}
+
+// RedisBigKeys scans up to limit keys matching match and returns how many
+// were scanned and up to maxReportedKeys keys using more than threshold bytes.
+func (d *Data) RedisBigKeys(ctx context.Context, match string, limit int, threshold int64) (int, []BigKey, error) {
+	var big []BigKey
+	scanned, err := d.scanKeys(ctx, match, limit, func(rdb *redis.Client, keys []string) error {
+		cmds, err := rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for _, k := range keys {
+				p.MemoryUsage(ctx, k)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("memory usage: %w", err)
+		}
+		for i, cmd := range cmds {
+			if n := cmd.(*redis.IntCmd).Val(); n > threshold && len(big) < maxReportedKeys {
+				big = append(big, BigKey{Key: keys[i], Bytes: n})
+			}
+		}
+		return nil
+	})
+	return scanned, big, err
+}
+
+// AnalyzeTables runs ANALYZE TABLE to refresh index statistics, on tables or
+// on every table of the database when tables is empty. It returns the tables
+// analyzed.
+func (d *Data) AnalyzeTables(ctx context.Context, tables []string) ([]string, error) {
+	db := d.db.WithContext(ctx)
+	if len(tables) == 0 {
+		err := db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'").
+			Scan(&tables).Error
+		if err != nil {
+			return nil, fmt.Errorf("list tables: %w", err)
+		}
+	}
+	for i, t := range tables {
+		// ANALYZE returns a result set per table; Exec discards it.
+		if err := db.Exec("ANALYZE TABLE `" + strings.ReplaceAll(t, "`", "``") + "`").Error; err != nil {
+			return tables[:i], fmt.Errorf("analyze table %s: %w", t, err)
+		}
+	}
+	return tables, nil
+}
+
+// Transaction is a running InnoDB transaction.
+type Transaction struct {
+	ID       string
+	ThreadID int64
+	Started  time.Time
+	State    string
+	Query    string // statement currently running, truncated
+}
+
+// LongTransactions returns InnoDB transactions open for longer than threshold,
+// oldest first. Long transactions hold locks and block purge of old row versions.
+func (d *Data) LongTransactions(ctx context.Context, threshold time.Duration) ([]Transaction, error) {
+	var txs []Transaction
+	err := d.db.WithContext(ctx).Raw(`SELECT trx_id AS id, trx_mysql_thread_id AS thread_id, trx_started AS started,
+		trx_state AS state, LEFT(IFNULL(trx_query, ''), 256) AS query
+		FROM information_schema.innodb_trx
+		WHERE trx_started < NOW() - INTERVAL ? SECOND
+		ORDER BY trx_started`, int64(threshold.Seconds())).Scan(&txs).Error
+	if err != nil {
+		return nil, fmt.Errorf("list long transactions: %w", err)
+	}
+	return txs, nil
+}
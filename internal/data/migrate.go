@@ -0,0 +1,40 @@
+package data
+
+import (
+	"io/fs"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// NewMigrator opens the database of c for applying the versioned migrations
+// of fsys. Tenant databases are not migrated.
+func NewMigrator(c *conf.Data_Database, fsys fs.FS, logger log.Logger) (*orm.SQLMigrator, func(), error) {
+	db, cleanup, err := OpenDB(c, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := orm.NewSQLMigrator(db, fsys)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return m, cleanup, nil
+}
+
+// OpenDB opens the database of c on its own, for the maintenance
+// subcommands, e.g. to run orm.Seed. Tenant databases are not opened.
+func OpenDB(c *conf.Data_Database, logger log.Logger) (*gorm.DB, func(), error) {
+	cfg, err := dbConfig(c, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := orm.MakeDB(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.GetDB(), func() { db.Close() }, nil
+}
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/go-kratos/kratos-layout/pkg/orm"
+
+// Greeter is the storage model of biz.Greeter. Deleting it is a soft delete.
+type Greeter struct {
+	orm.BaseModel
+	Name string `gorm:"size:255;not null;index"`
+}
+
+// TableName implements gorm's tabler.
+func (Greeter) TableName() string { return "greeters" }
@@ -0,0 +1,33 @@
+// Package models is the central list of GORM models owned by this service.
+// The app (AutoMigrate) and cmd/atlas-loader (schema generation) both read
+// it, so a model added to All is picked up everywhere.
+package models
+
+import (
+	"github.com/go-kratos/kratos-layout/pkg/counter"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+	"github.com/go-kratos/kratos-layout/pkg/rules"
+	"github.com/go-kratos/kratos-layout/pkg/statemachine"
+	"github.com/go-kratos/kratos-layout/pkg/timer"
+)
+
+// All returns a zero value of every model.
+func All() []any {
+	return []any{
+		&outbox.Message{},
+		&orm.AuditLog{},
+		&orm.LegalHold{},
+		&statemachine.Instance{},
+		&counter.Counter{},
+		&counter.Flush{},
+		&rules.Rule{},
+		&timer.Timer{},
+		&Greeter{},
+	}
+}
+
+// RegisterAll registers All with orm.RegisterModel. It is idempotent.
+func RegisterAll() {
+	orm.RegisterModel(All()...)
+}
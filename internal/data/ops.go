@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+)
+
+// FlushCache deletes every Redis key under the "<name>:" prefix and returns
+// the number of keys removed.
+func (d *Data) FlushCache(ctx context.Context, name string) (int64, error) {
+	if name == "" {
+		return 0, errors.New("cache name is required")
+	}
+	rdb := d.Redis()
+	var (
+		removed int64
+		cursor  uint64
+	)
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, name+":*", 500).Result()
+		if err != nil {
+			return removed, fmt.Errorf("scan cache %s: %w", name, err)
+		}
+		if len(keys) > 0 {
+			n, err := rdb.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return removed, fmt.Errorf("flush cache %s: %w", name, err)
+			}
+			removed += n
+		}
+		if next == 0 {
+			return removed, nil
+		}
+		cursor = next
+	}
+}
+
+// ReconnectDB closes the idle MySQL connections so the pool dials fresh ones,
+// e.g. after a failover moved the primary. Connections in use are kept.
+func (d *Data) ReconnectDB(ctx context.Context) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxIdleConns(0)
+	sqlDB.SetMaxIdleConns(d.maxIdleConns)
+	return sqlDB.PingContext(ctx)
+}
+
+// ReconnectRedis replaces the Redis client with a freshly dialed one using the
+// same options. The previous client is closed once the new one answers PING.
+func (d *Data) ReconnectRedis(ctx context.Context) error {
+	old := d.Redis()
+	opt := *old.Options()
+	rdb := redis.NewClient(&opt)
+	rdb.AddHook(profile.RedisHook{})
+	rdb.AddHook(debugtrace.RedisHook{})
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
+		return fmt.Errorf("ping redis: %w", err)
+	}
+	d.rdb.Store(rdb)
+	if err := old.Close(); err != nil {
+		d.log.Warnf("close previous redis client: %v", err)
+	}
+	return nil
+}
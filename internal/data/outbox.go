@@ -0,0 +1,23 @@
+package data
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+)
+
+// NewOutbox creates the transactional outbox. Events added inside InTx are
+// committed with the business change and relayed to the event bus.
+// data.outbox sets the retention of published messages and quarantines
+// poison ones; metrics may be nil.
+func NewOutbox(c *conf.Data, d *Data, bus eventbus.Bus, metrics *outbox.Metrics, logger log.Logger) *outbox.Outbox {
+	oc := c.GetOutbox()
+	return outbox.New(d.DB, bus, logger,
+		outbox.WithInterval(oc.GetInterval().AsDuration()),
+		outbox.WithBatchSize(int(oc.GetBatchSize())),
+		outbox.WithRetention(oc.GetRetention().AsDuration()),
+		outbox.WithMaxAttempts(int(oc.GetMaxAttempts())),
+		outbox.WithMetrics(metrics))
+}
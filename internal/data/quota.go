@@ -0,0 +1,12 @@
+package data
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/pkg/quota"
+)
+
+// NewQuotaStore keeps quota counters in Redis under the "quota:" prefix.
+func NewQuotaStore(d *Data) quota.Store {
+	return quota.NewRedisStore(func() redis.Cmdable { return d.Redis() }, "quota")
+}
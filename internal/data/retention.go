@@ -0,0 +1,31 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kratos/kratos-layout/internal/data/models"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// EnforceRetention deletes or anonymizes the expired rows of the registered
+// models declaring an orm.RetentionPolicy in the default database. Tenant
+// databases are not covered.
+func (d *Data) EnforceRetention(ctx context.Context, batch int) ([]orm.RetentionResult, error) {
+	models.RegisterAll()
+	return orm.EnforceRetention(ctx, d.db, batch, orm.Models()...)
+}
+
+// PlaceLegalHold exempts the row of table entity with primary key id from
+// retention enforcement, attributed like orm.AuditTrail changes.
+func (d *Data) PlaceLegalHold(ctx context.Context, entity, id, reason string) error {
+	if entity == "" || id == "" {
+		return errors.New("entity and id are required")
+	}
+	return orm.PlaceLegalHold(d.db.WithContext(ctx), entity, id, reason, auditActor(ctx))
+}
+
+// ReleaseLegalHold ends the legal hold of a row.
+func (d *Data) ReleaseLegalHold(ctx context.Context, entity, id string) error {
+	return orm.ReleaseLegalHold(d.db.WithContext(ctx), entity, id, auditActor(ctx))
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/reload"
+	"github.com/go-kratos/kratos-layout/pkg/rules"
+)
+
+// rulesLoadTimeout bounds the first load of the rules at startup.
+const rulesLoadTimeout = 10 * time.Second
+
+// NewRules loads the business rules of data.rules, overridden by the rows
+// of the rules table when data.rules.db is set, which RulesReloadJob then
+// reloads. Usecases evaluate them with a rules.Engine of their input type.
+// The rules of the config are reloaded on SIGHUP.
+func NewRules(c *conf.Data, d *Data) (*rules.Set, error) {
+	rc := c.GetRules()
+	var static atomic.Pointer[[]rules.Rule]
+	static.Store(configRules(rc))
+	var src rules.Source = rules.SourceFunc(func(context.Context) ([]rules.Rule, error) {
+		return *static.Load(), nil
+	})
+	if rc.GetDb() {
+		// Rules are shared by the tenants, in the default database.
+		src = rules.Layered(src, rules.FromDB(func(ctx context.Context) *gorm.DB { return d.db.WithContext(ctx) }))
+	}
+	set := rules.NewSet(src)
+	ctx, cancel := context.WithTimeout(context.Background(), rulesLoadTimeout)
+	defer cancel()
+	if _, err := set.Reload(ctx); err != nil {
+		return nil, err
+	}
+	reload.Register("rules", []string{"data.rules.rules"}, func(ctx context.Context, m proto.Message) error {
+		prev := static.Swap(configRules(m.(*conf.Bootstrap).GetData().GetRules()))
+		if _, err := set.Reload(ctx); err != nil {
+			static.Store(prev)
+			return err
+		}
+		return nil
+	})
+	return set, nil
+}
+
+func configRules(c *conf.Data_Rules) *[]rules.Rule {
+	result := make([]rules.Rule, 0, len(c.GetRules()))
+	for _, r := range c.GetRules() {
+		result = append(result, rules.Rule{Name: r.GetName(), Expression: r.GetExpression()})
+	}
+	return &result
+}
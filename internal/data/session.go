@@ -0,0 +1,20 @@
+package data
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/session"
+)
+
+// NewSessions creates a store of sessions of T in Redis under prefix,
+// valid for ttl since their last use and encoded with the codec of
+// codec.UseSession, e.g. in the constructor of a repo:
+//
+//	carts := data.NewSessions[biz.Cart](d, codecs, "session:cart", 30*time.Minute)
+func NewSessions[T any](d *Data, codecs *codec.Selector, prefix string, ttl time.Duration) *session.Store[T] {
+	b := session.NewRedisBackend(func() redis.Cmdable { return d.Redis() }, prefix)
+	return session.New[T](b, codecs.For(codec.UseSession), ttl)
+}
@@ -0,0 +1,14 @@
+package data
+
+import (
+	"github.com/go-kratos/kratos-layout/pkg/statemachine"
+)
+
+// NewStateMachines creates the registry of the state machines of the
+// usecases. Repos define their machines on it; transitions and their
+// actions run in InTx, and StateTimeoutJob fires the timeouts. Instances
+// are stored in the database of the request's tenant, while timeouts are
+// only fired in the default one.
+func NewStateMachines(d *Data) *statemachine.Registry {
+	return statemachine.NewRegistry(d.DB, statemachine.WithTx(d.InTx))
+}
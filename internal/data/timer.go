@@ -0,0 +1,60 @@
+package data
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+	"github.com/go-kratos/kratos-layout/pkg/timer"
+)
+
+const (
+	defaultTimerTopic = "timers"
+	// defaultTimerMaxDelay is the default timerMaxDelaySec of RocketMQ.
+	defaultTimerMaxDelay = 72 * time.Hour
+)
+
+// NewTimers creates the timers of the usecases. Handlers registered on it
+// run in InTx with the removal of their timer. When data.timer is enabled
+// and RocketMQ is the broker, the timers are delivered by delay messages
+// on the topic of data.timer, and TimerJob sends the ones beyond the
+// longest delay once they get close; otherwise TimerJob fires them all.
+// Like state machine timeouts, timers are only fired in the default
+// database when tenants are configured.
+func NewTimers(c *conf.RocketMQ, nc *conf.Nats, rc *conf.Region, dc *conf.Data, d *Data, bus eventbus.Bus, logger log.Logger) (*timer.Service, func(), error) {
+	tc := dc.GetTimer()
+	opts := []timer.Option{timer.WithTx(d.InTx)}
+	local, _ := strconv.ParseBool(env.Get("LOCAL_MQ"))
+	if !tc.GetEnabled() || local || nc.GetUrl() != "" || nc.GetEmbedded() || c == nil {
+		return timer.New(d.DB, logger, opts...), func() {}, nil
+	}
+
+	topic := tc.GetTopic()
+	if topic == "" {
+		topic = defaultTimerTopic
+	}
+	maxDelay := defaultTimerMaxDelay
+	if tc.GetMaxDelay() != nil {
+		maxDelay = tc.GetMaxDelay().AsDuration()
+	}
+	send, cleanup, err := newTimerSender(c, region.NewScopeFromProto(rc), topic, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if send != nil {
+		opts = append(opts, timer.WithSender(send, maxDelay))
+	}
+	svc := timer.New(d.DB, logger, opts...)
+	if send != nil {
+		if err := bus.Subscribe(topic, svc.Deliver); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	return svc, cleanup, nil
+}
@@ -0,0 +1,17 @@
+//go:build norocketmq
+
+package data
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+	"github.com/go-kratos/kratos-layout/pkg/timer"
+)
+
+// newTimerSender returns no sender: without RocketMQ, TimerJob fires the
+// timers.
+func newTimerSender(*conf.RocketMQ, *region.Scope, string, log.Logger) (timer.SendFunc, func(), error) {
+	return nil, func() {}, nil
+}
@@ -0,0 +1,30 @@
+//go:build !norocketmq
+
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+	"github.com/go-kratos/kratos-layout/pkg/rocketmq"
+	"github.com/go-kratos/kratos-layout/pkg/timer"
+)
+
+// newTimerSender sends the delay messages of the timers to topic, which
+// must be a DELAY topic.
+func newTimerSender(c *conf.RocketMQ, scope *region.Scope, topic string, logger log.Logger) (timer.SendFunc, func(), error) {
+	p, cleanup, err := rocketmq.NewProducer(rocketmq.NewConfigFromProto(c), []string{scope.Topic(topic)}, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	send := func(ctx context.Context, key string, body []byte, at time.Time) error {
+		msg := &rocketmq.Message{Topic: scope.Topic(topic), Body: body, Keys: []string{key}, Tag: scope.Name()}
+		_, err := p.SendAt(ctx, msg, at)
+		return err
+	}
+	return send, cleanup, nil
+}
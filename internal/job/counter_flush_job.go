@@ -0,0 +1,57 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/counter"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+const defaultCounterFlushInterval = 10 * time.Second
+
+// counterFlusher is implemented by counter.Counters.
+type counterFlusher interface {
+	Flush(ctx context.Context) (int, error)
+}
+
+// CounterFlushJob adds the counter increments buffered in Redis to the
+// counters table when data.counter is enabled. Every instance may run it:
+// a batch is applied once however many flush it.
+type CounterFlushJob struct {
+	TickerJob
+	flusher counterFlusher
+	enabled bool
+}
+
+// NewCounterFlushJob creates the counter flush job.
+func NewCounterFlushJob(c *conf.Data, counters *counter.Counters, logs *zapLog.Scoped) *CounterFlushJob {
+	return newCounterFlushJob(c.GetCounter(), counters, logs.For("job/counter_flush").Logger())
+}
+
+func newCounterFlushJob(c *conf.Data_Counter, f counterFlusher, logger log.Logger) *CounterFlushJob {
+	j := &CounterFlushJob{flusher: f, enabled: c.GetEnabled()}
+	interval := defaultCounterFlushInterval
+	if c.GetFlushInterval() != nil {
+		interval = c.GetFlushInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("CounterFlushJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *CounterFlushJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	n, err := j.flusher.Flush(ctx)
+	if err != nil {
+		j.log.Errorf("counters: %v", err)
+		return
+	}
+	if n > 0 {
+		j.log.Debugf("counters: flushed %d counters", n)
+	}
+}
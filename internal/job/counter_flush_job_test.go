@@ -0,0 +1,39 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+type countingFlusher struct{ runs int }
+
+func (f *countingFlusher) Flush(context.Context) (int, error) {
+	f.runs++
+	return 3, nil
+}
+
+func TestCounterFlushJob(t *testing.T) {
+	ctx := context.Background()
+	f := &countingFlusher{}
+	j := newCounterFlushJob(nil, f, log.DefaultLogger)
+	if j.interval != defaultCounterFlushInterval {
+		t.Fatalf("unexpected default interval %s", j.interval)
+	}
+	j.execute(ctx)
+	if f.runs != 0 {
+		t.Fatal("disabled job flushed counters")
+	}
+
+	c := &conf.Data_Counter{Enabled: true, FlushInterval: durationpb.New(time.Minute)}
+	j = newCounterFlushJob(c, f, log.DefaultLogger)
+	j.execute(ctx)
+	if f.runs != 1 || j.interval != time.Minute {
+		t.Fatalf("unexpected run: runs %d, interval %s", f.runs, j.interval)
+	}
+}
@@ -0,0 +1,62 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+const (
+	defaultProbeInterval = 5 * time.Second
+	redisPingTimeout     = time.Second
+)
+
+// redisPinger is implemented by data.Data.
+type redisPinger interface {
+	PingRedis(ctx context.Context) error
+}
+
+// DependencyJob pings Redis when data.degradation has a policy for it and
+// reports it down and up again through lifecycle.DependencyDown and
+// DependencyUp, which switch its degradation policy on and off.
+type DependencyJob struct {
+	TickerJob
+	redis   redisPinger
+	tracker *lifecycle.DependencyTracker
+	enabled bool
+}
+
+// NewDependencyJob creates the dependency probe job.
+func NewDependencyJob(c *conf.Data, d *data.Data, logs *zapLog.Scoped) *DependencyJob {
+	return newDependencyJob(c.GetDegradation(), d, lifecycle.Default(), logs.For("job/dependency").Logger())
+}
+
+func newDependencyJob(c *conf.Data_Degradation, redis redisPinger, bus *lifecycle.Bus, logger log.Logger) *DependencyJob {
+	_, enabled := c.GetPolicies()[data.RedisDependency]
+	j := &DependencyJob{
+		redis:   redis,
+		tracker: lifecycle.NewDependencyTracker(bus, data.RedisDependency),
+		enabled: enabled,
+	}
+	interval := defaultProbeInterval
+	if c.GetProbeInterval() != nil {
+		interval = c.GetProbeInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("DependencyJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *DependencyJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisPingTimeout)
+	defer cancel()
+	j.tracker.Observe(ctx, j.redis.PingRedis(ctx))
+}
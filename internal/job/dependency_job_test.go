@@ -0,0 +1,40 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+type fakeRedis struct{ err error }
+
+func (r *fakeRedis) PingRedis(context.Context) error { return r.err }
+
+func TestDependencyJob(t *testing.T) {
+	ctx := context.Background()
+	bus := lifecycle.NewBus()
+	var kinds []string
+	bus.Subscribe(func(_ context.Context, e lifecycle.Event) { kinds = append(kinds, e.Kind()) })
+	redis := &fakeRedis{err: errors.New("connection refused")}
+
+	j := newDependencyJob(nil, redis, bus, log.DefaultLogger)
+	j.execute(ctx)
+	if len(kinds) != 0 {
+		t.Fatalf("probed redis without a policy: %v", kinds)
+	}
+
+	c := &conf.Data_Degradation{Policies: map[string]*conf.Data_Degradation_Policy{"redis": {Mode: "bypass"}}}
+	j = newDependencyJob(c, redis, bus, log.DefaultLogger)
+	j.execute(ctx)
+	j.execute(ctx)
+	redis.err = nil
+	j.execute(ctx)
+	if len(kinds) != 2 || kinds[0] != "dependency_down" || kinds[1] != "dependency_up" {
+		t.Fatalf("unexpected events %v", kinds)
+	}
+}
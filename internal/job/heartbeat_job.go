@@ -0,0 +1,123 @@
+package job
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+const (
+	defaultHeartbeatTopic     = "mq_heartbeat"
+	defaultHeartbeatInterval  = time.Minute
+	defaultHeartbeatThreshold = 10 * time.Second
+	heartbeatPoll             = 100 * time.Millisecond
+)
+
+// canaryStore shares canary receipts between the instances of the service.
+type canaryStore interface {
+	MarkCanary(ctx context.Context, id string, t time.Time) error
+	CanaryConsumed(ctx context.Context, id string) (time.Time, error)
+}
+
+// HeartbeatJob publishes a canary message on the event bus every interval
+// and checks that the service's consumer, on any instance, received it
+// within the threshold. Results are reported through
+// lifecycle.HeartbeatChecked (mq_end_to_end_healthy), so a broker or
+// subscription that silently stopped delivering is noticed.
+type HeartbeatJob struct {
+	TickerJob
+	bus       eventbus.Bus
+	store     canaryStore
+	enabled   bool
+	topic     string
+	threshold time.Duration
+}
+
+// NewHeartbeatJob creates the heartbeat job and subscribes to the canary
+// topic when data.mq_heartbeat is enabled.
+func NewHeartbeatJob(c *conf.Data, d *data.Data, bus eventbus.Bus, logs *zapLog.Scoped) (*HeartbeatJob, error) {
+	return newHeartbeatJob(c.GetMqHeartbeat(), d, bus, logs.For("job/heartbeat").Logger())
+}
+
+func newHeartbeatJob(c *conf.Data_MQHeartbeat, store canaryStore, bus eventbus.Bus, logger log.Logger) (*HeartbeatJob, error) {
+	j := &HeartbeatJob{
+		bus:       bus,
+		store:     store,
+		enabled:   c.GetEnabled(),
+		topic:     c.GetTopic(),
+		threshold: defaultHeartbeatThreshold,
+	}
+	if j.topic == "" {
+		j.topic = defaultHeartbeatTopic
+	}
+	if c.GetThreshold() != nil {
+		j.threshold = c.GetThreshold().AsDuration()
+	}
+	interval := defaultHeartbeatInterval
+	if c.GetInterval() != nil {
+		interval = c.GetInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("HeartbeatJob", interval, logger, j.execute, false)
+	if j.enabled {
+		if err := bus.Subscribe(j.topic, j.consume); err != nil {
+			return nil, fmt.Errorf("subscribe heartbeat topic %s: %w", j.topic, err)
+		}
+	}
+	return j, nil
+}
+
+// consume records the receipt of a canary published by any instance.
+func (j *HeartbeatJob) consume(ctx context.Context, e *eventbus.Event) error {
+	return j.store.MarkCanary(ctx, string(e.Body), time.Now())
+}
+
+func (j *HeartbeatJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	latency, err := j.check(ctx)
+	lifecycle.Emit(ctx, lifecycle.HeartbeatChecked{Topic: j.topic, Latency: latency, Err: err})
+}
+
+// check publishes a canary and waits for its receipt.
+func (j *HeartbeatJob) check(ctx context.Context) (time.Duration, error) {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	ctx, cancel := context.WithTimeout(ctx, j.threshold)
+	defer cancel()
+	sent := time.Now()
+	if err := j.bus.Publish(ctx, &eventbus.Event{Topic: j.topic, Key: id, Body: []byte(id)}); err != nil {
+		return 0, fmt.Errorf("publish canary: %w", err)
+	}
+	ticker := time.NewTicker(heartbeatPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("canary not consumed within %s", j.threshold)
+		case <-ticker.C:
+		}
+		consumed, err := j.store.CanaryConsumed(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return 0, fmt.Errorf("read canary receipt: %w", err)
+		}
+		if !consumed.IsZero() {
+			return max(consumed.Sub(sent), 0), nil
+		}
+	}
+}
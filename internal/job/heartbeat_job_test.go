@@ -0,0 +1,77 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+type memoryCanaries struct {
+	mu       sync.Mutex
+	consumed map[string]time.Time
+}
+
+func (m *memoryCanaries) MarkCanary(_ context.Context, id string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consumed[id] = t
+	return nil
+}
+
+func (m *memoryCanaries) CanaryConsumed(_ context.Context, id string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.consumed[id], nil
+}
+
+func TestHeartbeatJob_Check(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.NewLocal(log.DefaultLogger)
+	store := &memoryCanaries{consumed: map[string]time.Time{}}
+	c := &conf.Data_MQHeartbeat{Enabled: true, Threshold: durationpb.New(time.Second)}
+	j, err := newHeartbeatJob(c, store, bus, log.DefaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.topic != defaultHeartbeatTopic || j.interval != defaultHeartbeatInterval {
+		t.Fatalf("unexpected defaults: topic %s, interval %s", j.topic, j.interval)
+	}
+	if err := bus.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	latency, err := j.check(ctx)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if latency <= 0 || latency > time.Second {
+		t.Fatalf("unexpected latency %s", latency)
+	}
+
+	// A stopped consumer no longer receives canaries.
+	if err := bus.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	j.threshold = 200 * time.Millisecond
+	if _, err := j.check(ctx); err == nil {
+		t.Fatal("expected the check to fail without a consumer")
+	}
+}
+
+func TestHeartbeatJob_Disabled(t *testing.T) {
+	bus := eventbus.NewLocal(log.DefaultLogger)
+	if _, err := newHeartbeatJob(nil, &memoryCanaries{}, bus, log.DefaultLogger); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is subscribed, so the topic is still free.
+	if err := bus.Subscribe(defaultHeartbeatTopic, func(context.Context, *eventbus.Event) error { return nil }); err != nil {
+		t.Fatalf("disabled heartbeat subscribed: %v", err)
+	}
+}
@@ -1,20 +1,115 @@
 package job
 
 import (
+	"fmt"
+
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/google/wire"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
 )
 
+const defaultMaxCatchUpRuns = 10
+
 // Registry holds all background jobs for Kratos lifecycle management.
 type Registry struct {
+	Schedule     *Schedule
+	Weight       *WeightJob
+	Reconcile    *ReconcileJob
+	Maintenance  *MaintenanceJob
+	Heartbeat    *HeartbeatJob
+	Retention    *RetentionJob
+	StateTimeout *StateTimeoutJob
+	Timer        *TimerJob
+	CounterFlush *CounterFlushJob
+	RulesReload  *RulesReloadJob
+	Dependency   *DependencyJob
 }
 
-// Servers returns all jobs as transport.Server slice for kratos.Server().
+// Servers returns all jobs as transport.Server slice for kratos.Server(),
+// with the Schedule applied.
 func (r *Registry) Servers() []transport.Server {
-	return []transport.Server{}
+	servers := []transport.Server{r.Weight, r.Reconcile, r.Maintenance, r.Heartbeat, r.Retention, r.StateTimeout, r.Timer, r.CounterFlush, r.RulesReload, r.Dependency}
+	for _, s := range servers {
+		if j, ok := s.(interface{ tickerJob() *TickerJob }); ok {
+			r.Schedule.apply(j.tickerJob())
+		}
+	}
+	return servers
+}
+
+// Schedule makes the jobs keep their schedule across restarts when
+// data.jobs.persist_runs is set, see TickerJob.
+type Schedule struct {
+	runs       RunStore
+	catchUp    CatchUp
+	byJob      map[string]CatchUp
+	maxCatchUp int
+}
+
+// NewSchedule creates the schedule of data.jobs, storing the runs in Redis.
+func NewSchedule(c *conf.Data, d *data.Data) (*Schedule, error) {
+	return newSchedule(c.GetJobs(), d)
+}
+
+func newSchedule(c *conf.Data_Jobs, runs RunStore) (*Schedule, error) {
+	s := &Schedule{byJob: make(map[string]CatchUp), maxCatchUp: defaultMaxCatchUpRuns}
+	if !c.GetPersistRuns() {
+		return s, nil
+	}
+	s.runs = runs
+	var err error
+	if s.catchUp, err = parseCatchUp(c.GetCatchUp()); err != nil {
+		return nil, err
+	}
+	for job, policy := range c.GetCatchUpByJob() {
+		if s.byJob[job], err = parseCatchUp(policy); err != nil {
+			return nil, fmt.Errorf("%s: %w", job, err)
+		}
+	}
+	if c.GetMaxCatchUpRuns() > 0 {
+		s.maxCatchUp = int(c.GetMaxCatchUpRuns())
+	}
+	return s, nil
+}
+
+func parseCatchUp(s string) (CatchUp, error) {
+	switch s {
+	case "", "skip":
+		return CatchUpSkip, nil
+	case "run_once":
+		return CatchUpOnce, nil
+	case "run_all":
+		return CatchUpAll, nil
+	}
+	return 0, fmt.Errorf("unknown catch_up %q: want skip, run_once or run_all", s)
+}
+
+// apply sets the run store and catch-up policy of j.
+func (s *Schedule) apply(j *TickerJob) {
+	if s == nil || s.runs == nil {
+		return
+	}
+	j.runs, j.maxCatchUp = s.runs, s.maxCatchUp
+	j.catchUp = s.catchUp
+	if c, ok := s.byJob[j.name]; ok {
+		j.catchUp = c
+	}
 }
 
 // ProviderSet is the job providers.
 var ProviderSet = wire.NewSet(
+	NewSchedule,
+	NewWeightJob,
+	NewReconcileJob,
+	NewMaintenanceJob,
+	NewHeartbeatJob,
+	NewRetentionJob,
+	NewStateTimeoutJob,
+	NewTimerJob,
+	NewCounterFlushJob,
+	NewRulesReloadJob,
+	NewDependencyJob,
 	wire.Struct(new(Registry), "*"),
 )
@@ -0,0 +1,124 @@
+package job
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+const (
+	maintenanceTick         = time.Minute
+	maintenanceInterval     = time.Hour
+	maintenanceTimeout      = 10 * time.Minute
+	defaultRedisScanLimit   = 100000
+	defaultBigKeyBytes      = 1 << 20
+	defaultLongTxThreshold  = time.Minute
+	defaultMaintenanceMatch = "*"
+)
+
+type maintenanceTask struct {
+	name     string
+	interval time.Duration
+	next     time.Time
+	run      func(ctx context.Context) (findings int, err error)
+}
+
+// MaintenanceJob runs the data maintenance tasks enabled in data.maintenance,
+// each on its own interval. Results are logged and reported through
+// lifecycle.MaintenanceReported.
+type MaintenanceJob struct {
+	TickerJob
+	tasks []*maintenanceTask
+}
+
+// NewMaintenanceJob creates the maintenance job.
+func NewMaintenanceJob(c *conf.Data, d *data.Data, logs *zapLog.Scoped) *MaintenanceJob {
+	logger := logs.For("job/maintenance").Logger()
+	j := &MaintenanceJob{}
+	j.TickerJob = newTickerJob("MaintenanceJob", maintenanceTick, logger, j.execute, false)
+
+	m := c.GetMaintenance()
+	match := m.GetRedisMatch()
+	if match == "" {
+		match = defaultMaintenanceMatch
+	}
+	limit := int(m.GetRedisScanLimit())
+	if limit <= 0 {
+		limit = defaultRedisScanLimit
+	}
+	bigKeyBytes := m.GetBigKeyBytes()
+	if bigKeyBytes <= 0 {
+		bigKeyBytes = defaultBigKeyBytes
+	}
+	longTx := defaultLongTxThreshold
+	if m.GetLongTxThreshold() != nil {
+		longTx = m.GetLongTxThreshold().AsDuration()
+	}
+
+	j.add("redis_ttl_audit", m.GetRedisTtlAudit(), func(ctx context.Context) (int, error) {
+		scanned, persistent, sample, err := d.RedisKeysWithoutTTL(ctx, match, limit)
+		if err == nil && persistent > 0 {
+			j.log.Warnf("redis ttl audit: %d of %d scanned keys never expire, e.g. %s", persistent, scanned, strings.Join(sample, ", "))
+		}
+		return persistent, err
+	})
+	j.add("redis_big_keys", m.GetRedisBigKeys(), func(ctx context.Context) (int, error) {
+		scanned, big, err := d.RedisBigKeys(ctx, match, limit, bigKeyBytes)
+		for _, k := range big {
+			j.log.Warnf("redis big key: %s uses %d bytes", k.Key, k.Bytes)
+		}
+		if err == nil {
+			j.log.Infof("redis big keys: %d of %d scanned keys exceed %d bytes", len(big), scanned, bigKeyBytes)
+		}
+		return len(big), err
+	})
+	j.add("mysql_analyze", m.GetMysqlAnalyze(), func(ctx context.Context) (int, error) {
+		tables, err := d.AnalyzeTables(ctx, m.GetAnalyzeTables())
+		j.log.Infof("analyzed %d tables", len(tables))
+		return 0, err
+	})
+	j.add("mysql_long_tx", m.GetMysqlLongTx(), func(ctx context.Context) (int, error) {
+		txs, err := d.LongTransactions(ctx, longTx)
+		for _, tx := range txs {
+			j.log.Warnf("long transaction %s (thread %d) open for %s, state %s: %s",
+				tx.ID, tx.ThreadID, time.Since(tx.Started).Round(time.Second), tx.State, tx.Query)
+		}
+		return len(txs), err
+	})
+	return j
+}
+
+func (j *MaintenanceJob) add(name string, c *conf.Data_Maintenance_Task, run func(ctx context.Context) (int, error)) {
+	if !c.GetEnabled() {
+		return
+	}
+	interval := maintenanceInterval
+	if c.GetInterval() != nil {
+		interval = c.GetInterval().AsDuration()
+	}
+	j.tasks = append(j.tasks, &maintenanceTask{name: name, interval: interval, run: run})
+}
+
+// execute runs the tasks that are due. A task first runs on the first tick
+// after startup.
+func (j *MaintenanceJob) execute(ctx context.Context) {
+	for _, t := range j.tasks {
+		now := time.Now()
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Before(t.next) {
+			continue
+		}
+		t.next = now.Add(t.interval)
+		taskCtx, cancel := context.WithTimeout(ctx, maintenanceTimeout)
+		findings, err := t.run(taskCtx)
+		cancel()
+		lifecycle.Emit(ctx, lifecycle.MaintenanceReported{Task: t.name, Findings: findings, Err: err})
+	}
+}
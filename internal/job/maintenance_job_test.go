@@ -0,0 +1,38 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+func TestMaintenanceJob_Schedule(t *testing.T) {
+	j := &MaintenanceJob{}
+	runs := map[string]int{}
+	task := func(name string) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) {
+			runs[name]++
+			return 0, nil
+		}
+	}
+	j.add("hourly", &conf.Data_Maintenance_Task{Enabled: true}, task("hourly"))
+	j.add("always", &conf.Data_Maintenance_Task{Enabled: true, Interval: durationpb.New(time.Nanosecond)}, task("always"))
+	j.add("disabled", &conf.Data_Maintenance_Task{}, task("disabled"))
+	j.add("unset", nil, task("unset"))
+
+	if len(j.tasks) != 2 {
+		t.Fatalf("expected 2 enabled tasks, got %d", len(j.tasks))
+	}
+	ctx := context.Background()
+	j.execute(ctx)
+	time.Sleep(time.Millisecond)
+	j.execute(ctx)
+
+	if runs["hourly"] != 1 || runs["always"] != 2 {
+		t.Fatalf("unexpected runs: %v", runs)
+	}
+}
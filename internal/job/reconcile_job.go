@@ -0,0 +1,51 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/reconcile"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+)
+
+const reconcileInterval = time.Minute
+
+// ReconcileJob runs the reconcilers one after another on every tick.
+// Add a reconciler here for each state that must converge with an external
+// system (registry metadata, third-party sync, cache warm-up).
+type ReconcileJob struct {
+	TickerJob
+	runners []reconcile.Runner
+}
+
+// NewReconcileJob creates the reconciliation job.
+func NewReconcileJob(r *nacos.Registry, logs *zapLog.Scoped) *ReconcileJob {
+	logger := logs.For("job/reconcile").Logger()
+	j := &ReconcileJob{
+		runners: []reconcile.Runner{
+			newRegistrySync(r, logger),
+		},
+	}
+	j.TickerJob = newTickerJob("ReconcileJob", reconcileInterval, logger, j.execute, false)
+	return j
+}
+
+// Results returns the outcome of the last run of every reconciler.
+func (j *ReconcileJob) Results() []reconcile.Result {
+	results := make([]reconcile.Result, 0, len(j.runners))
+	for _, r := range j.runners {
+		results = append(results, r.Last())
+	}
+	return results
+}
+
+func (j *ReconcileJob) execute(ctx context.Context) {
+	for _, r := range j.runners {
+		if ctx.Err() != nil {
+			return
+		}
+		// Failures are reported through lifecycle.Reconciled.
+		_, _ = r.Run(ctx)
+	}
+}
@@ -0,0 +1,65 @@
+package job
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/reconcile"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+)
+
+// newRegistrySync restores this instance's Nacos registration when the
+// server lost it (e.g. after a Nacos restart) or its weight, enabled flag
+// or metadata drifted. Other instances of the service are never touched.
+func newRegistrySync(r *nacos.Registry, logger log.Logger) reconcile.Runner {
+	desired := func(context.Context) (map[string]nacos.Instance, error) {
+		result := make(map[string]nacos.Instance)
+		for _, in := range r.Local() {
+			result[in.Key()] = in
+		}
+		return result, nil
+	}
+	return reconcile.New(reconcile.Spec[nacos.Instance]{
+		Name:    "nacos-registration",
+		Desired: desired,
+		Actual: func(ctx context.Context) (map[string]nacos.Instance, error) {
+			local, _ := desired(ctx)
+			services := make(map[string]struct{})
+			for _, in := range local {
+				services[in.Service] = struct{}{}
+			}
+			result := make(map[string]nacos.Instance)
+			for service := range services {
+				remote, err := r.Remote(ctx, service)
+				if err != nil {
+					return nil, err
+				}
+				for _, in := range remote {
+					if _, ok := local[in.Key()]; ok {
+						result[in.Key()] = in
+					}
+				}
+			}
+			return result, nil
+		},
+		Equal: func(d, a nacos.Instance) bool {
+			if d.Weight != a.Weight || d.Enabled != a.Enabled {
+				return false
+			}
+			// Nacos may add its own metadata; only ours must match.
+			for k, v := range d.Metadata {
+				if a.Metadata[k] != v {
+					return false
+				}
+			}
+			return true
+		},
+		Apply: func(ctx context.Context, c reconcile.Change[nacos.Instance]) error {
+			if c.Op == reconcile.OpDelete {
+				return nil
+			}
+			return r.Restore(ctx, c.Key)
+		},
+	}, logger, reconcile.WithRateLimit(5, 1))
+}
@@ -0,0 +1,60 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/internal/data"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+const defaultRetentionInterval = 24 * time.Hour
+
+// retentionEnforcer is implemented by data.Data.
+type retentionEnforcer interface {
+	EnforceRetention(ctx context.Context, batch int) ([]orm.RetentionResult, error)
+}
+
+// RetentionJob enforces the retention policies of the models when
+// data.retention is enabled: expired rows are deleted or anonymized unless
+// they are on legal hold, and every change is recorded in audit_logs.
+type RetentionJob struct {
+	TickerJob
+	enforcer retentionEnforcer
+	enabled  bool
+	batch    int
+}
+
+// NewRetentionJob creates the retention job.
+func NewRetentionJob(c *conf.Data, d *data.Data, logs *zapLog.Scoped) *RetentionJob {
+	return newRetentionJob(c.GetRetention(), d, logs.For("job/retention").Logger())
+}
+
+func newRetentionJob(c *conf.Data_Retention, e retentionEnforcer, logger log.Logger) *RetentionJob {
+	j := &RetentionJob{enforcer: e, enabled: c.GetEnabled(), batch: int(c.GetBatchSize())}
+	interval := defaultRetentionInterval
+	if c.GetInterval() != nil {
+		interval = c.GetInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("RetentionJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *RetentionJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	results, err := j.enforcer.EnforceRetention(ctx, j.batch)
+	for _, r := range results {
+		if r.Affected > 0 || r.Held > 0 {
+			j.log.Infof("retention: %s %s: %d rows, %d kept on legal hold", r.Action, r.Entity, r.Affected, r.Held)
+		}
+	}
+	if err != nil {
+		j.log.Errorf("retention: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+type countingEnforcer struct {
+	runs  int
+	batch int
+}
+
+func (e *countingEnforcer) EnforceRetention(_ context.Context, batch int) ([]orm.RetentionResult, error) {
+	e.runs++
+	e.batch = batch
+	return []orm.RetentionResult{{Entity: "sessions", Action: orm.RetentionDelete, Affected: 3, Held: 1}}, nil
+}
+
+func TestRetentionJob(t *testing.T) {
+	ctx := context.Background()
+	e := &countingEnforcer{}
+	j := newRetentionJob(nil, e, log.DefaultLogger)
+	if j.interval != defaultRetentionInterval {
+		t.Fatalf("unexpected default interval %s", j.interval)
+	}
+	j.execute(ctx)
+	if e.runs != 0 {
+		t.Fatal("disabled job enforced retention")
+	}
+
+	c := &conf.Data_Retention{Enabled: true, Interval: durationpb.New(time.Hour), BatchSize: 100}
+	j = newRetentionJob(c, e, log.DefaultLogger)
+	j.execute(ctx)
+	if e.runs != 1 || e.batch != 100 || j.interval != time.Hour {
+		t.Fatalf("unexpected run: runs %d, batch %d, interval %s", e.runs, e.batch, j.interval)
+	}
+}
@@ -0,0 +1,57 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/rules"
+)
+
+const defaultRulesReloadInterval = 30 * time.Second
+
+// rulesReloader is implemented by rules.Set.
+type rulesReloader interface {
+	Reload(ctx context.Context) (bool, error)
+}
+
+// RulesReloadJob reloads the business rules from the rules table when
+// data.rules.db is set, so edited rules apply without a redeploy. Engines
+// recompile the changed rules on their next evaluation.
+type RulesReloadJob struct {
+	TickerJob
+	reloader rulesReloader
+	enabled  bool
+}
+
+// NewRulesReloadJob creates the rules reload job.
+func NewRulesReloadJob(c *conf.Data, set *rules.Set, logs *zapLog.Scoped) *RulesReloadJob {
+	return newRulesReloadJob(c.GetRules(), set, logs.For("job/rules_reload").Logger())
+}
+
+func newRulesReloadJob(c *conf.Data_Rules, r rulesReloader, logger log.Logger) *RulesReloadJob {
+	j := &RulesReloadJob{reloader: r, enabled: c.GetDb()}
+	interval := defaultRulesReloadInterval
+	if c.GetReloadInterval() != nil {
+		interval = c.GetReloadInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("RulesReloadJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *RulesReloadJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	changed, err := j.reloader.Reload(ctx)
+	if err != nil {
+		j.log.Errorf("rules: %v", err)
+		return
+	}
+	if changed {
+		j.log.Info("rules: reloaded changed rules")
+	}
+}
@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+type countingReloader struct {
+	runs int
+	err  error
+}
+
+func (r *countingReloader) Reload(context.Context) (bool, error) {
+	r.runs++
+	return r.err == nil, r.err
+}
+
+func TestRulesReloadJob(t *testing.T) {
+	ctx := context.Background()
+	r := &countingReloader{}
+	j := newRulesReloadJob(&conf.Data_Rules{Rules: []*conf.Data_Rules_Rule{{Name: "r", Expression: "true"}}}, r, log.DefaultLogger)
+	if j.interval != defaultRulesReloadInterval {
+		t.Fatalf("unexpected default interval %s", j.interval)
+	}
+	j.execute(ctx)
+	if r.runs != 0 {
+		t.Fatal("reloaded rules only defined in the config")
+	}
+
+	j = newRulesReloadJob(&conf.Data_Rules{Db: true, ReloadInterval: durationpb.New(time.Minute)}, r, log.DefaultLogger)
+	j.execute(ctx)
+	r.err = errors.New("db down")
+	j.execute(ctx)
+	if r.runs != 2 || j.interval != time.Minute {
+		t.Fatalf("unexpected runs: runs %d, interval %s", r.runs, j.interval)
+	}
+}
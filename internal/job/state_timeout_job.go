@@ -0,0 +1,57 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/statemachine"
+)
+
+const defaultStateTimeoutInterval = 10 * time.Second
+
+// timeoutFirer is implemented by statemachine.Registry.
+type timeoutFirer interface {
+	FireTimeouts(ctx context.Context, limit int) (int, error)
+}
+
+// StateTimeoutJob fires the timeout transitions of the state machines
+// defined on the statemachine.Registry when data.state_machine is enabled,
+// e.g. cancelling orders left unpaid.
+type StateTimeoutJob struct {
+	TickerJob
+	firer   timeoutFirer
+	enabled bool
+	batch   int
+}
+
+// NewStateTimeoutJob creates the state machine timeout job.
+func NewStateTimeoutJob(c *conf.Data, r *statemachine.Registry, logs *zapLog.Scoped) *StateTimeoutJob {
+	return newStateTimeoutJob(c.GetStateMachine(), r, logs.For("job/state_timeout").Logger())
+}
+
+func newStateTimeoutJob(c *conf.Data_StateMachine, f timeoutFirer, logger log.Logger) *StateTimeoutJob {
+	j := &StateTimeoutJob{firer: f, enabled: c.GetEnabled(), batch: int(c.GetBatchSize())}
+	interval := defaultStateTimeoutInterval
+	if c.GetInterval() != nil {
+		interval = c.GetInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("StateTimeoutJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *StateTimeoutJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	fired, err := j.firer.FireTimeouts(ctx, j.batch)
+	if fired > 0 {
+		j.log.Infof("state machines: fired %d timeouts", fired)
+	}
+	if err != nil {
+		j.log.Errorf("state machines: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+type countingFirer struct {
+	runs  int
+	limit int
+}
+
+func (f *countingFirer) FireTimeouts(_ context.Context, limit int) (int, error) {
+	f.runs++
+	f.limit = limit
+	return 2, nil
+}
+
+func TestStateTimeoutJob(t *testing.T) {
+	ctx := context.Background()
+	f := &countingFirer{}
+	j := newStateTimeoutJob(nil, f, log.DefaultLogger)
+	if j.interval != defaultStateTimeoutInterval {
+		t.Fatalf("unexpected default interval %s", j.interval)
+	}
+	j.execute(ctx)
+	if f.runs != 0 {
+		t.Fatal("disabled job fired timeouts")
+	}
+
+	c := &conf.Data_StateMachine{Enabled: true, Interval: durationpb.New(time.Minute), BatchSize: 50}
+	j = newStateTimeoutJob(c, f, log.DefaultLogger)
+	j.execute(ctx)
+	if f.runs != 1 || f.limit != 50 || j.interval != time.Minute {
+		t.Fatalf("unexpected run: runs %d, limit %d, interval %s", f.runs, f.limit, j.interval)
+	}
+}
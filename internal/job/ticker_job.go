@@ -2,15 +2,43 @@ package job
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
 )
 
+// CatchUp is what a job does at startup about the runs it missed while no
+// instance was running, e.g. during a deploy or an outage.
+type CatchUp int
+
+const (
+	// CatchUpSkip waits for the next run on the schedule of the last one.
+	CatchUpSkip CatchUp = iota
+	// CatchUpOnce runs once right away.
+	CatchUpOnce
+	// CatchUpAll runs once per missed interval right away, up to a limit.
+	CatchUpAll
+)
+
+// RunStore persists when each job last ran successfully, shared by the
+// instances of the service.
+type RunStore interface {
+	LastJobRun(ctx context.Context, job string) (time.Time, error)
+	SetLastJobRun(ctx context.Context, job string, t time.Time) error
+}
+
 // TickerJob provides common ticker-based background job lifecycle management.
 // Embed this in concrete job types to get Start/Stop for free.
 // Stop is safe to call multiple times (protected by sync.Once).
+//
+// With a RunStore, the job keeps its schedule across restarts: it waits for
+// the rest of the interval since the last run instead of running again
+// right away, and applies its CatchUp when one or more runs were missed.
 type TickerJob struct {
 	name             string
 	log              *log.Helper
@@ -20,6 +48,10 @@ type TickerJob struct {
 	executeImmediate bool
 	executeFn        func(ctx context.Context)
 	wg               sync.WaitGroup
+
+	runs       RunStore
+	catchUp    CatchUp
+	maxCatchUp int
 }
 
 func newTickerJob(name string, interval time.Duration, logger log.Logger, executeFn func(ctx context.Context), executeImmediate bool) TickerJob {
@@ -33,19 +65,32 @@ func newTickerJob(name string, interval time.Duration, logger log.Logger, execut
 	}
 }
 
+// tickerJob returns j; it lets Schedule reach the TickerJob of a job.
+func (j *TickerJob) tickerJob() *TickerJob { return j }
+
 // Start implements transport.Server.
 func (j *TickerJob) Start(ctx context.Context) error {
 	j.log.Infof("%s started, interval: %s", j.name, j.interval)
 
-	if j.executeImmediate {
+	first, catchUp, known := j.interval, []time.Time(nil), false
+	if j.runs != nil {
+		first, catchUp, known = j.resume(ctx, time.Now())
+	}
+	if known {
+		j.wg.Add(1)
+		func() {
+			defer j.wg.Done()
+			j.catchUpRuns(ctx, catchUp)
+		}()
+	} else if j.executeImmediate {
 		j.wg.Add(1)
 		go func() {
 			defer j.wg.Done()
-			j.executeFn(ctx)
+			j.run(ctx, time.Now())
 		}()
 	}
 
-	ticker := time.NewTicker(j.interval)
+	ticker := time.NewTicker(first)
 	defer ticker.Stop()
 
 	for {
@@ -59,15 +104,102 @@ func (j *TickerJob) Start(ctx context.Context) error {
 			j.wg.Wait()
 			return nil
 		case <-ticker.C:
+			if first != j.interval {
+				ticker.Reset(j.interval)
+				first = j.interval
+			}
 			j.wg.Add(1)
 			func() {
 				defer j.wg.Done()
-				j.executeFn(ctx)
+				j.run(ctx, time.Now())
 			}()
 		}
 	}
 }
 
+// resume reads the last run of the job and returns the delay of its first
+// tick and the missed runs to catch up, as the times they were due. known
+// is false when the last run is unknown: the job then starts as without a
+// RunStore.
+func (j *TickerJob) resume(ctx context.Context, now time.Time) (first time.Duration, catchUp []time.Time, known bool) {
+	last, err := j.runs.LastJobRun(ctx, j.name)
+	if err != nil {
+		j.log.Warnf("%s: read last run: %v", j.name, err)
+		return j.interval, nil, false
+	}
+	if last.IsZero() {
+		return j.interval, nil, false
+	}
+	elapsed := now.Sub(last)
+	if elapsed < j.interval {
+		return j.interval - elapsed, nil, true
+	}
+	missed := int(elapsed / j.interval)
+	switch j.catchUp {
+	case CatchUpOnce:
+		catchUp = []time.Time{now}
+	case CatchUpAll:
+		n := missed
+		if j.maxCatchUp > 0 && n > j.maxCatchUp {
+			n = j.maxCatchUp
+		}
+		for i := missed - n + 1; i <= missed; i++ {
+			catchUp = append(catchUp, last.Add(time.Duration(i)*j.interval))
+		}
+	default:
+		// Keep the phase of the schedule: the next run is due at last plus
+		// a whole number of intervals.
+		return j.interval - elapsed%j.interval, nil, true
+	}
+	j.log.Infof("%s: last run %s ago, missed %d runs, catching up %d", j.name, elapsed.Truncate(time.Second), missed, len(catchUp))
+	return j.interval, catchUp, true
+}
+
+// catchUpRuns runs the job once per missed run, recording each as run when
+// it was due, so a restart during the catch-up resumes it. It stops when
+// the job is stopped or a run fails.
+func (j *TickerJob) catchUpRuns(ctx context.Context, due []time.Time) {
+	for _, at := range due {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.stopCh:
+			return
+		default:
+		}
+		if !j.run(ctx, at) {
+			return
+		}
+	}
+}
+
+// run executes the job once, emitting JobStarted and JobFinished, and
+// records a successful run at the given time in the RunStore. A panic is
+// recovered and reported as a failed run.
+func (j *TickerJob) run(ctx context.Context, at time.Time) bool {
+	lifecycle.Emit(ctx, lifecycle.JobStarted{Job: j.name})
+	start := time.Now()
+	err := j.execute(ctx)
+	lifecycle.Emit(ctx, lifecycle.JobFinished{Job: j.name, Duration: time.Since(start), Err: err})
+	if err == nil && j.runs != nil {
+		if err := j.runs.SetLastJobRun(ctx, j.name, at); err != nil {
+			j.log.Warnf("%s: record run: %v", j.name, err)
+		}
+	}
+	return err == nil
+}
+
+func (j *TickerJob) execute(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			j.log.Errorf("%s panic: %v\n%s", j.name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	j.executeFn(ctx)
+	return nil
+}
+
 // Stop implements transport.Server. Safe to call multiple times.
 func (j *TickerJob) Stop(_ context.Context) error {
 	j.stopOnce.Do(func() {
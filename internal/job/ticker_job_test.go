@@ -3,11 +3,14 @@ package job
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
 )
 
 func TestTickerJob_StartStop(t *testing.T) {
@@ -169,3 +172,122 @@ func TestTickerJob_StopWaitsForExecution(t *testing.T) {
 		t.Fatal("Start did not return in time")
 	}
 }
+
+func TestTickerJob_RecoversPanic(t *testing.T) {
+	var count atomic.Int32
+	j := newTickerJob("test-job", 20*time.Millisecond, log.DefaultLogger, func(_ context.Context) {
+		count.Add(1)
+		panic("boom")
+	}, true)
+
+	ctx := context.Background()
+	done := make(chan error, 1)
+	go func() { done <- j.Start(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := j.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if got := count.Load(); got < 2 {
+		t.Errorf("expected the job to keep running after a panic, got %d executions", got)
+	}
+}
+
+// memRuns is an in-memory RunStore.
+type memRuns struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (m *memRuns) LastJobRun(_ context.Context, job string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last[job], nil
+}
+
+func (m *memRuns) SetLastJobRun(_ context.Context, job string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last[job] = t
+	return nil
+}
+
+func TestTickerJob_Resume(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		last      time.Time
+		catchUp   CatchUp
+		first     time.Duration
+		catchUps  int
+		lastDue   time.Time
+		wantKnown bool
+	}{
+		{name: "never ran", first: time.Hour},
+		{name: "ran recently", last: now.Add(-20 * time.Minute), first: 40 * time.Minute, wantKnown: true},
+		{name: "skip keeps the phase", last: now.Add(-150 * time.Minute), first: 30 * time.Minute, wantKnown: true},
+		{name: "run once", last: now.Add(-150 * time.Minute), catchUp: CatchUpOnce, first: time.Hour, catchUps: 1, lastDue: now, wantKnown: true},
+		{name: "run all", last: now.Add(-150 * time.Minute), catchUp: CatchUpAll, first: time.Hour, catchUps: 2, lastDue: now.Add(-30 * time.Minute), wantKnown: true},
+		{name: "run all is bounded", last: now.Add(-30 * time.Hour), catchUp: CatchUpAll, first: time.Hour, catchUps: 3, lastDue: now, wantKnown: true},
+	}
+	for _, tt := range tests {
+		runs := &memRuns{last: map[string]time.Time{}}
+		if !tt.last.IsZero() {
+			runs.last["test-job"] = tt.last
+		}
+		j := newTickerJob("test-job", time.Hour, log.DefaultLogger, func(_ context.Context) {}, false)
+		j.runs, j.catchUp, j.maxCatchUp = runs, tt.catchUp, 3
+		first, due, known := j.resume(context.Background(), now)
+		if first != tt.first || len(due) != tt.catchUps || known != tt.wantKnown {
+			t.Errorf("%s: got first %s, %d catch-ups, known %v", tt.name, first, len(due), known)
+			continue
+		}
+		if len(due) > 0 && !due[len(due)-1].Equal(tt.lastDue) {
+			t.Errorf("%s: last catch-up due at %s, want %s", tt.name, due[len(due)-1], tt.lastDue)
+		}
+	}
+}
+
+func TestTickerJob_CatchUpAll(t *testing.T) {
+	last := time.Now().Add(-350 * time.Millisecond)
+	runs := &memRuns{last: map[string]time.Time{"test-job": last}}
+	var count atomic.Int32
+	j := newTickerJob("test-job", 100*time.Millisecond, log.DefaultLogger, func(_ context.Context) {
+		count.Add(1)
+	}, false)
+	s, err := newSchedule(&conf.Data_Jobs{PersistRuns: true, CatchUp: "run_once", CatchUpByJob: map[string]string{"test-job": "run_all"}}, runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.apply(&j)
+
+	ctx := context.Background()
+	done := make(chan error, 1)
+	go func() { done <- j.Start(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	if err := j.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	<-done
+
+	if got := count.Load(); got != 3 {
+		t.Errorf("expected the 3 missed runs, got %d", got)
+	}
+	if got, want := runs.last["test-job"], last.Add(300*time.Millisecond); !got.Equal(want) {
+		t.Errorf("recorded last run %s, want the last due one %s", got, want)
+	}
+}
+
+func TestNewSchedule(t *testing.T) {
+	if _, err := newSchedule(&conf.Data_Jobs{PersistRuns: true, CatchUp: "all"}, &memRuns{}); err == nil {
+		t.Error("expected an unknown catch_up to fail")
+	}
+	s, err := newSchedule(&conf.Data_Jobs{CatchUp: "all"}, &memRuns{})
+	if err != nil || s.runs != nil {
+		t.Errorf("expected runs not to be persisted by default, got %v", err)
+	}
+}
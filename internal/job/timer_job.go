@@ -0,0 +1,57 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/timer"
+)
+
+const defaultTimerInterval = 10 * time.Second
+
+// timerPoller is implemented by timer.Service.
+type timerPoller interface {
+	Poll(ctx context.Context, limit int) (int, error)
+}
+
+// TimerJob sends the delay messages of the workflow timers getting close
+// and fires the ones due whose message is late, or all of them without
+// RocketMQ, when data.timer is enabled.
+type TimerJob struct {
+	TickerJob
+	poller  timerPoller
+	enabled bool
+	batch   int
+}
+
+// NewTimerJob creates the workflow timer job.
+func NewTimerJob(c *conf.Data, s *timer.Service, logs *zapLog.Scoped) *TimerJob {
+	return newTimerJob(c.GetTimer(), s, logs.For("job/timer").Logger())
+}
+
+func newTimerJob(c *conf.Data_Timer, p timerPoller, logger log.Logger) *TimerJob {
+	j := &TimerJob{poller: p, enabled: c.GetEnabled(), batch: int(c.GetBatchSize())}
+	interval := defaultTimerInterval
+	if c.GetInterval() != nil {
+		interval = c.GetInterval().AsDuration()
+	}
+	j.TickerJob = newTickerJob("TimerJob", interval, logger, j.execute, false)
+	return j
+}
+
+func (j *TimerJob) execute(ctx context.Context) {
+	if !j.enabled {
+		return
+	}
+	fired, err := j.poller.Poll(ctx, j.batch)
+	if fired > 0 {
+		j.log.Infof("timers: fired %d", fired)
+	}
+	if err != nil {
+		j.log.Errorf("timers: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+type countingPoller struct {
+	runs  int
+	limit int
+}
+
+func (p *countingPoller) Poll(_ context.Context, limit int) (int, error) {
+	p.runs++
+	p.limit = limit
+	return 1, nil
+}
+
+func TestTimerJob(t *testing.T) {
+	ctx := context.Background()
+	p := &countingPoller{}
+	j := newTimerJob(nil, p, log.DefaultLogger)
+	if j.interval != defaultTimerInterval {
+		t.Fatalf("unexpected default interval %s", j.interval)
+	}
+	j.execute(ctx)
+	if p.runs != 0 {
+		t.Fatal("disabled job polled timers")
+	}
+
+	c := &conf.Data_Timer{Enabled: true, Interval: durationpb.New(time.Minute), BatchSize: 20}
+	j = newTimerJob(c, p, log.DefaultLogger)
+	j.execute(ctx)
+	if p.runs != 1 || p.limit != 20 || j.interval != time.Minute {
+		t.Fatalf("unexpected run: runs %d, limit %d, interval %s", p.runs, p.limit, j.interval)
+	}
+}
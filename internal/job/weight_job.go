@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/internal/data"
+	"github.com/go-kratos/kratos-layout/pkg/health"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+)
+
+const (
+	weightInterval  = 10 * time.Second
+	weightMin       = 1  // Nacos rejects zero weights
+	weightRecoverBy = 20 // max weight regained per interval
+	dbLatencyGood   = 50 * time.Millisecond
+	dbLatencyBad    = time.Second
+	dbPingTimeout   = 2 * time.Second
+)
+
+// WeightJob lowers the Nacos weight of this instance while it is unhealthy
+// (server error rate, DB latency) and restores it gradually on recovery, so
+// weighted load balancing on clients shifts traffic away from it.
+type WeightJob struct {
+	TickerJob
+	registry *nacos.Registry
+	probes   []health.Probe
+	weigher  *health.Weigher
+	applied  float64 // weight last accepted by the registry
+}
+
+// NewWeightJob creates the weight feedback job.
+func NewWeightJob(r *nacos.Registry, errs *health.ErrorRate, d *data.Data, logs *zapLog.Scoped) *WeightJob {
+	db := lifecycle.NewDependencyTracker(lifecycle.Default(), "mysql")
+	j := &WeightJob{
+		registry: r,
+		probes: []health.Probe{
+			errs,
+			health.Latency(func(ctx context.Context) error {
+				ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+				defer cancel()
+				err := d.Ping(ctx)
+				db.Observe(ctx, err)
+				return err
+			}, dbLatencyGood, dbLatencyBad),
+		},
+		weigher: health.NewWeigher(r.Weight(), weightMin, weightRecoverBy),
+		applied: r.Weight(),
+	}
+	j.TickerJob = newTickerJob("WeightJob", weightInterval, logs.For("job/weight").Logger(), j.execute, false)
+	return j
+}
+
+func (j *WeightJob) execute(ctx context.Context) {
+	score := health.Min(ctx, j.probes...)
+	weight, _ := j.weigher.Next(score)
+	if weight == j.applied {
+		return
+	}
+	if err := j.registry.SetWeight(ctx, weight); err != nil {
+		j.log.Errorf("set instance weight to %v: %v", weight, err)
+		return
+	}
+	j.applied = weight
+	j.log.Infof("instance weight set to %v (health score %.2f)", weight, score)
+}
@@ -0,0 +1,87 @@
+// Package ormtest opens the databases of tests: a private in-memory sqlite
+// database per test, or the MySQL server named in the environment.
+package ormtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// MySQLDSNEnv names the environment variable holding the DSN of the MySQL
+// server of the tests needing one, e.g. root:root@tcp(127.0.0.1:3306)/.
+const MySQLDSNEnv = "TEST_MYSQL_DSN"
+
+type options struct {
+	name   string
+	params string
+	config func(*gorm.Config)
+}
+
+// Option configures Open.
+type Option func(*options)
+
+// WithName appends suffix to the name of the database, so a test opens
+// several of them.
+func WithName(suffix string) Option {
+	return func(o *options) { o.name = suffix }
+}
+
+// WithSharedCache shares the database between the connections of the pool,
+// which otherwise each get an empty one.
+func WithSharedCache() Option {
+	return func(o *options) { o.params += "&cache=shared" }
+}
+
+// WithForeignKeys enforces foreign key constraints.
+func WithForeignKeys() Option {
+	return func(o *options) { o.params += "&_foreign_keys=1" }
+}
+
+// WithConfig adjusts the gorm config, e.g. to prepare statements.
+func WithConfig(fn func(*gorm.Config)) Option {
+	return func(o *options) { o.config = fn }
+}
+
+// Open opens an in-memory sqlite database named after t, with statement
+// logs discarded, and closes it when t ends.
+func Open(t testing.TB, opts ...Option) *gorm.DB {
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &gorm.Config{Logger: logger.Discard}
+	if o.config != nil {
+		o.config(c)
+	}
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+o.name+"?mode=memory"+o.params), c)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// MySQL returns the config of the server of MySQLDSNEnv, and skips t when
+// the variable is not set.
+func MySQL(t testing.TB) *mysql.Config {
+	t.Helper()
+	dsn := os.Getenv(MySQLDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", MySQLDSNEnv)
+	}
+	c, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", MySQLDSNEnv, err)
+	}
+	return c
+}
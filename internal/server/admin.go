@@ -0,0 +1,43 @@
+package server
+
+import (
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+	"github.com/go-kratos/kratos-layout/pkg/quota"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+	"github.com/go-kratos/kratos-layout/pkg/support"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// NewAdminServer new an admin server for operational endpoints.
+func NewAdminServer(c *conf.Server, gs *grpc.Server, hs *http.Server, m Maintainer, r *nacos.Registry, q *quota.Quota, bundle *support.Bundle, prof *profile.Profiler, traces debugtrace.Store, audit admin.AuditStore, search AuditSearcher, ob *outbox.Outbox, logger log.Logger) *admin.Server {
+	token := c.Admin.GetToken()
+	if token == "" {
+		token = env.Get("ADMIN_TOKEN")
+	}
+	srv := admin.NewServer(c.Admin.GetNetwork(), c.Admin.GetAddr(), token, logger)
+	srv.SetAuditStore(audit)
+	for _, op := range c.Admin.GetOperators() {
+		srv.AddOperator(op.GetName(), op.GetToken(), op.GetPermissions()...)
+	}
+	srv.HandleFunc("/catalog", admin.CatalogHandler(gs, hs, newRoutePolicy(c)))
+	handleMetrics(srv)
+	srv.HandleFunc("/ready", readyHandler(m))
+	srv.HandleFunc("/runbook", newRunbook(m, r, logger).Handler())
+	srv.HandleFunc("/quota", quotaReportHandler(q))
+	srv.HandleFunc("/support-bundle", bundle.Handler())
+	srv.HandleFunc("/profile", profileHandler(prof))
+	srv.HandleFunc("/debug-traces", debugTraceHandler(traces))
+	srv.HandleFunc("/audit", admin.AuditHandler(audit))
+	srv.HandleFunc("/audit/search", auditSearchHandler(search))
+	srv.HandleFunc("/audit/export", auditExportHandler(search))
+	srv.HandleFunc("/outbox", outboxHandler(ob))
+	return srv
+}
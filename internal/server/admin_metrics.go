@@ -0,0 +1,14 @@
+//go:build !nometrics
+
+package server
+
+import (
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// handleMetrics exposes the default prometheus registry on /admin/metrics.
+func handleMetrics(srv *admin.Server) {
+	srv.HandleFunc("/metrics", promhttp.Handler().ServeHTTP)
+}
@@ -0,0 +1,8 @@
+//go:build nometrics
+
+package server
+
+import "github.com/go-kratos/kratos-layout/pkg/admin"
+
+// handleMetrics is a no-op: metrics are compiled out.
+func handleMetrics(*admin.Server) {}
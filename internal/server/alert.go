@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/alert"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// NewAlerter creates the alerter from config and subscribes it to lifecycle
+// events (dependency down/up, failed jobs). Panics are reported by the
+// recovery middleware.
+func NewAlerter(c *conf.Alert, logger log.Logger) (*alert.Alerter, func(), error) {
+	notifiers := make([]alert.Notifier, 0, len(c.GetNotifiers()))
+	for _, n := range c.GetNotifiers() {
+		switch n.GetType() {
+		case "webhook":
+			notifiers = append(notifiers, alert.NewWebhook(n.GetUrl()))
+		case "dingtalk":
+			notifiers = append(notifiers, alert.NewDingTalk(n.GetUrl(), n.GetSecret()))
+		case "feishu":
+			notifiers = append(notifiers, alert.NewFeishu(n.GetUrl(), n.GetSecret()))
+		default:
+			return nil, nil, fmt.Errorf("unknown alert notifier type %q", n.GetType())
+		}
+	}
+
+	labels := map[string]string{}
+	if name := env.Get("SERVICE_NAME"); name != "" {
+		labels["service"] = name
+	}
+	if host, err := os.Hostname(); err == nil {
+		labels["instance"] = host
+	}
+	opts := []alert.Option{alert.WithLabels(labels)}
+	if c.GetDedupWindow() != nil {
+		opts = append(opts, alert.WithDedupWindow(c.GetDedupWindow().AsDuration()))
+	}
+	if c.GetMaxPerMinute() > 0 {
+		opts = append(opts, alert.WithRateLimit(int(c.GetMaxPerMinute())))
+	}
+
+	a := alert.NewAlerter(notifiers, logger, opts...)
+	lifecycle.Subscribe(alert.LifecycleSubscriber(a))
+	return a, a.Close, nil
+}
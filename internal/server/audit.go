@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	nethttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+	"github.com/go-kratos/kratos-layout/pkg/stream"
+)
+
+// AuditSearcher searches the audit log: the row changes of orm.AuditTrail
+// and the admin requests. *data.Data queries audit_logs with its indexes;
+// a search index of the logs, e.g. Elasticsearch, may implement it instead.
+type AuditSearcher interface {
+	SearchAuditLogs(ctx context.Context, f orm.AuditFilter, cursor string, size int) (orm.Page[orm.AuditLog], error)
+	StreamAuditLogs(ctx context.Context, f orm.AuditFilter) iter.Seq2[orm.AuditLog, error]
+}
+
+// auditRecord is an audit log as served by the admin endpoints, with its
+// values as JSON objects.
+type auditRecord struct {
+	ID       uint64          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Actor    string          `json:"actor"`
+	Action   string          `json:"action"`
+	Entity   string          `json:"entity"`
+	EntityID string          `json:"entity_id,omitempty"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+}
+
+func newAuditRecord(l orm.AuditLog) auditRecord {
+	return auditRecord{
+		ID: l.ID, Time: l.CreatedAt, Actor: l.Actor, Action: l.Action,
+		Entity: l.Entity, EntityID: l.EntityID, Before: l.Before, After: l.After,
+	}
+}
+
+// auditSearchHandler searches the audit log, newest first; it requires the
+// audit.read permission:
+//
+//	GET /admin/audit/search?actor=alice&action=update&entity=accounts&entity_id=1
+//	    &since=2024-01-02T00:00:00Z&until=2024-01-03T00:00:00Z&q=free+text&limit=50&cursor=<next_cursor>
+func auditSearchHandler(s AuditSearcher) nethttp.HandlerFunc {
+	return auditHandler(func(w nethttp.ResponseWriter, r *nethttp.Request, f orm.AuditFilter) {
+		query := r.URL.Query()
+		size := orm.DefaultPageSize
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+				return
+			}
+			size = n
+		}
+		page, err := s.SearchAuditLogs(r.Context(), f, query.Get("cursor"), size)
+		switch {
+		case errors.Is(err, orm.ErrInvalidCursor):
+			admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		case err != nil:
+			admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		logs := make([]auditRecord, 0, len(page.Items))
+		for _, l := range page.Items {
+			logs = append(logs, newAuditRecord(l))
+		}
+		admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"logs": logs, "next_cursor": page.NextCursor})
+	})
+}
+
+// auditExportHandler streams every audit log matching the filters of
+// auditSearchHandler, oldest first, as NDJSON or, with format=json, a JSON
+// array; it requires the audit.read permission:
+//
+//	GET /admin/audit/export?entity=accounts&since=2024-01-01T00:00:00Z
+func auditExportHandler(s AuditSearcher) nethttp.HandlerFunc {
+	return auditHandler(func(w nethttp.ResponseWriter, r *nethttp.Request, f orm.AuditFilter) {
+		records := func(yield func(auditRecord, error) bool) {
+			for l, err := range s.StreamAuditLogs(r.Context(), f) {
+				if !yield(newAuditRecord(l), err) {
+					return
+				}
+			}
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="audit_logs.ndjson"`)
+		write := stream.NDJSON[auditRecord]
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Disposition", `attachment; filename="audit_logs.json"`)
+			write = stream.JSONArray[auditRecord]
+		}
+		if err := write(w, records); err != nil && !errors.Is(err, stream.ErrAborted) {
+			w.Header().Del("Content-Disposition")
+			admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	})
+}
+
+// auditHandler checks the permission and method of the audit endpoints and
+// parses their filters.
+func auditHandler(h func(w nethttp.ResponseWriter, r *nethttp.Request, f orm.AuditFilter)) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if op, _ := admin.OperatorFromContext(r.Context()); !op.Can("audit.read") {
+			admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+			return
+		}
+		if r.Method != nethttp.MethodGet {
+			admin.WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		query := r.URL.Query()
+		f := orm.AuditFilter{
+			Actor:    query.Get("actor"),
+			Action:   query.Get("action"),
+			Entity:   query.Get("entity"),
+			EntityID: query.Get("entity_id"),
+			Text:     query.Get("q"),
+		}
+		for name, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+			v := query.Get(name)
+			if v == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": name + " must be RFC 3339"})
+				return
+			}
+			*t = parsed
+		}
+		h(w, r, f)
+	}
+}
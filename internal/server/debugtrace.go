@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	nethttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+)
+
+// debugTraceHandler issues debug tokens and serves the captured traces:
+//
+//	POST /admin/debug-traces[?ttl=30m]  issues a token (debug.issue)
+//	GET  /admin/debug-traces[?limit=20] lists the traces, newest first (debug.read)
+//	GET  /admin/debug-traces?id=<id>    returns one trace (debug.read)
+func debugTraceHandler(store debugtrace.Store) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		op, _ := admin.OperatorFromContext(r.Context())
+		query := r.URL.Query()
+		switch r.Method {
+		case nethttp.MethodPost:
+			if !op.Can("debug.issue") {
+				admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+				return
+			}
+			var ttl time.Duration
+			if v := query.Get("ttl"); v != "" {
+				var err error
+				if ttl, err = time.ParseDuration(v); err != nil {
+					admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "ttl must be a duration"})
+					return
+				}
+			}
+			value, tok, err := debugtrace.Issue(r.Context(), store, op.Name, ttl)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			admin.WriteJSON(w, nethttp.StatusOK, map[string]any{
+				"header": debugtrace.TokenHeader, "token": value, "operator": tok.Operator, "expires_at": tok.ExpiresAt,
+			})
+		case nethttp.MethodGet:
+			if !op.Can("debug.read") {
+				admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+				return
+			}
+			if id := query.Get("id"); id != "" {
+				t, err := store.Get(r.Context(), id)
+				switch {
+				case errors.Is(err, debugtrace.ErrTraceNotFound):
+					admin.WriteJSON(w, nethttp.StatusNotFound, map[string]string{"error": err.Error()})
+				case err != nil:
+					admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+				default:
+					admin.WriteJSON(w, nethttp.StatusOK, t)
+				}
+				return
+			}
+			limit := 20
+			if v := query.Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+					return
+				}
+				limit = n
+			}
+			traces, err := store.List(r.Context(), limit)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if traces == nil {
+				traces = []debugtrace.Summary{}
+			}
+			admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"traces": traces})
+		default:
+			admin.WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	}
+}
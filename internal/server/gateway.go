@@ -0,0 +1,43 @@
+package server
+
+import (
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/client"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/gateway"
+	"github.com/go-kratos/kratos-layout/pkg/health"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	mw "github.com/go-kratos/kratos-layout/pkg/middleware"
+)
+
+// NewGateway creates the gateway of server.gateway, nil when it has no
+// routes. Proxied requests run through the middleware chain of the HTTP
+// server, so they are authenticated, rate limited and logged alike.
+func NewGateway(c *conf.Server, f *client.Factory, errs *health.ErrorRate, reg *mw.Registry, logs *zapLog.Scoped) (*gateway.Gateway, func(), error) {
+	if len(c.GetGateway().GetRoutes()) == 0 {
+		return nil, func() {}, nil
+	}
+	middlewares, err := buildMiddlewares(c, errs, reg)
+	if err != nil {
+		return nil, nil, err
+	}
+	routes := make([]gateway.Route, 0, len(c.GetGateway().GetRoutes()))
+	for _, r := range c.GetGateway().GetRoutes() {
+		routes = append(routes, gateway.Route{
+			Prefix:      r.GetPrefix(),
+			Service:     r.GetService(),
+			StripPrefix: r.GetStripPrefix(),
+		})
+	}
+	gw, err := gateway.New(f, routes, logs,
+		gateway.WithMiddleware(middlewares...),
+		gateway.WithErrorEncoder(errdetail.ErrorEncoder))
+	if err != nil {
+		return nil, nil, err
+	}
+	return gw, func() {
+		if err := gw.Close(); err != nil {
+			logs.For("server/gateway").Errorf("close gateway: %v", err)
+		}
+	}, nil
+}
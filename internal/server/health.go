@@ -0,0 +1,12 @@
+package server
+
+import (
+	"github.com/go-kratos/kratos-layout/pkg/health"
+)
+
+// NewErrorRate creates the server error rate tracker shared by the gRPC and
+// HTTP servers. Below 5% server errors the instance is healthy, from 50% on
+// it gets the minimum registry weight.
+func NewErrorRate() *health.ErrorRate {
+	return health.NewErrorRate(20, 0.05, 0.5)
+}
@@ -4,18 +4,30 @@ import (
 	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
 	"github.com/go-kratos/kratos-layout/internal/conf"
 	"github.com/go-kratos/kratos-layout/internal/service"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/gateway"
+	"github.com/go-kratos/kratos-layout/pkg/grpcweb"
+	"github.com/go-kratos/kratos-layout/pkg/health"
+	"github.com/go-kratos/kratos-layout/pkg/httpcodec"
+	mw "github.com/go-kratos/kratos-layout/pkg/middleware"
 
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
 )
 
-// NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.Logger) *http.Server {
+// NewHTTPServer new an HTTP server. With server.http.grpc_web, it also
+// serves gRPC-Web calls through gs, and with server.gateway the routes of gw
+// after its own.
+func NewHTTPServer(c *conf.Server, gs *grpc.Server, greeter *service.GreeterService, gw *gateway.Gateway, errs *health.ErrorRate, reg *mw.Registry, logger log.Logger) (*http.Server, error) {
+	middlewares, err := buildMiddlewares(c, errs, reg)
+	if err != nil {
+		return nil, err
+	}
 	var opts = []http.ServerOption{
-		http.Middleware(
-			recovery.Recovery(),
-		),
+		http.Middleware(middlewares...),
+		http.ErrorEncoder(errdetail.ErrorEncoder),
+		http.ResponseEncoder(httpcodec.ResponseEncoder),
 	}
 	if c.Http.Network != "" {
 		opts = append(opts, http.Network(c.Http.Network))
@@ -26,7 +38,13 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 	if c.Http.Timeout != nil {
 		opts = append(opts, http.Timeout(c.Http.Timeout.AsDuration()))
 	}
+	if web := c.Http.GetGrpcWeb(); web.GetEnabled() {
+		opts = append(opts, http.Filter(grpcweb.Filter(gs, grpcweb.WithAllowedOrigins(web.GetAllowedOrigins()...))))
+	}
 	srv := http.NewServer(opts...)
 	v1.RegisterGreeterHTTPServer(srv, greeter)
-	return srv
+	if gw != nil {
+		gw.Register(srv)
+	}
+	return srv, nil
 }
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"os"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	"github.com/go-kratos/kratos-layout/pkg/metering"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+)
+
+// NewMeter creates the usage meter publishing to the billing topic through
+// the outbox. Request usage is recorded by the metering middleware.
+func NewMeter(c *conf.Server, ob *outbox.Outbox, logger log.Logger) *metering.Meter {
+	mc := c.GetMetering()
+	opts := []metering.Option{
+		metering.WithTopic(mc.GetTopic()),
+		metering.WithSampling(mc.GetSampleRate()),
+	}
+	if w := mc.GetAggregateWindow().AsDuration(); w > 0 {
+		instance, _ := os.Hostname()
+		opts = append(opts, metering.WithAggregation(w, instance))
+	}
+	m := metering.New(ob, logger, opts...)
+	if mc.GetJobs() {
+		lifecycle.Subscribe(metering.LifecycleSubscriber(m))
+	}
+	return m
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/alert"
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/etag"
+	"github.com/go-kratos/kratos-layout/pkg/health"
+	"github.com/go-kratos/kratos-layout/pkg/locale"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
+	"github.com/go-kratos/kratos-layout/pkg/metering"
+	mw "github.com/go-kratos/kratos-layout/pkg/middleware"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+	"github.com/go-kratos/kratos-layout/pkg/quota"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+)
+
+// defaultMiddlewares is the chain used when conf.Server.Middlewares is empty.
+var defaultMiddlewares = []mw.Entry{
+	{Name: "recovery"},
+	{Name: "metadata"},
+	{Name: "logfields"},
+}
+
+// middlewareEntries returns the configured middleware chain.
+func middlewareEntries(c *conf.Server) []mw.Entry {
+	if len(c.GetMiddlewares()) == 0 {
+		return defaultMiddlewares
+	}
+	entries := make([]mw.Entry, 0, len(c.GetMiddlewares()))
+	for _, m := range c.GetMiddlewares() {
+		entries = append(entries, mw.Entry{
+			Name:      m.GetName(),
+			Selectors: m.GetSelectors(),
+			Options:   m.GetOptions(),
+		})
+	}
+	return entries
+}
+
+// NewMiddlewareRegistry creates the middleware registry shared by the gRPC and HTTP servers.
+// Register service-specific middlewares here so they can be referenced by name from config.
+func NewMiddlewareRegistry(c *conf.Server, alerter *alert.Alerter, q *quota.Quota, meter *metering.Meter, prof *profile.Profiler, traces debugtrace.Store, logger log.Logger) *mw.Registry {
+	r := mw.NewRegistry(logger)
+	// recovery raises an alert for every recovered panic.
+	r.Register("recovery", func(map[string]string) (middleware.Middleware, error) {
+		return recovery.Recovery(recovery.WithHandler(alert.RecoveryHandler(alerter))), nil
+	})
+	// metadata falls back to server.metadata.propagate_keys when no option is given.
+	r.Register("metadata", func(opts map[string]string) (middleware.Middleware, error) {
+		keys := c.GetMetadata().GetPropagateKeys()
+		if v := opts["propagate_keys"]; v != "" {
+			keys = strings.Split(v, ",")
+		}
+		return metadata.Server(keys...), nil
+	})
+	// logfields attaches the operation and the listed metadata keys (option
+	// keys, comma-separated) as request-scoped log fields; list it after metadata.
+	r.Register("logfields", func(opts map[string]string) (middleware.Middleware, error) {
+		var keys []string
+		if v := opts["keys"]; v != "" {
+			keys = strings.Split(v, ",")
+		}
+		return zapLog.Server(keys...), nil
+	})
+	// locale resolves the locale and currency of the request, defaulting to
+	// the locale and currency options, and propagates them; list it after
+	// metadata.
+	r.Register("locale", func(opts map[string]string) (middleware.Middleware, error) {
+		return locale.Server(locale.WithDefault(locale.Preference{Locale: opts["locale"], Currency: opts["currency"]}))
+	})
+	// quota enforces server.quota; list it after metadata so the tenant is known.
+	r.Register("quota", func(map[string]string) (middleware.Middleware, error) {
+		return quota.Server(q, quota.DefaultSubject, logger), nil
+	})
+	// metering emits billable usage for successful requests, attributed like quota.
+	r.Register("metering", func(map[string]string) (middleware.Middleware, error) {
+		return metering.Server(meter, quota.DefaultSubject), nil
+	})
+	// concurrency caps the in-flight requests of the routes in server.concurrency.
+	r.Register("concurrency", func(map[string]string) (middleware.Middleware, error) {
+		return concurrency.Server(concurrencyLimits(c.GetConcurrency().GetLimits()))
+	})
+	// etag maps orm.ErrStaleObject to 412; with option require=true, updates
+	// without If-Match get 428.
+	r.Register("etag", func(opts map[string]string) (middleware.Middleware, error) {
+		var require bool
+		if v := opts["require"]; v != "" {
+			var err error
+			if require, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("etag require option: %w", err)
+			}
+		}
+		return etag.Server(require), nil
+	})
+	// profile samples the rate option (default 0.01) of requests for the
+	// latency breakdown served at /admin/profile; list it first.
+	r.Register("profile", func(opts map[string]string) (middleware.Middleware, error) {
+		rate, err := profileRate(opts)
+		if err != nil {
+			return nil, err
+		}
+		return prof.Server(rate), nil
+	})
+	// debugtrace captures the requests carrying an X-Debug-Token issued at
+	// /admin/debug-traces; list it first, after recovery.
+	r.Register("debugtrace", func(map[string]string) (middleware.Middleware, error) {
+		return debugtrace.Server(traces, logger), nil
+	})
+	return r
+}
+
+func concurrencyLimits(limits []*conf.Server_Concurrency_Limit) []concurrency.Limit {
+	result := make([]concurrency.Limit, 0, len(limits))
+	for _, l := range limits {
+		result = append(result, concurrency.Limit{
+			Selectors:    l.GetSelectors(),
+			Max:          int(l.GetMax()),
+			QueueTimeout: l.GetQueueTimeout().AsDuration(),
+		})
+	}
+	return result
+}
+
+// buildMiddlewares assembles the server middleware chain from config.
+// The error rate tracker always runs outermost so recovered panics count as
+// errors, followed by the X-Degraded reporting, and the profile handler
+// timer innermost.
+func buildMiddlewares(c *conf.Server, errs *health.ErrorRate, reg *mw.Registry) ([]middleware.Middleware, error) {
+	chain, err := reg.Build(middlewareEntries(c))
+	if err != nil {
+		return nil, err
+	}
+	chain = append([]middleware.Middleware{errs.Middleware(), degrade.Server()}, chain...)
+	return append(chain, profile.Handler()), nil
+}
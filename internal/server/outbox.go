@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	nethttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/outbox"
+)
+
+// stuckMessage is an outbox message as listed by the admin endpoint, without
+// its body.
+type stuckMessage struct {
+	ID            uint64     `json:"id"`
+	Topic         string     `json:"topic"`
+	Key           string     `json:"key"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// outboxHandler serves the outbox backlog and fixes stuck messages:
+//
+//	GET  /admin/outbox[?limit=50]               backlog per topic and failing messages (outbox.read)
+//	POST /admin/outbox?action=requeue&id=<id>   retries a message from scratch (outbox.write)
+//	POST /admin/outbox?action=discard&id=<id>   deletes a message unpublished (outbox.write)
+func outboxHandler(ob *outbox.Outbox) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		op, _ := admin.OperatorFromContext(r.Context())
+		query := r.URL.Query()
+		switch r.Method {
+		case nethttp.MethodGet:
+			if !op.Can("outbox.read") {
+				admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+				return
+			}
+			limit := 50
+			if v := query.Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+					return
+				}
+				limit = n
+			}
+			topics, err := ob.Stats(r.Context())
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			msgs, err := ob.Stuck(r.Context(), limit)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if topics == nil {
+				topics = []outbox.TopicStats{}
+			}
+			stuck := make([]stuckMessage, 0, len(msgs))
+			for _, m := range msgs {
+				stuck = append(stuck, stuckMessage{
+					ID: m.ID, Topic: m.Topic, Key: m.Key, CreatedAt: m.CreatedAt,
+					Attempts: m.Attempts, LastError: m.LastError, QuarantinedAt: m.QuarantinedAt,
+				})
+			}
+			admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"topics": topics, "stuck": stuck})
+		case nethttp.MethodPost:
+			if !op.Can("outbox.write") {
+				admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+				return
+			}
+			id, err := strconv.ParseUint(query.Get("id"), 10, 64)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "id must be a message id"})
+				return
+			}
+			switch query.Get("action") {
+			case "requeue":
+				err = ob.Requeue(r.Context(), id)
+			case "discard":
+				err = ob.Discard(r.Context(), id)
+			default:
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "action must be requeue or discard"})
+				return
+			}
+			switch {
+			case errors.Is(err, outbox.ErrNotFound):
+				admin.WriteJSON(w, nethttp.StatusNotFound, map[string]string{"error": err.Error()})
+			case err != nil:
+				admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			default:
+				admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"id": id, "action": query.Get("action")})
+			}
+		default:
+			admin.WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	}
+}
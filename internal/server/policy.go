@@ -0,0 +1,24 @@
+package server
+
+import (
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	mw "github.com/go-kratos/kratos-layout/pkg/middleware"
+)
+
+// routePolicies is the route policy table keyed by operation.
+// Operations not listed get no extra protection.
+var routePolicies = map[string]admin.RoutePolicy{
+	v1.OperationGreeterSayHello: {Auth: "none"},
+}
+
+// newRoutePolicy returns the effective policy lookup for the configured middleware chain.
+func newRoutePolicy(c *conf.Server) admin.PolicyFunc {
+	entries := middlewareEntries(c)
+	return func(operation string) admin.RoutePolicy {
+		p := routePolicies[operation]
+		p.Middlewares = append(mw.Names(entries, operation), p.Middlewares...)
+		return p
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"strconv"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+)
+
+// defaultProfileRate is the fraction of requests sampled by the profile
+// middleware when its rate option is not set.
+const defaultProfileRate = 0.01
+
+// NewProfiler creates the profiler fed by the profile middleware.
+func NewProfiler() *profile.Profiler {
+	return profile.New()
+}
+
+// profileRate parses the rate option of the profile middleware.
+func profileRate(opts map[string]string) (float64, error) {
+	v := opts["rate"]
+	if v == "" {
+		return defaultProfileRate, nil
+	}
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("profile rate must be within [0, 1], got %q", v)
+	}
+	return rate, nil
+}
+
+// profileHandler serves the sampled hotspots:
+// GET /admin/profile[?top=20] lists them, DELETE /admin/profile resets them.
+func profileHandler(p *profile.Profiler) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method == nethttp.MethodDelete {
+			p.Reset()
+			admin.WriteJSON(w, nethttp.StatusOK, map[string]string{"status": "reset"})
+			return
+		}
+		top := 20
+		if v := r.URL.Query().Get("top"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "top must be an integer"})
+				return
+			}
+			top = n
+		}
+		admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"hotspots": p.Hotspots(top)})
+	}
+}
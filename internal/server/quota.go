@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	nethttp "net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/quota"
+	"github.com/go-kratos/kratos-layout/pkg/reload"
+)
+
+// NewQuota creates the quota accountant from server.quota, reloaded on
+// SIGHUP. It is enforced, and warned about, only when the quota middleware
+// is configured.
+func NewQuota(c *conf.Server, store quota.Store) *quota.Quota {
+	q := quota.New(store, quotaLimits(c.GetQuota().GetDefaultLimits()), quotaSubjects(c.GetQuota()),
+		quota.WithWarnRatio(c.GetQuota().GetWarnRatio()))
+	reload.Register("quota", []string{"server.quota"}, func(_ context.Context, m proto.Message) error {
+		qc := m.(*conf.Bootstrap).GetServer().GetQuota()
+		q.Update(quotaLimits(qc.GetDefaultLimits()), quotaSubjects(qc), qc.GetWarnRatio())
+		return nil
+	})
+	return q
+}
+
+func quotaSubjects(c *conf.Server_Quota) map[string][]quota.Limit {
+	subjects := make(map[string][]quota.Limit, len(c.GetSubjects()))
+	for _, s := range c.GetSubjects() {
+		subjects[s.GetName()] = quotaLimits(s.GetLimits())
+	}
+	return subjects
+}
+
+func quotaLimits(limits []*conf.Server_Quota_Limit) []quota.Limit {
+	result := make([]quota.Limit, 0, len(limits))
+	for _, l := range limits {
+		result = append(result, quota.Limit{
+			Window:   quota.Window(l.GetWindow()),
+			Requests: l.GetRequests(),
+			Bytes:    l.GetBytes(),
+		})
+	}
+	return result
+}
+
+// quotaReportHandler serves the usage of a subject:
+// GET /admin/quota?subject=tenant:acme[&date=2026-01-31]
+func quotaReportHandler(q *quota.Quota) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		subject := r.URL.Query().Get("subject")
+		if subject == "" {
+			admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "subject is required"})
+			return
+		}
+		at := time.Now()
+		if d := r.URL.Query().Get("date"); d != "" {
+			parsed, err := time.Parse(time.DateOnly, d)
+			if err != nil {
+				admin.WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+				return
+			}
+			at = parsed
+		}
+		usages, err := q.Usage(r.Context(), subject, at)
+		if err != nil {
+			admin.WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"subject": subject, "usage": usages})
+	}
+}
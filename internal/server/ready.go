@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	nethttp "net/http"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+)
+
+// readyTimeout bounds the database ping of a readiness probe, so a hung
+// connection fails the probe instead of blocking it.
+const readyTimeout = 2 * time.Second
+
+// Pinger is the data layer surface used by the readiness probe.
+type Pinger interface {
+	Ping(ctx context.Context) error
+	DBStats() sql.DBStats
+}
+
+// readyHandler serves GET /admin/ready: 200 when the database answers a
+// ping within readyTimeout, 503 otherwise. Both carry the pool statistics.
+func readyHandler(p Pinger) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		stats := p.DBStats()
+		pool := map[string]any{
+			"open":          stats.OpenConnections,
+			"in_use":        stats.InUse,
+			"idle":          stats.Idle,
+			"wait_count":    stats.WaitCount,
+			"wait_duration": stats.WaitDuration.String(),
+		}
+		if err := p.Ping(ctx); err != nil {
+			admin.WriteJSON(w, nethttp.StatusServiceUnavailable, map[string]any{"status": "unavailable", "error": err.Error(), "db": pool})
+			return
+		}
+		admin.WriteJSON(w, nethttp.StatusOK, map[string]any{"status": "ok", "db": pool})
+	}
+}
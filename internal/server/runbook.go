@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+	"github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+)
+
+// Maintainer is the data layer surface used by runbook actions.
+type Maintainer interface {
+	FlushCache(ctx context.Context, name string) (int64, error)
+	ReconnectDB(ctx context.Context) error
+	ReconnectRedis(ctx context.Context) error
+	PlaceLegalHold(ctx context.Context, entity, id, reason string) error
+	ReleaseLegalHold(ctx context.Context, entity, id string) error
+	Pinger
+}
+
+// newRunbook registers the routine ops actions exposed under /admin/runbook.
+func newRunbook(m Maintainer, r *nacos.Registry, logger log.Logger) *admin.Runbook {
+	rb := admin.NewRunbook(30*time.Second, logger)
+	rb.Register(admin.Action{
+		Name:        "cache.flush",
+		Description: "Delete every Redis key of a named cache (<name>:*)",
+		Params:      []string{"name"},
+		Run: func(ctx context.Context, params map[string]string) (any, error) {
+			n, err := m.FlushCache(ctx, params["name"])
+			return map[string]int64{"deleted": n}, err
+		},
+	})
+	rb.Register(admin.Action{
+		Name:        "db.reconnect",
+		Description: "Drop idle MySQL connections and verify connectivity",
+		Run: func(ctx context.Context, _ map[string]string) (any, error) {
+			return nil, m.ReconnectDB(ctx)
+		},
+	})
+	rb.Register(admin.Action{
+		Name:        "redis.reconnect",
+		Description: "Replace the Redis client with a freshly dialed one",
+		Run: func(ctx context.Context, _ map[string]string) (any, error) {
+			return nil, m.ReconnectRedis(ctx)
+		},
+	})
+	rb.Register(admin.Action{
+		Name:        "legal_hold.place",
+		Description: "Exempt a row (table name and primary key) from data retention",
+		Params:      []string{"entity", "id", "reason"},
+		Run: func(ctx context.Context, params map[string]string) (any, error) {
+			return nil, m.PlaceLegalHold(ctx, params["entity"], params["id"], params["reason"])
+		},
+	})
+	rb.Register(admin.Action{
+		Name:        "legal_hold.release",
+		Description: "Release the legal hold of a row",
+		Params:      []string{"entity", "id"},
+		Run: func(ctx context.Context, params map[string]string) (any, error) {
+			return nil, m.ReleaseLegalHold(ctx, params["entity"], params["id"])
+		},
+	})
+	rb.Register(admin.Action{
+		Name:        "registry.reregister",
+		Description: "Register this instance with Nacos again",
+		Run: func(ctx context.Context, _ map[string]string) (any, error) {
+			n, err := r.Reregister(ctx)
+			return map[string]int{"instances": n}, err
+		},
+	})
+	return rb
+}
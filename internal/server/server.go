@@ -5,4 +5,6 @@ import (
 )
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer)
+var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewAdminServer, NewErrorRate, NewAlerter, NewQuota,
+	NewMeter, NewMiddlewareRegistry, NewProfiler, NewGateway,
+)
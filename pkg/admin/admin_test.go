@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+type greeter struct {
+	v1.UnimplementedGreeterServer
+}
+
+func TestServer_Disabled(t *testing.T) {
+	s := NewServer("", "", "", log.DefaultLogger)
+	assert.False(t, s.Enabled())
+	assert.Empty(t, s.Servers())
+	s.HandleFunc("/noop", func(nethttp.ResponseWriter, *nethttp.Request) {})
+}
+
+func TestServer_Token(t *testing.T) {
+	s := NewServer("", "127.0.0.1:0", "secret", log.DefaultLogger)
+	s.HandleFunc("/ping", func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		WriteJSON(w, nethttp.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	rec := httptest.NewRecorder()
+	s.srv.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/admin/ping", nil))
+	assert.Equal(t, nethttp.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(nethttp.MethodGet, "/admin/ping", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	s.srv.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+}
+
+func TestServer_NoToken(t *testing.T) {
+	ping := func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		WriteJSON(w, nethttp.StatusOK, map[string]string{"status": "ok"})
+	}
+	for addr, want := range map[string]int{
+		"127.0.0.1:0": nethttp.StatusOK,
+		"[::1]:0":     nethttp.StatusOK,
+		"0.0.0.0:0":   nethttp.StatusNotFound,
+		":0":          nethttp.StatusNotFound,
+	} {
+		s := NewServer("", addr, "", log.DefaultLogger)
+		s.HandleFunc("/ping", ping)
+		rec := httptest.NewRecorder()
+		s.srv.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/admin/ping", nil))
+		assert.Equal(t, want, rec.Code, addr)
+	}
+
+	// Operators authenticate the callers off loopback.
+	s := NewServer("", "0.0.0.0:0", "", log.DefaultLogger)
+	s.AddOperator("oncall", "xxx")
+	s.HandleFunc("/ping", ping)
+	rec := httptest.NewRecorder()
+	s.srv.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/admin/ping", nil))
+	assert.Equal(t, nethttp.StatusUnauthorized, rec.Code)
+}
+
+func TestBuildCatalog(t *testing.T) {
+	gs := grpc.NewServer()
+	hs := http.NewServer()
+	v1.RegisterGreeterServer(gs, &greeter{})
+	v1.RegisterGreeterHTTPServer(hs, &greeter{})
+	hs.Route("/").GET("/healthz", func(http.Context) error { return nil })
+
+	endpoints, err := BuildCatalog(gs, hs, func(operation string) RoutePolicy {
+		return RoutePolicy{Middlewares: []string{"recovery"}, Auth: "jwt"}
+	})
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+
+	assert.Equal(t, "", endpoints[0].Operation)
+	assert.Equal(t, "/healthz", endpoints[0].HTTPPath)
+
+	assert.Equal(t, v1.OperationGreeterSayHello, endpoints[1].Operation)
+	assert.True(t, endpoints[1].GRPC)
+	assert.Equal(t, nethttp.MethodGet, endpoints[1].HTTPMethod)
+	assert.Equal(t, "/helloworld/{name}", endpoints[1].HTTPPath)
+	assert.False(t, endpoints[1].Deprecated)
+	assert.Equal(t, "jwt", endpoints[1].RoutePolicy.Auth)
+
+	rec := httptest.NewRecorder()
+	CatalogHandler(gs, hs, nil)(rec, httptest.NewRequest(nethttp.MethodGet, "/admin/catalog", nil))
+	var body struct {
+		Endpoints []Endpoint `json:"endpoints"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.Endpoints, 2)
+}
+
+func TestOperator_Can(t *testing.T) {
+	op := Operator{Name: "oncall", Permissions: []string{"cache.*", "db.reconnect"}}
+	assert.True(t, op.Can("cache.flush"))
+	assert.True(t, op.Can("db.reconnect"))
+	assert.False(t, op.Can("redis.reconnect"))
+	assert.False(t, op.Can("cachex.flush"))
+	assert.True(t, Operator{Permissions: []string{"*"}}.Can("anything"))
+}
+
+func TestRunbook(t *testing.T) {
+	s := NewServer("", "127.0.0.1:0", "secret", log.DefaultLogger)
+	s.AddOperator("oncall", "oncall-token", "cache.*")
+
+	var flushed string
+	rb := NewRunbook(time.Second, log.DefaultLogger)
+	rb.Register(Action{Name: "cache.flush", Run: func(_ context.Context, params map[string]string) (any, error) {
+		flushed = params["name"]
+		return map[string]int{"deleted": 3}, nil
+	}})
+	rb.Register(Action{Name: "db.reconnect", Run: func(context.Context, map[string]string) (any, error) {
+		return nil, errors.New("refused")
+	}})
+	s.HandleFunc("/runbook", rb.Handler())
+
+	do := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(nethttp.MethodGet, "/admin/runbook", "oncall-token")
+	require.Equal(t, nethttp.StatusOK, rec.Code)
+	var list struct {
+		Operator string   `json:"operator"`
+		Actions  []Action `json:"actions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, "oncall", list.Operator)
+	require.Len(t, list.Actions, 1)
+	assert.Equal(t, "cache.flush", list.Actions[0].Name)
+
+	rec = do(nethttp.MethodPost, "/admin/runbook?action=cache.flush&name=user", "oncall-token")
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, "user", flushed)
+
+	assert.Equal(t, nethttp.StatusForbidden, do(nethttp.MethodPost, "/admin/runbook?action=db.reconnect", "oncall-token").Code)
+	assert.Equal(t, nethttp.StatusInternalServerError, do(nethttp.MethodPost, "/admin/runbook?action=db.reconnect", "secret").Code)
+	assert.Equal(t, nethttp.StatusNotFound, do(nethttp.MethodPost, "/admin/runbook?action=nope", "secret").Code)
+	assert.Equal(t, nethttp.StatusUnauthorized, do(nethttp.MethodGet, "/admin/runbook", "wrong").Code)
+}
+
+type memoryAudit struct {
+	events []AuditEvent
+	query  AuditQuery
+}
+
+func (m *memoryAudit) Record(_ context.Context, e AuditEvent) error {
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *memoryAudit) List(_ context.Context, q AuditQuery) ([]AuditEvent, error) {
+	m.query = q
+	return m.events, nil
+}
+
+func TestServer_Audit(t *testing.T) {
+	store := &memoryAudit{}
+	s := NewServer("", "127.0.0.1:0", "secret", log.DefaultLogger)
+	s.AddOperator("oncall", "oncall-token", "cache.*")
+	s.SetAuditStore(store)
+	s.HandleFunc("/switch", func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		WriteJSON(w, nethttp.StatusAccepted, map[string]string{"status": "ok"})
+	})
+	s.HandleFunc("/audit", AuditHandler(store))
+
+	do := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, nethttp.StatusAccepted, do(nethttp.MethodGet, "/admin/switch", "secret"))
+	assert.Empty(t, store.events, "reads are not audited")
+
+	assert.Equal(t, nethttp.StatusAccepted, do(nethttp.MethodPost, "/admin/switch?name=maintenance&api_token=x", "oncall-token"))
+	assert.Equal(t, nethttp.StatusUnauthorized, do(nethttp.MethodDelete, "/admin/switch", "wrong"))
+	require.Len(t, store.events, 2)
+	assert.Equal(t, "oncall", store.events[0].Operator)
+	assert.Equal(t, "POST /admin/switch", store.events[0].Action)
+	assert.Equal(t, map[string]string{"name": "maintenance", "api_token": "[redacted]"}, store.events[0].Params)
+	assert.Equal(t, nethttp.StatusAccepted, store.events[0].Status)
+	assert.Empty(t, store.events[1].Operator)
+	assert.Equal(t, nethttp.StatusUnauthorized, store.events[1].Status)
+
+	assert.Equal(t, nethttp.StatusForbidden, do(nethttp.MethodGet, "/admin/audit", "oncall-token"))
+	assert.Equal(t, nethttp.StatusBadRequest, do(nethttp.MethodGet, "/admin/audit?since=yesterday", "secret"))
+	assert.Equal(t, nethttp.StatusOK, do(nethttp.MethodGet, "/admin/audit?operator=oncall&since=2024-01-02T15:04:05Z&limit=5000", "secret"))
+	assert.Equal(t, "oncall", store.query.Operator)
+	assert.Equal(t, MaxAuditLimit, store.query.Limit)
+	assert.Equal(t, 2024, store.query.Since.Year())
+}
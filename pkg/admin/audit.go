@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"context"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEvent is an admin request that may have changed something: every
+// request other than GET, HEAD and OPTIONS, including rejected ones.
+type AuditEvent struct {
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"` // empty when authentication failed
+	// Action is the method and path, e.g. "POST /admin/runbook".
+	Action string            `json:"action"`
+	Params map[string]string `json:"params,omitempty"`
+	Status int               `json:"status"`
+	// DurationMS is how long the handler ran.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// AuditQuery filters AuditStore.List. Zero fields match everything.
+type AuditQuery struct {
+	Operator string
+	Action   string
+	Since    time.Time
+	// Limit caps the number of events, newest first.
+	Limit int
+}
+
+// AuditStore persists audit events.
+type AuditStore interface {
+	Record(ctx context.Context, e AuditEvent) error
+	List(ctx context.Context, q AuditQuery) ([]AuditEvent, error)
+}
+
+// Audit limits.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// redactedParams are query parameters never written to the audit log.
+var redactedParams = []string{"token", "password", "secret", "key"}
+
+// auditParams returns the query parameters of r with secrets redacted.
+func auditParams(r *nethttp.Request) map[string]string {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(query))
+	for k := range query {
+		params[k] = query.Get(k)
+		for _, secret := range redactedParams {
+			if strings.Contains(strings.ToLower(k), secret) {
+				params[k] = "[redacted]"
+				break
+			}
+		}
+	}
+	return params
+}
+
+func audited(r *nethttp.Request) bool {
+	switch r.Method {
+	case nethttp.MethodGet, nethttp.MethodHead, nethttp.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// statusRecorder captures the status written by a handler.
+type statusRecorder struct {
+	nethttp.ResponseWriter
+	status int
+	once   sync.Once
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.once.Do(func() { w.status = status })
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	w.once.Do(func() { w.status = nethttp.StatusOK })
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusRecorder) Unwrap() nethttp.ResponseWriter { return w.ResponseWriter }
+
+// SetAuditStore persists the audit events of the server in store, in
+// addition to the audit log lines.
+func (s *Server) SetAuditStore(store AuditStore) {
+	s.audit = store
+}
+
+// record logs e and saves it to the audit store. Saving outlives the
+// request so a client disconnecting does not drop the event.
+func (s *Server) record(ctx context.Context, e AuditEvent) {
+	kv := []any{"audit", "admin", "operator", e.Operator, "action", e.Action, "params", e.Params,
+		"status", e.Status, "duration_ms", e.DurationMS}
+	if e.Status >= 400 {
+		s.log.Warnw(kv...)
+	} else {
+		s.log.Infow(kv...)
+	}
+	if s.audit == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.audit.Record(ctx, e); err != nil {
+		s.log.Errorf("failed to persist admin audit event %s by %s: %v", e.Action, e.Operator, err)
+	}
+}
+
+// AuditHandler lists persisted audit events, newest first; it requires the
+// audit.read permission:
+//
+//	GET /admin/audit?operator=oncall&action=POST+/admin/runbook&since=2024-01-02T15:04:05Z&limit=100
+func AuditHandler(store AuditStore) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if op, _ := OperatorFromContext(r.Context()); !op.Can("audit.read") {
+			WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": ErrForbidden.Error()})
+			return
+		}
+		if r.Method != nethttp.MethodGet {
+			WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		query := r.URL.Query()
+		q := AuditQuery{Operator: query.Get("operator"), Action: query.Get("action"), Limit: DefaultAuditLimit}
+		if v := query.Get("since"); v != "" {
+			since, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "since must be RFC 3339"})
+				return
+			}
+			q.Since = since
+		}
+		if v := query.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				WriteJSON(w, nethttp.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+				return
+			}
+			q.Limit = min(limit, MaxAuditLimit)
+		}
+		events, err := store.List(r.Context(), q)
+		if err != nil {
+			WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if events == nil {
+			events = []AuditEvent{}
+		}
+		WriteJSON(w, nethttp.StatusOK, map[string]any{"events": events})
+	}
+}
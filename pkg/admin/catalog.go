@@ -0,0 +1,169 @@
+package admin
+
+import (
+	nethttp "net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RoutePolicy describes how a route is protected.
+type RoutePolicy struct {
+	Middlewares []string `json:"middlewares"`
+	Auth        string   `json:"auth,omitempty"`
+	RateLimit   string   `json:"rate_limit,omitempty"`
+}
+
+// PolicyFunc returns the route policy for an operation.
+type PolicyFunc func(operation string) RoutePolicy
+
+// Endpoint is a single entry of the API catalog.
+type Endpoint struct {
+	Operation   string      `json:"operation,omitempty"` // e.g. /helloworld.v1.Greeter/SayHello
+	GRPC        bool        `json:"grpc"`
+	HTTPMethod  string      `json:"http_method,omitempty"`
+	HTTPPath    string      `json:"http_path,omitempty"`
+	Deprecated  bool        `json:"deprecated"`
+	RoutePolicy RoutePolicy `json:"policy"`
+}
+
+// builtinServicePrefixes are framework services registered on every gRPC server
+// (health, reflection, channelz, kratos metadata); they are left out of the catalog.
+var builtinServicePrefixes = []string{"grpc.", "kratos.api."}
+
+func isBuiltinService(name string) bool {
+	for _, prefix := range builtinServicePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildCatalog lists every gRPC method registered on gs and every HTTP route
+// registered on hs. HTTP routes are matched to their operation through the
+// google.api.http annotations of the proto descriptors.
+func BuildCatalog(gs *grpc.Server, hs *http.Server, policy PolicyFunc) ([]Endpoint, error) {
+	byOperation := map[string]*Endpoint{}
+	byRoute := map[string]*Endpoint{}
+
+	if gs != nil {
+		for service, info := range gs.GetServiceInfo() {
+			if isBuiltinService(service) {
+				continue
+			}
+			sd := findService(service)
+			for _, m := range info.Methods {
+				op := "/" + service + "/" + m.Name
+				e := &Endpoint{Operation: op, GRPC: true}
+				if sd != nil {
+					if md := sd.Methods().ByName(protoreflect.Name(m.Name)); md != nil {
+						e.Deprecated = isDeprecated(sd, md)
+						e.HTTPMethod, e.HTTPPath = httpRule(md)
+					}
+				}
+				byOperation[op] = e
+				if e.HTTPPath != "" {
+					byRoute[e.HTTPMethod+" "+e.HTTPPath] = e
+				}
+			}
+		}
+	}
+
+	var extra []*Endpoint
+	if hs != nil {
+		err := hs.WalkRoute(func(r http.RouteInfo) error {
+			if _, ok := byRoute[r.Method+" "+r.Path]; ok {
+				return nil
+			}
+			extra = append(extra, &Endpoint{HTTPMethod: r.Method, HTTPPath: r.Path})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]Endpoint, 0, len(byOperation)+len(extra))
+	for _, e := range byOperation {
+		result = append(result, *e)
+	}
+	for _, e := range extra {
+		result = append(result, *e)
+	}
+	for i := range result {
+		if policy != nil {
+			result[i].RoutePolicy = policy(result[i].Operation)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Operation != result[j].Operation {
+			return result[i].Operation < result[j].Operation
+		}
+		return result[i].HTTPPath < result[j].HTTPPath
+	})
+	return result, nil
+}
+
+// CatalogHandler serves the catalog as JSON.
+func CatalogHandler(gs *grpc.Server, hs *http.Server, policy PolicyFunc) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		endpoints, err := BuildCatalog(gs, hs, policy)
+		if err != nil {
+			WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		WriteJSON(w, nethttp.StatusOK, map[string]any{"endpoints": endpoints})
+	}
+}
+
+func findService(name string) protoreflect.ServiceDescriptor {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil
+	}
+	sd, _ := d.(protoreflect.ServiceDescriptor)
+	return sd
+}
+
+func isDeprecated(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor) bool {
+	if opts, ok := sd.Options().(*descriptorpb.ServiceOptions); ok && opts.GetDeprecated() {
+		return true
+	}
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	return ok && opts.GetDeprecated()
+}
+
+// httpRule returns the HTTP method and path bound by the google.api.http annotation.
+func httpRule(md protoreflect.MethodDescriptor) (method, path string) {
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return "", ""
+	}
+	rule, ok := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return "", ""
+	}
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return nethttp.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		return nethttp.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		return nethttp.MethodPut, p.Put
+	case *annotations.HttpRule_Delete:
+		return nethttp.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return nethttp.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	}
+	return "", ""
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"context"
+	"strings"
+)
+
+// Operator is an authenticated caller of the admin server.
+type Operator struct {
+	Name string `json:"name"`
+	// Permissions are action names such as "cache.flush", group wildcards
+	// such as "cache.*", or "*" for everything.
+	Permissions []string `json:"permissions"`
+}
+
+// Can reports whether o holds permission.
+func (o Operator) Can(permission string) bool {
+	for _, p := range o.Permissions {
+		switch {
+		case p == "*", p == permission:
+			return true
+		case strings.HasSuffix(p, ".*") && strings.HasPrefix(permission, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+type operatorKey struct{}
+
+// NewOperatorContext returns a new context carrying op.
+func NewOperatorContext(ctx context.Context, op Operator) context.Context {
+	return context.WithValue(ctx, operatorKey{}, op)
+}
+
+// OperatorFromContext returns the operator authenticated for the request.
+func OperatorFromContext(ctx context.Context) (Operator, bool) {
+	op, ok := ctx.Value(operatorKey{}).(Operator)
+	return op, ok
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var (
+	// ErrUnknownAction is returned when no action is registered under a name.
+	ErrUnknownAction = errors.New("admin: unknown action")
+	// ErrForbidden is returned when the operator lacks the action's permission.
+	ErrForbidden = errors.New("admin: permission denied")
+)
+
+// Action is a routine operational fix that on-call can trigger without
+// restarting the process. Its name doubles as the permission required to run it.
+type Action struct {
+	Name        string   `json:"name"` // e.g. cache.flush
+	Description string   `json:"description"`
+	Params      []string `json:"params,omitempty"` // accepted query parameters
+	// Run performs the action and returns a JSON-serializable result.
+	Run func(ctx context.Context, params map[string]string) (any, error) `json:"-"`
+}
+
+// Runbook is the set of actions exposed on the admin server.
+// Every invocation is written to the audit log.
+type Runbook struct {
+	timeout time.Duration
+	log     *log.Helper
+
+	mu      sync.RWMutex
+	actions map[string]Action
+}
+
+// NewRunbook creates an empty runbook. Actions run with the given timeout.
+func NewRunbook(timeout time.Duration, logger log.Logger) *Runbook {
+	return &Runbook{
+		timeout: timeout,
+		log:     log.NewHelper(log.With(logger, "module", "pkg/admin/runbook")),
+		actions: make(map[string]Action),
+	}
+}
+
+// Register adds a, replacing any action with the same name.
+func (r *Runbook) Register(a Action) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[a.Name] = a
+}
+
+// Actions returns the registered actions sorted by name.
+func (r *Runbook) Actions() []Action {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	actions := make([]Action, 0, len(r.actions))
+	for _, a := range r.actions {
+		actions = append(actions, a)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+	return actions
+}
+
+// Run executes the named action on behalf of op.
+func (r *Runbook) Run(ctx context.Context, op Operator, name string, params map[string]string) (any, error) {
+	r.mu.RLock()
+	a, ok := r.actions[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAction, name)
+	}
+	if !op.Can(name) {
+		r.audit(op, name, params, 0, ErrForbidden)
+		return nil, ErrForbidden
+	}
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err := a.Run(ctx, params)
+	r.audit(op, name, params, time.Since(start), err)
+	return result, err
+}
+
+func (r *Runbook) audit(op Operator, name string, params map[string]string, d time.Duration, err error) {
+	if err != nil {
+		r.log.Warnw("audit", "runbook", "operator", op.Name, "action", name, "params", params,
+			"duration", d, "result", "failed", "error", err)
+		return
+	}
+	r.log.Infow("audit", "runbook", "operator", op.Name, "action", name, "params", params,
+		"duration", d, "result", "ok")
+}
+
+// Handler serves the runbook.
+//
+//	GET  /admin/runbook                               lists actions the caller may run
+//	POST /admin/runbook?action=cache.flush&name=user  runs an action
+func (r *Runbook) Handler() nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		op, _ := OperatorFromContext(req.Context())
+		switch req.Method {
+		case nethttp.MethodGet:
+			allowed := []Action{}
+			for _, a := range r.Actions() {
+				if op.Can(a.Name) {
+					allowed = append(allowed, a)
+				}
+			}
+			WriteJSON(w, nethttp.StatusOK, map[string]any{"operator": op.Name, "actions": allowed})
+		case nethttp.MethodPost:
+			query := req.URL.Query()
+			name := query.Get("action")
+			params := make(map[string]string, len(query))
+			for k := range query {
+				if k != "action" {
+					params[k] = query.Get(k)
+				}
+			}
+			result, err := r.Run(req.Context(), op, name, params)
+			switch {
+			case errors.Is(err, ErrUnknownAction):
+				WriteJSON(w, nethttp.StatusNotFound, map[string]string{"error": err.Error()})
+			case errors.Is(err, ErrForbidden):
+				WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": err.Error()})
+			case err != nil:
+				WriteJSON(w, nethttp.StatusInternalServerError, map[string]string{"error": err.Error()})
+			default:
+				WriteJSON(w, nethttp.StatusOK, map[string]any{"action": name, "result": result})
+			}
+		default:
+			WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	}
+}
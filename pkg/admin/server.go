@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	nethttp "net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// PathPrefix is the prefix of every admin endpoint.
+const PathPrefix = "/admin"
+
+// Server is the admin HTTP server exposing operational endpoints.
+// It listens separately from the business HTTP server so it can be bound to
+// an internal interface. An empty addr disables it.
+type Server struct {
+	srv       *http.Server
+	addr      string
+	local     bool // listens on loopback or a unix socket
+	token     string
+	operators []operatorToken
+	audit     AuditStore
+	log       *log.Helper
+}
+
+type operatorToken struct {
+	token    string
+	operator Operator
+}
+
+// NewServer creates a new admin server.
+// Requests must carry "Authorization: Bearer <token>" when token is non-empty.
+// Without a token nor operators, handlers are only served on loopback or a
+// unix socket; elsewhere they are refused, not opened to anyone reaching addr.
+func NewServer(network, addr, token string, logger log.Logger) *Server {
+	s := &Server{
+		addr:  addr,
+		local: network == "unix" || isLoopback(addr),
+		token: token,
+		log:   log.NewHelper(log.With(logger, "module", "pkg/admin")),
+	}
+	if addr == "" {
+		return s
+	}
+
+	opts := []http.ServerOption{http.Address(addr)}
+	if network != "" {
+		opts = append(opts, http.Network(network))
+	}
+	s.srv = http.NewServer(opts...)
+	switch {
+	case token != "":
+	case s.local:
+		s.log.Warnf("admin server listening on %s without token", addr)
+	default:
+		s.log.Errorf("admin server listening on %s without token, its endpoints are refused unless operators are added", addr)
+	}
+	return s
+}
+
+// isLoopback reports whether addr (host:port) is bound to a loopback address.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Enabled reports whether the admin server has a listener configured.
+func (s *Server) Enabled() bool {
+	return s.srv != nil
+}
+
+// Servers returns the admin server as transport.Server slice for kratos.Server().
+// Returns an empty slice when disabled.
+func (s *Server) Servers() []transport.Server {
+	if s.srv == nil {
+		return []transport.Server{}
+	}
+	return []transport.Server{s.srv}
+}
+
+// AddOperator registers a named operator authenticating with token and
+// holding the given permissions. The server token grants every permission.
+func (s *Server) AddOperator(name, token string, permissions ...string) {
+	if token == "" {
+		s.log.Warnf("admin operator %s has no token, ignored", name)
+		return
+	}
+	s.operators = append(s.operators, operatorToken{
+		token:    token,
+		operator: Operator{Name: name, Permissions: permissions},
+	})
+}
+
+// HandleFunc registers a token-guarded handler at PathPrefix + path.
+// It is a no-op when the server is disabled, and refuses h when nothing
+// would authenticate its callers off loopback, see NewServer.
+func (s *Server) HandleFunc(path string, h nethttp.HandlerFunc) {
+	if s.srv == nil {
+		return
+	}
+	if s.token == "" && len(s.operators) == 0 && !s.local {
+		s.log.Errorf("admin endpoint %s not registered: no token on %s", PathPrefix+path, s.addr)
+		return
+	}
+	s.srv.HandleFunc(PathPrefix+path, s.guard(h))
+}
+
+// guard authenticates requests and audits those that may change something.
+func (s *Server) guard(h nethttp.HandlerFunc) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		op, ok := s.authenticate(r)
+		serve := func(w nethttp.ResponseWriter) {
+			if !ok {
+				WriteJSON(w, nethttp.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			h(w, r.WithContext(NewOperatorContext(r.Context(), op)))
+		}
+		if !audited(r) {
+			serve(w)
+			return
+		}
+
+		e := AuditEvent{
+			Time:     time.Now(),
+			Operator: op.Name,
+			Action:   r.Method + " " + r.URL.Path,
+			Params:   auditParams(r),
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: nethttp.StatusOK}
+		serve(rec)
+		e.Status = rec.status
+		e.DurationMS = time.Since(e.Time).Milliseconds()
+		s.record(r.Context(), e)
+	}
+}
+
+// authenticate resolves the operator of r from its bearer token.
+// Without any token configured every caller acts as the admin, which
+// HandleFunc only allows on loopback.
+func (s *Server) authenticate(r *nethttp.Request) (Operator, bool) {
+	admin := Operator{Name: "admin", Permissions: []string{"*"}}
+	if s.token == "" && len(s.operators) == 0 {
+		return admin, true
+	}
+	got := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if s.token != "" && subtle.ConstantTimeCompare(got, []byte(s.token)) == 1 {
+		return admin, true
+	}
+	for _, o := range s.operators {
+		if subtle.ConstantTimeCompare(got, []byte(o.token)) == 1 {
+			return o.operator, true
+		}
+	}
+	return Operator{}, false
+}
+
+// WriteJSON writes v as a JSON response with the given status code.
+func WriteJSON(w nethttp.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
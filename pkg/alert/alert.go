@@ -0,0 +1,214 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"golang.org/x/time/rate"
+)
+
+// Severity classifies an alert.
+type Severity string
+
+// Severities.
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Alert is a notification about an operational problem.
+type Alert struct {
+	Title    string
+	Content  string
+	Severity Severity
+	// Key identifies repeated occurrences of the same problem for
+	// deduplication. Defaults to Title.
+	Key    string
+	Labels map[string]string
+	Time   time.Time
+}
+
+func (a *Alert) dedupKey() string {
+	if a.Key != "" {
+		return a.Key
+	}
+	return a.Title
+}
+
+// Notifier delivers alerts to a channel.
+type Notifier interface {
+	Notify(ctx context.Context, a *Alert) error
+}
+
+// Option configures an Alerter.
+type Option func(*options)
+
+type options struct {
+	dedupWindow time.Duration
+	perMinute   int
+	queueSize   int
+	timeout     time.Duration
+	labels      map[string]string
+}
+
+// WithDedupWindow suppresses alerts with the same key within d.
+func WithDedupWindow(d time.Duration) Option {
+	return func(o *options) { o.dedupWindow = d }
+}
+
+// WithRateLimit caps the number of alerts sent per minute across all keys.
+func WithRateLimit(perMinute int) Option {
+	return func(o *options) {
+		if perMinute > 0 {
+			o.perMinute = perMinute
+		}
+	}
+}
+
+// WithLabels adds labels to every alert, e.g. service name and instance.
+func WithLabels(labels map[string]string) Option {
+	return func(o *options) { o.labels = labels }
+}
+
+// Alerter deduplicates, rate limits and asynchronously fans alerts out to
+// notifiers. Send never blocks the caller; alerts are dropped when the queue
+// is full or the rate limit is exceeded.
+type Alerter struct {
+	opts      options
+	notifiers []Notifier
+	limiter   *rate.Limiter
+	log       *log.Helper
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+
+	queue chan *Alert
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewAlerter creates an alerter and starts its delivery worker.
+// Call Close to flush pending alerts.
+func NewAlerter(notifiers []Notifier, logger log.Logger, opts ...Option) *Alerter {
+	o := options{
+		dedupWindow: 5 * time.Minute,
+		perMinute:   20,
+		queueSize:   100,
+		timeout:     5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	a := &Alerter{
+		opts:      o,
+		notifiers: notifiers,
+		limiter:   rate.NewLimiter(rate.Limit(float64(o.perMinute)/60), o.perMinute),
+		log:       log.NewHelper(log.With(logger, "module", "pkg/alert")),
+		lastSent:  make(map[string]time.Time),
+		queue:     make(chan *Alert, o.queueSize),
+		done:      make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go a.run()
+	return a
+}
+
+// Send queues alert for delivery and reports whether it was accepted.
+func (a *Alerter) Send(alert *Alert) bool {
+	if a == nil || len(a.notifiers) == 0 {
+		return false
+	}
+	now := time.Now()
+	if alert.Time.IsZero() {
+		alert.Time = now
+	}
+	if len(a.opts.labels) > 0 {
+		labels := make(map[string]string, len(a.opts.labels)+len(alert.Labels))
+		for k, v := range a.opts.labels {
+			labels[k] = v
+		}
+		for k, v := range alert.Labels {
+			labels[k] = v
+		}
+		alert.Labels = labels
+	}
+
+	key := alert.dedupKey()
+	a.mu.Lock()
+	if last, ok := a.lastSent[key]; ok && now.Sub(last) < a.opts.dedupWindow {
+		a.mu.Unlock()
+		return false
+	}
+	if !a.limiter.AllowN(now, 1) {
+		a.mu.Unlock()
+		a.log.Warnf("alert rate limit exceeded, dropped: %s", alert.Title)
+		return false
+	}
+	a.lastSent[key] = now
+	a.pruneLocked(now)
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return false
+	default:
+	}
+	select {
+	case a.queue <- alert:
+		return true
+	default:
+		a.log.Warnf("alert queue full, dropped: %s", alert.Title)
+		return false
+	}
+}
+
+// pruneLocked forgets keys outside the dedup window. a.mu must be held.
+func (a *Alerter) pruneLocked(now time.Time) {
+	if len(a.lastSent) < 1024 {
+		return
+	}
+	for k, t := range a.lastSent {
+		if now.Sub(t) >= a.opts.dedupWindow {
+			delete(a.lastSent, k)
+		}
+	}
+}
+
+func (a *Alerter) run() {
+	defer a.wg.Done()
+	for {
+		select {
+		case alert := <-a.queue:
+			a.deliver(alert)
+		case <-a.done:
+			for {
+				select {
+				case alert := <-a.queue:
+					a.deliver(alert)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *Alerter) deliver(alert *Alert) {
+	for _, n := range a.notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.timeout)
+		if err := n.Notify(ctx, alert); err != nil {
+			a.log.Errorf("send alert %q: %v", alert.Title, err)
+		}
+		cancel()
+	}
+}
+
+// Close stops accepting alerts and delivers the queued ones.
+func (a *Alerter) Close() {
+	a.once.Do(func() { close(a.done) })
+	a.wg.Wait()
+}
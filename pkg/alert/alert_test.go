@@ -0,0 +1,163 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// recorder is a Notifier collecting alerts.
+type recorder struct {
+	mu     sync.Mutex
+	alerts []*Alert
+}
+
+func (r *recorder) Notify(_ context.Context, a *Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *recorder) titles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	titles := make([]string, 0, len(r.alerts))
+	for _, a := range r.alerts {
+		titles = append(titles, a.Title)
+	}
+	return titles
+}
+
+func TestAlerter_Dedup(t *testing.T) {
+	rec := &recorder{}
+	a := NewAlerter([]Notifier{rec}, log.DefaultLogger, WithDedupWindow(time.Hour))
+
+	assert.True(t, a.Send(&Alert{Title: "db down"}))
+	assert.False(t, a.Send(&Alert{Title: "db down"}))
+	assert.True(t, a.Send(&Alert{Title: "db down", Key: "other"}))
+	a.Close()
+
+	assert.Equal(t, []string{"db down", "db down"}, rec.titles())
+	assert.False(t, a.Send(&Alert{Title: "after close"}))
+}
+
+func TestAlerter_RateLimit(t *testing.T) {
+	rec := &recorder{}
+	a := NewAlerter([]Notifier{rec}, log.DefaultLogger, WithRateLimit(2), WithLabels(map[string]string{"service": "svc"}))
+
+	assert.True(t, a.Send(&Alert{Title: "a"}))
+	assert.True(t, a.Send(&Alert{Title: "b", Labels: map[string]string{"k": "v"}}))
+	assert.False(t, a.Send(&Alert{Title: "c"}))
+	a.Close()
+
+	require.Len(t, rec.alerts, 2)
+	assert.Equal(t, map[string]string{"service": "svc", "k": "v"}, rec.alerts[1].Labels)
+}
+
+func TestAlerter_NoNotifiers(t *testing.T) {
+	a := NewAlerter(nil, log.DefaultLogger)
+	defer a.Close()
+	assert.False(t, a.Send(&Alert{Title: "x"}))
+
+	var nilAlerter *Alerter
+	assert.False(t, nilAlerter.Send(&Alert{Title: "x"}))
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	err := NewWebhook(srv.URL).Notify(context.Background(), &Alert{Title: "t", Severity: SeverityWarning, Time: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, "t", got["title"])
+	assert.Equal(t, "warning", got["severity"])
+}
+
+func TestDingTalk(t *testing.T) {
+	var (
+		query signedQuery
+		body  map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = signedQuery{timestamp: r.URL.Query().Get("timestamp"), sign: r.URL.Query().Get("sign")}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	err := NewDingTalk(srv.URL+"/robot/send?access_token=x", "SEC").Notify(context.Background(), &Alert{Title: "t", Time: time.Now()})
+	require.NoError(t, err)
+	assert.NotEmpty(t, query.timestamp)
+	assert.NotEmpty(t, query.sign)
+	assert.Equal(t, "markdown", body["msgtype"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer failing.Close()
+	assert.Error(t, NewDingTalk(failing.URL, "").Notify(context.Background(), &Alert{Title: "t"}))
+}
+
+type signedQuery struct {
+	timestamp string
+	sign      string
+}
+
+func TestFeishu(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer srv.Close()
+
+	err := NewFeishu(srv.URL, "SEC").Notify(context.Background(), &Alert{Title: "t", Content: "c", Time: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, "text", body["msg_type"])
+	assert.NotEmpty(t, body["sign"])
+	assert.Contains(t, body["content"].(map[string]any)["text"], "c")
+}
+
+func TestLifecycleSubscriber(t *testing.T) {
+	rec := &recorder{}
+	a := NewAlerter([]Notifier{rec}, log.DefaultLogger)
+	sub := LifecycleSubscriber(a)
+	ctx := context.Background()
+
+	sub(ctx, lifecycle.DependencyDown{Name: "mysql", Err: errors.New("refused")})
+	sub(ctx, lifecycle.JobFinished{Job: "sync"})
+	sub(ctx, lifecycle.JobFinished{Job: "sync", Err: errors.New("boom")})
+	sub(ctx, lifecycle.JobStarted{Job: "sync"})
+	sub(ctx, lifecycle.QuotaWarning{Subject: "tenant:acme", Window: "daily", Used: 80, Limit: 100})
+	a.Close()
+
+	assert.Equal(t, []string{"dependency down: mysql", "job failed: sync", "quota almost exhausted: tenant:acme"}, rec.titles())
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	rec := &recorder{}
+	a := NewAlerter([]Notifier{rec}, log.DefaultLogger)
+
+	err := RecoveryHandler(a)(context.Background(), nil, "nil map")
+	assert.Error(t, err)
+	a.Close()
+
+	require.Len(t, rec.alerts, 1)
+	assert.Equal(t, SeverityCritical, rec.alerts[0].Severity)
+	assert.Equal(t, "nil map", rec.alerts[0].Content)
+}
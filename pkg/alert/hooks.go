@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// RecoveryHandler returns a recovery handler that raises a critical alert
+// for every recovered panic, deduplicated per operation.
+// Use it with recovery.WithHandler.
+func RecoveryHandler(a *Alerter) recovery.HandlerFunc {
+	return func(ctx context.Context, _, err any) error {
+		operation := "unknown"
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			operation = tr.Operation()
+		}
+		a.Send(&Alert{
+			Title:    "panic in " + operation,
+			Content:  fmt.Sprint(err),
+			Severity: SeverityCritical,
+			Key:      "panic:" + operation,
+		})
+		return recovery.ErrUnknownRequest
+	}
+}
+
+// LifecycleSubscriber raises alerts for failing dependencies and jobs, and
+// for quota subjects close to their limits.
+func LifecycleSubscriber(a *Alerter) lifecycle.Subscriber {
+	return func(_ context.Context, e lifecycle.Event) {
+		switch ev := e.(type) {
+		case lifecycle.DependencyDown:
+			a.Send(&Alert{
+				Title:    "dependency down: " + ev.Name,
+				Content:  fmt.Sprint(ev.Err),
+				Severity: SeverityCritical,
+				Key:      "dependency_down:" + ev.Name,
+			})
+		case lifecycle.DependencyUp:
+			a.Send(&Alert{
+				Title:    "dependency recovered: " + ev.Name,
+				Content:  "down for " + ev.Downtime.String(),
+				Severity: SeverityInfo,
+				Key:      "dependency_up:" + ev.Name,
+			})
+		case lifecycle.JobFinished:
+			if ev.Err == nil {
+				return
+			}
+			a.Send(&Alert{
+				Title:    "job failed: " + ev.Job,
+				Content:  ev.Err.Error(),
+				Severity: SeverityWarning,
+				Key:      "job_failed:" + ev.Job,
+			})
+		case lifecycle.QuotaWarning:
+			a.Send(&Alert{
+				Title:    "quota almost exhausted: " + ev.Subject,
+				Content:  fmt.Sprintf("%s window: %d of %d requests, %d of %d bytes used; resets at %s", ev.Window, ev.Used, ev.Limit, ev.UsedBytes, ev.LimitBytes, ev.ResetAt.Format(time.RFC3339)),
+				Severity: SeverityWarning,
+				Key:      "quota_warning:" + ev.Subject + ":" + ev.Window,
+			})
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook posts alerts as JSON to a generic HTTP endpoint.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook creates a generic webhook notifier.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: http.DefaultClient}
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, a *Alert) error {
+	body := map[string]any{
+		"title":    a.Title,
+		"content":  a.Content,
+		"severity": a.Severity,
+		"labels":   a.Labels,
+		"time":     a.Time.Format(time.RFC3339),
+	}
+	_, err := postJSON(ctx, w.client, w.url, body)
+	return err
+}
+
+// DingTalk sends alerts to a DingTalk group robot as markdown.
+type DingTalk struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewDingTalk creates a DingTalk robot notifier.
+// secret enables request signing and may be empty.
+func NewDingTalk(webhookURL, secret string) *DingTalk {
+	return &DingTalk{url: webhookURL, secret: secret, client: http.DefaultClient}
+}
+
+// Notify implements Notifier.
+func (d *DingTalk) Notify(ctx context.Context, a *Alert) error {
+	target := d.url
+	if d.secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		mac.Write([]byte(ts + "\n" + d.secret))
+		sign := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		target += "&timestamp=" + ts + "&sign=" + sign
+	}
+	body := map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": a.Title,
+			"text":  "### " + formatText(a, "\n\n"),
+		},
+	}
+	resp, err := postJSON(ctx, d.client, target, body)
+	if err != nil {
+		return err
+	}
+	var r struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return fmt.Errorf("decode dingtalk response: %w", err)
+	}
+	if r.ErrCode != 0 {
+		return fmt.Errorf("dingtalk: %d %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+// Feishu sends alerts to a Feishu (Lark) group bot as text.
+type Feishu struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewFeishu creates a Feishu bot notifier.
+// secret enables request signing and may be empty.
+func NewFeishu(webhookURL, secret string) *Feishu {
+	return &Feishu{url: webhookURL, secret: secret, client: http.DefaultClient}
+}
+
+// Notify implements Notifier.
+func (f *Feishu) Notify(ctx context.Context, a *Alert) error {
+	body := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": formatText(a, "\n")},
+	}
+	if f.secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		// Feishu signs an empty message with timestamp+"\n"+secret as the key.
+		mac := hmac.New(sha256.New, []byte(ts+"\n"+f.secret))
+		body["timestamp"] = ts
+		body["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	resp, err := postJSON(ctx, f.client, f.url, body)
+	if err != nil {
+		return err
+	}
+	var r struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return fmt.Errorf("decode feishu response: %w", err)
+	}
+	if r.Code != 0 {
+		return fmt.Errorf("feishu: %d %s", r.Code, r.Msg)
+	}
+	return nil
+}
+
+// formatText renders an alert as plain text lines joined by sep.
+func formatText(a *Alert, sep string) string {
+	lines := []string{fmt.Sprintf("[%s] %s", strings.ToUpper(string(a.Severity)), a.Title)}
+	if a.Content != "" {
+		lines = append(lines, a.Content)
+	}
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+": "+a.Labels[k])
+	}
+	lines = append(lines, "time: "+a.Time.Format(time.DateTime))
+	return strings.Join(lines, sep)
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, body any) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("post alert: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("read alert response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("post alert: status %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
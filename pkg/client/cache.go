@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+)
+
+// Cache stores serialized responses.
+type Cache interface {
+	// Get returns the value of key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheRule enables response caching for one gRPC method. Only cache
+// idempotent reads whose staleness up to TTL is acceptable.
+type CacheRule struct {
+	TTL time.Duration
+	// KeyFields are the request fields (proto names) identifying the response.
+	// Empty uses the whole request.
+	KeyFields []string
+	// Vary lists outgoing metadata keys that partition the cache, e.g.
+	// x-md-tenant when responses differ per tenant.
+	Vary []string
+}
+
+// CacheInterceptor serves unary calls matching rules (keyed by full method
+// name, e.g. /user.v1.User/GetUser) from c and stores successful responses,
+// serialized with cdc (binary protobuf when nil). Cache failures are logged
+// and the call goes to the server.
+func CacheInterceptor(c Cache, rules map[string]CacheRule, cdc codec.Codec, logger log.Logger) grpc.UnaryClientInterceptor {
+	return cacheInterceptor(c, rules, cdc, stalePolicy{}, logger)
+}
+
+// stalePolicy serves the cached responses of a service past their TTL while
+// it is down, see WithDegradation.
+type stalePolicy struct {
+	ctl      *degrade.Controller
+	service  string
+	maxStale time.Duration // zero disables stale responses
+}
+
+// staleKey is the key of the copy of the response of key kept for
+// stalePolicy.maxStale past its TTL.
+func staleKey(key string) string { return "stale:" + key }
+
+func cacheInterceptor(c Cache, rules map[string]CacheRule, cdc codec.Codec, stale stalePolicy, logger log.Logger) grpc.UnaryClientInterceptor {
+	l := log.NewHelper(log.With(logger, "module", "pkg/client/cache"))
+	if cdc == nil {
+		cdc, _ = codec.Get(codec.Protobuf)
+	}
+	// serveStale answers from the stale copy of the response of key.
+	serveStale := func(ctx context.Context, method, key string, out proto.Message, cause error) bool {
+		data, found, err := c.Get(ctx, staleKey(key))
+		if err != nil || !found {
+			return false
+		}
+		if err := cdc.Unmarshal(data, out); err != nil {
+			proto.Reset(out)
+			return false
+		}
+		l.WithContext(ctx).Warnf("serve stale %s of %s: %v", method, stale.service, cause)
+		degrade.Mark(ctx, stale.service)
+		return true
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		rule, ok := rules[method]
+		in, isReq := req.(proto.Message)
+		out, isReply := reply.(proto.Message)
+		if !ok || rule.TTL <= 0 || !isReq || !isReply {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		key, err := cacheKey(ctx, method, in, rule)
+		if err != nil {
+			l.WithContext(ctx).Warnf("cache key for %s: %v", method, err)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		data, found, err := c.Get(ctx, key)
+		if err != nil {
+			l.WithContext(ctx).Warnf("cache get %s: %v", method, err)
+		}
+		if found {
+			if err := cdc.Unmarshal(data, out); err == nil {
+				return nil
+			}
+			proto.Reset(out)
+		}
+
+		if stale.maxStale > 0 && stale.ctl.Active(stale.service) &&
+			serveStale(ctx, method, key, out, fmt.Errorf("%s is down", stale.service)) {
+			return nil
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			if stale.maxStale > 0 && unavailable(err) && serveStale(ctx, method, key, out, err) {
+				return nil
+			}
+			return err
+		}
+		if data, err := cdc.Marshal(out); err == nil {
+			if err := c.Set(ctx, key, data, rule.TTL); err != nil {
+				l.WithContext(ctx).Warnf("cache set %s: %v", method, err)
+			}
+			if stale.maxStale > 0 {
+				if err := c.Set(ctx, staleKey(key), data, rule.TTL+stale.maxStale); err != nil {
+					l.WithContext(ctx).Warnf("cache set stale %s: %v", method, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// unavailable reports whether err means the server could not answer, as
+// opposed to an answer that is an error.
+func unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+func cacheKey(ctx context.Context, method string, req proto.Message, rule CacheRule) (string, error) {
+	h := sha256.New()
+	if len(rule.KeyFields) == 0 {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+	} else {
+		m := req.ProtoReflect()
+		for _, name := range rule.KeyFields {
+			fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+			if fd == nil {
+				return "", fmt.Errorf("unknown request field %q", name)
+			}
+			fmt.Fprintf(h, "%s=%v\x00", name, m.Get(fd))
+		}
+	}
+	if len(rule.Vary) > 0 {
+		md, _ := grpcmd.FromOutgoingContext(ctx)
+		vary := append([]string(nil), rule.Vary...)
+		sort.Strings(vary)
+		for _, k := range vary {
+			fmt.Fprintf(h, "%s=%s\x00", k, strings.Join(md.Get(k), ","))
+		}
+	}
+	return method + ":" + hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// MemoryCache is an in-process Cache bounded by a maximum number of entries.
+// When full, expired entries are purged and then arbitrary ones evicted.
+type MemoryCache struct {
+	max int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a cache holding up to maxEntries responses.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{max: max(maxEntries, 1), entries: make(map[string]memoryEntry)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.max {
+		c.evict()
+	}
+	c.entries[key] = memoryEntry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *MemoryCache) evict() {
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < c.max {
+			return
+		}
+		delete(c.entries, k)
+	}
+}
+
+// RedisCache is a Cache shared between instances.
+type RedisCache struct {
+	client func() redis.Cmdable
+	prefix string
+}
+
+// NewRedisCache creates a cache storing responses under prefix:<method>:<hash>.
+// client is called for every operation so a reconnected client is picked up.
+func NewRedisCache(client func() redis.Cmdable, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get implements Cache.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := c.client().Get(ctx, c.prefix+":"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set implements Cache.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client().Set(ctx, c.prefix+":"+key, value, ttl).Err()
+}
@@ -0,0 +1,418 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kmd "github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	"github.com/go-kratos/kratos-layout/pkg/vcr"
+)
+
+// fakeWatcher returns the instance lists pushed to updates.
+type fakeWatcher struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	updates chan []*registry.ServiceInstance
+}
+
+func (w *fakeWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case ins := <-w.updates:
+		return ins, nil
+	}
+}
+
+func (w *fakeWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+type fakeDiscovery struct {
+	w *fakeWatcher
+}
+
+func (d *fakeDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *fakeDiscovery) Watch(context.Context, string) (registry.Watcher, error) {
+	return d.w, nil
+}
+
+func newFakeDiscovery() *fakeDiscovery {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fakeDiscovery{w: &fakeWatcher{ctx: ctx, cancel: cancel, updates: make(chan []*registry.ServiceInstance, 8)}}
+}
+
+func instances(ids ...string) []*registry.ServiceInstance {
+	result := make([]*registry.ServiceInstance, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, &registry.ServiceInstance{ID: id, Endpoints: []string{"grpc://" + id}})
+	}
+	return result
+}
+
+func ids(ins []*registry.ServiceInstance) []string {
+	result := make([]string, 0, len(ins))
+	for _, in := range ins {
+		result = append(result, in.ID)
+	}
+	return result
+}
+
+func TestStaleDiscovery_Disabled(t *testing.T) {
+	d := newFakeDiscovery()
+	assert.Same(t, registry.Discovery(d), newStaleDiscovery(d, 0, log.DefaultLogger))
+}
+
+func TestStaleDiscovery_ServesCacheUntilTTL(t *testing.T) {
+	d := newFakeDiscovery()
+	sd := newStaleDiscovery(d, 100*time.Millisecond, log.DefaultLogger)
+	w, err := sd.Watch(context.Background(), "svc")
+	require.NoError(t, err)
+	defer w.Stop()
+
+	d.w.updates <- instances("a", "b")
+	got, err := w.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(got))
+
+	// Flap: the cache is served instead of the empty list.
+	d.w.updates <- nil
+	got, err = w.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(got))
+
+	// Further empty updates don't end the stale window early.
+	d.w.updates <- nil
+	start := time.Now()
+	got, err = w.Next()
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Without a cache, empty lists pass through.
+	d.w.updates <- nil
+	got, err = w.Next()
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestStaleDiscovery_Recovery(t *testing.T) {
+	d := newFakeDiscovery()
+	sd := newStaleDiscovery(d, time.Minute, log.DefaultLogger)
+	w, err := sd.Watch(context.Background(), "svc")
+	require.NoError(t, err)
+	defer w.Stop()
+
+	d.w.updates <- instances("a")
+	_, err = w.Next()
+	require.NoError(t, err)
+
+	d.w.updates <- nil
+	got, err := w.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids(got))
+
+	d.w.updates <- instances("c")
+	got, err = w.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(got))
+}
+
+func TestStaleDiscovery_Stop(t *testing.T) {
+	d := newFakeDiscovery()
+	w, err := newStaleDiscovery(d, time.Minute, log.DefaultLogger).Watch(context.Background(), "svc")
+	require.NoError(t, err)
+
+	require.NoError(t, w.Stop())
+	require.NoError(t, w.Stop())
+	_, err = w.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFactory(t *testing.T) {
+	f := NewFactory(newFakeDiscovery(), log.DefaultLogger, WithStaleTTL(time.Second), WithTimeout(time.Second))
+	assert.Len(t, f.middlewares(), 1)
+
+	conn, err := f.GRPC(context.Background(), "greeter")
+	require.NoError(t, err)
+	assert.NoError(t, conn.Close())
+}
+
+type greeter struct {
+	v1.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, req *v1.HelloRequest) (*v1.HelloReply, error) {
+	return &v1.HelloReply{Message: "hello " + req.GetName()}, nil
+}
+
+func TestFactory_Endpoints(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	v1.RegisterGreeterServer(srv, greeter{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	// The fake discovery knows no instances: only the fixed endpoint answers.
+	f := NewFactory(newFakeDiscovery(), log.DefaultLogger, WithTimeout(time.Second),
+		WithEndpoints(map[string]Endpoint{"greeter": {GRPC: lis.Addr().String()}}))
+	conn, err := f.GRPC(context.Background(), "greeter")
+	require.NoError(t, err)
+	defer conn.Close()
+	reply, err := v1.NewGreeterClient(conn).SayHello(context.Background(), &v1.HelloRequest{Name: "stub"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello stub", reply.GetMessage())
+}
+
+// mdGreeter replies with the incoming value of a metadata key.
+type mdGreeter struct {
+	v1.UnimplementedGreeterServer
+}
+
+func (mdGreeter) SayHello(ctx context.Context, req *v1.HelloRequest) (*v1.HelloReply, error) {
+	md, _ := grpcmd.FromIncomingContext(ctx)
+	return &v1.HelloReply{Message: strings.Join(md.Get(req.GetName()), ",")}, nil
+}
+
+func TestFactory_PropagateKeys(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	v1.RegisterGreeterServer(srv, mdGreeter{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	// The context of a request received with a key out of DefaultKeys.
+	ctx := kmd.NewServerContext(context.Background(), kmd.New(map[string][]string{"x-md-foo": {"bar"}}))
+	endpoints := WithEndpoints(map[string]Endpoint{"greeter": {GRPC: lis.Addr().String()}})
+	for name, tc := range map[string]struct {
+		opts []Option
+		want string
+	}{
+		"default keys": {want: ""},
+		"configured":   {opts: []Option{WithPropagateKeys("x-request-id", "x-md-foo")}, want: "bar"},
+		"prefix":       {opts: []Option{WithPropagateKeys("x-md-")}, want: "bar"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := NewFactory(newFakeDiscovery(), log.DefaultLogger, append(tc.opts, endpoints, WithTimeout(time.Second))...)
+			conn, err := f.GRPC(context.Background(), "greeter")
+			require.NoError(t, err)
+			defer conn.Close()
+			reply, err := v1.NewGreeterClient(conn).SayHello(ctx, &v1.HelloRequest{Name: "x-md-foo"})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, reply.GetMessage())
+		})
+	}
+}
+
+func TestFactory_Cassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greeter.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+interactions:
+  - kind: grpc
+    request:
+      method: /helloworld.v1.Greeter/SayHello
+      body: '{"name":"vcr"}'
+    response:
+      body: '{"message":"replayed"}'
+`), 0o644))
+	c, err := vcr.New(path, vcr.ModeReplay)
+	require.NoError(t, err)
+
+	// Nothing listens on the endpoint: the reply comes from the cassette.
+	f := NewFactory(newFakeDiscovery(), log.DefaultLogger, WithCassette(c),
+		WithEndpoints(map[string]Endpoint{"greeter": {GRPC: "127.0.0.1:1"}}))
+	conn, err := f.GRPC(context.Background(), "greeter")
+	require.NoError(t, err)
+	defer conn.Close()
+	reply, err := v1.NewGreeterClient(conn).SayHello(context.Background(), &v1.HelloRequest{Name: "vcr"})
+	require.NoError(t, err)
+	assert.Equal(t, "replayed", reply.GetMessage())
+}
+
+func TestCacheInterceptor(t *testing.T) {
+	const method = "/helloworld.v1.Greeter/SayHello"
+	calls := 0
+	invoker := func(_ context.Context, _ string, req, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		calls++
+		reply.(*v1.HelloReply).Message = "hello " + req.(*v1.HelloRequest).Name
+		return nil
+	}
+	interceptor := CacheInterceptor(NewMemoryCache(10), map[string]CacheRule{
+		method: {TTL: time.Minute, KeyFields: []string{"name"}, Vary: []string{"x-md-tenant"}},
+	}, nil, log.DefaultLogger)
+
+	call := func(ctx context.Context, m, name string) string {
+		reply := &v1.HelloReply{}
+		require.NoError(t, interceptor(ctx, m, &v1.HelloRequest{Name: name}, reply, nil, invoker))
+		return reply.Message
+	}
+	ctx := context.Background()
+	acme := grpcmd.AppendToOutgoingContext(ctx, "x-md-tenant", "acme")
+
+	assert.Equal(t, "hello a", call(ctx, method, "a"))
+	assert.Equal(t, "hello a", call(ctx, method, "a"))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "hello a", call(acme, method, "a"))
+	assert.Equal(t, 2, calls, "vary metadata partitions the cache")
+	assert.Equal(t, "hello b", call(ctx, method, "b"))
+	assert.Equal(t, 3, calls)
+	call(ctx, "/helloworld.v1.Greeter/Other", "a")
+	call(ctx, "/helloworld.v1.Greeter/Other", "a")
+	assert.Equal(t, 5, calls, "methods without a rule are not cached")
+}
+
+func TestCacheInterceptor_Codec(t *testing.T) {
+	const method = "/helloworld.v1.Greeter/SayHello"
+	invoker := func(_ context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		reply.(*v1.HelloReply).Message = "hello"
+		return nil
+	}
+	json, err := codec.Get(codec.JSON)
+	require.NoError(t, err)
+	cache := NewMemoryCache(10)
+	interceptor := CacheInterceptor(cache, map[string]CacheRule{method: {TTL: time.Minute}}, json, log.DefaultLogger)
+	ctx := context.Background()
+	require.NoError(t, interceptor(ctx, method, &v1.HelloRequest{Name: "a"}, &v1.HelloReply{}, nil, invoker))
+
+	key, err := cacheKey(ctx, method, &v1.HelloRequest{Name: "a"}, CacheRule{TTL: time.Minute})
+	require.NoError(t, err)
+	data, ok, err := cache.Get(ctx, key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"message":"hello"}`, string(data))
+
+	reply := &v1.HelloReply{}
+	require.NoError(t, interceptor(ctx, method, &v1.HelloRequest{Name: "a"}, reply, nil, nil), "served from the cache")
+	assert.Equal(t, "hello", reply.GetMessage())
+}
+
+func TestCacheInterceptor_Stale(t *testing.T) {
+	const method = "/helloworld.v1.Greeter/SayHello"
+	bus := lifecycle.NewBus()
+	ctl := degrade.New(bus, map[string]degrade.Policy{"greeter": {Mode: degrade.Stale, MaxStale: time.Hour}})
+	bus.Subscribe(ctl.Subscriber())
+	var callErr error
+	calls := 0
+	invoker := func(_ context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		calls++
+		if callErr != nil {
+			return callErr
+		}
+		reply.(*v1.HelloReply).Message = "hello"
+		return nil
+	}
+	cache := NewMemoryCache(10)
+	interceptor := cacheInterceptor(cache, map[string]CacheRule{method: {TTL: time.Millisecond}}, nil,
+		stalePolicy{ctl: ctl, service: "greeter", maxStale: time.Hour}, log.DefaultLogger)
+	call := func() (string, []string, error) {
+		ctx := degrade.NewContext(context.Background())
+		reply := &v1.HelloReply{}
+		err := interceptor(ctx, method, &v1.HelloRequest{Name: "a"}, reply, nil, invoker)
+		return reply.GetMessage(), degrade.FromContext(ctx), err
+	}
+
+	msg, marks, err := call()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", msg)
+	assert.Empty(t, marks)
+	time.Sleep(5 * time.Millisecond)
+
+	callErr = status.Error(codes.Unavailable, "connection refused")
+	msg, marks, err = call()
+	require.NoError(t, err, "served stale past the TTL")
+	assert.Equal(t, "hello", msg)
+	assert.Equal(t, []string{"greeter"}, marks)
+
+	callErr = status.Error(codes.NotFound, "no such greeting")
+	_, _, err = call()
+	assert.Equal(t, codes.NotFound, status.Code(err), "answers that are errors are returned")
+
+	bus.Emit(context.Background(), lifecycle.DependencyDown{Name: "greeter", Err: errNoInstances})
+	calls = 0
+	msg, marks, err = call()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", msg)
+	assert.Equal(t, []string{"greeter"}, marks)
+	assert.Zero(t, calls, "not called while down")
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Millisecond))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok, "expired entries are evicted first")
+	v, ok, _ := c.Get(ctx, "b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), v)
+}
+
+func TestHedgeInterceptor(t *testing.T) {
+	const method = "/helloworld.v1.Greeter/SayHello"
+	var mu sync.Mutex
+	calls := 0
+	// The first attempt hangs until cancelled, the hedge answers at once.
+	invoker := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		reply.(*v1.HelloReply).Message = "hedged"
+		return nil
+	}
+	interceptor := HedgeInterceptor(map[string]HedgeRule{method: {Delay: 10 * time.Millisecond}}, NewHedgeBudget(0.1))
+
+	reply := &v1.HelloReply{}
+	require.NoError(t, interceptor(context.Background(), method, &v1.HelloRequest{}, reply, nil, invoker))
+	assert.Equal(t, "hedged", reply.Message)
+	assert.Equal(t, 2, calls)
+
+	failing := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return errors.New("unavailable")
+	}
+	assert.EqualError(t, interceptor(context.Background(), method, &v1.HelloRequest{}, &v1.HelloReply{}, nil, failing), "unavailable")
+}
+
+func TestHedgeBudget(t *testing.T) {
+	b := NewHedgeBudget(0.5)
+	for b.withdraw() {
+	}
+	b.deposit()
+	assert.False(t, b.withdraw())
+	b.deposit()
+	assert.True(t, b.withdraw())
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/registry"
+	kgrpc "github.com/go-kratos/kratos/v2/transport/grpc"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
+	"github.com/go-kratos/kratos-layout/pkg/vcr"
+)
+
+// Option configures a Factory.
+type Option func(*options)
+
+type options struct {
+	timeout     time.Duration
+	staleTTL    time.Duration
+	middlewares []middleware.Middleware
+	cache       Cache
+	cacheRules  map[string]CacheRule
+	cacheCodec  codec.Codec
+	hedgeRules  map[string]HedgeRule
+	hedgeBudget float64
+	endpoints   map[string]Endpoint
+	cassette    *vcr.Cassette
+	degrade     *degrade.Controller
+	keys        []string
+}
+
+// Endpoint is a fixed address of a downstream service, bypassing discovery.
+// An empty address keeps discovery for that transport.
+type Endpoint struct {
+	GRPC string // host:port
+	HTTP string // host:port or http(s)://host:port
+}
+
+// WithTimeout sets the default request timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithStaleTTL keeps serving the last-known-good endpoints of a service for d
+// after discovery suddenly reports zero instances. Zero disables the fallback.
+func WithStaleTTL(d time.Duration) Option {
+	return func(o *options) { o.staleTTL = d }
+}
+
+// WithMiddleware appends client middlewares after the default metadata propagation.
+func WithMiddleware(m ...middleware.Middleware) Option {
+	return func(o *options) { o.middlewares = append(o.middlewares, m...) }
+}
+
+// WithPropagateKeys sets the metadata keys, matched by case-insensitive
+// prefix, copied from the server context into outgoing requests. It should
+// be the allowlist of the server, e.g. server.metadata.propagate_keys, so
+// the keys received reach the next hop; metadata.DefaultKeys when unset.
+func WithPropagateKeys(keys ...string) Option {
+	return func(o *options) { o.keys = keys }
+}
+
+// WithResponseCache caches responses of the gRPC methods in rules, see CacheInterceptor.
+func WithResponseCache(c Cache, rules map[string]CacheRule) Option {
+	return func(o *options) {
+		o.cache = c
+		o.cacheRules = rules
+	}
+}
+
+// WithCacheCodec serializes the cached responses with c instead of binary
+// protobuf, e.g. JSON to read them in redis-cli.
+func WithCacheCodec(c codec.Codec) Option {
+	return func(o *options) { o.cacheCodec = c }
+}
+
+// WithHedging sends hedged requests for the gRPC methods in rules, capped at
+// budget (e.g. 0.1) extra attempts per request, see HedgeInterceptor.
+func WithHedging(rules map[string]HedgeRule, budget float64) Option {
+	return func(o *options) {
+		o.hedgeRules = rules
+		o.hedgeBudget = budget
+	}
+}
+
+// WithEndpoints dials the services in endpoints at fixed addresses instead
+// of resolving them, e.g. to point a local run at cmd/stubserver.
+func WithEndpoints(endpoints map[string]Endpoint) Option {
+	return func(o *options) { o.endpoints = endpoints }
+}
+
+// WithCassette records the calls of the created clients to c, or answers
+// them from it in replay mode, for deterministic tests. Cache hits are
+// served before the cassette; hedged attempts are recorded as one call.
+func WithCassette(c *vcr.Cassette) Option {
+	return func(o *options) { o.cassette = c }
+}
+
+// WithDegradation serves the cached responses of the services c degrades
+// with degrade.Stale for up to the MaxStale of their policy past the TTL of
+// the cache rule, while the service is down or when a call fails with
+// Unavailable or DeadlineExceeded. Such responses degrade.Mark the request.
+func WithDegradation(c *degrade.Controller) Option {
+	return func(o *options) { o.degrade = c }
+}
+
+// Factory creates clients for downstream services resolved through service discovery.
+type Factory struct {
+	opts      options
+	discovery registry.Discovery
+	logger    log.Logger
+	budget    *HedgeBudget
+}
+
+// NewFactory creates a client factory on top of d.
+func NewFactory(d registry.Discovery, logger log.Logger, opts ...Option) *Factory {
+	o := options{
+		timeout: 2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.hedgeBudget <= 0 {
+		o.hedgeBudget = 0.1
+	}
+	return &Factory{
+		opts:      o,
+		discovery: newStaleDiscovery(d, o.staleTTL, logger),
+		logger:    logger,
+		budget:    NewHedgeBudget(o.hedgeBudget),
+	}
+}
+
+func (f *Factory) middlewares() []middleware.Middleware {
+	return append([]middleware.Middleware{metadata.Client(f.opts.keys...)}, f.opts.middlewares...)
+}
+
+// GRPC dials the gRPC endpoints of service. The caller closes the connection.
+// Extra options are applied after the factory defaults.
+func (f *Factory) GRPC(ctx context.Context, service string, opts ...kgrpc.ClientOption) (*grpc.ClientConn, error) {
+	o := []kgrpc.ClientOption{
+		kgrpc.WithEndpoint("discovery:///" + service + ".grpc"),
+		kgrpc.WithDiscovery(f.discovery),
+		kgrpc.WithTimeout(f.opts.timeout),
+		kgrpc.WithMiddleware(f.middlewares()...),
+	}
+	if e := f.opts.endpoints[service]; e.GRPC != "" {
+		o[0] = kgrpc.WithEndpoint(e.GRPC)
+	}
+	// kgrpc.WithUnaryInterceptor replaces earlier interceptors, so they are
+	// passed at once. Cache hits are served before any hedging.
+	var ints []grpc.UnaryClientInterceptor
+	if f.opts.cache != nil && len(f.opts.cacheRules) > 0 {
+		ints = append(ints, cacheInterceptor(f.opts.cache, f.opts.cacheRules, f.opts.cacheCodec, f.stalePolicy(service), f.logger))
+	}
+	if f.opts.cassette != nil {
+		ints = append(ints, f.opts.cassette.UnaryClientInterceptor())
+	}
+	if len(f.opts.hedgeRules) > 0 {
+		// The budget is shared by all connections so hedging stays capped
+		// however many clients repos create.
+		ints = append(ints, HedgeInterceptor(f.opts.hedgeRules, f.budget))
+	}
+	if len(ints) > 0 {
+		o = append(o, kgrpc.WithUnaryInterceptor(ints...))
+	}
+	return kgrpc.DialInsecure(ctx, append(o, opts...)...)
+}
+
+func (f *Factory) stalePolicy(service string) stalePolicy {
+	p := f.opts.degrade.Policy(service)
+	if p.Mode != degrade.Stale {
+		return stalePolicy{}
+	}
+	return stalePolicy{ctl: f.opts.degrade, service: service, maxStale: p.MaxStale}
+}
+
+// HTTPEndpoint returns the fixed HTTP address of service set with
+// WithEndpoints, or "" when it is resolved through discovery.
+func (f *Factory) HTTPEndpoint(service string) string {
+	return f.opts.endpoints[service].HTTP
+}
+
+// HTTP creates a client for the HTTP endpoints of service. The caller closes the client.
+// Error details rendered by errdetail.ErrorEncoder are decoded.
+func (f *Factory) HTTP(ctx context.Context, service string, opts ...khttp.ClientOption) (*khttp.Client, error) {
+	o := []khttp.ClientOption{
+		khttp.WithEndpoint("discovery:///" + service + ".http"),
+		khttp.WithDiscovery(f.discovery),
+		khttp.WithTimeout(f.opts.timeout),
+		khttp.WithMiddleware(f.middlewares()...),
+		khttp.WithErrorDecoder(errdetail.ErrorDecoder),
+	}
+	if e := f.opts.endpoints[service]; e.HTTP != "" {
+		o[0] = khttp.WithEndpoint(e.HTTP)
+	}
+	if f.opts.cassette != nil {
+		o = append(o, khttp.WithTransport(f.opts.cassette.Transport(nil)))
+	}
+	return khttp.NewClient(ctx, append(o, opts...)...)
+}
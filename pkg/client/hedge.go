@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// HedgeRule enables hedged requests for one idempotent gRPC method: when the
+// first attempt hasn't answered after the delay, a second one is sent (which
+// the balancer usually routes to another instance) and the first response wins.
+type HedgeRule struct {
+	// Percentile of recently observed latencies (e.g. 0.95) after which the
+	// hedge is sent. Zero always uses Delay.
+	Percentile float64
+	// Delay is used until enough latencies are observed, and as the lower
+	// bound of the percentile delay.
+	Delay time.Duration
+}
+
+// hedgeMinSamples is the number of latencies observed before the percentile
+// delay is used.
+const hedgeMinSamples = 20
+
+// HedgeBudget caps hedged attempts to a fraction of all requests so that a
+// slow downstream is not hit with up to twice the load.
+type HedgeBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+	max    float64
+}
+
+// NewHedgeBudget allows hedges for up to ratio (e.g. 0.1) of requests,
+// with bursts of up to 10 hedges.
+func NewHedgeBudget(ratio float64) *HedgeBudget {
+	return &HedgeBudget{ratio: ratio, tokens: 10, max: 10}
+}
+
+// deposit is called for every request.
+func (b *HedgeBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.max, b.tokens+b.ratio)
+	b.mu.Unlock()
+}
+
+// withdraw reports whether a hedge may be sent.
+func (b *HedgeBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// latencies is a fixed-size window of observed call latencies.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (l *latencies) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < 1000 {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % len(l.samples)
+}
+
+func (l *latencies) percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	sorted := slices.Clone(l.samples)
+	l.mu.Unlock()
+	if len(sorted) < hedgeMinSamples {
+		return 0, false
+	}
+	slices.Sort(sorted)
+	return sorted[int(p*float64(len(sorted)-1))], true
+}
+
+type attempt struct {
+	reply proto.Message
+	err   error
+}
+
+// HedgeInterceptor sends hedged attempts for the unary methods in rules
+// (keyed by full method name). Hedging is skipped when the budget is spent
+// or the call's deadline would expire before the hedge is sent.
+func HedgeInterceptor(rules map[string]HedgeRule, budget *HedgeBudget) grpc.UnaryClientInterceptor {
+	var mu sync.Mutex
+	observed := make(map[string]*latencies, len(rules))
+	window := func(method string) *latencies {
+		mu.Lock()
+		defer mu.Unlock()
+		l, ok := observed[method]
+		if !ok {
+			l = &latencies{}
+			observed[method] = l
+		}
+		return l
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		rule, ok := rules[method]
+		out, isReply := reply.(proto.Message)
+		if !ok || !isReply {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		budget.deposit()
+		lat := window(method)
+		delay := rule.Delay
+		if p, ok := lat.percentile(rule.Percentile); ok && rule.Percentile > 0 && p > delay {
+			delay = p
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results := make(chan attempt, 2)
+		send := func() {
+			r := out.ProtoReflect().New().Interface()
+			start := time.Now()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			if err == nil {
+				lat.observe(time.Since(start))
+			}
+			results <- attempt{reply: r, err: err}
+		}
+		go send()
+
+		var hedgeC <-chan time.Time
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > delay {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			hedgeC = timer.C
+		}
+		pending := 1
+		for {
+			select {
+			case <-hedgeC:
+				hedgeC = nil
+				if budget.withdraw() {
+					pending++
+					go send()
+				}
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					proto.Reset(out)
+					proto.Merge(out, res.reply)
+					return nil
+				}
+				// A primary failing before the delay is an error, not slowness:
+				// it is returned without hedging.
+				if pending == 0 {
+					return res.err
+				}
+			}
+		}
+	}
+}
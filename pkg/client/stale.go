@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// errNoInstances reports a service whose discovery result became empty.
+var errNoInstances = errors.New("discovery reports no instances")
+
+// staleDiscovery wraps a discovery so that a service which suddenly reports
+// zero instances keeps its last-known-good endpoints for ttl. A registry flap
+// then degrades into slightly stale routing instead of a total outage.
+type staleDiscovery struct {
+	registry.Discovery
+	ttl time.Duration
+	log *log.Helper
+}
+
+// newStaleDiscovery wraps d. A non-positive ttl disables the fallback.
+func newStaleDiscovery(d registry.Discovery, ttl time.Duration, logger log.Logger) registry.Discovery {
+	if ttl <= 0 {
+		return d
+	}
+	return &staleDiscovery{
+		Discovery: d,
+		ttl:       ttl,
+		log:       log.NewHelper(log.With(logger, "module", "pkg/client/discovery")),
+	}
+}
+
+// Watch implements registry.Discovery.
+func (d *staleDiscovery) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	w, err := d.Discovery.Watch(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return newStaleWatcher(w, serviceName, d.ttl, d.log), nil
+}
+
+type watchResult struct {
+	instances []*registry.ServiceInstance
+	err       error
+}
+
+// staleWatcher reads the wrapped watcher in the background so that it can
+// stop serving the cached endpoints once the ttl expires, even if the
+// registry sends no further update.
+type staleWatcher struct {
+	w           registry.Watcher
+	serviceName string
+	ttl         time.Duration
+	log         *log.Helper
+	tracker     *lifecycle.DependencyTracker
+
+	updates  chan watchResult
+	done     chan struct{}
+	stopOnce sync.Once
+
+	last       []*registry.ServiceInstance
+	staleUntil time.Time // non-zero while serving last
+}
+
+func newStaleWatcher(w registry.Watcher, serviceName string, ttl time.Duration, logger *log.Helper) *staleWatcher {
+	sw := &staleWatcher{
+		w:           w,
+		serviceName: serviceName,
+		ttl:         ttl,
+		log:         logger,
+		tracker:     lifecycle.NewDependencyTracker(lifecycle.Default(), serviceName),
+		updates:     make(chan watchResult),
+		done:        make(chan struct{}),
+	}
+	go sw.pump()
+	return sw
+}
+
+func (sw *staleWatcher) pump() {
+	for {
+		instances, err := sw.w.Next()
+		select {
+		case sw.updates <- watchResult{instances: instances, err: err}:
+		case <-sw.done:
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+	}
+}
+
+// Next implements registry.Watcher.
+func (sw *staleWatcher) Next() ([]*registry.ServiceInstance, error) {
+	for {
+		r, expired := sw.wait()
+		if expired {
+			sw.log.Warnf("service %s still has no instances after %s, dropping cached endpoints", sw.serviceName, sw.ttl)
+			sw.last, sw.staleUntil = nil, time.Time{}
+			return nil, nil
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		if len(r.instances) > 0 {
+			sw.tracker.Observe(context.Background(), nil)
+			if !sw.staleUntil.IsZero() {
+				sw.log.Infof("service %s recovered with %d instances", sw.serviceName, len(r.instances))
+			}
+			sw.last, sw.staleUntil = r.instances, time.Time{}
+			return r.instances, nil
+		}
+		if len(sw.last) == 0 {
+			return r.instances, nil
+		}
+		sw.tracker.Observe(context.Background(), errNoInstances)
+		if sw.staleUntil.IsZero() {
+			sw.staleUntil = time.Now().Add(sw.ttl)
+			sw.log.Warnf("service %s reports no instances, serving %d cached endpoints for %s", sw.serviceName, len(sw.last), sw.ttl)
+			return sw.last, nil
+		}
+		// Still empty while already serving the cache: keep waiting.
+	}
+}
+
+// wait blocks for the next update, or until the cache expires while serving it.
+func (sw *staleWatcher) wait() (watchResult, bool) {
+	var expired <-chan time.Time
+	if !sw.staleUntil.IsZero() {
+		timer := time.NewTimer(time.Until(sw.staleUntil))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-sw.done:
+		return watchResult{err: context.Canceled}, false
+	case <-expired:
+		return watchResult{}, true
+	case r := <-sw.updates:
+		return r, false
+	}
+}
+
+// Stop implements registry.Watcher.
+func (sw *staleWatcher) Stop() error {
+	sw.stopOnce.Do(func() { close(sw.done) })
+	return sw.w.Stop()
+}
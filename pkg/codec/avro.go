@@ -0,0 +1,441 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Avro is the name of the Avro codec.
+const Avro = "avro"
+
+// avroCodec encodes Go values in the Avro binary encoding, with the schema
+// AvroSchema derives from their type; the schema is not embedded, so
+// readers use the same type or AvroSchema's output. Marshal(&v) and
+// Marshal(v) are the same, the outermost pointer is not a union.
+type avroCodec struct{}
+
+func (avroCodec) Name() string { return Avro }
+
+func (avroCodec) Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer {
+		return nil, errors.New("codec avro: marshal of nil")
+	}
+	var e avroEncoder
+	if err := e.encode(rv); err != nil {
+		return nil, fmt.Errorf("codec avro: %w", err)
+	}
+	return e.buf, nil
+}
+
+func (avroCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("codec avro: unmarshal into non-pointer %T", v)
+	}
+	d := avroDecoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return fmt.Errorf("codec avro: %w", err)
+	}
+	if d.pos != len(data) {
+		return fmt.Errorf("codec avro: %d trailing bytes", len(data)-d.pos)
+	}
+	return nil
+}
+
+// AvroSchema returns the JSON schema the avro codec writes v with:
+// structs are records of their exported fields, named by `avro:"name"`
+// tags or the field name; int8 to int32, uint8 and uint16 are int, other
+// integers long; pointers are unions with null; time.Time is a long with
+// the timestamp-micros logical type. It fails on types Avro cannot
+// represent, such as interfaces or maps with non-string keys.
+func AvroSchema(v any) (string, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "", errors.New("codec avro: schema of nil")
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s, err := avroSchemaOf(t, map[reflect.Type]string{})
+	if err != nil {
+		return "", fmt.Errorf("codec avro: %w", err)
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+var avroInvalidName = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// avroSchemaOf returns the schema of t; named holds the records already
+// defined, which later occurrences reference by name.
+func avroSchemaOf(t reflect.Type, named map[reflect.Type]string) (any, error) {
+	if t == timeType {
+		return map[string]string{"type": "long", "logicalType": "timestamp-micros"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		items, err := avroSchemaOf(t.Elem(), named)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := avroSchemaOf(t.Elem(), named)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "map", "values": values}, nil
+	case reflect.Pointer:
+		s, err := avroSchemaOf(t.Elem(), named)
+		if err != nil {
+			return nil, err
+		}
+		return []any{"null", s}, nil
+	case reflect.Struct:
+		if name, ok := named[t]; ok {
+			return name, nil
+		}
+		name := avroInvalidName.ReplaceAllString(t.Name(), "_")
+		if name == "" {
+			name = fmt.Sprintf("Record%d", len(named)+1)
+		}
+		named[t] = name
+		fields := []map[string]any{}
+		for _, f := range structFields(t, "avro") {
+			s, err := avroSchemaOf(t.FieldByIndex(f.index).Type, named)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			fields = append(fields, map[string]any{"name": f.name, "type": s})
+		}
+		return map[string]any{"type": "record", "name": name, "fields": fields}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+type avroEncoder struct {
+	buf []byte
+}
+
+func (e *avroEncoder) long(i int64) {
+	e.buf = binary.AppendVarint(e.buf, i) // zig-zag, like Avro
+}
+
+func (e *avroEncoder) bytes(b []byte) {
+	e.long(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *avroEncoder) encode(v reflect.Value) error {
+	if v.Type() == timeType {
+		e.long(v.Interface().(time.Time).UnixMicro())
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.long(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return fmt.Errorf("%d overflows long", v.Uint())
+		}
+		e.long(int64(v.Uint()))
+	case reflect.Float32:
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.bytes([]byte(v.String()))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.bytes(b)
+			return nil
+		}
+		// One block followed by the empty block ending the array.
+		if v.Len() > 0 {
+			e.long(int64(v.Len()))
+			for i := range v.Len() {
+				if err := e.encode(v.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		e.long(0)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		if v.Len() > 0 {
+			e.long(int64(v.Len()))
+			keys := v.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+			for _, k := range keys {
+				e.bytes([]byte(k.String()))
+				if err := e.encode(v.MapIndex(k)); err != nil {
+					return err
+				}
+			}
+		}
+		e.long(0)
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.long(0)
+			return nil
+		}
+		e.long(1)
+		return e.encode(v.Elem())
+	case reflect.Struct:
+		for _, f := range structFields(v.Type(), "avro") {
+			if err := e.encode(v.FieldByIndex(f.index)); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+var errAvroShort = errors.New("unexpected end of data")
+
+type avroDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *avroDecoder) long() (int64, error) {
+	i, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errAvroShort
+	}
+	d.pos += n
+	return i, nil
+}
+
+func (d *avroDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errAvroShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *avroDecoder) bytes() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > int64(len(d.data)-d.pos) {
+		return nil, errAvroShort
+	}
+	return d.read(int(n))
+}
+
+// blocks calls item for each item of an array or map.
+func (d *avroDecoder) blocks(item func() error) error {
+	for {
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if n < 0 {
+			// A negative count is followed by the size of the block.
+			n = -n
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		if n > int64(len(d.data)-d.pos) {
+			return errAvroShort
+		}
+		for range n {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (d *avroDecoder) decode(v reflect.Value) error {
+	if v.Type() == timeType {
+		us, err := d.long()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.UnixMicro(us).UTC()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b[0] != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := d.long()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %s", i, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := d.long()
+		if err != nil {
+			return err
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%d overflows %s", i, v.Type())
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+	case reflect.Float64:
+		b, err := d.read(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	case reflect.String:
+		b, err := d.bytes()
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			v.SetZero()
+			if len(b) > 0 {
+				v.SetBytes(slices.Clone(b))
+			}
+			return nil
+		}
+		v.SetZero()
+		return d.blocks(func() error {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(e); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, e))
+			return nil
+		})
+	case reflect.Array:
+		v.SetZero()
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		i := 0
+		return d.blocks(func() error {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(e); err != nil {
+				return err
+			}
+			if i < v.Len() {
+				v.Index(i).Set(e)
+			}
+			i++
+			return nil
+		})
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		v.SetZero()
+		return d.blocks(func() error {
+			k, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(e); err != nil {
+				return err
+			}
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			v.SetMapIndex(reflect.ValueOf(string(k)).Convert(v.Type().Key()), e)
+			return nil
+		})
+	case reflect.Pointer:
+		branch, err := d.long()
+		if err != nil {
+			return err
+		}
+		switch branch {
+		case 0:
+			v.SetZero()
+			return nil
+		case 1:
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			return d.decode(v.Elem())
+		}
+		return fmt.Errorf("invalid union branch %d", branch)
+	case reflect.Struct:
+		for _, f := range structFields(v.Type(), "avro") {
+			if err := d.decode(fieldByIndexAlloc(v, f.index)); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
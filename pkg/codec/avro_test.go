@@ -0,0 +1,90 @@
+package codec
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type invoice struct {
+	ID      string           `avro:"id"`
+	Lines   []invoiceLine    `avro:"lines"`
+	Totals  map[string]int64 `avro:"totals"`
+	Paid    *time.Time       `avro:"paid_at"`
+	Parent  *invoice         `avro:"parent"`
+	Scores  [2]float32       `avro:"scores"`
+	Digest  []byte           `avro:"digest"`
+	Flags   map[string]bool  `avro:"flags"`
+	Issued  time.Time        `avro:"issued_at"`
+	private string
+}
+
+type invoiceLine struct {
+	SKU string
+	Qty int16
+}
+
+func TestAvro_RoundTrip(t *testing.T) {
+	c, err := Get(Avro)
+	require.NoError(t, err)
+	paid := time.Date(2024, 5, 2, 9, 30, 0, 1000, time.UTC)
+	in := invoice{
+		ID:     "inv-1",
+		Lines:  []invoiceLine{{SKU: "a", Qty: 2}, {SKU: "b", Qty: -1}},
+		Totals: map[string]int64{"CNY": 1250, "USD": 170},
+		Paid:   &paid,
+		Parent: &invoice{ID: "inv-0", Issued: time.Unix(0, 0).UTC()},
+		Scores: [2]float32{0.5, 1},
+		Digest: []byte{0xde, 0xad},
+		Issued: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	data, err := c.Marshal(in)
+	require.NoError(t, err)
+	ptr, err := c.Marshal(&in)
+	require.NoError(t, err)
+	assert.Equal(t, data, ptr, "the outermost pointer is not a union")
+
+	var out invoice
+	require.NoError(t, c.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestAvro_Format(t *testing.T) {
+	c, err := Get(Avro)
+	require.NoError(t, err)
+	data, err := c.Marshal(invoiceLine{SKU: "ab", Qty: -2})
+	require.NoError(t, err)
+	assert.Equal(t, "04616203", hex.EncodeToString(data), "string length 2 zig-zagged, then -2")
+
+	// Arrays written in blocks with a negative count and a byte size.
+	var lines []invoiceLine
+	require.NoError(t, c.Unmarshal([]byte{0x01, 0x06, 0x02, 'x', 0x02, 0x02, 0x02, 'y', 0x04, 0x00}, &lines))
+	assert.Equal(t, []invoiceLine{{SKU: "x", Qty: 1}, {SKU: "y", Qty: 2}}, lines)
+
+	_, err = c.Marshal(struct{ V any }{})
+	assert.ErrorContains(t, err, "field V: unsupported type interface {}")
+	var short invoiceLine
+	assert.ErrorContains(t, c.Unmarshal([]byte{0x04, 'a'}, &short), "unexpected end of data")
+}
+
+func TestAvroSchema(t *testing.T) {
+	schema, err := AvroSchema(&invoice{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"record","name":"invoice","fields":[
+		{"name":"id","type":"string"},
+		{"name":"lines","type":{"type":"array","items":{"type":"record","name":"invoiceLine","fields":[
+			{"name":"SKU","type":"string"},{"name":"Qty","type":"int"}]}}},
+		{"name":"totals","type":{"type":"map","values":"long"}},
+		{"name":"paid_at","type":["null",{"type":"long","logicalType":"timestamp-micros"}]},
+		{"name":"parent","type":["null","invoice"]},
+		{"name":"scores","type":{"type":"array","items":"float"}},
+		{"name":"digest","type":"bytes"},
+		{"name":"flags","type":{"type":"map","values":"boolean"}},
+		{"name":"issued_at","type":{"type":"long","logicalType":"timestamp-micros"}}]}`, schema)
+
+	_, err = AvroSchema(map[int]string{})
+	assert.ErrorContains(t, err, "unsupported map key type int")
+}
@@ -0,0 +1,153 @@
+// Package codec selects the serialization of stored and published values,
+// such as cached responses and event payloads, by name from configuration.
+//
+// Codecs live in the kratos encoding registry, so the ones registered for
+// HTTP bodies are available too. json, protobuf (alias proto), gob,
+// msgpack and avro are built in; others are registered by the service with
+// Register, e.g. in an init function wrapping the library it uses:
+//
+//	func init() { codec.Register(cborCodec{}) } // Name() returns "cbor"
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/json"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals and unmarshals values. Implementations must be safe for
+// concurrent use.
+type Codec = encoding.Codec
+
+// Built-in codec names.
+const (
+	JSON     = json.Name
+	Protobuf = "protobuf"
+	Gob      = "gob"
+)
+
+// Use cases selected in conf.Data.codecs.
+const (
+	UseCache     = "cache"     // Downstream responses cached by pkg/client
+	UseEventBus  = "eventbus"  // Event payloads encoded by eventbus.Schema
+	UseTaskQueue = "taskqueue" // Payloads of the tasks queued with timer.Tasks
+	UseSession   = "session"   // Values kept by session.Store
+)
+
+// aliases maps alternative names to registered codecs. The kratos proto
+// codec panics on values that are not messages, so proto means protobuf.
+var aliases = map[string]string{"proto": Protobuf}
+
+// known lists the names Register was called with, for error messages.
+var known = []string{JSON, Protobuf, Gob, Msgpack, Avro}
+
+func init() {
+	encoding.RegisterCodec(protoCodec{})
+	encoding.RegisterCodec(gobCodec{})
+	encoding.RegisterCodec(msgpackCodec{})
+	encoding.RegisterCodec(avroCodec{})
+}
+
+// Register registers c under its lowercase name. Call it from an init
+// function: the registry is not safe for concurrent registration.
+func Register(c Codec) {
+	encoding.RegisterCodec(c)
+	if name := strings.ToLower(c.Name()); !slices.Contains(known, name) {
+		known = append(known, name)
+	}
+}
+
+// Get returns the codec registered under name.
+func Get(name string) (Codec, error) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	if c := encoding.GetCodec(name); c != nil {
+		return c, nil
+	}
+	return nil, fmt.Errorf("codec %q is not registered, known codecs: %s", name, strings.Join(known, ", "))
+}
+
+// Selector holds the codec of each use case.
+type Selector struct {
+	byUse map[string]Codec
+}
+
+// NewSelector resolves names, mapping use cases such as UseCache to codec
+// names, so a misspelled or unregistered codec fails at startup.
+func NewSelector(names map[string]string) (*Selector, error) {
+	s := &Selector{byUse: make(map[string]Codec, len(names))}
+	for use, name := range names {
+		c, err := Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("codec for %s: %w", use, err)
+		}
+		s.byUse[use] = c
+	}
+	return s, nil
+}
+
+// Lookup returns the codec configured for useCase.
+func (s *Selector) Lookup(useCase string) (Codec, bool) {
+	if s == nil {
+		return nil, false
+	}
+	c, ok := s.byUse[useCase]
+	return c, ok
+}
+
+// For returns the codec configured for useCase, JSON when none is.
+func (s *Selector) For(useCase string) Codec {
+	if c, ok := s.Lookup(useCase); ok {
+		return c
+	}
+	return encoding.GetCodec(JSON)
+}
+
+// protoCodec encodes proto messages in the binary wire format.
+type protoCodec struct{}
+
+func (protoCodec) Name() string { return Protobuf }
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("codec protobuf: %T is not a proto message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("codec protobuf: %T is not a proto message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// gobCodec encodes Go values with encoding/gob, for Go-only consumers.
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return Gob }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("codec gob: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return fmt.Errorf("codec gob: %w", err)
+	}
+	return nil
+}
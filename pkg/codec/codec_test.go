@@ -0,0 +1,83 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type order struct {
+	ID     string
+	Amount int64
+	Tags   []string
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := order{ID: "o-1", Amount: 1250, Tags: []string{"vip"}}
+	for _, name := range []string{JSON, Gob, Msgpack, Avro} {
+		t.Run(name, func(t *testing.T) {
+			c, err := Get(name)
+			require.NoError(t, err)
+			data, err := c.Marshal(in)
+			require.NoError(t, err)
+			var out order
+			require.NoError(t, c.Unmarshal(data, &out))
+			assert.Equal(t, in, out)
+		})
+	}
+
+	msg := durationpb.New(90 * time.Second)
+	for _, name := range []string{JSON, Protobuf, "proto", "PROTOBUF"} {
+		t.Run("message/"+name, func(t *testing.T) {
+			c, err := Get(name)
+			require.NoError(t, err)
+			data, err := c.Marshal(msg)
+			require.NoError(t, err)
+			out := &durationpb.Duration{}
+			require.NoError(t, c.Unmarshal(data, out))
+			assert.True(t, proto.Equal(msg, out))
+		})
+	}
+
+	c, err := Get(Protobuf)
+	require.NoError(t, err)
+	_, err = c.Marshal(in)
+	assert.ErrorContains(t, err, "is not a proto message")
+}
+
+type upperCodec struct{ Codec }
+
+func (upperCodec) Name() string { return "Upper" }
+
+func TestRegister(t *testing.T) {
+	_, err := Get("cbor")
+	assert.ErrorContains(t, err, `codec "cbor" is not registered, known codecs: json, protobuf, gob, msgpack, avro`)
+
+	json, err := Get(JSON)
+	require.NoError(t, err)
+	Register(upperCodec{json})
+	c, err := Get("upper")
+	require.NoError(t, err)
+	assert.Equal(t, "Upper", c.Name())
+}
+
+func TestSelector(t *testing.T) {
+	s, err := NewSelector(map[string]string{UseCache: "protobuf"})
+	require.NoError(t, err)
+	c, ok := s.Lookup(UseCache)
+	require.True(t, ok)
+	assert.Equal(t, Protobuf, c.Name())
+	_, ok = s.Lookup(UseEventBus)
+	assert.False(t, ok)
+	assert.Equal(t, JSON, s.For(UseEventBus).Name())
+
+	var none *Selector
+	assert.Equal(t, JSON, none.For(UseCache).Name())
+
+	_, err = NewSelector(map[string]string{UseEventBus: "cbor"})
+	assert.ErrorContains(t, err, `codec for eventbus: codec "cbor" is not registered`)
+}
@@ -0,0 +1,736 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Msgpack is the name of the MessagePack codec.
+const Msgpack = "msgpack"
+
+// msgpackCodec encodes Go values in MessagePack. Structs are maps keyed by
+// field name, or by the name in a `msgpack:"name,omitempty"` tag ("-"
+// skips the field); embedded structs are flattened like encoding/json does.
+// time.Time is the timestamp extension (-1), decoded in UTC. Decoding into
+// an empty interface yields nil, bool, int64, uint64, float64, string,
+// []byte, []any, map[string]any (map[any]any for other keys) or time.Time.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return Msgpack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var e msgpackEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, fmt.Errorf("codec msgpack: %w", err)
+	}
+	return e.buf, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("codec msgpack: unmarshal into non-pointer %T", v)
+	}
+	d := msgpackDecoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return fmt.Errorf("codec msgpack: %w", err)
+	}
+	if d.pos != len(data) {
+		return fmt.Errorf("codec msgpack: %d trailing bytes", len(data)-d.pos)
+	}
+	return nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// msgpackTimestamp is the extension type of timestamps.
+const msgpackTimestamp = -1
+
+// structField is an encoded field of a struct.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var structFieldsCache sync.Map // reflect.Type -> []structField
+
+// structFields returns the encoded fields of t named after tag, e.g.
+// "msgpack", flattening embedded structs without a tag name.
+func structFields(t reflect.Type, tag string) []structField {
+	type key struct {
+		t   reflect.Type
+		tag string
+	}
+	if f, ok := structFieldsCache.Load(key{t, tag}); ok {
+		return f.([]structField)
+	}
+	var fields []structField
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				continue
+			}
+			idx := append(slices.Clone(index), i)
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct && f.Type != timeType {
+				walk(f.Type, idx)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields = append(fields, structField{name: name, index: idx, omitEmpty: opts == "omitempty"})
+		}
+	}
+	walk(t, nil)
+	structFieldsCache.Store(key{t, tag}, fields)
+	return fields
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xca), math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.encodeBytes(b)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(i))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(u))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+	}
+}
+
+// encodeLen writes the header of a value of n elements in the smallest of
+// the fix, 8 (when code8 is set), 16 and 32 bit formats.
+func (e *msgpackEncoder) encodeLen(n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, code16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, code32), uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	e.encodeLen(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encodeBytes(b []byte) {
+	// bin has no fix format: -1 keeps the 8 bit one for n = 0.
+	e.encodeLen(len(b), 0, -1, 0xc4, 0xc5, 0xc6)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *msgpackEncoder) encodeArray(v reflect.Value) error {
+	e.encodeLen(v.Len(), 0x90, 15, 0, 0xdc, 0xdd)
+	for i := range v.Len() {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		// Sorted, so equal maps encode the same.
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+	}
+	e.encodeLen(len(keys), 0x80, 15, 0, 0xde, 0xdf)
+	for _, k := range keys {
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeStruct(v reflect.Value) error {
+	fields := structFields(v.Type(), "msgpack")
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		values, names = append(values, fv), append(names, f.name)
+	}
+	e.encodeLen(len(values), 0x80, 15, 0, 0xde, 0xdf)
+	for i, fv := range values {
+		e.encodeString(names[i])
+		if err := e.encode(fv); err != nil {
+			return fmt.Errorf("field %s: %w", names[i], err)
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd6, 0xff), uint32(sec))
+	case sec >= 0 && sec>>34 == 0:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd7, 0xff), nsec<<34|uint64(sec))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc7, 12, 0xff), uint32(nsec))
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(sec))
+	}
+}
+
+var errMsgpackShort = errors.New("unexpected end of data")
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// msgpackKind is the family of an encoded value.
+type msgpackKind int
+
+const (
+	mpNil msgpackKind = iota
+	mpBool
+	mpInt
+	mpUint
+	mpFloat
+	mpString
+	mpBinary
+	mpArray
+	mpMap
+	mpExt
+)
+
+// header is the decoded header of a value: its scalar for nil, bool and
+// numbers, its length for the others.
+type header struct {
+	kind    msgpackKind
+	b       bool
+	i       int64
+	u       uint64
+	f       float64
+	n       int
+	extType int8
+}
+
+func (d *msgpackDecoder) header() (header, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return header{}, err
+	}
+	c := b[0]
+	lenOf := func(kind msgpackKind, size int) (header, error) {
+		n, err := d.readUint(size)
+		return header{kind: kind, n: int(n)}, err
+	}
+	extOf := func(n int) (header, error) {
+		t, err := d.read(1)
+		if err != nil {
+			return header{}, err
+		}
+		return header{kind: mpExt, n: n, extType: int8(t[0])}, nil
+	}
+	switch {
+	case c <= 0x7f:
+		return header{kind: mpUint, u: uint64(c)}, nil
+	case c >= 0xe0:
+		return header{kind: mpInt, i: int64(int8(c))}, nil
+	case c&0xe0 == 0xa0:
+		return header{kind: mpString, n: int(c & 0x1f)}, nil
+	case c&0xf0 == 0x90:
+		return header{kind: mpArray, n: int(c & 0x0f)}, nil
+	case c&0xf0 == 0x80:
+		return header{kind: mpMap, n: int(c & 0x0f)}, nil
+	}
+	switch c {
+	case 0xc0:
+		return header{kind: mpNil}, nil
+	case 0xc2, 0xc3:
+		return header{kind: mpBool, b: c == 0xc3}, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		return header{kind: mpUint, u: u}, err
+	case 0xd0:
+		u, err := d.readUint(1)
+		return header{kind: mpInt, i: int64(int8(u))}, err
+	case 0xd1:
+		u, err := d.readUint(2)
+		return header{kind: mpInt, i: int64(int16(u))}, err
+	case 0xd2:
+		u, err := d.readUint(4)
+		return header{kind: mpInt, i: int64(int32(u))}, err
+	case 0xd3:
+		u, err := d.readUint(8)
+		return header{kind: mpInt, i: int64(u)}, err
+	case 0xca:
+		u, err := d.readUint(4)
+		return header{kind: mpFloat, f: float64(math.Float32frombits(uint32(u)))}, err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return header{kind: mpFloat, f: math.Float64frombits(u)}, err
+	case 0xd9, 0xda, 0xdb:
+		return lenOf(mpString, 1<<(c-0xd9))
+	case 0xc4, 0xc5, 0xc6:
+		return lenOf(mpBinary, 1<<(c-0xc4))
+	case 0xdc, 0xdd:
+		return lenOf(mpArray, 2<<(c-0xdc))
+	case 0xde, 0xdf:
+		return lenOf(mpMap, 2<<(c-0xde))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return extOf(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return header{}, err
+		}
+		return extOf(int(n))
+	}
+	return header{}, fmt.Errorf("invalid code 0x%02x", c)
+}
+
+func (d *msgpackDecoder) decode(v reflect.Value) error {
+	h, err := d.header()
+	if err != nil {
+		return err
+	}
+	return d.decodeValue(h, v)
+}
+
+func (d *msgpackDecoder) decodeValue(h header, v reflect.Value) error {
+	if h.kind == mpNil {
+		v.SetZero()
+		return nil
+	}
+	if v.Type() == timeType {
+		t, err := d.decodeTime(h)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeValue(h, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("cannot decode into %s", v.Type())
+		}
+		x, err := d.decodeAny(h)
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(x))
+		}
+		return nil
+	case reflect.Bool:
+		if h.kind != mpBool {
+			return d.mismatch(h, v)
+		}
+		v.SetBool(h.b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := h.i
+		switch {
+		case h.kind == mpUint && h.u <= math.MaxInt64:
+			i = int64(h.u)
+		case h.kind != mpInt:
+			return d.mismatch(h, v)
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %s", i, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := h.u
+		switch {
+		case h.kind == mpInt && h.i >= 0:
+			u = uint64(h.i)
+		case h.kind != mpUint:
+			return d.mismatch(h, v)
+		}
+		if v.OverflowUint(u) {
+			return fmt.Errorf("%d overflows %s", u, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch h.kind {
+		case mpFloat:
+			v.SetFloat(h.f)
+		case mpInt:
+			v.SetFloat(float64(h.i))
+		case mpUint:
+			v.SetFloat(float64(h.u))
+		default:
+			return d.mismatch(h, v)
+		}
+	case reflect.String:
+		if h.kind != mpString && h.kind != mpBinary {
+			return d.mismatch(h, v)
+		}
+		b, err := d.read(h.n)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && (h.kind == mpBinary || h.kind == mpString) {
+			b, err := d.read(h.n)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(slices.Clone(b))
+			return nil
+		}
+		if h.kind != mpArray {
+			return d.mismatch(h, v)
+		}
+		if h.n > len(d.data)-d.pos {
+			return errMsgpackShort
+		}
+		v.Set(reflect.MakeSlice(v.Type(), h.n, h.n))
+		for i := range h.n {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && h.kind == mpBinary {
+			b, err := d.read(h.n)
+			if err != nil {
+				return err
+			}
+			v.SetZero()
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		if h.kind != mpArray {
+			return d.mismatch(h, v)
+		}
+		v.SetZero()
+		for i := range h.n {
+			if i >= v.Len() {
+				if err := d.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if h.kind != mpMap {
+			return d.mismatch(h, v)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), min(h.n, len(d.data)-d.pos)))
+		}
+		for range h.n {
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(k); err != nil {
+				return err
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(e); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, e)
+		}
+	case reflect.Struct:
+		if h.kind != mpMap {
+			return d.mismatch(h, v)
+		}
+		fields := structFields(v.Type(), "msgpack")
+		for range h.n {
+			var name string
+			if err := d.decode(reflect.ValueOf(&name).Elem()); err != nil {
+				return err
+			}
+			i := slices.IndexFunc(fields, func(f structField) bool { return f.name == name })
+			if i < 0 {
+				if err := d.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decode(fieldByIndexAlloc(v, fields[i].index)); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// fieldByIndexAlloc returns the field of v at index, allocating the
+// embedded pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func (d *msgpackDecoder) mismatch(h header, v reflect.Value) error {
+	return fmt.Errorf("cannot decode %s into %s", [...]string{"nil", "bool", "int", "uint", "float", "string", "binary", "array", "map", "ext"}[h.kind], v.Type())
+}
+
+func (d *msgpackDecoder) decodeTime(h header) (time.Time, error) {
+	if h.kind != mpExt || h.extType != msgpackTimestamp {
+		return time.Time{}, fmt.Errorf("cannot decode %v into time.Time", h.kind)
+	}
+	b, err := d.read(h.n)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch h.n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b))).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp of %d bytes", h.n)
+}
+
+func (d *msgpackDecoder) decodeAny(h header) (any, error) {
+	switch h.kind {
+	case mpNil:
+		return nil, nil
+	case mpBool:
+		return h.b, nil
+	case mpInt:
+		return h.i, nil
+	case mpUint:
+		if h.u <= math.MaxInt64 {
+			return int64(h.u), nil
+		}
+		return h.u, nil
+	case mpFloat:
+		return h.f, nil
+	case mpString:
+		b, err := d.read(h.n)
+		return string(b), err
+	case mpBinary:
+		b, err := d.read(h.n)
+		return slices.Clone(b), err
+	case mpArray:
+		if h.n > len(d.data)-d.pos {
+			return nil, errMsgpackShort
+		}
+		a := make([]any, h.n)
+		for i := range a {
+			if err := d.decode(reflect.ValueOf(&a[i]).Elem()); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case mpMap:
+		m := make(map[any]any, min(h.n, len(d.data)-d.pos))
+		strKeys := true
+		for range h.n {
+			var k, e any
+			if err := d.decode(reflect.ValueOf(&k).Elem()); err != nil {
+				return nil, err
+			}
+			if err := d.decode(reflect.ValueOf(&e).Elem()); err != nil {
+				return nil, err
+			}
+			if _, ok := k.(string); !ok {
+				strKeys = false
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, fmt.Errorf("map key of type %T", k)
+			}
+			m[k] = e
+		}
+		if !strKeys {
+			return m, nil
+		}
+		sm := make(map[string]any, len(m))
+		for k, e := range m {
+			sm[k.(string)] = e
+		}
+		return sm, nil
+	default:
+		if h.extType == msgpackTimestamp {
+			return d.decodeTime(h)
+		}
+		return nil, fmt.Errorf("unsupported extension type %d", h.extType)
+	}
+}
+
+// skip skips the next value.
+func (d *msgpackDecoder) skip() error {
+	h, err := d.header()
+	if err != nil {
+		return err
+	}
+	switch h.kind {
+	case mpString, mpBinary, mpExt:
+		_, err = d.read(h.n)
+		return err
+	case mpArray, mpMap:
+		n := h.n
+		if h.kind == mpMap {
+			n *= 2
+		}
+		for range n {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shipment struct {
+	order
+	Carrier  string            `msgpack:"carrier"`
+	Weight   float64           `msgpack:"weight,omitempty"`
+	Note     *string           `msgpack:"note"`
+	Parcels  []parcel          `msgpack:"parcels"`
+	Labels   map[string]string `msgpack:"labels"`
+	Raw      []byte            `msgpack:"raw"`
+	Shipped  time.Time         `msgpack:"shipped"`
+	Extra    any               `msgpack:"extra"`
+	internal int
+	Skipped  string `msgpack:"-"`
+}
+
+type parcel struct {
+	Seq   uint16
+	Delta int32
+}
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	c, err := Get(Msgpack)
+	require.NoError(t, err)
+	note := "fragile"
+	in := shipment{
+		order:   order{ID: "o-1", Amount: -70000, Tags: []string{"vip"}},
+		Carrier: "sf",
+		Note:    &note,
+		Parcels: []parcel{{Seq: 1, Delta: -1}, {Seq: 300, Delta: math.MinInt32}},
+		Labels:  map[string]string{"b": "2", "a": "1"},
+		Raw:     []byte{0, 1, 2},
+		Shipped: time.Date(2024, 5, 1, 8, 0, 0, 123, time.UTC),
+		Extra:   map[string]any{"n": int64(-3), "big": uint64(math.MaxUint64), "list": []any{"x", 1.5, true, nil}},
+		Skipped: "not encoded",
+	}
+	data, err := c.Marshal(&in)
+	require.NoError(t, err)
+	var out shipment
+	require.NoError(t, c.Unmarshal(data, &out))
+	in.Skipped = ""
+	assert.Equal(t, in, out)
+
+	again, err := c.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, data, again, "map keys are sorted")
+}
+
+func TestMsgpack_Format(t *testing.T) {
+	c, err := Get(Msgpack)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		in   any
+		want string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{7, "07"},
+		{-5, "fb"},
+		{200, "ccc8"},
+		{-200, "d1ff38"},
+		{uint64(1) << 40, "cf0000010000000000"},
+		{1.5, "cb3ff8000000000000"},
+		{"hi", "a26869"},
+		{[]byte{1}, "c40101"},
+		{[]int{1, 2}, "920102"},
+		{map[string]int{"a": 1}, "81a16101"},
+		{time.Unix(1, 0), "d6ff00000001"},
+	} {
+		data, err := c.Marshal(tc.in)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, hex.EncodeToString(data), "%#v", tc.in)
+	}
+
+	var small int8
+	assert.ErrorContains(t, c.Unmarshal([]byte{0xcc, 0xc8}, &small), "200 overflows int8")
+	var s string
+	assert.ErrorContains(t, c.Unmarshal([]byte{0xa2, 'h'}, &s), "unexpected end of data")
+	assert.ErrorContains(t, c.Unmarshal([]byte{0x07, 0x07}, &small), "1 trailing bytes")
+	assert.ErrorContains(t, c.Unmarshal([]byte{0x07}, small), "non-pointer")
+}
@@ -0,0 +1,190 @@
+package concurrency
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig bounds and tunes an Adaptive limit. Zero values take the
+// defaults noted on each field.
+type AdaptiveConfig struct {
+	Min int // Lower bound and initial limit, 1
+	Max int // Upper bound, 64
+	// Interval between adjustments, 1s.
+	Interval time.Duration
+	// TargetLatency is the processing time above which the limit shrinks,
+	// e.g. because the workers contend on a shared resource. Zero ignores
+	// latency.
+	TargetLatency time.Duration
+	// Backlog returns the work waiting beyond the callers blocked in
+	// Acquire, e.g. the length of a queue. Optional.
+	Backlog func() int
+	// Pressure returns the load of a shared dependency from 0 to 1, e.g.
+	// DBPressure; at MaxPressure or above the limit shrinks. Optional.
+	Pressure    func() float64
+	MaxPressure float64 // 0.8
+}
+
+// Adaptive is a semaphore whose limit follows the load within [Min, Max]:
+// it grows while work is waiting and both latency and pressure are fine,
+// halves when latency or pressure is too high, and shrinks by one when the
+// workers are mostly idle. It replaces a fixed worker count.
+type Adaptive struct {
+	cfg AdaptiveConfig
+
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	waiting  int
+	peak     int           // highest inFlight since the last adjustment
+	latency  time.Duration // moving average of the processing time
+	wake     chan struct{} // closed when a slot may be free
+}
+
+// NewAdaptive creates a limit starting at cfg.Min. Call Run to adjust it.
+func NewAdaptive(cfg AdaptiveConfig) *Adaptive {
+	cfg.Min = max(cfg.Min, 1)
+	if cfg.Max <= 0 {
+		cfg.Max = 64
+	}
+	cfg.Max = max(cfg.Max, cfg.Min)
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxPressure <= 0 {
+		cfg.MaxPressure = 0.8
+	}
+	return &Adaptive{cfg: cfg, limit: cfg.Min, wake: make(chan struct{})}
+}
+
+// Acquire waits for a slot until ctx ends and returns the function
+// releasing it, which records the processing time.
+func (a *Adaptive) Acquire(ctx context.Context) (release func(), err error) {
+	a.mu.Lock()
+	for a.inFlight >= a.limit {
+		wake := a.wake
+		a.waiting++
+		a.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			a.mu.Lock()
+			a.waiting--
+			a.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		a.mu.Lock()
+		a.waiting--
+	}
+	a.inFlight++
+	a.peak = max(a.peak, a.inFlight)
+	a.mu.Unlock()
+
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { a.release(time.Since(start)) })
+	}, nil
+}
+
+func (a *Adaptive) release(elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inFlight--
+	if a.latency == 0 {
+		a.latency = elapsed
+	} else {
+		a.latency = (a.latency*7 + elapsed) / 8
+	}
+	a.broadcast()
+}
+
+// broadcast wakes the waiters. a.mu must be held.
+func (a *Adaptive) broadcast() {
+	close(a.wake)
+	a.wake = make(chan struct{})
+}
+
+// Limit returns the current limit.
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// Max returns the upper bound of the limit.
+func (a *Adaptive) Max() int { return a.cfg.Max }
+
+// InFlight returns the number of holders.
+func (a *Adaptive) InFlight() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inFlight
+}
+
+// Run adjusts the limit every interval until ctx is done.
+func (a *Adaptive) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.adjust()
+		}
+	}
+}
+
+// adjust moves the limit once according to the signals.
+func (a *Adaptive) adjust() {
+	backlog := 0
+	if a.cfg.Backlog != nil {
+		backlog = a.cfg.Backlog()
+	}
+	pressured := a.cfg.Pressure != nil && a.cfg.Pressure() >= a.cfg.MaxPressure
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	backlog += a.waiting
+	slow := a.cfg.TargetLatency > 0 && a.latency > a.cfg.TargetLatency
+	limit := a.limit
+	switch {
+	case pressured || slow:
+		limit /= 2
+	case backlog > 0:
+		limit += max(1, limit/4)
+	case a.peak <= limit/2:
+		limit--
+	}
+	limit = min(max(limit, a.cfg.Min), a.cfg.Max)
+	if limit > a.limit {
+		a.broadcast()
+	}
+	a.limit = limit
+	a.peak = a.inFlight
+}
+
+// DBPressure reports the share of the connections of db in use, or 1 while
+// callers wait for a connection, as an Adaptive pressure signal. Pools
+// without a maximum report 0.
+func DBPressure(db *sql.DB) func() float64 {
+	var mu sync.Mutex
+	var lastWaits int64
+	return func() float64 {
+		s := db.Stats()
+		mu.Lock()
+		waited := s.WaitCount > lastWaits
+		lastWaits = s.WaitCount
+		mu.Unlock()
+		if waited {
+			return 1
+		}
+		if s.MaxOpenConnections <= 0 {
+			return 0
+		}
+		return float64(s.InUse) / float64(s.MaxOpenConnections)
+	}
+}
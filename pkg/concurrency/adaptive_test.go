@@ -0,0 +1,81 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptive_Scale(t *testing.T) {
+	backlog, pressure := 0, 0.0
+	a := NewAdaptive(AdaptiveConfig{
+		Min:      2,
+		Max:      8,
+		Backlog:  func() int { return backlog },
+		Pressure: func() float64 { return pressure },
+	})
+	assert.Equal(t, 2, a.Limit())
+
+	backlog = 100
+	for range 10 {
+		a.adjust()
+	}
+	assert.Equal(t, 8, a.Limit(), "grows to max while work is waiting")
+
+	pressure = 0.9
+	a.adjust()
+	assert.Equal(t, 4, a.Limit(), "halves under pressure")
+
+	backlog, pressure = 0, 0
+	for range 10 {
+		a.adjust()
+	}
+	assert.Equal(t, 2, a.Limit(), "shrinks to min when idle")
+}
+
+func TestAdaptive_Latency(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{Min: 1, Max: 8, TargetLatency: time.Millisecond, Backlog: func() int { return 1 }})
+	a.adjust()
+	a.adjust()
+	require.Equal(t, 3, a.Limit())
+
+	release, err := a.Acquire(context.Background())
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	release()
+	release()
+	assert.Equal(t, 0, a.InFlight(), "release is idempotent")
+	a.adjust()
+	assert.Equal(t, 1, a.Limit(), "halves when slower than the target")
+}
+
+func TestAdaptive_Acquire(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{Min: 1, Max: 2})
+	release, err := a.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = a.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan func())
+	go func() {
+		r, err := a.Acquire(context.Background())
+		assert.NoError(t, err)
+		acquired <- r
+	}()
+	require.Eventually(t, func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.waiting == 1
+	}, time.Second, time.Millisecond)
+	a.adjust()
+	second := <-acquired
+	assert.Equal(t, 2, a.InFlight(), "a raised limit wakes the waiters")
+	release()
+	second()
+}
@@ -0,0 +1,131 @@
+// Package concurrency caps the in-flight requests of expensive operations so
+// that they cannot take every server goroutine and starve cheap ones.
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	mw "github.com/go-kratos/kratos-layout/pkg/middleware"
+)
+
+// ReasonLimited is the error reason of requests rejected because their
+// operation stayed at its limit for the whole queue timeout.
+const ReasonLimited = "CONCURRENCY_LIMITED"
+
+// Limit caps each operation matched by Selectors (pkg/middleware syntax) at
+// Max requests in flight. Further requests wait up to QueueTimeout for a
+// slot and are then rejected with 429; zero rejects them immediately.
+type Limit struct {
+	Selectors    []string
+	Max          int
+	QueueTimeout time.Duration
+}
+
+// Gate is a semaphore with a bounded wait.
+type Gate struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewGate creates a gate admitting n (>= 1) holders at a time.
+func NewGate(n int, queueTimeout time.Duration) *Gate {
+	return &Gate{slots: make(chan struct{}, max(n, 1)), timeout: queueTimeout}
+}
+
+// Acquire waits for a slot until the queue timeout or ctx ends, and returns
+// the function releasing it.
+func (g *Gate) Acquire(ctx context.Context) (release func(), ok bool) {
+	release = func() { <-g.slots }
+	select {
+	case g.slots <- struct{}{}:
+		return release, true
+	default:
+	}
+	if g.timeout <= 0 {
+		return nil, false
+	}
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case g.slots <- struct{}{}:
+		return release, true
+	case <-timer.C:
+		return nil, false
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// InFlight returns the number of holders.
+func (g *Gate) InFlight() int {
+	return len(g.slots)
+}
+
+type rule struct {
+	Limit
+	match func(operation string) bool
+}
+
+// Server enforces limits; the first limit matching an operation applies.
+// Every operation gets its own gate, so two routes matched by one limit do
+// not share slots.
+func Server(limits []Limit) (middleware.Middleware, error) {
+	rules := make([]rule, 0, len(limits))
+	for _, l := range limits {
+		if l.Max <= 0 {
+			return nil, fmt.Errorf("concurrency limit for %v: max must be positive", l.Selectors)
+		}
+		match, err := mw.NewMatcher(l.Selectors)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule{Limit: l, match: match})
+	}
+
+	var mu sync.Mutex
+	gates := make(map[string]*Gate)
+	gate := func(operation string) *Gate {
+		mu.Lock()
+		defer mu.Unlock()
+		if g, ok := gates[operation]; ok {
+			return g
+		}
+		var g *Gate
+		for _, r := range rules {
+			if r.match(operation) {
+				g = NewGate(r.Max, r.QueueTimeout)
+				break
+			}
+		}
+		gates[operation] = g
+		return g
+	}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			g := gate(tr.Operation())
+			if g == nil {
+				return handler(ctx, req)
+			}
+			release, ok := g.Acquire(ctx)
+			if !ok {
+				return nil, errdetail.WithRetryInfo(errors.New(429, ReasonLimited,
+					"too many concurrent requests for "+tr.Operation()), time.Second)
+			}
+			defer release()
+			return handler(ctx, req)
+		}
+	}, nil
+}
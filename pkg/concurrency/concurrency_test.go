@@ -0,0 +1,105 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind { return transport.KindGRPC }
+func (t *testTransport) Operation() string    { return t.operation }
+
+func TestGate(t *testing.T) {
+	g := NewGate(1, 20*time.Millisecond)
+	release, ok := g.Acquire(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, 1, g.InFlight())
+
+	start := time.Now()
+	_, ok = g.Acquire(context.Background())
+	assert.False(t, ok)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	release, ok = g.Acquire(context.Background())
+	require.True(t, ok)
+	release()
+	assert.Zero(t, g.InFlight())
+
+	_, ok = g.Acquire(context.Background())
+	require.True(t, ok)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	_, ok = NewGate(1, time.Hour).Acquire(ctx)
+	assert.True(t, ok, "a free slot is taken even when ctx is done")
+	_, ok = g.Acquire(ctx)
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestServer(t *testing.T) {
+	m, err := Server([]Limit{{Selectors: []string{"/report.v1.Report/*"}, Max: 1}})
+	require.NoError(t, err)
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	h := m(func(ctx context.Context, _ any) (any, error) {
+		if tr, _ := transport.FromServerContext(ctx); tr.Operation() == "/report.v1.Report/Export" {
+			entered <- struct{}{}
+			<-unblock
+		}
+		return "ok", nil
+	})
+	call := func(op string) (any, error) {
+		return h(transport.NewServerContext(context.Background(), &testTransport{operation: op}), nil)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = call("/report.v1.Report/Export")
+	}()
+	<-entered
+
+	_, err = call("/report.v1.Report/Export")
+	assert.Equal(t, ReasonLimited, errors.Reason(err))
+	assert.Equal(t, 429, errors.Code(err))
+	delay, ok := errdetail.RetryDelay(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+
+	// Other routes, matched or not, have their own slots.
+	for _, op := range []string{"/report.v1.Report/Summary", "/helloworld.v1.Greeter/SayHello"} {
+		reply, err := call(op)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", reply)
+	}
+
+	close(unblock)
+	wg.Wait()
+}
+
+func TestServer_InvalidLimit(t *testing.T) {
+	_, err := Server([]Limit{{Selectors: []string{"/a/*"}}})
+	assert.Error(t, err)
+	_, err = Server([]Limit{{Selectors: []string{"re:("}, Max: 1}})
+	assert.Error(t, err)
+}
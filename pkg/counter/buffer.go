@@ -0,0 +1,199 @@
+package counter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Buffer holds increments until they are flushed to the database.
+type Buffer interface {
+	// Add adds delta to the pending increments of key.
+	Add(ctx context.Context, key Key, delta int64) error
+	// Pending returns the increments of keys not in the database yet,
+	// including the ones of a batch being flushed.
+	Pending(ctx context.Context, keys ...Key) ([]int64, error)
+	// Take moves the pending increments aside as a batch, or returns the
+	// batch of a flush that did not finish. The batch ID is empty when
+	// nothing is pending.
+	Take(ctx context.Context) (batch string, deltas map[Key]int64, err error)
+	// Done drops a flushed batch.
+	Done(ctx context.Context, batch string) error
+}
+
+// batchField holds the batch ID in the Redis hash of a taken batch. Field
+// names of keys never start with a NUL byte.
+const batchField = "\x00batch"
+
+// field encodes key as a hash field.
+func field(k Key) string { return k.Name + "\x00" + k.ID }
+
+func parseField(f string) (Key, bool) {
+	name, id, ok := strings.Cut(f, "\x00")
+	return Key{Name: name, ID: id}, ok && name != ""
+}
+
+func newBatchID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// takeScript renames the pending hash to the flushing one and stamps it
+// with a batch ID, unless a flushing hash is left from an unfinished flush.
+var takeScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	if redis.call('EXISTS', KEYS[1]) == 0 then
+		return {}
+	end
+	redis.call('RENAME', KEYS[1], KEYS[2])
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+end
+return redis.call('HGETALL', KEYS[2])
+`)
+
+// doneScript deletes the flushing hash if it still holds the batch.
+var doneScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisBuffer keeps pending increments in one Redis hash, and the batch
+// being flushed in another. Both share a hash tag, so it works on Redis
+// Cluster.
+type RedisBuffer struct {
+	client   func() redis.Cmdable
+	pending  string
+	flushing string
+}
+
+var _ Buffer = (*RedisBuffer)(nil)
+
+// NewRedisBuffer creates a buffer under "{prefix}:pending" and
+// "{prefix}:flushing". client is called per operation so reconnects are
+// picked up.
+func NewRedisBuffer(client func() redis.Cmdable, prefix string) *RedisBuffer {
+	tag := "{" + prefix + "}"
+	return &RedisBuffer{client: client, pending: tag + ":pending", flushing: tag + ":flushing"}
+}
+
+// Add implements Buffer.
+func (b *RedisBuffer) Add(ctx context.Context, key Key, delta int64) error {
+	return b.client().HIncrBy(ctx, b.pending, field(key), delta).Err()
+}
+
+// Pending implements Buffer.
+func (b *RedisBuffer) Pending(ctx context.Context, keys ...Key) ([]int64, error) {
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = field(k)
+	}
+	p := b.client().Pipeline()
+	pending := p.HMGet(ctx, b.pending, fields...)
+	flushing := p.HMGet(ctx, b.flushing, fields...)
+	if _, err := p.Exec(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]int64, len(keys))
+	for i := range keys {
+		out[i] = parseInt(pending.Val()[i]) + parseInt(flushing.Val()[i])
+	}
+	return out, nil
+}
+
+// Take implements Buffer.
+func (b *RedisBuffer) Take(ctx context.Context) (string, map[Key]int64, error) {
+	res, err := takeScript.Run(ctx, b.client(), []string{b.pending, b.flushing}, batchField, newBatchID()).StringSlice()
+	if err != nil {
+		return "", nil, err
+	}
+	var batch string
+	deltas := make(map[Key]int64, len(res)/2)
+	for i := 0; i+1 < len(res); i += 2 {
+		if res[i] == batchField {
+			batch = res[i+1]
+			continue
+		}
+		if k, ok := parseField(res[i]); ok {
+			deltas[k] = parseInt(res[i+1])
+		}
+	}
+	return batch, deltas, nil
+}
+
+// Done implements Buffer.
+func (b *RedisBuffer) Done(ctx context.Context, batch string) error {
+	return doneScript.Run(ctx, b.client(), []string{b.flushing}, batchField, batch).Err()
+}
+
+func parseInt(v any) int64 {
+	s, _ := v.(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// MemoryBuffer is an in-process Buffer for tests and single instances.
+// Pending increments are lost when the process exits.
+type MemoryBuffer struct {
+	mu       sync.Mutex
+	pending  map[Key]int64
+	batch    string
+	flushing map[Key]int64
+}
+
+var _ Buffer = (*MemoryBuffer)(nil)
+
+// NewMemoryBuffer creates an empty in-process buffer.
+func NewMemoryBuffer() *MemoryBuffer {
+	return &MemoryBuffer{pending: make(map[Key]int64)}
+}
+
+// Add implements Buffer.
+func (b *MemoryBuffer) Add(_ context.Context, key Key, delta int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending[key] += delta
+	return nil
+}
+
+// Pending implements Buffer.
+func (b *MemoryBuffer) Pending(_ context.Context, keys ...Key) ([]int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]int64, len(keys))
+	for i, k := range keys {
+		out[i] = b.pending[k] + b.flushing[k]
+	}
+	return out, nil
+}
+
+// Take implements Buffer.
+func (b *MemoryBuffer) Take(context.Context) (string, map[Key]int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.batch == "" && len(b.pending) > 0 {
+		b.batch, b.flushing, b.pending = newBatchID(), b.pending, make(map[Key]int64)
+	}
+	deltas := make(map[Key]int64, len(b.flushing))
+	for k, v := range b.flushing {
+		deltas[k] = v
+	}
+	return b.batch, deltas, nil
+}
+
+// Done implements Buffer.
+func (b *MemoryBuffer) Done(_ context.Context, batch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.batch == batch {
+		b.batch, b.flushing = "", nil
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+// Package counter keeps denormalized counters, such as likes, views or
+// usage, that change too often to update a row per request: increments go
+// to a Buffer, Redis in production, and Flush adds them to the counters
+// table in batches. Reads combine both, so they see every increment, and
+// tell how much of the value is not stored yet.
+//
+// Every batch is applied once: its ID is recorded with the upserts, so a
+// batch whose flush failed or was interrupted, or that two instances flush
+// concurrently, is not counted twice.
+package counter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// flushBatchSize is the number of rows per upsert statement.
+const flushBatchSize = 500
+
+// flushRetention is how long applied batch IDs are kept.
+const flushRetention = 7 * 24 * time.Hour
+
+// Key identifies a counter, e.g. {Name: "post_likes", ID: "42"}.
+type Key struct {
+	Name string
+	ID   string
+}
+
+// Counter is the stored value of a counter.
+type Counter struct {
+	Name      string `gorm:"primaryKey;size:64"`
+	EntityID  string `gorm:"primaryKey;size:128"`
+	Value     int64  `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// TableName implements gorm's tabler.
+func (Counter) TableName() string { return "counters" }
+
+// Flush records an applied batch.
+type Flush struct {
+	Batch     string    `gorm:"primaryKey;size:32"`
+	FlushedAt time.Time `gorm:"not null;index"`
+}
+
+// TableName implements gorm's tabler.
+func (Flush) TableName() string { return "counter_flushes" }
+
+// Value is the current value of a counter.
+type Value struct {
+	// Count is Stored plus Pending.
+	Count   int64
+	Stored  int64
+	Pending int64
+	// StoredAt is when Stored last changed; zero when never flushed.
+	StoredAt time.Time
+	// Partial reports that the buffer could not be read: Count is only
+	// the stored value, missing the increments since StoredAt.
+	Partial bool
+}
+
+// DBFunc returns the database session for ctx.
+type DBFunc func(ctx context.Context) *gorm.DB
+
+// Counters increments and reads counters.
+type Counters struct {
+	buf Buffer
+	db  DBFunc
+}
+
+// New creates counters buffering increments in buf and storing them in
+// the counters table of db.
+func New(buf Buffer, db DBFunc) *Counters {
+	return &Counters{buf: buf, db: db}
+}
+
+// Incr adds delta to the counter of key. The increment is buffered, not
+// part of a database transaction of ctx.
+func (c *Counters) Incr(ctx context.Context, key Key, delta int64) error {
+	if key.Name == "" || strings.ContainsRune(key.Name, 0) {
+		return fmt.Errorf("counter: invalid name %q", key.Name)
+	}
+	if err := c.buf.Add(ctx, key, delta); err != nil {
+		return fmt.Errorf("counter: increment %s/%s: %w", key.Name, key.ID, err)
+	}
+	return nil
+}
+
+// Get returns the value of the counter of key. An unavailable buffer does
+// not fail it: the value is then the stored one, marked Partial.
+func (c *Counters) Get(ctx context.Context, key Key) (Value, error) {
+	values, err := c.GetMany(ctx, key)
+	if err != nil {
+		return Value{}, err
+	}
+	return values[0], nil
+}
+
+// GetMany returns the values of the counters of keys, in order.
+func (c *Counters) GetMany(ctx context.Context, keys ...Key) ([]Value, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pairs := make([][]any, len(keys))
+	for i, k := range keys {
+		pairs[i] = []any{k.Name, k.ID}
+	}
+	var rows []Counter
+	if err := c.db(ctx).Where("(name, entity_id) IN ?", pairs).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("counter: read counters: %w", err)
+	}
+	stored := make(map[Key]Counter, len(rows))
+	for _, r := range rows {
+		stored[Key{Name: r.Name, ID: r.EntityID}] = r
+	}
+	pending, err := c.buf.Pending(ctx, keys...)
+	values := make([]Value, len(keys))
+	for i, k := range keys {
+		r := stored[k]
+		values[i] = Value{Count: r.Value, Stored: r.Value, StoredAt: r.UpdatedAt, Partial: err != nil}
+		if err == nil {
+			values[i].Pending = pending[i]
+			values[i].Count += pending[i]
+		}
+	}
+	return values, nil
+}
+
+// Flush adds the buffered increments to the stored counters and returns
+// how many counters changed. On failure the batch stays in the buffer and
+// the next Flush applies it.
+func (c *Counters) Flush(ctx context.Context) (int, error) {
+	batch, deltas, err := c.buf.Take(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("counter: take batch: %w", err)
+	}
+	if batch == "" {
+		return 0, nil
+	}
+	now := time.Now()
+	rows := make([]Counter, 0, len(deltas))
+	for k, d := range deltas {
+		if d != 0 {
+			rows = append(rows, Counter{Name: k.Name, EntityID: k.ID, Value: d, UpdatedAt: now})
+		}
+	}
+	// A fixed order keeps concurrent flushes from deadlocking.
+	slices.SortFunc(rows, func(a, b Counter) int {
+		return strings.Compare(a.Name+"\x00"+a.EntityID, b.Name+"\x00"+b.EntityID)
+	})
+
+	applied := true
+	err = c.db(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Flush{Batch: batch, FlushedAt: now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			applied = false
+			return nil
+		}
+		upsert := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}, {Name: "entity_id"}},
+			DoUpdates: clause.Set{{Column: clause.Column{Name: "value"}, Value: addExcluded(tx)}, {Column: clause.Column{Name: "updated_at"}, Value: now}},
+		}
+		for chunk := range slices.Chunk(rows, flushBatchSize) {
+			if err := tx.Clauses(upsert).Create(&chunk).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("flushed_at < ?", now.Add(-flushRetention)).Delete(&Flush{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("counter: flush batch %s: %w", batch, err)
+	}
+	if err := c.buf.Done(ctx, batch); err != nil {
+		return 0, fmt.Errorf("counter: drop batch %s: %w", batch, err)
+	}
+	if !applied {
+		return 0, nil
+	}
+	return len(rows), nil
+}
+
+// addExcluded adds the inserted value to the stored one in an upsert.
+func addExcluded(db *gorm.DB) clause.Expr {
+	if db.Dialector.Name() == orm.DriverMySQL {
+		return gorm.Expr("value + VALUES(value)")
+	}
+	return gorm.Expr("counters.value + excluded.value")
+}
@@ -0,0 +1,111 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+func newCounters(t *testing.T, buf Buffer) (*Counters, *gorm.DB) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&Counter{}, &Flush{}))
+	return New(buf, func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }), db
+}
+
+// flakyBuffer fails Pending and Done on demand.
+type flakyBuffer struct {
+	*MemoryBuffer
+	failPending, failDone bool
+}
+
+func (b *flakyBuffer) Pending(ctx context.Context, keys ...Key) ([]int64, error) {
+	if b.failPending {
+		return nil, errors.New("redis down")
+	}
+	return b.MemoryBuffer.Pending(ctx, keys...)
+}
+
+func (b *flakyBuffer) Done(ctx context.Context, batch string) error {
+	if b.failDone {
+		return errors.New("redis down")
+	}
+	return b.MemoryBuffer.Done(ctx, batch)
+}
+
+func TestCounters(t *testing.T) {
+	ctx := context.Background()
+	buf := &flakyBuffer{MemoryBuffer: NewMemoryBuffer()}
+	c, _ := newCounters(t, buf)
+	likes, views := Key{Name: "post_likes", ID: "1"}, Key{Name: "post_views", ID: "1"}
+
+	require.NoError(t, c.Incr(ctx, likes, 1))
+	require.NoError(t, c.Incr(ctx, likes, 2))
+	require.NoError(t, c.Incr(ctx, views, 10))
+	assert.Error(t, c.Incr(ctx, Key{ID: "1"}, 1))
+
+	v, err := c.Get(ctx, likes)
+	require.NoError(t, err)
+	assert.Equal(t, Value{Count: 3, Pending: 3}, v, "unflushed increments are read from the buffer")
+
+	n, err := c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.NoError(t, c.Incr(ctx, likes, -1))
+
+	values, err := c.GetMany(ctx, likes, views, Key{Name: "post_likes", ID: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), values[0].Count)
+	assert.Equal(t, int64(3), values[0].Stored)
+	assert.Equal(t, int64(-1), values[0].Pending)
+	assert.False(t, values[0].StoredAt.IsZero())
+	assert.Equal(t, int64(10), values[1].Count)
+	assert.Equal(t, Value{}, values[2])
+
+	n, err = c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "flushes add to the stored value")
+	n, err = c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	buf.failPending = true
+	v, err = c.Get(ctx, likes)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), v.Count)
+	assert.True(t, v.Partial, "the stored value is served without the buffer")
+}
+
+func TestCounters_FlushOnce(t *testing.T) {
+	ctx := context.Background()
+	buf := &flakyBuffer{MemoryBuffer: NewMemoryBuffer(), failDone: true}
+	c, db := newCounters(t, buf)
+	key := Key{Name: "downloads", ID: "a"}
+
+	require.NoError(t, c.Incr(ctx, key, 5))
+	_, err := c.Flush(ctx)
+	assert.ErrorContains(t, err, "drop batch")
+
+	// The batch was applied but is still in the buffer: it is not counted
+	// again by reads or the next flush.
+	require.NoError(t, c.Incr(ctx, key, 1))
+	buf.failDone = false
+	n, err := c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = c.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var stored Counter
+	require.NoError(t, db.First(&stored, "name = ? AND entity_id = ?", "downloads", "a").Error)
+	assert.Equal(t, int64(6), stored.Value)
+	var batches int64
+	require.NoError(t, db.Model(&Flush{}).Count(&batches).Error)
+	assert.Equal(t, int64(2), batches)
+}
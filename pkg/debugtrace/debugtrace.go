@@ -0,0 +1,268 @@
+// Package debugtrace captures everything one request did, on demand: an
+// operator issues a short-lived token from the admin server, the caller
+// sends it in the X-Debug-Token header, and the request, its reply, its SQL
+// statements, Redis commands and downstream calls are stored as a Trace
+// retrievable from the admin server, to diagnose issues that only happen
+// for some users or data.
+//
+// Requests without the header pay one header lookup.
+package debugtrace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/pkg/support"
+)
+
+const (
+	// TokenHeader carries the debug token of a request.
+	TokenHeader = "X-Debug-Token"
+	// TraceIDHeader is set on the reply of a traced request to the ID of its
+	// trace.
+	TraceIDHeader = "X-Debug-Trace-Id"
+)
+
+const (
+	// MaxBodyBytes bounds the captured request and reply bodies.
+	MaxBodyBytes = 64 << 10
+	// MaxSpans bounds the spans of a trace; the ones after are counted in
+	// Trace.DroppedSpans.
+	MaxSpans = 1000
+	// maxSpanName bounds the statement or command of a span.
+	maxSpanName = 2048
+)
+
+// Span kinds.
+const (
+	KindDB    = "db"
+	KindRedis = "redis"
+	KindCall  = "call"
+)
+
+// Span is a statement, command or call made by a traced request.
+type Span struct {
+	Kind string `json:"kind"`
+	// Name is the SQL statement, the Redis command or the called operation.
+	Name string `json:"name"`
+	// Start is the time since the start of the request.
+	Start    time.Duration `json:"start_ns"`
+	Duration time.Duration `json:"duration_ns"`
+	Rows     int64         `json:"rows,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Trace is the capture of one request.
+type Trace struct {
+	ID        string `json:"id"`
+	Operation string `json:"operation"`
+	Transport string `json:"transport"`
+	// Operator issued the token the request carried.
+	Operator      string            `json:"operator"`
+	Start         time.Time         `json:"start"`
+	Duration      time.Duration     `json:"duration_ns"`
+	RequestHeader map[string]string `json:"request_header,omitempty"`
+	Request       any               `json:"request,omitempty"`
+	Reply         any               `json:"reply,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Spans         []Span            `json:"spans"`
+	DroppedSpans  int               `json:"dropped_spans,omitempty"`
+
+	mu sync.Mutex
+}
+
+// Summary describes a stored trace in listings.
+type Summary struct {
+	ID        string        `json:"id"`
+	Operation string        `json:"operation"`
+	Operator  string        `json:"operator"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration_ns"`
+	Spans     int           `json:"spans"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Summary returns the summary of t.
+func (t *Trace) Summary() Summary {
+	return Summary{ID: t.ID, Operation: t.Operation, Operator: t.Operator, Start: t.Start,
+		Duration: t.Duration, Spans: len(t.Spans), Error: t.Error}
+}
+
+// snapshot returns a copy of t safe to read while spans are recorded.
+func (t *Trace) snapshot() *Trace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &Trace{ID: t.ID, Operation: t.Operation, Transport: t.Transport, Operator: t.Operator,
+		Start: t.Start, Duration: t.Duration, RequestHeader: t.RequestHeader, Request: t.Request,
+		Reply: t.Reply, Error: t.Error, Spans: append([]Span{}, t.Spans...), DroppedSpans: t.DroppedSpans}
+}
+
+type traceKey struct{}
+
+// Enabled reports whether the request of ctx is traced, so callers can
+// skip building span names otherwise.
+func Enabled(ctx context.Context) bool {
+	_, ok := ctx.Value(traceKey{}).(*Trace)
+	return ok
+}
+
+// Record adds a span started at start and ending now to the trace of ctx.
+// It is a no-op for requests that are not traced.
+func Record(ctx context.Context, kind, name string, start time.Time, rows int64, err error) {
+	t, ok := ctx.Value(traceKey{}).(*Trace)
+	if !ok {
+		return
+	}
+	if len(name) > maxSpanName {
+		name = name[:maxSpanName] + "..."
+	}
+	s := Span{Kind: kind, Name: name, Start: start.Sub(t.Start), Duration: time.Since(start), Rows: rows}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.Spans) >= MaxSpans {
+		t.DroppedSpans++
+		return
+	}
+	t.Spans = append(t.Spans, s)
+}
+
+// Server traces the requests carrying a valid debug token, saving their
+// traces to store. A missing, unknown or expired token leaves the request
+// untraced; it never fails it. List it first so the trace covers the
+// other middlewares.
+func Server(store Store, logger log.Logger) middleware.Middleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/debugtrace"))
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			token := tr.RequestHeader().Get(TokenHeader)
+			if token == "" {
+				return handler(ctx, req)
+			}
+			tok, err := store.Token(ctx, token)
+			if err != nil {
+				l.WithContext(ctx).Warnf("debug token of %s not accepted: %v", tr.Operation(), err)
+				return handler(ctx, req)
+			}
+
+			t := &Trace{
+				ID:            newID(),
+				Operation:     tr.Operation(),
+				Transport:     tr.Kind().String(),
+				Operator:      tok.Operator,
+				Start:         time.Now(),
+				RequestHeader: captureHeader(tr.RequestHeader()),
+				Request:       capture(req),
+				Spans:         []Span{},
+			}
+			tr.ReplyHeader().Set(TraceIDHeader, t.ID)
+			reply, err := handler(context.WithValue(ctx, traceKey{}, t), req)
+
+			t.Duration = time.Since(t.Start)
+			t.Reply = capture(reply)
+			if err != nil {
+				t.Error = err.Error()
+			}
+			// Goroutines of the request may still record spans.
+			snapshot := t.snapshot()
+			saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			if serr := store.Save(saveCtx, snapshot); serr != nil {
+				l.WithContext(ctx).Errorf("save debug trace %s of %s: %v", t.ID, t.Operation, serr)
+			} else {
+				l.WithContext(ctx).Infof("debug trace %s of %s captured for %s", t.ID, t.Operation, t.Operator)
+			}
+			return reply, err
+		}
+	}
+}
+
+// Client records the calls of a traced request to downstream services.
+// Add it to the client middlewares.
+func Client() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if !Enabled(ctx) {
+				return handler(ctx, req)
+			}
+			name := "unknown"
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				name = tr.Kind().String() + " " + tr.Operation()
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			Record(ctx, KindCall, name, start, 0, err)
+			return reply, err
+		}
+	}
+}
+
+// sensitiveHeaders are masked in captured request headers, in addition to
+// the ones support.Mask masks.
+var sensitiveHeaders = []string{"authorization", "cookie", "x-api-key", strings.ToLower(TokenHeader)}
+
+func captureHeader(h transport.Header) map[string]string {
+	out := make(map[string]string, len(h.Keys()))
+	doc := make(map[string]any, len(h.Keys()))
+	for _, k := range h.Keys() {
+		doc[k] = h.Get(k)
+	}
+	for k, v := range support.Mask(doc).(map[string]any) {
+		out[k], _ = v.(string)
+		for _, s := range sensitiveHeaders {
+			if strings.EqualFold(k, s) {
+				out[k] = support.Masked
+			}
+		}
+	}
+	return out
+}
+
+// capture returns v as a JSON document with secrets masked, or a note when
+// it is larger than MaxBodyBytes or cannot be encoded.
+func capture(v any) any {
+	if v == nil {
+		return nil
+	}
+	var b []byte
+	var err error
+	if m, ok := v.(proto.Message); ok {
+		b, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return map[string]any{"unencodable": err.Error()}
+	}
+	if len(b) > MaxBodyBytes {
+		return map[string]any{"truncated_bytes": len(b)}
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return map[string]any{"unencodable": err.Error()}
+	}
+	return support.Mask(doc)
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,133 @@
+package debugtrace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+func (hc headerCarrier) Keys() []string {
+	out := make([]string, 0, len(hc))
+	for k := range hc {
+		out = append(out, k)
+	}
+	return out
+}
+
+type testTransport struct {
+	transport.Transporter
+	req, reply headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Operation() string               { return "/user.v1.User/Get" }
+func (t *testTransport) RequestHeader() transport.Header { return t.req }
+func (t *testTransport) ReplyHeader() transport.Header   { return t.reply }
+
+func serve(t *testing.T, store Store, token string, h func(ctx context.Context) (any, error)) (*testTransport, error) {
+	t.Helper()
+	tr := &testTransport{req: headerCarrier{}, reply: headerCarrier{}}
+	tr.req.Set("Authorization", "Bearer secret")
+	tr.req.Set("X-Request-Id", "r1")
+	if token != "" {
+		tr.req.Set(TokenHeader, token)
+	}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	req, err := structpb.NewStruct(map[string]any{"id": "42", "password": "hunter2"})
+	require.NoError(t, err)
+	_, err = Server(store, log.DefaultLogger)(func(ctx context.Context, _ any) (any, error) {
+		return h(ctx)
+	})(ctx, req)
+	return tr, err
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	token, tok, err := Issue(ctx, store, "oncall", 0)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(DefaultTokenTTL), tok.ExpiresAt, time.Second)
+
+	errNotFound := errors.New("user not found")
+	tr, err := serve(t, store, token, func(ctx context.Context) (any, error) {
+		start := time.Now()
+		Record(ctx, KindDB, "SELECT * FROM users WHERE id = 42", start, 1, nil)
+		call := Client()(func(context.Context, any) (any, error) { return nil, errNotFound })
+		_, _ = call(transport.NewClientContext(ctx, &testTransport{}), nil)
+		return nil, errNotFound
+	})
+	assert.ErrorIs(t, err, errNotFound)
+	id := tr.reply.Get(TraceIDHeader)
+	require.NotEmpty(t, id)
+
+	trace, err := store.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "/user.v1.User/Get", trace.Operation)
+	assert.Equal(t, "oncall", trace.Operator)
+	assert.Equal(t, "user not found", trace.Error)
+	assert.Equal(t, map[string]any{"id": "42", "password": "REDACTED"}, trace.Request)
+	assert.Equal(t, "REDACTED", trace.RequestHeader["Authorization"])
+	assert.Equal(t, "REDACTED", trace.RequestHeader[TokenHeader])
+	assert.Equal(t, "r1", trace.RequestHeader["X-Request-Id"])
+	require.Len(t, trace.Spans, 2)
+	assert.Equal(t, Span{Kind: KindDB, Name: "SELECT * FROM users WHERE id = 42", Start: trace.Spans[0].Start,
+		Duration: trace.Spans[0].Duration, Rows: 1}, trace.Spans[0])
+	assert.Equal(t, KindCall, trace.Spans[1].Kind)
+	assert.Equal(t, "http /user.v1.User/Get", trace.Spans[1].Name)
+	assert.Equal(t, "user not found", trace.Spans[1].Error)
+
+	summaries, err := store.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 2, summaries[0].Spans)
+}
+
+func TestServer_Untraced(t *testing.T) {
+	store := NewMemoryStore()
+	for _, token := range []string{"", "forged"} {
+		tr, err := serve(t, store, token, func(ctx context.Context) (any, error) {
+			assert.False(t, Enabled(ctx))
+			return nil, nil
+		})
+		require.NoError(t, err, "an unknown token never fails the request")
+		assert.Empty(t, tr.reply.Get(TraceIDHeader))
+	}
+	summaries, err := store.List(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+
+	_, _, err = Issue(context.Background(), store, "oncall", 48*time.Hour)
+	assert.ErrorContains(t, err, "at most 24h0m0s")
+}
+
+func TestRecord_Bounded(t *testing.T) {
+	store := NewMemoryStore()
+	token, _, err := Issue(context.Background(), store, "oncall", time.Minute)
+	require.NoError(t, err)
+	tr, err := serve(t, store, token, func(ctx context.Context) (any, error) {
+		for range MaxSpans + 5 {
+			Record(ctx, KindRedis, "GET user:42", time.Now(), 0, nil)
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	trace, err := store.Get(context.Background(), tr.reply.Get(TraceIDHeader))
+	require.NoError(t, err)
+	assert.Len(t, trace.Spans, MaxSpans)
+	assert.Equal(t, 5, trace.DroppedSpans)
+}
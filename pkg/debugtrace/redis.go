@@ -0,0 +1,69 @@
+package debugtrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ redis.Hook = RedisHook{}
+
+// RedisHook records the Redis commands and pipelines of traced requests.
+// Add it with (*redis.Client).AddHook.
+type RedisHook struct{}
+
+// DialHook implements redis.Hook.
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook.
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !Enabled(ctx) {
+			return next(ctx, cmd)
+		}
+		start := time.Now()
+		err := next(ctx, cmd)
+		Record(ctx, KindRedis, commandString(cmd), start, 0, redisError(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook.
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !Enabled(ctx) {
+			return next(ctx, cmds)
+		}
+		start := time.Now()
+		err := next(ctx, cmds)
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = commandString(cmd)
+		}
+		Record(ctx, KindRedis, "pipeline: "+strings.Join(names, "; "), start, 0, redisError(err))
+		return err
+	}
+}
+
+// commandString returns the command with its arguments.
+func commandString(cmd redis.Cmder) string {
+	args := make([]string, len(cmd.Args()))
+	for i, a := range cmd.Args() {
+		args[i] = fmt.Sprint(a)
+	}
+	return strings.Join(args, " ")
+}
+
+// redisError drops redis.Nil, which is a miss, not a failure.
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
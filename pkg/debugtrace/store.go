@@ -0,0 +1,255 @@
+package debugtrace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultTokenTTL is how long an issued token is valid when no TTL is
+	// given; MaxTokenTTL bounds it.
+	DefaultTokenTTL = 15 * time.Minute
+	MaxTokenTTL     = 24 * time.Hour
+	// TraceRetention is how long traces are kept.
+	TraceRetention = 24 * time.Hour
+	// maxTraces bounds the stored traces; the oldest are dropped first.
+	maxTraces = 1000
+)
+
+var (
+	// ErrTokenNotFound is returned for an unknown or expired token.
+	ErrTokenNotFound = errors.New("debug token not found or expired")
+	// ErrTraceNotFound is returned for an unknown or expired trace.
+	ErrTraceNotFound = errors.New("debug trace not found")
+)
+
+// Token is an issued debug token.
+type Token struct {
+	Operator  string    `json:"operator"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store keeps the issued tokens and the captured traces. It must be shared
+// by the instances of the service, as the admin server of one instance
+// issues tokens and serves traces captured by any.
+type Store interface {
+	// SaveToken saves t for the token value until it expires.
+	SaveToken(ctx context.Context, value string, t Token) error
+	// Token returns the token of value, or ErrTokenNotFound.
+	Token(ctx context.Context, value string) (Token, error)
+	// Save saves a captured trace.
+	Save(ctx context.Context, t *Trace) error
+	// Get returns the trace of id, or ErrTraceNotFound.
+	Get(ctx context.Context, id string) (*Trace, error)
+	// List returns up to limit traces, newest first.
+	List(ctx context.Context, limit int) ([]Summary, error)
+}
+
+// Issue creates a token valid for ttl (DefaultTokenTTL when zero, at most
+// MaxTokenTTL) on behalf of operator and returns its value.
+func Issue(ctx context.Context, store Store, operator string, ttl time.Duration) (string, Token, error) {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	if ttl > MaxTokenTTL {
+		return "", Token{}, fmt.Errorf("debug token ttl must be at most %s", MaxTokenTTL)
+	}
+	value := newID() + newID()
+	t := Token{Operator: operator, ExpiresAt: time.Now().Add(ttl).Truncate(time.Second)}
+	if err := store.SaveToken(ctx, value, t); err != nil {
+		return "", Token{}, fmt.Errorf("save debug token: %w", err)
+	}
+	return value, t, nil
+}
+
+// tokenKey hashes a token value, so stored keys do not reveal tokens.
+func tokenKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisStore keeps tokens and traces in Redis with their TTL, and an index
+// of the traces in a sorted set.
+type RedisStore struct {
+	client func() redis.Cmdable
+	prefix string
+}
+
+var _ Store = (*RedisStore)(nil)
+
+// NewRedisStore creates a store under "prefix:". client is called per
+// operation so reconnects are picked up.
+func NewRedisStore(client func() redis.Cmdable, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix + ":"}
+}
+
+// SaveToken implements Store.
+func (s *RedisStore) SaveToken(ctx context.Context, value string, t Token) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.client().Set(ctx, s.prefix+"token:"+tokenKey(value), b, time.Until(t.ExpiresAt)).Err()
+}
+
+// Token implements Store.
+func (s *RedisStore) Token(ctx context.Context, value string) (Token, error) {
+	b, err := s.client().Get(ctx, s.prefix+"token:"+tokenKey(value)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Token{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	var t Token
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Token{}, err
+	}
+	return t, nil
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(ctx context.Context, t *Trace) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	index := s.prefix + "traces"
+	p := s.client().TxPipeline()
+	p.Set(ctx, s.prefix+"trace:"+t.ID, b, TraceRetention)
+	p.ZAdd(ctx, index, redis.Z{Score: float64(t.Start.UnixNano()), Member: t.ID})
+	p.ZRemRangeByScore(ctx, index, "-inf", fmt.Sprint(time.Now().Add(-TraceRetention).UnixNano()))
+	p.ZRemRangeByRank(ctx, index, 0, -maxTraces-1)
+	_, err = p.Exec(ctx)
+	return err
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, id string) (*Trace, error) {
+	b, err := s.client().Get(ctx, s.prefix+"trace:"+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrTraceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &Trace{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// List implements Store.
+func (s *RedisStore) List(ctx context.Context, limit int) ([]Summary, error) {
+	ids, err := s.client().ZRevRange(ctx, s.prefix+"traces", 0, int64(limit)-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.prefix + "trace:" + id
+	}
+	values, err := s.client().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Summary, 0, len(values))
+	for _, v := range values {
+		b, ok := v.(string)
+		if !ok {
+			continue // expired
+		}
+		var t Trace
+		if err := json.Unmarshal([]byte(b), &t); err != nil {
+			return nil, err
+		}
+		result = append(result, t.Summary())
+	}
+	return result, nil
+}
+
+// MemoryStore is an in-process Store for tests and single instances.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+	traces map[string]*Trace
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-process store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: make(map[string]Token), traces: make(map[string]*Trace)}
+}
+
+// SaveToken implements Store.
+func (s *MemoryStore) SaveToken(_ context.Context, value string, t Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenKey(value)] = t
+	return nil
+}
+
+// Token implements Store.
+func (s *MemoryStore) Token(_ context.Context, value string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[tokenKey(value)]
+	if !ok || !time.Now().Before(t.ExpiresAt) {
+		return Token{}, ErrTokenNotFound
+	}
+	return t, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, t *Trace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traces[t.ID] = t
+	if len(s.traces) > maxTraces {
+		oldest := t
+		for _, o := range s.traces {
+			if o.Start.Before(oldest.Start) {
+				oldest = o
+			}
+		}
+		delete(s.traces, oldest.ID)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Trace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.traces[id]
+	if !ok {
+		return nil, ErrTraceNotFound
+	}
+	return t, nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, limit int) ([]Summary, error) {
+	s.mu.Lock()
+	result := make([]Summary, 0, len(s.traces))
+	for _, t := range s.traces {
+		result = append(result, t.Summary())
+	}
+	s.mu.Unlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.After(result[j].Start) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
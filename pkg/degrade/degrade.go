@@ -0,0 +1,193 @@
+// Package degrade keeps the service answering while a dependency is down,
+// with a policy per dependency configured in one place: serve without the
+// Redis cache, buffer events to a local WAL while the broker is down, or
+// serve stale cached responses of a downstream service.
+//
+// A Controller follows the DependencyDown and DependencyUp events of the
+// health checks on the lifecycle bus. Components ask it whether their policy
+// applies and Mark the requests they degrade; Server reports them to the
+// caller in the X-Degraded header.
+package degrade
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// Header lists the dependencies a response was degraded by, e.g.
+// "redis,user-service".
+const Header = "X-Degraded"
+
+// DefaultMaxStale is how long past their TTL cached responses are served
+// under the Stale policy when the policy does not say.
+const DefaultMaxStale = 10 * time.Minute
+
+// Mode is how requests needing a dependency are served while it is down.
+type Mode string
+
+const (
+	// Fail returns the errors of the dependency, the default.
+	Fail Mode = "fail"
+	// Bypass serves without the dependency, e.g. without the cache.
+	Bypass Mode = "bypass"
+	// Buffer keeps writes in a local WAL and replays them on recovery.
+	Buffer Mode = "buffer"
+	// Stale serves the last cached responses of a downstream service.
+	Stale Mode = "stale"
+)
+
+// ParseMode parses a policy mode, "" being Fail.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case "":
+		return Fail, nil
+	case Fail, Bypass, Buffer, Stale:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown degradation mode %q: want fail, bypass, buffer or stale", s)
+}
+
+// Policy is the degradation policy of a dependency.
+type Policy struct {
+	Mode Mode
+	// MaxStale is how long past their TTL cached responses are served under
+	// Stale, DefaultMaxStale when zero.
+	MaxStale time.Duration
+}
+
+// Controller tracks which dependencies with a policy are down.
+type Controller struct {
+	bus      *lifecycle.Bus
+	policies map[string]Policy
+
+	mu   sync.RWMutex
+	down map[string]bool
+}
+
+// New creates a controller of policies by dependency name, the names of the
+// DependencyDown events, e.g. "redis" or a downstream service. Changes of
+// the applying policies are emitted to bus as DegradationChanged.
+func New(bus *lifecycle.Bus, policies map[string]Policy) *Controller {
+	return &Controller{bus: bus, policies: policies, down: make(map[string]bool)}
+}
+
+// Policy returns the policy of dep, Fail when it has none. A nil
+// controller has no policies.
+func (c *Controller) Policy(dep string) Policy {
+	if c == nil {
+		return Policy{Mode: Fail}
+	}
+	p, ok := c.policies[dep]
+	if !ok || p.Mode == "" {
+		p.Mode = Fail
+	}
+	if p.MaxStale <= 0 {
+		p.MaxStale = DefaultMaxStale
+	}
+	return p
+}
+
+// Active reports whether dep is down and its policy is not Fail.
+func (c *Controller) Active(dep string) bool {
+	if c == nil || c.Policy(dep).Mode == Fail {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.down[dep]
+}
+
+// Subscriber follows the dependency events, to subscribe to the bus of the
+// health checks.
+func (c *Controller) Subscriber() lifecycle.Subscriber {
+	return func(ctx context.Context, e lifecycle.Event) {
+		switch ev := e.(type) {
+		case lifecycle.DependencyDown:
+			c.set(ctx, ev.Name, true)
+		case lifecycle.DependencyUp:
+			c.set(ctx, ev.Name, false)
+		}
+	}
+}
+
+func (c *Controller) set(ctx context.Context, dep string, down bool) {
+	mode := c.Policy(dep).Mode
+	if mode == Fail {
+		return
+	}
+	c.mu.Lock()
+	changed := c.down[dep] != down
+	if down {
+		c.down[dep] = true
+	} else {
+		delete(c.down, dep)
+	}
+	c.mu.Unlock()
+	if changed && c.bus != nil {
+		c.bus.Emit(ctx, lifecycle.DegradationChanged{Dependency: dep, Mode: string(mode), Active: down})
+	}
+}
+
+type marksKey struct{}
+
+// marks are the dependencies a request was degraded by.
+type marks struct {
+	mu   sync.Mutex
+	deps []string
+}
+
+// NewContext returns a context collecting the Marks of a request.
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, marksKey{}, &marks{})
+}
+
+// Mark records that the request of ctx was served degraded by dep. It is a
+// no-op outside a context from NewContext.
+func Mark(ctx context.Context, dep string) {
+	m, ok := ctx.Value(marksKey{}).(*marks)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.deps, dep) {
+		m.deps = append(m.deps, dep)
+	}
+}
+
+// FromContext returns the dependencies the request of ctx was degraded by.
+func FromContext(ctx context.Context) []string {
+	m, ok := ctx.Value(marksKey{}).(*marks)
+	if !ok {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.deps)
+}
+
+// Server collects the Marks of each request and lists them in the Header of
+// the reply, so callers can tell a degraded answer from a complete one.
+func Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			ctx = NewContext(ctx)
+			reply, err := handler(ctx, req)
+			if deps := FromContext(ctx); len(deps) > 0 {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					tr.ReplyHeader().Set(Header, strings.Join(deps, ","))
+				}
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package degrade
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+type headerCarrier http.Header
+
+func (h headerCarrier) Get(key string) string      { return http.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h headerCarrier) Keys() []string             { return nil }
+func (h headerCarrier) Values(key string) []string { return http.Header(h).Values(key) }
+
+type testTransport struct{ reply http.Header }
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return "" }
+func (t *testTransport) RequestHeader() transport.Header { return headerCarrier(http.Header{}) }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier(t.reply) }
+
+func TestParseMode(t *testing.T) {
+	m, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, Fail, m)
+	m, err = ParseMode("stale")
+	require.NoError(t, err)
+	assert.Equal(t, Stale, m)
+	_, err = ParseMode("retry")
+	assert.Error(t, err)
+}
+
+func TestController(t *testing.T) {
+	bus := lifecycle.NewBus()
+	c := New(bus, map[string]Policy{
+		"redis":        {Mode: Bypass},
+		"user-service": {Mode: Stale, MaxStale: time.Minute},
+	})
+	var changes []lifecycle.DegradationChanged
+	bus.Subscribe(c.Subscriber())
+	bus.Subscribe(func(_ context.Context, e lifecycle.Event) {
+		if ev, ok := e.(lifecycle.DegradationChanged); ok {
+			changes = append(changes, ev)
+		}
+	})
+	ctx := context.Background()
+
+	assert.Equal(t, Policy{Mode: Fail, MaxStale: DefaultMaxStale}, c.Policy("mysql"))
+	assert.Equal(t, time.Minute, c.Policy("user-service").MaxStale)
+	assert.False(t, c.Active("redis"))
+
+	bus.Emit(ctx, lifecycle.DependencyDown{Name: "redis", Err: errors.New("refused")})
+	bus.Emit(ctx, lifecycle.DependencyDown{Name: "mysql", Err: errors.New("refused")})
+	assert.True(t, c.Active("redis"))
+	assert.False(t, c.Active("mysql"), "no policy")
+	assert.False(t, c.Active("user-service"))
+
+	bus.Emit(ctx, lifecycle.DependencyUp{Name: "redis"})
+	assert.False(t, c.Active("redis"))
+	assert.Equal(t, []lifecycle.DegradationChanged{
+		{Dependency: "redis", Mode: "bypass", Active: true},
+		{Dependency: "redis", Mode: "bypass"},
+	}, changes)
+
+	var nilController *Controller
+	assert.False(t, nilController.Active("redis"))
+	assert.Equal(t, Fail, nilController.Policy("redis").Mode)
+}
+
+func TestServer(t *testing.T) {
+	tr := &testTransport{reply: http.Header{}}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	handler := Server()(func(ctx context.Context, _ any) (any, error) {
+		Mark(ctx, "redis")
+		Mark(ctx, "user-service")
+		Mark(ctx, "redis")
+		return "ok", nil
+	})
+	reply, err := handler(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+	assert.Equal(t, "redis,user-service", tr.reply.Get(Header))
+
+	tr.reply = http.Header{}
+	_, err = Server()(func(context.Context, any) (any, error) { return nil, nil })(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tr.reply.Get(Header), "complete responses are not flagged")
+
+	Mark(context.Background(), "redis")
+	assert.Nil(t, FromContext(context.Background()))
+}
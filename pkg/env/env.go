@@ -2,6 +2,9 @@ package env
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Get returns the value of the environment variable.
@@ -18,3 +21,95 @@ func GetOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// RunMode returns the RUN_MODE environment variable (dev, test, prod ...),
+// defaulting to prod.
+func RunMode() string {
+	return GetOrDefault("RUN_MODE", "prod")
+}
+
+// IsDev reports whether the service runs in local development (RUN_MODE=dev).
+func IsDev() bool {
+	return RunMode() == "dev"
+}
+
+// Deployment describes where the instance runs. Pod, namespace and node
+// come from the Kubernetes Downward API:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	  - name: POD_NAMESPACE
+//	    valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	  - name: NODE_NAME
+//	    valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+type Deployment struct {
+	Env       string
+	Region    string
+	Pod       string
+	Namespace string
+	Node      string
+	// Kubernetes reports whether the instance runs in a pod.
+	Kubernetes bool
+}
+
+// serviceAccountNamespace holds the namespace of the pod, mounted with its
+// service account token.
+var serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// CurrentDeployment reads RUN_MODE, REGION, POD_NAME, POD_NAMESPACE and
+// NODE_NAME. Pod falls back to the hostname, which Kubernetes sets to the
+// pod name, and namespace to the one of the service account.
+func CurrentDeployment() Deployment {
+	d := Deployment{
+		Env:        RunMode(),
+		Region:     Get("REGION"),
+		Pod:        Get("POD_NAME"),
+		Namespace:  Get("POD_NAMESPACE"),
+		Node:       Get("NODE_NAME"),
+		Kubernetes: Get("KUBERNETES_SERVICE_HOST") != "",
+	}
+	if d.Pod == "" {
+		d.Pod, _ = os.Hostname()
+	}
+	if d.Namespace == "" && d.Kubernetes {
+		if b, err := os.ReadFile(serviceAccountNamespace); err == nil {
+			d.Namespace = strings.TrimSpace(string(b))
+		}
+	}
+	return d
+}
+
+// InstanceID returns the ID the instance registers with: the pod name on
+// Kubernetes, unique even in pods sharing the node's network namespace
+// (hostNetwork), whose hostname is the node's; the hostname elsewhere.
+func (d Deployment) InstanceID() string {
+	if d.Kubernetes && Get("POD_NAME") != "" {
+		return d.Pod
+	}
+	if h, err := os.Hostname(); err == nil {
+		return h
+	}
+	return "unknown"
+}
+
+// defaultGracePeriod is the terminationGracePeriodSeconds of a pod that
+// does not set it.
+const defaultGracePeriod = 30 * time.Second
+
+// TerminationGracePeriod returns the time the instance has to stop after
+// SIGTERM: TERMINATION_GRACE_PERIOD_SECONDS, set to the
+// terminationGracePeriodSeconds of the pod, as the Downward API does not
+// expose it; the Kubernetes default on Kubernetes, zero (unbounded)
+// elsewhere.
+func (d Deployment) TerminationGracePeriod() time.Duration {
+	if v := Get("TERMINATION_GRACE_PERIOD_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	if d.Kubernetes {
+		return defaultGracePeriod
+	}
+	return 0
+}
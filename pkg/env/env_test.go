@@ -2,9 +2,12 @@ package env
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const testKey = "TEST_ENV_VAR"
@@ -34,3 +37,66 @@ func TestGetOrDefault(t *testing.T) {
 
 	os.Unsetenv(testKey)
 }
+
+func TestIsDev(t *testing.T) {
+	t.Setenv("RUN_MODE", "")
+	assert.Equal(t, "prod", RunMode())
+	assert.False(t, IsDev())
+
+	t.Setenv("RUN_MODE", "dev")
+	assert.True(t, IsDev())
+}
+
+func TestCurrentDeployment(t *testing.T) {
+	t.Setenv("RUN_MODE", "staging")
+	t.Setenv("REGION", "cn-hangzhou")
+	t.Setenv("POD_NAME", "app-7d9f-x2")
+	t.Setenv("NODE_NAME", "node-3")
+	t.Setenv("POD_NAMESPACE", "orders")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+
+	want := Deployment{Env: "staging", Region: "cn-hangzhou", Pod: "app-7d9f-x2", Namespace: "orders",
+		Node: "node-3", Kubernetes: true}
+	if got := CurrentDeployment(); got != want {
+		t.Errorf("CurrentDeployment() = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("POD_NAME", "")
+	if got := CurrentDeployment(); got.Pod == "" {
+		t.Error("Pod should fall back to the hostname")
+	}
+}
+
+func TestCurrentDeployment_ServiceAccountNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "namespace")
+	require.NoError(t, os.WriteFile(path, []byte("payments\n"), 0o600))
+	prev := serviceAccountNamespace
+	serviceAccountNamespace = path
+	t.Cleanup(func() { serviceAccountNamespace = prev })
+	t.Setenv("POD_NAMESPACE", "")
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	assert.Empty(t, CurrentDeployment().Namespace)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	assert.Equal(t, "payments", CurrentDeployment().Namespace)
+}
+
+func TestDeployment_InstanceID(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	t.Setenv("POD_NAME", "app-7d9f-x2")
+	assert.Equal(t, host, Deployment{Pod: "app-7d9f-x2"}.InstanceID())
+	assert.Equal(t, "app-7d9f-x2", Deployment{Pod: "app-7d9f-x2", Kubernetes: true}.InstanceID())
+
+	t.Setenv("POD_NAME", "")
+	assert.Equal(t, host, Deployment{Pod: host, Kubernetes: true}.InstanceID())
+}
+
+func TestDeployment_TerminationGracePeriod(t *testing.T) {
+	t.Setenv("TERMINATION_GRACE_PERIOD_SECONDS", "")
+	assert.Zero(t, Deployment{}.TerminationGracePeriod())
+	assert.Equal(t, 30*time.Second, Deployment{Kubernetes: true}.TerminationGracePeriod())
+
+	t.Setenv("TERMINATION_GRACE_PERIOD_SECONDS", "90")
+	assert.Equal(t, 90*time.Second, Deployment{Kubernetes: true}.TerminationGracePeriod())
+}
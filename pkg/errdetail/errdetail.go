@@ -0,0 +1,173 @@
+package errdetail
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Error is a kratos error carrying additional google.rpc error details.
+// It unwraps to the underlying *errors.Error, so errors.FromError, errors.Code
+// and errors.Reason keep working.
+type Error struct {
+	err     *errors.Error
+	details []proto.Message
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.err.Error() }
+
+// Unwrap returns the underlying kratos error.
+func (e *Error) Unwrap() error { return e.err }
+
+// Details returns the attached error details.
+func (e *Error) Details() []proto.Message { return e.details }
+
+// GRPCStatus returns the kratos status with the attached details appended
+// after the ErrorInfo.
+func (e *Error) GRPCStatus() *status.Status {
+	s := e.err.GRPCStatus()
+	if len(e.details) == 0 {
+		return s
+	}
+	details := make([]protoadapt.MessageV1, 0, len(e.details))
+	for _, d := range e.details {
+		details = append(details, protoadapt.MessageV1Of(d))
+	}
+	withDetails, err := s.WithDetails(details...)
+	if err != nil {
+		return s
+	}
+	return withDetails
+}
+
+// WithDetails attaches details to err.
+// Non-kratos errors are converted with errors.FromError first.
+// Details already attached to err are preserved.
+func WithDetails(err error, details ...proto.Message) error {
+	if err == nil {
+		return nil
+	}
+	var de *Error
+	if stderrors.As(err, &de) {
+		merged := make([]proto.Message, 0, len(de.details)+len(details))
+		merged = append(merged, de.details...)
+		merged = append(merged, details...)
+		return &Error{err: de.err, details: merged}
+	}
+	return &Error{err: errors.FromError(err), details: details}
+}
+
+// Details extracts error details from err.
+// It understands both locally created errors and errors returned by a
+// gRPC client (via the status details).
+func Details(err error) []proto.Message {
+	if err == nil {
+		return nil
+	}
+	var de *Error
+	if stderrors.As(err, &de) {
+		return de.details
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	var result []proto.Message
+	for _, d := range s.Details() {
+		if _, ok := d.(*errdetails.ErrorInfo); ok {
+			continue
+		}
+		if m, ok := d.(proto.Message); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// FieldViolation describes a single invalid request field.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// BadRequest returns a 400 error carrying field violations.
+func BadRequest(reason, message string, violations ...FieldViolation) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	return WithDetails(errors.BadRequest(reason, message), br)
+}
+
+// FieldViolations returns the field violations carried by err.
+func FieldViolations(err error) []FieldViolation {
+	var result []FieldViolation
+	for _, d := range Details(err) {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				result = append(result, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return result
+}
+
+// WithRetryInfo attaches a retry delay hint to err.
+func WithRetryInfo(err error, delay time.Duration) error {
+	return WithDetails(err, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+}
+
+// RetryDelay returns the retry delay hint carried by err.
+func RetryDelay(err error) (time.Duration, bool) {
+	for _, d := range Details(err) {
+		if ri, ok := d.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+			return ri.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// QuotaViolation describes a single exceeded quota.
+type QuotaViolation struct {
+	Subject     string
+	Description string
+}
+
+// QuotaFailure returns a 429 error carrying quota violations and an optional retry delay.
+func QuotaFailure(reason, message string, retryAfter time.Duration, violations ...QuotaViolation) error {
+	qf := &errdetails.QuotaFailure{}
+	for _, v := range violations {
+		qf.Violations = append(qf.Violations, &errdetails.QuotaFailure_Violation{
+			Subject:     v.Subject,
+			Description: v.Description,
+		})
+	}
+	err := WithDetails(errors.New(429, reason, message), qf)
+	if retryAfter > 0 {
+		err = WithRetryInfo(err, retryAfter)
+	}
+	return err
+}
+
+// QuotaViolations returns the quota violations carried by err.
+func QuotaViolations(err error) []QuotaViolation {
+	var result []QuotaViolation
+	for _, d := range Details(err) {
+		if qf, ok := d.(*errdetails.QuotaFailure); ok {
+			for _, v := range qf.GetViolations() {
+				result = append(result, QuotaViolation{Subject: v.GetSubject(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package errdetail
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/status"
+)
+
+func TestBadRequest(t *testing.T) {
+	err := BadRequest("INVALID_NAME", "invalid name", FieldViolation{Field: "name", Description: "required"})
+
+	assert.Equal(t, 400, errors.Code(err))
+	assert.Equal(t, "INVALID_NAME", errors.Reason(err))
+	assert.Equal(t, []FieldViolation{{Field: "name", Description: "required"}}, FieldViolations(err))
+}
+
+func TestQuotaFailure(t *testing.T) {
+	err := QuotaFailure("QUOTA_EXCEEDED", "daily quota exceeded", time.Minute, QuotaViolation{Subject: "tenant:1", Description: "1000/day"})
+
+	assert.Equal(t, 429, errors.Code(err))
+	assert.Equal(t, []QuotaViolation{{Subject: "tenant:1", Description: "1000/day"}}, QuotaViolations(err))
+	delay, ok := RetryDelay(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, delay)
+}
+
+func TestGRPCStatusRoundTrip(t *testing.T) {
+	err := BadRequest("INVALID_NAME", "invalid name", FieldViolation{Field: "name", Description: "required"})
+
+	// Simulate the error crossing the wire as a gRPC status.
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	wire := status.FromProto(s.Proto()).Err()
+
+	assert.Equal(t, "INVALID_NAME", errors.Reason(wire))
+	assert.Equal(t, []FieldViolation{{Field: "name", Description: "required"}}, FieldViolations(wire))
+}
+
+func TestHTTPRoundTrip(t *testing.T) {
+	err := WithRetryInfo(BadRequest("INVALID_NAME", "invalid name", FieldViolation{Field: "name", Description: "required"}), 5*time.Second)
+
+	rec := httptest.NewRecorder()
+	ErrorEncoder(rec, httptest.NewRequest("GET", "/", nil), err)
+	assert.Equal(t, 400, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"@type":"type.googleapis.com/google.rpc.BadRequest"`)
+
+	decoded := ErrorDecoder(context.Background(), rec.Result())
+	assert.Equal(t, 400, errors.Code(decoded))
+	assert.Equal(t, "INVALID_NAME", errors.Reason(decoded))
+	assert.Equal(t, []FieldViolation{{Field: "name", Description: "required"}}, FieldViolations(decoded))
+	delay, ok := RetryDelay(decoded)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+}
+
+func TestHTTPWithoutDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ErrorEncoder(rec, httptest.NewRequest("GET", "/", nil), errors.NotFound("NOT_FOUND", "missing"))
+	assert.Equal(t, 404, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "details")
+}
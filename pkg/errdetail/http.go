@@ -0,0 +1,99 @@
+package errdetail
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// httpError is the JSON body rendered for errors.
+// It extends the kratos errors.Status body with a details array whose entries
+// use the protojson Any representation ({"@type": "...", ...}).
+type httpError struct {
+	Code     int32             `json:"code"`
+	Reason   string            `json:"reason"`
+	Message  string            `json:"message"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Details  []json.RawMessage `json:"details,omitempty"`
+}
+
+// ErrorEncoder renders errors as JSON including their details.
+// Errors without details are rendered by http.DefaultErrorEncoder.
+func ErrorEncoder(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
+	details := Details(err)
+	if len(details) == 0 {
+		http.DefaultErrorEncoder(w, r, err)
+		return
+	}
+
+	se := errors.FromError(err)
+	body := httpError{
+		Code:     se.Code,
+		Reason:   se.Reason,
+		Message:  se.Message,
+		Metadata: se.Metadata,
+	}
+	for _, d := range details {
+		a, err := anypb.New(d)
+		if err != nil {
+			continue
+		}
+		raw, err := protojson.Marshal(a)
+		if err != nil {
+			continue
+		}
+		body.Details = append(body.Details, raw)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(nethttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(se.Code))
+	_, _ = w.Write(data)
+}
+
+// ErrorDecoder is an http client error decoder that restores details rendered
+// by ErrorEncoder. Use it with http.WithErrorDecoder.
+func ErrorDecoder(_ context.Context, res *nethttp.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode <= 299 {
+		return nil
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.New(res.StatusCode, errors.UnknownReason, "").WithCause(err)
+	}
+
+	var body httpError
+	if err := json.Unmarshal(data, &body); err != nil {
+		return errors.New(res.StatusCode, errors.UnknownReason, "").WithCause(err)
+	}
+	se := errors.New(res.StatusCode, body.Reason, body.Message).WithMetadata(body.Metadata)
+	if len(body.Details) == 0 {
+		return se
+	}
+
+	details := make([]proto.Message, 0, len(body.Details))
+	for _, raw := range body.Details {
+		a := &anypb.Any{}
+		if err := protojson.Unmarshal(raw, a); err != nil {
+			continue
+		}
+		m, err := a.UnmarshalNew()
+		if err != nil {
+			continue
+		}
+		details = append(details, m)
+	}
+	return WithDetails(se, details...)
+}
@@ -0,0 +1,37 @@
+package errdetail
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Server is a middleware logging failed requests: 5xx at error level with
+// the cause chain and stack (see LogFields), others at warn level with the
+// message only, so client mistakes don't flood the logs with stacks.
+func Server(logger log.Logger) middleware.Middleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/errdetail"))
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			reply, err := handler(ctx, req)
+			if err == nil {
+				return reply, nil
+			}
+			se := errors.FromError(err)
+			kv := []any{"msg", "request failed", "code", se.Code, "reason", se.Reason}
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				kv = append(kv, "kind", tr.Kind().String(), "operation", tr.Operation())
+			}
+			kv = append(kv, LogFields(err)...)
+			if Severe(err) {
+				l.WithContext(ctx).Errorw(kv...)
+			} else {
+				l.WithContext(ctx).Warnw(kv...)
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package errdetail
+
+import (
+	stderrors "errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// stackError records the call stack where an error entered the service's
+// code. It is transparent: Error, errors.Is/As and errors.FromError see the
+// wrapped error.
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+func (e *stackError) Unwrap() error { return e.err }
+
+// WithStack annotates err with the caller's stack unless err already carries
+// one. Call it where an error from a driver or another library first enters
+// the service, e.g. in repositories.
+func WithStack(err error) error {
+	if err == nil || hasStack(err) {
+		return err
+	}
+	return &stackError{err: err, pcs: callers()}
+}
+
+// Wrapf annotates err with a message, like fmt.Errorf("...: %w", err), and
+// with the caller's stack unless err already carries one.
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	wrapped := fmt.Errorf(format+": %w", append(args, err)...)
+	if hasStack(err) {
+		return wrapped
+	}
+	return &stackError{err: wrapped, pcs: callers()}
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, callers and WithStack/Wrapf.
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+func hasStack(err error) bool {
+	var se *stackError
+	return stderrors.As(err, &se)
+}
+
+// StackTrace returns the deepest stack recorded in err's chain, one
+// "function\n\tfile:line" frame per line, or "" when none was recorded.
+func StackTrace(err error) string {
+	var deepest *stackError
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		if se, ok := e.(*stackError); ok {
+			deepest = se
+		}
+	}
+	if deepest == nil {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(deepest.pcs)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Causes returns the messages of err's cause chain, outermost first, with
+// the text already repeated by the cause trimmed from each wrapper message.
+func Causes(err error) []string {
+	var causes []string
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		if _, ok := e.(*stackError); ok {
+			continue
+		}
+		msg := e.Error()
+		if next := stderrors.Unwrap(e); next != nil {
+			msg = strings.TrimSuffix(strings.TrimSuffix(msg, next.Error()), ": ")
+		}
+		if msg != "" {
+			causes = append(causes, msg)
+		}
+	}
+	return causes
+}
+
+// Severe reports whether err is a server-side failure (5xx), the only kind
+// worth a stack in the logs. Errors without a kratos code count as 500.
+func Severe(err error) bool {
+	return err != nil && errors.Code(err) >= 500
+}
+
+// LogFields returns key-values describing err for structured logs: its
+// message, plus for severe errors the cause chain and the deepest stack.
+func LogFields(err error) []any {
+	if err == nil {
+		return nil
+	}
+	kv := []any{"error", err.Error()}
+	if !Severe(err) {
+		return kv
+	}
+	if causes := Causes(err); len(causes) > 1 {
+		kv = append(kv, "causes", strings.Join(causes, " <- "))
+	}
+	if stack := StackTrace(err); stack != "" {
+		kv = append(kv, "stack", stack)
+	}
+	return kv
+}
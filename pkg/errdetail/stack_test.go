@@ -0,0 +1,68 @@
+package errdetail
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func findUser() error {
+	return WithStack(gorm.ErrRecordNotFound)
+}
+
+func TestWithStack(t *testing.T) {
+	assert.Nil(t, WithStack(nil))
+	assert.Nil(t, Wrapf(nil, "x"))
+
+	err := Wrapf(findUser(), "load user %d", 7)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Equal(t, "load user 7: record not found", err.Error())
+
+	stack := StackTrace(err)
+	require.NotEmpty(t, stack)
+	// The deepest stack starts where the error entered, not at Wrapf.
+	assert.True(t, strings.HasPrefix(stack, "github.com/go-kratos/kratos-layout/pkg/errdetail.findUser\n"), stack)
+	assert.Equal(t, []string{"load user 7", "record not found"}, Causes(err))
+	assert.Empty(t, StackTrace(stderrors.New("plain")))
+}
+
+func TestLogFields(t *testing.T) {
+	notFound := WithStack(errors.NotFound("USER_NOT_FOUND", "user not found"))
+	assert.False(t, Severe(notFound))
+	assert.Equal(t, []any{"error", notFound.Error()}, LogFields(notFound))
+
+	internal := Wrapf(findUser(), "load user")
+	assert.True(t, Severe(internal))
+	kv := LogFields(internal)
+	require.Len(t, kv, 6)
+	assert.Equal(t, "causes", kv[2])
+	assert.Equal(t, "load user <- record not found", kv[3])
+	assert.Equal(t, "stack", kv[4])
+}
+
+type capture struct{ levels []log.Level }
+
+func (c *capture) Log(level log.Level, _ ...any) error {
+	c.levels = append(c.levels, level)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	logs := &capture{}
+	fail := func(err error) error {
+		_, got := Server(logs)(func(context.Context, any) (any, error) { return nil, err })(context.Background(), nil)
+		return got
+	}
+	assert.NoError(t, fail(nil))
+	assert.Error(t, fail(errors.BadRequest("BAD", "bad")))
+	assert.Error(t, fail(fmt.Errorf("db down")))
+	assert.Equal(t, []log.Level{log.LevelWarn, log.LevelError}, logs.levels)
+}
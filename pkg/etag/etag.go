@@ -0,0 +1,150 @@
+// Package etag implements conditional requests on top of orm.Version:
+// responses carry the resource version as ETag, and updates sent with
+// If-Match only apply to that version, failing with 412 otherwise.
+//
+// A handler reading a resource sets its ETag:
+//
+//	etag.SetVersion(ctx, account.Version)
+//
+// and a repository updating it applies the version the client saw, so the
+// optimistic lock turns a concurrent change into orm.ErrStaleObject, which
+// Server maps to 412:
+//
+//	if v, ok := etag.Expected(ctx); ok {
+//		account.Version = v
+//	}
+//	err := db.Save(account).Error
+package etag
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// Error reasons.
+const (
+	// ReasonPreconditionFailed is returned with 412 when If-Match does not
+	// match the current version.
+	ReasonPreconditionFailed = "PRECONDITION_FAILED"
+	// ReasonPreconditionRequired is returned with 428 for updates without
+	// If-Match when Server requires it.
+	ReasonPreconditionRequired = "PRECONDITION_REQUIRED"
+)
+
+// Format returns the strong entity tag of version v.
+func Format(v orm.Version) string {
+	return `"` + strconv.FormatInt(int64(v), 10) + `"`
+}
+
+// Parse returns the version of a strong entity tag produced by Format. Weak
+// tags (W/"...") never match If-Match and are rejected.
+func Parse(tag string) (orm.Version, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return orm.Version(n), true
+}
+
+// SetVersion sets the ETag of the response to v.
+func SetVersion(ctx context.Context, v orm.Version) {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		tr.ReplyHeader().Set("ETag", Format(v))
+	}
+}
+
+// ifMatch returns the entity tags of the If-Match header, and whether it is set.
+func ifMatch(ctx context.Context) ([]string, bool) {
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	header := tr.RequestHeader().Get("If-Match")
+	if header == "" {
+		return nil, false
+	}
+	return strings.Split(header, ","), true
+}
+
+// Expected returns the version required by If-Match. It is false without
+// If-Match, for "*" and for lists of several tags; use Check for those.
+func Expected(ctx context.Context) (orm.Version, bool) {
+	tags, ok := ifMatch(ctx)
+	if !ok || len(tags) != 1 {
+		return 0, false
+	}
+	return Parse(tags[0])
+}
+
+// Check returns a 412 error when If-Match is set and does not match the
+// current version. "*" matches any existing resource.
+func Check(ctx context.Context, current orm.Version) error {
+	tags, ok := ifMatch(ctx)
+	if !ok {
+		return nil
+	}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "*" {
+			return nil
+		}
+		if v, ok := Parse(tag); ok && v == current {
+			return nil
+		}
+	}
+	return PreconditionFailed()
+}
+
+// PreconditionFailed returns the 412 error of a version mismatch.
+func PreconditionFailed() *errors.Error {
+	return errors.New(http.StatusPreconditionFailed, ReasonPreconditionFailed,
+		"the resource was modified, reload it and retry")
+}
+
+// unsafe reports whether the request may modify a resource: every gRPC
+// call, and HTTP methods other than GET, HEAD and OPTIONS.
+func unsafe(tr transport.Transporter) bool {
+	ht, ok := tr.(khttp.Transporter)
+	if !ok {
+		return true
+	}
+	switch ht.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// Server maps orm.ErrStaleObject returned by handlers to 412. With require,
+// unsafe requests without If-Match are rejected with 428 so clients cannot
+// overwrite changes they have not seen; restrict it with selectors.
+func Server(require bool) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok && require && unsafe(tr) {
+				if _, set := ifMatch(ctx); !set {
+					return nil, errors.New(http.StatusPreconditionRequired, ReasonPreconditionRequired,
+						"If-Match with the version of the resource is required")
+				}
+			}
+			reply, err := handler(ctx, req)
+			if stderrors.Is(err, orm.ErrStaleObject) {
+				return nil, PreconditionFailed().WithCause(err)
+			}
+			return reply, err
+		}
+	}
+}
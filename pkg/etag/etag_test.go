@@ -0,0 +1,106 @@
+package etag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+type headerCarrier http.Header
+
+func (h headerCarrier) Get(key string) string      { return http.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h headerCarrier) Values(key string) []string { return http.Header(h).Values(key) }
+func (h headerCarrier) Keys() []string             { return nil }
+
+type testTransport struct {
+	khttp.Transporter
+	request *http.Request
+	reply   http.Header
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Request() *http.Request          { return t.request }
+func (t *testTransport) RequestHeader() transport.Header { return headerCarrier(t.request.Header) }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier(t.reply) }
+
+func newContext(method, ifMatch string) (context.Context, *testTransport) {
+	r, _ := http.NewRequest(method, "/v1/accounts/1", nil)
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	tr := &testTransport{request: r, reply: http.Header{}}
+	return transport.NewServerContext(context.Background(), tr), tr
+}
+
+func TestFormatParse(t *testing.T) {
+	assert.Equal(t, `"3"`, Format(3))
+	v, ok := Parse(` "3"`)
+	assert.True(t, ok)
+	assert.Equal(t, orm.Version(3), v)
+	for _, bad := range []string{``, `3`, `W/"3"`, `"x"`, `"0"`, `"`} {
+		_, ok := Parse(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx, tr := newContext("GET", "")
+	SetVersion(ctx, 2)
+	assert.Equal(t, `"2"`, tr.reply.Get("ETag"))
+	assert.NoError(t, Check(ctx, 2))
+	_, ok := Expected(ctx)
+	assert.False(t, ok)
+
+	ctx, _ = newContext("PUT", `"2"`)
+	v, ok := Expected(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, orm.Version(2), v)
+	assert.NoError(t, Check(ctx, 2))
+	err := Check(ctx, 3)
+	assert.Equal(t, 412, errors.Code(err))
+	assert.Equal(t, ReasonPreconditionFailed, errors.Reason(err))
+
+	ctx, _ = newContext("PUT", `"1", "3"`)
+	_, ok = Expected(ctx)
+	assert.False(t, ok)
+	assert.NoError(t, Check(ctx, 3))
+
+	ctx, _ = newContext("DELETE", "*")
+	assert.NoError(t, Check(ctx, 7))
+}
+
+func TestServer(t *testing.T) {
+	stale := Server(false)(func(context.Context, any) (any, error) {
+		return nil, fmt.Errorf("save account: %w", orm.ErrStaleObject)
+	})
+	ctx, _ := newContext("PUT", `"1"`)
+	_, err := stale(ctx, nil)
+	assert.Equal(t, 412, errors.Code(err))
+	assert.ErrorIs(t, err, orm.ErrStaleObject)
+
+	ok := Server(true)(func(context.Context, any) (any, error) { return "ok", nil })
+	ctx, _ = newContext("PATCH", "")
+	_, err = ok(ctx, nil)
+	assert.Equal(t, 428, errors.Code(err))
+	assert.Equal(t, ReasonPreconditionRequired, errors.Reason(err))
+
+	ctx, _ = newContext("GET", "")
+	reply, err := ok(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+
+	ctx, _ = newContext("PATCH", `"4"`)
+	_, err = ok(ctx, nil)
+	require.NoError(t, err)
+}
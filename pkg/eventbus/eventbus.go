@@ -0,0 +1,34 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Event is a message published on the bus.
+type Event struct {
+	Topic string
+	Key   string // Business key used for lookup, e.g. an order ID
+	Body  []byte
+	// Region the event was published in, set by a bus created with
+	// WithRegion. Empty when unknown.
+	Region string
+}
+
+// Handler processes a delivered event.
+// Returning an error causes the event to be redelivered, so handlers must be
+// idempotent.
+type Handler func(ctx context.Context, e *Event) error
+
+// Bus publishes events and dispatches them to subscribed handlers with
+// at-least-once semantics.
+// It implements transport.Server: subscriptions are registered before the
+// app starts, consumption begins in Start and ends in Stop.
+type Bus interface {
+	transport.Server
+	// Publish sends e to its topic.
+	Publish(ctx context.Context, e *Event) error
+	// Subscribe registers h for topic. Only one handler per topic is allowed.
+	Subscribe(topic string, h Handler) error
+}
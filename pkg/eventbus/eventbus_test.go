@@ -0,0 +1,381 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/region"
+)
+
+func newTestLocal(t *testing.T, opts ...LocalOption) *Local {
+	t.Helper()
+	opts = append([]LocalOption{WithRetryBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)
+	b := NewLocal(log.DefaultLogger, opts...)
+	t.Cleanup(func() { _ = b.Stop(context.Background()) })
+	return b
+}
+
+func TestLocal_PublishBeforeStart(t *testing.T) {
+	b := newTestLocal(t)
+	ctx := context.Background()
+
+	received := make(chan *Event, 1)
+	require.NoError(t, b.Subscribe("orders", func(_ context.Context, e *Event) error {
+		received <- e
+		return nil
+	}))
+	require.NoError(t, b.Publish(ctx, &Event{Topic: "orders", Key: "o-1", Body: []byte("created")}))
+	require.NoError(t, b.Start(ctx))
+
+	select {
+	case e := <-received:
+		assert.Equal(t, "o-1", e.Key)
+		assert.Equal(t, []byte("created"), e.Body)
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+}
+
+func TestLocal_SubscribeAfterStart(t *testing.T) {
+	b := newTestLocal(t)
+	ctx := context.Background()
+	require.NoError(t, b.Start(ctx))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	require.NoError(t, b.Subscribe("orders", func(context.Context, *Event) error {
+		wg.Done()
+		return nil
+	}))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Publish(ctx, &Event{Topic: "orders"}))
+	}
+	wg.Wait()
+}
+
+func TestLocal_Retry(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int32
+		maxAttempts int
+		wantCalls   int32
+	}{
+		{name: "succeeds after retries", failures: 2, maxAttempts: 3, wantCalls: 3},
+		{name: "dropped after max attempts", failures: 10, maxAttempts: 2, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestLocal(t, WithMaxAttempts(tt.maxAttempts))
+			ctx := context.Background()
+
+			var calls atomic.Int32
+			done := make(chan struct{})
+			require.NoError(t, b.Subscribe("orders", func(context.Context, *Event) error {
+				n := calls.Add(1)
+				if n == tt.wantCalls {
+					defer close(done)
+				}
+				if n <= tt.failures {
+					return errors.New("boom")
+				}
+				return nil
+			}))
+			require.NoError(t, b.Start(ctx))
+			require.NoError(t, b.Publish(ctx, &Event{Topic: "orders"}))
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("handler not called enough times")
+			}
+			time.Sleep(20 * time.Millisecond)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestLocal_AdaptiveWorkers(t *testing.T) {
+	b := newTestLocal(t, WithAdaptiveWorkers(concurrency.AdaptiveConfig{Min: 1, Max: 8, Interval: 5 * time.Millisecond}))
+	ctx := context.Background()
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(100)
+	require.NoError(t, b.Subscribe("orders", func(context.Context, *Event) error {
+		defer wg.Done()
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, b.Publish(ctx, &Event{Topic: "orders"}))
+	}
+	require.NoError(t, b.Start(ctx))
+	wg.Wait()
+	assert.Greater(t, peak.Load(), int32(1), "scaled up on the backlog")
+	assert.LessOrEqual(t, peak.Load(), int32(8))
+}
+
+func TestLocal_Validation(t *testing.T) {
+	b := newTestLocal(t)
+	ctx := context.Background()
+
+	assert.Error(t, b.Publish(ctx, &Event{}))
+	assert.Error(t, b.Subscribe("orders", nil))
+
+	h := func(context.Context, *Event) error { return nil }
+	require.NoError(t, b.Subscribe("orders", h))
+	assert.Error(t, b.Subscribe("orders", h))
+}
+
+func TestLocal_PublishBlocksWhenFull(t *testing.T) {
+	b := newTestLocal(t, WithBufferSize(1))
+	require.NoError(t, b.Publish(context.Background(), &Event{Topic: "orders"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Publish(ctx, &Event{Topic: "orders"}), context.DeadlineExceeded)
+}
+
+func newTestNats(t *testing.T) *Nats {
+	t.Helper()
+	b, cleanup, err := NewNats(&NatsConfig{
+		Embedded:   true,
+		StoreDir:   t.TempDir(),
+		Durable:    "test",
+		MaxDeliver: 3,
+		AckWait:    time.Second,
+		Streams:    []NatsStream{{Name: "ORDERS", Subjects: []string{"orders.>"}}},
+	}, log.DefaultLogger)
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	return b
+}
+
+func TestNats_PublishSubscribe(t *testing.T) {
+	b := newTestNats(t)
+	ctx := context.Background()
+
+	received := make(chan *Event, 1)
+	require.NoError(t, b.Subscribe("orders.created", func(_ context.Context, e *Event) error {
+		received <- e
+		return nil
+	}))
+	require.NoError(t, b.Start(ctx))
+	require.NoError(t, b.Publish(ctx, &Event{Topic: "orders.created", Key: "o-1", Body: []byte("created")}))
+
+	select {
+	case e := <-received:
+		assert.Equal(t, "orders.created", e.Topic)
+		assert.Equal(t, "o-1", e.Key)
+		assert.Equal(t, []byte("created"), e.Body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	require.NoError(t, b.Stop(ctx))
+}
+
+func TestWithRegion(t *testing.T) {
+	nb := newTestNats(t)
+	b := WithRegion(nb, region.NewScope("cn-east", true, false))
+	ctx := context.Background()
+
+	received := make(chan *Event, 1)
+	require.NoError(t, b.Subscribe("orders.created", func(_ context.Context, e *Event) error {
+		received <- e
+		return nil
+	}))
+	require.NoError(t, b.Start(ctx))
+	require.NoError(t, b.Publish(ctx, &Event{Topic: "orders.created", Key: "o-1"}))
+
+	select {
+	case e := <-received:
+		assert.Equal(t, "orders.created", e.Topic)
+		assert.Equal(t, "cn-east", e.Region)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	_, ok := nb.consumes["orders.created_cn-east"]
+	assert.True(t, ok, "subscribed to the subject of the region")
+	require.NoError(t, b.Stop(ctx))
+
+	assert.Same(t, nb, WithRegion(nb, region.NewScope("", true, true)))
+}
+
+func TestNats_Redelivery(t *testing.T) {
+	b := newTestNats(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	require.NoError(t, b.Start(ctx))
+	require.NoError(t, b.Subscribe("orders.paid", func(context.Context, *Event) error {
+		if calls.Add(1) < 3 {
+			return errors.New("boom")
+		}
+		close(done)
+		return nil
+	}))
+	require.NoError(t, b.Publish(ctx, &Event{Topic: "orders.paid"}))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event not redelivered, calls=%d", calls.Load())
+	}
+}
+
+func TestNats_Errors(t *testing.T) {
+	b := newTestNats(t)
+	ctx := context.Background()
+
+	assert.Error(t, b.Publish(ctx, &Event{Topic: "unbound.subject"}))
+	require.NoError(t, b.Start(ctx))
+	assert.Error(t, b.Subscribe("unbound.subject", func(context.Context, *Event) error { return nil }))
+}
+
+func TestNakDelay(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, nakDelay(1, time.Second))
+	assert.Equal(t, 400*time.Millisecond, nakDelay(3, time.Second))
+	assert.Equal(t, time.Second, nakDelay(10, time.Second))
+}
+
+type orderV3 struct {
+	ID       string `json:"id"`
+	Amount   int64  `json:"amount_cents"`
+	Currency string `json:"currency"`
+}
+
+func TestSchema(t *testing.T) {
+	s := NewSchema[orderV3](3).
+		// v1 -> v2: amount in cents.
+		Upcast(1, func(data json.RawMessage) (json.RawMessage, error) {
+			var v1 struct {
+				ID     string  `json:"id"`
+				Amount float64 `json:"amount"`
+			}
+			if err := json.Unmarshal(data, &v1); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]any{"id": v1.ID, "amount_cents": int64(v1.Amount * 100)})
+		}).
+		// v2 -> v3: explicit currency.
+		Upcast(2, func(data json.RawMessage) (json.RawMessage, error) {
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				return nil, err
+			}
+			m["currency"] = "CNY"
+			return json.Marshal(m)
+		})
+
+	legacy := &Event{Topic: "orders", Body: []byte(`{"id":"o-1","amount":12.5}`)}
+	v, err := s.Decode(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, orderV3{ID: "o-1", Amount: 1250, Currency: "CNY"}, v)
+
+	e, err := s.Encode("orders", "o-2", orderV3{ID: "o-2", Amount: 1, Currency: "USD"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version":3,"data":{"id":"o-2","amount_cents":1,"currency":"USD"}}`, string(e.Body))
+
+	var got orderV3
+	h := Handle(s, func(_ context.Context, key string, v orderV3) error {
+		assert.Equal(t, "o-2", key)
+		got = v
+		return nil
+	})
+	require.NoError(t, h(context.Background(), e))
+	assert.Equal(t, "USD", got.Currency)
+
+	_, err = s.Decode(&Event{Topic: "orders", Body: []byte(`{"schema_version":4,"data":{}}`)})
+	assert.ErrorIs(t, err, ErrFutureVersion)
+
+	_, err = NewSchema[orderV3](2).Decode(legacy)
+	assert.ErrorContains(t, err, "no upcaster from v1")
+}
+
+func TestSchema_Codec(t *testing.T) {
+	gob, err := codec.Get(codec.Gob)
+	require.NoError(t, err)
+	s := NewSchema[orderV3](3).WithCodec(gob)
+
+	in := orderV3{ID: "o-1", Amount: 1250, Currency: "CNY"}
+	e, err := s.Encode("orders", "o-1", in)
+	require.NoError(t, err)
+	assert.Contains(t, string(e.Body), `"codec":"gob"`)
+	v, err := s.Decode(e)
+	require.NoError(t, err)
+	assert.Equal(t, in, v)
+
+	jsonEvent, err := NewSchema[orderV3](3).Encode("orders", "o-1", in)
+	require.NoError(t, err)
+	v, err = s.Decode(jsonEvent)
+	require.NoError(t, err)
+	assert.Equal(t, in, v, "JSON events in flight still decode after switching codecs")
+
+	_, err = NewSchema[orderV3](4).Decode(e)
+	assert.ErrorContains(t, err, "upcasting gob payloads is not supported")
+}
+
+// flakyBus records the events it publishes, failing while down.
+type flakyBus struct {
+	*Local
+	mu        sync.Mutex
+	down      bool
+	published []string
+}
+
+func (b *flakyBus) Publish(_ context.Context, e *Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return errors.New("broker unavailable")
+	}
+	b.published = append(b.published, e.Key)
+	return nil
+}
+
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	inner := &flakyBus{Local: newTestLocal(t), down: true}
+	bus, err := WithWAL(inner, dir, log.DefaultLogger)
+	require.NoError(t, err)
+	w := bus.(*walBus)
+	ctx := degrade.NewContext(context.Background())
+
+	require.NoError(t, bus.Publish(ctx, &Event{Topic: "orders", Key: "o-1", Body: []byte("created")}))
+	assert.Equal(t, []string{Dependency}, degrade.FromContext(ctx))
+	inner.down = false
+	require.NoError(t, bus.Publish(context.Background(), &Event{Topic: "orders", Key: "o-2"}))
+	assert.Empty(t, inner.published, "published behind the buffered events")
+
+	// A restart keeps the buffered events.
+	reopened, err := WithWAL(inner, dir, log.DefaultLogger)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.(*walBus).pending)
+	require.NoError(t, reopened.(*walBus).file.Close())
+
+	w.replay(context.Background())
+	assert.Equal(t, []string{"o-1", "o-2"}, inner.published)
+	assert.Zero(t, w.pending)
+	require.NoError(t, bus.Publish(context.Background(), &Event{Topic: "orders", Key: "o-3"}))
+	assert.Equal(t, []string{"o-1", "o-2", "o-3"}, inner.published)
+
+	assert.Error(t, bus.Publish(context.Background(), &Event{}), "invalid events are not buffered")
+	require.NoError(t, bus.Stop(context.Background()))
+}
@@ -0,0 +1,288 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+)
+
+var _ Bus = (*Local)(nil)
+
+// LocalOption configures a Local bus.
+type LocalOption func(*localOptions)
+
+type localOptions struct {
+	bufferSize  int
+	workers     int
+	adaptive    *concurrency.AdaptiveConfig
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+// WithBufferSize sets the number of events buffered per topic.
+// Publish blocks while the buffer is full.
+func WithBufferSize(n int) LocalOption {
+	return func(o *localOptions) {
+		if n > 0 {
+			o.bufferSize = n
+		}
+	}
+}
+
+// WithWorkers sets the number of concurrent handlers per topic.
+func WithWorkers(n int) LocalOption {
+	return func(o *localOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithAdaptiveWorkers scales the concurrent handlers of each topic within
+// the bounds of cfg instead of a fixed number, growing while events are
+// buffered. cfg.Backlog is the buffer of the topic.
+func WithAdaptiveWorkers(cfg concurrency.AdaptiveConfig) LocalOption {
+	return func(o *localOptions) { o.adaptive = &cfg }
+}
+
+// WithMaxAttempts sets how many times a failing event is delivered before it is dropped.
+func WithMaxAttempts(n int) LocalOption {
+	return func(o *localOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the initial and maximum delay between redeliveries.
+// The delay doubles after each failed attempt.
+func WithRetryBackoff(initial, max time.Duration) LocalOption {
+	return func(o *localOptions) {
+		o.backoff = initial
+		o.maxBackoff = max
+	}
+}
+
+type localTopic struct {
+	queue   chan *Event
+	handler Handler
+}
+
+// Local is an in-process Bus for local development and tests.
+// Events are buffered per topic until a handler consumes them. A failing
+// handler is retried with exponential backoff, like a broker redelivery;
+// events that still fail after the max attempts are logged and dropped.
+// Buffered events are lost when the process exits.
+type Local struct {
+	opts localOptions
+	log  *log.Helper
+
+	mu      sync.Mutex
+	topics  map[string]*localTopic
+	running bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewLocal creates an in-process bus.
+func NewLocal(logger log.Logger, opts ...LocalOption) *Local {
+	o := localOptions{
+		bufferSize:  1024,
+		workers:     1,
+		maxAttempts: 3,
+		backoff:     100 * time.Millisecond,
+		maxBackoff:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Local{
+		opts:   o,
+		log:    log.NewHelper(log.With(logger, "module", "pkg/eventbus/local")),
+		topics: make(map[string]*localTopic),
+	}
+}
+
+// topic returns the named topic, creating it on first use. b.mu must be held.
+func (b *Local) topic(name string) *localTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &localTopic{queue: make(chan *Event, b.opts.bufferSize)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// Publish implements Bus. Events published before Start are buffered.
+func (b *Local) Publish(ctx context.Context, e *Event) error {
+	if e == nil || e.Topic == "" {
+		return errors.New("publish event: topic is required")
+	}
+	b.mu.Lock()
+	t := b.topic(e.Topic)
+	b.mu.Unlock()
+
+	cp := *e
+	cp.Body = append([]byte(nil), e.Body...)
+	select {
+	case t.queue <- &cp:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("publish event to %s: %w", e.Topic, ctx.Err())
+	}
+}
+
+// Subscribe implements Bus.
+func (b *Local) Subscribe(topic string, h Handler) error {
+	if h == nil {
+		return errors.New("handler cannot be nil")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+	if t.handler != nil {
+		return fmt.Errorf("topic %s already has a handler", topic)
+	}
+	t.handler = h
+	if b.running {
+		b.startTopic(topic, t)
+	}
+	return nil
+}
+
+// Start implements transport.Server.
+func (b *Local) Start(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return nil
+	}
+	b.ctx, b.cancel = context.WithCancel(ctx)
+	b.running = true
+	for name, t := range b.topics {
+		if t.handler != nil {
+			b.startTopic(name, t)
+		}
+	}
+	b.log.Info("local event bus started")
+	return nil
+}
+
+// Stop implements transport.Server. It waits for in-flight handlers until ctx is done.
+func (b *Local) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.running {
+		b.mu.Unlock()
+		return nil
+	}
+	b.running = false
+	b.cancel()
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		b.log.Info("local event bus stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startTopic launches the workers of a topic. b.mu must be held.
+func (b *Local) startTopic(name string, t *localTopic) {
+	ctx := b.ctx
+	if b.opts.adaptive != nil {
+		b.startAdaptive(ctx, name, t)
+		return
+	}
+	for i := 0; i < b.opts.workers; i++ {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-t.queue:
+					b.dispatch(ctx, name, t.handler, e)
+				}
+			}
+		}()
+	}
+}
+
+// startAdaptive launches a loop handing the events of a topic to handlers
+// while the adaptive limit has room. b.mu must be held.
+func (b *Local) startAdaptive(ctx context.Context, name string, t *localTopic) {
+	cfg := *b.opts.adaptive
+	cfg.Backlog = func() int { return len(t.queue) }
+	a := concurrency.NewAdaptive(cfg)
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		a.Run(ctx)
+	}()
+	go func() {
+		defer b.wg.Done()
+		for {
+			release, err := a.Acquire(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				release()
+				return
+			case e := <-t.queue:
+				b.wg.Add(1)
+				go func() {
+					defer b.wg.Done()
+					defer release()
+					b.dispatch(ctx, name, t.handler, e)
+				}()
+			}
+		}
+	}()
+}
+
+func (b *Local) dispatch(ctx context.Context, topic string, h Handler, e *Event) {
+	backoff := b.opts.backoff
+	for attempt := 1; ; attempt++ {
+		err := h(ctx, e)
+		if err == nil {
+			return
+		}
+		if attempt >= b.opts.maxAttempts {
+			b.log.WithContext(ctx).Errorf("drop event topic=%s key=%s after %d attempts: %v", topic, e.Key, attempt, err)
+			return
+		}
+		b.log.WithContext(ctx).Warnf("handle event topic=%s key=%s attempt=%d: %v", topic, e.Key, attempt, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > b.opts.maxBackoff {
+			backoff = b.opts.maxBackoff
+		}
+	}
+}
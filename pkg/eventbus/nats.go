@@ -0,0 +1,338 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+var _ Bus = (*Nats)(nil)
+
+// headerKey and headerRegion carry Event.Key and Event.Region in the NATS
+// message headers.
+const (
+	headerKey    = "Eventbus-Key"
+	headerRegion = "Eventbus-Region"
+)
+
+// NatsStream describes a JetStream stream provisioned at startup.
+type NatsStream struct {
+	Name     string
+	Subjects []string
+	MaxAge   time.Duration
+	Replicas int
+}
+
+// NatsConfig holds NATS JetStream bus configuration.
+type NatsConfig struct {
+	URL        string        // Server URL, ignored when Embedded
+	Embedded   bool          // Run a NATS server inside the process
+	StoreDir   string        // JetStream storage of the embedded server, temp dir when empty
+	Durable    string        // Durable consumer name prefix
+	MaxDeliver int           // Max deliveries of a failing message
+	AckWait    time.Duration // Redelivery timeout of unacknowledged messages
+	Streams    []NatsStream
+}
+
+// NewNatsConfigFromProto creates a NatsConfig from proto configuration.
+func NewNatsConfigFromProto(c *conf.Nats) *NatsConfig {
+	cfg := &NatsConfig{
+		URL:        c.GetUrl(),
+		Embedded:   c.GetEmbedded(),
+		StoreDir:   c.GetStoreDir(),
+		Durable:    c.GetDurable(),
+		MaxDeliver: 16,
+		AckWait:    30 * time.Second,
+	}
+	if cfg.Durable == "" {
+		cfg.Durable = "eventbus"
+	}
+	if c.GetMaxDeliver() > 0 {
+		cfg.MaxDeliver = int(c.GetMaxDeliver())
+	}
+	if c.GetAckWait() != nil {
+		cfg.AckWait = c.GetAckWait().AsDuration()
+	}
+	for _, s := range c.GetStreams() {
+		cfg.Streams = append(cfg.Streams, NatsStream{
+			Name:     s.GetName(),
+			Subjects: s.GetSubjects(),
+			MaxAge:   s.GetMaxAge().AsDuration(),
+			Replicas: int(s.GetReplicas()),
+		})
+	}
+	return cfg
+}
+
+// Nats is a Bus backed by NATS JetStream.
+// Topics are subjects; every subscribed subject must be bound to a stream.
+// Each subscription uses a durable consumer with explicit acks, a handler
+// error naks the message with an increasing delay until MaxDeliver is reached.
+type Nats struct {
+	cfg *NatsConfig
+	nc  *nats.Conn
+	js  jetstream.JetStream
+	log *log.Helper
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	consumes map[string]jetstream.ConsumeContext
+	running  bool
+}
+
+// NewNats connects to NATS (or starts the embedded server) and provisions the
+// configured streams.
+func NewNats(cfg *NatsConfig, logger log.Logger) (*Nats, func(), error) {
+	logHelper := log.NewHelper(log.With(logger, "module", "pkg/eventbus/nats"))
+
+	var (
+		nc  *nats.Conn
+		err error
+	)
+	shutdown := func() {}
+	if cfg.Embedded {
+		var ns *server.Server
+		ns, shutdown, err = startEmbedded(cfg.StoreDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		nc, err = nats.Connect("", nats.InProcessServer(ns))
+		logHelper.Info("embedded nats server started")
+	} else {
+		nc, err = nats.Connect(cfg.URL)
+	}
+	if err != nil {
+		shutdown()
+		return nil, nil, fmt.Errorf("connect nats: %w", err)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		shutdown()
+		return nil, nil, fmt.Errorf("create jetstream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, s := range cfg.Streams {
+		_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     s.Name,
+			Subjects: s.Subjects,
+			MaxAge:   s.MaxAge,
+			Replicas: s.Replicas,
+		})
+		if err != nil {
+			nc.Close()
+			shutdown()
+			return nil, nil, fmt.Errorf("provision stream %s: %w", s.Name, err)
+		}
+	}
+	logHelper.Infof("nats event bus connected, url=%s, streams=%d", nc.ConnectedUrlRedacted(), len(cfg.Streams))
+
+	b := &Nats{
+		cfg:      cfg,
+		nc:       nc,
+		js:       js,
+		log:      logHelper,
+		handlers: make(map[string]Handler),
+		consumes: make(map[string]jetstream.ConsumeContext),
+	}
+	cleanup := func() {
+		logHelper.Info("shutting down nats event bus")
+		b.stopConsumers()
+		if err := nc.Drain(); err != nil {
+			logHelper.Errorf("drain nats connection: %v", err)
+		}
+		shutdown()
+	}
+	return b, cleanup, nil
+}
+
+// startEmbedded starts an in-process NATS server with JetStream enabled.
+// It does not listen on the network.
+func startEmbedded(storeDir string) (*server.Server, func(), error) {
+	removeDir := false
+	if storeDir == "" {
+		dir, err := os.MkdirTemp("", "eventbus-nats-")
+		if err != nil {
+			return nil, nil, fmt.Errorf("create nats store dir: %w", err)
+		}
+		storeDir, removeDir = dir, true
+	}
+	ns, err := server.NewServer(&server.Options{
+		DontListen: true,
+		JetStream:  true,
+		StoreDir:   storeDir,
+		NoLog:      true,
+		NoSigs:     true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("create embedded nats server: %w", err)
+	}
+	shutdown := func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+		if removeDir {
+			_ = os.RemoveAll(storeDir)
+		}
+	}
+	ns.Start()
+	if !ns.ReadyForConnections(10 * time.Second) {
+		shutdown()
+		return nil, nil, errors.New("embedded nats server not ready")
+	}
+	return ns, shutdown, nil
+}
+
+// Publish implements Bus. It waits for the stream acknowledgement.
+func (b *Nats) Publish(ctx context.Context, e *Event) error {
+	if e == nil || e.Topic == "" {
+		return errors.New("publish event: topic is required")
+	}
+	msg := nats.NewMsg(e.Topic)
+	msg.Data = e.Body
+	if e.Key != "" {
+		msg.Header.Set(headerKey, e.Key)
+	}
+	if e.Region != "" {
+		msg.Header.Set(headerRegion, e.Region)
+	}
+	if _, err := b.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("publish event to %s: %w", e.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe implements Bus. Subjects subscribed after Start are consumed immediately.
+func (b *Nats) Subscribe(topic string, h Handler) error {
+	if h == nil {
+		return errors.New("handler cannot be nil")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.handlers[topic]; ok {
+		return fmt.Errorf("topic %s already has a handler", topic)
+	}
+	if b.running {
+		if err := b.consume(context.Background(), topic, h); err != nil {
+			return err
+		}
+	}
+	b.handlers[topic] = h
+	return nil
+}
+
+// Start implements transport.Server.
+func (b *Nats) Start(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return nil
+	}
+	for topic, h := range b.handlers {
+		if err := b.consume(ctx, topic, h); err != nil {
+			return err
+		}
+	}
+	b.running = true
+	return nil
+}
+
+// Stop implements transport.Server.
+func (b *Nats) Stop(context.Context) error {
+	b.stopConsumers()
+	return nil
+}
+
+func (b *Nats) stopConsumers() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, cc := range b.consumes {
+		cc.Stop()
+		delete(b.consumes, topic)
+	}
+	b.running = false
+}
+
+// consume creates the durable consumer of topic and starts delivering to h. b.mu must be held.
+func (b *Nats) consume(ctx context.Context, topic string, h Handler) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stream, err := b.js.StreamNameBySubject(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("find stream for %s: %w", topic, err)
+	}
+	cons, err := b.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durableName(b.cfg.Durable, topic),
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       b.cfg.AckWait,
+		MaxDeliver:    b.cfg.MaxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer for %s: %w", topic, err)
+	}
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		b.dispatch(h, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", topic, err)
+	}
+	b.consumes[topic] = cc
+	b.log.Infof("subscribed to subject %s on stream %s", topic, stream)
+	return nil
+}
+
+func (b *Nats) dispatch(h Handler, msg jetstream.Msg) {
+	e := &Event{Topic: msg.Subject(), Key: msg.Headers().Get(headerKey), Body: msg.Data(), Region: msg.Headers().Get(headerRegion)}
+	err := h(context.Background(), e)
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			b.log.Errorf("ack event topic=%s key=%s: %v", e.Topic, e.Key, err)
+		}
+		return
+	}
+
+	var attempt uint64 = 1
+	if md, mdErr := msg.Metadata(); mdErr == nil {
+		attempt = md.NumDelivered
+	}
+	b.log.Warnf("handle event topic=%s key=%s attempt=%d: %v", e.Topic, e.Key, attempt, err)
+	if err := msg.NakWithDelay(nakDelay(attempt, b.cfg.AckWait)); err != nil {
+		b.log.Errorf("nak event topic=%s key=%s: %v", e.Topic, e.Key, err)
+	}
+}
+
+// nakDelay doubles from 100ms per delivery, capped at max.
+func nakDelay(attempt uint64, max time.Duration) time.Duration {
+	d := 100 * time.Millisecond
+	for i := uint64(1); i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// durableName builds a valid consumer name from prefix and subject.
+func durableName(prefix, subject string) string {
+	r := strings.NewReplacer(".", "_", "*", "any", ">", "all")
+	return prefix + "_" + r.Replace(subject)
+}
@@ -0,0 +1,51 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kratos/kratos-layout/pkg/region"
+)
+
+// regional scopes a Bus to a region.
+type regional struct {
+	Bus
+	scope *region.Scope
+}
+
+// WithRegion tags the events published on bus with the region of scope and
+// publishes and subscribes to the topics of the region: with
+// conf.Region.scope_topics, "orders" is the broker topic "orders_cn-east".
+// Handlers still see the topic they subscribed to. Returns bus unchanged
+// when scope has no region.
+func WithRegion(bus Bus, scope *region.Scope) Bus {
+	if scope.Name() == "" {
+		return bus
+	}
+	return &regional{Bus: bus, scope: scope}
+}
+
+// Publish implements Bus. Events already tagged, e.g. relayed from another
+// region, keep their region.
+func (b *regional) Publish(ctx context.Context, e *Event) error {
+	if e == nil || e.Topic == "" {
+		return errors.New("publish event: topic is required")
+	}
+	cp := *e
+	cp.Topic = b.scope.Topic(e.Topic)
+	if cp.Region == "" {
+		cp.Region = b.scope.Name()
+	}
+	return b.Bus.Publish(ctx, &cp)
+}
+
+// Subscribe implements Bus.
+func (b *regional) Subscribe(topic string, h Handler) error {
+	if h == nil {
+		return errors.New("handler cannot be nil")
+	}
+	return b.Bus.Subscribe(b.scope.Topic(topic), func(ctx context.Context, e *Event) error {
+		e.Topic = topic
+		return h(ctx, e)
+	})
+}
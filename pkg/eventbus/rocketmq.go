@@ -0,0 +1,160 @@
+//go:build !norocketmq
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+	"github.com/go-kratos/kratos-layout/pkg/rocketmq"
+)
+
+var _ Bus = (*RocketMQ)(nil)
+
+// RocketMQ is a Bus backed by a RocketMQ producer and push consumer.
+// A handler error is reported as a consume failure so the broker redelivers
+// the message, or dead-lettered after cfg.MaxDeliveryAttempts. Event.Region
+// is sent as the message tag.
+type RocketMQ struct {
+	cfg      *rocketmq.Config
+	producer *rocketmq.Producer
+	logger   log.Logger
+	log      *log.Helper
+
+	mu              sync.RWMutex
+	handlers        map[string]Handler
+	consumer        *rocketmq.PushConsumer
+	consumerCleanup func()
+}
+
+// NewRocketMQ creates a RocketMQ backed bus. The producer connects immediately;
+// the consumer is created in Start once subscriptions are known.
+func NewRocketMQ(cfg *rocketmq.Config, logger log.Logger) (*RocketMQ, func(), error) {
+	producer, cleanup, err := rocketmq.NewProducer(cfg, nil, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := &RocketMQ{
+		cfg:      cfg,
+		producer: producer,
+		logger:   logger,
+		log:      log.NewHelper(log.With(logger, "module", "pkg/eventbus/rocketmq")),
+		handlers: make(map[string]Handler),
+	}
+	return b, func() {
+		b.stopConsumer()
+		cleanup()
+	}, nil
+}
+
+// Publish implements Bus.
+func (b *RocketMQ) Publish(ctx context.Context, e *Event) error {
+	if e == nil || e.Topic == "" {
+		return errors.New("publish event: topic is required")
+	}
+	msg := &rocketmq.Message{Topic: e.Topic, Body: e.Body, Tag: e.Region}
+	if e.Key != "" {
+		msg.Keys = []string{e.Key}
+	}
+	_, err := b.producer.SendMessage(ctx, msg)
+	return err
+}
+
+// Subscribe implements Bus. Topics subscribed after Start are added to the running consumer.
+func (b *RocketMQ) Subscribe(topic string, h Handler) error {
+	if h == nil {
+		return errors.New("handler cannot be nil")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.handlers[topic]; ok {
+		return fmt.Errorf("topic %s already has a handler", topic)
+	}
+	if b.consumer != nil {
+		if err := b.consumer.Subscribe(topic, rocketmq.SubAll); err != nil {
+			return err
+		}
+	}
+	b.handlers[topic] = h
+	return nil
+}
+
+// Start implements transport.Server.
+func (b *RocketMQ) Start(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.consumer != nil || len(b.handlers) == 0 {
+		return nil
+	}
+	subs := make(map[string]*rocketmq.FilterExpression, len(b.handlers))
+	for topic := range b.handlers {
+		subs[topic] = rocketmq.SubAll
+	}
+	var middlewares []rocketmq.ConsumerMiddleware
+	if b.cfg.MaxDeliveryAttempts > 0 {
+		dlq := rocketmq.RepublishDeadLetter(b.producer, b.cfg.DeadLetterTopic)
+		middlewares = append(middlewares, rocketmq.DeadLetter(b.cfg.MaxDeliveryAttempts, dlq, b.logger))
+	}
+	pcfg := rocketmq.NewPushConsumerConfigFromConfig(b.cfg)
+	if b.cfg.Workers != nil {
+		pcfg.Adaptive = concurrency.NewAdaptive(*b.cfg.Workers)
+	}
+	c, cleanup, err := rocketmq.NewPushConsumer(pcfg, subs, b.consume, b.logger, middlewares...)
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		cleanup()
+		return err
+	}
+	b.consumer, b.consumerCleanup = c, cleanup
+	return nil
+}
+
+// Stop implements transport.Server.
+func (b *RocketMQ) Stop(context.Context) error {
+	b.stopConsumer()
+	return nil
+}
+
+func (b *RocketMQ) stopConsumer() {
+	b.mu.Lock()
+	cleanup := b.consumerCleanup
+	b.consumer, b.consumerCleanup = nil, nil
+	b.mu.Unlock()
+
+	// Graceful stop waits for in-flight consume calls, which take b.mu.
+	if cleanup != nil {
+		cleanup()
+	}
+}
+
+func (b *RocketMQ) consume(mv *rocketmq.MessageView) rocketmq.ConsumerResult {
+	b.mu.RLock()
+	h, ok := b.handlers[mv.GetTopic()]
+	b.mu.RUnlock()
+	if !ok {
+		b.log.Warnf("no handler for topic %s, msgId=%s", mv.GetTopic(), mv.GetMessageId())
+		return rocketmq.ConsumeSuccess
+	}
+
+	e := &Event{Topic: mv.GetTopic(), Body: mv.GetBody()}
+	if keys := mv.GetKeys(); len(keys) > 0 {
+		e.Key = keys[0]
+	}
+	if tag := mv.GetTag(); tag != nil {
+		e.Region = *tag
+	}
+	if err := h(rocketmq.MessageContext(mv), e); err != nil {
+		b.log.Warnf("handle event topic=%s msgId=%s attempt=%d: %v", e.Topic, mv.GetMessageId(), mv.GetDeliveryAttempt(), err)
+		return rocketmq.ConsumeFailure
+	}
+	return rocketmq.ConsumeSuccess
+}
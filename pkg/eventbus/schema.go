@@ -0,0 +1,154 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+// ErrFutureVersion is returned when a payload is newer than the consumer's
+// schema, typically while producers are upgraded before consumers. The event
+// is redelivered and succeeds once the consumer runs the new version.
+var ErrFutureVersion = errors.New("eventbus: payload version is newer than schema")
+
+// envelope wraps versioned payloads. Bodies that are not an envelope were
+// published before the topic adopted a schema and count as version 1.
+// Payloads of another codec than JSON are a base64 string in Data.
+type envelope struct {
+	Version int             `json:"schema_version"`
+	Codec   string          `json:"codec,omitempty"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Upcaster migrates a JSON payload from one version to the next.
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// Schema versions the JSON payload T of a long-lived topic. Producers encode
+// the current version; consumers decode any older version by running the
+// upcasters from the payload's version up to the current one, so handlers
+// only ever see the current struct.
+type Schema[T any] struct {
+	version   int
+	upcasters map[int]Upcaster
+	codec     codec.Codec // nil for JSON
+}
+
+// NewSchema creates a schema whose current version is version (>= 1).
+func NewSchema[T any](version int) *Schema[T] {
+	return &Schema[T]{version: max(version, 1), upcasters: make(map[int]Upcaster)}
+}
+
+// Version returns the current version.
+func (s *Schema[T]) Version() int { return s.version }
+
+// Upcast registers u to migrate payloads from version from to from+1.
+func (s *Schema[T]) Upcast(from int, u Upcaster) *Schema[T] {
+	s.upcasters[from] = u
+	return s
+}
+
+// WithCodec encodes payloads with c instead of JSON, e.g. the codec of
+// codec.UseEventBus. Consumers decode with the codec named in the envelope,
+// so a topic can switch codecs while older events are in flight. Upcasters
+// only run on JSON payloads.
+func (s *Schema[T]) WithCodec(c codec.Codec) *Schema[T] {
+	s.codec = c
+	if c != nil && c.Name() == codec.JSON {
+		s.codec = nil
+	}
+	return s
+}
+
+// Encode returns an event carrying v at the current version.
+func (s *Schema[T]) Encode(topic, key string, v T) (*Event, error) {
+	env := envelope{Version: s.version}
+	var err error
+	if s.codec == nil {
+		env.Data, err = json.Marshal(v)
+	} else {
+		var data []byte
+		if data, err = s.codec.Marshal(v); err == nil {
+			env.Codec = s.codec.Name()
+			env.Data, err = json.Marshal(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", topic, err)
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", topic, err)
+	}
+	return &Event{Topic: topic, Key: key, Body: body}, nil
+}
+
+// Decode migrates the payload of e to the current version and decodes it.
+func (s *Schema[T]) Decode(e *Event) (T, error) {
+	var v T
+	version, name, data := s.unwrap(e.Body)
+	if version > s.version {
+		return v, fmt.Errorf("%w: %s v%d > v%d", ErrFutureVersion, e.Topic, version, s.version)
+	}
+	if name != "" && name != codec.JSON {
+		return s.decodeWith(e.Topic, version, name, data)
+	}
+	for ; version < s.version; version++ {
+		up, ok := s.upcasters[version]
+		if !ok {
+			return v, fmt.Errorf("decode %s payload: no upcaster from v%d", e.Topic, version)
+		}
+		var err error
+		if data, err = up(data); err != nil {
+			return v, fmt.Errorf("upcast %s payload from v%d: %w", e.Topic, version, err)
+		}
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decode %s payload v%d: %w", e.Topic, version, err)
+	}
+	return v, nil
+}
+
+// decodeWith decodes a payload of the named codec, which must be at the
+// current version.
+func (s *Schema[T]) decodeWith(topic string, version int, name string, data json.RawMessage) (T, error) {
+	var v T
+	if version < s.version {
+		return v, fmt.Errorf("decode %s payload v%d: upcasting %s payloads is not supported", topic, version, name)
+	}
+	c, err := codec.Get(name)
+	if err != nil {
+		return v, fmt.Errorf("decode %s payload: %w", topic, err)
+	}
+	var raw []byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return v, fmt.Errorf("decode %s payload v%d: %w", topic, version, err)
+	}
+	if err := c.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("decode %s payload v%d: %w", topic, version, err)
+	}
+	return v, nil
+}
+
+func (s *Schema[T]) unwrap(body []byte) (int, string, json.RawMessage) {
+	var env envelope
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) &&
+		json.Unmarshal(body, &env) == nil && env.Version > 0 && env.Data != nil {
+		return env.Version, env.Codec, env.Data
+	}
+	return 1, "", body
+}
+
+// Handle adapts a typed handler to a Handler decoding payloads with s.
+func Handle[T any](s *Schema[T], h func(ctx context.Context, key string, v T) error) Handler {
+	return func(ctx context.Context, e *Event) error {
+		v, err := s.Decode(e)
+		if err != nil {
+			return err
+		}
+		return h(ctx, e.Key, v)
+	}
+}
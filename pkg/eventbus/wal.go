@@ -0,0 +1,256 @@
+package eventbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/degrade"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// Dependency is the name the buses created with WithWAL report their broker
+// as to the lifecycle bus, the key of its degradation policy.
+const Dependency = "eventbus"
+
+const (
+	walFile           = "eventbus.wal"
+	walReplayInterval = 5 * time.Second
+	walReplayBatch    = 100
+)
+
+// WithWAL buffers the events bus fails to publish in a write-ahead log in
+// dir, so publishers keep going while the broker is down, and replays them
+// in order once it is back. While events are buffered, new ones are
+// appended behind them instead of being published. Publish failures and
+// recoveries are reported as the Dependency to the lifecycle bus, and
+// buffered publishes degrade.Mark their request.
+// An event may be published twice when the process stops during a replay,
+// which handlers tolerate like any redelivery.
+func WithWAL(bus Bus, dir string, logger log.Logger) (Bus, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create wal dir: %w", err)
+	}
+	b := &walBus{
+		Bus:      bus,
+		path:     filepath.Join(dir, walFile),
+		interval: walReplayInterval,
+		tracker:  lifecycle.NewDependencyTracker(lifecycle.Default(), Dependency),
+		log:      log.NewHelper(log.With(logger, "module", "eventbus/wal")),
+	}
+	events, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	if b.file, err = os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644); err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	b.pending = len(events)
+	if b.pending > 0 {
+		b.log.Warnf("%d buffered events left from a previous run, replaying them once started", b.pending)
+	}
+	return b, nil
+}
+
+// walBus buffers the events its Bus fails to publish, see WithWAL.
+type walBus struct {
+	Bus
+	path     string
+	interval time.Duration
+	tracker  *lifecycle.DependencyTracker
+	log      *log.Helper
+
+	mu      sync.Mutex // guards file and pending, held by replays
+	file    *os.File
+	pending int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Publish implements Bus.
+func (b *walBus) Publish(ctx context.Context, e *Event) error {
+	if e == nil || e.Topic == "" {
+		return errors.New("publish event: topic is required")
+	}
+	b.mu.Lock()
+	buffering := b.pending > 0
+	b.mu.Unlock()
+	if !buffering {
+		err := b.Bus.Publish(ctx, e)
+		b.tracker.Observe(ctx, err)
+		if err == nil {
+			return nil
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.append(e); err != nil {
+		return err
+	}
+	degrade.Mark(ctx, Dependency)
+	return nil
+}
+
+// Start implements transport.Server. Buffered events are replayed in the
+// background until Stop.
+func (b *walBus) Start(ctx context.Context) error {
+	b.stop, b.done = make(chan struct{}), make(chan struct{})
+	go b.loop()
+	return b.Bus.Start(ctx)
+}
+
+// Stop implements transport.Server. Events still buffered stay in the log
+// for the next run.
+func (b *walBus) Stop(ctx context.Context) error {
+	if b.stop != nil {
+		close(b.stop)
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+		}
+	}
+	err := b.Bus.Stop(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cerr := b.file.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (b *walBus) loop() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.replay(context.Background())
+		}
+	}
+}
+
+// replay publishes the buffered events in batches, so publishers waiting
+// behind the lock are held up by one batch at most.
+func (b *walBus) replay(ctx context.Context) {
+	for {
+		b.mu.Lock()
+		if b.pending == 0 {
+			b.mu.Unlock()
+			return
+		}
+		n, err := b.replayBatch(ctx)
+		remaining := b.pending
+		b.mu.Unlock()
+		if err != nil {
+			return
+		}
+		b.log.Infof("replayed %d buffered events, %d left", n, remaining)
+		if remaining == 0 {
+			b.tracker.Observe(ctx, nil)
+			return
+		}
+	}
+}
+
+// replayBatch publishes up to walReplayBatch buffered events, keeping the
+// others in the log, and returns how many were published. It is called
+// with mu held.
+func (b *walBus) replayBatch(ctx context.Context) (int, error) {
+	events, err := b.read()
+	if err != nil {
+		b.log.Errorf("replay: %v", err)
+		return 0, err
+	}
+	n := 0
+	for ; n < len(events) && n < walReplayBatch; n++ {
+		if err = b.Bus.Publish(ctx, events[n]); err != nil {
+			b.tracker.Observe(ctx, err)
+			break
+		}
+	}
+	if n > 0 {
+		if rerr := b.rewrite(events[n:]); rerr != nil {
+			b.log.Errorf("replay: %v", rerr)
+			return n, rerr
+		}
+	}
+	return n, err
+}
+
+// append writes e to the end of the log. It is called with mu held.
+func (b *walBus) append(e *Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("buffer event: %w", err)
+	}
+	if _, err := b.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("buffer event: %w", err)
+	}
+	if err := b.file.Sync(); err != nil {
+		return fmt.Errorf("buffer event: %w", err)
+	}
+	b.pending++
+	return nil
+}
+
+// read returns the buffered events. Lines that do not parse, e.g. the last
+// one when the process died writing it, are skipped.
+func (b *walBus) read() ([]*Event, error) {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read wal: %w", err)
+	}
+	var events []*Event
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			b.log.Errorf("skip corrupt wal entry: %v", err)
+			continue
+		}
+		events = append(events, &e)
+	}
+	return events, nil
+}
+
+// rewrite replaces the log with events. It is called with mu held.
+func (b *walBus) rewrite(events []*Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("rewrite wal: %w", err)
+		}
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("rewrite wal: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("rewrite wal: %w", err)
+	}
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("rewrite wal: %w", err)
+	}
+	_ = b.file.Close()
+	b.file, b.pending = f, len(events)
+	return nil
+}
@@ -0,0 +1,243 @@
+// Package fingerprint detects configuration changes between deploys: it
+// hashes the effective configuration at startup, compares it with the
+// snapshot the previous run stored, and logs the keys that changed, with
+// secrets masked.
+package fingerprint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/pkg/support"
+)
+
+// Snapshot is the configuration of a run.
+type Snapshot struct {
+	// Fingerprint is the SHA-256 of the configuration, secrets included.
+	Fingerprint string `json:"fingerprint"`
+	Version     string `json:"version,omitempty"`
+	// Values are the configured values by key path, e.g. "data.redis.addr".
+	// Secrets are masked, followed by a digest telling their changes apart.
+	Values map[string]string `json:"values"`
+	Taken  time.Time         `json:"taken"`
+}
+
+// New takes the snapshot of c, the configuration of version.
+func New(c proto.Message, version string) (*Snapshot, error) {
+	raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	values, masked := make(map[string]string), make(map[string]string)
+	flatten("", v, values)
+	flatten("", support.Mask(v), masked)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, values[k])
+		if masked[k] != values[k] {
+			masked[k] += "#" + digest(values[k])
+		}
+	}
+	return &Snapshot{
+		Fingerprint: hex.EncodeToString(h.Sum(nil)),
+		Version:     version,
+		Values:      masked,
+		Taken:       time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// flatten adds the leaves of v to out by dotted key path. Lists are leaves.
+func flatten(prefix string, v any, out map[string]string) {
+	if m, ok := v.(map[string]any); ok {
+		for k, val := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			flatten(k, val, out)
+		}
+		return
+	}
+	if s, ok := v.(string); ok {
+		out[prefix] = s
+		return
+	}
+	b, _ := json.Marshal(v)
+	out[prefix] = string(b)
+}
+
+// digest is a short hash of a secret, to tell that it changed.
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Change is a configuration key whose value changed. Old is empty for an
+// added key, New for a removed one.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Diff returns the keys changed from prev to s, sorted.
+func (s *Snapshot) Diff(prev *Snapshot) []Change {
+	var changes []Change
+	for k, v := range s.Values {
+		if old, ok := prev.Values[k]; !ok || old != v {
+			changes = append(changes, Change{Key: k, Old: old, New: v})
+		}
+	}
+	for k, old := range prev.Values {
+		if _, ok := s.Values[k]; !ok {
+			changes = append(changes, Change{Key: k, Old: old})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Store keeps the snapshot of the previous run.
+type Store interface {
+	// Load returns the stored snapshot, nil when there is none.
+	Load(ctx context.Context) (*Snapshot, error)
+	// Save replaces the stored snapshot with s.
+	Save(ctx context.Context, s *Snapshot) error
+}
+
+// Check compares s with the snapshot in store, logs the changes and stores
+// s in its place. It returns the changes, none on the first run.
+func Check(ctx context.Context, store Store, s *Snapshot, logger log.Logger) ([]Change, error) {
+	l := log.NewHelper(log.With(logger, "module", "fingerprint"))
+	prev, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load config fingerprint: %w", err)
+	}
+	var changes []Change
+	switch {
+	case prev == nil:
+		l.Infow("msg", "config fingerprint recorded", "fingerprint", s.Fingerprint, "keys", len(s.Values))
+	case prev.Fingerprint == s.Fingerprint:
+		l.Infow("msg", "config unchanged since the previous run", "fingerprint", s.Fingerprint,
+			"previous_version", prev.Version)
+	default:
+		changes = s.Diff(prev)
+		l.Warnw("msg", "config changed since the previous run", "fingerprint", s.Fingerprint,
+			"previous_fingerprint", prev.Fingerprint, "previous_version", prev.Version,
+			"previous_taken", prev.Taken, "changes", changes)
+	}
+	if err := store.Save(ctx, s); err != nil {
+		return changes, fmt.Errorf("save config fingerprint: %w", err)
+	}
+	return changes, nil
+}
+
+// FileStore keeps the snapshot in a JSON file, for a single instance with a
+// persistent disk.
+type FileStore struct {
+	path string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a store in the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load implements Store.
+func (s *FileStore) Load(_ context.Context) (*Snapshot, error) {
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(b)
+}
+
+// Save implements Store.
+func (s *FileStore) Save(_ context.Context, snap *Snapshot) error {
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// RedisStore keeps the snapshot in a Redis key shared by the instances of a
+// service, so the first instance of a deploy reports the changes.
+type RedisStore struct {
+	client func() redis.Cmdable
+	key    string
+}
+
+var _ Store = (*RedisStore)(nil)
+
+// NewRedisStore creates a store in key. client is called per operation so
+// reconnects are picked up.
+func NewRedisStore(client func() redis.Cmdable, key string) *RedisStore {
+	return &RedisStore{client: client, key: key}
+}
+
+// Load implements Store.
+func (s *RedisStore) Load(ctx context.Context) (*Snapshot, error) {
+	b, err := s.client().Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(b)
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(ctx context.Context, snap *Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return s.client().Set(ctx, s.key, b, 0).Err()
+}
+
+func decode(b []byte) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if s.Values == nil {
+		s.Values = make(map[string]string)
+	}
+	return &s, nil
+}
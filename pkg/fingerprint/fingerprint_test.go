@@ -0,0 +1,69 @@
+package fingerprint
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/support"
+)
+
+func config(addr, password, servers string) *conf.Bootstrap {
+	return &conf.Bootstrap{
+		Data:     &conf.Data{Redis: &conf.Data_Redis{Addr: addr, Password: password}},
+		Rocketmq: &conf.RocketMQ{NameServers: servers},
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(config("127.0.0.1:6379", "s3cret", "127.0.0.1:8081"), "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:6379", s.Values["data.redis.addr"])
+	assert.True(t, strings.HasPrefix(s.Values["data.redis.password"], support.Masked+"#"))
+	assert.NotContains(t, s.Values["data.redis.password"], "s3cret")
+	assert.Equal(t, "1.0.0", s.Version)
+
+	same, err := New(config("127.0.0.1:6379", "s3cret", "127.0.0.1:8081"), "1.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, s.Fingerprint, same.Fingerprint)
+
+	rotated, err := New(config("127.0.0.1:6379", "n3w", "127.0.0.1:8081"), "1.0.1")
+	require.NoError(t, err)
+	assert.NotEqual(t, s.Fingerprint, rotated.Fingerprint)
+	assert.NotEqual(t, s.Values["data.redis.password"], rotated.Values["data.redis.password"])
+}
+
+func TestCheck(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "fp", "config.json"))
+	ctx := context.Background()
+
+	first, err := New(config("127.0.0.1:6379", "s3cret", "127.0.0.1:8081"), "1.0.0")
+	require.NoError(t, err)
+	changes, err := Check(ctx, store, first, log.DefaultLogger)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = Check(ctx, store, first, log.DefaultLogger)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	next, err := New(config("10.0.0.1:6379", "n3w", ""), "1.1.0")
+	require.NoError(t, err)
+	changes, err = Check(ctx, store, next, log.DefaultLogger)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, Change{Key: "data.redis.addr", Old: "127.0.0.1:6379", New: "10.0.0.1:6379"}, changes[0])
+	assert.Equal(t, "data.redis.password", changes[1].Key)
+	assert.Equal(t, Change{Key: "rocketmq.name_servers", Old: "127.0.0.1:8081"}, changes[2])
+
+	stored, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, next.Fingerprint, stored.Fingerprint)
+	assert.Equal(t, "1.1.0", stored.Version)
+}
@@ -0,0 +1,270 @@
+// Package gateway reverse-proxies path prefixes to other registered
+// services, so that small deployments can expose several services behind one
+// HTTP port without a dedicated gateway.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/go-kratos/kratos-layout/pkg/client"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+// Route maps a path prefix to a service.
+type Route struct {
+	Prefix  string // e.g. /orders, matching /orders and /orders/...
+	Service string // registered name, e.g. order-service
+	// StripPrefix forwards /orders/1 as /1.
+	StripPrefix bool
+}
+
+// Option configures a Gateway.
+type Option func(*options)
+
+type options struct {
+	middlewares  []middleware.Middleware
+	errorEncoder khttp.EncodeErrorFunc
+}
+
+// WithMiddleware runs every proxied request through m, e.g. the chain of the
+// HTTP server for the same authentication, rate limits and logging.
+func WithMiddleware(m ...middleware.Middleware) Option {
+	return func(o *options) { o.middlewares = append(o.middlewares, m...) }
+}
+
+// WithErrorEncoder encodes the errors of the gateway and its middlewares,
+// khttp.DefaultErrorEncoder by default.
+func WithErrorEncoder(enc khttp.EncodeErrorFunc) Option {
+	return func(o *options) { o.errorEncoder = enc }
+}
+
+// Gateway proxies HTTP requests to the service of the longest matching route
+// prefix. Upstream responses, errors included, are relayed as they are; only
+// the failures to reach a service are errors of the gateway.
+type Gateway struct {
+	opts    options
+	factory *client.Factory
+	routes  []Route // longest prefix first
+	log     *log.Helper
+
+	mu        sync.Mutex
+	upstreams map[string]*upstream
+}
+
+// New creates a gateway dialing services through f, so they are resolved
+// with discovery or the fixed endpoints of the factory.
+func New(f *client.Factory, routes []Route, logs *zapLog.Scoped, opts ...Option) (*Gateway, error) {
+	o := options{errorEncoder: khttp.DefaultErrorEncoder}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	seen := make(map[string]bool, len(routes))
+	sorted := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		r.Prefix = "/" + strings.Trim(r.Prefix, "/")
+		if r.Prefix == "/" {
+			return nil, fmt.Errorf("gateway: route to %q: prefix must not be empty or /", r.Service)
+		}
+		if r.Service == "" {
+			return nil, fmt.Errorf("gateway: route %s: service is required", r.Prefix)
+		}
+		if seen[r.Prefix] {
+			return nil, fmt.Errorf("gateway: duplicate route %s", r.Prefix)
+		}
+		seen[r.Prefix] = true
+		sorted = append(sorted, r)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) > len(sorted[j].Prefix) })
+	return &Gateway{
+		opts:      o,
+		factory:   f,
+		routes:    sorted,
+		log:       logs.For("pkg/gateway").Helper,
+		upstreams: make(map[string]*upstream),
+	}, nil
+}
+
+// Routes returns the routes, longest prefix first.
+func (g *Gateway) Routes() []Route {
+	return append([]Route(nil), g.routes...)
+}
+
+// Register serves the route prefixes on srv. Routes registered on srv
+// before take precedence.
+func (g *Gateway) Register(srv *khttp.Server) {
+	for _, r := range g.routes {
+		srv.Handle(r.Prefix, g)
+		srv.HandlePrefix(r.Prefix+"/", g)
+	}
+}
+
+// match returns the route of path.
+func (g *Gateway) match(path string) (Route, bool) {
+	for _, r := range g.routes {
+		if path == r.Prefix || strings.HasPrefix(path, r.Prefix+"/") {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route, ok := g.match(req.URL.Path)
+	if !ok {
+		g.opts.errorEncoder(w, req, kerrors.NotFound("ROUTE_NOT_FOUND", "no gateway route for "+req.URL.Path))
+		return
+	}
+	// Operations are request paths, so middleware selectors match the
+	// proxied routes like local ones.
+	khttp.SetOperation(req.Context(), req.URL.Path)
+	h := func(ctx context.Context, _ any) (any, error) {
+		return nil, g.proxy(ctx, w, req, route)
+	}
+	if len(g.opts.middlewares) > 0 {
+		h = middleware.Chain(g.opts.middlewares...)(h)
+	}
+	if _, err := h(req.Context(), req); err != nil {
+		g.opts.errorEncoder(w, req, err)
+	}
+}
+
+// proxy forwards req to the service of route and relays the response.
+func (g *Gateway) proxy(ctx context.Context, w http.ResponseWriter, req *http.Request, route Route) error {
+	up, err := g.upstream(route.Service)
+	if err != nil {
+		return kerrors.ServiceUnavailable("UPSTREAM_UNAVAILABLE", err.Error())
+	}
+	var proxyErr error
+	p := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme = up.base.Scheme
+			pr.Out.URL.Host = up.base.Host
+			pr.Out.Host = ""
+			pr.Out.RequestURI = "" // rejected by the http.Client of the kratos client
+			if route.StripPrefix {
+				pr.Out.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(pr.In.URL.Path, route.Prefix), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetXForwarded()
+		},
+		Transport:    up,
+		ErrorHandler: func(_ http.ResponseWriter, _ *http.Request, err error) { proxyErr = err },
+	}
+	p.ServeHTTP(w, req.WithContext(ctx))
+	if proxyErr == nil {
+		return nil
+	}
+	if se := new(kerrors.Error); errors.As(proxyErr, &se) {
+		return se
+	}
+	if errors.Is(proxyErr, context.Canceled) {
+		return kerrors.ClientClosed("CLIENT_CLOSED", proxyErr.Error())
+	}
+	g.log.Warnw("msg", "proxy request", "service", route.Service, "path", req.URL.Path, "error", proxyErr)
+	return kerrors.New(http.StatusBadGateway, "BAD_GATEWAY", proxyErr.Error())
+}
+
+// upstream returns the client of service, dialed on first use. It watches
+// discovery until Close, beyond the request dialing it.
+func (g *Gateway) upstream(service string) (*upstream, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if up, ok := g.upstreams[service]; ok {
+		return up, nil
+	}
+	// Responses, redirects included, are relayed as they are rather than
+	// decoded into errors or followed.
+	cli, err := g.factory.HTTP(context.Background(), service,
+		khttp.WithErrorDecoder(func(context.Context, *http.Response) error { return nil }),
+		khttp.WithTransport(redirectGuard{http.DefaultTransport}))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", service, err)
+	}
+	base := &url.URL{Scheme: "http", Host: service}
+	if e := g.factory.HTTPEndpoint(service); e != "" {
+		if !strings.Contains(e, "://") {
+			e = "http://" + e
+		}
+		if base, err = url.Parse(e); err != nil {
+			_ = cli.Close()
+			return nil, fmt.Errorf("endpoint of %s: %w", service, err)
+		}
+	}
+	up := &upstream{client: cli, base: base}
+	g.upstreams[service] = up
+	return up, nil
+}
+
+// Close closes the upstream clients.
+func (g *Gateway) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var errs []error
+	for _, up := range g.upstreams {
+		errs = append(errs, up.client.Close())
+	}
+	clear(g.upstreams)
+	return errors.Join(errs...)
+}
+
+// upstream sends requests to the instances of a service. With discovery,
+// the client replaces the host of base with the selected instance.
+type upstream struct {
+	client *khttp.Client
+	base   *url.URL
+}
+
+// RoundTrip implements http.RoundTripper.
+func (u *upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	guarded := new(guardedRedirect)
+	resp, err := u.client.Do(req.WithContext(context.WithValue(req.Context(), guardedRedirectKey{}, guarded)))
+	if err != nil {
+		return nil, err
+	}
+	if guarded.location != "" {
+		resp.Header.Set("Location", guarded.location)
+	}
+	return resp, nil
+}
+
+// guardedRedirect holds the Location of a redirect while it passes through
+// the http.Client of the kratos client, which follows any redirect with one.
+// It travels in the request context, so no upstream header can forge it.
+type guardedRedirect struct {
+	location string
+}
+
+type guardedRedirectKey struct{}
+
+// redirectGuard moves the Location of redirects to the guardedRedirect of
+// the request.
+type redirectGuard struct {
+	next http.RoundTripper
+}
+
+func (t redirectGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	guarded, ok := req.Context().Value(guardedRedirectKey{}).(*guardedRedirect)
+	if loc := resp.Header.Get("Location"); ok && loc != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		resp.Header.Del("Location")
+		guarded.location = loc
+	}
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/client"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+func newBackend(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/moved") {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/forged") {
+			w.Header().Set("X-Gateway-Location", "https://evil.example")
+		}
+		w.Header().Set("X-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = io.WriteString(w, name+" "+r.Method+" "+r.URL.RequestURI())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGateway(t *testing.T) {
+	orders, admin := newBackend(t, "orders"), newBackend(t, "admin")
+	f := client.NewFactory(nil, log.DefaultLogger, client.WithEndpoints(map[string]client.Endpoint{
+		"order-service": {HTTP: orders.URL},
+		"admin-service": {HTTP: strings.TrimPrefix(admin.URL, "http://")},
+		"down-service":  {HTTP: "127.0.0.1:1"},
+	}))
+	var operations []string
+	record := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			r := req.(*http.Request)
+			if r.Header.Get("Authorization") == "" {
+				return nil, kerrors.Unauthorized("NO_TOKEN", "missing token")
+			}
+			operations = append(operations, r.URL.Path)
+			return next(ctx, req)
+		}
+	}
+	g, err := New(f, []Route{
+		{Prefix: "/orders", Service: "order-service"},
+		{Prefix: "/orders/admin/", Service: "admin-service", StripPrefix: true},
+		{Prefix: "/down", Service: "down-service"},
+	}, zapLog.NewScoped(log.DefaultLogger), WithMiddleware(record))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = g.Close() })
+	assert.Equal(t, "/orders/admin", g.Routes()[0].Prefix, "longest prefix first")
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer t")
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/orders/1?expand=items")
+	assert.Equal(t, http.StatusTeapot, w.Code, "upstream statuses are relayed")
+	assert.Equal(t, "orders POST /orders/1?expand=items", w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("X-Forwarded-For"))
+
+	w = serve(http.MethodGet, "/orders/admin/users")
+	assert.Equal(t, "admin GET /users", w.Body.String())
+	w = serve(http.MethodGet, "/orders")
+	assert.Equal(t, "orders GET /orders", w.Body.String())
+
+	w = serve(http.MethodGet, "/orders/moved")
+	assert.Equal(t, http.StatusFound, w.Code, "redirects are not followed")
+	assert.Equal(t, "/elsewhere", w.Header().Get("Location"))
+
+	w = serve(http.MethodGet, "/orders/forged")
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Empty(t, w.Header().Get("Location"), "only redirects of the upstream set Location")
+	assert.Equal(t, "https://evil.example", w.Header().Get("X-Gateway-Location"))
+
+	assert.Equal(t, http.StatusBadGateway, serve(http.MethodGet, "/down/x").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/ordersx").Code)
+	assert.Equal(t, []string{"/orders/1", "/orders/admin/users", "/orders", "/orders/moved", "/orders/forged", "/down/x"}, operations)
+
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "middlewares run before proxying")
+}
+
+func TestNew_InvalidRoutes(t *testing.T) {
+	for name, routes := range map[string][]Route{
+		"root":      {{Prefix: "/", Service: "a"}},
+		"service":   {{Prefix: "/a"}},
+		"duplicate": {{Prefix: "/a", Service: "a"}, {Prefix: "a/", Service: "b"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(nil, routes, zapLog.NewScoped(log.DefaultLogger))
+			assert.Error(t, err)
+		})
+	}
+}
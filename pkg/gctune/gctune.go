@@ -0,0 +1,60 @@
+// Package gctune applies garbage collector settings from configuration:
+// the GC target percentage, a soft memory limit and a heap ballast for
+// latency-sensitive services with small live heaps.
+package gctune
+
+import "runtime/debug"
+
+// Config tunes the garbage collector. Zero values keep the runtime defaults,
+// including the GOGC and GOMEMLIMIT environment variables.
+type Config struct {
+	// GOGC is the GC target percentage. Negative disables proportional
+	// collection, leaving MemoryLimit as the only trigger.
+	GOGC int
+	// MemoryLimit is the soft memory limit in bytes.
+	MemoryLimit int64
+	// Ballast is the size in bytes of an allocation that is never touched.
+	// It raises the heap target, so a small heap is collected less often,
+	// without using physical memory.
+	Ballast int64
+}
+
+// Tuner holds the applied settings until Close.
+type Tuner struct {
+	ballast   []byte
+	prevGOGC  int
+	prevLimit int64
+	applied   Config
+}
+
+// Apply applies c and returns a Tuner restoring the previous settings on
+// Close. The ballast is kept alive until then.
+func Apply(c Config) *Tuner {
+	t := &Tuner{applied: c}
+	if c.GOGC != 0 {
+		t.prevGOGC = debug.SetGCPercent(c.GOGC)
+	}
+	if c.MemoryLimit > 0 {
+		t.prevLimit = debug.SetMemoryLimit(c.MemoryLimit)
+	}
+	if c.Ballast > 0 {
+		t.ballast = make([]byte, c.Ballast)
+	}
+	return t
+}
+
+// Ballast returns the size of the ballast in bytes.
+func (t *Tuner) Ballast() int64 {
+	return int64(len(t.ballast))
+}
+
+// Close restores the settings replaced by Apply and releases the ballast.
+func (t *Tuner) Close() {
+	if t.applied.GOGC != 0 {
+		debug.SetGCPercent(t.prevGOGC)
+	}
+	if t.applied.MemoryLimit > 0 {
+		debug.SetMemoryLimit(t.prevLimit)
+	}
+	t.ballast = nil
+}
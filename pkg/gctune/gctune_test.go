@@ -0,0 +1,37 @@
+package gctune
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	prevGOGC := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(prevGOGC)
+	prevLimit := debug.SetMemoryLimit(-1)
+
+	tuner := Apply(Config{GOGC: 400, MemoryLimit: 1 << 30, Ballast: 64 << 20})
+	assert.Equal(t, 400, debug.SetGCPercent(400))
+	assert.Equal(t, int64(1<<30), debug.SetMemoryLimit(-1))
+	assert.Equal(t, int64(64<<20), tuner.Ballast())
+
+	tuner.Close()
+	assert.Equal(t, 100, debug.SetGCPercent(100))
+	assert.Equal(t, prevLimit, debug.SetMemoryLimit(-1))
+	assert.Zero(t, tuner.Ballast())
+}
+
+func TestApply_Zero(t *testing.T) {
+	prevGOGC := debug.SetGCPercent(150)
+	defer debug.SetGCPercent(prevGOGC)
+	prevLimit := debug.SetMemoryLimit(-1)
+
+	tuner := Apply(Config{})
+	assert.Equal(t, 150, debug.SetGCPercent(150))
+	assert.Equal(t, prevLimit, debug.SetMemoryLimit(-1))
+	assert.Zero(t, tuner.Ballast())
+	tuner.Close()
+	assert.Equal(t, 150, debug.SetGCPercent(150))
+}
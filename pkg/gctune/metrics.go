@@ -0,0 +1,25 @@
+//go:build !nometrics
+
+package gctune
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterMetrics replaces the Go collector of reg with one exporting the
+// runtime GC and memory metrics (go_gc_pauses_seconds, go_gc_heap_*,
+// go_memory_classes_*, go_gc_gogc_percent, go_gc_gomemlimit_bytes) and adds
+// go_gc_ballast_bytes.
+func RegisterMetrics(reg prometheus.Registerer, t *Tuner) error {
+	reg.Unregister(collectors.NewGoCollector())
+	if err := reg.Register(collectors.NewGoCollector(
+		collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC, collectors.MetricsMemory),
+	)); err != nil {
+		return err
+	}
+	return reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "go_gc_ballast_bytes",
+		Help: "Size of the heap ballast raising the GC heap target.",
+	}, func() float64 { return float64(t.Ballast()) }))
+}
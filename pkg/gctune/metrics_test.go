@@ -0,0 +1,31 @@
+//go:build !nometrics
+
+package gctune
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector())
+	tuner := Apply(Config{Ballast: 1 << 20})
+	defer tuner.Close()
+
+	require.NoError(t, RegisterMetrics(reg, tuner))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP go_gc_ballast_bytes Size of the heap ballast raising the GC heap target.
+# TYPE go_gc_ballast_bytes gauge
+go_gc_ballast_bytes 1.048576e+06
+`), "go_gc_ballast_bytes"))
+	n, err := testutil.GatherAndCount(reg, "go_gc_pauses_seconds", "go_gc_heap_goal_bytes")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
@@ -0,0 +1,189 @@
+// Package grpcweb serves gRPC-Web requests from browsers on an HTTP server
+// by translating them to gRPC and back, so frontends can call the gRPC
+// services, with their middleware, without an Envoy in front.
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Content types of gRPC-Web requests. The text variant carries
+// base64-encoded frames, for clients that cannot read binary streams.
+const (
+	ContentType     = "application/grpc-web"
+	ContentTypeText = "application/grpc-web-text"
+)
+
+// trailerFlag marks the frame holding the trailers at the end of a response.
+const trailerFlag = 0x80
+
+// Option configures the gRPC-Web filter.
+type Option func(*options)
+
+type options struct {
+	origins []string
+}
+
+// WithAllowedOrigins answers CORS requests of browser origins, e.g.
+// https://app.example.com, or of any origin with "*". Without it only
+// same-origin pages can call the services.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *options) { o.origins = origins }
+}
+
+// IsGRPCWebRequest reports whether r is a gRPC-Web call.
+func IsGRPCWebRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), ContentType)
+}
+
+// Filter returns an HTTP filter passing gRPC-Web calls, and their CORS
+// preflights, to grpcServer, typically the kratos gRPC server, and other
+// requests to the next handler:
+//
+//	http.NewServer(http.Filter(grpcweb.Filter(gs)))
+//
+// Unary and server streaming methods are supported; gRPC-Web has no client
+// streaming.
+func Filter(grpcServer http.Handler, opts ...Option) func(http.Handler) http.Handler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.preflight(r) {
+				o.allow(w, r)
+				w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+				w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if !IsGRPCWebRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			o.allow(w, r)
+			serve(grpcServer, w, r)
+		})
+	}
+}
+
+// preflight reports whether r is the CORS preflight of a gRPC-Web call.
+func (o *options) preflight(r *http.Request) bool {
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" || len(o.origins) == 0 {
+		return false
+	}
+	headers := strings.ToLower(r.Header.Get("Access-Control-Request-Headers"))
+	return strings.Contains(headers, "x-grpc-web")
+}
+
+// allow sets the CORS response headers when the origin is allowed.
+func (o *options) allow(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !(slices.Contains(o.origins, "*") || slices.Contains(o.origins, origin)) {
+		return
+	}
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Set("Access-Control-Allow-Credentials", "true")
+	h.Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+	h.Add("Vary", "Origin")
+}
+
+// serve makes r look like an HTTP/2 gRPC request to grpcServer and converts
+// the response, whose trailers are sent as a final frame in the body.
+func serve(grpcServer http.Handler, w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(contentType, ContentTypeText)
+	subtype := strings.TrimPrefix(strings.TrimPrefix(contentType, ContentTypeText), ContentType)
+
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+	req.Header.Set("Content-Type", "application/grpc"+subtype)
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+	if text {
+		req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	}
+
+	rw := &responseWriter{w: w, header: make(http.Header), contentType: contentType}
+	if text {
+		rw.enc = base64.NewEncoder(base64.StdEncoding, w)
+	}
+	grpcServer.ServeHTTP(rw, req)
+	rw.finish()
+}
+
+// responseWriter records the headers written by the gRPC server and writes
+// the ones set after the body as a trailer frame.
+type responseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	enc         io.WriteCloser // base64 encoder of text responses
+	sent        http.Header    // headers at the time they were written
+}
+
+func (rw *responseWriter) Header() http.Header { return rw.header }
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.sent != nil {
+		return
+	}
+	rw.sent = rw.header.Clone()
+	h := rw.w.Header()
+	for k, v := range rw.header {
+		if k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = v
+	}
+	h.Set("Content-Type", rw.contentType)
+	h.Del("Content-Length")
+	rw.w.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	if rw.enc != nil {
+		return rw.enc.Write(b)
+	}
+	return rw.w.Write(b)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.WriteHeader(http.StatusOK)
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailer frame: the headers added after the response
+// headers were written, and the ones set with http.TrailerPrefix.
+func (rw *responseWriter) finish() {
+	rw.WriteHeader(http.StatusOK)
+	var trailer bytes.Buffer
+	for k, vv := range rw.header {
+		name := strings.TrimPrefix(k, http.TrailerPrefix)
+		if k == "Trailer" || (name == k && slices.Equal(rw.sent[k], vv)) {
+			continue
+		}
+		for _, v := range vv {
+			trailer.WriteString(strings.ToLower(name) + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+trailer.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(trailer.Len()))
+	_, _ = rw.Write(append(frame, trailer.Bytes()...))
+	if rw.enc != nil {
+		_ = rw.enc.Close()
+	}
+}
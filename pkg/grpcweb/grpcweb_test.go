@@ -0,0 +1,156 @@
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+type greeter struct {
+	v1.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, req *v1.HelloRequest) (*v1.HelloReply, error) {
+	if req.GetName() == "missing" {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user missing not found")
+	}
+	return &v1.HelloReply{Message: "hello " + req.GetName()}, nil
+}
+
+// operationHeader is a middleware echoing the operation as a reply header.
+func operationHeader(handler middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, req any) (any, error) {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			tr.ReplyHeader().Set("x-operation", tr.Operation())
+		}
+		return handler(ctx, req)
+	}
+}
+
+func newServer(t *testing.T, opts ...Option) *httptest.Server {
+	gs := grpc.NewServer(grpc.Middleware(operationHeader))
+	v1.RegisterGreeterServer(gs, greeter{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "http")
+	})
+	srv := httptest.NewServer(Filter(gs, opts...)(next))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func frame(flag byte, payload []byte) []byte {
+	b := make([]byte, 5, 5+len(payload))
+	b[0] = flag
+	binary.BigEndian.PutUint32(b[1:], uint32(len(payload)))
+	return append(b, payload...)
+}
+
+// call sends a gRPC-Web SayHello and returns the response, its message frame
+// payload, if any, and the trailers.
+func call(t *testing.T, url, contentType, name string) (*http.Response, []byte, string) {
+	msg, err := proto.Marshal(&v1.HelloRequest{Name: name})
+	require.NoError(t, err)
+	body := frame(0, msg)
+	if contentType == ContentTypeText {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+	req, err := http.NewRequest(http.MethodPost, url+"/helloworld.v1.Greeter/SayHello", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Grpc-Web", "1")
+	req.Header.Set("Origin", "https://app.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if contentType == ContentTypeText {
+		data, err = base64.StdEncoding.DecodeString(string(data))
+		require.NoError(t, err)
+	}
+
+	var message []byte
+	var trailer string
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 5)
+		n := binary.BigEndian.Uint32(data[1:5])
+		payload := data[5 : 5+n]
+		if data[0]&trailerFlag != 0 {
+			trailer = string(payload)
+		} else {
+			message = payload
+		}
+		data = data[5+n:]
+	}
+	return resp, message, trailer
+}
+
+func TestFilter(t *testing.T) {
+	srv := newServer(t, WithAllowedOrigins("https://app.example.com"))
+
+	for _, contentType := range []string{ContentType + "+proto", ContentTypeText} {
+		resp, message, trailer := call(t, srv.URL, contentType, "alice")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, contentType, resp.Header.Get("Content-Type"))
+		assert.Equal(t, "/helloworld.v1.Greeter/SayHello", resp.Header.Get("X-Operation"), "middleware ran")
+		assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		var reply v1.HelloReply
+		require.NoError(t, proto.Unmarshal(message, &reply))
+		assert.Equal(t, "hello alice", reply.GetMessage())
+		assert.Contains(t, trailer, "grpc-status: 0\r\n")
+	}
+
+	resp, message, trailer := call(t, srv.URL, ContentType, "missing")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, message)
+	assert.Contains(t, trailer, "grpc-status: 5\r\n")
+	assert.Contains(t, trailer, "grpc-status-details-bin: ", "error reason and metadata are kept")
+
+	resp, err := http.Get(srv.URL + "/helloworld/alice")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "http", string(body), "other requests reach the HTTP routes")
+}
+
+func TestFilter_CORS(t *testing.T) {
+	preflight := func(srv *httptest.Server, origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, srv.URL+"/helloworld.v1.Greeter/SayHello", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,x-user-agent")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	srv := newServer(t, WithAllowedOrigins("https://app.example.com"))
+	resp := preflight(srv, "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.True(t, strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "x-grpc-web"))
+
+	resp = preflight(srv, "https://evil.example.com")
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+	resp = preflight(newServer(t), "https://app.example.com")
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"), "same origin only without allowed origins")
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// ErrorRate tracks the server error rate through a middleware and scores it.
+// Only server errors (code >= 500) count; client errors do not indicate an
+// unhealthy instance.
+type ErrorRate struct {
+	minRequests int64
+	good, bad   float64
+
+	total  atomic.Int64
+	failed atomic.Int64
+}
+
+// NewErrorRate creates an error rate probe.
+// Windows with fewer than minRequests requests score 1. Rates up to good
+// score 1, rates from bad on score 0.
+func NewErrorRate(minRequests int64, good, bad float64) *ErrorRate {
+	return &ErrorRate{minRequests: minRequests, good: good, bad: bad}
+}
+
+// Middleware counts requests and server errors.
+func (e *ErrorRate) Middleware() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			reply, err := handler(ctx, req)
+			e.total.Add(1)
+			if err != nil && errors.FromError(err).Code >= 500 {
+				e.failed.Add(1)
+			}
+			return reply, err
+		}
+	}
+}
+
+// Score implements Probe. It scores the requests seen since the previous
+// call and starts a new window.
+func (e *ErrorRate) Score(context.Context) float64 {
+	total, failed := e.total.Swap(0), e.failed.Swap(0)
+	if total == 0 || total < e.minRequests {
+		return 1
+	}
+	return linear(float64(failed)/float64(total), e.good, e.bad)
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Probe reports a health score in [0, 1], where 1 is fully healthy.
+type Probe interface {
+	Score(ctx context.Context) float64
+}
+
+// ProbeFunc adapts a function to Probe.
+type ProbeFunc func(ctx context.Context) float64
+
+// Score implements Probe.
+func (f ProbeFunc) Score(ctx context.Context) float64 { return f(ctx) }
+
+// Min returns the lowest score among probes, or 1 when there are none.
+func Min(ctx context.Context, probes ...Probe) float64 {
+	score := 1.0
+	for _, p := range probes {
+		score = math.Min(score, p.Score(ctx))
+	}
+	return score
+}
+
+// Latency returns a probe that times fn, e.g. a database ping.
+// Durations up to good score 1, durations from bad on score 0, in between
+// the score falls linearly. An error scores 0.
+func Latency(fn func(ctx context.Context) error, good, bad time.Duration) Probe {
+	return ProbeFunc(func(ctx context.Context) float64 {
+		start := time.Now()
+		if err := fn(ctx); err != nil {
+			return 0
+		}
+		return linear(float64(time.Since(start)), float64(good), float64(bad))
+	})
+}
+
+// linear maps v onto [1, 0] between the good and bad thresholds.
+func linear(v, good, bad float64) float64 {
+	switch {
+	case v <= good:
+		return 1
+	case v >= bad:
+		return 0
+	default:
+		return (bad - v) / (bad - good)
+	}
+}
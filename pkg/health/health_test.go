@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMin(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, 1.0, Min(ctx))
+	assert.Equal(t, 0.3, Min(ctx,
+		ProbeFunc(func(context.Context) float64 { return 0.8 }),
+		ProbeFunc(func(context.Context) float64 { return 0.3 }),
+	))
+}
+
+func TestLatency(t *testing.T) {
+	ctx := context.Background()
+
+	fast := Latency(func(context.Context) error { return nil }, 50*time.Millisecond, time.Second)
+	assert.Equal(t, 1.0, fast.Score(ctx))
+
+	failing := Latency(func(context.Context) error { return errors.New("down") }, 50*time.Millisecond, time.Second)
+	assert.Equal(t, 0.0, failing.Score(ctx))
+
+	slow := Latency(func(context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, 0.0, slow.Score(ctx))
+}
+
+func TestErrorRate(t *testing.T) {
+	ctx := context.Background()
+	er := NewErrorRate(10, 0.1, 0.5)
+
+	call := func(err error) {
+		_, _ = er.Middleware()(func(context.Context, any) (any, error) { return nil, err })(ctx, nil)
+	}
+
+	// Too few requests.
+	call(kerrors.InternalServer("X", "x"))
+	assert.Equal(t, 1.0, er.Score(ctx))
+
+	tests := []struct {
+		name      string
+		serverErr int
+		clientErr int
+		ok        int
+		want      float64
+	}{
+		{name: "healthy", ok: 20, want: 1},
+		{name: "client errors ignored", clientErr: 15, ok: 5, want: 1},
+		{name: "degraded", serverErr: 6, ok: 14, want: 0.5},
+		{name: "unhealthy", serverErr: 12, ok: 8, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.serverErr; i++ {
+				call(kerrors.ServiceUnavailable("X", "x"))
+			}
+			for i := 0; i < tt.clientErr; i++ {
+				call(kerrors.BadRequest("X", "x"))
+			}
+			for i := 0; i < tt.ok; i++ {
+				call(nil)
+			}
+			assert.InDelta(t, tt.want, er.Score(ctx), 1e-9)
+		})
+	}
+}
+
+func TestWeigher(t *testing.T) {
+	w := NewWeigher(100, 1, 25)
+
+	_, changed := w.Next(1)
+	assert.False(t, changed)
+
+	weight, changed := w.Next(0.2)
+	assert.True(t, changed)
+	assert.Equal(t, 20.0, weight)
+
+	weight, _ = w.Next(0)
+	assert.Equal(t, 1.0, weight, "never below min")
+
+	// Recovery is gradual.
+	weight, _ = w.Next(1)
+	assert.Equal(t, 26.0, weight)
+	weight, _ = w.Next(1)
+	assert.Equal(t, 51.0, weight)
+	for i := 0; i < 5; i++ {
+		w.Next(1)
+	}
+	assert.Equal(t, 100.0, w.Current())
+}
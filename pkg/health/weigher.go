@@ -0,0 +1,38 @@
+package health
+
+import "math"
+
+// Weigher turns health scores into a registry instance weight.
+// A lower score applies immediately, while recovery is limited to step per
+// update so a flapping instance does not get its full share back at once.
+type Weigher struct {
+	base    float64
+	min     float64
+	step    float64
+	current float64
+}
+
+// NewWeigher creates a weigher starting at base. Weights never drop below min,
+// registries usually reject zero weights.
+func NewWeigher(base, min, step float64) *Weigher {
+	return &Weigher{base: base, min: min, step: step, current: base}
+}
+
+// Current returns the last computed weight.
+func (w *Weigher) Current() float64 {
+	return w.current
+}
+
+// Next computes the weight for score and reports whether it changed.
+// Weights are rounded to integers to avoid registry churn on noise.
+func (w *Weigher) Next(score float64) (float64, bool) {
+	target := math.Round(math.Max(w.base*math.Max(0, math.Min(1, score)), w.min))
+	if target > w.current+w.step {
+		target = w.current + w.step
+	}
+	if target == w.current {
+		return w.current, false
+	}
+	w.current = target
+	return target, true
+}
@@ -0,0 +1,140 @@
+// Package httpcodec adds binary protobuf bodies to kratos HTTP servers and
+// clients, next to JSON. Importing it registers the application/x-protobuf
+// codec, so requests with that Content-Type are decoded as protobuf;
+// ResponseEncoder picks the response codec from the Accept header.
+//
+// Kratos HTTP clients send protobuf with the content type option and ask for
+// protobuf responses with the Client middleware:
+//
+//	conn, err := http.NewClient(ctx, http.WithEndpoint(addr),
+//		http.WithMiddleware(httpcodec.Client()))
+//	...
+//	err = conn.Invoke(ctx, "POST", path, in, out, http.ContentType(httpcodec.ContentTypeProtobuf))
+package httpcodec
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	nethttp "net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/json"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeProtobuf is the content type of binary protobuf bodies.
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// Name is the codec name, the subtype of ContentTypeProtobuf.
+const Name = "x-protobuf"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is the kratos proto codec under the x-protobuf name, returning an
+// error instead of panicking for values that are not messages.
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("httpcodec: %T is not a proto message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("httpcodec: %T is not a proto message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (codec) Name() string { return Name }
+
+// ResponseEncoder is an http.EncodeResponseFunc choosing the codec from the
+// Accept header: the registered type with the highest q value, JSON when
+// none matches. Protobuf is only chosen for proto messages.
+func ResponseEncoder(w nethttp.ResponseWriter, r *nethttp.Request, v any) error {
+	if v == nil {
+		return nil
+	}
+	if rd, ok := v.(http.Redirector); ok {
+		url, code := rd.Redirect()
+		nethttp.Redirect(w, r, url, code)
+		return nil
+	}
+	c := Negotiate(r.Header.Values("Accept"))
+	if _, isMsg := v.(proto.Message); !isMsg && c.Name() == Name {
+		c = encoding.GetCodec(json.Name)
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", "application/"+c.Name())
+	_, err = w.Write(data)
+	return err
+}
+
+// Negotiate returns the registered codec preferred by the Accept header
+// values, JSON when none matches.
+func Negotiate(accept []string) encoding.Codec {
+	type candidate struct {
+		subtype string
+		q       float64
+	}
+	var candidates []candidate
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			_, subtype, _ := strings.Cut(mediaType, "/")
+			if q > 0 {
+				candidates = append(candidates, candidate{subtype: subtype, q: q})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if c.subtype == "*" {
+			break
+		}
+		if codec := encoding.GetCodec(c.subtype); codec != nil {
+			return codec
+		}
+	}
+	return encoding.GetCodec(json.Name)
+}
+
+// Client is an HTTP client middleware asking for protobuf responses. Replies
+// are decoded by their Content-Type, so servers without this package still
+// answer in JSON and work.
+func Client() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok && tr.Kind() == transport.KindHTTP {
+				tr.RequestHeader().Set("Accept", ContentTypeProtobuf+", application/json;q=0.5")
+			}
+			return handler(ctx, req)
+		}
+	}
+}
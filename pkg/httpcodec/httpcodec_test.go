@@ -0,0 +1,95 @@
+package httpcodec
+
+import (
+	"bytes"
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept []string
+		want   string
+	}{
+		{nil, "json"},
+		{[]string{ContentTypeProtobuf}, Name},
+		{[]string{"application/json, application/x-protobuf"}, "json"},
+		{[]string{"application/json;q=0.5, application/x-protobuf"}, Name},
+		{[]string{"text/html", "application/x-protobuf;q=0.1"}, Name},
+		{[]string{"application/x-protobuf;q=0"}, "json"},
+		{[]string{"*/*"}, "json"},
+		{[]string{"application/unknown"}, "json"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Negotiate(tt.accept).Name(), tt.accept)
+	}
+}
+
+func TestResponseEncoder(t *testing.T) {
+	msg := wrapperspb.String("hello")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", ContentTypeProtobuf)
+	w := httptest.NewRecorder()
+	require.NoError(t, ResponseEncoder(w, r, msg))
+	assert.Equal(t, ContentTypeProtobuf, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	got := &wrapperspb.StringValue{}
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), got))
+	assert.Equal(t, "hello", got.GetValue())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, ResponseEncoder(w, r, map[string]string{"a": "b"}))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "non-messages fall back to JSON")
+	assert.JSONEq(t, `{"a":"b"}`, w.Body.String())
+
+	r.Header.Del("Accept")
+	w = httptest.NewRecorder()
+	require.NoError(t, ResponseEncoder(w, r, msg))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestRequestDecoder(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.String("hello"))
+	require.NoError(t, err)
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", ContentTypeProtobuf)
+
+	got := &wrapperspb.StringValue{}
+	require.NoError(t, http.DefaultRequestDecoder(r, got))
+	assert.Equal(t, "hello", got.GetValue())
+}
+
+type clientTransport struct {
+	transport.Transporter
+	header nethttp.Header
+}
+
+func (t *clientTransport) Kind() transport.Kind { return transport.KindHTTP }
+
+func (t *clientTransport) RequestHeader() transport.Header { return headerCarrier(t.header) }
+
+type headerCarrier nethttp.Header
+
+func (h headerCarrier) Get(key string) string      { return nethttp.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { nethttp.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { nethttp.Header(h).Add(key, value) }
+func (h headerCarrier) Values(key string) []string { return nethttp.Header(h).Values(key) }
+func (h headerCarrier) Keys() []string             { return nil }
+
+func TestClient(t *testing.T) {
+	tr := &clientTransport{header: nethttp.Header{}}
+	ctx := transport.NewClientContext(context.Background(), tr)
+	_, err := Client()(func(context.Context, any) (any, error) { return nil, nil })(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Name, Negotiate(tr.header.Values("Accept")).Name())
+}
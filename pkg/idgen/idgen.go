@@ -0,0 +1,97 @@
+// Package idgen generates unique, time-ordered string IDs prefixed with the
+// region that created them, so IDs minted concurrently in the data centers
+// of an active-active deployment never collide and tell where they came from.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idLen is the length of an ID without its region prefix: a 48-bit
+// millisecond timestamp and 80 random bits in Crockford base32, as a ULID.
+const idLen = 26
+
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Generator generates IDs such as "cn-east-01J9Z3KQ6V8W2R7T5N4M3B1C0D". IDs of
+// one generator sort by creation time, also within a millisecond.
+type Generator struct {
+	region string
+
+	mu   sync.Mutex
+	last [16]byte
+}
+
+// New creates a generator for region, usually env.CurrentDeployment().Region
+// or conf.Region.name. Without a region IDs have no prefix.
+func New(region string) *Generator {
+	return &Generator{region: region}
+}
+
+// Region returns the region of the generator.
+func (g *Generator) Region() string { return g.region }
+
+// New returns a new ID.
+func (g *Generator) New() string {
+	id := g.next(time.Now())
+	if g.region == "" {
+		return encode(id)
+	}
+	return g.region + "-" + encode(id)
+}
+
+// next returns the next 128-bit value: within the same millisecond the
+// random part of the previous value is incremented to keep the order.
+func (g *Generator) next(now time.Time) [16]byte {
+	var id [16]byte
+	ms := uint64(now.UnixMilli())
+	binary.BigEndian.PutUint64(id[:8], ms<<16)
+	_, _ = rand.Read(id[6:])
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if binary.BigEndian.Uint64(g.last[:8])>>16 >= ms {
+		id = g.last
+		for i := 15; i >= 6; i-- {
+			if id[i]++; id[i] != 0 {
+				break
+			}
+		}
+	}
+	g.last = id
+	return id
+}
+
+// RegionOf returns the region prefix of id, or "" when id has none or is not
+// an ID of this package.
+func RegionOf(id string) string {
+	if len(id) <= idLen+1 || id[len(id)-idLen-1] != '-' || !valid(id[len(id)-idLen:]) {
+		return ""
+	}
+	return id[:len(id)-idLen-1]
+}
+
+// encode encodes id in 26 base32 characters, most significant first.
+func encode(id [16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var b [idLen]byte
+	for i := idLen - 1; i >= 0; i-- {
+		b[i] = alphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+func valid(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(alphabet, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
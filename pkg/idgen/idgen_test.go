@@ -0,0 +1,38 @@
+package idgen
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator(t *testing.T) {
+	g := New("cn-east")
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = g.New()
+	}
+	assert.True(t, sort.StringsAreSorted(ids), "ids of a generator sort by creation")
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		assert.Len(t, id, len("cn-east-")+idLen)
+		assert.Equal(t, "cn-east", RegionOf(id))
+		assert.False(t, seen[id], "duplicate id %s", id)
+		seen[id] = true
+	}
+
+	id := New("").New()
+	assert.Len(t, id, idLen)
+	assert.Empty(t, RegionOf(id))
+	assert.Empty(t, RegionOf("order-42"))
+}
+
+func TestGenerator_ClockBackwards(t *testing.T) {
+	g := New("us-west")
+	now := time.Now()
+	first := g.next(now)
+	second := g.next(now.Add(-time.Second))
+	assert.Less(t, encode(first), encode(second))
+}
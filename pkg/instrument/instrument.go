@@ -0,0 +1,100 @@
+// Package instrument records spans and metrics for repository calls. It is
+// the runtime of the decorators generated by cmd/repogen.
+package instrument
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// Error classes reported by Classify.
+const (
+	ClassNotFound = "not_found"
+	ClassConflict = "conflict"
+	ClassTimeout  = "timeout"
+	ClassCanceled = "canceled"
+	ClassOther    = "other"
+)
+
+// Recorder records repository calls as spans named <repo>.<method> and,
+// when created with NewRecorder, as Prometheus metrics.
+type Recorder struct {
+	tracer trace.Tracer
+	// observe records a finished call; nil without metrics.
+	observe func(repo, method, class string, elapsed time.Duration)
+}
+
+// NewTracingRecorder creates a recorder emitting spans only, e.g. in
+// binaries built without metrics (nometrics). Spans go to the global
+// OpenTelemetry tracer provider.
+func NewTracingRecorder() *Recorder {
+	return &Recorder{tracer: otel.Tracer("github.com/go-kratos/kratos-layout/pkg/instrument")}
+}
+
+// Call is an in-flight repository call.
+type Call struct {
+	r      *Recorder
+	repo   string
+	method string
+	start  time.Time
+	span   trace.Span
+}
+
+// Start begins a call of repo.method. A nil Recorder records nothing.
+func (r *Recorder) Start(ctx context.Context, repo, method string) (context.Context, *Call) {
+	if r == nil {
+		return ctx, nil
+	}
+	ctx, span := r.tracer.Start(ctx, repo+"."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("repo", repo), attribute.String("repo.method", method)))
+	return ctx, &Call{r: r, repo: repo, method: method, start: time.Now(), span: span}
+}
+
+// End finishes the call with its error result.
+func (c *Call) End(err error) {
+	if c == nil {
+		return
+	}
+	class := Classify(err)
+	if c.r.observe != nil {
+		c.r.observe(c.repo, c.method, class, time.Since(c.start))
+	}
+	if class != "" {
+		c.span.SetAttributes(attribute.String("error.class", class))
+		// A missing row is an answer, not a failure of the call.
+		if class != ClassNotFound {
+			c.span.RecordError(err)
+			c.span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	c.span.End()
+}
+
+// Classify returns the class of a repository error, or "" for nil.
+func Classify(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, gorm.ErrRecordNotFound) || kerrors.IsNotFound(err):
+		return ClassNotFound
+	case errors.Is(err, orm.ErrStaleObject) || errors.Is(err, gorm.ErrDuplicatedKey) || kerrors.IsConflict(err):
+		return ClassConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	default:
+		return ClassOther
+	}
+}
@@ -0,0 +1,44 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("find: %w", gorm.ErrRecordNotFound), ClassNotFound},
+		{kerrors.NotFound("USER_NOT_FOUND", "user not found"), ClassNotFound},
+		{orm.ErrStaleObject, ClassConflict},
+		{gorm.ErrDuplicatedKey, ClassConflict},
+		{context.DeadlineExceeded, ClassTimeout},
+		{context.Canceled, ClassCanceled},
+		{errors.New("boom"), ClassOther},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Classify(tt.err), "%v", tt.err)
+	}
+}
+
+func TestTracingRecorder(t *testing.T) {
+	r := NewTracingRecorder()
+	_, call := r.Start(context.Background(), "GreeterRepo", "Save")
+	call.End(errors.New("boom"))
+
+	var nilRecorder *Recorder
+	ctx, call := nilRecorder.Start(context.Background(), "GreeterRepo", "Save")
+	assert.NotNil(t, ctx)
+	call.End(errors.New("boom"))
+}
@@ -0,0 +1,40 @@
+//go:build !nometrics
+
+package instrument
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewRecorder creates a recorder also recording Prometheus metrics,
+// registered with reg:
+//
+//	repo_call_duration_seconds{repo, method}
+//	repo_call_errors_total{repo, method, class}
+func NewRecorder(reg prometheus.Registerer) (*Recorder, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repo_call_duration_seconds",
+		Help:    "Duration of repository calls.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repo", "method"})
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "repo_call_errors_total",
+		Help: "Number of failed repository calls by error class.",
+	}, []string{"repo", "method", "class"})
+	for _, c := range []prometheus.Collector{duration, errors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	r := NewTracingRecorder()
+	r.observe = func(repo, method, class string, elapsed time.Duration) {
+		duration.WithLabelValues(repo, method).Observe(elapsed.Seconds())
+		if class != "" {
+			errors.WithLabelValues(repo, method, class).Inc()
+		}
+	}
+	return r, nil
+}
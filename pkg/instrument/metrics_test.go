@@ -0,0 +1,38 @@
+//go:build !nometrics
+
+package instrument
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r, err := NewRecorder(reg)
+	require.NoError(t, err)
+
+	_, call := r.Start(context.Background(), "GreeterRepo", "Save")
+	call.End(nil)
+	_, call = r.Start(context.Background(), "GreeterRepo", "FindByID")
+	call.End(gorm.ErrRecordNotFound)
+
+	count, err := testutil.GatherAndCount(reg, "repo_call_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP repo_call_errors_total Number of failed repository calls by error class.
+# TYPE repo_call_errors_total counter
+repo_call_errors_total{class="not_found",method="FindByID",repo="GreeterRepo"} 1
+`), "repo_call_errors_total"))
+
+	_, err = NewRecorder(reg)
+	assert.Error(t, err, "duplicate registration")
+}
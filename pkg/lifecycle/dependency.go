@@ -0,0 +1,41 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DependencyTracker turns repeated health checks of a dependency into
+// DependencyDown and DependencyUp events, emitted only on state changes.
+type DependencyTracker struct {
+	bus  *Bus
+	name string
+
+	mu        sync.Mutex
+	downSince time.Time // zero while up
+}
+
+// NewDependencyTracker creates a tracker for the named dependency, which starts as up.
+func NewDependencyTracker(bus *Bus, name string) *DependencyTracker {
+	return &DependencyTracker{bus: bus, name: name}
+}
+
+// Observe records the result of a health check.
+func (t *DependencyTracker) Observe(ctx context.Context, err error) {
+	t.mu.Lock()
+	var e Event
+	switch {
+	case err != nil && t.downSince.IsZero():
+		t.downSince = time.Now()
+		e = DependencyDown{Name: t.name, Err: err}
+	case err == nil && !t.downSince.IsZero():
+		e = DependencyUp{Name: t.name, Downtime: time.Since(t.downSince)}
+		t.downSince = time.Time{}
+	}
+	t.mu.Unlock()
+
+	if e != nil {
+		t.bus.Emit(ctx, e)
+	}
+}
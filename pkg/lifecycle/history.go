@@ -0,0 +1,52 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Record is an event as kept by History.
+type Record struct {
+	Time   time.Time         `json:"time"`
+	Kind   string            `json:"kind"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// History keeps the most recent events, such as dependency outages and job
+// failures, so they can be inspected after the fact.
+type History struct {
+	mu      sync.Mutex
+	records []Record
+	size    int
+}
+
+// NewHistory creates a history holding up to size events.
+func NewHistory(size int) *History {
+	return &History{size: max(size, 1)}
+}
+
+// Subscriber returns a subscriber recording events into h.
+func (h *History) Subscriber() Subscriber {
+	return func(_ context.Context, e Event) {
+		kv := e.Fields()
+		fields := make(map[string]string, len(kv)/2)
+		for i := 0; i+1 < len(kv); i += 2 {
+			fields[fmt.Sprint(kv[i])] = fmt.Sprint(kv[i+1])
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(h.records) == h.size {
+			h.records = append(h.records[:0], h.records[1:]...)
+		}
+		h.records = append(h.records, Record{Time: time.Now(), Kind: e.Kind(), Fields: fields})
+	}
+}
+
+// Records returns the recorded events, oldest first.
+func (h *History) Records() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Record(nil), h.records...)
+}
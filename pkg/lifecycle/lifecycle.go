@@ -0,0 +1,229 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is a typed service lifecycle event.
+type Event interface {
+	// Kind returns the stable event name used in logs and metrics.
+	Kind() string
+	// Fields returns the event attributes as log key-value pairs.
+	Fields() []any
+}
+
+// ServiceRegistered is emitted after the instance registered with the registry.
+type ServiceRegistered struct {
+	Name      string
+	ID        string
+	Endpoints []string
+}
+
+// ServiceDeregistered is emitted after the instance left the registry.
+type ServiceDeregistered struct {
+	Name      string
+	ID        string
+	Endpoints []string
+}
+
+// DependencyDown is emitted when a dependency (database, downstream service,
+// broker) becomes unavailable.
+type DependencyDown struct {
+	Name string
+	Err  error
+}
+
+// DependencyUp is emitted when a dependency that was down recovers.
+type DependencyUp struct {
+	Name     string
+	Downtime time.Duration
+}
+
+// ConfigReloaded is emitted after configuration was reloaded at runtime.
+type ConfigReloaded struct {
+	Source string
+}
+
+// JobStarted is emitted when a background job run starts.
+type JobStarted struct {
+	Job string
+}
+
+// JobFinished is emitted when a background job run completes.
+type JobFinished struct {
+	Job      string
+	Duration time.Duration
+	Err      error
+}
+
+// Reconciled is emitted after a reconciler run.
+type Reconciled struct {
+	Name    string
+	Created int
+	Updated int
+	Deleted int
+	Failed  int
+	Err     error
+}
+
+// MaintenanceReported is emitted after a data maintenance task, such as a
+// Redis key audit or a long transaction check, ran.
+type MaintenanceReported struct {
+	Task     string
+	Findings int // keys, tables or transactions needing attention
+	Err      error
+}
+
+// HeartbeatChecked is emitted after a canary message was published on the
+// event bus and either consumed or not within the threshold (Err set).
+type HeartbeatChecked struct {
+	Topic   string
+	Latency time.Duration // from publish to consumption
+	Err     error
+}
+
+// QuotaWarning is emitted when a quota subject reaches the warn ratio of a
+// limit, once per window period and instance. Zero limits are unlimited.
+type QuotaWarning struct {
+	Subject    string
+	Window     string
+	Used       int64
+	Limit      int64
+	UsedBytes  int64
+	LimitBytes int64
+	ResetAt    time.Time
+}
+
+// DegradationChanged is emitted when a dependency with a degradation policy
+// goes down, and the policy starts applying, or recovers.
+type DegradationChanged struct {
+	Dependency string
+	Mode       string // the policy, e.g. bypass or stale
+	Active     bool
+}
+
+// Changed reports whether the run applied or attempted any change.
+func (e Reconciled) Changed() bool {
+	return e.Created+e.Updated+e.Deleted+e.Failed > 0
+}
+
+func (ServiceRegistered) Kind() string   { return "service_registered" }
+func (ServiceDeregistered) Kind() string { return "service_deregistered" }
+func (DependencyDown) Kind() string      { return "dependency_down" }
+func (DependencyUp) Kind() string        { return "dependency_up" }
+func (ConfigReloaded) Kind() string      { return "config_reloaded" }
+func (JobStarted) Kind() string          { return "job_started" }
+func (JobFinished) Kind() string         { return "job_finished" }
+func (Reconciled) Kind() string          { return "reconciled" }
+func (MaintenanceReported) Kind() string { return "maintenance_reported" }
+func (HeartbeatChecked) Kind() string    { return "mq_heartbeat" }
+func (QuotaWarning) Kind() string        { return "quota_warning" }
+func (DegradationChanged) Kind() string  { return "degradation_changed" }
+
+func (e ServiceRegistered) Fields() []any {
+	return []any{"service", e.Name, "id", e.ID, "endpoints", e.Endpoints}
+}
+
+func (e ServiceDeregistered) Fields() []any {
+	return []any{"service", e.Name, "id", e.ID, "endpoints", e.Endpoints}
+}
+
+func (e DependencyDown) Fields() []any {
+	return []any{"dependency", e.Name, "error", errString(e.Err)}
+}
+
+func (e DependencyUp) Fields() []any {
+	return []any{"dependency", e.Name, "downtime", e.Downtime.String()}
+}
+
+func (e ConfigReloaded) Fields() []any {
+	return []any{"source", e.Source}
+}
+
+func (e JobStarted) Fields() []any {
+	return []any{"job", e.Job}
+}
+
+func (e JobFinished) Fields() []any {
+	return []any{"job", e.Job, "duration", e.Duration.String(), "error", errString(e.Err)}
+}
+
+func (e Reconciled) Fields() []any {
+	return []any{"reconciler", e.Name, "created", e.Created, "updated", e.Updated,
+		"deleted", e.Deleted, "failed", e.Failed, "error", errString(e.Err)}
+}
+
+func (e MaintenanceReported) Fields() []any {
+	return []any{"task", e.Task, "findings", e.Findings, "error", errString(e.Err)}
+}
+
+func (e HeartbeatChecked) Fields() []any {
+	return []any{"topic", e.Topic, "latency", e.Latency, "error", errString(e.Err)}
+}
+
+func (e QuotaWarning) Fields() []any {
+	return []any{"subject", e.Subject, "window", e.Window, "used", e.Used, "limit", e.Limit,
+		"used_bytes", e.UsedBytes, "limit_bytes", e.LimitBytes, "reset_at", e.ResetAt.Format(time.RFC3339)}
+}
+
+func (e DegradationChanged) Fields() []any {
+	return []any{"dependency", e.Dependency, "mode", e.Mode, "active", e.Active}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Subscriber handles events. It is called synchronously from Emit and must
+// not block.
+type Subscriber func(ctx context.Context, e Event)
+
+// Bus fans events out to subscribers in subscription order.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []Subscriber
+}
+
+// NewBus creates an empty bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds s to the bus.
+func (b *Bus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, s)
+}
+
+// Emit delivers e to all subscribers.
+func (b *Bus) Emit(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s(ctx, e)
+	}
+}
+
+var defaultBus = NewBus()
+
+// Default returns the process-wide bus used by Emit and Subscribe.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Emit delivers e through the default bus.
+func Emit(ctx context.Context, e Event) {
+	defaultBus.Emit(ctx, e)
+}
+
+// Subscribe adds s to the default bus.
+func Subscribe(s Subscriber) {
+	defaultBus.Subscribe(s)
+}
@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(b *Bus) *[]Event {
+	var got []Event
+	b.Subscribe(func(_ context.Context, e Event) { got = append(got, e) })
+	return &got
+}
+
+func TestBus_Emit(t *testing.T) {
+	b := NewBus()
+	first, second := collect(b), collect(b)
+
+	b.Emit(context.Background(), JobStarted{Job: "sync"})
+	assert.Equal(t, []Event{JobStarted{Job: "sync"}}, *first)
+	assert.Equal(t, []Event{JobStarted{Job: "sync"}}, *second)
+}
+
+func TestDependencyTracker(t *testing.T) {
+	b := NewBus()
+	got := collect(b)
+	tr := NewDependencyTracker(b, "mysql")
+	ctx := context.Background()
+	down := errors.New("connection refused")
+
+	tr.Observe(ctx, nil)
+	tr.Observe(ctx, down)
+	tr.Observe(ctx, down)
+	tr.Observe(ctx, nil)
+	tr.Observe(ctx, nil)
+
+	require.Len(t, *got, 2)
+	assert.Equal(t, DependencyDown{Name: "mysql", Err: down}, (*got)[0])
+	up, ok := (*got)[1].(DependencyUp)
+	require.True(t, ok)
+	assert.Equal(t, "mysql", up.Name)
+}
+
+func TestLogSubscriber(t *testing.T) {
+	var buf bytes.Buffer
+	sub := LogSubscriber(log.NewStdLogger(&buf))
+
+	sub(context.Background(), DependencyDown{Name: "redis", Err: errors.New("timeout")})
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "WARN"), out)
+	assert.Contains(t, out, "event=dependency_down")
+	assert.Contains(t, out, "dependency=redis")
+	assert.Contains(t, out, "error=timeout")
+}
+
+func TestHistory(t *testing.T) {
+	b := NewBus()
+	h := NewHistory(2)
+	b.Subscribe(h.Subscriber())
+
+	b.Emit(context.Background(), JobStarted{Job: "a"})
+	b.Emit(context.Background(), JobStarted{Job: "b"})
+	b.Emit(context.Background(), DependencyDown{Name: "mysql", Err: errors.New("timeout")})
+
+	records := h.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "b", records[0].Fields["job"])
+	assert.Equal(t, "dependency_down", records[1].Kind)
+	assert.Equal(t, "timeout", records[1].Fields["error"])
+}
@@ -0,0 +1,49 @@
+package lifecycle
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// LogSubscriber logs events with their fields. Failures (DependencyDown,
+// JobFinished, Reconciled or HeartbeatChecked with an error), maintenance
+// findings, quota warnings and active degradations are logged at warn level,
+// JobStarted, reconciler runs without changes and healthy heartbeats at debug.
+func LogSubscriber(logger log.Logger) Subscriber {
+	logger = log.With(logger, "module", "lifecycle")
+	return func(ctx context.Context, e Event) {
+		level := log.LevelInfo
+		switch ev := e.(type) {
+		case DependencyDown, QuotaWarning:
+			level = log.LevelWarn
+		case JobFinished:
+			if ev.Err != nil {
+				level = log.LevelWarn
+			}
+		case JobStarted:
+			level = log.LevelDebug
+		case Reconciled:
+			if ev.Err != nil {
+				level = log.LevelWarn
+			} else if !ev.Changed() {
+				level = log.LevelDebug
+			}
+		case MaintenanceReported:
+			if ev.Err != nil || ev.Findings > 0 {
+				level = log.LevelWarn
+			}
+		case HeartbeatChecked:
+			level = log.LevelDebug
+			if ev.Err != nil {
+				level = log.LevelWarn
+			}
+		case DegradationChanged:
+			if ev.Active {
+				level = log.LevelWarn
+			}
+		}
+		kv := append([]any{"event", e.Kind()}, e.Fields()...)
+		_ = log.WithContext(ctx, logger).Log(level, kv...)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build !nometrics
+
+package lifecycle
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsSubscriber records events as Prometheus metrics:
+//
+//	lifecycle_events_total{kind}
+//	dependency_up{dependency}
+//	job_duration_seconds{job, result}
+//	maintenance_findings{task}
+//	mq_end_to_end_healthy{topic}
+//	mq_end_to_end_latency_seconds{topic}
+//	quota_warnings_total{window}
+//	dependency_degraded{dependency, mode}
+func MetricsSubscriber(reg prometheus.Registerer) (Subscriber, error) {
+	events := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lifecycle_events_total",
+		Help: "Number of lifecycle events by kind.",
+	}, []string{"kind"})
+	dependencyUp := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dependency_up",
+		Help: "Whether a dependency is available (1) or down (0).",
+	}, []string{"dependency"})
+	jobDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_duration_seconds",
+		Help:    "Duration of background job runs.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"job", "result"})
+	maintenanceFindings := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maintenance_findings",
+		Help: "Findings of the last successful data maintenance task run.",
+	}, []string{"task"})
+	heartbeatHealthy := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mq_end_to_end_healthy",
+		Help: "Whether the last canary message was consumed within the threshold (1) or not (0).",
+	}, []string{"topic"})
+	heartbeatLatency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mq_end_to_end_latency_seconds",
+		Help:    "Time from publishing a canary message to its consumption.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+	quotaWarnings := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quota_warnings_total",
+		Help: "Number of quota subjects that reached the warn ratio of a limit, by window.",
+	}, []string{"window"})
+	degraded := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dependency_degraded",
+		Help: "Whether the degradation policy of a dependency applies (1) or not (0).",
+	}, []string{"dependency", "mode"})
+	for _, c := range []prometheus.Collector{events, dependencyUp, jobDuration, maintenanceFindings, heartbeatHealthy, heartbeatLatency, quotaWarnings, degraded} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(_ context.Context, e Event) {
+		events.WithLabelValues(e.Kind()).Inc()
+		switch ev := e.(type) {
+		case DependencyDown:
+			dependencyUp.WithLabelValues(ev.Name).Set(0)
+		case DependencyUp:
+			dependencyUp.WithLabelValues(ev.Name).Set(1)
+		case JobFinished:
+			result := "success"
+			if ev.Err != nil {
+				result = "failure"
+			}
+			jobDuration.WithLabelValues(ev.Job, result).Observe(ev.Duration.Seconds())
+		case MaintenanceReported:
+			if ev.Err == nil {
+				maintenanceFindings.WithLabelValues(ev.Task).Set(float64(ev.Findings))
+			}
+		case HeartbeatChecked:
+			if ev.Err != nil {
+				heartbeatHealthy.WithLabelValues(ev.Topic).Set(0)
+				return
+			}
+			heartbeatHealthy.WithLabelValues(ev.Topic).Set(1)
+			heartbeatLatency.WithLabelValues(ev.Topic).Observe(ev.Latency.Seconds())
+		case QuotaWarning:
+			quotaWarnings.WithLabelValues(ev.Window).Inc()
+		case DegradationChanged:
+			v := 0.0
+			if ev.Active {
+				v = 1
+			}
+			degraded.WithLabelValues(ev.Dependency, ev.Mode).Set(v)
+		}
+	}, nil
+}
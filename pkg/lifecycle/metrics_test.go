@@ -0,0 +1,82 @@
+//go:build !nometrics
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsSubscriber(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	sub, err := MetricsSubscriber(reg)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	sub(ctx, DependencyDown{Name: "redis"})
+	sub(ctx, JobFinished{Job: "sync", Duration: time.Second})
+	sub(ctx, JobFinished{Job: "sync", Duration: time.Second, Err: errors.New("boom")})
+
+	expected := `
+# HELP lifecycle_events_total Number of lifecycle events by kind.
+# TYPE lifecycle_events_total counter
+lifecycle_events_total{kind="dependency_down"} 1
+lifecycle_events_total{kind="job_finished"} 2
+# HELP dependency_up Whether a dependency is available (1) or down (0).
+# TYPE dependency_up gauge
+dependency_up{dependency="redis"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(expected), "lifecycle_events_total", "dependency_up"))
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "job_duration_seconds"))
+
+	sub(ctx, MaintenanceReported{Task: "redis_big_keys", Findings: 3})
+	sub(ctx, MaintenanceReported{Task: "redis_big_keys", Err: errors.New("timeout")})
+	expected = `
+# HELP maintenance_findings Findings of the last successful data maintenance task run.
+# TYPE maintenance_findings gauge
+maintenance_findings{task="redis_big_keys"} 3
+`
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(expected), "maintenance_findings"))
+
+	healthy := func(v string) string {
+		return `
+# HELP mq_end_to_end_healthy Whether the last canary message was consumed within the threshold (1) or not (0).
+# TYPE mq_end_to_end_healthy gauge
+mq_end_to_end_healthy{topic="mq_heartbeat"} ` + v + "\n"
+	}
+	sub(ctx, HeartbeatChecked{Topic: "mq_heartbeat", Latency: 20 * time.Millisecond})
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(healthy("1")), "mq_end_to_end_healthy"))
+	sub(ctx, HeartbeatChecked{Topic: "mq_heartbeat", Err: errors.New("canary not consumed")})
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(healthy("0")), "mq_end_to_end_healthy"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "mq_end_to_end_latency_seconds"))
+
+	sub(ctx, QuotaWarning{Subject: "tenant:acme", Window: "daily", Used: 80, Limit: 100})
+	expected = `
+# HELP quota_warnings_total Number of quota subjects that reached the warn ratio of a limit, by window.
+# TYPE quota_warnings_total counter
+quota_warnings_total{window="daily"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(expected), "quota_warnings_total"))
+
+	sub(ctx, DegradationChanged{Dependency: "redis", Mode: "bypass", Active: true})
+	sub(ctx, DegradationChanged{Dependency: "user-service", Mode: "stale", Active: true})
+	sub(ctx, DegradationChanged{Dependency: "user-service", Mode: "stale"})
+	expected = `
+# HELP dependency_degraded Whether the degradation policy of a dependency applies (1) or not (0).
+# TYPE dependency_degraded gauge
+dependency_degraded{dependency="redis",mode="bypass"} 1
+dependency_degraded{dependency="user-service",mode="stale"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(expected), "dependency_degraded"))
+
+	_, err = MetricsSubscriber(reg)
+	assert.Error(t, err, "duplicate registration")
+}
@@ -0,0 +1,194 @@
+// Package locale resolves the locale and currency of a request once, at the
+// service receiving it, and propagates them to the services it calls, so
+// that every service formats messages, dates and amounts alike.
+//
+// Formatting code reads them from the context:
+//
+//	p := message.NewPrinter(locale.Tag(ctx))
+//	amount := currency.MustParseISO(locale.Currency(ctx)).Amount(price)
+package locale
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+
+	md "github.com/go-kratos/kratos-layout/pkg/metadata"
+)
+
+// HeaderAcceptLanguage carries the languages preferred by the browser.
+const HeaderAcceptLanguage = "Accept-Language"
+
+// Defaults used when neither the request nor the profile of its user has
+// a preference.
+const (
+	DefaultLocale   = "en-US"
+	DefaultCurrency = "USD"
+)
+
+// Preference is the locale and currency of a request. Empty fields are
+// unset.
+type Preference struct {
+	Locale   string // BCP 47 tag, e.g. zh-CN
+	Currency string // ISO 4217 code, e.g. CNY
+}
+
+// ProfileFunc returns the preference stored in the profile of the user of
+// ctx, e.g. loaded by the authenticated user ID. It returns an empty
+// Preference when the user or the profile is unknown, or the lookup fails.
+type ProfileFunc func(ctx context.Context) Preference
+
+type options struct {
+	profile  ProfileFunc
+	defaults Preference
+}
+
+// Option configures Server.
+type Option func(*options)
+
+// WithProfile resolves the preferences the headers don't set from the
+// profile of the user.
+func WithProfile(f ProfileFunc) Option {
+	return func(o *options) { o.profile = f }
+}
+
+// WithDefault replaces DefaultLocale and DefaultCurrency; empty fields keep
+// them.
+func WithDefault(p Preference) Option {
+	return func(o *options) {
+		o.defaults.Locale = cmp.Or(p.Locale, o.defaults.Locale)
+		o.defaults.Currency = cmp.Or(p.Currency, o.defaults.Currency)
+	}
+}
+
+// Server resolves the locale and currency of incoming requests, in order
+// from:
+//
+//   - the x-md-locale and x-md-currency headers, set by the client or by
+//     the upstream service that resolved them;
+//   - the profile of the user, see WithProfile;
+//   - for the locale, the Accept-Language header, the browser's guess;
+//   - the defaults, see WithDefault.
+//
+// Unknown locales and currencies are skipped. The resolved ones are set in
+// the propagated metadata, which metadata.Client forwards downstream. List
+// it after metadata.
+func Server(opts ...Option) (middleware.Middleware, error) {
+	o := options{defaults: Preference{Locale: DefaultLocale, Currency: DefaultCurrency}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	defaults, err := Parse(o.defaults)
+	if err != nil {
+		return nil, fmt.Errorf("default preference: %w", err)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			return handler(NewContext(ctx, resolve(ctx, o.profile, defaults)), req)
+		}
+	}, nil
+}
+
+// resolve returns the preference of the request of ctx.
+func resolve(ctx context.Context, profile ProfileFunc, defaults Preference) Preference {
+	var p, accepted Preference
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		h := tr.RequestHeader()
+		p.Locale = parseLocale(h.Get(md.KeyLocale))
+		p.Currency = parseCurrency(h.Get(md.KeyCurrency))
+		if tags, _, err := language.ParseAcceptLanguage(h.Get(HeaderAcceptLanguage)); err == nil && len(tags) > 0 {
+			accepted.Locale = tags[0].String()
+		}
+	}
+	if (p.Locale == "" || p.Currency == "") && profile != nil {
+		u := profile(ctx)
+		p.Locale = cmp.Or(p.Locale, parseLocale(u.Locale))
+		p.Currency = cmp.Or(p.Currency, parseCurrency(u.Currency))
+	}
+	p.Locale = cmp.Or(p.Locale, accepted.Locale, defaults.Locale)
+	p.Currency = cmp.Or(p.Currency, defaults.Currency)
+	return p
+}
+
+// Parse canonicalizes the fields of p, e.g. zh_cn to zh-CN and cny to CNY.
+// Empty fields stay empty.
+func Parse(p Preference) (Preference, error) {
+	var out Preference
+	if p.Locale != "" {
+		tag, err := language.Parse(p.Locale)
+		if err != nil {
+			return Preference{}, fmt.Errorf("locale %q: %w", p.Locale, err)
+		}
+		out.Locale = tag.String()
+	}
+	if p.Currency != "" {
+		unit, err := currency.ParseISO(p.Currency)
+		if err != nil {
+			return Preference{}, fmt.Errorf("currency %q: %w", p.Currency, err)
+		}
+		out.Currency = unit.String()
+	}
+	return out, nil
+}
+
+func parseLocale(s string) string {
+	p, _ := Parse(Preference{Locale: s})
+	return p.Locale
+}
+
+func parseCurrency(s string) string {
+	p, _ := Parse(Preference{Currency: s})
+	return p.Currency
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying p, also set in the propagated metadata so
+// that calls made with ctx forward it. Use it for work not started by a
+// request, e.g. a job sending a user notification.
+func NewContext(ctx context.Context, p Preference) context.Context {
+	m, ok := metadata.FromServerContext(ctx)
+	if ok {
+		m = m.Clone()
+	} else {
+		m = metadata.New()
+	}
+	if p.Locale != "" {
+		m.Set(md.KeyLocale, p.Locale)
+	}
+	if p.Currency != "" {
+		m.Set(md.KeyCurrency, p.Currency)
+	}
+	return context.WithValue(metadata.NewServerContext(ctx, m), contextKey{}, p)
+}
+
+// FromContext returns the preference of ctx, and whether it was resolved
+// by Server or set by NewContext.
+func FromContext(ctx context.Context) (Preference, bool) {
+	p, ok := ctx.Value(contextKey{}).(Preference)
+	return p, ok
+}
+
+// Locale returns the locale of ctx, DefaultLocale when unset.
+func Locale(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return cmp.Or(p.Locale, DefaultLocale)
+}
+
+// Tag returns the locale of ctx as a language tag, e.g. for
+// message.NewPrinter.
+func Tag(ctx context.Context) language.Tag {
+	return language.Make(Locale(ctx))
+}
+
+// Currency returns the currency of ctx, DefaultCurrency when unset.
+func Currency(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return cmp.Or(p.Currency, DefaultCurrency)
+}
@@ -0,0 +1,96 @@
+package locale
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	md "github.com/go-kratos/kratos-layout/pkg/metadata"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	header headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return "/test" }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func serve(t *testing.T, header map[string]string, opts ...Option) (Preference, *testTransport) {
+	t.Helper()
+	in := &testTransport{header: headerCarrier{}}
+	for k, v := range header {
+		in.header.Set(k, v)
+	}
+	m, err := Server(opts...)
+	require.NoError(t, err)
+
+	var p Preference
+	out := &testTransport{header: headerCarrier{}}
+	_, err = md.Server()(m(func(ctx context.Context, _ any) (any, error) {
+		p, _ = FromContext(ctx)
+		cctx := transport.NewClientContext(ctx, out)
+		return md.Client()(func(context.Context, any) (any, error) { return nil, nil })(cctx, nil)
+	}))(transport.NewServerContext(context.Background(), in), nil)
+	require.NoError(t, err)
+	return p, out
+}
+
+func TestServer(t *testing.T) {
+	profile := WithProfile(func(context.Context) Preference {
+		return Preference{Locale: "ja-JP", Currency: "jpy"}
+	})
+
+	p, out := serve(t, map[string]string{"X-Md-Locale": "zh_cn", "X-Md-Currency": "cny", HeaderAcceptLanguage: "fr"}, profile)
+	assert.Equal(t, Preference{Locale: "zh-CN", Currency: "CNY"}, p, "headers come first")
+	assert.Equal(t, "zh-CN", out.header.Get(md.KeyLocale), "propagated downstream")
+	assert.Equal(t, "CNY", out.header.Get(md.KeyCurrency))
+
+	p, _ = serve(t, map[string]string{"X-Md-Currency": "EUR", HeaderAcceptLanguage: "fr"}, profile)
+	assert.Equal(t, Preference{Locale: "ja-JP", Currency: "EUR"}, p, "then the profile")
+
+	p, out = serve(t, map[string]string{"X-Md-Currency": "XYZ", HeaderAcceptLanguage: "fr-CH, en;q=0.8"},
+		WithDefault(Preference{Currency: "CHF"}))
+	assert.Equal(t, Preference{Locale: "fr-CH", Currency: "CHF"}, p, "then Accept-Language and the defaults")
+	assert.Equal(t, "CHF", out.header.Get(md.KeyCurrency), "unknown currencies are replaced")
+
+	p, _ = serve(t, nil)
+	assert.Equal(t, Preference{Locale: DefaultLocale, Currency: DefaultCurrency}, p)
+
+	_, err := Server(WithDefault(Preference{Currency: "dollars"}))
+	assert.Error(t, err)
+}
+
+func TestAccessors(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, DefaultLocale, Locale(ctx))
+	assert.Equal(t, DefaultCurrency, Currency(ctx))
+
+	ctx = NewContext(ctx, Preference{Locale: "de-DE", Currency: "EUR"})
+	assert.Equal(t, "de-DE", Locale(ctx))
+	assert.Equal(t, language.MustParse("de-DE"), Tag(ctx))
+	assert.Equal(t, "EUR", Currency(ctx))
+	assert.Equal(t, "de-DE", md.Locale(ctx), "set in the propagated metadata")
+	assert.Equal(t, "EUR", md.Currency(ctx))
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var _ log.Logger = (*Ring)(nil)
+
+// Ring keeps the most recent log lines in memory so they can be attached
+// to support bundles after the fact.
+type Ring struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewRing creates a ring holding up to size lines.
+func NewRing(size int) *Ring {
+	return &Ring{lines: make([]string, max(size, 1))}
+}
+
+// Log implements log.Logger.
+func (r *Ring) Log(level log.Level, keyvals ...interface{}) error {
+	var sb strings.Builder
+	sb.WriteString(time.Now().Format("2006-01-02 15:04:05.000"))
+	sb.WriteByte(' ')
+	sb.WriteString(level.String())
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&sb, " %v=%v", keyvals[i], keyvals[i+1])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines[r.next] = sb.String()
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Lines returns the buffered lines, oldest first.
+func (r *Ring) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.lines[:r.next]...)
+	}
+	return append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
+// Tee returns a logger writing to both next and r.
+func (r *Ring) Tee(next log.Logger) log.Logger {
+	return &tee{next: next, ring: r}
+}
+
+type tee struct {
+	next log.Logger
+	ring *Ring
+}
+
+func (t *tee) Log(level log.Level, keyvals ...interface{}) error {
+	_ = t.ring.Log(level, keyvals...)
+	return t.next.Log(level, keyvals...)
+}
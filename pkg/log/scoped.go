@@ -0,0 +1,109 @@
+package log
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Scoped hands out module-scoped helpers. Provide it once with wire and take
+// it in constructors instead of calling log.With in each of them:
+//
+//	func NewGreeterUsecase(repo GreeterRepo, logs *zapLog.Scoped) *GreeterUsecase {
+//		return &GreeterUsecase{repo: repo, log: logs.For("biz/greeter")}
+//	}
+type Scoped struct {
+	logger log.Logger
+}
+
+// NewScoped creates a Scoped on top of logger.
+func NewScoped(logger log.Logger) *Scoped {
+	return &Scoped{logger: logger}
+}
+
+// For returns a helper logging module=<module>, e.g. biz/greeter.
+func (s *Scoped) For(module string) *Helper {
+	l := log.With(s.logger, "module", module)
+	return &Helper{Helper: log.NewHelper(l), logger: l}
+}
+
+// Helper is a module-scoped log.Helper.
+type Helper struct {
+	*log.Helper
+	logger log.Logger
+}
+
+// Logger returns the module-scoped logger, for packages taking a log.Logger.
+func (h *Helper) Logger() log.Logger {
+	return h.logger
+}
+
+// FromContext returns a helper bound to ctx that also logs the request-scoped
+// fields attached to ctx with WithFields (see Server).
+func (h *Helper) FromContext(ctx context.Context) *log.Helper {
+	l := h.logger
+	if kv := Fields(ctx); len(kv) > 0 {
+		l = log.With(l, kv...)
+	}
+	return log.NewHelper(log.WithContext(ctx, l))
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a context carrying kv as request-scoped log fields in
+// addition to those already attached.
+func WithFields(ctx context.Context, kv ...any) context.Context {
+	prev := Fields(ctx)
+	fields := make([]any, 0, len(prev)+len(kv))
+	fields = append(append(fields, prev...), kv...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Fields returns the request-scoped log fields attached to ctx.
+func Fields(ctx context.Context) []any {
+	kv, _ := ctx.Value(fieldsKey{}).([]any)
+	return kv
+}
+
+// DefaultFieldKeys are the metadata keys Server logs when none are given.
+var DefaultFieldKeys = []string{"x-request-id", "x-md-tenant"}
+
+// Server is a middleware attaching the operation and the given incoming
+// metadata keys as request-scoped log fields. Keys are logged without their
+// x- / x-md- prefix and with underscores, e.g. x-request-id as request_id.
+// List it after the metadata middleware.
+func Server(keys ...string) middleware.Middleware {
+	if len(keys) == 0 {
+		keys = DefaultFieldKeys
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			var kv []any
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				kv = append(kv, "operation", tr.Operation())
+			}
+			if md, ok := metadata.FromServerContext(ctx); ok {
+				for _, k := range keys {
+					if v := md.Get(k); v != "" {
+						kv = append(kv, fieldName(k), v)
+					}
+				}
+			}
+			if len(kv) > 0 {
+				ctx = WithFields(ctx, kv...)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func fieldName(key string) string {
+	key = strings.ToLower(key)
+	key = strings.TrimPrefix(key, "x-md-")
+	key = strings.TrimPrefix(key, "x-")
+	return strings.ReplaceAll(key, "-", "_")
+}
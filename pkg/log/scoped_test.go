@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestScoped(t *testing.T) {
+	ring := NewRing(10)
+	h := NewScoped(ring).For("biz/greeter")
+
+	h.Info("plain")
+	ctx := WithFields(context.Background(), "request_id", "r1")
+	ctx = WithFields(ctx, "tenant", "t1")
+	h.FromContext(ctx).Info("scoped")
+
+	lines := ring.Lines()
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "module=biz/greeter")
+	assert.NotContains(t, lines[0], "request_id")
+	assert.Contains(t, lines[1], "module=biz/greeter request_id=r1 tenant=t1")
+}
+
+func TestServer(t *testing.T) {
+	md := metadata.New(map[string][]string{"x-request-id": {"r1"}, "x-md-tenant": {"t1"}, "x-md-lane": {"blue"}})
+	ctx := metadata.NewServerContext(context.Background(), md)
+
+	var fields []any
+	_, err := Server()(func(ctx context.Context, _ any) (any, error) {
+		fields = Fields(ctx)
+		return nil, nil
+	})(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"request_id", "r1", "tenant", "t1"}, fields)
+
+}
+
+func TestScopedCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	h := NewScoped(&ZapLogger{log: zap.New(core)}).For("test")
+
+	h.Info("plain")
+	_, _, line, _ := runtime.Caller(0)
+	h.FromContext(WithFields(context.Background(), "request_id", "r1")).Info("scoped")
+	_, _, ctxLine, _ := runtime.Caller(0)
+
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, fmt.Sprintf("pkg/log/scoped_test.go:%d", line-1), entries[0].ContextMap()["caller"])
+	assert.Equal(t, fmt.Sprintf("pkg/log/scoped_test.go:%d", ctxLine-1), entries[1].ContextMap()["caller"])
+	assert.Equal(t, "test", entries[0].ContextMap()["module"])
+}
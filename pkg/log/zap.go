@@ -10,14 +10,18 @@ import (
 	"github.com/go-kratos/kratos/v2/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
 )
 
 var _ log.Logger = (*ZapLogger)(nil)
 
 // ZapLogger is a logger impl.
 type ZapLogger struct {
-	log  *zap.Logger
-	Sync func() error
+	log   *zap.Logger
+	level zap.AtomicLevel
+	Sync  func() error
 }
 
 // NewZapLogger return a zap logger.
@@ -28,7 +32,12 @@ func NewZapLogger(encoder zapcore.Encoder, level zap.AtomicLevel, opts ...zap.Op
 			zapcore.AddSync(os.Stdout),
 		), level)
 	zapLogger := zap.New(core, opts...)
-	return &ZapLogger{log: zapLogger, Sync: zapLogger.Sync}
+	return &ZapLogger{log: zapLogger, level: level, Sync: zapLogger.Sync}
+}
+
+// SetLevel changes the minimum level logged, e.g. on a config reload.
+func (l *ZapLogger) SetLevel(lvl zapcore.Level) {
+	l.level.SetLevel(lvl)
 }
 
 // Log Implementation of logger interface.
@@ -42,6 +51,11 @@ func (l *ZapLogger) Log(level log.Level, keyvals ...interface{}) error {
 	// Zap.Field is used when keyvals pairs appear
 	for i := 0; i < len(keyvals); i += 2 {
 		data = append(data, zap.Any(fmt.Sprint(keyvals[i]), fmt.Sprint(keyvals[i+1])))
+		// Errors logged at error level also render their cause chain and
+		// the stack recorded with errdetail.WithStack.
+		if err, ok := keyvals[i+1].(error); ok && level >= log.LevelError {
+			data = append(data, errorFields(err)...)
+		}
 	}
 	switch level {
 	case log.LevelDebug:
@@ -58,8 +72,40 @@ func (l *ZapLogger) Log(level log.Level, keyvals ...interface{}) error {
 	return nil
 }
 
+func errorFields(err error) []zap.Field {
+	var fields []zap.Field
+	if causes := errdetail.Causes(err); len(causes) > 1 {
+		fields = append(fields, zap.String("causes", strings.Join(causes, " <- ")))
+	}
+	if stack := errdetail.StackTrace(err); stack != "" {
+		fields = append(fields, zap.String("stack", stack))
+	}
+	return fields
+}
+
+// InstanceFields attaches the service, version and deployment fields to
+// every entry, so log queries don't depend on agent-side enrichment. Empty
+// values are omitted.
+func InstanceFields(service, version string, d env.Deployment) zap.Option {
+	var fields []zap.Field
+	for _, f := range [][2]string{
+		{"service", service},
+		{"version", version},
+		{"env", d.Env},
+		{"region", d.Region},
+		{"pod", d.Pod},
+		{"namespace", d.Namespace},
+		{"node", d.Node},
+	} {
+		if f[1] != "" {
+			fields = append(fields, zap.String(f[0], f[1]))
+		}
+	}
+	return zap.Fields(fields...)
+}
+
 // InitDefaultLogger creates a console logger.
-func InitDefaultLogger(lvl zapcore.Level) *ZapLogger {
+func InitDefaultLogger(lvl zapcore.Level, opts ...zap.Option) *ZapLogger {
 	eConfig := zapcore.EncoderConfig{
 		TimeKey:        "t",
 		LevelKey:       "level",
@@ -75,12 +121,12 @@ func InitDefaultLogger(lvl zapcore.Level) *ZapLogger {
 	return NewZapLogger(
 		zapcore.NewConsoleEncoder(eConfig),
 		zap.NewAtomicLevelAt(lvl),
-		zap.AddStacktrace(zap.NewAtomicLevelAt(zapcore.ErrorLevel)),
+		append([]zap.Option{zap.AddStacktrace(zap.NewAtomicLevelAt(zapcore.ErrorLevel))}, opts...)...,
 	)
 }
 
 // InitJSONLogger creates a JSON logger.
-func InitJSONLogger(lvl zapcore.Level) *ZapLogger {
+func InitJSONLogger(lvl zapcore.Level, opts ...zap.Option) *ZapLogger {
 	eConfig := zap.NewProductionEncoderConfig()
 	eConfig.EncodeDuration = zapcore.SecondsDurationEncoder
 	eConfig.EncodeTime = timeEncoder
@@ -89,7 +135,7 @@ func InitJSONLogger(lvl zapcore.Level) *ZapLogger {
 	return NewZapLogger(
 		zapcore.NewJSONEncoder(eConfig),
 		zap.NewAtomicLevelAt(lvl),
-		zap.AddStacktrace(zap.NewAtomicLevelAt(zapcore.ErrorLevel)),
+		append([]zap.Option{zap.AddStacktrace(zap.NewAtomicLevelAt(zapcore.ErrorLevel))}, opts...)...,
 	)
 }
 
@@ -101,6 +147,7 @@ func timeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 var skipPatterns = []string{
 	"go-kratos/kratos",
 	"pkg/log/zap.go",
+	"pkg/log/ring.go",
 }
 
 // getCaller returns the caller information, skipping framework code.
@@ -1,11 +1,17 @@
 package log
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/go-kratos/kratos-layout/pkg/env"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
 )
 
 func TestZapLogger(t *testing.T) {
@@ -55,3 +61,56 @@ func TestCallerWithLogWith(t *testing.T) {
 	helper := log.NewHelper(withLogger)
 	helper.Info("this should show zap_test.go, not helper.go")
 }
+
+func TestRing(t *testing.T) {
+	ring := NewRing(2)
+	logger := ring.Tee(log.DefaultLogger)
+	_ = logger.Log(log.LevelInfo, "msg", "one")
+	_ = logger.Log(log.LevelWarn, "msg", "two")
+	_ = logger.Log(log.LevelError, "msg", "three")
+
+	lines := ring.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], "WARN msg=two") || !strings.HasSuffix(lines[1], "ERROR msg=three") {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	err := errdetail.Wrapf(errors.New("connection refused"), "save greeter")
+	fields := errorFields(err)
+	if len(fields) != 2 || fields[0].Key != "causes" || fields[1].Key != "stack" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+	if fields[0].String != "save greeter <- connection refused" {
+		t.Errorf("causes = %q", fields[0].String)
+	}
+	if !strings.Contains(fields[1].String, "TestErrorFields") {
+		t.Errorf("stack does not contain the caller: %s", fields[1].String)
+	}
+	if got := errorFields(errors.New("plain")); len(got) != 0 {
+		t.Errorf("plain error fields = %v", got)
+	}
+
+	logger := InitDefaultLogger(zapcore.DebugLevel)
+	log.NewHelper(logger).Errorw("msg", "save failed", "error", err)
+}
+
+func TestInstanceFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	d := env.Deployment{Env: "prod", Pod: "app-1", Node: "node-1"}
+	zap.New(core, InstanceFields("greeter", "1.2.0", d)).Info("hello")
+
+	got := logs.All()[0].ContextMap()
+	want := map[string]interface{}{"service": "greeter", "version": "1.2.0", "env": "prod", "pod": "app-1", "node": "node-1"}
+	if len(got) != len(want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	mmd "github.com/go-kratos/kratos/v2/middleware/metadata"
+)
+
+// Well-known propagated keys.
+const (
+	KeyRequestID     = "x-request-id"
+	KeyLane          = "x-md-lane"
+	KeyLocale        = "x-md-locale"
+	KeyCurrency      = "x-md-currency"
+	KeyTenant        = "x-md-tenant"
+	KeyAuthorization = "authorization"
+)
+
+// DefaultKeys is the allowlist used when no keys are configured.
+var DefaultKeys = []string{
+	KeyRequestID,
+	KeyLane,
+	KeyLocale,
+	KeyCurrency,
+	KeyTenant,
+	KeyAuthorization,
+}
+
+// normalizeKeys lowercases and trims keys, dropping empties and duplicates.
+// Falls back to DefaultKeys when nothing is left.
+func normalizeKeys(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, k)
+	}
+	if len(result) == 0 {
+		return DefaultKeys
+	}
+	return result
+}
+
+// Server extracts allowlisted headers (HTTP) or metadata (gRPC) from the
+// incoming request into the server context.
+// Keys are matched by case-insensitive prefix.
+func Server(keys ...string) middleware.Middleware {
+	return mmd.Server(mmd.WithPropagatedPrefix(normalizeKeys(keys)...))
+}
+
+// Client copies allowlisted keys from the server context into outgoing
+// requests, regardless of whether the downstream speaks HTTP or gRPC.
+func Client(keys ...string) middleware.Middleware {
+	return mmd.Client(mmd.WithPropagatedPrefix(normalizeKeys(keys)...))
+}
+
+// Get returns the first value of key from the server context.
+// Returns empty string if the key is not present.
+func Get(ctx context.Context, key string) string {
+	md, ok := metadata.FromServerContext(ctx)
+	if !ok {
+		return ""
+	}
+	return md.Get(key)
+}
+
+// RequestID returns the propagated request ID.
+func RequestID(ctx context.Context) string {
+	return Get(ctx, KeyRequestID)
+}
+
+// Lane returns the propagated traffic lane.
+func Lane(ctx context.Context) string {
+	return Get(ctx, KeyLane)
+}
+
+// Locale returns the propagated locale.
+func Locale(ctx context.Context) string {
+	return Get(ctx, KeyLocale)
+}
+
+// Currency returns the propagated currency.
+func Currency(ctx context.Context) string {
+	return Get(ctx, KeyCurrency)
+}
+
+// Tenant returns the propagated tenant ID.
+func Tenant(ctx context.Context) string {
+	return Get(ctx, KeyTenant)
+}
+
+var (
+	// ErrTenantMismatch is returned for an x-md-tenant other than the
+	// authenticated tenant.
+	ErrTenantMismatch = errors.New("x-md-tenant does not match the authenticated tenant")
+	// ErrTenantUnauthenticated is returned for an x-md-tenant without an
+	// authenticated tenant, as any client can set the header.
+	ErrTenantUnauthenticated = errors.New("x-md-tenant without an authenticated tenant")
+)
+
+type tenantClaimKey struct{}
+
+// WithTenantClaim returns a context carrying tenant as established by the
+// authentication of the request, e.g. the tenant claim of its verified JWT.
+// Call it from the auth middleware, or from a consumer trusting the tenant
+// of its messages.
+func WithTenantClaim(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantClaimKey{}, tenant)
+}
+
+// TenantClaim returns the tenant set with WithTenantClaim.
+func TenantClaim(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantClaimKey{}).(string)
+	return tenant, ok
+}
+
+// AuthenticatedTenant returns the tenant to authorize ctx for, e.g. to
+// route it to the database of the tenant: the tenant claim, which an
+// x-md-tenant header must match. A header without a claim is refused with
+// ErrTenantUnauthenticated; neither means no tenant, "".
+func AuthenticatedTenant(ctx context.Context) (string, error) {
+	header := Tenant(ctx)
+	claim, ok := TenantClaim(ctx)
+	switch {
+	case !ok && header != "":
+		return "", ErrTenantUnauthenticated
+	case ok && header != "" && header != claim:
+		return "", ErrTenantMismatch
+	}
+	return claim, nil
+}
@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	kind   transport.Kind
+	header headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return t.kind }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return "/test" }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestNormalizeKeys(t *testing.T) {
+	assert.Equal(t, DefaultKeys, normalizeKeys(nil))
+	assert.Equal(t, DefaultKeys, normalizeKeys([]string{" ", ""}))
+	assert.Equal(t, []string{"x-request-id", "x-md-"}, normalizeKeys([]string{"X-Request-ID", " x-md- ", "x-request-id"}))
+}
+
+func TestServerToClient(t *testing.T) {
+	in := &testTransport{kind: transport.KindHTTP, header: headerCarrier{}}
+	in.header.Set("X-Request-Id", "req-1")
+	in.header.Set("X-Md-Tenant", "t1")
+	in.header.Set("X-Other", "dropped")
+	ctx := transport.NewServerContext(context.Background(), in)
+
+	out := &testTransport{kind: transport.KindGRPC, header: headerCarrier{}}
+	_, err := Server()(func(ctx context.Context, _ any) (any, error) {
+		assert.Equal(t, "req-1", RequestID(ctx))
+		assert.Equal(t, "t1", Tenant(ctx))
+		assert.Equal(t, "", Get(ctx, "x-other"))
+
+		cctx := transport.NewClientContext(ctx, out)
+		return Client()(func(context.Context, any) (any, error) { return nil, nil })(cctx, nil)
+	})(ctx, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "req-1", out.header.Get("x-request-id"))
+	assert.Equal(t, "t1", out.header.Get("x-md-tenant"))
+	assert.Equal(t, "", out.header.Get("x-other"))
+}
+
+func TestAuthenticatedTenant(t *testing.T) {
+	header := func(tenant string) context.Context {
+		return metadata.NewServerContext(context.Background(), metadata.Metadata{KeyTenant: {tenant}})
+	}
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want string
+		err  error
+	}{
+		{"none", context.Background(), "", nil},
+		{"claim", WithTenantClaim(context.Background(), "acme"), "acme", nil},
+		{"matching header", WithTenantClaim(header("acme"), "acme"), "acme", nil},
+		{"other header", WithTenantClaim(header("globex"), "acme"), "", ErrTenantMismatch},
+		{"header only", header("acme"), "", ErrTenantUnauthenticated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := AuthenticatedTenant(tc.ctx)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
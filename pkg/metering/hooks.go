@@ -0,0 +1,64 @@
+package metering
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
+)
+
+// Meters recorded by the built-in hooks.
+const (
+	MeterRequests   = "api.requests"
+	MeterJobSeconds = "job.seconds"
+)
+
+// Server records one api.requests unit per successful request, attributed
+// to the subject returned by subject and tagged with the operation. The
+// request ID makes redelivered events share one ID.
+func Server(m *Meter, subject func(ctx context.Context) string) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			reply, err := handler(ctx, req)
+			if err != nil {
+				return reply, err
+			}
+			s := subject(ctx)
+			if s == "" {
+				return reply, nil
+			}
+			r := Record{Subject: s, Meter: MeterRequests, Quantity: 1, SourceID: metadata.RequestID(ctx)}
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				r.Attributes = map[string]string{"operation": tr.Operation()}
+				if r.SourceID != "" {
+					r.SourceID = tr.Operation() + "#" + r.SourceID
+				}
+			}
+			if err := m.Record(ctx, r); err != nil {
+				m.log.Warnf("record usage of %s: %v", s, err)
+			}
+			return reply, nil
+		}
+	}
+}
+
+// LifecycleSubscriber records the run time of finished background jobs as
+// job.seconds under the subject "job:<name>".
+func LifecycleSubscriber(m *Meter) lifecycle.Subscriber {
+	return func(ctx context.Context, e lifecycle.Event) {
+		ev, ok := e.(lifecycle.JobFinished)
+		if !ok {
+			return
+		}
+		r := Record{Subject: "job:" + ev.Job, Meter: MeterJobSeconds, Quantity: ev.Duration.Seconds()}
+		if ev.Err != nil {
+			r.Attributes = map[string]string{"result": "failed"}
+		}
+		if err := m.Record(ctx, r); err != nil {
+			m.log.Warnf("record usage of job %s: %v", ev.Job, err)
+		}
+	}
+}
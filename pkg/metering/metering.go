@@ -0,0 +1,293 @@
+// Package metering turns request and job activity into usage events for
+// billing. Events carry idempotent IDs so consumers can deduplicate
+// redeliveries, and are published through the transactional outbox.
+package metering
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	randv2 "math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+var _ transport.Server = (*Meter)(nil)
+
+// DefaultTopic is the topic usage events are published to.
+const DefaultTopic = "billing.usage"
+
+// Record is a single unit of billable activity.
+type Record struct {
+	Subject  string // tenant or API client being billed
+	Meter    string // what is measured, e.g. api.requests
+	Quantity float64
+	// SourceID identifies the activity, e.g. a request ID. Records with the
+	// same Subject, Meter and SourceID produce the same event ID.
+	SourceID   string
+	Attributes map[string]string
+	Time       time.Time
+}
+
+// UsageEvent is the body of a published usage event.
+type UsageEvent struct {
+	ID         string            `json:"id"`
+	Subject    string            `json:"subject"`
+	Meter      string            `json:"meter"`
+	Quantity   float64           `json:"quantity"`
+	Count      int64             `json:"count"` // number of records aggregated
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+}
+
+// Publisher stores events for publishing, e.g. *outbox.Outbox.
+type Publisher interface {
+	Add(ctx context.Context, events ...*eventbus.Event) error
+}
+
+// Option configures a Meter.
+type Option func(*options)
+
+type options struct {
+	topic      string
+	sampleRate float64
+	window     time.Duration
+	instance   string
+}
+
+// WithTopic sets the topic usage events are published to.
+func WithTopic(topic string) Option {
+	return func(o *options) {
+		if topic != "" {
+			o.topic = topic
+		}
+	}
+}
+
+// WithSampling keeps only a fraction rate (0, 1] of records and scales the
+// quantity of kept ones by 1/rate, so totals stay unbiased.
+func WithSampling(rate float64) Option {
+	return func(o *options) {
+		if rate > 0 && rate <= 1 {
+			o.sampleRate = rate
+		}
+	}
+}
+
+// WithAggregation sums records per subject, meter and attributes in memory
+// and publishes one event per window instead of one per record.
+// instance must be unique per process so event IDs don't collide.
+func WithAggregation(window time.Duration, instance string) Option {
+	return func(o *options) {
+		o.window = window
+		o.instance = instance
+	}
+}
+
+// Meter records usage and publishes it as events.
+// It implements transport.Server to flush aggregated usage periodically and
+// on shutdown; without aggregation Start and Stop do nothing.
+type Meter struct {
+	pub  Publisher
+	opts options
+	log  *log.Helper
+
+	mu      sync.Mutex
+	buckets map[string]*UsageEvent
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a meter publishing through pub.
+func New(pub Publisher, logger log.Logger, opts ...Option) *Meter {
+	o := options{topic: DefaultTopic, sampleRate: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Meter{
+		pub:     pub,
+		opts:    o,
+		log:     log.NewHelper(log.With(logger, "module", "pkg/metering")),
+		buckets: make(map[string]*UsageEvent),
+	}
+}
+
+// Record meters r. Without aggregation the event is added to the publisher
+// using ctx, so it commits together with the caller's transaction.
+func (m *Meter) Record(ctx context.Context, r Record) error {
+	if m == nil || r.Subject == "" || r.Meter == "" {
+		return nil
+	}
+	if m.opts.sampleRate < 1 {
+		if randv2.Float64() >= m.opts.sampleRate {
+			return nil
+		}
+		r.Quantity /= m.opts.sampleRate
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if m.opts.window > 0 {
+		m.aggregate(r)
+		return nil
+	}
+	return m.publish(ctx, &UsageEvent{
+		ID:         recordID(r),
+		Subject:    r.Subject,
+		Meter:      r.Meter,
+		Quantity:   r.Quantity,
+		Count:      1,
+		Attributes: r.Attributes,
+		Start:      r.Time,
+		End:        r.Time,
+	})
+}
+
+func (m *Meter) aggregate(r Record) {
+	key := aggregateKey(r)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.buckets[key]
+	if !ok {
+		e = &UsageEvent{
+			Subject:    r.Subject,
+			Meter:      r.Meter,
+			Attributes: r.Attributes,
+			Start:      r.Time,
+			End:        r.Time,
+		}
+		m.buckets[key] = e
+	}
+	e.Quantity += r.Quantity
+	e.Count++
+	if r.Time.Before(e.Start) {
+		e.Start = r.Time
+	}
+	if r.Time.After(e.End) {
+		e.End = r.Time
+	}
+}
+
+// Flush publishes aggregated usage. Events that fail to publish are kept
+// and retried on the next flush.
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	buckets := m.buckets
+	m.buckets = make(map[string]*UsageEvent)
+	m.mu.Unlock()
+
+	var firstErr error
+	for key, e := range buckets {
+		e.ID = hashID(m.opts.instance, key, e.Start.UTC().Format(time.RFC3339Nano))
+		if err := m.publish(ctx, e); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			m.mu.Lock()
+			if cur, ok := m.buckets[key]; ok {
+				cur.Quantity += e.Quantity
+				cur.Count += e.Count
+				cur.Start = e.Start
+			} else {
+				m.buckets[key] = e
+			}
+			m.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
+func (m *Meter) publish(ctx context.Context, e *UsageEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := m.pub.Add(ctx, &eventbus.Event{Topic: m.opts.topic, Key: e.Subject, Body: body}); err != nil {
+		return fmt.Errorf("publish usage event: %w", err)
+	}
+	return nil
+}
+
+// Start starts the periodic flush when aggregation is enabled.
+func (m *Meter) Start(context.Context) error {
+	if m.opts.window <= 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel, m.done = cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(m.opts.window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Flush(ctx); err != nil {
+					m.log.Errorf("flush usage: %v", err)
+				}
+			}
+		}
+	}(m.done)
+	return nil
+}
+
+// Stop stops the periodic flush and publishes the remaining usage.
+func (m *Meter) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return m.Flush(ctx)
+}
+
+func aggregateKey(r Record) string {
+	var sb strings.Builder
+	sb.WriteString(r.Subject)
+	sb.WriteByte('|')
+	sb.WriteString(r.Meter)
+	for _, k := range slices.Sorted(maps.Keys(r.Attributes)) {
+		sb.WriteByte('|')
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(r.Attributes[k])
+	}
+	return sb.String()
+}
+
+func recordID(r Record) string {
+	if r.SourceID == "" {
+		var b [16]byte
+		_, _ = rand.Read(b[:])
+		return hex.EncodeToString(b[:])
+	}
+	return hashID(r.Subject, r.Meter, r.SourceID)
+}
+
+func hashID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
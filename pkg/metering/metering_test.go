@@ -0,0 +1,123 @@
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []UsageEvent
+	fail   error
+}
+
+func (r *recorder) Add(_ context.Context, events ...*eventbus.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	for _, e := range events {
+		var ue UsageEvent
+		if err := json.Unmarshal(e.Body, &ue); err != nil {
+			return err
+		}
+		r.events = append(r.events, ue)
+	}
+	return nil
+}
+
+func TestMeter_IdempotentIDs(t *testing.T) {
+	rec := &recorder{}
+	m := New(rec, log.DefaultLogger)
+	ctx := context.Background()
+
+	r := Record{Subject: "tenant:acme", Meter: MeterRequests, Quantity: 1, SourceID: "req-1"}
+	require.NoError(t, m.Record(ctx, r))
+	require.NoError(t, m.Record(ctx, r))
+	require.NoError(t, m.Record(ctx, Record{Subject: "tenant:acme", Meter: MeterRequests, Quantity: 1}))
+	require.NoError(t, m.Record(ctx, Record{Meter: MeterRequests, Quantity: 1}), "records without subject are ignored")
+
+	require.Len(t, rec.events, 3)
+	assert.Equal(t, rec.events[0].ID, rec.events[1].ID)
+	assert.NotEqual(t, rec.events[0].ID, rec.events[2].ID)
+}
+
+func TestMeter_Aggregation(t *testing.T) {
+	rec := &recorder{}
+	m := New(rec, log.DefaultLogger, WithAggregation(time.Hour, "host-1"))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Record(ctx, Record{Subject: "tenant:acme", Meter: MeterRequests, Quantity: 1}))
+	}
+	require.NoError(t, m.Record(ctx, Record{Subject: "tenant:other", Meter: MeterRequests, Quantity: 2}))
+	assert.Empty(t, rec.events)
+
+	rec.fail = errors.New("db down")
+	assert.Error(t, m.Flush(ctx))
+	require.NoError(t, m.Record(ctx, Record{Subject: "tenant:acme", Meter: MeterRequests, Quantity: 1}))
+
+	rec.fail = nil
+	require.NoError(t, m.Start(ctx))
+	require.NoError(t, m.Stop(ctx))
+	require.Len(t, rec.events, 2)
+	total := map[string]float64{}
+	for _, e := range rec.events {
+		total[e.Subject] = e.Quantity
+	}
+	assert.Equal(t, map[string]float64{"tenant:acme": 4, "tenant:other": 2}, total)
+}
+
+func TestMeter_Sampling(t *testing.T) {
+	rec := &recorder{}
+	m := New(rec, log.DefaultLogger, WithSampling(0.5))
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, m.Record(context.Background(), Record{Subject: "s", Meter: "m", Quantity: 1}))
+	}
+	var sum float64
+	for _, e := range rec.events {
+		assert.Equal(t, 2.0, e.Quantity)
+		sum += e.Quantity
+	}
+	assert.InDelta(t, 1000, sum, 200)
+}
+
+func TestHooks(t *testing.T) {
+	rec := &recorder{}
+	m := New(rec, log.DefaultLogger)
+	ctx := metadata.NewServerContext(context.Background(), metadata.New(map[string][]string{"x-request-id": {"r-1"}}))
+
+	h := Server(m, func(context.Context) string { return "tenant:acme" })(func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	_, err := h(ctx, nil)
+	require.NoError(t, err)
+
+	failing := Server(m, func(context.Context) string { return "tenant:acme" })(func(context.Context, any) (any, error) {
+		return nil, errors.New("boom")
+	})
+	_, err = failing(ctx, nil)
+	require.Error(t, err)
+
+	LifecycleSubscriber(m)(ctx, lifecycle.JobFinished{Job: "sync", Duration: 1500 * time.Millisecond})
+	LifecycleSubscriber(m)(ctx, lifecycle.JobStarted{Job: "sync"})
+
+	require.Len(t, rec.events, 2)
+	assert.Equal(t, MeterRequests, rec.events[0].Meter)
+	assert.Equal(t, hashID("tenant:acme", MeterRequests, "r-1"), rec.events[0].ID)
+	assert.Equal(t, "job:sync", rec.events[1].Subject)
+	assert.Equal(t, 1.5, rec.events[1].Quantity)
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/logging"
+	"github.com/go-kratos/kratos/v2/middleware/ratelimit"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/middleware/selector"
+
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
+)
+
+// Factory builds a middleware from its options.
+type Factory func(options map[string]string) (middleware.Middleware, error)
+
+// Entry declares a middleware applied to the routes matched by Selectors.
+//
+// Selector syntax:
+//   - "/pkg.Service/Method"  exact operation
+//   - "/pkg.Service/*"       operation prefix
+//   - "re:<regex>"           operation regex
+//
+// An entry without selectors applies to every route.
+type Entry struct {
+	Name      string
+	Selectors []string
+	Options   map[string]string
+}
+
+// Registry resolves middleware names to factories.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates a registry with the built-in middlewares:
+// recovery, metadata, logging, errorlog and ratelimit.
+func NewRegistry(logger log.Logger) *Registry {
+	r := &Registry{factories: map[string]Factory{}}
+	r.Register("recovery", func(map[string]string) (middleware.Middleware, error) {
+		return recovery.Recovery(), nil
+	})
+	r.Register("metadata", func(opts map[string]string) (middleware.Middleware, error) {
+		return metadata.Server(splitList(opts["propagate_keys"])...), nil
+	})
+	r.Register("logging", func(map[string]string) (middleware.Middleware, error) {
+		return logging.Server(logger), nil
+	})
+	// errorlog logs failed requests only, with cause chain and stack for 5xx.
+	r.Register("errorlog", func(map[string]string) (middleware.Middleware, error) {
+		return errdetail.Server(logger), nil
+	})
+	r.Register("ratelimit", func(map[string]string) (middleware.Middleware, error) {
+		return ratelimit.Server(), nil
+	})
+	return r
+}
+
+// Register adds or replaces a factory.
+func (r *Registry) Register(name string, f Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = f
+}
+
+// Build resolves entries in order into a middleware chain.
+func (r *Registry) Build(entries []Entry) ([]middleware.Middleware, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]middleware.Middleware, 0, len(entries))
+	for _, e := range entries {
+		f, ok := r.factories[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("middleware %q is not registered", e.Name)
+		}
+		m, err := f(e.Options)
+		if err != nil {
+			return nil, fmt.Errorf("build middleware %q: %w", e.Name, err)
+		}
+		if len(e.Selectors) > 0 {
+			match, err := NewMatcher(e.Selectors)
+			if err != nil {
+				return nil, fmt.Errorf("middleware %q: %w", e.Name, err)
+			}
+			m = selector.Server(m).Match(func(_ context.Context, operation string) bool {
+				return match(operation)
+			}).Build()
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Names returns the names of the entries that apply to operation, in order.
+// Invalid selectors never match.
+func Names(entries []Entry, operation string) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if len(e.Selectors) > 0 {
+			match, err := NewMatcher(e.Selectors)
+			if err != nil || !match(operation) {
+				continue
+			}
+		}
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// NewMatcher compiles selectors (see Entry) into an operation matcher.
+func NewMatcher(selectors []string) (func(operation string) bool, error) {
+	var (
+		paths    []string
+		prefixes []string
+		regexes  []*regexp.Regexp
+	)
+	for _, s := range selectors {
+		switch {
+		case strings.HasPrefix(s, "re:"):
+			re, err := regexp.Compile(strings.TrimPrefix(s, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+			}
+			regexes = append(regexes, re)
+		case strings.HasSuffix(s, "*"):
+			prefixes = append(prefixes, strings.TrimSuffix(s, "*"))
+		default:
+			paths = append(paths, s)
+		}
+	}
+	return func(operation string) bool {
+		for _, p := range paths {
+			if operation == p {
+				return true
+			}
+		}
+		for _, p := range prefixes {
+			if strings.HasPrefix(operation, p) {
+				return true
+			}
+		}
+		for _, re := range regexes {
+			if re.MatchString(operation) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// splitList splits a comma-separated option value, dropping empty items.
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind { return transport.KindGRPC }
+func (t *testTransport) Operation() string    { return t.operation }
+
+func TestRegistry_Build(t *testing.T) {
+	r := NewRegistry(log.DefaultLogger)
+	var calls []string
+	r.Register("trace", func(opts map[string]string) (middleware.Middleware, error) {
+		return func(h middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req any) (any, error) {
+				calls = append(calls, opts["tag"])
+				return h(ctx, req)
+			}
+		}, nil
+	})
+
+	chain, err := r.Build([]Entry{
+		{Name: "recovery"},
+		{Name: "trace", Options: map[string]string{"tag": "all"}},
+		{Name: "trace", Selectors: []string{"/helloworld.v1.Greeter/*"}, Options: map[string]string{"tag": "greeter"}},
+		{Name: "trace", Selectors: []string{"re:^/admin\\."}, Options: map[string]string{"tag": "admin"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, chain, 4)
+
+	h := middleware.Chain(chain...)(func(context.Context, any) (any, error) { return "ok", nil })
+	for _, op := range []string{"/helloworld.v1.Greeter/SayHello", "/admin.v1.Ops/Flush"} {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{operation: op})
+		_, err := h(ctx, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"all", "greeter", "all", "admin"}, calls)
+}
+
+func TestRegistry_BuildErrors(t *testing.T) {
+	r := NewRegistry(log.DefaultLogger)
+
+	_, err := r.Build([]Entry{{Name: "unknown"}})
+	assert.ErrorContains(t, err, `"unknown" is not registered`)
+
+	_, err = r.Build([]Entry{{Name: "recovery", Selectors: []string{"re:("}}})
+	assert.ErrorContains(t, err, "invalid selector")
+}
+
+func TestNames(t *testing.T) {
+	entries := []Entry{
+		{Name: "recovery"},
+		{Name: "ratelimit", Selectors: []string{"/helloworld.v1.Greeter/SayHello"}},
+		{Name: "logging", Selectors: []string{"/report.v1.*"}},
+	}
+	assert.Equal(t, []string{"recovery", "ratelimit"}, Names(entries, "/helloworld.v1.Greeter/SayHello"))
+	assert.Equal(t, []string{"recovery", "logging"}, Names(entries, "/report.v1.Report/Export"))
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, splitList(" a, ,b "))
+	assert.Nil(t, splitList(""))
+}
@@ -0,0 +1,269 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Audit actions.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditLog is one row change recorded by AuditTrail.
+type AuditLog struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `gorm:"not null;index;index:idx_audit_logs_action_created_at,priority:2"`
+	Actor     string    `gorm:"size:128;not null;default:'';index"`
+	Action    string    `gorm:"size:16;not null;index:idx_audit_logs_action_created_at,priority:1"`
+	Entity    string    `gorm:"size:128;not null;index:idx_audit_logs_entity"`
+	// EntityID is the primary key of the row; empty for statements that
+	// were not on a loaded model, e.g. Where(...).Updates(...).
+	EntityID string `gorm:"size:64;not null;default:'';index:idx_audit_logs_entity"`
+	// Before and After hold the changed columns as JSON objects: all columns
+	// of created and deleted rows, only the differing ones of updated rows.
+	Before []byte `gorm:"type:text"`
+	After  []byte `gorm:"type:text"`
+}
+
+// TableName implements gorm's tabler.
+func (AuditLog) TableName() string { return "audit_logs" }
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are attributed to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+const auditBeforeKey = "orm:audit_before"
+
+// auditRedacted replaces the values of encrypted fields.
+const auditRedacted = "[redacted]"
+
+// AuditTrail is a gorm plugin writing an AuditLog for every create, update
+// and delete, in the statement's transaction when it runs in one.
+//
+// Updates and deletes of a loaded model (with its primary key set) reload
+// the row to record what changed; other statements are recorded without
+// Before and with the assigned values as After. Fields tagged
+// orm:"encrypted" are redacted.
+type AuditTrail struct {
+	// Tables to audit; empty audits every table.
+	Tables []string
+	// Actor returns who made the change; nil uses ActorFromContext.
+	Actor func(ctx context.Context) string
+}
+
+// Name implements gorm.Plugin.
+func (AuditTrail) Name() string { return "orm:audit_trail" }
+
+// Initialize implements gorm.Plugin.
+func (p AuditTrail) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("orm:audit_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("orm:audit_update_before", p.loadBefore); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("orm:audit_update", p.after(AuditUpdate)); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("orm:audit_delete_before", p.loadBefore); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("orm:audit_delete", p.after(AuditDelete))
+}
+
+func (p AuditTrail) audited(db *gorm.DB) bool {
+	s := db.Statement.Schema
+	if s == nil || s.Table == (AuditLog{}).TableName() || db.Statement.DryRun {
+		return false
+	}
+	return len(p.Tables) == 0 || slices.Contains(p.Tables, s.Table)
+}
+
+func (p AuditTrail) actor(ctx context.Context) string {
+	if p.Actor != nil {
+		return p.Actor(ctx)
+	}
+	return ActorFromContext(ctx)
+}
+
+// primaryKey returns the primary key of the model rv as a string, or false
+// when it is not set.
+func primaryKey(ctx context.Context, s *schema.Schema, rv reflect.Value) (any, string, bool) {
+	f := s.PrioritizedPrimaryField
+	if f == nil || rv.Kind() != reflect.Struct || rv.Type() != s.ModelType {
+		return nil, "", false
+	}
+	v, zero := f.ValueOf(ctx, rv)
+	if zero {
+		return nil, "", false
+	}
+	return v, fmt.Sprint(v), true
+}
+
+// columns returns the column values of the model rv.
+func columns(ctx context.Context, s *schema.Schema, rv reflect.Value) map[string]any {
+	m := make(map[string]any, len(s.DBNames))
+	for _, f := range s.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		if f.Tag.Get("orm") == "encrypted" {
+			m[f.DBName] = auditRedacted
+			continue
+		}
+		v, _ := f.ValueOf(ctx, rv)
+		m[f.DBName] = v
+	}
+	return m
+}
+
+// reload reads the current columns of the row with primary key pk.
+func reload(db *gorm.DB, pk any) (map[string]any, error) {
+	s := db.Statement.Schema
+	row := reflect.New(s.ModelType)
+	err := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Where(clause.Eq{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Value: pk}).
+		Take(row.Interface()).Error
+	if err != nil {
+		return nil, err
+	}
+	return columns(db.Statement.Context, s, row.Elem()), nil
+}
+
+func (p AuditTrail) afterCreate(db *gorm.DB) {
+	if db.Error != nil || !p.audited(db) {
+		return
+	}
+	ctx, s := db.Statement.Context, db.Statement.Schema
+	var logs []AuditLog
+	record := func(rv reflect.Value) {
+		_, id, _ := primaryKey(ctx, s, rv)
+		logs = append(logs, p.entry(ctx, db, AuditCreate, id, nil, columns(ctx, s, rv)))
+	}
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			record(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		record(rv)
+	}
+	p.write(db, logs)
+}
+
+func (p AuditTrail) loadBefore(db *gorm.DB) {
+	if db.Error != nil || !p.audited(db) {
+		return
+	}
+	pk, _, ok := primaryKey(db.Statement.Context, db.Statement.Schema, db.Statement.ReflectValue)
+	if !ok {
+		return
+	}
+	before, err := reload(db, pk)
+	if err != nil {
+		// A missing row changes nothing and is recorded like a statement
+		// without a loaded model.
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			db.AddError(fmt.Errorf("audit: load row: %w", err))
+		}
+		return
+	}
+	db.InstanceSet(auditBeforeKey, before)
+}
+
+func (p AuditTrail) after(action string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.RowsAffected == 0 || !p.audited(db) {
+			return
+		}
+		ctx := db.Statement.Context
+		v, loaded := db.InstanceGet(auditBeforeKey)
+		if !loaded {
+			var after map[string]any
+			if m, ok := db.Statement.Dest.(map[string]any); ok && action == AuditUpdate {
+				after = m
+			}
+			p.write(db, []AuditLog{p.entry(ctx, db, action, "", nil, after)})
+			return
+		}
+		before := v.(map[string]any)
+		pk, id, _ := primaryKey(ctx, db.Statement.Schema, db.Statement.ReflectValue)
+		if action == AuditDelete {
+			p.write(db, []AuditLog{p.entry(ctx, db, action, id, before, nil)})
+			return
+		}
+		after, err := reload(db, pk)
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: reload row: %w", err))
+			return
+		}
+		before, after = diff(before, after)
+		if len(after) == 0 {
+			return
+		}
+		p.write(db, []AuditLog{p.entry(ctx, db, action, id, before, after)})
+	}
+}
+
+// diff keeps the columns whose values differ between before and after.
+func diff(before, after map[string]any) (map[string]any, map[string]any) {
+	b, a := make(map[string]any), make(map[string]any)
+	for k, av := range after {
+		bv := before[k]
+		bj, _ := json.Marshal(bv)
+		aj, _ := json.Marshal(av)
+		if string(bj) != string(aj) {
+			b[k], a[k] = bv, av
+		}
+	}
+	return b, a
+}
+
+func (p AuditTrail) entry(ctx context.Context, db *gorm.DB, action, id string, before, after map[string]any) AuditLog {
+	l := AuditLog{
+		Actor:    p.actor(ctx),
+		Action:   action,
+		Entity:   db.Statement.Schema.Table,
+		EntityID: id,
+	}
+	if before != nil {
+		l.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		l.After, _ = json.Marshal(after)
+	}
+	return l
+}
+
+// write inserts logs on the statement's connection, i.e. inside its
+// transaction when there is one, so a rolled back change leaves no log.
+func (p AuditTrail) write(db *gorm.DB, logs []AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: write log: %w", err))
+	}
+}
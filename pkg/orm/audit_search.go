@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"iter"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter selects audit logs for SearchAuditLogs and StreamAuditLogs.
+// Zero fields match everything.
+type AuditFilter struct {
+	Actor    string
+	Action   string // AuditCreate, AuditUpdate, AuditDelete or "admin"
+	Entity   string // table, or the method and path of admin requests
+	EntityID string
+	Since    time.Time // inclusive
+	Until    time.Time // exclusive
+	// Text matches a substring of the recorded values (Before or After) or
+	// the entity ID. It is not indexed, so bound it with the other filters,
+	// e.g. a time range, on large tables.
+	Text string
+}
+
+// Scope applies f to a query of audit_logs; the other filters use the
+// indexes of AuditLog.
+func (f AuditFilter) Scope(db *gorm.DB) *gorm.DB {
+	if f.Actor != "" {
+		db = db.Where("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.Entity != "" {
+		db = db.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != "" {
+		db = db.Where("entity_id = ?", f.EntityID)
+	}
+	if !f.Since.IsZero() {
+		db = db.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		db = db.Where("created_at < ?", f.Until)
+	}
+	if f.Text != "" {
+		like := "%" + likeEscaper.Replace(f.Text) + "%"
+		db = db.Where("(`before` LIKE ? ESCAPE '!' OR `after` LIKE ? ESCAPE '!' OR entity_id = ?)", like, like, f.Text)
+	}
+	return db
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern with !, which needs
+// no escaping in SQL strings unlike the default backslash.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// SearchAuditLogs loads the page of the audit logs matching f that follows
+// cursor, newest first (keyset pagination on the ID).
+func SearchAuditLogs(db *gorm.DB, f AuditFilter, cursor string, size int) (Page[AuditLog], error) {
+	p := Page[AuditLog]{Total: -1}
+	size = pageSize(size)
+	q := db.Model(&AuditLog{}).Scopes(f.Scope).Order("id DESC").Limit(size + 1)
+	if cursor != "" {
+		before, err := decodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		q = q.Where("id < ?", before)
+	}
+	if err := q.Find(&p.Items).Error; err != nil {
+		return p, err
+	}
+	if len(p.Items) > size {
+		p.Items = p.Items[:size]
+		next, err := encodeCursor(p.Items[size-1].ID)
+		if err != nil {
+			return p, err
+		}
+		p.NextCursor = next
+	}
+	return p, nil
+}
+
+// StreamAuditLogs iterates all the audit logs matching f, oldest first, for
+// exports; see StreamRows.
+func StreamAuditLogs(db *gorm.DB, f AuditFilter) iter.Seq2[AuditLog, error] {
+	return StreamRows[AuditLog](db.Scopes(f.Scope).Order("id"))
+}
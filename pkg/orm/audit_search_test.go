@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+func TestSearchAuditLogs(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&AuditLog{}))
+	base := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	logs := []AuditLog{
+		{CreatedAt: base, Actor: "alice", Action: AuditCreate, Entity: "accounts", EntityID: "1", After: []byte(`{"owner":"alice"}`)},
+		{CreatedAt: base.Add(time.Hour), Actor: "bob", Action: AuditUpdate, Entity: "accounts", EntityID: "1", Before: []byte(`{"plan":"free"}`), After: []byte(`{"plan":"pro_100%"}`)},
+		{CreatedAt: base.Add(2 * time.Hour), Actor: "alice", Action: AuditDelete, Entity: "accounts", EntityID: "2", Before: []byte(`{"owner":"carol"}`)},
+		{CreatedAt: base.Add(3 * time.Hour), Actor: "admin:oncall", Action: "admin", Entity: "POST /admin/runbook"},
+	}
+	require.NoError(t, db.Create(&logs).Error)
+
+	ids := func(p Page[AuditLog]) []uint64 {
+		var ids []uint64
+		for _, l := range p.Items {
+			ids = append(ids, l.ID)
+		}
+		return ids
+	}
+	search := func(f AuditFilter) []uint64 {
+		p, err := SearchAuditLogs(db, f, "", 0)
+		require.NoError(t, err)
+		return ids(p)
+	}
+
+	assert.Equal(t, []uint64{4, 3, 2, 1}, search(AuditFilter{}), "newest first")
+	assert.Equal(t, []uint64{3, 1}, search(AuditFilter{Actor: "alice"}))
+	assert.Equal(t, []uint64{2}, search(AuditFilter{Action: AuditUpdate, Entity: "accounts", EntityID: "1"}))
+	assert.Equal(t, []uint64{3, 2}, search(AuditFilter{Since: base.Add(time.Hour), Until: base.Add(3 * time.Hour)}))
+	assert.Equal(t, []uint64{3}, search(AuditFilter{Text: "carol"}), "in the recorded values")
+	assert.Equal(t, []uint64{3}, search(AuditFilter{Text: "2"}), "or the entity ID")
+	assert.Equal(t, []uint64{2}, search(AuditFilter{Text: "pro_100%"}))
+	assert.Empty(t, search(AuditFilter{Text: "pro_1000"}), "wildcards are literal")
+
+	p, err := SearchAuditLogs(db, AuditFilter{}, "", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3, 2}, ids(p))
+	assert.Equal(t, int64(-1), p.Total)
+	require.NotEmpty(t, p.NextCursor)
+	p, err = SearchAuditLogs(db, AuditFilter{}, p.NextCursor, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1}, ids(p))
+	assert.Empty(t, p.NextCursor)
+	_, err = SearchAuditLogs(db, AuditFilter{}, "!", 3)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	var exported []uint64
+	for l, err := range StreamAuditLogs(db, AuditFilter{Entity: "accounts"}) {
+		require.NoError(t, err)
+		exported = append(exported, l.ID)
+	}
+	assert.Equal(t, []uint64{1, 2, 3}, exported, "oldest first")
+}
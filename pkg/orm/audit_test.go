@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type auditedAccount struct {
+	BaseModel
+	Owner   string
+	Balance int64
+	Phone   string `orm:"encrypted"`
+}
+
+type unauditedNote struct {
+	ID   uint64
+	Text string
+}
+
+func TestAuditTrail(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(AuditTrail{Tables: []string{"audited_accounts"}}))
+	require.NoError(t, db.AutoMigrate(&auditedAccount{}, &unauditedNote{}, &AuditLog{}))
+	ctx := WithActor(context.Background(), "alice")
+	db = db.WithContext(ctx)
+
+	logs := func() []AuditLog {
+		var logs []AuditLog
+		require.NoError(t, db.Order("id").Find(&logs).Error)
+		return logs
+	}
+	object := func(data []byte) map[string]any {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(data, &m))
+		return m
+	}
+
+	acc := &auditedAccount{Owner: "alice", Balance: 100, Phone: "13800000000"}
+	require.NoError(t, db.Create(acc).Error)
+	require.NoError(t, db.Create(&unauditedNote{Text: "not audited"}).Error)
+	got := logs()
+	require.Len(t, got, 1)
+	assert.Equal(t, "alice", got[0].Actor)
+	assert.Equal(t, AuditCreate, got[0].Action)
+	assert.Equal(t, "audited_accounts", got[0].Entity)
+	assert.Equal(t, "1", got[0].EntityID)
+	assert.Nil(t, got[0].Before)
+	after := object(got[0].After)
+	assert.Equal(t, float64(100), after["balance"])
+	assert.Equal(t, auditRedacted, after["phone"])
+
+	acc.Balance = 70
+	require.NoError(t, db.Save(acc).Error)
+	got = logs()
+	require.Len(t, got, 2)
+	assert.Equal(t, AuditUpdate, got[1].Action)
+	assert.Equal(t, "1", got[1].EntityID)
+	assert.Equal(t, float64(100), object(got[1].Before)["balance"])
+	assert.Equal(t, float64(70), object(got[1].After)["balance"])
+	assert.NotContains(t, object(got[1].After), "owner", "unchanged columns are left out")
+
+	require.NoError(t, db.Model(&auditedAccount{}).Where("owner = ?", "alice").Update("balance", 50).Error)
+	got = logs()
+	require.Len(t, got, 3)
+	assert.Empty(t, got[2].EntityID)
+	assert.Nil(t, got[2].Before)
+	assert.Equal(t, float64(50), object(got[2].After)["balance"])
+
+	rollback := errors.New("rollback")
+	require.ErrorIs(t, db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Delete(acc).Error)
+		return rollback
+	}), rollback)
+	assert.Len(t, logs(), 3, "a rolled back change leaves no log")
+
+	require.NoError(t, db.Delete(acc).Error)
+	got = logs()
+	require.Len(t, got, 4)
+	assert.Equal(t, AuditDelete, got[3].Action)
+	assert.Equal(t, float64(50), object(got[3].Before)["balance"])
+	assert.Nil(t, got[3].After)
+
+	require.NoError(t, db.Where("id = ?", 999).Delete(&auditedAccount{}).Error)
+	assert.Len(t, logs(), 4, "statements changing no row are not logged")
+}
@@ -0,0 +1,69 @@
+package orm
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// ClearOption configures ClearAllData.
+type ClearOption func(*clearOptions)
+
+type clearOptions struct {
+	truncate bool
+	only     []string
+	except   []string
+}
+
+// WithTruncate clears tables with TRUNCATE instead of DELETE, which is
+// faster on large tables and resets AUTO_INCREMENT. On MySQL, foreign key
+// checks are disabled while truncating. ClickHouse always truncates.
+func WithTruncate() ClearOption {
+	return func(o *clearOptions) { o.truncate = true }
+}
+
+// WithOnlyTables clears only the given tables.
+func WithOnlyTables(tables ...string) ClearOption {
+	return func(o *clearOptions) { o.only = append(o.only, tables...) }
+}
+
+// WithPreservedTables keeps the data of the given tables, e.g.
+// schema_migrations or seeded lookup tables.
+func WithPreservedTables(tables ...string) ClearOption {
+	return func(o *clearOptions) { o.except = append(o.except, tables...) }
+}
+
+func newClearOptions(opts []ClearOption) clearOptions {
+	var o clearOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// tables filters tables by the allowlist and the preserved tables.
+func (o clearOptions) tables(tables []string) []string {
+	return slices.DeleteFunc(slices.Clone(tables), func(t string) bool {
+		return t == "" || (len(o.only) > 0 && !slices.Contains(o.only, t)) || slices.Contains(o.except, t)
+	})
+}
+
+// clearTables empties tables on conn, which must be a single connection
+// when truncating so that FOREIGN_KEY_CHECKS applies to every statement.
+func clearTables(conn *gorm.DB, tables []string, truncate bool) error {
+	stmt := "DELETE FROM "
+	if truncate {
+		stmt = "TRUNCATE TABLE "
+		if err := conn.Exec("SET FOREIGN_KEY_CHECKS=0").Error; err != nil {
+			return fmt.Errorf("disable foreign key checks failed: %w", err)
+		}
+		defer conn.Exec("SET FOREIGN_KEY_CHECKS=1")
+	}
+	for _, t := range tables {
+		if err := conn.Exec(stmt + quoteIdentifier(t)).Error; err != nil {
+			return fmt.Errorf("clear data from table %s failed: %w", t, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+func TestClearOptions_Tables(t *testing.T) {
+	tables := []string{"users", "orders", "schema_migrations", ""}
+	assert.Equal(t, []string{"users", "orders", "schema_migrations"}, newClearOptions(nil).tables(tables))
+	assert.Equal(t, []string{"users", "orders"},
+		newClearOptions([]ClearOption{WithPreservedTables("schema_migrations")}).tables(tables))
+	assert.Equal(t, []string{"orders"},
+		newClearOptions([]ClearOption{WithOnlyTables("orders", "users"), WithPreservedTables("users")}).tables(tables))
+	assert.Equal(t, "users", tables[0], "the input is not modified")
+	assert.True(t, newClearOptions([]ClearOption{WithTruncate()}).truncate)
+}
+
+func TestClearTables(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Exec("CREATE TABLE a (id INTEGER)").Error)
+	require.NoError(t, db.Exec("CREATE TABLE b (id INTEGER)").Error)
+	require.NoError(t, db.Exec("INSERT INTO a VALUES (1)").Error)
+	require.NoError(t, db.Exec("INSERT INTO b VALUES (1)").Error)
+
+	require.NoError(t, clearTables(db, []string{"a"}, false))
+	var n int64
+	require.NoError(t, db.Table("a").Count(&n).Error)
+	assert.Zero(t, n)
+	require.NoError(t, db.Table("b").Count(&n).Error)
+	assert.EqualValues(t, 1, n)
+
+	assert.ErrorContains(t, clearTables(db, []string{"missing"}, false), "clear data from table missing failed")
+}
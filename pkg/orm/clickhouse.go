@@ -0,0 +1,220 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/driver/clickhouse"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// gormClickHouse is the DB of DriverClickHouse, meant for append-heavy
+// event and analytics tables. Optimistic locking is not registered: updates
+// are asynchronous mutations in ClickHouse.
+type gormClickHouse struct {
+	dbConfig *DBConfig
+	db       *gorm.DB
+	utilDB   *gorm.DB
+	sqlDB    *sql.DB
+}
+
+func newGormClickHouse(dbConfig *DBConfig, forUtil bool) (*gormClickHouse, error) {
+	gc := &gormClickHouse{dbConfig: dbConfig}
+	dbName, silent := dbConfig.DBName, true
+	if forUtil {
+		dbName, silent = "default", false
+	}
+	opts, err := gc.options(dbName)
+	if err != nil {
+		return nil, err
+	}
+	db, sqlDB, err := gc.openConnection(opts, silent)
+	if err != nil {
+		return nil, err
+	}
+	if forUtil {
+		gc.utilDB = db
+	} else {
+		gc.db = db
+	}
+	gc.sqlDB = sqlDB
+	return gc, nil
+}
+
+// options builds the connection options, from DSN when it is set.
+func (gc *gormClickHouse) options(dbName string) (*ch.Options, error) {
+	var opts *ch.Options
+	if gc.dbConfig.DSN != "" {
+		parsed, err := ch.ParseDSN(gc.dbConfig.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("parse clickhouse dsn: %w", err)
+		}
+		opts = parsed
+	} else {
+		opts = &ch.Options{
+			Addr: []string{net.JoinHostPort(gc.dbConfig.Host, gc.dbConfig.Port)},
+			Auth: ch.Auth{
+				Database: dbName,
+				Username: gc.dbConfig.Username,
+				Password: gc.dbConfig.Password,
+			},
+		}
+	}
+	if gc.dbConfig.TLS != nil {
+		tlsConfig, err := gc.dbConfig.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		opts.TLS = tlsConfig
+	}
+	return opts, nil
+}
+
+// openConnection creates a new database connection with the given options
+func (gc *gormClickHouse) openConnection(opts *ch.Options, silent bool) (*gorm.DB, *sql.DB, error) {
+	sqlDB := ch.OpenDB(opts)
+	sqlDB.SetMaxIdleConns(gc.dbConfig.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(gc.dbConfig.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(gc.dbConfig.getConnMaxLifetime())
+	sqlDB.SetConnMaxIdleTime(gc.dbConfig.getConnMaxIdleTime())
+
+	gormConfig := &gorm.Config{}
+	switch {
+	case gc.dbConfig.Logger != nil:
+		gormConfig.Logger = gc.dbConfig.Logger
+	case silent:
+		gormConfig.Logger = logger.Default.LogMode(logger.Silent)
+	}
+
+	gormDB, err := gorm.Open(clickhouse.New(clickhouse.Config{Conn: sqlDB}), gormConfig)
+	if err != nil {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("failed to open gorm: %w", err)
+	}
+	if gc.dbConfig.DefaultQueryTimeout > 0 {
+		if err := gormDB.Use(QueryTimeout{Timeout: gc.dbConfig.DefaultQueryTimeout}); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
+	if gc.dbConfig.Encryption != nil {
+		if err := gormDB.Use(Encryption{Keys: gc.dbConfig.Encryption}); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register encryption: %w", err)
+		}
+	}
+	if gc.dbConfig.Metrics != nil {
+		if err := gormDB.Use(gc.dbConfig.Metrics); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register statement metrics: %w", err)
+		}
+	}
+	return gormDB, sqlDB, nil
+}
+
+// Close closes the database connection
+func (gc *gormClickHouse) Close() error {
+	if gc.sqlDB != nil {
+		return gc.sqlDB.Close()
+	}
+	return nil
+}
+
+// Ping verifies the database connection within the deadline of ctx
+func (gc *gormClickHouse) Ping(ctx context.Context) error {
+	if gc.sqlDB == nil {
+		return fmt.Errorf("db is nil, please init db first")
+	}
+	if err := gc.sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping db failed: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the connection pool statistics
+func (gc *gormClickHouse) Stats() sql.DBStats {
+	if gc.sqlDB == nil {
+		return sql.DBStats{}
+	}
+	return gc.sqlDB.Stats()
+}
+
+// CreateDB creates the database if it does not exist
+func (gc *gormClickHouse) CreateDB() error {
+	if gc.utilDB == nil {
+		return fmt.Errorf("util db is nil, please use MakeDBUtil first")
+	}
+	if err := gc.utilDB.Exec("CREATE DATABASE IF NOT EXISTS " + quoteIdentifier(gc.dbConfig.DBName)).Error; err != nil {
+		return fmt.Errorf("create db failed: %w", err)
+	}
+	return nil
+}
+
+// DropDB drops the database if it exists
+func (gc *gormClickHouse) DropDB() error {
+	if gc.utilDB == nil {
+		return fmt.Errorf("util db is nil, please use MakeDBUtil first")
+	}
+	if err := gc.utilDB.Exec("DROP DATABASE IF EXISTS " + quoteIdentifier(gc.dbConfig.DBName)).Error; err != nil {
+		return fmt.Errorf("drop db failed: %w", err)
+	}
+	return nil
+}
+
+// Dump is not supported for ClickHouse.
+func (gc *gormClickHouse) Dump(string) error {
+	return fmt.Errorf("dump is not supported for %s", DriverClickHouse)
+}
+
+// Restore is not supported for ClickHouse.
+func (gc *gormClickHouse) Restore(string) error {
+	return fmt.Errorf("restore is not supported for %s", DriverClickHouse)
+}
+
+// GetUtilDB returns the utility database connection for database management operations
+func (gc *gormClickHouse) GetUtilDB() *gorm.DB {
+	return gc.utilDB
+}
+
+// GetDB returns the main database connection
+func (gc *gormClickHouse) GetDB() *gorm.DB {
+	return gc.db
+}
+
+// ClearAllData truncates all tables (only works in test environment with test/dev database)
+func (gc *gormClickHouse) ClearAllData(opts ...ClearOption) error {
+	if flag.Lookup("test.v") == nil {
+		return fmt.Errorf("ClearAllData can only be called in test environment")
+	}
+	if !strings.Contains(gc.dbConfig.DBName, "test") && !strings.Contains(gc.dbConfig.DBName, "dev") {
+		return fmt.Errorf("ClearAllData can only be used with test or dev database, got: %s", gc.dbConfig.DBName)
+	}
+	if gc.db == nil {
+		return fmt.Errorf("db is nil, please init db first")
+	}
+
+	var tables []string
+	if err := gc.db.Raw("SHOW TABLES").Scan(&tables).Error; err != nil {
+		return fmt.Errorf("get table list failed: %w", err)
+	}
+	for _, t := range newClearOptions(opts).tables(tables) {
+		if err := gc.db.Exec("TRUNCATE TABLE " + quoteIdentifier(t)).Error; err != nil {
+			return fmt.Errorf("clear data from table %s failed: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// Migrate runs AutoMigrate over the models registered for DriverClickHouse.
+func (gc *gormClickHouse) Migrate(ctx context.Context) ([]string, error) {
+	if gc.db == nil {
+		return nil, fmt.Errorf("db is nil, please init db first")
+	}
+	return migrate(gc.db.WithContext(ctx), ModelsFor(DriverClickHouse))
+}
@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type clickHouseEvent struct {
+	Name string
+}
+
+func TestMakeDB_UnsupportedDriver(t *testing.T) {
+	_, err := MakeDB(&DBConfig{Driver: "oracle"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unsupported db driver "oracle"`)
+
+	_, err = MakeDBUtil(&DBConfig{Driver: "oracle"})
+	require.Error(t, err)
+}
+
+func TestGormClickHouse_options(t *testing.T) {
+	gc := &gormClickHouse{dbConfig: &DBConfig{
+		Driver:   DriverClickHouse,
+		Username: "default",
+		Password: "secret",
+		Host:     "127.0.0.1",
+		Port:     "9000",
+		DBName:   "events",
+	}}
+
+	opts, err := gc.options("events")
+	require.NoError(t, err)
+	require.Equal(t, []string{"127.0.0.1:9000"}, opts.Addr)
+	require.Equal(t, "events", opts.Auth.Database)
+	require.Equal(t, "default", opts.Auth.Username)
+	require.Equal(t, "secret", opts.Auth.Password)
+	require.Nil(t, opts.TLS)
+
+	opts, err = gc.options("default")
+	require.NoError(t, err)
+	require.Equal(t, "default", opts.Auth.Database)
+}
+
+func TestGormClickHouse_options_DSN(t *testing.T) {
+	gc := &gormClickHouse{dbConfig: &DBConfig{
+		DSN: "clickhouse://analytics:pw@ch-1:9000,ch-2:9000/events",
+		TLS: &TLSConfig{InsecureSkipVerify: true},
+	}}
+
+	opts, err := gc.options("events")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ch-1:9000", "ch-2:9000"}, opts.Addr)
+	require.Equal(t, "events", opts.Auth.Database)
+	require.Equal(t, "analytics", opts.Auth.Username)
+	require.NotNil(t, opts.TLS)
+
+	gc.dbConfig.DSN = "://bad"
+	_, err = gc.options("events")
+	require.Error(t, err)
+}
+
+func TestGormClickHouse_Nil(t *testing.T) {
+	gc := &gormClickHouse{dbConfig: &DBConfig{DBName: "events_test"}}
+
+	require.NoError(t, gc.Close())
+	require.Error(t, gc.Ping(context.Background()))
+	require.Equal(t, sql.DBStats{}, gc.Stats())
+	require.Error(t, gc.CreateDB())
+	require.Error(t, gc.DropDB())
+	require.Error(t, gc.ClearAllData())
+	_, err := gc.Migrate(context.Background())
+	require.Error(t, err)
+}
+
+func TestRegisterModelFor(t *testing.T) {
+	RegisterModelFor(DriverClickHouse, &clickHouseEvent{})
+	RegisterModelFor(DriverClickHouse, &clickHouseEvent{})
+
+	require.Len(t, ModelsFor(DriverClickHouse), 1)
+	for _, m := range Models() {
+		require.NotEqual(t, &clickHouseEvent{}, m, "clickhouse models are not migrated on mysql")
+	}
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type dryRunItem struct {
+	ID   uint64
+	Name string
+}
+
+// sqlLog collects the sql of log entries.
+type sqlLog struct {
+	mu   sync.Mutex
+	sqls []string
+}
+
+func (l *sqlLog) Log(_ log.Level, keyvals ...any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == "sql" {
+			l.sqls = append(l.sqls, keyvals[i+1].(string))
+		}
+	}
+	return nil
+}
+
+func TestDryRun(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&dryRunItem{}))
+	require.NoError(t, db.Create(&dryRunItem{ID: 1, Name: "existing"}).Error)
+
+	logged := &sqlLog{}
+	require.NoError(t, db.Use(DryRun{Logger: logged}))
+
+	require.NoError(t, db.Create(&dryRunItem{ID: 2, Name: "new"}).Error)
+	require.NoError(t, db.Model(&dryRunItem{ID: 1}).Update("name", "renamed").Error)
+	require.NoError(t, db.Exec("DELETE FROM dry_run_items").Error)
+
+	var items []dryRunItem
+	require.NoError(t, db.Find(&items).Error)
+	require.Len(t, items, 1, "writes are not executed, reads are")
+	assert.Equal(t, "existing", items[0].Name)
+
+	require.Len(t, logged.sqls, 3)
+	assert.True(t, strings.HasPrefix(logged.sqls[0], "INSERT INTO `dry_run_items`"), logged.sqls[0])
+	assert.Contains(t, logged.sqls[0], `"new"`)
+	assert.Contains(t, logged.sqls[1], `"renamed"`)
+	assert.Equal(t, "DELETE FROM dry_run_items", logged.sqls[2])
+}
@@ -0,0 +1,275 @@
+package orm
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dumpBatchSize is the number of rows per INSERT statement of a dump.
+const dumpBatchSize = 500
+
+// binaryTypes are the column types dumped as hex literals.
+var binaryTypes = map[string]bool{
+	"BINARY": true, "VARBINARY": true, "BIT": true, "GEOMETRY": true,
+	"BLOB": true, "TINYBLOB": true, "MEDIUMBLOB": true, "LONGBLOB": true,
+}
+
+// Dump writes the tables of the database to path as SQL statements which
+// Restore, or the mysql client, loads back. It is meant for snapshotting a
+// seeded test database once and restoring it between test cases; views,
+// triggers and routines are not dumped.
+func (gm *gormMysql) Dump(path string) (err error) {
+	if gm.utilDB == nil {
+		return fmt.Errorf("util db is nil, please use MakeDBUtil first")
+	}
+	var tables []string
+	err = gm.utilDB.Raw("SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		gm.dbConfig.DBName).Scan(&tables).Error
+	if err != nil {
+		return fmt.Errorf("get table list failed: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create dump file failed: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("close dump file failed: %w", cerr)
+		}
+	}()
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "-- dump of %s at %s\n", quoteIdentifier(gm.dbConfig.DBName), time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "SET NAMES %s;\nSET FOREIGN_KEY_CHECKS=0;\n", gm.dbConfig.getCharset())
+	for _, t := range tables {
+		if err := gm.dumpTable(w, t); err != nil {
+			return fmt.Errorf("dump table %s failed: %w", t, err)
+		}
+	}
+	fmt.Fprint(w, "\nSET FOREIGN_KEY_CHECKS=1;\n")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write dump file failed: %w", err)
+	}
+	return nil
+}
+
+func (gm *gormMysql) dumpTable(w *bufio.Writer, table string) error {
+	qualified := quoteIdentifier(gm.dbConfig.DBName) + "." + quoteIdentifier(table)
+	var name, create string
+	if err := gm.utilDB.Raw("SHOW CREATE TABLE "+qualified).Row().Scan(&name, &create); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nDROP TABLE IF EXISTS %s;\n%s;\n", quoteIdentifier(table), create)
+
+	// Generated columns cannot be inserted into.
+	var columns []string
+	err := gm.utilDB.Raw("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND EXTRA NOT LIKE '%GENERATED%' ORDER BY ORDINAL_POSITION",
+		gm.dbConfig.DBName, table).Scan(&columns).Error
+	if err != nil {
+		return err
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdentifier(c)
+	}
+	list := strings.Join(quoted, ", ")
+
+	rows, err := gm.utilDB.Raw(fmt.Sprintf("SELECT %s FROM %s", list, qualified)).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if n%dumpBatchSize == 0 {
+			if n > 0 {
+				w.WriteString(";\n")
+			}
+			fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES\n(", quoteIdentifier(table), list)
+		} else {
+			w.WriteString(",\n(")
+		}
+		for i, v := range values {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString(sqlLiteral(v, binaryTypes[types[i].DatabaseTypeName()]))
+		}
+		w.WriteString(")")
+		n++
+	}
+	if n > 0 {
+		w.WriteString(";\n")
+	}
+	return rows.Err()
+}
+
+// sqlLiteral formats a scanned value as a MySQL literal.
+func sqlLiteral(v any, binary bool) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if binary {
+			if len(v) == 0 {
+				return "''"
+			}
+			return "X'" + hex.EncodeToString(v) + "'"
+		}
+		return quoteString(string(v))
+	case string:
+		return quoteString(v)
+	case time.Time:
+		if v.IsZero() {
+			return "'0000-00-00 00:00:00'"
+		}
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var stringEscaper = strings.NewReplacer(
+	"\\", "\\\\", "'", "\\'", "\x00", "\\0", "\n", "\\n", "\r", "\\r", "\x1a", "\\Z",
+)
+
+// quoteString quotes s as a MySQL string literal with backslash escapes.
+func quoteString(s string) string {
+	return "'" + stringEscaper.Replace(s) + "'"
+}
+
+// Restore replaces the tables of the database with those of a file written
+// by Dump; tables missing from the dump are dropped. Like ClearAllData, it
+// only runs on test or dev databases.
+func (gm *gormMysql) Restore(path string) error {
+	if !strings.Contains(gm.dbConfig.DBName, "test") && !strings.Contains(gm.dbConfig.DBName, "dev") {
+		return fmt.Errorf("Restore can only be used with test or dev database, got: %s", gm.dbConfig.DBName)
+	}
+	if gm.utilDB == nil {
+		return fmt.Errorf("util db is nil, please use MakeDBUtil first")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read dump file failed: %w", err)
+	}
+	statements, err := splitStatements(string(data))
+	if err != nil {
+		return fmt.Errorf("parse dump file failed: %w", err)
+	}
+
+	// USE and SET are per session: run everything on one connection and
+	// reset it before it goes back to the pool.
+	return gm.utilDB.Connection(func(conn *gorm.DB) error {
+		defer conn.Exec("SET FOREIGN_KEY_CHECKS=1")
+		defer conn.Exec("USE information_schema")
+		if err := conn.Exec("USE " + quoteIdentifier(gm.dbConfig.DBName)).Error; err != nil {
+			return fmt.Errorf("use db failed: %w", err)
+		}
+		if err := conn.Exec("SET FOREIGN_KEY_CHECKS=0").Error; err != nil {
+			return err
+		}
+		var tables []string
+		err := conn.Raw("SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'",
+			gm.dbConfig.DBName).Scan(&tables).Error
+		if err != nil {
+			return fmt.Errorf("get table list failed: %w", err)
+		}
+		for _, t := range tables {
+			if err := conn.Exec("DROP TABLE " + quoteIdentifier(t)).Error; err != nil {
+				return fmt.Errorf("drop table %s failed: %w", t, err)
+			}
+		}
+		for _, s := range statements {
+			// Without arguments Exec leaves ? and @ in the values as is.
+			if err := conn.Exec(s).Error; err != nil {
+				return fmt.Errorf("restore failed: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// splitStatements splits a SQL script on the semicolons outside of quotes
+// and comments, dropping -- and # comments. /* */ comments are kept since
+// mysqldump's /*!... */ ones are executed.
+func splitStatements(script string) ([]string, error) {
+	var (
+		statements []string
+		cur        strings.Builder
+		quote      byte
+	)
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			statements = append(statements, s)
+		}
+		cur.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if quote != 0 {
+			cur.WriteByte(c)
+			switch {
+			case c == '\\' && quote != '`' && i+1 < len(script):
+				i++
+				cur.WriteByte(script[i])
+			case c == quote && i+1 < len(script) && script[i+1] == quote:
+				i++
+				cur.WriteByte(script[i])
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			cur.WriteByte(c)
+		case c == '#' || c == '-' && strings.HasPrefix(script[i:], "--") &&
+			(i+2 == len(script) || script[i+2] == ' ' || script[i+2] == '\t' || script[i+2] == '\n' || script[i+2] == '\r'):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			cur.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			cur.WriteString(script[i : i+2+end+2])
+			i += 2 + end + 1
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	flush()
+	return statements, nil
+}
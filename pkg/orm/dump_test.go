@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLLiteral(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 120000000, time.Local)
+	tests := []struct {
+		v      any
+		binary bool
+		want   string
+	}{
+		{nil, false, "NULL"},
+		{[]byte("42"), false, "'42'"},
+		{[]byte("it's a\\b\n;"), false, `'it\'s a\\b\n;'`},
+		{[]byte{0, 0xff}, true, "X'00ff'"},
+		{[]byte{}, true, "''"},
+		{"x\x00\x1a\r", false, `'x\0\Z\r'`},
+		{ts, false, "'2024-05-06 07:08:09.12'"},
+		{time.Time{}, false, "'0000-00-00 00:00:00'"},
+		{true, false, "1"},
+		{int64(-3), false, "-3"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sqlLiteral(tt.v, tt.binary), "%#v", tt.v)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- dump of `db`\n" +
+		"SET NAMES utf8mb4;\n" +
+		"/*!40101 SET @OLD_SQL_MODE=@@SQL_MODE */;\n" +
+		"# comment; with a semicolon\n" +
+		"INSERT INTO `a;b` VALUES ('x;y', 'it\\'s', 'd''q', \"e;\"), ('--not a comment');\n" +
+		"  ;\n" +
+		"SELECT 1--1"
+	got, err := splitStatements(script)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SET NAMES utf8mb4",
+		"/*!40101 SET @OLD_SQL_MODE=@@SQL_MODE */",
+		"INSERT INTO `a;b` VALUES ('x;y', 'it\\'s', 'd''q', \"e;\"), ('--not a comment')",
+		"SELECT 1--1",
+	}, got)
+
+	_, err = splitStatements("SELECT 'open")
+	assert.Error(t, err)
+	_, err = splitStatements("/* open")
+	assert.Error(t, err)
+}
+
+func TestGormMysql_DumpRestore_Errors(t *testing.T) {
+	gm := &gormMysql{dbConfig: &DBConfig{DBName: "hahaha_test"}}
+	assert.ErrorContains(t, gm.Dump(t.TempDir()+"/dump.sql"), "util db is nil")
+	assert.ErrorContains(t, gm.Restore(t.TempDir()+"/dump.sql"), "util db is nil")
+
+	gm = &gormMysql{dbConfig: &DBConfig{DBName: "production_db"}}
+	assert.ErrorContains(t, gm.Restore(t.TempDir()+"/dump.sql"), "test or dev database")
+}
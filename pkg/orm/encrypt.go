@@ -0,0 +1,263 @@
+package orm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrUnknownKey is returned when reading a value encrypted with a key that is
+// not in the Keyring, e.g. after a retired key was removed too early.
+var ErrUnknownKey = errors.New("orm: unknown encryption key")
+
+// encryptedPrefix marks encrypted column values: enc:<key id>:<base64>.
+const encryptedPrefix = "enc:"
+
+// Keyring holds the AES keys of field encryption. Values are encrypted with
+// the primary key and decrypted with the key they name, so a key is rotated
+// by adding a new primary and keeping the old one until its rows are rewritten.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// ParseKeyring parses "id:base64key,id:base64key,...". The first key is the
+// primary; keys are 16, 24 or 32 bytes (AES-128, -192 or -256).
+func ParseKeyring(s string) (*Keyring, error) {
+	k := &Keyring{aeads: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("orm: invalid encryption key %q, want id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("orm: decode encryption key %s: %w", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("orm: encryption key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("orm: encryption key %s: %w", id, err)
+		}
+		if _, dup := k.aeads[id]; dup {
+			return nil, fmt.Errorf("orm: duplicate encryption key %s", id)
+		}
+		k.aeads[id] = aead
+		if k.primary == "" {
+			k.primary = id
+		}
+	}
+	if k.primary == "" {
+		return nil, errors.New("orm: no encryption key")
+	}
+	return k, nil
+}
+
+// Encrypt encrypts plaintext with the primary key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt. Values without the encrypted
+// prefix, e.g. rows written before the column was encrypted, are returned
+// unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("orm: malformed encrypted value")
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("orm: malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("orm: decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+const encryptionRestoreKey = "orm:encryption_restore"
+
+// Encryption is a gorm plugin encrypting the string fields tagged
+// orm:"encrypted" with AES-GCM, for PII columns such as phone numbers:
+//
+//	type Customer struct {
+//		orm.BaseModel
+//		Phone string `orm:"encrypted" gorm:"size:255"`
+//	}
+//
+// Creates and updates store the ciphertext and leave the plaintext in the
+// model; queries decrypt into models of the statement's type. Empty strings
+// are stored as is. The nonce is random, so encrypted columns cannot be
+// filtered on, and values read with Raw/Scan or into other structs stay
+// encrypted. Size the columns for the base64 ciphertext: about 4/3 of the
+// plaintext plus 40 bytes and the key id.
+type Encryption struct {
+	Keys *Keyring
+}
+
+// Name implements gorm.Plugin.
+func (Encryption) Name() string { return "orm:encryption" }
+
+// Initialize implements gorm.Plugin.
+func (p Encryption) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("orm:encryption_create", p.encrypt),
+		cb.Create().After("gorm:create").Register("orm:encryption_create_restore", p.restore),
+		cb.Update().Before("gorm:update").Register("orm:encryption_update", p.encrypt),
+		cb.Update().After("gorm:update").Register("orm:encryption_update_restore", p.restore),
+		cb.Query().After("gorm:query").Register("orm:encryption_query", p.decrypt),
+	)
+}
+
+func encryptedFields(s *schema.Schema) []*schema.Field {
+	if s == nil {
+		return nil
+	}
+	var fields []*schema.Field
+	for _, f := range s.Fields {
+		if f.Tag.Get("orm") == "encrypted" && f.FieldType.Kind() == reflect.String {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// eachModel calls fn with every model of the statement's type in its value.
+func eachModel(db *gorm.DB, fn func(rv reflect.Value)) {
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Type() == db.Statement.Schema.ModelType {
+				fn(elem)
+			}
+		}
+	case reflect.Struct:
+		if rv.Type() == db.Statement.Schema.ModelType {
+			fn(rv)
+		}
+	}
+}
+
+func (p Encryption) encrypt(db *gorm.DB) {
+	fields := encryptedFields(db.Statement.Schema)
+	if len(fields) == 0 || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+
+	// Update("phone", v) and Updates(map) carry the values in Dest.
+	if m, ok := db.Statement.Dest.(map[string]any); ok {
+		cp := make(map[string]any, len(m))
+		for k, v := range m {
+			cp[k] = v
+		}
+		var restore []func()
+		for _, f := range fields {
+			for _, key := range []string{f.Name, f.DBName} {
+				if plaintext, ok := cp[key].(string); ok && plaintext != "" {
+					enc, err := p.Keys.Encrypt(plaintext)
+					if err != nil {
+						db.AddError(fmt.Errorf("encrypt %s: %w", f.Name, err))
+						return
+					}
+					cp[key] = enc
+					// gorm copies the assigned values into the Model.
+					restore = append(restore, func() {
+						eachModel(db, func(rv reflect.Value) { _ = f.Set(ctx, rv, plaintext) })
+					})
+				}
+			}
+		}
+		db.Statement.Dest = cp
+		if len(restore) > 0 {
+			db.InstanceSet(encryptionRestoreKey, restore)
+		}
+		return
+	}
+
+	var restore []func()
+	eachModel(db, func(rv reflect.Value) {
+		for _, f := range fields {
+			v, zero := f.ValueOf(ctx, rv)
+			if zero {
+				continue
+			}
+			plaintext := reflect.ValueOf(v).String()
+			enc, err := p.Keys.Encrypt(plaintext)
+			if err != nil {
+				db.AddError(fmt.Errorf("encrypt %s: %w", f.Name, err))
+				continue
+			}
+			db.AddError(f.Set(ctx, rv, enc))
+			restore = append(restore, func() { _ = f.Set(ctx, rv, plaintext) })
+		}
+	})
+	if len(restore) > 0 {
+		db.InstanceSet(encryptionRestoreKey, restore)
+	}
+}
+
+// restore puts the plaintext back into the models, whether or not the
+// statement succeeded.
+func (Encryption) restore(db *gorm.DB) {
+	if v, ok := db.InstanceGet(encryptionRestoreKey); ok {
+		for _, fn := range v.([]func()) {
+			fn()
+		}
+	}
+}
+
+func (p Encryption) decrypt(db *gorm.DB) {
+	fields := encryptedFields(db.Statement.Schema)
+	if len(fields) == 0 || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+	eachModel(db, func(rv reflect.Value) {
+		for _, f := range fields {
+			v, zero := f.ValueOf(ctx, rv)
+			if zero {
+				continue
+			}
+			plaintext, err := p.Keys.Decrypt(reflect.ValueOf(v).String())
+			if err != nil {
+				db.AddError(fmt.Errorf("decrypt %s: %w", f.Name, err))
+				continue
+			}
+			db.AddError(f.Set(ctx, rv, plaintext))
+		}
+	})
+}
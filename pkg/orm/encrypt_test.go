@@ -0,0 +1,102 @@
+package orm
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type encryptedCustomer struct {
+	BaseModel
+	Name  string
+	Phone string `orm:"encrypted"`
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestParseKeyring(t *testing.T) {
+	k, err := ParseKeyring("k2:" + testKey('b') + ", k1:" + testKey('a'))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", k.primary)
+	assert.Len(t, k.aeads, 2)
+
+	for _, bad := range []string{"", "k1", ":" + testKey('a'), "k1:!!", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "k1:" + testKey('a') + ",k1:" + testKey('b')} {
+		_, err := ParseKeyring(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, err := ParseKeyring("k1:" + testKey('a'))
+	require.NoError(t, err)
+	enc, err := old.Encrypt("13800000000")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, "enc:k1:"))
+
+	rotated, err := ParseKeyring("k2:" + testKey('b') + ",k1:" + testKey('a'))
+	require.NoError(t, err)
+	got, err := rotated.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", got)
+
+	newer, err := rotated.Encrypt("13800000000")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newer, "enc:k2:"))
+	_, err = old.Decrypt(newer)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	got, err = old.Decrypt("legacy plaintext")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy plaintext", got)
+	_, err = old.Decrypt("enc:k1:????")
+	assert.Error(t, err)
+}
+
+func TestEncryption(t *testing.T) {
+	keys, err := ParseKeyring("k1:" + testKey('a'))
+	require.NoError(t, err)
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(Encryption{Keys: keys}))
+	require.NoError(t, db.AutoMigrate(&encryptedCustomer{}))
+
+	c := &encryptedCustomer{Name: "alice", Phone: "13800000000"}
+	require.NoError(t, db.Create(c).Error)
+	assert.Equal(t, "13800000000", c.Phone, "the model keeps the plaintext")
+
+	stored := func(id uint64) string {
+		var phone string
+		require.NoError(t, db.Raw("SELECT phone FROM encrypted_customers WHERE id = ?", id).Scan(&phone).Error)
+		return phone
+	}
+	assert.True(t, strings.HasPrefix(stored(c.ID), "enc:k1:"))
+
+	var got encryptedCustomer
+	require.NoError(t, db.First(&got, c.ID).Error)
+	assert.Equal(t, "13800000000", got.Phone)
+
+	got.Phone = "13900000000"
+	require.NoError(t, db.Save(&got).Error)
+	assert.Equal(t, "13900000000", got.Phone)
+	require.NoError(t, db.Model(&got).Update("phone", "13700000000").Error)
+	assert.Equal(t, "13700000000", got.Phone)
+	assert.True(t, strings.HasPrefix(stored(c.ID), "enc:k1:"))
+
+	batch := []encryptedCustomer{{Name: "bob", Phone: "1"}, {Name: "carol"}}
+	require.NoError(t, db.Create(&batch).Error)
+	assert.Empty(t, stored(batch[1].ID), "empty values are stored as is")
+
+	require.NoError(t, db.Exec("INSERT INTO encrypted_customers (created_at, updated_at, name, phone) VALUES (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'dave', 'legacy')").Error)
+
+	var all []encryptedCustomer
+	require.NoError(t, db.Order("id").Find(&all).Error)
+	require.Len(t, all, 4)
+	assert.Equal(t, []string{"13700000000", "1", "", "legacy"},
+		[]string{all[0].Phone, all[1].Phone, all[2].Phone, all[3].Phone})
+}
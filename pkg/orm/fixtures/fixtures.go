@@ -0,0 +1,192 @@
+// Package fixtures loads YAML or JSON fixture files into tables so data-layer
+// tests start from a deterministic state.
+//
+// Each file holds the rows of the table named after it:
+//
+//	# users.yml
+//	- id: 1
+//	  name: alice
+//	- id: 2
+//	  name: bob
+//
+// Tables are emptied before loading, children before parents, and rows are
+// inserted parents first following the foreign keys between loaded tables.
+package fixtures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture is the content of one file.
+type Fixture struct {
+	Table string
+	Rows  []map[string]any
+}
+
+// Parse reads the files matching patterns in fsys. JSON is parsed as YAML.
+func Parse(fsys fs.FS, patterns ...string) ([]Fixture, error) {
+	var fixtures []Fixture
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			ext := path.Ext(file)
+			if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+				continue
+			}
+			table := strings.TrimSuffix(path.Base(file), ext)
+			if seen[table] {
+				return nil, fmt.Errorf("fixtures: table %s loaded from more than one file", table)
+			}
+			seen[table] = true
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			var rows []map[string]any
+			if err := yaml.Unmarshal(data, &rows); err != nil {
+				return nil, fmt.Errorf("fixtures: parse %s: %w", file, err)
+			}
+			fixtures = append(fixtures, Fixture{Table: table, Rows: rows})
+		}
+	}
+	return fixtures, nil
+}
+
+// Load parses the files matching patterns in fsys and loads them into db.
+func Load(ctx context.Context, db *gorm.DB, fsys fs.FS, patterns ...string) error {
+	fixtures, err := Parse(fsys, patterns...)
+	if err != nil {
+		return err
+	}
+	return Insert(ctx, db, fixtures...)
+}
+
+// Insert empties the fixtures' tables and inserts their rows in a single
+// transaction.
+func Insert(ctx context.Context, db *gorm.DB, fixtures ...Fixture) error {
+	tables := make([]string, 0, len(fixtures))
+	byTable := make(map[string]Fixture, len(fixtures))
+	for _, f := range fixtures {
+		tables = append(tables, f.Table)
+		byTable[f.Table] = f
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deps, err := foreignKeys(tx, tables)
+		if err != nil {
+			return err
+		}
+		ordered, err := sortTables(tables, deps)
+		if err != nil {
+			return err
+		}
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if err := tx.Exec("DELETE FROM " + quote(tx, ordered[i])).Error; err != nil {
+				return fmt.Errorf("fixtures: empty %s: %w", ordered[i], err)
+			}
+		}
+		for _, table := range ordered {
+			rows := byTable[table].Rows
+			if len(rows) == 0 {
+				continue
+			}
+			if err := tx.Table(table).Create(&rows).Error; err != nil {
+				return fmt.Errorf("fixtures: insert into %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+func quote(db *gorm.DB, name string) string {
+	var sb strings.Builder
+	db.Dialector.QuoteTo(&sb, name)
+	return sb.String()
+}
+
+// foreignKeys returns, for each table, the tables it references.
+func foreignKeys(db *gorm.DB, tables []string) (map[string][]string, error) {
+	deps := make(map[string][]string, len(tables))
+	switch db.Dialector.Name() {
+	case "mysql":
+		var refs []struct {
+			TableName           string
+			ReferencedTableName string
+		}
+		err := db.Raw(`SELECT table_name AS table_name, referenced_table_name AS referenced_table_name
+			FROM information_schema.key_column_usage
+			WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL AND table_name IN ?`, tables).
+			Scan(&refs).Error
+		if err != nil {
+			return nil, fmt.Errorf("fixtures: load foreign keys: %w", err)
+		}
+		for _, r := range refs {
+			deps[r.TableName] = append(deps[r.TableName], r.ReferencedTableName)
+		}
+	case "sqlite":
+		for _, t := range tables {
+			var refs []string
+			if err := db.Raw(`SELECT "table" FROM pragma_foreign_key_list(?)`, t).Scan(&refs).Error; err != nil {
+				return nil, fmt.Errorf("fixtures: load foreign keys of %s: %w", t, err)
+			}
+			deps[t] = refs
+		}
+	}
+	return deps, nil
+}
+
+// sortTables orders tables so that referenced tables come first. References
+// to tables outside the set and self-references are ignored.
+func sortTables(tables []string, deps map[string][]string) ([]string, error) {
+	sorted := append([]string(nil), tables...)
+	sort.Strings(sorted)
+	in := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		in[t] = true
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(tables))
+	result := make([]string, 0, len(tables))
+	var visit func(t string) error
+	visit = func(t string) error {
+		switch state[t] {
+		case done:
+			return nil
+		case visiting:
+			return errors.New("fixtures: foreign key cycle through " + t)
+		}
+		state[t] = visiting
+		for _, d := range deps[t] {
+			if d != t && in[d] {
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		state[t] = done
+		result = append(result, t)
+		return nil
+	}
+	for _, t := range sorted {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type user struct {
+	ID   uint64
+	Name string
+}
+
+type order struct {
+	ID     uint64
+	UserID uint64
+	User   user
+	Amount int64
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db := ormtest.Open(t, ormtest.WithSharedCache(), ormtest.WithForeignKeys())
+	require.NoError(t, db.AutoMigrate(&user{}, &order{}))
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	db := newTestDB(t)
+	// orders sorts before users but references them.
+	fsys := fstest.MapFS{
+		"testdata/orders.yml": {Data: []byte("- {id: 1, user_id: 2, amount: 100}\n- {id: 2, user_id: 1, amount: 5}\n")},
+		"testdata/users.json": {Data: []byte(`[{"id": 1, "name": "alice"}, {"id": 2, "name": "bob"}]`)},
+		"testdata/README.md":  {Data: []byte("ignored")},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, Load(ctx, db, fsys, "testdata/*"))
+	var orders []order
+	require.NoError(t, db.Preload("User").Order("id").Find(&orders).Error)
+	require.Len(t, orders, 2)
+	assert.Equal(t, "bob", orders[0].User.Name)
+
+	// Loading again replaces the data instead of failing on duplicates.
+	require.NoError(t, Insert(ctx, db, Fixture{Table: "orders"}, Fixture{Table: "users", Rows: []map[string]any{{"id": 3, "name": "carol"}}}))
+	var names []string
+	require.NoError(t, db.Model(&user{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"carol"}, names)
+
+	err := Insert(ctx, db, Fixture{Table: "orders", Rows: []map[string]any{{"id": 9, "user_id": 42}}})
+	assert.Error(t, err, "foreign keys are enforced")
+}
+
+func TestSortTables(t *testing.T) {
+	deps := map[string][]string{"orders": {"users"}, "items": {"orders", "products"}, "users": {"users"}}
+	sorted, err := sortTables([]string{"items", "orders", "products", "users"}, deps)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders", "products", "items"}, sorted)
+
+	_, err = sortTables([]string{"a", "b"}, map[string][]string{"a": {"b"}, "b": {"a"}})
+	assert.ErrorContains(t, err, "cycle")
+}
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
@@ -15,18 +16,42 @@ import (
 type DBUtil interface {
 	CreateDB() error
 	DropDB() error
+	// Dump writes the tables of the database to a SQL file at path, e.g.
+	// to snapshot a seeded test database once.
+	Dump(path string) error
+	// Restore replaces the tables of the database with those dumped to
+	// path. Only allowed on test or dev databases.
+	Restore(path string) error
 	GetUtilDB() *gorm.DB
 	Close() error
 }
 
 type DB interface {
 	GetDB() *gorm.DB
-	ClearAllData() error
+	// ClearAllData deletes the rows of every table, only in tests on test or
+	// dev databases; see WithTruncate and WithPreservedTables.
+	ClearAllData(opts ...ClearOption) error
+	// Migrate runs AutoMigrate over the models added with RegisterModel and
+	// returns the DDL statements applied.
+	Migrate(ctx context.Context) ([]string, error)
+	// Ping verifies a connection to the database is still alive, dialing
+	// one if necessary, within the deadline of ctx.
+	Ping(ctx context.Context) error
+	// Stats returns the connection pool statistics.
+	Stats() sql.DBStats
 	Close() error
 }
 
+// Drivers supported by DBConfig.Driver.
+const (
+	DriverMySQL      = "mysql"
+	DriverClickHouse = "clickhouse"
+)
+
 // DBConfig is the configuration for the database
 type DBConfig struct {
+	// Driver is DriverMySQL (the default) or DriverClickHouse.
+	Driver          string
 	Username        string
 	Password        string
 	Host            string
@@ -38,6 +63,43 @@ type DBConfig struct {
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
 	MultiStatements bool
+	// TLS requires encrypted connections; nil connects in plain text.
+	TLS *TLSConfig
+	// DSN overrides the connection built from the fields above, e.g. for a
+	// tenant on a dedicated server. Reference TLS with tls=<TLS.Name>.
+	DSN string
+	// Logger receives gorm's logs; nil keeps the main connection silent.
+	Logger logger.Interface
+	// DefaultQueryTimeout bounds every statement whose context has no
+	// deadline, so a runaway query cannot hold a pool connection forever.
+	// Zero disables it.
+	DefaultQueryTimeout time.Duration
+	// Encryption encrypts the model fields tagged orm:"encrypted"; nil
+	// leaves them in plain text.
+	Encryption *Keyring
+	// Audit records row changes in audit_logs; nil disables it. MySQL only.
+	Audit *AuditTrail
+	// DryRun logs writes instead of executing them; nil executes them.
+	DryRun *DryRun
+	// Metrics times the statements by table and operation; nil disables it.
+	Metrics *StatementMetrics
+	// PrepareStmt prepares every statement once per connection and reuses
+	// it, saving the server a parse of hot queries. MySQL only.
+	PrepareStmt bool
+	// PrepareStmtMaxSize caps the cached statements, evicting the least
+	// recently used; zero leaves the cache unbounded.
+	PrepareStmtMaxSize int
+	// PrepareStmtTTL closes cached statements unused for this long; zero
+	// keeps them until Close.
+	PrepareStmtTTL time.Duration
+}
+
+// getDriver returns the driver, defaulting to mysql
+func (c *DBConfig) getDriver() string {
+	if c.Driver == "" {
+		return DriverMySQL
+	}
+	return c.Driver
 }
 
 // getCharset returns the charset, defaulting to utf8mb4
@@ -70,15 +132,34 @@ func quoteIdentifier(name string) string {
 }
 
 func MakeDBUtil(dbConfig *DBConfig) (DBUtil, error) {
-	return newGormMysql(dbConfig, true)
+	switch dbConfig.getDriver() {
+	case DriverMySQL:
+		return newGormMysql(dbConfig, true)
+	case DriverClickHouse:
+		return newGormClickHouse(dbConfig, true)
+	default:
+		return nil, fmt.Errorf("unsupported db driver %q", dbConfig.Driver)
+	}
 }
 
 func MakeDB(dbConfig *DBConfig) (DB, error) {
-	return newGormMysql(dbConfig, false)
+	switch dbConfig.getDriver() {
+	case DriverMySQL:
+		return newGormMysql(dbConfig, false)
+	case DriverClickHouse:
+		return newGormClickHouse(dbConfig, false)
+	default:
+		return nil, fmt.Errorf("unsupported db driver %q", dbConfig.Driver)
+	}
 }
 
 func newGormMysql(dbConfig *DBConfig, forUtil bool) (*gormMysql, error) {
 	gm := &gormMysql{dbConfig: dbConfig}
+	if dbConfig.TLS != nil {
+		if err := dbConfig.TLS.register(); err != nil {
+			return nil, err
+		}
+	}
 
 	var err error
 	if forUtil {
@@ -101,14 +182,46 @@ type gormMysql struct {
 	sqlDB    *sql.DB
 }
 
-// Close closes the database connection
+// Close closes the cached prepared statements and the database connection
 func (gm *gormMysql) Close() error {
 	if gm.sqlDB != nil {
+		closePreparedStmts(gm.db, gm.utilDB)
 		return gm.sqlDB.Close()
 	}
 	return nil
 }
 
+// closePreparedStmts closes the statements cached with DBConfig.PrepareStmt.
+func closePreparedStmts(dbs ...*gorm.DB) {
+	for _, db := range dbs {
+		if db == nil {
+			continue
+		}
+		if p, ok := db.ConnPool.(*gorm.PreparedStmtDB); ok {
+			p.Close()
+		}
+	}
+}
+
+// Ping verifies the database connection within the deadline of ctx
+func (gm *gormMysql) Ping(ctx context.Context) error {
+	if gm.sqlDB == nil {
+		return fmt.Errorf("db is nil, please init db first")
+	}
+	if err := gm.sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping db failed: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the connection pool statistics
+func (gm *gormMysql) Stats() sql.DBStats {
+	if gm.sqlDB == nil {
+		return sql.DBStats{}
+	}
+	return gm.sqlDB.Stats()
+}
+
 // CreateDB creates the database if it does not exist
 func (gm *gormMysql) CreateDB() error {
 	if gm.utilDB == nil {
@@ -154,7 +267,7 @@ func (gm *gormMysql) GetDB() *gorm.DB {
 }
 
 // ClearAllData clears all data from all tables (only works in test environment with test/dev database)
-func (gm *gormMysql) ClearAllData() error {
+func (gm *gormMysql) ClearAllData(opts ...ClearOption) error {
 	if flag.Lookup("test.v") == nil {
 		return fmt.Errorf("ClearAllData can only be called in test environment")
 	}
@@ -167,32 +280,15 @@ func (gm *gormMysql) ClearAllData() error {
 		return fmt.Errorf("db is nil, please init db first")
 	}
 
-	rs, err := gm.db.Raw("SHOW TABLES;").Rows()
-	if err != nil {
+	var tables []string
+	if err := gm.db.Raw("SHOW TABLES").Scan(&tables).Error; err != nil {
 		return fmt.Errorf("get table list failed: %w", err)
 	}
-	defer rs.Close()
 
-	var tName string
-	for rs.Next() {
-		if err := rs.Scan(&tName); err != nil {
-			return fmt.Errorf("scan table name failed: %w", err)
-		}
-		if tName == "" {
-			continue
-		}
-
-		quotedTable := quoteIdentifier(tName)
-		if err := gm.db.Exec(fmt.Sprintf("DELETE FROM %s", quotedTable)).Error; err != nil {
-			return fmt.Errorf("clear data from table %s failed: %w", tName, err)
-		}
-	}
-
-	if err := rs.Err(); err != nil {
-		return fmt.Errorf("iterate tables failed: %w", err)
-	}
-
-	return nil
+	o := newClearOptions(opts)
+	return gm.db.Connection(func(conn *gorm.DB) error {
+		return clearTables(conn, o.tables(tables), o.truncate)
+	})
 }
 
 // openConnection creates a new database connection with the given DSN
@@ -207,8 +303,15 @@ func (gm *gormMysql) openConnection(dsn string, silent bool) (gormDB *gorm.DB, s
 	sqlDB.SetConnMaxLifetime(gm.dbConfig.getConnMaxLifetime())
 	sqlDB.SetConnMaxIdleTime(gm.dbConfig.getConnMaxIdleTime())
 
-	gormConfig := &gorm.Config{}
-	if silent {
+	gormConfig := &gorm.Config{
+		PrepareStmt:        gm.dbConfig.PrepareStmt,
+		PrepareStmtMaxSize: gm.dbConfig.PrepareStmtMaxSize,
+		PrepareStmtTTL:     gm.dbConfig.PrepareStmtTTL,
+	}
+	switch {
+	case gm.dbConfig.Logger != nil:
+		gormConfig.Logger = gm.dbConfig.Logger
+	case silent:
 		gormConfig.Logger = logger.Default.LogMode(logger.Silent)
 	}
 
@@ -217,6 +320,40 @@ func (gm *gormMysql) openConnection(dsn string, silent bool) (gormDB *gorm.DB, s
 		sqlDB.Close()
 		return nil, nil, fmt.Errorf("failed to open gorm: %w", err)
 	}
+	if err := gormDB.Use(OptimisticLock{}); err != nil {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("failed to register optimistic lock: %w", err)
+	}
+	if gm.dbConfig.DefaultQueryTimeout > 0 {
+		if err := gormDB.Use(QueryTimeout{Timeout: gm.dbConfig.DefaultQueryTimeout}); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
+	if gm.dbConfig.Encryption != nil {
+		if err := gormDB.Use(Encryption{Keys: gm.dbConfig.Encryption}); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register encryption: %w", err)
+		}
+	}
+	if gm.dbConfig.Audit != nil {
+		if err := gormDB.Use(*gm.dbConfig.Audit); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register audit trail: %w", err)
+		}
+	}
+	if gm.dbConfig.DryRun != nil {
+		if err := gormDB.Use(*gm.dbConfig.DryRun); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register dry run: %w", err)
+		}
+	}
+	if gm.dbConfig.Metrics != nil {
+		if err := gormDB.Use(gm.dbConfig.Metrics); err != nil {
+			sqlDB.Close()
+			return nil, nil, fmt.Errorf("failed to register statement metrics: %w", err)
+		}
+	}
 
 	return gormDB, sqlDB, nil
 }
@@ -233,6 +370,9 @@ func (gm *gormMysql) buildDSN(dbName string) string {
 	if gm.dbConfig.MultiStatements {
 		dsn += "&multiStatements=true"
 	}
+	if gm.dbConfig.TLS != nil {
+		dsn += "&tls=" + gm.dbConfig.TLS.name()
+	}
 	return dsn
 }
 
@@ -241,7 +381,10 @@ func (gm *gormMysql) initGormDB() error {
 		return fmt.Errorf("gorm db already initialized")
 	}
 
-	dsn := gm.buildDSN(gm.dbConfig.DBName)
+	dsn := gm.dbConfig.DSN
+	if dsn == "" {
+		dsn = gm.buildDSN(gm.dbConfig.DBName)
+	}
 	db, sqlDB, err := gm.openConnection(dsn, true)
 	if err != nil {
 		return err
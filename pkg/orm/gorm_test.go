@@ -1,26 +1,39 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
+	"net"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
-)
+	"gorm.io/gorm"
 
-var password = "root"
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
 
-func TestMakeDBUtil(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "hahaha_test",
+// mysqlConfig returns the config of database dbName on the server of
+// ormtest.MySQL, skipping t without one.
+func mysqlConfig(t *testing.T, dbName string) *DBConfig {
+	c := ormtest.MySQL(t)
+	host, port, err := net.SplitHostPort(c.Addr)
+	require.NoError(t, err)
+	return &DBConfig{
+		Username:     c.User,
+		Password:     c.Passwd,
+		Host:         host,
+		Port:         port,
+		DBName:       dbName,
 		MaxIdleConns: 10,
 		MaxOpenConns: 100,
 		DBCharset:    "utf8mb4",
 	}
+}
+
+func TestMakeDBUtil(t *testing.T) {
+	dbConf := mysqlConfig(t, "hahaha_test")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -34,16 +47,7 @@ func TestMakeDBUtil(t *testing.T) {
 }
 
 func TestMakeDB(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "hahaha_test",
-		MaxIdleConns: 10,
-		MaxOpenConns: 100,
-		DBCharset:    "utf8mb4",
-	}
+	dbConf := mysqlConfig(t, "hahaha_test")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -175,16 +179,7 @@ func TestDBConfig_getConnMaxIdleTime(t *testing.T) {
 }
 
 func TestGormMysql_GetUtilDB(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "hahaha_test",
-		MaxIdleConns: 10,
-		MaxOpenConns: 100,
-		DBCharset:    "utf8mb4",
-	}
+	dbConf := mysqlConfig(t, "hahaha_test")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -197,16 +192,7 @@ func TestGormMysql_GetUtilDB(t *testing.T) {
 }
 
 func TestGormMysql_GetDB(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "hahaha_test",
-		MaxIdleConns: 10,
-		MaxOpenConns: 100,
-		DBCharset:    "utf8mb4",
-	}
+	dbConf := mysqlConfig(t, "hahaha_test")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -232,7 +218,7 @@ func TestGormMysql_GetDB(t *testing.T) {
 func TestGormMysql_CreateDB_Error(t *testing.T) {
 	dbConf := &DBConfig{
 		Username:     "root",
-		Password:     password,
+		Password:     "root",
 		Host:         "127.0.0.1",
 		Port:         "3306",
 		DBName:       "hahaha_test",
@@ -252,7 +238,7 @@ func TestGormMysql_CreateDB_Error(t *testing.T) {
 func TestGormMysql_DropDB_Error(t *testing.T) {
 	dbConf := &DBConfig{
 		Username:     "root",
-		Password:     password,
+		Password:     "root",
 		Host:         "127.0.0.1",
 		Port:         "3306",
 		DBName:       "hahaha_test",
@@ -270,16 +256,7 @@ func TestGormMysql_DropDB_Error(t *testing.T) {
 }
 
 func TestGormMysql_ClearAllData_Error(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "production_db",
-		MaxIdleConns: 10,
-		MaxOpenConns: 100,
-		DBCharset:    "utf8mb4",
-	}
+	dbConf := mysqlConfig(t, "production_db")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -305,7 +282,7 @@ func TestGormMysql_ClearAllData_Error(t *testing.T) {
 func TestGormMysql_ClearAllData_DBNil(t *testing.T) {
 	dbConf := &DBConfig{
 		Username:     "root",
-		Password:     password,
+		Password:     "root",
 		Host:         "127.0.0.1",
 		Port:         "3306",
 		DBName:       "hahaha_test",
@@ -323,16 +300,7 @@ func TestGormMysql_ClearAllData_DBNil(t *testing.T) {
 }
 
 func TestGormMysql_Close(t *testing.T) {
-	dbConf := &DBConfig{
-		Username:     "root",
-		Password:     password,
-		Host:         "127.0.0.1",
-		Port:         "3306",
-		DBName:       "hahaha_test",
-		MaxIdleConns: 10,
-		MaxOpenConns: 100,
-		DBCharset:    "utf8mb4",
-	}
+	dbConf := mysqlConfig(t, "hahaha_test")
 
 	utilDB, err := MakeDBUtil(dbConf)
 	require.NoError(t, err)
@@ -352,6 +320,32 @@ func TestGormMysql_Close_Nil(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestGormMysql_Ping_Nil(t *testing.T) {
+	gm := &gormMysql{}
+
+	err := gm.Ping(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "db is nil")
+	require.Equal(t, sql.DBStats{}, gm.Stats())
+}
+
+func TestGormMysql_Ping_Deadline(t *testing.T) {
+	// sql.Open does not dial, so no server is needed; 192.0.2.1 is reserved
+	// for documentation and never answers.
+	sqlDB, err := sql.Open("mysql", "root:root@tcp(192.0.2.1:3306)/app?timeout=10s")
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	gm := &gormMysql{sqlDB: sqlDB}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = gm.Ping(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, 0, gm.Stats().OpenConnections)
+}
+
 func TestGormMysql_buildDSN(t *testing.T) {
 	dbConf := &DBConfig{
 		Username:     "testuser",
@@ -414,3 +408,17 @@ func TestGormMysql_buildDSN_DefaultCharset(t *testing.T) {
 	require.Contains(t, result, "charset=utf8mb4")
 	require.True(t, strings.HasSuffix(result, "&parseTime=True&loc=Local"))
 }
+
+func TestGormMysql_ClosePreparedStmts(t *testing.T) {
+	db := ormtest.Open(t, ormtest.WithConfig(func(c *gorm.Config) { c.PrepareStmt, c.PrepareStmtMaxSize = true, 10 }))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, db.Raw("SELECT 1").Scan(&n).Error)
+	stmts := db.ConnPool.(*gorm.PreparedStmtDB).Stmts
+	require.Len(t, stmts.Keys(), 1)
+
+	gm := &gormMysql{dbConfig: &DBConfig{}, db: db, sqlDB: sqlDB}
+	require.NoError(t, gm.Close())
+	require.Empty(t, stmts.Keys())
+}
@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject is returned by updates of a versioned model whose row was
+// modified since it was loaded. Reload the row and retry the change.
+var ErrStaleObject = errors.New("orm: stale object, row was modified concurrently")
+
+// ErrLockOutsideTx is returned by queries with LockForUpdate or LockShare
+// that do not run in a transaction, where the lock would be released as
+// soon as the statement completes.
+var ErrLockOutsideTx = errors.New("orm: row lock outside of a transaction")
+
+// LockForUpdate is a scope adding FOR UPDATE to a query: the selected rows
+// are locked against updates, deletes and other locking reads until the
+// transaction ends. Run it on the transaction's *gorm.DB, e.g. Data.DB(ctx)
+// within Data.InTx:
+//
+//	err := d.InTx(ctx, func(ctx context.Context) error {
+//		var acc Account
+//		if err := d.DB(ctx).Scopes(orm.LockForUpdate).First(&acc, id).Error; err != nil {
+//			return err
+//		}
+//		acc.Balance -= amount
+//		return d.DB(ctx).Save(&acc).Error
+//	})
+//
+// Queries outside a transaction fail with ErrLockOutsideTx. Lock rows in
+// the same order everywhere, e.g. by primary key, to avoid deadlocks, and
+// retry the ones that still happen with Data.InTxRetry. SQLite ignores
+// row locks.
+func LockForUpdate(db *gorm.DB) *gorm.DB {
+	return lock(db, clause.LockingStrengthUpdate)
+}
+
+// LockShare is LockForUpdate with FOR SHARE: the selected rows can still be
+// read and share-locked by other transactions but not changed, e.g. to keep
+// a parent row while inserting its children.
+func LockShare(db *gorm.DB) *gorm.DB {
+	return lock(db, clause.LockingStrengthShare)
+}
+
+func lock(db *gorm.DB, strength string) *gorm.DB {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		_ = db.AddError(ErrLockOutsideTx)
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: strength})
+}
+
+// Version is an optimistic lock column. Add it to a model:
+//
+//	type Account struct {
+//		orm.BaseModel
+//		Balance int64
+//		Version orm.Version
+//	}
+//
+// With the OptimisticLock plugin, creating the model sets it to 1 and every
+// update of a loaded model matches on it, increments it and fails with
+// ErrStaleObject when no row had the loaded version.
+type Version int64
+
+var versionType = reflect.TypeOf(Version(0))
+
+const lockVersionKey = "orm:optimistic_lock_version"
+
+// OptimisticLock is a gorm plugin implementing Version. Updates without a
+// loaded version (e.g. Model(&Account{}).Where(...).Update) are not checked.
+type OptimisticLock struct{}
+
+// Name implements gorm.Plugin.
+func (OptimisticLock) Name() string { return "orm:optimistic_lock" }
+
+// Initialize implements gorm.Plugin.
+func (p OptimisticLock) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("orm:optimistic_lock_create", p.create); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("orm:optimistic_lock_before", p.beforeUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Register("orm:optimistic_lock_after", p.afterUpdate)
+}
+
+func versionField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	for _, f := range db.Statement.Schema.Fields {
+		if f.FieldType == versionType {
+			return f
+		}
+	}
+	return nil
+}
+
+func (OptimisticLock) create(db *gorm.DB) {
+	f := versionField(db)
+	if f == nil || db.Error != nil {
+		return
+	}
+	set := func(rv reflect.Value) {
+		if v, zero := f.ValueOf(db.Statement.Context, rv); zero || v.(Version) == 0 {
+			db.AddError(f.Set(db.Statement.Context, rv, Version(1)))
+		}
+	}
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+func (OptimisticLock) beforeUpdate(db *gorm.DB) {
+	f := versionField(db)
+	if f == nil || db.Error != nil || db.Statement.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	v, zero := f.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
+	if zero {
+		return
+	}
+	current := v.(Version)
+	db.InstanceSet(lockVersionKey, current)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: current},
+	}})
+	if len(db.Statement.Selects) > 0 && db.Statement.Selects[0] != "*" {
+		db.Statement.Selects = append(db.Statement.Selects, f.DBName)
+	}
+	db.Statement.SetColumn(f.DBName, current+1)
+}
+
+func (OptimisticLock) afterUpdate(db *gorm.DB) {
+	v, ok := db.InstanceGet(lockVersionKey)
+	if !ok || db.Error != nil || db.Statement.DryRun || db.RowsAffected > 0 {
+		return
+	}
+	// Roll the in-memory version back so the caller sees the version it loaded.
+	db.Statement.SetColumn(versionField(db).DBName, v.(Version))
+	db.AddError(ErrStaleObject)
+}
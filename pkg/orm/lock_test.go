@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type lockedAccount struct {
+	BaseModel
+	Balance int64
+	Version Version
+}
+
+func TestOptimisticLock(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(OptimisticLock{}))
+	require.NoError(t, db.AutoMigrate(&lockedAccount{}))
+
+	acc := &lockedAccount{Balance: 100}
+	require.NoError(t, db.Create(acc).Error)
+	assert.Equal(t, Version(1), acc.Version)
+
+	var first, second lockedAccount
+	require.NoError(t, db.First(&first, acc.ID).Error)
+	require.NoError(t, db.First(&second, acc.ID).Error)
+
+	first.Balance = 50
+	require.NoError(t, db.Save(&first).Error)
+	assert.Equal(t, Version(2), first.Version)
+
+	second.Balance = 70
+	assert.ErrorIs(t, db.Save(&second).Error, ErrStaleObject)
+	assert.Equal(t, Version(1), second.Version)
+	assert.ErrorIs(t, db.Model(&second).Update("balance", 80).Error, ErrStaleObject)
+
+	require.NoError(t, db.Model(&first).Update("balance", 40).Error)
+	assert.Equal(t, Version(3), first.Version)
+
+	var got lockedAccount
+	require.NoError(t, db.First(&got, acc.ID).Error)
+	assert.Equal(t, int64(40), got.Balance)
+	assert.Equal(t, Version(3), got.Version)
+
+	var n int64
+	require.NoError(t, db.Model(&lockedAccount{}).Count(&n).Error)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestLockScopes(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&lockedAccount{}))
+	require.NoError(t, db.Create(&lockedAccount{Balance: 100}).Error)
+
+	var acc lockedAccount
+	assert.ErrorIs(t, db.Scopes(LockForUpdate).First(&acc).Error, ErrLockOutsideTx)
+
+	for strength, scope := range map[string]func(*gorm.DB) *gorm.DB{
+		clause.LockingStrengthUpdate: LockForUpdate,
+		clause.LockingStrengthShare:  LockShare,
+	} {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			q := tx.Scopes(scope).First(&acc)
+			assert.Equal(t, clause.Locking{Strength: strength}, q.Statement.Clauses["FOR"].Expression)
+			return q.Error
+		})
+		require.NoError(t, err, strength)
+	}
+	assert.Equal(t, int64(100), acc.Balance)
+}
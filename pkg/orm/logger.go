@@ -0,0 +1,110 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/go-kratos/kratos-layout/pkg/debugtrace"
+	"github.com/go-kratos/kratos-layout/pkg/profile"
+)
+
+var _ logger.Interface = (*Logger)(nil)
+
+// DefaultSlowThreshold is the slow query threshold used when none is configured.
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// Logger routes gorm logs through a kratos logger, typically pkg/log.ZapLogger,
+// so SQL errors and slow queries show up in the service's structured logs.
+//
+// Failed statements are logged at error level, statements slower than the
+// threshold at warn level and, at logger.Info, every statement at debug level.
+// gorm.ErrRecordNotFound is not treated as an error. Statement time is also
+// recorded for requests sampled by pkg/profile, whatever the level.
+type Logger struct {
+	log   *log.Helper
+	level logger.LogLevel
+	slow  time.Duration
+}
+
+// NewLogger creates a gorm logger. A non-positive slowThreshold disables
+// slow query reporting.
+func NewLogger(l log.Logger, level logger.LogLevel, slowThreshold time.Duration) *Logger {
+	return &Logger{
+		log:   log.NewHelper(log.With(l, "module", "pkg/orm")),
+		level: level,
+		slow:  slowThreshold,
+	}
+}
+
+// ParseLogLevel parses silent, error, warn or info, defaulting to warn.
+func ParseLogLevel(s string) logger.LogLevel {
+	switch strings.ToLower(s) {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "info":
+		return logger.Info
+	default:
+		return logger.Warn
+	}
+}
+
+// LogMode implements logger.Interface.
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	cp := *l
+	cp.level = level
+	return &cp
+}
+
+// Info implements logger.Interface.
+func (l *Logger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Info {
+		l.log.WithContext(ctx).Infof(msg, data...)
+	}
+}
+
+// Warn implements logger.Interface.
+func (l *Logger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Warn {
+		l.log.WithContext(ctx).Warnf(msg, data...)
+	}
+}
+
+// Error implements logger.Interface.
+func (l *Logger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Error {
+		l.log.WithContext(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace implements logger.Interface.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	profile.Observe(ctx, profile.DB, elapsed)
+	if debugtrace.Enabled(ctx) {
+		sql, rows := fc()
+		debugtrace.Record(ctx, debugtrace.KindDB, sql, begin, rows, err)
+	}
+	if l.level <= logger.Silent {
+		return
+	}
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log.WithContext(ctx).Errorw("msg", "sql failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err)
+	case l.slow > 0 && elapsed > l.slow && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.log.WithContext(ctx).Warnw("msg", fmt.Sprintf("slow sql >= %v", l.slow), "sql", sql, "rows", rows, "elapsed", elapsed)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.log.WithContext(ctx).Debugw("msg", "sql", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLogger_Trace(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(log.NewStdLogger(&buf), logger.Warn, 100*time.Millisecond)
+	ctx := context.Background()
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+
+	l.Trace(ctx, time.Now(), sql, nil)
+	assert.Empty(t, buf.String(), "fast queries are not logged at warn level")
+
+	l.Trace(ctx, time.Now(), sql, gorm.ErrRecordNotFound)
+	assert.Empty(t, buf.String(), "record not found is not an error")
+
+	l.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	assert.Contains(t, buf.String(), "WARN")
+	assert.Contains(t, buf.String(), "sql=SELECT 1")
+	buf.Reset()
+
+	l.Trace(ctx, time.Now(), sql, errors.New("deadlock"))
+	assert.Contains(t, buf.String(), "ERROR")
+	assert.Contains(t, buf.String(), "error=deadlock")
+	buf.Reset()
+
+	l.LogMode(logger.Info).Trace(ctx, time.Now(), sql, nil)
+	assert.Contains(t, buf.String(), "DEBUG")
+	buf.Reset()
+
+	l.LogMode(logger.Silent).Trace(ctx, time.Now(), sql, errors.New("deadlock"))
+	assert.Empty(t, buf.String())
+}
+
+func TestParseLogLevel(t *testing.T) {
+	assert.Equal(t, logger.Silent, ParseLogLevel("silent"))
+	assert.Equal(t, logger.Info, ParseLogLevel("INFO"))
+	assert.Equal(t, logger.Warn, ParseLogLevel(""))
+}
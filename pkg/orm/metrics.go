@@ -0,0 +1,37 @@
+//go:build !nometrics
+
+package orm
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewStatementMetrics creates the statement metrics plugin, registered with
+// reg:
+//
+//	db_statement_duration_seconds{table, operation}
+//	db_statement_errors_total{table, operation}
+func NewStatementMetrics(reg prometheus.Registerer) (*StatementMetrics, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_statement_duration_seconds",
+		Help:    "Duration of SQL statements.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"table", "operation"})
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_statement_errors_total",
+		Help: "Number of failed SQL statements.",
+	}, []string{"table", "operation"})
+	for _, c := range []prometheus.Collector{duration, errors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return &StatementMetrics{observe: func(table, operation string, elapsed time.Duration, failed bool) {
+		duration.WithLabelValues(table, operation).Observe(elapsed.Seconds())
+		if failed {
+			errors.WithLabelValues(table, operation).Inc()
+		}
+	}}, nil
+}
@@ -0,0 +1,72 @@
+//go:build !nometrics
+
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type meteredUser struct {
+	ID   uint64
+	Name string
+}
+
+func TestStatementMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewStatementMetrics(reg)
+	require.NoError(t, err)
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&meteredUser{}))
+	require.NoError(t, db.Use(m))
+
+	require.NoError(t, db.Create(&meteredUser{Name: "a"}).Error)
+	var u meteredUser
+	require.NoError(t, db.First(&u).Error)
+	assert.ErrorIs(t, db.First(&u, 42).Error, gorm.ErrRecordNotFound)
+	require.NoError(t, db.Model(&u).Update("name", "b").Error)
+	require.NoError(t, db.Exec("SELECT 1").Error)
+	assert.Error(t, db.Table("missing").Where("id = 1").Delete(&meteredUser{}).Error)
+	require.NoError(t, db.Session(&gorm.Session{DryRun: true}).Create(&meteredUser{Name: "c"}).Error)
+
+	assert.Equal(t, 5, testutil.CollectAndCount(reg, "db_statement_duration_seconds"))
+	expected := `
+# HELP db_statement_errors_total Number of failed SQL statements.
+# TYPE db_statement_errors_total counter
+db_statement_errors_total{operation="delete",table="missing"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(reg, strings.NewReader(expected), "db_statement_errors_total"))
+	count := func(table, op string) uint64 {
+		var n uint64
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+		for _, mf := range mfs {
+			if mf.GetName() != "db_statement_duration_seconds" {
+				continue
+			}
+			for _, metric := range mf.GetMetric() {
+				labels := map[string]string{}
+				for _, l := range metric.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+				if labels["table"] == table && labels["operation"] == op {
+					n = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+		return n
+	}
+	assert.EqualValues(t, 1, count("metered_users", "create"), "the dry run is not timed")
+	assert.EqualValues(t, 2, count("metered_users", "query"))
+	assert.EqualValues(t, 1, count("metered_users", "update"))
+	assert.EqualValues(t, 1, count(noTable, "raw"))
+	assert.EqualValues(t, 1, count("missing", "delete"))
+}
@@ -0,0 +1,118 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var registry struct {
+	mu     sync.Mutex
+	models map[string][]any
+	types  map[string]map[reflect.Type]bool
+}
+
+// RegisterModel adds models to the set migrated by DB.Migrate of MySQL
+// connections. Registering the same type twice is a no-op. Call it from
+// package init or before Migrate.
+func RegisterModel(models ...any) {
+	RegisterModelFor(DriverMySQL, models...)
+}
+
+// RegisterModelFor is RegisterModel for the connections of driver, e.g.
+// DriverClickHouse for analytics tables.
+func RegisterModelFor(driver string, models ...any) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.types == nil {
+		registry.models = make(map[string][]any)
+		registry.types = make(map[string]map[reflect.Type]bool)
+	}
+	if registry.types[driver] == nil {
+		registry.types[driver] = make(map[reflect.Type]bool)
+	}
+	for _, m := range models {
+		t := reflect.TypeOf(m)
+		if registry.types[driver][t] {
+			continue
+		}
+		registry.types[driver][t] = true
+		registry.models[driver] = append(registry.models[driver], m)
+	}
+}
+
+// Models returns the MySQL models in registration order.
+func Models() []any {
+	return ModelsFor(DriverMySQL)
+}
+
+// ModelsFor returns the models of driver in registration order.
+func ModelsFor(driver string) []any {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return append([]any(nil), registry.models[driver]...)
+}
+
+// Migrate runs AutoMigrate over the registered models and returns the DDL
+// statements it applied. AutoMigrate only adds missing tables, columns and
+// indexes; it never drops or narrows anything.
+func (gm *gormMysql) Migrate(ctx context.Context) ([]string, error) {
+	if gm.db == nil {
+		return nil, fmt.Errorf("db is nil, please init db first")
+	}
+	return migrate(gm.db.WithContext(ctx), Models())
+}
+
+func migrate(db *gorm.DB, models []any) ([]string, error) {
+	if len(models) == 0 {
+		return nil, nil
+	}
+	rec := &ddlRecorder{Interface: db.Logger}
+	if err := db.Session(&gorm.Session{Logger: rec}).AutoMigrate(models...); err != nil {
+		return rec.statements(), fmt.Errorf("auto migrate: %w", err)
+	}
+	return rec.statements(), nil
+}
+
+// ddlRecorder records the schema changing statements passing through a
+// gorm logger.
+type ddlRecorder struct {
+	logger.Interface
+
+	mu    sync.Mutex
+	stmts []string
+}
+
+func (r *ddlRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+
+func (r *ddlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, rows := fc()
+	if err == nil && isDDL(sql) {
+		r.mu.Lock()
+		r.stmts = append(r.stmts, sql)
+		r.mu.Unlock()
+	}
+	r.Interface.Trace(ctx, begin, func() (string, int64) { return sql, rows }, err)
+}
+
+func (r *ddlRecorder) statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.stmts...)
+}
+
+func isDDL(sql string) bool {
+	sql = strings.ToUpper(strings.TrimSpace(sql))
+	for _, prefix := range []string{"CREATE ", "ALTER ", "DROP ", "RENAME "} {
+		if strings.HasPrefix(sql, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type migrateUser struct {
+	ID   uint64
+	Name string
+}
+
+func TestMigrate(t *testing.T) {
+	RegisterModel(&migrateUser{})
+	RegisterModel(&migrateUser{})
+	assert.Len(t, Models(), 1, "duplicate registrations are ignored")
+
+	db := ormtest.Open(t)
+	gm := &gormMysql{db: db}
+	ddl, err := gm.Migrate(context.Background())
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable(&migrateUser{}))
+	require.Len(t, ddl, 1)
+	assert.Contains(t, ddl[0], "CREATE TABLE `migrate_users`")
+
+	ddl, err = gm.Migrate(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, ddl, "an up to date schema applies nothing")
+
+	_, err = (&gormMysql{}).Migrate(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BaseModel provides an auto-increment ID, audit timestamps maintained by
+// gorm and soft delete. Embed it in models:
+//
+//	type User struct {
+//		orm.BaseModel
+//		Name string
+//	}
+//
+// Delete on such a model sets DeletedAt instead of removing the row, and
+// queries skip soft-deleted rows unless scoped with WithDeleted or OnlyDeleted.
+type BaseModel struct {
+	ID        uint64         `gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// WithDeleted is a scope including soft-deleted rows.
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// OnlyDeleted is a scope selecting soft-deleted rows only.
+func OnlyDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where(clause.Neq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_at"},
+		Value:  nil,
+	})
+}
+
+// Restore clears the soft delete of the rows matched by db and model, e.g.
+// Restore(db, &User{}, id).
+func Restore(db *gorm.DB, model any, conds ...any) error {
+	q := db.Unscoped().Model(model)
+	if len(conds) > 0 {
+		q = q.Where(conds[0], conds[1:]...)
+	}
+	return q.Update("deleted_at", nil).Error
+}
+
+// HardDelete permanently removes the rows matched by model and conds,
+// bypassing soft delete.
+func HardDelete(db *gorm.DB, model any, conds ...any) error {
+	return db.Unscoped().Delete(model, conds...).Error
+}
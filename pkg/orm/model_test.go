@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type softUser struct {
+	BaseModel
+	Name string
+}
+
+func TestBaseModel_SoftDelete(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&softUser{}))
+
+	alice, bob := &softUser{Name: "alice"}, &softUser{Name: "bob"}
+	require.NoError(t, db.Create(alice).Error)
+	require.NoError(t, db.Create(bob).Error)
+	assert.NotZero(t, alice.ID)
+	assert.False(t, alice.CreatedAt.IsZero())
+
+	require.NoError(t, db.Delete(&softUser{}, bob.ID).Error)
+
+	count := func(scopes ...func(*gorm.DB) *gorm.DB) int64 {
+		var n int64
+		require.NoError(t, db.Model(&softUser{}).Scopes(scopes...).Count(&n).Error)
+		return n
+	}
+	assert.Equal(t, int64(1), count())
+	assert.Equal(t, int64(2), count(WithDeleted))
+	assert.Equal(t, int64(1), count(OnlyDeleted))
+
+	require.NoError(t, Restore(db, &softUser{}, "id = ?", bob.ID))
+	assert.Equal(t, int64(2), count())
+
+	require.NoError(t, HardDelete(db, &softUser{}, bob.ID))
+	assert.Equal(t, int64(1), count(WithDeleted))
+}
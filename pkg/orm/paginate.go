@@ -0,0 +1,142 @@
+package orm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page sizes.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidCursor is returned for a cursor that wasn't produced by FindAfter.
+var ErrInvalidCursor = errors.New("orm: invalid cursor")
+
+// Page is one page of query results.
+type Page[T any] struct {
+	Items []T
+	// Total is the number of matching rows. Keyset pagination doesn't count
+	// and leaves it at -1.
+	Total int64
+	// NextCursor fetches the following page; empty on the last page.
+	NextCursor string
+}
+
+// pageSize clamps size to (0, MaxPageSize], defaulting to DefaultPageSize.
+func pageSize(size int) int {
+	switch {
+	case size <= 0:
+		return DefaultPageSize
+	case size > MaxPageSize:
+		return MaxPageSize
+	}
+	return size
+}
+
+// Paginate is a scope applying LIMIT/OFFSET for the 1-based page:
+//
+//	db.Scopes(orm.Paginate(2, 20)).Find(&users)
+func Paginate(page, size int) func(*gorm.DB) *gorm.DB {
+	size = pageSize(size)
+	page = max(page, 1)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset((page - 1) * size).Limit(size)
+	}
+}
+
+// FindPage counts the rows matched by db and loads the 1-based page. The
+// next cursor is the following page number. Deep pages get slow as the
+// database still reads every skipped row; use FindAfter for feeds and exports.
+func FindPage[T any](db *gorm.DB, page, size int) (Page[T], error) {
+	var p Page[T]
+	var model T
+	if err := db.Session(&gorm.Session{}).Model(&model).Count(&p.Total).Error; err != nil {
+		return p, fmt.Errorf("count: %w", err)
+	}
+	if err := db.Scopes(Paginate(page, size)).Find(&p.Items).Error; err != nil {
+		return p, err
+	}
+	page, size = max(page, 1), pageSize(size)
+	if int64(page*size) < p.Total {
+		p.NextCursor = strconv.Itoa(page + 1)
+	}
+	return p, nil
+}
+
+// FindAfter loads the page of rows following cursor, ordered by column
+// ascending (keyset pagination). column must be unique and key must return
+// its value for an item; an empty cursor starts from the beginning.
+//
+//	page, err := orm.FindAfter(db.Where("tenant = ?", t), "id", cursor, 50,
+//		func(u User) any { return u.ID })
+func FindAfter[T any](db *gorm.DB, column, cursor string, size int, key func(T) any) (Page[T], error) {
+	p := Page[T]{Total: -1}
+	size = pageSize(size)
+	col := clause.Column{Name: column}
+	q := db.Order(clause.OrderByColumn{Column: col}).Limit(size + 1)
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		q = q.Where(clause.Gt{Column: col, Value: after})
+	}
+	if err := q.Find(&p.Items).Error; err != nil {
+		return p, err
+	}
+	if len(p.Items) > size {
+		p.Items = p.Items[:size]
+		next, err := encodeCursor(key(p.Items[size-1]))
+		if err != nil {
+			return p, err
+		}
+		p.NextCursor = next
+	}
+	return p, nil
+}
+
+func encodeCursor(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// Keep integer keys exact instead of going through float64.
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	}
+	switch v.(type) {
+	case string, bool:
+		return v, nil
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type pageItem struct {
+	ID   uint64
+	Name string
+}
+
+func newPageDB(t *testing.T, n int) *gorm.DB {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&pageItem{}))
+	for i := 1; i <= n; i++ {
+		require.NoError(t, db.Create(&pageItem{ID: uint64(i), Name: fmt.Sprintf("item-%d", i)}).Error)
+	}
+	return db
+}
+
+func TestFindPage(t *testing.T) {
+	db := newPageDB(t, 5)
+
+	p, err := FindPage[pageItem](db.Order("id"), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), p.Total)
+	assert.Len(t, p.Items, 2)
+	assert.Equal(t, "2", p.NextCursor)
+
+	p, err = FindPage[pageItem](db.Where("id > ?", 1).Order("id"), 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), p.Total)
+	assert.Equal(t, uint64(4), p.Items[0].ID)
+	assert.Empty(t, p.NextCursor)
+}
+
+func TestFindAfter(t *testing.T) {
+	db := newPageDB(t, 5)
+	key := func(i pageItem) any { return i.ID }
+
+	var ids []uint64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		p, err := FindAfter(db, "id", cursor, 2, key)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), p.Total)
+		for _, item := range p.Items {
+			ids = append(ids, item.ID)
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		cursor = p.NextCursor
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids)
+
+	_, err := FindAfter(db, "id", "not a cursor!", 2, key)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestPaginate_Clamp(t *testing.T) {
+	assert.Equal(t, DefaultPageSize, pageSize(0))
+	assert.Equal(t, MaxPageSize, pageSize(1000))
+}
@@ -0,0 +1,103 @@
+package orm
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Replicated holds the region that last wrote a row and its version, for
+// tables written by more than one region of an active-active deployment.
+// Embed it in models written with UpsertReplicated:
+//
+//	type Profile struct {
+//		ID string `gorm:"primaryKey;size:64"` // e.g. from idgen
+//		orm.Replicated
+//		Nickname  string
+//		UpdatedAt time.Time
+//	}
+//
+// The writer increments Version on every change. When both regions change
+// a row concurrently, the higher version wins and a tie goes to the greater
+// region name, so every region converges on the same row whatever the
+// order the changes are replicated in.
+type Replicated struct {
+	Region  string `gorm:"size:32;not null;default:''"`
+	Version int64  `gorm:"not null;default:0"`
+}
+
+// Newer reports whether r wins over o.
+func (r Replicated) Newer(o Replicated) bool {
+	return r.Version > o.Version || (r.Version == o.Version && r.Region > o.Region)
+}
+
+// UpsertReplicated inserts value, a pointer to a model embedding Replicated,
+// or updates the row with the same primary key when value is Newer than it.
+// It reports whether the row was written: false means the stored row already
+// is the same or a newer version, and value is dropped. Creation timestamps
+// are never overwritten.
+func UpsertReplicated(db *gorm.DB, value any) (bool, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return false, err
+	}
+	s := stmt.Schema
+	region, version := s.LookUpField("region"), s.LookUpField("version")
+	if region == nil || version == nil || len(s.PrimaryFields) == 0 {
+		return false, fmt.Errorf("upsert %s: model must have a primary key and embed orm.Replicated", s.Name)
+	}
+
+	onConflict := clause.OnConflict{}
+	for _, f := range s.PrimaryFields {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: f.DBName})
+	}
+	var columns []string
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.PrimaryKey || !f.Updatable || f.AutoCreateTime != 0 || f == region || f == version {
+			continue
+		}
+		columns = append(columns, f.DBName)
+	}
+	// On MySQL region and version go last, as their new values are visible
+	// to the assignments after them.
+	columns = append(columns, region.DBName, version.DBName)
+
+	if db.Dialector.Name() == DriverMySQL {
+		newer := replicatedNewer(s, "VALUES(?)", func(f *schema.Field) any { return clause.Column{Name: f.DBName} })
+		for _, c := range columns {
+			col := clause.Column{Name: c}
+			onConflict.DoUpdates = append(onConflict.DoUpdates, clause.Assignment{
+				Column: col,
+				Value:  clause.Expr{SQL: "IF(?, VALUES(?), ?)", Vars: []any{newer, col, col}},
+			})
+		}
+	} else {
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			replicatedNewer(s, "?", func(f *schema.Field) any { return clause.Column{Table: "excluded", Name: f.DBName} }),
+		}}
+	}
+
+	res := db.Clauses(onConflict).Create(value)
+	if res.Error != nil {
+		return false, fmt.Errorf("upsert %s: %w", s.Table, res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// replicatedNewer is the condition under which the incoming row wins over
+// the stored one. format wraps the incoming column, e.g. VALUES(?) on MySQL.
+func replicatedNewer(s *schema.Schema, format string, incoming func(*schema.Field) any) clause.Expr {
+	region, version := s.LookUpField("region"), s.LookUpField("version")
+	stored := func(f *schema.Field) clause.Column { return clause.Column{Table: s.Table, Name: f.DBName} }
+	return clause.Expr{
+		SQL: "(" + format + " > ? OR (" + format + " = ? AND " + format + " > ?))",
+		Vars: []any{
+			incoming(version), stored(version),
+			incoming(version), stored(version),
+			incoming(region), stored(region),
+		},
+	}
+}
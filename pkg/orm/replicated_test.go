@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type replicatedProfile struct {
+	ID string `gorm:"primaryKey;size:64"`
+	Replicated
+	Nickname  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func TestUpsertReplicated(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&replicatedProfile{}))
+
+	upsert := func(region string, version int64, nickname string) bool {
+		t.Helper()
+		ok, err := UpsertReplicated(db, &replicatedProfile{ID: "p1", Replicated: Replicated{Region: region, Version: version}, Nickname: nickname})
+		require.NoError(t, err)
+		return ok
+	}
+	stored := func() replicatedProfile {
+		t.Helper()
+		var p replicatedProfile
+		require.NoError(t, db.First(&p, "id = ?", "p1").Error)
+		return p
+	}
+
+	assert.True(t, upsert("cn-east", 1, "alice"), "insert")
+	created := stored().CreatedAt
+
+	assert.True(t, upsert("cn-north", 2, "alicia"), "newer version")
+	assert.False(t, upsert("cn-east", 1, "stale"), "replayed older version")
+	assert.False(t, upsert("cn-east", 2, "tie"), "tie won by the greater region")
+	assert.True(t, upsert("cn-south", 2, "south"), "tie with a greater region")
+
+	p := stored()
+	assert.Equal(t, Replicated{Region: "cn-south", Version: 2}, p.Replicated)
+	assert.Equal(t, "south", p.Nickname)
+	assert.True(t, created.Equal(p.CreatedAt), "created_at is kept")
+
+	_, err := UpsertReplicated(db, &softUser{Name: "bob"})
+	assert.ErrorContains(t, err, "embed orm.Replicated")
+}
+
+func TestReplicated_Newer(t *testing.T) {
+	assert.True(t, Replicated{Region: "a", Version: 2}.Newer(Replicated{Region: "b", Version: 1}))
+	assert.True(t, Replicated{Region: "b", Version: 1}.Newer(Replicated{Region: "a", Version: 1}))
+	assert.False(t, Replicated{Region: "a", Version: 1}.Newer(Replicated{Region: "a", Version: 1}))
+}
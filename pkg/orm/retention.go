@@ -0,0 +1,343 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Retention actions.
+const (
+	RetentionDelete    = "delete"
+	RetentionAnonymize = "anonymize"
+)
+
+// Audit actions of retention enforcement and legal holds.
+const (
+	AuditPurge       = "purge"
+	AuditAnonymize   = "anonymize"
+	AuditLegalHold   = "legal_hold"
+	AuditHoldRelease = "hold_release"
+)
+
+// RetentionActor is the audit_logs actor of enforcement without WithActor.
+const RetentionActor = "retention"
+
+// DefaultRetentionBatch is the number of rows EnforceRetention handles per
+// transaction when no batch size is given.
+const DefaultRetentionBatch = 500
+
+// ErrNoLegalHold is returned by ReleaseLegalHold when the row is not held.
+var ErrNoLegalHold = errors.New("orm: no active legal hold")
+
+// RetentionPolicy declares how long the rows of a model are kept.
+type RetentionPolicy struct {
+	// Period after which a row expires, measured from Column.
+	Period time.Duration
+	// Column is the time column rows expire by; empty uses created_at.
+	Column string
+	// Action is RetentionDelete (default), which removes expired rows, soft
+	// deleted ones included, or RetentionAnonymize.
+	Action string
+	// Anonymize maps the columns RetentionAnonymize overwrites to their
+	// replacement values, e.g. {"phone": "", "email": nil}.
+	Anonymize map[string]any
+	// AnonymizedColumn is a nullable time column set when a row is
+	// anonymized, so it is skipped afterwards; empty uses anonymized_at.
+	AnonymizedColumn string
+}
+
+// Retained is implemented by models with a retention period:
+//
+//	func (Order) RetentionPolicy() orm.RetentionPolicy {
+//		return orm.RetentionPolicy{Period: 3 * 365 * 24 * time.Hour}
+//	}
+type Retained interface {
+	RetentionPolicy() RetentionPolicy
+}
+
+// LegalHold exempts a row from retention enforcement while it is active,
+// i.e. until ReleasedAt is set. Released holds are kept as history.
+type LegalHold struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement"`
+	CreatedAt  time.Time  `gorm:"not null"`
+	Entity     string     `gorm:"size:128;not null;index:idx_legal_holds_entity"`
+	EntityID   string     `gorm:"size:64;not null;index:idx_legal_holds_entity"`
+	Reason     string     `gorm:"size:255;not null;default:''"`
+	Actor      string     `gorm:"size:128;not null;default:''"`
+	ReleasedAt *time.Time `gorm:"index"`
+}
+
+// TableName implements gorm's tabler.
+func (LegalHold) TableName() string { return "legal_holds" }
+
+// RetentionResult is the outcome of enforcing the policy of one table.
+type RetentionResult struct {
+	Entity   string
+	Action   string
+	Affected int // rows deleted or anonymized
+	Held     int // expired rows kept because of a legal hold
+}
+
+// retentionDetail is the After column of purge and anonymize audit rows.
+// Deleted values are never copied to audit_logs.
+type retentionDetail struct {
+	Column  string   `json:"column"`
+	Period  string   `json:"period"`
+	Columns []string `json:"columns,omitempty"`
+}
+
+// PlaceLegalHold puts the row entity (a table name) with primary key id on
+// legal hold and records it in audit_logs. Holding a held row is a no-op.
+func PlaceLegalHold(db *gorm.DB, entity, id, reason, actor string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := activeHolds(tx, entity, []string{id}).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		hold := LegalHold{Entity: entity, EntityID: id, Reason: reason, Actor: actor}
+		if err := tx.Create(&hold).Error; err != nil {
+			return err
+		}
+		after, _ := json.Marshal(map[string]string{"reason": reason})
+		return tx.Create(&AuditLog{Actor: actor, Action: AuditLegalHold, Entity: entity, EntityID: id, After: after}).Error
+	})
+}
+
+// ReleaseLegalHold releases the active hold of a row and records it in
+// audit_logs, or returns ErrNoLegalHold.
+func ReleaseLegalHold(db *gorm.DB, entity, id, actor string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := activeHolds(tx, entity, []string{id}).Update("released_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w on %s %s", ErrNoLegalHold, entity, id)
+		}
+		return tx.Create(&AuditLog{Actor: actor, Action: AuditHoldRelease, Entity: entity, EntityID: id}).Error
+	})
+}
+
+func activeHolds(db *gorm.DB, entity string, ids []string) *gorm.DB {
+	return db.Model(&LegalHold{}).Where("entity = ? AND entity_id IN ? AND released_at IS NULL", entity, ids)
+}
+
+// EnforceRetention deletes or anonymizes the expired rows of the models
+// implementing Retained; other models are skipped. Rows on legal hold are
+// kept. Each batch of rows is locked, changed and recorded in audit_logs,
+// one row per change, in one transaction, so concurrent runs on several
+// instances do not record a row twice.
+//
+// Changes are made without the model, so AuditTrail does not record them
+// again with the deleted values. Policies of different tables are enforced
+// independently; their errors are joined.
+func EnforceRetention(ctx context.Context, db *gorm.DB, batch int, models ...any) ([]RetentionResult, error) {
+	if batch <= 0 {
+		batch = DefaultRetentionBatch
+	}
+	actor := ActorFromContext(ctx)
+	if actor == "" {
+		actor = RetentionActor
+	}
+	db = db.WithContext(ctx)
+	var (
+		results []RetentionResult
+		errs    []error
+	)
+	for _, m := range models {
+		r, ok := m.(Retained)
+		if !ok {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			errs = append(errs, fmt.Errorf("retention: parse %T: %w", m, err))
+			continue
+		}
+		e, err := newRetentionEnforcer(stmt.Schema, r.RetentionPolicy(), batch, actor)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		res, err := e.run(db)
+		results = append(results, res)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retention: %s: %w", stmt.Schema.Table, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+type retentionEnforcer struct {
+	schema  *schema.Schema
+	policy  RetentionPolicy
+	pk      *schema.Field
+	batch   int
+	actor   string
+	columns []string // anonymized columns, sorted
+}
+
+func newRetentionEnforcer(s *schema.Schema, p RetentionPolicy, batch int, actor string) (*retentionEnforcer, error) {
+	if p.Column == "" {
+		p.Column = "created_at"
+	}
+	if p.Action == "" {
+		p.Action = RetentionDelete
+	}
+	if p.AnonymizedColumn == "" {
+		p.AnonymizedColumn = "anonymized_at"
+	}
+	e := &retentionEnforcer{schema: s, policy: p, pk: s.PrioritizedPrimaryField, batch: batch, actor: actor}
+	switch {
+	case p.Period <= 0:
+		return nil, fmt.Errorf("retention: %s: period must be positive", s.Table)
+	case e.pk == nil:
+		return nil, fmt.Errorf("retention: %s: a single primary key is required", s.Table)
+	case s.LookUpField(p.Column) == nil:
+		return nil, fmt.Errorf("retention: %s: no column %s", s.Table, p.Column)
+	}
+	switch p.Action {
+	case RetentionDelete:
+	case RetentionAnonymize:
+		if len(p.Anonymize) == 0 {
+			return nil, fmt.Errorf("retention: %s: no columns to anonymize", s.Table)
+		}
+		if s.LookUpField(p.AnonymizedColumn) == nil {
+			return nil, fmt.Errorf("retention: %s: no column %s", s.Table, p.AnonymizedColumn)
+		}
+		for c := range p.Anonymize {
+			if s.LookUpField(c) == nil {
+				return nil, fmt.Errorf("retention: %s: no column %s", s.Table, c)
+			}
+			e.columns = append(e.columns, c)
+		}
+		slices.Sort(e.columns)
+	default:
+		return nil, fmt.Errorf("retention: %s: unknown action %q", s.Table, p.Action)
+	}
+	return e, nil
+}
+
+// run enforces the policy batch by batch in primary key order.
+func (e *retentionEnforcer) run(db *gorm.DB) (RetentionResult, error) {
+	res := RetentionResult{Entity: e.schema.Table, Action: e.policy.Action}
+	cutoff := time.Now().Add(-e.policy.Period)
+	var last any
+	for {
+		var n int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			ids, err := e.expired(tx, cutoff, last)
+			if err != nil {
+				return err
+			}
+			n = ids.Len()
+			if n == 0 {
+				return nil
+			}
+			last = ids.Index(n - 1).Interface()
+			free, held, err := e.withoutHolds(tx, ids)
+			if err != nil {
+				return err
+			}
+			res.Held += held
+			if len(free) == 0 {
+				return nil
+			}
+			if err := e.apply(tx, free); err != nil {
+				return err
+			}
+			if err := e.audit(tx, free); err != nil {
+				return err
+			}
+			res.Affected += len(free)
+			return nil
+		})
+		if err != nil || n < e.batch {
+			return res, err
+		}
+		if err := db.Statement.Context.Err(); err != nil {
+			return res, err
+		}
+	}
+}
+
+// expired locks and returns the primary keys of the next batch of expired
+// rows after last.
+func (e *retentionEnforcer) expired(tx *gorm.DB, cutoff time.Time, last any) (reflect.Value, error) {
+	pk := clause.Column{Name: e.pk.DBName}
+	q := tx.Table(e.schema.Table).Scopes(LockForUpdate).
+		Where(clause.Lt{Column: clause.Column{Name: e.policy.Column}, Value: cutoff})
+	if e.policy.Action == RetentionAnonymize {
+		q = q.Where(clause.Eq{Column: clause.Column{Name: e.policy.AnonymizedColumn}, Value: nil})
+	}
+	if last != nil {
+		q = q.Where(clause.Gt{Column: pk, Value: last})
+	}
+	ids := reflect.New(reflect.SliceOf(e.pk.FieldType))
+	err := q.Order(clause.OrderByColumn{Column: pk}).Limit(e.batch).Pluck(e.pk.DBName, ids.Interface()).Error
+	return ids.Elem(), err
+}
+
+// withoutHolds drops the held rows from ids.
+func (e *retentionEnforcer) withoutHolds(tx *gorm.DB, ids reflect.Value) ([]any, int, error) {
+	keys := make([]string, ids.Len())
+	for i := range keys {
+		keys[i] = fmt.Sprint(ids.Index(i).Interface())
+	}
+	var held []string
+	if err := activeHolds(tx, e.schema.Table, keys).Pluck("entity_id", &held).Error; err != nil {
+		return nil, 0, fmt.Errorf("read legal holds: %w", err)
+	}
+	var free []any
+	for i, k := range keys {
+		if !slices.Contains(held, k) {
+			free = append(free, ids.Index(i).Interface())
+		}
+	}
+	return free, ids.Len() - len(free), nil
+}
+
+func (e *retentionEnforcer) apply(tx *gorm.DB, ids []any) error {
+	pk := clause.Column{Name: e.pk.DBName}
+	if e.policy.Action == RetentionDelete {
+		return tx.Exec("DELETE FROM ? WHERE ? IN ?", clause.Table{Name: e.schema.Table}, pk, ids).Error
+	}
+	values := make(map[string]any, len(e.columns)+1)
+	for _, c := range e.columns {
+		values[c] = e.policy.Anonymize[c]
+	}
+	values[e.policy.AnonymizedColumn] = time.Now()
+	// Without a model, the update has no schema for callbacks to audit.
+	return tx.Table(e.schema.Table).Where(clause.IN{Column: pk, Values: ids}).UpdateColumns(values).Error
+}
+
+func (e *retentionEnforcer) audit(tx *gorm.DB, ids []any) error {
+	action := AuditPurge
+	if e.policy.Action == RetentionAnonymize {
+		action = AuditAnonymize
+	}
+	after, _ := json.Marshal(retentionDetail{Column: e.policy.Column, Period: e.policy.Period.String(), Columns: e.columns})
+	logs := make([]AuditLog, len(ids))
+	for i, id := range ids {
+		logs[i] = AuditLog{Actor: e.actor, Action: action, Entity: e.schema.Table, EntityID: fmt.Sprint(id), After: after}
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&logs).Error; err != nil {
+		return fmt.Errorf("write audit logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type retainedSession struct {
+	BaseModel
+	Token string
+}
+
+func (retainedSession) RetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{Period: 24 * time.Hour}
+}
+
+type retainedCustomer struct {
+	BaseModel
+	Name         string
+	Phone        string
+	AnonymizedAt *time.Time
+}
+
+func (retainedCustomer) RetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{Period: 24 * time.Hour, Action: RetentionAnonymize, Anonymize: map[string]any{"name": "", "phone": nil}}
+}
+
+type invalidRetention struct {
+	ID   uint64
+	Name string
+}
+
+func (invalidRetention) RetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{Period: time.Hour}
+}
+
+func TestEnforceRetention(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(AuditTrail{Tables: []string{"retained_sessions", "retained_customers"}}))
+	require.NoError(t, db.AutoMigrate(&retainedSession{}, &retainedCustomer{}, &unauditedNote{}, &LegalHold{}, &AuditLog{}))
+	ctx := context.Background()
+
+	old := time.Now().Add(-48 * time.Hour)
+	for i := range 5 {
+		s := &retainedSession{Token: "t"}
+		if i < 4 {
+			s.CreatedAt = old
+		}
+		require.NoError(t, db.Create(s).Error)
+	}
+	require.NoError(t, db.Delete(&retainedSession{}, 2).Error)
+	require.NoError(t, db.Create(&retainedCustomer{BaseModel: BaseModel{CreatedAt: old}, Name: "bob", Phone: "13800000000"}).Error)
+	require.NoError(t, db.Create(&retainedCustomer{Name: "carol", Phone: "13900000000"}).Error)
+	require.NoError(t, db.Where("1 = 1").Delete(&AuditLog{}).Error)
+
+	require.NoError(t, PlaceLegalHold(db, "retained_sessions", "3", "case 42", "alice"))
+	require.NoError(t, PlaceLegalHold(db, "retained_sessions", "3", "case 42", "alice"))
+
+	models := []any{&unauditedNote{}, &retainedSession{}, &retainedCustomer{}}
+	results, err := EnforceRetention(ctx, db, 2, models...)
+	require.NoError(t, err)
+	assert.Equal(t, []RetentionResult{
+		{Entity: "retained_sessions", Action: RetentionDelete, Affected: 3, Held: 1},
+		{Entity: "retained_customers", Action: RetentionAnonymize, Affected: 1},
+	}, results)
+
+	var sessions []uint64
+	require.NoError(t, db.Unscoped().Model(&retainedSession{}).Order("id").Pluck("id", &sessions).Error)
+	assert.Equal(t, []uint64{3, 5}, sessions, "soft deleted rows are purged, held ones kept")
+	var customers []retainedCustomer
+	require.NoError(t, db.Order("id").Find(&customers).Error)
+	require.Len(t, customers, 2)
+	assert.Empty(t, customers[0].Name)
+	assert.Empty(t, customers[0].Phone)
+	assert.NotNil(t, customers[0].AnonymizedAt)
+	assert.Equal(t, "carol", customers[1].Name)
+
+	var logs []AuditLog
+	require.NoError(t, db.Order("id").Find(&logs).Error)
+	require.Len(t, logs, 5, "one hold and one row per change, none from AuditTrail")
+	assert.Equal(t, AuditLegalHold, logs[0].Action)
+	assert.Equal(t, "alice", logs[0].Actor)
+	for _, l := range logs[1:4] {
+		assert.Equal(t, AuditPurge, l.Action)
+		assert.Equal(t, RetentionActor, l.Actor)
+		assert.Equal(t, "retained_sessions", l.Entity)
+	}
+	assert.Equal(t, []string{"1", "2", "4"}, []string{logs[1].EntityID, logs[2].EntityID, logs[3].EntityID})
+	assert.Equal(t, AuditAnonymize, logs[4].Action)
+	var detail retentionDetail
+	require.NoError(t, json.Unmarshal(logs[4].After, &detail))
+	assert.Equal(t, retentionDetail{Column: "created_at", Period: "24h0m0s", Columns: []string{"name", "phone"}}, detail)
+
+	// Anonymized rows are not anonymized again; released holds no longer
+	// protect their row.
+	require.NoError(t, ReleaseLegalHold(db, "retained_sessions", "3", "alice"))
+	assert.ErrorIs(t, ReleaseLegalHold(db, "retained_sessions", "3", "alice"), ErrNoLegalHold)
+	results, err = EnforceRetention(WithActor(ctx, "gdpr-request"), db, 0, models...)
+	require.NoError(t, err)
+	assert.Equal(t, []RetentionResult{
+		{Entity: "retained_sessions", Action: RetentionDelete, Affected: 1},
+		{Entity: "retained_customers", Action: RetentionAnonymize},
+	}, results)
+	var last AuditLog
+	require.NoError(t, db.Order("id DESC").First(&last).Error)
+	assert.Equal(t, "gdpr-request", last.Actor)
+	assert.Equal(t, "3", last.EntityID)
+}
+
+func TestEnforceRetention_InvalidPolicy(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&invalidRetention{}, &retainedSession{}, &LegalHold{}, &AuditLog{}))
+
+	results, err := EnforceRetention(context.Background(), db, 0, &invalidRetention{}, &retainedSession{})
+	assert.ErrorContains(t, err, "invalid_retentions: no column created_at")
+	assert.Equal(t, []RetentionResult{{Entity: "retained_sessions", Action: RetentionDelete}}, results, "other tables are still enforced")
+}
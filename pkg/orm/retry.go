@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// MySQL error numbers worth retrying.
+const (
+	errLockWaitTimeout = 1205
+	errDeadlock        = 1213
+)
+
+// RetryPolicy bounds retries of transient errors.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; values below 1 mean 1.
+	MaxAttempts int
+	// InitialBackoff doubles after every attempt up to MaxBackoff. Each
+	// wait is jittered between half and the full backoff so that both sides
+	// of a deadlock don't retry in lockstep.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy makes up to 3 attempts, waiting 50ms then 100ms.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond, MaxBackoff: time.Second}
+
+// IsTransient reports whether err is a deadlock (1213), a lock wait
+// timeout (1205) or a dropped connection, after which re-running the
+// operation can succeed.
+func IsTransient(err error) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return me.Number == errDeadlock || me.Number == errLockWaitTimeout
+	}
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// Retry runs fn until it succeeds, fails with a non-transient error, the
+// policy's attempts are used up or ctx is done. fn must be safe to re-run:
+// retry whole transactions, not single statements within one.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsTransient(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+		wait := backoff / 2
+		if backoff > 1 {
+			wait += rand.N(backoff / 2)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, max(policy.MaxBackoff, policy.InitialBackoff))
+	}
+}
+
+// Retrier runs gorm operations on a database with a retry policy.
+type Retrier struct {
+	db     *gorm.DB
+	policy RetryPolicy
+}
+
+// WithRetry returns a Retrier for db. Backoffs stop when the context of db
+// (see gorm.DB.WithContext) is done.
+func WithRetry(db *gorm.DB, policy RetryPolicy) *Retrier {
+	return &Retrier{db: db, policy: policy}
+}
+
+// Do runs fn with the database, retrying transient errors. Use it for
+// single statements outside a transaction.
+func (r *Retrier) Do(fn func(db *gorm.DB) error) error {
+	return Retry(r.context(), r.policy, func() error {
+		return fn(r.db)
+	})
+}
+
+// Transaction runs fn in a transaction, re-running the whole transaction
+// on transient errors.
+func (r *Retrier) Transaction(fn func(tx *gorm.DB) error) error {
+	return Retry(r.context(), r.policy, func() error {
+		return r.db.Transaction(fn)
+	})
+}
+
+func (r *Retrier) context() context.Context {
+	if r.db.Statement != nil && r.db.Statement.Context != nil {
+		return r.db.Statement.Context
+	}
+	return context.Background()
+}
@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	assert.True(t, IsTransient(fmt.Errorf("update: %w", &mysql.MySQLError{Number: 1205})))
+	assert.True(t, IsTransient(driver.ErrBadConn))
+	assert.True(t, IsTransient(mysql.ErrInvalidConn))
+	assert.False(t, IsTransient(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.False(t, IsTransient(gorm.ErrRecordNotFound))
+	assert.False(t, IsTransient(nil))
+}
+
+func TestRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213}
+
+	calls := 0
+	err := Retry(context.Background(), fastRetry, func() error {
+		calls++
+		if calls < 3 {
+			return deadlock
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = Retry(context.Background(), fastRetry, func() error { calls++; return deadlock })
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	boom := errors.New("boom")
+	err = Retry(context.Background(), fastRetry, func() error { calls++; return boom })
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Retry(ctx, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}, func() error { return deadlock })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, deadlock)
+}
+
+func TestRetrier_Transaction(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Exec("CREATE TABLE counters (n INTEGER)").Error)
+
+	attempts := 0
+	err := WithRetry(db, fastRetry).Transaction(func(tx *gorm.DB) error {
+		attempts++
+		if err := tx.Exec("INSERT INTO counters (n) VALUES (?)", attempts).Error; err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return &mysql.MySQLError{Number: 1213}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// The failed attempt was rolled back.
+	var ns []int
+	require.NoError(t, db.Raw("SELECT n FROM counters").Scan(&ns).Error)
+	assert.Equal(t, []int{2}, ns)
+}
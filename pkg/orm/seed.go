@@ -0,0 +1,139 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedLock serializes the seeding of the same MySQL server.
+const seedLock = "orm:seed_history"
+
+// SeedFunc creates the data of a seeder in tx. It should be idempotent, e.g.
+// with FirstOrCreate or ON DUPLICATE KEY, so that rows created by hand
+// before it first ran don't make it fail.
+type SeedFunc func(ctx context.Context, tx *gorm.DB) error
+
+// SeedHistory records a seeder that ran.
+type SeedHistory struct {
+	Name      string    `gorm:"primaryKey;size:128"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName implements gorm's tabler.
+func (SeedHistory) TableName() string { return "seed_history" }
+
+type seeder struct {
+	name string
+	run  SeedFunc
+}
+
+var seeders struct {
+	mu   sync.Mutex
+	list []seeder
+}
+
+// RegisterSeeder adds a seeder of the data every environment needs, such as
+// default roles, system settings or dictionaries, run once per database by
+// Seed. Call it from package init; seeders run in registration order.
+func RegisterSeeder(name string, run SeedFunc) {
+	seeders.mu.Lock()
+	defer seeders.mu.Unlock()
+	seeders.list = append(seeders.list, seeder{name: name, run: run})
+}
+
+// Seeders returns the names of the registered seeders in registration order.
+func Seeders() []string {
+	seeders.mu.Lock()
+	defer seeders.mu.Unlock()
+	names := make([]string, 0, len(seeders.list))
+	for _, s := range seeders.list {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// SeedStatus is the state of a registered seeder in a database.
+type SeedStatus struct {
+	Name      string
+	AppliedAt time.Time // zero when pending
+}
+
+// SeedHistoryOf returns the state of every registered seeder in db,
+// creating seed_history when it does not exist.
+func SeedHistoryOf(ctx context.Context, db *gorm.DB) ([]SeedStatus, error) {
+	db = db.WithContext(ctx)
+	applied, err := seeded(db)
+	if err != nil {
+		return nil, err
+	}
+	names := Seeders()
+	statuses := make([]SeedStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, SeedStatus{Name: name, AppliedAt: applied[name].AppliedAt})
+	}
+	return statuses, nil
+}
+
+// Seed runs the registered seeders not recorded in seed_history of db and
+// returns their names. Each runs in a transaction with its record, so it
+// runs exactly once per database: a failing seeder is rolled back and
+// retried by the next Seed, the ones after it wait. On MySQL, concurrent
+// instances wait for each other through a named lock.
+func Seed(ctx context.Context, db *gorm.DB) ([]string, error) {
+	seeders.mu.Lock()
+	list := append([]seeder(nil), seeders.list...)
+	seeders.mu.Unlock()
+	names := make(map[string]bool, len(list))
+	for _, s := range list {
+		if names[s.name] {
+			return nil, fmt.Errorf("seeder %q is registered twice", s.name)
+		}
+		names[s.name] = true
+	}
+
+	var done []string
+	err := withLock(db.WithContext(ctx), seedLock, func(db *gorm.DB) error {
+		applied, err := seeded(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			if _, ok := applied[s.name]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := s.run(ctx, tx); err != nil {
+					return err
+				}
+				return tx.Create(&SeedHistory{Name: s.name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("seed %s: %w", s.name, err)
+			}
+			done = append(done, s.name)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// seeded returns the seed_history rows of db by name, creating the table
+// when it does not exist.
+func seeded(db *gorm.DB) (map[string]SeedHistory, error) {
+	if err := db.AutoMigrate(&SeedHistory{}); err != nil {
+		return nil, fmt.Errorf("create seed_history: %w", err)
+	}
+	var rows []SeedHistory
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read seed_history: %w", err)
+	}
+	applied := make(map[string]SeedHistory, len(rows))
+	for _, r := range rows {
+		applied[r.Name] = r
+	}
+	return applied, nil
+}
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type seedRole struct {
+	Name string `gorm:"primaryKey"`
+}
+
+func TestSeed(t *testing.T) {
+	seeders.list = nil
+	t.Cleanup(func() { seeders.list = nil })
+	ctx := context.Background()
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&seedRole{}))
+
+	runs := map[string]int{}
+	settingsErr := errors.New("settings table missing")
+	RegisterSeeder("roles", func(_ context.Context, tx *gorm.DB) error {
+		runs["roles"]++
+		return tx.FirstOrCreate(&seedRole{Name: "admin"}).Error
+	})
+	RegisterSeeder("settings", func(_ context.Context, tx *gorm.DB) error {
+		runs["settings"]++
+		if err := tx.Create(&seedRole{Name: "partial"}).Error; err != nil {
+			return err
+		}
+		return settingsErr
+	})
+	assert.Equal(t, []string{"roles", "settings"}, Seeders())
+
+	done, err := Seed(ctx, db)
+	assert.ErrorIs(t, err, settingsErr)
+	assert.Equal(t, []string{"roles"}, done)
+	var roles []string
+	require.NoError(t, db.Model(&seedRole{}).Pluck("name", &roles).Error)
+	assert.Equal(t, []string{"admin"}, roles, "a failing seeder is rolled back")
+
+	seeders.list[1].run = func(_ context.Context, tx *gorm.DB) error {
+		runs["settings"]++
+		return nil
+	}
+	done, err = Seed(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"settings"}, done, "seeders run once")
+	done, err = Seed(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, done)
+	assert.Equal(t, map[string]int{"roles": 1, "settings": 2}, runs)
+
+	statuses, err := SeedHistoryOf(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+
+	RegisterSeeder("roles", func(context.Context, *gorm.DB) error { return nil })
+	_, err = Seed(ctx, db)
+	assert.ErrorContains(t, err, "registered twice")
+}
@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const statementStartKey = "orm:statement_start"
+
+// noTable labels statements without a table, e.g. most Raw and Exec ones.
+const noTable = "none"
+
+// StatementMetrics is a gorm plugin timing every statement by table and
+// operation (create, query, update, delete, row or raw), to spot the
+// queries regressing after a schema change. Create it with
+// NewStatementMetrics; one value can be used by several connections.
+//
+// Row and Rows statements are timed until they return, not until their
+// rows are read. Missing records are not counted as errors.
+type StatementMetrics struct {
+	// observe records a finished statement.
+	observe func(table, operation string, elapsed time.Duration, failed bool)
+}
+
+// Name implements gorm.Plugin.
+func (*StatementMetrics) Name() string { return "orm:statement_metrics" }
+
+// Initialize implements gorm.Plugin.
+func (m *StatementMetrics) Initialize(db *gorm.DB) error {
+	const before, after = "orm:statement_metrics_before", "orm:statement_metrics_after"
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register(before, m.before),
+		cb.Create().After("*").Register(after, m.after("create")),
+		cb.Query().Before("*").Register(before, m.before),
+		cb.Query().After("*").Register(after, m.after("query")),
+		cb.Update().Before("*").Register(before, m.before),
+		cb.Update().After("*").Register(after, m.after("update")),
+		cb.Delete().Before("*").Register(before, m.before),
+		cb.Delete().After("*").Register(after, m.after("delete")),
+		cb.Row().Before("*").Register(before, m.before),
+		cb.Row().After("*").Register(after, m.after("row")),
+		cb.Raw().Before("*").Register(before, m.before),
+		cb.Raw().After("*").Register(after, m.after("raw")),
+	)
+}
+
+func (m *StatementMetrics) before(db *gorm.DB) {
+	db.InstanceSet(statementStartKey, time.Now())
+}
+
+func (m *StatementMetrics) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(statementStartKey)
+		if !ok || db.Statement.DryRun || m.observe == nil {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" && db.Statement.Schema != nil {
+			table = db.Statement.Schema.Table
+		}
+		if table == "" {
+			table = noTable
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		m.observe(table, operation, time.Since(v.(time.Time)), failed)
+	}
+}
@@ -0,0 +1,46 @@
+package orm
+
+import (
+	"iter"
+
+	"gorm.io/gorm"
+)
+
+// StreamRows iterates the rows matched by db one at a time instead of
+// loading them into a slice, for exports and large list responses:
+//
+//	for u, err := range orm.StreamRows[User](db.Where("tenant = ?", t).Order("id")) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The query runs when iteration starts and holds a pool connection until the
+// loop ends, so keep the loop body fast or bounded by a deadline. An error
+// ends the iteration. Rows are scanned with ScanRows, so query callbacks such
+// as Encryption do not apply.
+func StreamRows[T any](db *gorm.DB) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var model T
+		rows, err := db.Model(&model).Rows()
+		if err != nil {
+			yield(model, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var item T
+			if err := db.ScanRows(rows, &item); err != nil {
+				yield(item, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(model, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamRows(t *testing.T) {
+	db := newPageDB(t, 5)
+
+	var ids []uint64
+	for item, err := range StreamRows[pageItem](db.Where("id > ?", 1).Order("id")) {
+		require.NoError(t, err)
+		ids = append(ids, item.ID)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []uint64{2, 3, 4}, ids)
+
+	for _, err := range StreamRows[pageItem](db.Where("missing = 1")) {
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,268 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownTenant is returned for tenants that are not registered.
+var ErrUnknownTenant = errors.New("orm: unknown tenant")
+
+// Tenant describes where a tenant's data lives.
+type Tenant struct {
+	ID string
+	// DBName selects a schema on the default server.
+	DBName string
+	// DSN selects a dedicated server and takes precedence over DBName.
+	DSN string
+}
+
+// TenantDBConfig returns a copy of base pointing at t's database.
+func TenantDBConfig(base *DBConfig, t Tenant) *DBConfig {
+	c := *base
+	if t.DBName != "" {
+		c.DBName = t.DBName
+	}
+	c.DSN = t.DSN
+	return &c
+}
+
+// TenantRouter maps tenants to their own connection pools. Pools are opened
+// on first use and closed after being idle, so a service with many tenants
+// only keeps connections to the active ones. A pool held with Acquire, e.g.
+// by a transaction, is neither idle nor closed until it is released.
+type TenantRouter struct {
+	open func(Tenant) (DB, error)
+	idle time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]Tenant
+	conns   map[string]*tenantConn
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type tenantConn struct {
+	db       DB
+	lastUsed time.Time
+	// refs counts the Acquire calls not released yet.
+	refs int
+	// retired is set once the pool left conns while held; the last
+	// release closes it.
+	retired bool
+}
+
+// retire reports whether c can be closed now that it leaves the router, or
+// leaves closing it to its last release. Call it with the router locked.
+func (c *tenantConn) retire() bool {
+	if c.refs > 0 {
+		c.retired = true
+		return false
+	}
+	return true
+}
+
+// NewTenantRouter creates a router opening pools with open. Pools unused for
+// idleTimeout are closed; a non-positive idleTimeout keeps them until Close.
+func NewTenantRouter(open func(Tenant) (DB, error), idleTimeout time.Duration) *TenantRouter {
+	r := &TenantRouter{
+		open:    open,
+		idle:    idleTimeout,
+		tenants: make(map[string]Tenant),
+		conns:   make(map[string]*tenantConn),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		go r.janitor()
+	} else {
+		close(r.done)
+	}
+	return r
+}
+
+// Register adds or replaces a tenant. Replacing closes the tenant's pool so
+// the next use connects to the new location.
+func (r *TenantRouter) Register(t Tenant) error {
+	r.mu.Lock()
+	old, existed := r.tenants[t.ID]
+	r.tenants[t.ID] = t
+	var conn *tenantConn
+	if existed && old != t {
+		if c, ok := r.conns[t.ID]; ok && c.retire() {
+			conn = c
+		}
+		delete(r.conns, t.ID)
+	}
+	r.mu.Unlock()
+	if conn != nil {
+		return conn.db.Close()
+	}
+	return nil
+}
+
+// Unregister removes a tenant and closes its pool.
+func (r *TenantRouter) Unregister(id string) error {
+	r.mu.Lock()
+	delete(r.tenants, id)
+	conn, ok := r.conns[id]
+	if ok && !conn.retire() {
+		conn = nil
+	}
+	delete(r.conns, id)
+	r.mu.Unlock()
+	if conn != nil {
+		return conn.db.Close()
+	}
+	return nil
+}
+
+// Tenants returns the registered tenant IDs, sorted.
+func (r *TenantRouter) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// DB returns the database of tenant id, opening its pool if needed.
+func (r *TenantRouter) DB(id string) (*gorm.DB, error) {
+	conn, err := r.conn(id, false)
+	if err != nil {
+		return nil, err
+	}
+	return conn.db.GetDB(), nil
+}
+
+// Acquire returns the database of tenant id like DB and holds its pool
+// open until release is called: idle sweeps skip it, and re-registering or
+// unregistering the tenant closes it on release.
+func (r *TenantRouter) Acquire(id string) (db *gorm.DB, release func(), err error) {
+	conn, err := r.conn(id, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return conn.db.GetDB(), func() { once.Do(func() { r.release(conn) }) }, nil
+}
+
+func (r *TenantRouter) release(conn *tenantConn) {
+	r.mu.Lock()
+	conn.refs--
+	conn.lastUsed = time.Now()
+	closing := conn.retired && conn.refs == 0
+	r.mu.Unlock()
+	if closing {
+		conn.db.Close()
+	}
+}
+
+// conn returns the pool of tenant id, opening it if needed, with one more
+// reference when hold is set.
+func (r *TenantRouter) conn(id string, hold bool) (*tenantConn, error) {
+	use := func(conn *tenantConn) *tenantConn {
+		conn.lastUsed = time.Now()
+		if hold {
+			conn.refs++
+		}
+		return conn
+	}
+	r.mu.Lock()
+	t, ok := r.tenants[id]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTenant, id)
+	}
+	if conn, ok := r.conns[id]; ok {
+		defer r.mu.Unlock()
+		return use(conn), nil
+	}
+	r.mu.Unlock()
+
+	// Open outside the lock so a slow server does not block other tenants.
+	db, err := r.open(t)
+	if err != nil {
+		return nil, fmt.Errorf("open tenant %q: %w", id, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.tenants[id]; !ok || cur != t {
+		db.Close()
+		return nil, fmt.Errorf("%w: %q was changed while connecting", ErrUnknownTenant, id)
+	}
+	if conn, ok := r.conns[id]; ok {
+		// Another caller opened it concurrently.
+		db.Close()
+		return use(conn), nil
+	}
+	conn := use(&tenantConn{db: db})
+	r.conns[id] = conn
+	return conn, nil
+}
+
+// Close closes all pools, the held ones on release, and stops the idle
+// janitor.
+func (r *TenantRouter) Close() error {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+
+	r.mu.Lock()
+	conns := make(map[string]*tenantConn, len(r.conns))
+	for id, conn := range r.conns {
+		if conn.retire() {
+			conns[id] = conn
+		}
+	}
+	r.conns = make(map[string]*tenantConn)
+	r.mu.Unlock()
+	var errs []error
+	for id, conn := range conns {
+		if err := conn.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close tenant %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *TenantRouter) janitor() {
+	defer close(r.done)
+	ticker := time.NewTicker(max(r.idle/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			r.sweep(now)
+		}
+	}
+}
+
+// sweep closes the pools idle since before now-idle and not held.
+func (r *TenantRouter) sweep(now time.Time) {
+	r.mu.Lock()
+	var idle []DB
+	for id, conn := range r.conns {
+		if conn.refs == 0 && now.Sub(conn.lastUsed) >= r.idle {
+			idle = append(idle, conn.db)
+			delete(r.conns, id)
+		}
+	}
+	r.mu.Unlock()
+	for _, db := range idle {
+		db.Close()
+	}
+}
@@ -0,0 +1,130 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type sqliteDB struct {
+	db     *gorm.DB
+	closed *atomic.Int32
+}
+
+func (s sqliteDB) GetDB() *gorm.DB                           { return s.db }
+func (s sqliteDB) ClearAllData(...ClearOption) error         { return nil }
+func (s sqliteDB) Migrate(context.Context) ([]string, error) { return nil, nil }
+func (s sqliteDB) Ping(context.Context) error                { return nil }
+func (s sqliteDB) Stats() sql.DBStats                        { return sql.DBStats{} }
+func (s sqliteDB) Close() error                              { s.closed.Add(1); return nil }
+
+func TestTenantRouter(t *testing.T) {
+	var opened, closed atomic.Int32
+	open := func(tn Tenant) (DB, error) {
+		opened.Add(1)
+		db := ormtest.Open(t, ormtest.WithName(tn.DBName), ormtest.WithSharedCache())
+		return sqliteDB{db: db, closed: &closed}, nil
+	}
+	r := NewTenantRouter(open, 0)
+
+	_, err := r.DB("acme")
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+
+	require.NoError(t, r.Register(Tenant{ID: "acme", DBName: "acme"}))
+	require.NoError(t, r.Register(Tenant{ID: "globex", DBName: "globex"}))
+	assert.Equal(t, []string{"acme", "globex"}, r.Tenants())
+
+	acme, err := r.DB("acme")
+	require.NoError(t, err)
+	require.NoError(t, acme.Exec("CREATE TABLE t (id INTEGER)").Error)
+	again, err := r.DB("acme")
+	require.NoError(t, err)
+	assert.Same(t, acme, again)
+	assert.Equal(t, int32(1), opened.Load())
+
+	// Tenants are isolated.
+	globex, err := r.DB("globex")
+	require.NoError(t, err)
+	assert.Error(t, globex.Exec("SELECT * FROM t").Error)
+
+	// Moving a tenant closes its pool.
+	require.NoError(t, r.Register(Tenant{ID: "acme", DBName: "acme2"}))
+	assert.Equal(t, int32(1), closed.Load())
+	moved, err := r.DB("acme")
+	require.NoError(t, err)
+	assert.Error(t, moved.Exec("SELECT * FROM t").Error)
+
+	require.NoError(t, r.Unregister("globex"))
+	assert.Equal(t, int32(2), closed.Load())
+	_, err = r.DB("globex")
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+
+	require.NoError(t, r.Close())
+	assert.Equal(t, int32(3), closed.Load())
+}
+
+func TestTenantRouter_Sweep(t *testing.T) {
+	var closed atomic.Int32
+	open := func(Tenant) (DB, error) {
+		return sqliteDB{db: ormtest.Open(t), closed: &closed}, nil
+	}
+	r := NewTenantRouter(open, time.Hour)
+	defer r.Close()
+	require.NoError(t, r.Register(Tenant{ID: "acme"}))
+	_, err := r.DB("acme")
+	require.NoError(t, err)
+
+	r.sweep(time.Now())
+	assert.Equal(t, int32(0), closed.Load())
+	r.sweep(time.Now().Add(time.Hour))
+	assert.Equal(t, int32(1), closed.Load())
+}
+
+func TestTenantDBConfig(t *testing.T) {
+	base := &DBConfig{Host: "db", DBName: "app"}
+	assert.Equal(t, "app_acme", TenantDBConfig(base, Tenant{DBName: "app_acme"}).DBName)
+	assert.Equal(t, "u:p@tcp(other)/x", TenantDBConfig(base, Tenant{DSN: "u:p@tcp(other)/x"}).DSN)
+	assert.Equal(t, "app", base.DBName)
+}
+
+func TestTenantRouter_Acquire(t *testing.T) {
+	var closed atomic.Int32
+	open := func(Tenant) (DB, error) {
+		return sqliteDB{db: ormtest.Open(t), closed: &closed}, nil
+	}
+	r := NewTenantRouter(open, time.Hour)
+	defer r.Close()
+	require.NoError(t, r.Register(Tenant{ID: "acme"}))
+
+	_, release, err := r.Acquire("acme")
+	require.NoError(t, err)
+	r.sweep(time.Now().Add(time.Hour))
+	assert.Equal(t, int32(0), closed.Load(), "held pools are not idle")
+
+	// Moving a held tenant closes its pool on release.
+	require.NoError(t, r.Register(Tenant{ID: "acme", DBName: "acme2"}))
+	assert.Equal(t, int32(0), closed.Load())
+	release()
+	release()
+	assert.Equal(t, int32(1), closed.Load())
+
+	_, err = r.DB("acme")
+	require.NoError(t, err)
+	_, release, err = r.Acquire("acme")
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, int32(1), closed.Load())
+	release()
+	assert.Equal(t, int32(2), closed.Load())
+
+	_, _, err = r.Acquire("globex")
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+}
@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryTimeoutKey = "orm:query_timeout"
+
+// queryDeadline is the state of a statement given a deadline by QueryTimeout.
+type queryDeadline struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// QueryTimeout is a gorm plugin giving every statement whose context has no
+// deadline one of Timeout. Statements of a caller-set deadline, e.g. an
+// RPC's, keep it.
+//
+// Rows returned by Rows() and Row() are read after the statement callbacks
+// finish, so their deadline is only released when it expires: scanning has
+// to end within Timeout as well.
+type QueryTimeout struct {
+	Timeout time.Duration
+}
+
+// Name implements gorm.Plugin.
+func (QueryTimeout) Name() string { return "orm:query_timeout" }
+
+// Initialize implements gorm.Plugin.
+func (p QueryTimeout) Initialize(db *gorm.DB) error {
+	const before, after = "orm:query_timeout_before", "orm:query_timeout_after"
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register(before, p.before),
+		cb.Create().After("*").Register(after, p.after),
+		cb.Query().Before("*").Register(before, p.before),
+		cb.Query().After("*").Register(after, p.after),
+		cb.Update().Before("*").Register(before, p.before),
+		cb.Update().After("*").Register(after, p.after),
+		cb.Delete().Before("*").Register(before, p.before),
+		cb.Delete().After("*").Register(after, p.after),
+		cb.Raw().Before("*").Register(before, p.before),
+		cb.Raw().After("*").Register(after, p.after),
+		cb.Row().Before("*").Register(before, p.before),
+		cb.Row().After("*").Register(after, p.afterRow),
+	)
+}
+
+func (p QueryTimeout) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+	parent := db.Statement.Context
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(queryTimeoutKey, queryDeadline{parent: parent, cancel: cancel})
+}
+
+// after releases the deadline and restores the caller's context, so a
+// statement chained on the result is not bound to an expired one.
+func (QueryTimeout) after(db *gorm.DB) {
+	if d, ok := restoreContext(db); ok {
+		d.cancel()
+	}
+}
+
+// afterRow restores the caller's context but keeps the deadline running
+// for the rows still to be read.
+func (QueryTimeout) afterRow(db *gorm.DB) {
+	restoreContext(db)
+}
+
+func restoreContext(db *gorm.DB) (queryDeadline, bool) {
+	v, _ := db.InstanceGet(queryTimeoutKey)
+	d, ok := v.(queryDeadline)
+	if !ok {
+		return d, false
+	}
+	db.Statement.Context = d.parent
+	db.InstanceSet(queryTimeoutKey, nil)
+	return d, true
+}
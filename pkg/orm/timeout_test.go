@@ -0,0 +1,51 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+// slowSQL counts for several seconds in SQLite.
+const slowSQL = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 1000000000) SELECT count(*) FROM c"
+
+func TestQueryTimeout(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(QueryTimeout{Timeout: 50 * time.Millisecond}))
+
+	var deadlines []bool
+	require.NoError(t, db.Callback().Row().After("orm:query_timeout_before").Register("test:deadline", func(db *gorm.DB) {
+		_, ok := db.Statement.Context.Deadline()
+		deadlines = append(deadlines, ok)
+	}))
+
+	start := time.Now()
+	var n int64
+	err := db.Raw(slowSQL).Scan(&n).Error
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	require.Error(t, db.Exec(slowSQL).Error)
+
+	// A caller deadline is kept.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	res := db.WithContext(ctx).Raw("SELECT 1").Scan(&n)
+	require.NoError(t, res.Error)
+	dl, _ := res.Statement.Context.Deadline()
+	assert.WithinDuration(t, time.Now().Add(time.Hour), dl, time.Minute)
+
+	// The caller's context is restored once the statement finishes.
+	res = db.Raw("SELECT 1").Scan(&n)
+	require.NoError(t, res.Error)
+	_, ok := res.Statement.Context.Deadline()
+	assert.False(t, ok)
+	assert.NoError(t, res.Statement.Context.Err())
+
+	assert.Equal(t, []bool{true, true, true}, deadlines)
+}
@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DefaultTLSName is the name the TLS config is registered under with the
+// MySQL driver, referenced from DSNs as tls=custom.
+const DefaultTLSName = "custom"
+
+// TLSConfig requires encrypted connections, e.g. to managed MySQL (RDS,
+// PolarDB) enforcing TLS. Without any file the server certificate is
+// verified against the system roots.
+type TLSConfig struct {
+	// Name the config is registered under; empty uses DefaultTLSName.
+	// Distinct configs in one process need distinct names.
+	Name string
+	// CAFile is a PEM bundle of the CAs trusted for the server certificate,
+	// e.g. the provider's global bundle.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name verified in the server certificate,
+	// for connections through an IP or a proxy.
+	ServerName string
+	// InsecureSkipVerify encrypts without verifying the server. Only for
+	// local testing.
+	InsecureSkipVerify bool
+}
+
+func (c *TLSConfig) name() string {
+	if c.Name == "" {
+		return DefaultTLSName
+	}
+	return c.Name
+}
+
+// build loads the certificates into a *tls.Config.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read mysql CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("mysql CA %s contains no PEM certificates", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load mysql client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// register makes the config available to DSNs as tls=<name>.
+func (c *TLSConfig) register() error {
+	cfg, err := c.build()
+	if err != nil {
+		return err
+	}
+	if err := mysql.RegisterTLSConfig(c.name(), cfg); err != nil {
+		return fmt.Errorf("register mysql tls config: %w", err)
+	}
+	return nil
+}
+
+// Reload re-reads the certificate files, e.g. after a rotation. The
+// connections opened from then on use them; open ones keep theirs.
+func (c *TLSConfig) Reload() error {
+	return c.register()
+}
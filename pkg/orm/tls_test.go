@@ -0,0 +1,69 @@
+package orm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self-signed certificate and its key as PEM files.
+func writeCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysql"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir)
+
+	c := &TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "db.internal"}
+	cfg, err := c.build()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.RootCAs)
+	assert.Len(t, cfg.Certificates, 1)
+	assert.Equal(t, "db.internal", cfg.ServerName)
+	require.NoError(t, c.register())
+
+	_, err = (&TLSConfig{CAFile: keyFile}).build()
+	assert.ErrorContains(t, err, "no PEM certificates")
+	_, err = (&TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}).build()
+	assert.Error(t, err)
+	_, err = (&TLSConfig{CertFile: certFile}).build()
+	assert.Error(t, err)
+}
+
+func TestGormMysql_buildDSN_TLS(t *testing.T) {
+	gm := &gormMysql{dbConfig: &DBConfig{Username: "u", Password: "p", Host: "h", Port: "3306", TLS: &TLSConfig{}}}
+	assert.Equal(t, "u:p@tcp(h:3306)/db?charset=utf8mb4&parseTime=True&loc=Local&tls=custom", gm.buildDSN("db"))
+
+	gm.dbConfig.TLS.Name = "rds"
+	assert.Contains(t, gm.buildDSN("db"), "&tls=rds")
+}
@@ -0,0 +1,308 @@
+package orm
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration states reported by SQLMigrator.Status.
+const (
+	MigrationApplied  = "applied"
+	MigrationPending  = "pending"
+	MigrationModified = "modified" // applied, but the file changed since
+	MigrationMissing  = "missing"  // applied, but the file is gone
+)
+
+// migrationLock serializes migrators of the same MySQL server.
+const migrationLock = "orm:schema_migrations"
+
+// SchemaMigration records an applied versioned migration.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:64"`
+	Name      string    `gorm:"size:255;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName implements gorm's tabler.
+func (SchemaMigration) TableName() string { return "schema_migrations" }
+
+// Migration is a versioned SQL migration read from <version>_<name>.sql,
+// with the optional down/<version>_<name>.sql reverting it. Versions are
+// numeric, e.g. the timestamps of atlas migrate diff; atlas and make init-db
+// ignore the down directory.
+type Migration struct {
+	Version  string
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // of Up
+}
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Version   string
+	Name      string
+	State     string
+	AppliedAt time.Time // zero when pending
+}
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by
+// version. Other files, such as atlas.sum, are ignored.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	ups, err := readMigrations(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	downs, err := readMigrations(fsys, "down")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, d := range downs {
+		i := slices.IndexFunc(ups, func(m Migration) bool { return m.Version == d.Version })
+		if i < 0 || ups[i].Name != d.Name {
+			return nil, fmt.Errorf("migration down/%s_%s.sql has no up file", d.Version, d.Name)
+		}
+		ups[i].Down = d.Up
+	}
+	return ups, nil
+}
+
+// readMigrations reads the <version>_<name>.sql files of dir.
+func readMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, e := range entries {
+		file := e.Name()
+		if e.IsDir() || path.Ext(file) != ".sql" {
+			continue
+		}
+		version, name, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), "_")
+		if !ok || version == "" || strings.Trim(version, "0123456789") != "" {
+			return nil, fmt.Errorf("migration %s: want <version>_<name>.sql with a numeric version", path.Join(dir, file))
+		}
+		if i := slices.IndexFunc(migrations, func(m Migration) bool { return compareVersions(m.Version, version) == 0 }); i >= 0 {
+			return nil, fmt.Errorf("migration %s: version %s is also used by %s", path.Join(dir, file), version, migrations[i].Name)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, Migration{Version: version, Name: name, Up: string(data), Checksum: hex.EncodeToString(sum[:])})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return compareVersions(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// compareVersions compares numeric versions of any length.
+func compareVersions(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+}
+
+// SQLMigrator applies versioned SQL migrations and records them in
+// schema_migrations, so deployments need no atlas CLI:
+//
+//	m, err := orm.NewSQLMigrator(db.GetDB(), os.DirFS("scripts/sql/migration"))
+//	applied, err := m.Up(ctx)
+//
+// Each file runs statement by statement. MySQL commits DDL implicitly, so
+// a failing file can leave its earlier statements applied: fix the file or
+// the schema by hand and run Up again. On MySQL, concurrent migrators wait
+// for each other through a named lock. Databases migrated with atlas
+// migrate apply keep their history in atlas_schema_revisions, not here.
+type SQLMigrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewSQLMigrator loads the migrations of fsys and creates the history table
+// of db when it does not exist.
+func NewSQLMigrator(db *gorm.DB, fsys fs.FS) (*SQLMigrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return &SQLMigrator{db: db, migrations: migrations}, nil
+}
+
+func (m *SQLMigrator) applied(db *gorm.DB) (map[string]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	applied := make(map[string]SchemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Status returns the state of every migration, in version order, including
+// applied ones whose file is missing.
+func (m *SQLMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name, State: MigrationPending}
+		if r, ok := applied[mig.Version]; ok {
+			s.State, s.AppliedAt = MigrationApplied, r.AppliedAt
+			if r.Checksum != mig.Checksum {
+				s.State = MigrationModified
+			}
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range applied {
+		statuses = append(statuses, MigrationStatus{Version: r.Version, Name: r.Name, State: MigrationMissing, AppliedAt: r.AppliedAt})
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return compareVersions(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// Up applies the pending migrations in version order and returns them. A
+// pending migration older than the newest applied one, e.g. from a merged
+// branch, is an error: renumber it.
+func (m *SQLMigrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		var newest string
+		for v := range applied {
+			if newest == "" || compareVersions(v, newest) > 0 {
+				newest = v
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if newest != "" && compareVersions(mig.Version, newest) < 0 {
+				return fmt.Errorf("migration %s_%s is older than the applied %s", mig.Version, mig.Name, newest)
+			}
+			if err := execScript(db, mig.Up); err != nil {
+				return fmt.Errorf("apply %s_%s: %w", mig.Version, mig.Name, err)
+			}
+			row := SchemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}
+			if err := db.Create(&row).Error; err != nil {
+				return fmt.Errorf("record %s_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, with their
+// down files, and returns them.
+func (m *SQLMigrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		versions := make([]string, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		slices.SortFunc(versions, func(a, b string) int { return compareVersions(b, a) })
+		for _, v := range versions[:min(steps, len(versions))] {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == v })
+			if i < 0 {
+				return fmt.Errorf("revert %s_%s: migration file is missing", v, applied[v].Name)
+			}
+			mig := m.migrations[i]
+			if strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("revert %s_%s: no down/%s_%s.sql", v, mig.Name, v, mig.Name)
+			}
+			if err := execScript(db, mig.Down); err != nil {
+				return fmt.Errorf("revert %s_%s: %w", v, mig.Name, err)
+			}
+			if err := db.Delete(&SchemaMigration{Version: v}).Error; err != nil {
+				return fmt.Errorf("record revert of %s_%s: %w", v, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// locked runs fn on one connection holding the migration lock on MySQL.
+func (m *SQLMigrator) locked(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return withLock(m.db.WithContext(ctx), migrationLock, fn)
+}
+
+// maxLockWait is the longest wait for a named lock held by another instance.
+const maxLockWait = 5 * time.Minute
+
+// lockWait returns the seconds to wait for a named lock within ctx: up to
+// maxLockWait, ending a second before the deadline of ctx so GET_LOCK times
+// out, naming the lock, rather than the context.
+func lockWait(ctx context.Context) int {
+	wait := maxLockWait
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = min(wait, time.Until(deadline)-time.Second)
+	}
+	return max(int(wait/time.Second), 0)
+}
+
+// withLock runs fn on one connection of db holding the named lock on MySQL,
+// and on db elsewhere. The lock is waited for within the context of db.
+func withLock(db *gorm.DB, lock string, fn func(db *gorm.DB) error) error {
+	if db.Dialector.Name() != DriverMySQL {
+		return fn(db)
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		var got int
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", lock, lockWait(conn.Statement.Context)).Scan(&got).Error; err != nil {
+			return fmt.Errorf("acquire lock %s: %w", lock, err)
+		}
+		if got != 1 {
+			return fmt.Errorf("acquire lock %s: timed out, held by another instance", lock)
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", lock)
+		return fn(conn)
+	})
+}
+
+// execScript executes the statements of a migration file one by one.
+func execScript(db *gorm.DB, script string) error {
+	statements, err := splitStatements(script)
+	if err != nil {
+		return err
+	}
+	for i, s := range statements {
+		if err := db.Exec(s).Error; err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+func migrationFS() fstest.MapFS {
+	return fstest.MapFS{
+		"20240101000000_users.sql":      {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\n-- seed; with a comment\nINSERT INTO users (name) VALUES ('a;b');")},
+		"down/20240101000000_users.sql": {Data: []byte("DROP TABLE users;")},
+		"20240201000000_email.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+		"down/20240201000000_email.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+		"atlas.sum":                     {Data: []byte("h1:...")},
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFS())
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "20240101000000", migrations[0].Version)
+	assert.Equal(t, "users", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE users;", migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+
+	assert.Equal(t, -1, compareVersions("9", "10"))
+	assert.Equal(t, 0, compareVersions("010", "10"))
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":  {"init.sql": {}},
+		"down only": {"1_a.sql": {}, "down/2_b.sql": {}},
+		"duplicate": {"1_a.sql": {}, "1_b.sql": {}},
+	} {
+		_, err := LoadMigrations(fsys)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSQLMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := ormtest.Open(t)
+	fsys := migrationFS()
+	m, err := NewSQLMigrator(db, fsys)
+	require.NoError(t, err)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, MigrationPending, statuses[0].State)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	var name string
+	require.NoError(t, db.Raw("SELECT name FROM users").Scan(&name).Error)
+	assert.Equal(t, "a;b", name)
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "nothing pending")
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "email", reverted[0].Name)
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{MigrationApplied, MigrationPending}, []string{statuses[0].State, statuses[1].State})
+
+	// Files changed or removed after being applied are reported.
+	fsys["20240101000000_users.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);")}
+	delete(fsys, "20240201000000_email.sql")
+	delete(fsys, "down/20240201000000_email.sql")
+	fsys["20240301000000_x.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE x (id INTEGER);")}
+	m2, err := NewSQLMigrator(db, fsys)
+	require.NoError(t, err)
+	_, err = m2.Up(ctx)
+	require.NoError(t, err)
+	fsys["20240201000000_late.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	m2, err = NewSQLMigrator(db, fsys)
+	require.NoError(t, err)
+	_, err = m2.Up(ctx)
+	assert.ErrorContains(t, err, "older than the applied 20240301000000")
+	statuses, err = m2.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, MigrationModified, statuses[0].State)
+
+	_, err = m2.Down(ctx, 1)
+	assert.ErrorContains(t, err, "no down/20240301000000_x.sql")
+
+	// A failing statement is reported and not recorded.
+	fsys["20240401000000_bad.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE y (id INTEGER); NOT SQL;")}
+	delete(fsys, "20240201000000_late.sql")
+	m3, err := NewSQLMigrator(db, fsys)
+	require.NoError(t, err)
+	_, err = m3.Up(ctx)
+	assert.ErrorContains(t, err, "apply 20240401000000_bad: statement 2")
+	statuses, err = m3.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, MigrationPending, statuses[len(statuses)-1].State)
+}
+
+func TestLockWait(t *testing.T) {
+	assert.Equal(t, 300, lockWait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.InDelta(t, 58, lockWait(ctx), 1, "a second before the deadline")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, 0, lockWait(ctx))
+}
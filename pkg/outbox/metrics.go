@@ -0,0 +1,82 @@
+//go:build !nometrics
+
+package outbox
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewMetrics creates the relay metrics, registered with reg:
+//
+//	outbox_delivery_lag_seconds{topic}      time from Add to publish
+//	outbox_publish_failures_total{topic}
+//	outbox_quarantined_total{topic}
+//	outbox_deleted_total                    published messages cleaned up
+//	outbox_pending_messages{topic}
+//	outbox_quarantined_messages{topic}
+//	outbox_oldest_pending_seconds{topic}    age of the oldest pending message
+//
+// The gauges are refreshed every minute by the relay.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	lag := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "outbox_delivery_lag_seconds",
+		Help:    "Time from adding an outbox message to publishing it.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+	}, []string{"topic"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Number of failed outbox publishes.",
+	}, []string{"topic"})
+	quarantines := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_quarantined_total",
+		Help: "Number of outbox messages quarantined after their last attempt.",
+	}, []string{"topic"})
+	deleted := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_deleted_total",
+		Help: "Number of published outbox messages deleted by retention.",
+	})
+	pending := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outbox_pending_messages",
+		Help: "Number of outbox messages waiting to be published.",
+	}, []string{"topic"})
+	quarantined := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outbox_quarantined_messages",
+		Help: "Number of quarantined outbox messages.",
+	}, []string{"topic"})
+	oldest := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outbox_oldest_pending_seconds",
+		Help: "Age of the oldest outbox message waiting to be published.",
+	}, []string{"topic"})
+	for _, c := range []prometheus.Collector{lag, failures, quarantines, deleted, pending, quarantined, oldest} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return &Metrics{
+		delivered: func(topic string, d time.Duration) {
+			lag.WithLabelValues(topic).Observe(d.Seconds())
+		},
+		failed: func(topic string, q bool) {
+			failures.WithLabelValues(topic).Inc()
+			if q {
+				quarantines.WithLabelValues(topic).Inc()
+			}
+		},
+		deleted: func(n int) { deleted.Add(float64(n)) },
+		stats: func(stats []TopicStats) {
+			// Topics without a backlog are dropped rather than left stale.
+			pending.Reset()
+			quarantined.Reset()
+			oldest.Reset()
+			for _, s := range stats {
+				pending.WithLabelValues(s.Topic).Set(float64(s.Pending))
+				quarantined.WithLabelValues(s.Topic).Set(float64(s.Quarantined))
+				if s.OldestPending != nil {
+					oldest.WithLabelValues(s.Topic).Set(time.Since(*s.OldestPending).Seconds())
+				}
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,50 @@
+//go:build !nometrics
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg)
+	require.NoError(t, err)
+	db := newTestDB(t)
+	bus := &fakeBus{}
+	o := New(func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }, bus, log.DefaultLogger,
+		WithMetrics(m), WithMaxAttempts(1))
+	ctx := context.Background()
+
+	require.NoError(t, o.Add(ctx, &eventbus.Event{Topic: "orders"}))
+	_, err = o.Relay(ctx)
+	require.NoError(t, err)
+	bus.fail = errors.New("broker down")
+	require.NoError(t, o.Add(ctx, &eventbus.Event{Topic: "orders"}, &eventbus.Event{Topic: "users"}))
+	_, err = o.Relay(ctx)
+	require.NoError(t, err)
+	o.maintain(ctx)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "outbox_delivery_lag_seconds"))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP outbox_pending_messages Number of outbox messages waiting to be published.
+# TYPE outbox_pending_messages gauge
+outbox_pending_messages{topic="orders"} 0
+outbox_pending_messages{topic="users"} 1
+# HELP outbox_quarantined_total Number of outbox messages quarantined after their last attempt.
+# TYPE outbox_quarantined_total counter
+outbox_quarantined_total{topic="orders"} 1
+`), "outbox_pending_messages", "outbox_quarantined_total"))
+}
@@ -0,0 +1,446 @@
+// Package outbox implements the transactional outbox pattern: events are
+// written to a table in the same transaction as the business change and a
+// relay publishes them to the event bus afterwards, so neither is lost when
+// the process crashes between the two.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+var _ transport.Server = (*Outbox)(nil)
+
+// Message is a pending or published outbox row.
+type Message struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement"`
+	Topic       string     `gorm:"size:255;not null"`
+	Key         string     `gorm:"size:255;not null;default:''"`
+	Body        []byte     `gorm:"type:mediumblob;not null"`
+	CreatedAt   time.Time  `gorm:"not null"`
+	PublishedAt *time.Time `gorm:"index:idx_outbox_published_at"`
+	Attempts    int        `gorm:"not null;default:0"`
+	LastError   string     `gorm:"size:1024;not null;default:''"`
+	// QuarantinedAt is set when the message failed WithMaxAttempts times; it
+	// is no longer relayed until requeued.
+	QuarantinedAt *time.Time `gorm:"index:idx_outbox_quarantined_at"`
+}
+
+// TableName implements gorm's tabler.
+func (Message) TableName() string { return "outbox_messages" }
+
+// DBFunc returns the database session for ctx. Pass a transaction-aware
+// accessor (such as data.Data.DB) so Add joins the caller's transaction.
+type DBFunc func(ctx context.Context) *gorm.DB
+
+// Option configures an Outbox.
+type Option func(*options)
+
+type options struct {
+	interval    time.Duration
+	batchSize   int
+	retention   time.Duration
+	maxAttempts int
+	metrics     *Metrics
+}
+
+// WithInterval sets how often the relay polls for pending messages.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.interval = d
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of messages relayed per poll.
+func WithBatchSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithRetention deletes published messages older than d; zero keeps them.
+func WithRetention(d time.Duration) Option {
+	return func(o *options) { o.retention = d }
+}
+
+// WithMaxAttempts quarantines a message after n failed publishes, so that a
+// poison message does not hold up the others; zero retries forever. Later
+// messages with the same key are then relayed before it.
+func WithMaxAttempts(n int) Option {
+	return func(o *options) { o.maxAttempts = n }
+}
+
+// WithMetrics records the relay to m.
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// Outbox stores events transactionally and relays them to the event bus.
+// It implements transport.Server; the relay runs between Start and Stop.
+type Outbox struct {
+	db   DBFunc
+	bus  eventbus.Bus
+	opts options
+	log  *log.Helper
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates an outbox relaying to bus.
+func New(db DBFunc, bus eventbus.Bus, logger log.Logger, opts ...Option) *Outbox {
+	o := options{
+		interval:  time.Second,
+		batchSize: 100,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Outbox{
+		db:   db,
+		bus:  bus,
+		opts: o,
+		log:  log.NewHelper(log.With(logger, "module", "pkg/outbox")),
+	}
+}
+
+// Add stores events for publishing. Call it inside the transaction that performs
+// the business change; the event is published only if it commits.
+func (o *Outbox) Add(ctx context.Context, events ...*eventbus.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	msgs := make([]Message, 0, len(events))
+	for _, e := range events {
+		if e.Topic == "" {
+			return errors.New("outbox: event topic is required")
+		}
+		body := e.Body
+		if body == nil {
+			body = []byte{}
+		}
+		msgs = append(msgs, Message{Topic: e.Topic, Key: e.Key, Body: body, CreatedAt: now})
+	}
+	if err := o.db(ctx).Create(&msgs).Error; err != nil {
+		return fmt.Errorf("add outbox messages: %w", err)
+	}
+	return nil
+}
+
+// Start starts the relay loop.
+func (o *Outbox) Start(context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	o.done = make(chan struct{})
+	go o.loop(ctx, o.done)
+	return nil
+}
+
+// Stop stops the relay loop and waits for the running batch to finish.
+func (o *Outbox) Stop(ctx context.Context) error {
+	o.mu.Lock()
+	cancel, done := o.cancel, o.done
+	o.cancel, o.done = nil, nil
+	o.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// maintenanceInterval is how often the relay deletes expired messages and
+// refreshes the backlog metrics.
+const maintenanceInterval = time.Minute
+
+func (o *Outbox) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(o.opts.interval)
+	defer ticker.Stop()
+	var lastMaintenance time.Time
+	for {
+		// Drain the backlog before waiting for the next tick.
+		for {
+			n, err := o.Relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				o.log.Errorf("relay outbox: %v", err)
+			}
+			if err != nil || n < o.opts.batchSize {
+				break
+			}
+		}
+		if time.Since(lastMaintenance) >= maintenanceInterval {
+			o.maintain(ctx)
+			lastMaintenance = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// maintain deletes the expired messages and records the backlog.
+func (o *Outbox) maintain(ctx context.Context) {
+	if o.opts.retention > 0 {
+		n, err := o.Cleanup(ctx, time.Now().Add(-o.opts.retention))
+		if err != nil && ctx.Err() == nil {
+			o.log.Errorf("clean up outbox: %v", err)
+		}
+		o.opts.metrics.recordDeleted(n)
+	}
+	if o.opts.metrics != nil {
+		stats, err := o.Stats(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				o.log.Errorf("outbox stats: %v", err)
+			}
+			return
+		}
+		o.opts.metrics.recordStats(stats)
+	}
+}
+
+// Relay publishes one batch of pending messages in insertion order and
+// returns how many were published. It stops at the first failure so that
+// per-key ordering is preserved; the failed message is retried next time.
+// Rows are locked with SKIP LOCKED so several instances can relay at once.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	published := 0
+	err := o.db(ctx).Transaction(func(tx *gorm.DB) error {
+		var msgs []Message
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("published_at IS NULL AND quarantined_at IS NULL").
+			Order("id").
+			Limit(o.opts.batchSize).
+			Find(&msgs).Error
+		if err != nil {
+			return fmt.Errorf("load outbox messages: %w", err)
+		}
+		for i := range msgs {
+			m := &msgs[i]
+			perr := o.bus.Publish(ctx, &eventbus.Event{Topic: m.Topic, Key: m.Key, Body: m.Body})
+			if perr != nil {
+				return o.markFailed(tx, m, perr)
+			}
+			now := time.Now()
+			if err := tx.Model(m).Updates(map[string]any{"published_at": &now, "attempts": m.Attempts + 1}).Error; err != nil {
+				return fmt.Errorf("mark outbox message %d published: %w", m.ID, err)
+			}
+			o.opts.metrics.recordDelivered(m.Topic, now.Sub(m.CreatedAt))
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+// markFailed records the publish error while keeping the batch's progress,
+// and quarantines the message after its last attempt.
+func (o *Outbox) markFailed(tx *gorm.DB, m *Message, cause error) error {
+	msg := cause.Error()
+	if len(msg) > 1024 {
+		msg = msg[:1024]
+	}
+	attempts := m.Attempts + 1
+	updates := map[string]any{"attempts": attempts, "last_error": msg}
+	quarantined := o.opts.maxAttempts > 0 && attempts >= o.opts.maxAttempts
+	if quarantined {
+		updates["quarantined_at"] = time.Now()
+	}
+	if err := tx.Model(m).Updates(updates).Error; err != nil {
+		return fmt.Errorf("mark outbox message %d failed: %w", m.ID, err)
+	}
+	o.opts.metrics.recordFailed(m.Topic, quarantined)
+	if quarantined {
+		o.log.Errorf("quarantined outbox message %d to %s after %d attempts: %v", m.ID, m.Topic, attempts, cause)
+		return nil
+	}
+	o.log.Warnf("publish outbox message %d to %s (attempt %d): %v", m.ID, m.Topic, attempts, cause)
+	return nil
+}
+
+// Cleanup deletes the messages published before t, in batches, and returns
+// how many it deleted.
+func (o *Outbox) Cleanup(ctx context.Context, t time.Time) (int, error) {
+	deleted := 0
+	for {
+		var ids []uint64
+		err := o.db(ctx).Model(&Message{}).
+			Where("published_at < ?", t).
+			Order("id").
+			Limit(o.opts.batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return deleted, fmt.Errorf("load published outbox messages: %w", err)
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+		res := o.db(ctx).Where("id IN ?", ids).Delete(&Message{})
+		if res.Error != nil {
+			return deleted, fmt.Errorf("delete published outbox messages: %w", res.Error)
+		}
+		deleted += int(res.RowsAffected)
+		if len(ids) < o.opts.batchSize {
+			return deleted, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+	}
+}
+
+// TopicStats is the backlog of a topic.
+type TopicStats struct {
+	Topic       string `json:"topic"`
+	Pending     int64  `json:"pending"`
+	Quarantined int64  `json:"quarantined"`
+	// OldestPending is when the oldest pending message was added, nil
+	// without pending messages.
+	OldestPending *time.Time `json:"oldest_pending,omitempty"`
+}
+
+// Stats returns the backlog of the topics with unpublished messages.
+func (o *Outbox) Stats(ctx context.Context) ([]TopicStats, error) {
+	var rows []struct {
+		Topic       string
+		Quarantined bool
+		Count       int64
+		Oldest      uint64
+	}
+	err := o.db(ctx).Model(&Message{}).
+		Select("topic, quarantined_at IS NOT NULL AS quarantined, COUNT(*) AS count, MIN(id) AS oldest").
+		Where("published_at IS NULL").
+		Group("topic, quarantined_at IS NOT NULL").
+		Order("topic").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("outbox stats: %w", err)
+	}
+	var stats []TopicStats
+	for _, r := range rows {
+		if len(stats) == 0 || stats[len(stats)-1].Topic != r.Topic {
+			stats = append(stats, TopicStats{Topic: r.Topic})
+		}
+		s := &stats[len(stats)-1]
+		if r.Quarantined {
+			s.Quarantined = r.Count
+			continue
+		}
+		s.Pending = r.Count
+		// Messages are added in id order, so the lowest id is the oldest.
+		var oldest Message
+		if err := o.db(ctx).Select("created_at").Take(&oldest, r.Oldest).Error; err != nil {
+			return nil, fmt.Errorf("outbox stats: oldest message of %s: %w", r.Topic, err)
+		}
+		s.OldestPending = &oldest.CreatedAt
+	}
+	return stats, nil
+}
+
+// ErrNotFound is returned for a message that does not exist or was
+// published.
+var ErrNotFound = errors.New("outbox: no unpublished message with this id")
+
+// Stuck returns up to limit unpublished messages that failed to publish,
+// quarantined or not, oldest first.
+func (o *Outbox) Stuck(ctx context.Context, limit int) ([]Message, error) {
+	var msgs []Message
+	err := o.db(ctx).
+		Where("published_at IS NULL AND attempts > 0").
+		Order("id").
+		Limit(limit).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("load stuck outbox messages: %w", err)
+	}
+	return msgs, nil
+}
+
+// Requeue makes the unpublished message id pending again with no failed
+// attempts, e.g. once the cause of its quarantine is fixed.
+func (o *Outbox) Requeue(ctx context.Context, id uint64) error {
+	res := o.db(ctx).Model(&Message{}).
+		Where("id = ? AND published_at IS NULL", id).
+		Updates(map[string]any{"attempts": 0, "last_error": "", "quarantined_at": nil})
+	if res.Error != nil {
+		return fmt.Errorf("requeue outbox message %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Discard deletes the unpublished message id, which is never published.
+func (o *Outbox) Discard(ctx context.Context, id uint64) error {
+	res := o.db(ctx).Where("id = ? AND published_at IS NULL", id).Delete(&Message{})
+	if res.Error != nil {
+		return fmt.Errorf("discard outbox message %d: %w", id, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Metrics records the relay. Create it with NewMetrics; a nil Metrics
+// records nothing.
+type Metrics struct {
+	delivered func(topic string, lag time.Duration)
+	failed    func(topic string, quarantined bool)
+	deleted   func(n int)
+	stats     func(stats []TopicStats)
+}
+
+func (m *Metrics) recordDelivered(topic string, lag time.Duration) {
+	if m != nil {
+		m.delivered(topic, lag)
+	}
+}
+
+func (m *Metrics) recordFailed(topic string, quarantined bool) {
+	if m != nil {
+		m.failed(topic, quarantined)
+	}
+}
+
+func (m *Metrics) recordDeleted(n int) {
+	if m != nil && n > 0 {
+		m.deleted(n)
+	}
+}
+
+func (m *Metrics) recordStats(stats []TopicStats) {
+	if m != nil {
+		m.stats(stats)
+	}
+}
@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+type fakeBus struct {
+	eventbus.Bus
+
+	mu        sync.Mutex
+	published []*eventbus.Event
+	fail      error
+}
+
+func (b *fakeBus) Publish(_ context.Context, e *eventbus.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail != nil {
+		return b.fail
+	}
+	b.published = append(b.published, e)
+	return nil
+}
+
+func (b *fakeBus) topics() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	topics := make([]string, 0, len(b.published))
+	for _, e := range b.published {
+		topics = append(topics, e.Topic)
+	}
+	return topics
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := ormtest.Open(t, ormtest.WithSharedCache())
+	require.NoError(t, db.AutoMigrate(&Message{}))
+	return db
+}
+
+func TestOutbox_AddInTransaction(t *testing.T) {
+	db := newTestDB(t)
+	bus := &fakeBus{}
+	type txKey struct{}
+	dbFunc := func(ctx context.Context) *gorm.DB {
+		if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+			return tx
+		}
+		return db.WithContext(ctx)
+	}
+	o := New(dbFunc, bus, log.DefaultLogger)
+	ctx := context.Background()
+
+	rollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, o.Add(context.WithValue(ctx, txKey{}, tx), &eventbus.Event{Topic: "orders.created"}))
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return o.Add(context.WithValue(ctx, txKey{}, tx),
+			&eventbus.Event{Topic: "orders.paid", Key: "o-1", Body: []byte("{}")},
+			&eventbus.Event{Topic: "orders.shipped", Key: "o-1"},
+		)
+	}))
+	assert.Error(t, o.Add(ctx, &eventbus.Event{}))
+
+	n, err := o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"orders.paid", "orders.shipped"}, bus.topics())
+
+	n, err = o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestOutbox_RelayFailure(t *testing.T) {
+	db := newTestDB(t)
+	bus := &fakeBus{fail: errors.New("broker down")}
+	o := New(func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }, bus, log.DefaultLogger)
+	ctx := context.Background()
+	require.NoError(t, o.Add(ctx, &eventbus.Event{Topic: "a"}, &eventbus.Event{Topic: "b"}))
+
+	n, err := o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	var m Message
+	require.NoError(t, db.Order("id").First(&m).Error)
+	assert.Equal(t, 1, m.Attempts)
+	assert.Equal(t, "broker down", m.LastError)
+	assert.Nil(t, m.PublishedAt)
+
+	bus.mu.Lock()
+	bus.fail = nil
+	bus.mu.Unlock()
+	require.NoError(t, o.Start(ctx))
+	assert.Eventually(t, func() bool { return len(bus.topics()) == 2 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, o.Stop(ctx))
+	assert.Equal(t, []string{"a", "b"}, bus.topics())
+}
+
+func TestOutbox_Quarantine(t *testing.T) {
+	db := newTestDB(t)
+	bus := &fakeBus{fail: errors.New("payload rejected")}
+	o := New(func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }, bus, log.DefaultLogger, WithMaxAttempts(2))
+	ctx := context.Background()
+	require.NoError(t, o.Add(ctx, &eventbus.Event{Topic: "a", Key: "k"}, &eventbus.Event{Topic: "b", Key: "k"}))
+
+	for range 2 {
+		_, err := o.Relay(ctx)
+		require.NoError(t, err)
+	}
+	stuck, err := o.Stuck(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+	assert.Equal(t, "a", stuck[0].Topic)
+	assert.Equal(t, 2, stuck[0].Attempts)
+	assert.NotNil(t, stuck[0].QuarantinedAt)
+
+	stats, err := o.Stats(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, TopicStats{Topic: "a", Quarantined: 1}, stats[0])
+	assert.Equal(t, int64(1), stats[1].Pending)
+	assert.NotNil(t, stats[1].OldestPending)
+
+	bus.mu.Lock()
+	bus.fail = nil
+	bus.mu.Unlock()
+	n, err := o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the quarantined message does not hold up the others")
+	assert.Equal(t, []string{"b"}, bus.topics())
+
+	require.NoError(t, o.Requeue(ctx, stuck[0].ID))
+	n, err = o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"b", "a"}, bus.topics())
+	assert.ErrorIs(t, o.Requeue(ctx, stuck[0].ID), ErrNotFound, "published messages cannot be requeued")
+	assert.ErrorIs(t, o.Discard(ctx, stuck[0].ID), ErrNotFound)
+
+	require.NoError(t, o.Add(ctx, &eventbus.Event{Topic: "c"}))
+	var pending Message
+	require.NoError(t, db.Where("topic = ?", "c").First(&pending).Error)
+	require.NoError(t, o.Discard(ctx, pending.ID))
+	stats, err = o.Stats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestOutbox_Cleanup(t *testing.T) {
+	db := newTestDB(t)
+	o := New(func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }, &fakeBus{}, log.DefaultLogger, WithBatchSize(2))
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now()
+	require.NoError(t, db.Create([]Message{
+		{Topic: "a", Body: []byte{}, CreatedAt: old, PublishedAt: &old},
+		{Topic: "a", Body: []byte{}, CreatedAt: old, PublishedAt: &old},
+		{Topic: "a", Body: []byte{}, CreatedAt: old, PublishedAt: &old},
+		{Topic: "a", Body: []byte{}, CreatedAt: old},
+		{Topic: "a", Body: []byte{}, CreatedAt: recent, PublishedAt: &recent},
+	}).Error)
+
+	n, err := o.Cleanup(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	var left int64
+	require.NoError(t, db.Model(&Message{}).Count(&left).Error)
+	assert.Equal(t, int64(2), left, "pending and recent messages are kept")
+}
@@ -0,0 +1,192 @@
+// Package profile samples a fraction of requests and aggregates where their
+// time went (middleware, handler, database, Redis) and how much they
+// allocated, so hotspots can be found in production without a profiler.
+package profile
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Phase is a part of a request whose time is recorded separately.
+type Phase int
+
+const (
+	// DB is time spent in SQL statements.
+	DB Phase = iota
+	// Redis is time spent in Redis commands.
+	Redis
+	phases
+)
+
+// sample is the in-flight record of a sampled request.
+type sample struct {
+	handlerStart time.Time
+	handler      time.Duration
+	phase        [phases]atomic.Int64
+	calls        [phases]atomic.Int64
+}
+
+type sampleKey struct{}
+
+// Observe records d spent in phase p by the sampled request of ctx.
+// It is a no-op for requests that are not sampled.
+func Observe(ctx context.Context, p Phase, d time.Duration) {
+	if s, ok := ctx.Value(sampleKey{}).(*sample); ok {
+		s.phase[p].Add(int64(d))
+		s.calls[p].Add(1)
+	}
+}
+
+// Hotspot is the aggregate of the sampled requests of one operation.
+// Durations are averages per request.
+type Hotspot struct {
+	Operation  string        `json:"operation"`
+	Samples    int64         `json:"samples"`
+	Total      time.Duration `json:"total_ns"`
+	Max        time.Duration `json:"max_ns"`
+	Middleware time.Duration `json:"middleware_ns"`
+	Handler    time.Duration `json:"handler_ns"`
+	DB         time.Duration `json:"db_ns"`
+	DBCalls    float64       `json:"db_calls"`
+	Redis      time.Duration `json:"redis_ns"`
+	RedisCalls float64       `json:"redis_calls"`
+	// AllocBytes is the heap allocated by the process while the request ran,
+	// so it includes concurrent requests and is an upper bound.
+	AllocBytes int64 `json:"alloc_bytes"`
+	// Weight is the total sampled time, the ranking key.
+	Weight time.Duration `json:"weight_ns"`
+}
+
+type aggregate struct {
+	samples, allocs     int64
+	total, max, handler time.Duration
+	phase               [phases]time.Duration
+	calls               [phases]int64
+}
+
+// Profiler aggregates sampled requests per operation.
+type Profiler struct {
+	mu  sync.Mutex
+	ops map[string]*aggregate
+}
+
+// New creates a profiler.
+func New() *Profiler {
+	return &Profiler{ops: make(map[string]*aggregate)}
+}
+
+// Server samples rate (0..1) of requests. List it first in the chain so
+// the middleware time covers every other middleware; the handler time is
+// measured by Handler.
+func (p *Profiler) Server(rate float64) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if rate <= 0 || rand.Float64() >= rate {
+				return handler(ctx, req)
+			}
+			s := &sample{}
+			allocs := heapAllocs()
+			start := time.Now()
+			reply, err := handler(context.WithValue(ctx, sampleKey{}, s), req)
+			p.record(operation(ctx), s, time.Since(start), heapAllocs()-allocs)
+			return reply, err
+		}
+	}
+}
+
+// Handler measures the handler time of sampled requests. It must be the
+// innermost middleware.
+func Handler() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			s, ok := ctx.Value(sampleKey{}).(*sample)
+			if !ok {
+				return handler(ctx, req)
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			s.handler += time.Since(start)
+			return reply, err
+		}
+	}
+}
+
+func (p *Profiler) record(op string, s *sample, total time.Duration, allocs int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	a, ok := p.ops[op]
+	if !ok {
+		a = &aggregate{}
+		p.ops[op] = a
+	}
+	a.samples++
+	a.allocs += allocs
+	a.total += total
+	a.max = max(a.max, total)
+	a.handler += s.handler
+	for i := range phases {
+		a.phase[i] += time.Duration(s.phase[i].Load())
+		a.calls[i] += s.calls[i].Load()
+	}
+}
+
+// Hotspots returns up to n operations (all when n <= 0) ordered by total
+// sampled time, highest first.
+func (p *Profiler) Hotspots(n int) []Hotspot {
+	p.mu.Lock()
+	result := make([]Hotspot, 0, len(p.ops))
+	for op, a := range p.ops {
+		c := a.samples
+		result = append(result, Hotspot{
+			Operation:  op,
+			Samples:    c,
+			Total:      a.total / time.Duration(c),
+			Max:        a.max,
+			Middleware: (a.total - a.handler) / time.Duration(c),
+			Handler:    a.handler / time.Duration(c),
+			DB:         a.phase[DB] / time.Duration(c),
+			DBCalls:    float64(a.calls[DB]) / float64(c),
+			Redis:      a.phase[Redis] / time.Duration(c),
+			RedisCalls: float64(a.calls[Redis]) / float64(c),
+			AllocBytes: a.allocs / c,
+			Weight:     a.total,
+		})
+	}
+	p.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Weight > result[j].Weight })
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// Reset discards the aggregated samples.
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = make(map[string]*aggregate)
+}
+
+func operation(ctx context.Context) string {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		return tr.Operation()
+	}
+	return "unknown"
+}
+
+// heapAllocs returns the cumulative bytes allocated on the heap.
+func heapAllocs() int64 {
+	s := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(s)
+	return int64(s[0].Value.Uint64())
+}
@@ -0,0 +1,66 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind { return transport.KindGRPC }
+func (t *testTransport) Operation() string    { return t.operation }
+
+func call(t *testing.T, p *Profiler, rate float64, op string, h middleware.Handler) {
+	t.Helper()
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: op})
+	_, err := middleware.Chain(p.Server(rate), Handler())(h)(ctx, nil)
+	require.NoError(t, err)
+}
+
+func TestProfiler(t *testing.T) {
+	p := New()
+	slow := func(ctx context.Context, _ any) (any, error) {
+		Observe(ctx, DB, 30*time.Millisecond)
+		Observe(ctx, DB, 10*time.Millisecond)
+		Observe(ctx, Redis, 5*time.Millisecond)
+		_ = make([]byte, 1<<20)
+		time.Sleep(10 * time.Millisecond)
+		return nil, nil
+	}
+	fast := func(context.Context, any) (any, error) { return nil, nil }
+
+	call(t, p, 1, "/svc/Slow", slow)
+	call(t, p, 1, "/svc/Slow", slow)
+	call(t, p, 1, "/svc/Fast", fast)
+	call(t, p, 0, "/svc/Unsampled", slow)
+
+	hot := p.Hotspots(0)
+	require.Len(t, hot, 2)
+	assert.Equal(t, "/svc/Slow", hot[0].Operation)
+	assert.Equal(t, int64(2), hot[0].Samples)
+	assert.Equal(t, 40*time.Millisecond, hot[0].DB)
+	assert.Equal(t, 2.0, hot[0].DBCalls)
+	assert.Equal(t, 5*time.Millisecond, hot[0].Redis)
+	assert.GreaterOrEqual(t, hot[0].Handler, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, hot[0].Total, hot[0].Handler)
+	assert.InDelta(t, hot[0].Total-hot[0].Handler, hot[0].Middleware, float64(time.Microsecond))
+	assert.GreaterOrEqual(t, hot[0].AllocBytes, int64(1<<20))
+	assert.Equal(t, "/svc/Fast", hot[1].Operation)
+
+	assert.Len(t, p.Hotspots(1), 1)
+	p.Reset()
+	assert.Empty(t, p.Hotspots(0))
+}
+
+func TestObserve_NotSampled(t *testing.T) {
+	assert.NotPanics(t, func() { Observe(context.Background(), DB, time.Second) })
+}
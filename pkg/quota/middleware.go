@@ -0,0 +1,155 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	"github.com/go-kratos/kratos-layout/pkg/metadata"
+)
+
+// Response headers describing the most constrained request window.
+const (
+	HeaderLimit     = "X-Quota-Limit"
+	HeaderRemaining = "X-Quota-Remaining"
+	HeaderReset     = "X-Quota-Reset" // unix seconds
+	HeaderWindow    = "X-Quota-Window"
+)
+
+// The same values under the conventional rate limit header names, which
+// client libraries read to back off before being rejected.
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset" // unix seconds
+)
+
+// HeaderAPIKey is the request header identifying API clients.
+const HeaderAPIKey = "X-Api-Key"
+
+// SubjectFunc resolves the accounting subject of a request.
+// Requests with an empty subject are not accounted.
+type SubjectFunc func(ctx context.Context) string
+
+// DefaultSubject uses the propagated tenant, falling back to the API key header.
+func DefaultSubject(ctx context.Context) string {
+	if tenant := metadata.Tenant(ctx); tenant != "" {
+		return "tenant:" + tenant
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if key := tr.RequestHeader().Get(HeaderAPIKey); key != "" {
+			return "key:" + key
+		}
+	}
+	return ""
+}
+
+// Server enforces q on incoming requests. Exhausted subjects get a 429 with
+// QuotaFailure and RetryInfo details. Request and reply sizes are counted
+// towards byte quotas. Storage errors are logged and the request is let through.
+//
+// With WithWarnRatio, a subject reaching the ratio of a limit is logged at
+// warn level and reported through lifecycle.QuotaWarning, once per window
+// period and instance.
+func Server(q *Quota, subject SubjectFunc, logger log.Logger) middleware.Middleware {
+	if subject == nil {
+		subject = DefaultSubject
+	}
+	l := log.NewHelper(log.With(logger, "module", "pkg/quota"))
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			s := subject(ctx)
+			if s == "" {
+				return handler(ctx, req)
+			}
+			usages, exceeded, err := q.Check(ctx, s)
+			if err != nil {
+				l.Warnf("check quota of %s: %v", s, err)
+				return handler(ctx, req)
+			}
+			setHeaders(ctx, usages)
+			for _, u := range q.approaching(s, usages) {
+				l.Warnf("%s is approaching its quota: %s", s, describeUsage(&u))
+				lifecycle.Emit(ctx, lifecycle.QuotaWarning{
+					Subject: s, Window: string(u.Window), Used: u.Used, Limit: u.Requests,
+					UsedBytes: u.UsedSize, LimitBytes: u.Bytes, ResetAt: u.ResetAt,
+				})
+			}
+			if exceeded != nil {
+				return nil, errdetail.QuotaFailure("QUOTA_EXCEEDED", "quota exceeded",
+					time.Until(exceeded.ResetAt), errdetail.QuotaViolation{
+						Subject:     s,
+						Description: describe(exceeded),
+					})
+			}
+
+			reply, err := handler(ctx, req)
+			if err := q.Record(ctx, s, size(req)+size(reply)); err != nil {
+				l.Warnf("record quota of %s: %v", s, err)
+			}
+			return reply, err
+		}
+	}
+}
+
+func setHeaders(ctx context.Context, usages []Usage) {
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return
+	}
+	var tightest *Usage
+	for i := range usages {
+		u := &usages[i]
+		if u.Requests > 0 && (tightest == nil || u.Remaining() < tightest.Remaining()) {
+			tightest = u
+		}
+	}
+	if tightest == nil {
+		return
+	}
+	limit := strconv.FormatInt(tightest.Requests, 10)
+	remaining := strconv.FormatInt(tightest.Remaining(), 10)
+	reset := strconv.FormatInt(tightest.ResetAt.Unix(), 10)
+	h := tr.ReplyHeader()
+	h.Set(HeaderLimit, limit)
+	h.Set(HeaderRemaining, remaining)
+	h.Set(HeaderReset, reset)
+	h.Set(HeaderWindow, string(tightest.Window))
+	h.Set(HeaderRateLimitLimit, limit)
+	h.Set(HeaderRateLimitRemaining, remaining)
+	h.Set(HeaderRateLimitReset, reset)
+}
+
+func describe(u *Usage) string {
+	if u.Requests > 0 && u.Used >= u.Requests {
+		return fmt.Sprintf("%s request quota of %d exhausted", u.Window, u.Requests)
+	}
+	return fmt.Sprintf("%s byte quota of %d exhausted", u.Window, u.Bytes)
+}
+
+func describeUsage(u *Usage) string {
+	var used []string
+	if u.Requests > 0 {
+		used = append(used, fmt.Sprintf("%d of %d requests", u.Used, u.Requests))
+	}
+	if u.Bytes > 0 {
+		used = append(used, fmt.Sprintf("%d of %d bytes", u.UsedSize, u.Bytes))
+	}
+	return fmt.Sprintf("%s used in the %s window", strings.Join(used, " and "), u.Window)
+}
+
+func size(v any) int64 {
+	if m, ok := v.(proto.Message); ok && m != nil {
+		return int64(proto.Size(m))
+	}
+	return 0
+}
@@ -0,0 +1,242 @@
+// Package quota tracks request counts and bytes per subject (API key or
+// tenant) in fixed daily and monthly windows and enforces configured limits.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Window is a fixed accounting period aligned to UTC calendar boundaries.
+type Window string
+
+// Windows.
+const (
+	Daily   Window = "daily"
+	Monthly Window = "monthly"
+)
+
+// period returns the identifier of the period containing t and the time the
+// next period starts.
+func (w Window) period(t time.Time) (string, time.Time, error) {
+	t = t.UTC()
+	switch w {
+	case Daily:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start.Format("20060102"), start.AddDate(0, 0, 1), nil
+	case Monthly:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("200601"), start.AddDate(0, 1, 0), nil
+	}
+	return "", time.Time{}, fmt.Errorf("quota: unknown window %q", w)
+}
+
+// retention is how long counters are kept after their period ends so that
+// recent periods can still be reported.
+func (w Window) retention() time.Duration {
+	if w == Monthly {
+		return 62 * 24 * time.Hour
+	}
+	return 8 * 24 * time.Hour
+}
+
+// Limit caps usage within a window. Zero fields are unlimited.
+type Limit struct {
+	Window   Window `json:"window"`
+	Requests int64  `json:"requests,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+}
+
+// Usage is the consumption of a subject within one window period.
+type Usage struct {
+	Limit
+	Period   string    `json:"period"`
+	Used     int64     `json:"used_requests"`
+	UsedSize int64     `json:"used_bytes"`
+	ResetAt  time.Time `json:"reset_at"`
+}
+
+// Exceeded reports whether the usage has reached its limit.
+func (u Usage) Exceeded() bool {
+	return (u.Requests > 0 && u.Used >= u.Requests) || (u.Bytes > 0 && u.UsedSize >= u.Bytes)
+}
+
+// Approaching reports whether the usage has reached ratio of its limit,
+// e.g. 0.8, without exceeding it.
+func (u Usage) Approaching(ratio float64) bool {
+	if ratio <= 0 || u.Exceeded() {
+		return false
+	}
+	return (u.Requests > 0 && float64(u.Used) >= ratio*float64(u.Requests)) ||
+		(u.Bytes > 0 && float64(u.UsedSize) >= ratio*float64(u.Bytes))
+}
+
+// Remaining returns the number of requests left, or -1 when unlimited.
+func (u Usage) Remaining() int64 {
+	if u.Requests <= 0 {
+		return -1
+	}
+	return max(u.Requests-u.Used, 0)
+}
+
+// Counter is the request and byte count of one period.
+type Counter struct {
+	Requests int64
+	Bytes    int64
+}
+
+// Store persists counters.
+type Store interface {
+	// Add increments the counter stored at key and keeps it for ttl.
+	Add(ctx context.Context, key string, delta Counter, ttl time.Duration) error
+	// Get returns the counters stored at keys; missing keys are zero.
+	Get(ctx context.Context, keys ...string) ([]Counter, error)
+}
+
+// Option configures a Quota.
+type Option func(*Quota)
+
+// WithWarnRatio makes the Server middleware warn once per subject and window
+// period when usage reaches ratio of a limit, e.g. 0.8. Zero disables it.
+func WithWarnRatio(ratio float64) Option {
+	return func(q *Quota) { q.warnRatio = ratio }
+}
+
+// Quota accounts usage per subject against configured limits.
+type Quota struct {
+	store Store
+	now   func() time.Time
+
+	// limitsMu guards the limits and the warn ratio, replaced by Update.
+	limitsMu  sync.RWMutex
+	defaults  []Limit
+	subjects  map[string][]Limit
+	warnRatio float64
+
+	mu sync.Mutex
+	// warned holds the period last warned about per subject and window.
+	warned map[string]string
+}
+
+// New creates a quota accountant. defaults apply to every subject without an
+// entry in subjects. Subjects may be configured with windows that only track
+// usage by leaving both limit fields zero.
+func New(store Store, defaults []Limit, subjects map[string][]Limit, opts ...Option) *Quota {
+	q := &Quota{
+		store:    store,
+		defaults: defaults,
+		subjects: subjects,
+		now:      time.Now,
+		warned:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Limits returns the limits applying to subject.
+func (q *Quota) Limits(subject string) []Limit {
+	q.limitsMu.RLock()
+	defer q.limitsMu.RUnlock()
+	if limits, ok := q.subjects[subject]; ok {
+		return limits
+	}
+	return q.defaults
+}
+
+// Update replaces the limits and the warn ratio, e.g. on a config reload.
+// The usage counted so far is kept.
+func (q *Quota) Update(defaults []Limit, subjects map[string][]Limit, warnRatio float64) {
+	q.limitsMu.Lock()
+	defer q.limitsMu.Unlock()
+	q.defaults, q.subjects, q.warnRatio = defaults, subjects, warnRatio
+}
+
+func key(subject string, w Window, period string) string {
+	return subject + ":" + string(w) + ":" + period
+}
+
+// Usage returns the usage of subject in every configured window for the
+// periods containing at.
+func (q *Quota) Usage(ctx context.Context, subject string, at time.Time) ([]Usage, error) {
+	limits := q.Limits(subject)
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	usages := make([]Usage, len(limits))
+	keys := make([]string, len(limits))
+	for i, l := range limits {
+		period, reset, err := l.Window.period(at)
+		if err != nil {
+			return nil, err
+		}
+		usages[i] = Usage{Limit: l, Period: period, ResetAt: reset}
+		keys[i] = key(subject, l.Window, period)
+	}
+	counters, err := q.store.Get(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range counters {
+		usages[i].Used, usages[i].UsedSize = c.Requests, c.Bytes
+	}
+	return usages, nil
+}
+
+// Check returns the current usage of subject and the first exhausted window,
+// if any.
+func (q *Quota) Check(ctx context.Context, subject string) ([]Usage, *Usage, error) {
+	usages, err := q.Usage(ctx, subject, q.now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range usages {
+		if usages[i].Exceeded() {
+			return usages, &usages[i], nil
+		}
+	}
+	return usages, nil, nil
+}
+
+// Record adds one request of size bytes to every window of subject.
+func (q *Quota) Record(ctx context.Context, subject string, bytes int64) error {
+	now := q.now()
+	for _, l := range q.Limits(subject) {
+		period, _, err := l.Window.period(now)
+		if err != nil {
+			return err
+		}
+		err = q.store.Add(ctx, key(subject, l.Window, period), Counter{Requests: 1, Bytes: bytes}, l.Window.retention())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// approaching returns the usages reaching the warn ratio that were not
+// warned about yet in their period.
+func (q *Quota) approaching(subject string, usages []Usage) []Usage {
+	q.limitsMu.RLock()
+	ratio := q.warnRatio
+	q.limitsMu.RUnlock()
+
+	var result []Usage
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, u := range usages {
+		if !u.Approaching(ratio) {
+			continue
+		}
+		k := subject + ":" + string(u.Window)
+		if q.warned[k] == u.Period {
+			continue
+		}
+		q.warned[k] = u.Period
+		result = append(result, u)
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+	"github.com/go-kratos/kratos-layout/pkg/errdetail"
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+func TestWindow_Period(t *testing.T) {
+	at := time.Date(2026, 12, 31, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		window Window
+		period string
+		reset  time.Time
+	}{
+		{Daily, "20261231", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Monthly, "202612", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		period, reset, err := tt.window.period(at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.period, period)
+		assert.Equal(t, tt.reset, reset)
+	}
+	_, _, err := Window("weekly").period(at)
+	assert.Error(t, err)
+}
+
+func TestQuota_Usage(t *testing.T) {
+	q := New(NewMemoryStore(),
+		[]Limit{{Window: Daily, Requests: 2}},
+		map[string][]Limit{"tenant:big": {{Window: Daily, Requests: 100}, {Window: Monthly, Bytes: 10}}},
+	)
+	ctx := context.Background()
+
+	require.NoError(t, q.Record(ctx, "tenant:small", 5))
+	usages, exceeded, err := q.Check(ctx, "tenant:small")
+	require.NoError(t, err)
+	assert.Nil(t, exceeded)
+	require.Len(t, usages, 1)
+	assert.Equal(t, int64(1), usages[0].Used)
+	assert.Equal(t, int64(5), usages[0].UsedSize)
+	assert.Equal(t, int64(1), usages[0].Remaining())
+
+	require.NoError(t, q.Record(ctx, "tenant:big", 12))
+	_, exceeded, err = q.Check(ctx, "tenant:big")
+	require.NoError(t, err)
+	require.NotNil(t, exceeded)
+	assert.Equal(t, Monthly, exceeded.Window)
+}
+
+type headerCarrier map[string]string
+
+func (h headerCarrier) Get(key string) string      { return h[key] }
+func (h headerCarrier) Set(key, value string)      { h[key] = value }
+func (h headerCarrier) Add(key, value string)      { h[key] = value }
+func (h headerCarrier) Keys() []string             { return nil }
+func (h headerCarrier) Values(key string) []string { return []string{h[key]} }
+
+type fakeTransport struct {
+	transport.Transporter
+	req, reply headerCarrier
+}
+
+func (t *fakeTransport) RequestHeader() transport.Header { return t.req }
+func (t *fakeTransport) ReplyHeader() transport.Header   { return t.reply }
+
+func TestServer(t *testing.T) {
+	q := New(NewMemoryStore(), []Limit{{Window: Daily, Requests: 2}, {Window: Monthly, Requests: 10}}, nil)
+	h := Server(q, nil, log.DefaultLogger)(func(context.Context, any) (any, error) {
+		return &v1.HelloReply{Message: "hi"}, nil
+	})
+
+	call := func(apiKey string) (*fakeTransport, error) {
+		tr := &fakeTransport{req: headerCarrier{HeaderAPIKey: apiKey}, reply: headerCarrier{}}
+		_, err := h(transport.NewServerContext(context.Background(), tr), &v1.HelloRequest{Name: "x"})
+		return tr, err
+	}
+
+	tr, err := call("k1")
+	require.NoError(t, err)
+	assert.Equal(t, "2", tr.reply[HeaderLimit])
+	assert.Equal(t, "2", tr.reply[HeaderRemaining])
+	assert.Equal(t, "daily", tr.reply[HeaderWindow])
+	assert.Equal(t, "2", tr.reply[HeaderRateLimitRemaining])
+	assert.Equal(t, tr.reply[HeaderReset], tr.reply[HeaderRateLimitReset])
+
+	tr, err = call("k1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", tr.reply[HeaderRemaining])
+
+	tr, err = call("k1")
+	require.Error(t, err)
+	assert.Equal(t, 429, errors.Code(err))
+	assert.Equal(t, "0", tr.reply[HeaderRemaining])
+	violations := errdetail.QuotaViolations(err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "key:k1", violations[0].Subject)
+	_, ok := errdetail.RetryDelay(err)
+	assert.True(t, ok)
+
+	_, err = call("")
+	assert.NoError(t, err, "anonymous requests are not accounted")
+}
+
+func TestQuota_Update(t *testing.T) {
+	q := New(NewMemoryStore(), []Limit{{Window: Daily, Requests: 1}}, nil)
+	ctx := context.Background()
+
+	require.NoError(t, q.Record(ctx, "tenant:acme", 0))
+	_, exceeded, err := q.Check(ctx, "tenant:acme")
+	require.NoError(t, err)
+	require.NotNil(t, exceeded)
+
+	q.Update([]Limit{{Window: Daily, Requests: 2}}, nil, 0)
+	usages, exceeded, err := q.Check(ctx, "tenant:acme")
+	require.NoError(t, err)
+	assert.Nil(t, exceeded)
+	require.Len(t, usages, 1)
+	assert.Equal(t, int64(1), usages[0].Used)
+}
+
+func TestUsage_Approaching(t *testing.T) {
+	assert.True(t, Usage{Limit: Limit{Requests: 10}, Used: 8}.Approaching(0.8))
+	assert.False(t, Usage{Limit: Limit{Requests: 10}, Used: 7}.Approaching(0.8))
+	assert.False(t, Usage{Limit: Limit{Requests: 10}, Used: 10}.Approaching(0.8), "exceeded")
+	assert.True(t, Usage{Limit: Limit{Requests: 10, Bytes: 100}, Used: 1, UsedSize: 90}.Approaching(0.8))
+	assert.False(t, Usage{Limit: Limit{Requests: 10}, Used: 9}.Approaching(0), "disabled")
+	assert.False(t, Usage{Used: 9}.Approaching(0.8), "unlimited")
+}
+
+func TestServer_Warning(t *testing.T) {
+	var warnings []lifecycle.QuotaWarning
+	lifecycle.Subscribe(func(_ context.Context, e lifecycle.Event) {
+		if w, ok := e.(lifecycle.QuotaWarning); ok && w.Subject == "key:warned" {
+			warnings = append(warnings, w)
+		}
+	})
+
+	q := New(NewMemoryStore(), []Limit{{Window: Daily, Requests: 4}}, nil, WithWarnRatio(0.5))
+	h := Server(q, nil, log.DefaultLogger)(func(context.Context, any) (any, error) {
+		return &v1.HelloReply{Message: "hi"}, nil
+	})
+	tr := &fakeTransport{req: headerCarrier{HeaderAPIKey: "warned"}, reply: headerCarrier{}}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	for range 4 {
+		_, err := h(ctx, &v1.HelloRequest{Name: "x"})
+		require.NoError(t, err)
+	}
+	_, err := h(ctx, &v1.HelloRequest{Name: "x"})
+	require.Error(t, err)
+
+	require.Len(t, warnings, 1, "warned once per period, not when exceeded")
+	assert.Equal(t, "daily", warnings[0].Window)
+	assert.Equal(t, int64(2), warnings[0].Used)
+	assert.Equal(t, int64(4), warnings[0].Limit)
+}
@@ -0,0 +1,93 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps counters as Redis hashes with "requests" and "bytes" fields.
+type RedisStore struct {
+	client func() redis.Cmdable
+	prefix string
+}
+
+// NewRedisStore creates a Redis backed store. client is resolved on every
+// call so the caller may swap the underlying connection. Keys are prefixed
+// with prefix + ":".
+func NewRedisStore(client func() redis.Cmdable, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Add implements Store.
+func (s *RedisStore) Add(ctx context.Context, key string, delta Counter, ttl time.Duration) error {
+	k := s.prefix + ":" + key
+	_, err := s.client().TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HIncrBy(ctx, k, "requests", delta.Requests)
+		p.HIncrBy(ctx, k, "bytes", delta.Bytes)
+		p.Expire(ctx, k, ttl)
+		return nil
+	})
+	return err
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, keys ...string) ([]Counter, error) {
+	p := s.client().Pipeline()
+	cmds := make([]*redis.SliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = p.HMGet(ctx, s.prefix+":"+key, "requests", "bytes")
+	}
+	if _, err := p.Exec(ctx); err != nil {
+		return nil, err
+	}
+	counters := make([]Counter, len(keys))
+	for i, cmd := range cmds {
+		vals := cmd.Val()
+		counters[i] = Counter{Requests: parseInt(vals[0]), Bytes: parseInt(vals[1])}
+	}
+	return counters, nil
+}
+
+func parseInt(v any) int64 {
+	s, _ := v.(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// MemoryStore keeps counters in process memory. It suits tests and single
+// instance development setups; expired counters are never reclaimed.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]Counter)}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, key string, delta Counter, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.counters[key]
+	c.Requests += delta.Requests
+	c.Bytes += delta.Bytes
+	s.counters[key] = c
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, keys ...string) ([]Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counters := make([]Counter, len(keys))
+	for i, key := range keys {
+		counters[i] = s.counters[key]
+	}
+	return counters, nil
+}
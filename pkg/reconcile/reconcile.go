@@ -0,0 +1,230 @@
+// Package reconcile converges an actual state towards a desired state:
+// fetch both, compute the difference and apply it with rate limits.
+// The outcome of every run is kept and emitted as a lifecycle.Reconciled event.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"golang.org/x/time/rate"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+)
+
+// ErrTooManyChanges is returned when a diff exceeds the configured maximum,
+// which usually means one of the state fetchers returned a partial result.
+var ErrTooManyChanges = errors.New("reconcile: too many changes")
+
+// Op is the kind of change needed to converge a key.
+type Op string
+
+// Operations.
+const (
+	OpCreate Op = "create"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Change is a single difference between desired and actual state.
+type Change[T any] struct {
+	Op      Op
+	Key     string
+	Desired T
+	Actual  T
+}
+
+// Diff returns the changes turning actual into desired, sorted by key.
+// A nil equal treats every key present on both sides as up to date.
+func Diff[T any](desired, actual map[string]T, equal func(desired, actual T) bool) []Change[T] {
+	var changes []Change[T]
+	for key, d := range desired {
+		a, ok := actual[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change[T]{Op: OpCreate, Key: key, Desired: d})
+		case equal != nil && !equal(d, a):
+			changes = append(changes, Change[T]{Op: OpUpdate, Key: key, Desired: d, Actual: a})
+		}
+	}
+	for key, a := range actual {
+		if _, ok := desired[key]; !ok {
+			changes = append(changes, Change[T]{Op: OpDelete, Key: key, Actual: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Spec describes a reconciliation.
+type Spec[T any] struct {
+	Name    string
+	Desired func(ctx context.Context) (map[string]T, error)
+	Actual  func(ctx context.Context) (map[string]T, error)
+	Equal   func(desired, actual T) bool
+	// Apply converges one key. Changes with an Op that Apply does not handle
+	// should return nil. Deletes are skipped unless AllowDelete is set.
+	Apply       func(ctx context.Context, c Change[T]) error
+	AllowDelete bool
+}
+
+// Result is the outcome of one run.
+type Result struct {
+	Name     string        `json:"name"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Created  int           `json:"created"`
+	Updated  int           `json:"updated"`
+	Deleted  int           `json:"deleted"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"` // deletes not allowed, or dry run
+	Error    string        `json:"error,omitempty"`
+}
+
+// Option configures a Reconciler.
+type Option func(*options)
+
+type options struct {
+	limit      rate.Limit
+	burst      int
+	maxChanges int
+	dryRun     bool
+}
+
+// WithRateLimit caps the number of changes applied per second.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(o *options) {
+		o.limit = rate.Limit(perSecond)
+		o.burst = max(burst, 1)
+	}
+}
+
+// WithMaxChanges aborts a run whose diff has more than n changes.
+func WithMaxChanges(n int) Option {
+	return func(o *options) { o.maxChanges = n }
+}
+
+// WithDryRun computes and logs changes without applying them.
+func WithDryRun(dryRun bool) Option {
+	return func(o *options) { o.dryRun = dryRun }
+}
+
+// Runner is a reconciler with its state type erased, so reconcilers of
+// different types can be scheduled together.
+type Runner interface {
+	Name() string
+	Run(ctx context.Context) (Result, error)
+	Last() Result
+}
+
+var _ Runner = (*Reconciler[int])(nil)
+
+// Reconciler runs a Spec.
+type Reconciler[T any] struct {
+	spec    Spec[T]
+	opts    options
+	limiter *rate.Limiter
+	log     *log.Helper
+
+	mu   sync.Mutex
+	last Result
+}
+
+// New creates a reconciler for spec.
+func New[T any](spec Spec[T], logger log.Logger, opts ...Option) *Reconciler[T] {
+	o := options{limit: rate.Inf, burst: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Reconciler[T]{
+		spec:    spec,
+		opts:    o,
+		limiter: rate.NewLimiter(o.limit, o.burst),
+		log:     log.NewHelper(log.With(logger, "module", "pkg/reconcile", "reconciler", spec.Name)),
+	}
+}
+
+// Name returns the spec name.
+func (r *Reconciler[T]) Name() string { return r.spec.Name }
+
+// Last returns the result of the previous run.
+func (r *Reconciler[T]) Last() Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run performs one reconciliation. Failing changes are counted and the
+// remaining ones still applied; the returned error joins all failures.
+func (r *Reconciler[T]) Run(ctx context.Context) (Result, error) {
+	res := Result{Name: r.spec.Name, Started: time.Now()}
+	err := r.run(ctx, &res)
+	res.Duration = time.Since(res.Started)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.last = res
+	r.mu.Unlock()
+
+	lifecycle.Emit(ctx, lifecycle.Reconciled{
+		Name:    res.Name,
+		Created: res.Created,
+		Updated: res.Updated,
+		Deleted: res.Deleted,
+		Failed:  res.Failed,
+		Err:     err,
+	})
+	return res, err
+}
+
+func (r *Reconciler[T]) run(ctx context.Context, res *Result) error {
+	desired, err := r.spec.Desired(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch desired state: %w", err)
+	}
+	actual, err := r.spec.Actual(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch actual state: %w", err)
+	}
+	changes := Diff(desired, actual, r.spec.Equal)
+	if r.opts.maxChanges > 0 && len(changes) > r.opts.maxChanges {
+		return fmt.Errorf("%w: %d > %d", ErrTooManyChanges, len(changes), r.opts.maxChanges)
+	}
+
+	var errs []error
+	for _, c := range changes {
+		if r.opts.dryRun {
+			r.log.Infof("dry run: %s %s", c.Op, c.Key)
+			res.Skipped++
+			continue
+		}
+		if c.Op == OpDelete && !r.spec.AllowDelete {
+			r.log.Debugf("skip %s %s", c.Op, c.Key)
+			res.Skipped++
+			continue
+		}
+		if err := r.limiter.Wait(ctx); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if err := r.spec.Apply(ctx, c); err != nil {
+			res.Failed++
+			errs = append(errs, fmt.Errorf("%s %s: %w", c.Op, c.Key, err))
+			continue
+		}
+		switch c.Op {
+		case OpCreate:
+			res.Created++
+		case OpUpdate:
+			res.Updated++
+		case OpDelete:
+			res.Deleted++
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	desired := map[string]int{"a": 1, "b": 2, "c": 3}
+	actual := map[string]int{"b": 2, "c": 4, "d": 5}
+
+	changes := Diff(desired, actual, func(d, a int) bool { return d == a })
+	assert.Equal(t, []Change[int]{
+		{Op: OpCreate, Key: "a", Desired: 1},
+		{Op: OpUpdate, Key: "c", Desired: 3, Actual: 4},
+		{Op: OpDelete, Key: "d", Actual: 5},
+	}, changes)
+
+	assert.Len(t, Diff(desired, actual, nil), 2, "nil equal never updates")
+}
+
+type store map[string]int
+
+func (s store) spec(desired map[string]int, allowDelete bool) Spec[int] {
+	return Spec[int]{
+		Name:    "test",
+		Desired: func(context.Context) (map[string]int, error) { return desired, nil },
+		Actual:  func(context.Context) (map[string]int, error) { return s, nil },
+		Equal:   func(d, a int) bool { return d == a },
+		Apply: func(_ context.Context, c Change[int]) error {
+			if c.Key == "bad" {
+				return errors.New("rejected")
+			}
+			if c.Op == OpDelete {
+				delete(s, c.Key)
+			} else {
+				s[c.Key] = c.Desired
+			}
+			return nil
+		},
+		AllowDelete: allowDelete,
+	}
+}
+
+func TestReconciler_Run(t *testing.T) {
+	s := store{"b": 1, "old": 9}
+	r := New(s.spec(map[string]int{"a": 1, "b": 2, "bad": 3}, false), log.DefaultLogger)
+
+	res, err := r.Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, res.Created)
+	assert.Equal(t, 1, res.Updated)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, 1, res.Skipped)
+	assert.Equal(t, store{"a": 1, "b": 2, "old": 9}, s)
+	assert.Equal(t, res, r.Last())
+	assert.Contains(t, r.Last().Error, "create bad: rejected")
+}
+
+func TestReconciler_Options(t *testing.T) {
+	s := store{"old": 9}
+	desired := map[string]int{"a": 1, "b": 2, "c": 3}
+
+	_, err := New(s.spec(desired, true), log.DefaultLogger, WithMaxChanges(2)).Run(context.Background())
+	assert.ErrorIs(t, err, ErrTooManyChanges)
+
+	res, err := New(s.spec(desired, true), log.DefaultLogger, WithDryRun(true)).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Skipped)
+	assert.Equal(t, store{"old": 9}, s)
+
+	start := time.Now()
+	res, err = New(s.spec(desired, true), log.DefaultLogger, WithRateLimit(50, 1)).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Created)
+	assert.Equal(t, 1, res.Deleted)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, store{"a": 1, "b": 2, "c": 3}, s)
+}
@@ -0,0 +1,63 @@
+// Package region scopes the messaging resources of a service deployed
+// active-active in several regions (data centers).
+package region
+
+import (
+	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/env"
+)
+
+// Scope names the region of the instance and scopes topics and consumer
+// groups to it. A nil Scope, or one without a region, scopes nothing.
+type Scope struct {
+	name   string
+	topics bool
+	groups bool
+	global map[string]bool
+}
+
+// NewScope creates a scope for region. With topics, topics get the region
+// as suffix so events stay in the region that published them, except the
+// global ones. With groups, consumer groups get it, so that every region
+// consumes a topic replicated between them on its own.
+func NewScope(region string, topics, groups bool, global ...string) *Scope {
+	s := &Scope{name: region, topics: topics, groups: groups, global: make(map[string]bool, len(global))}
+	for _, t := range global {
+		s.global[t] = true
+	}
+	return s
+}
+
+// NewScopeFromProto creates a Scope from proto configuration. The region
+// defaults to the REGION environment variable.
+func NewScopeFromProto(c *conf.Region) *Scope {
+	name := c.GetName()
+	if name == "" {
+		name = env.CurrentDeployment().Region
+	}
+	return NewScope(name, c.GetScopeTopics(), c.GetScopeConsumerGroups(), c.GetGlobalTopics()...)
+}
+
+// Name returns the region, or "" when unknown.
+func (s *Scope) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+// Topic returns the name of topic in the region, e.g. orders_cn-east.
+func (s *Scope) Topic(topic string) string {
+	if s == nil || s.name == "" || !s.topics || s.global[topic] {
+		return topic
+	}
+	return topic + "_" + s.name
+}
+
+// Group returns the name of the consumer group in the region.
+func (s *Scope) Group(group string) string {
+	if s == nil || s.name == "" || !s.groups || group == "" {
+		return group
+	}
+	return group + "_" + s.name
+}
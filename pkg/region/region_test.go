@@ -0,0 +1,32 @@
+package region
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+func TestScope(t *testing.T) {
+	s := NewScopeFromProto(&conf.Region{Name: "cn-east", ScopeTopics: true, ScopeConsumerGroups: true, GlobalTopics: []string{"users"}})
+	assert.Equal(t, "cn-east", s.Name())
+	assert.Equal(t, "orders_cn-east", s.Topic("orders"))
+	assert.Equal(t, "users", s.Topic("users"))
+	assert.Equal(t, "billing_cn-east", s.Group("billing"))
+
+	topicsOnly := NewScope("cn-east", true, false)
+	assert.Equal(t, "billing", topicsOnly.Group("billing"))
+
+	var none *Scope
+	assert.Empty(t, none.Name())
+	assert.Equal(t, "orders", none.Topic("orders"))
+	assert.Equal(t, "orders", NewScope("", true, true).Topic("orders"))
+}
+
+func TestNewScopeFromProto_Env(t *testing.T) {
+	t.Setenv("REGION", "us-west")
+	s := NewScopeFromProto(nil)
+	assert.Equal(t, "us-west", s.Name())
+	assert.Equal(t, "orders", s.Topic("orders"), "scoping is opt-in")
+}
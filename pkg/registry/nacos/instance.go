@@ -0,0 +1,26 @@
+package nacos
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// ErrDisabled is returned by discovery when the binary is built with the
+// nonacos tag. It is declared in every build so callers can test for it.
+var ErrDisabled = errors.New("kratos/nacos: compiled out by the nonacos build tag")
+
+// Instance is a service instance as stored in Nacos.
+type Instance struct {
+	Service  string            `json:"service"`
+	IP       string            `json:"ip"`
+	Port     uint64            `json:"port"`
+	Weight   float64           `json:"weight"`
+	Enabled  bool              `json:"enabled"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Key identifies the instance within the registry group.
+func (i Instance) Key() string {
+	return i.Service + "/" + net.JoinHostPort(i.IP, strconv.FormatUint(i.Port, 10))
+}
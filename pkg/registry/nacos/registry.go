@@ -1,3 +1,5 @@
+//go:build !nonacos
+
 package nacos
 
 import (
@@ -9,12 +11,15 @@ import (
 	"net"
 	"net/url"
 	"strconv"
+	"sync"
 
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/vo"
 
 	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
 )
 
 var (
@@ -67,6 +72,9 @@ func WithDefaultKind(kind string) Option {
 type Registry struct {
 	opts options
 	cli  naming_client.INamingClient
+
+	mu         sync.Mutex
+	registered []vo.RegisterInstanceParam
 }
 
 // New new a nacos registry.
@@ -126,7 +134,7 @@ func parseEndpoint(endpoint string) (u *url.URL, host string, port uint64, err e
 }
 
 // Register the registration.
-func (r *Registry) Register(_ context.Context, si *registry.ServiceInstance) error {
+func (r *Registry) Register(ctx context.Context, si *registry.ServiceInstance) error {
 	if si.Name == "" {
 		return ErrServiceInstanceNameEmpty
 	}
@@ -136,7 +144,7 @@ func (r *Registry) Register(_ context.Context, si *registry.ServiceInstance) err
 			return err
 		}
 		rmd, weight := r.buildMetadata(si, u.Scheme)
-		_, e := r.cli.RegisterInstance(vo.RegisterInstanceParam{
+		param := vo.RegisterInstanceParam{
 			Ip:          host,
 			Port:        p,
 			ServiceName: si.Name + "." + u.Scheme,
@@ -147,35 +155,157 @@ func (r *Registry) Register(_ context.Context, si *registry.ServiceInstance) err
 			Metadata:    rmd,
 			ClusterName: r.opts.cluster,
 			GroupName:   r.opts.group,
-		})
+		}
+		_, e := r.cli.RegisterInstance(param)
 		if e != nil {
 			return fmt.Errorf("RegisterInstance err %w, endpoint: %s", e, endpoint)
 		}
+		r.mu.Lock()
+		r.registered = append(r.registered, param)
+		r.mu.Unlock()
+	}
+	lifecycle.Emit(ctx, lifecycle.ServiceRegistered{Name: si.Name, ID: si.ID, Endpoints: si.Endpoints})
+	return nil
+}
+
+// Weight returns the default instance weight.
+func (r *Registry) Weight() float64 {
+	return r.opts.weight
+}
+
+// SetWeight changes the weight of all instances registered by r.
+// Instances are re-registered so that the weight carried by client
+// heartbeats is updated as well.
+func (r *Registry) SetWeight(_ context.Context, weight float64) error {
+	if weight <= 0 {
+		return fmt.Errorf("kratos/nacos: weight must be positive, got %v", weight)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.registered {
+		param := r.registered[i]
+		param.Weight = weight
+		if _, err := r.cli.RegisterInstance(param); err != nil {
+			return fmt.Errorf("update weight of %s %s:%d: %w", param.ServiceName, param.Ip, param.Port, err)
+		}
+		r.registered[i] = param
 	}
 	return nil
 }
 
+// Reregister registers every instance registered by r again, e.g. after the
+// Nacos server lost its ephemeral instances. It returns the number of
+// instances registered.
+func (r *Registry) Reregister(_ context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, param := range r.registered {
+		if _, err := r.cli.RegisterInstance(param); err != nil {
+			return i, fmt.Errorf("reregister %s %s:%d: %w", param.ServiceName, param.Ip, param.Port, err)
+		}
+	}
+	return len(r.registered), nil
+}
+
+// Local returns the instances registered by r as they should appear in Nacos.
+func (r *Registry) Local() []Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]Instance, 0, len(r.registered))
+	for _, p := range r.registered {
+		result = append(result, Instance{
+			Service:  p.ServiceName,
+			IP:       p.Ip,
+			Port:     p.Port,
+			Weight:   p.Weight,
+			Enabled:  p.Enable,
+			Metadata: p.Metadata,
+		})
+	}
+	return result
+}
+
+// Remote returns every instance of service currently stored in Nacos,
+// including unhealthy ones.
+func (r *Registry) Remote(_ context.Context, service string) ([]Instance, error) {
+	res, err := r.cli.SelectAllInstances(vo.SelectAllInstancesParam{
+		ServiceName: service,
+		GroupName:   r.opts.group,
+		Clusters:    []string{r.opts.cluster},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Instance, 0, len(res))
+	for _, in := range res {
+		result = append(result, Instance{
+			Service:  service,
+			IP:       in.Ip,
+			Port:     in.Port,
+			Weight:   in.Weight,
+			Enabled:  in.Enable,
+			Metadata: in.Metadata,
+		})
+	}
+	return result, nil
+}
+
+// Restore registers the local instance identified by key again.
+func (r *Registry) Restore(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.registered {
+		if (Instance{Service: p.ServiceName, IP: p.Ip, Port: p.Port}).Key() != key {
+			continue
+		}
+		if _, err := r.cli.RegisterInstance(p); err != nil {
+			return fmt.Errorf("restore %s: %w", key, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("kratos/nacos: %s is not registered by this process", key)
+}
+
 // Deregister the registration.
-func (r *Registry) Deregister(_ context.Context, service *registry.ServiceInstance) error {
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
 	for _, endpoint := range service.Endpoints {
 		u, host, p, err := parseEndpoint(endpoint)
 		if err != nil {
 			return err
 		}
+		serviceName := service.Name + "." + u.Scheme
 		if _, err = r.cli.DeregisterInstance(vo.DeregisterInstanceParam{
 			Ip:          host,
 			Port:        p,
-			ServiceName: service.Name + "." + u.Scheme,
+			ServiceName: serviceName,
 			GroupName:   r.opts.group,
 			Cluster:     r.opts.cluster,
 			Ephemeral:   true,
 		}); err != nil {
 			return err
 		}
+		r.forget(serviceName, host, p)
 	}
+	lifecycle.Emit(ctx, lifecycle.ServiceDeregistered{Name: service.Name, ID: service.ID, Endpoints: service.Endpoints})
 	return nil
 }
 
+// forget removes a deregistered instance from the weight bookkeeping.
+func (r *Registry) forget(serviceName, host string, port uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.registered[:0]
+	for _, param := range r.registered {
+		if param.ServiceName != serviceName || param.Ip != host || param.Port != port {
+			kept = append(kept, param)
+		}
+	}
+	r.registered = kept
+}
+
 // Watch creates a watcher according to the service name.
 func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
 	return newWatcher(ctx, r.cli, serviceName, r.opts.group, r.opts.kind, []string{r.opts.cluster})
@@ -0,0 +1,77 @@
+//go:build nonacos
+
+package nacos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// Registry is a no-op registry for binaries built without Nacos: nothing is
+// registered and discovery fails, so clients must dial direct endpoints.
+type Registry struct{}
+
+// New returns a no-op registry.
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register implements registry.Registrar.
+func (r *Registry) Register(context.Context, *registry.ServiceInstance) error {
+	return nil
+}
+
+// Weight returns the default instance weight.
+func (r *Registry) Weight() float64 {
+	return 100
+}
+
+// SetWeight accepts any positive weight.
+func (r *Registry) SetWeight(_ context.Context, weight float64) error {
+	if weight <= 0 {
+		return fmt.Errorf("kratos/nacos: weight must be positive, got %v", weight)
+	}
+	return nil
+}
+
+// Reregister registers nothing.
+func (r *Registry) Reregister(context.Context) (int, error) {
+	return 0, nil
+}
+
+// Local returns no instances.
+func (r *Registry) Local() []Instance {
+	return nil
+}
+
+// Remote returns no instances.
+func (r *Registry) Remote(context.Context, string) ([]Instance, error) {
+	return nil, nil
+}
+
+// Restore always fails: nothing is registered by this process.
+func (r *Registry) Restore(_ context.Context, key string) error {
+	return fmt.Errorf("kratos/nacos: %s is not registered by this process", key)
+}
+
+// Deregister implements registry.Registrar.
+func (r *Registry) Deregister(context.Context, *registry.ServiceInstance) error {
+	return nil
+}
+
+// Watch implements registry.Discovery.
+func (r *Registry) Watch(context.Context, string) (registry.Watcher, error) {
+	return nil, ErrDisabled
+}
+
+// GetService implements registry.Discovery.
+func (r *Registry) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return nil, ErrDisabled
+}
@@ -1,3 +1,5 @@
+//go:build !nonacos
+
 package nacos
 
 import (
@@ -0,0 +1,11 @@
+//go:build nonacos
+
+package registry
+
+import "github.com/go-kratos/kratos-layout/pkg/registry/nacos"
+
+// NewNacosRegistryFromEnv returns a no-op registry: Nacos is compiled out
+// by the nonacos build tag.
+func NewNacosRegistryFromEnv() (*nacos.Registry, error) {
+	return nacos.New(), nil
+}
@@ -0,0 +1,186 @@
+// Package reload applies configuration changes to running subsystems
+// without a restart, e.g. on SIGHUP. Subsystems register the configuration
+// keys they apply; changes of the other keys are reported as requiring a
+// restart.
+package reload
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/pkg/fingerprint"
+)
+
+// Func applies the configuration c to a subsystem. On error the subsystem
+// must keep its previous settings.
+type Func func(ctx context.Context, c proto.Message) error
+
+// Report is the outcome of a reload.
+type Report struct {
+	// Reloaded are the subsystems that applied their changes.
+	Reloaded []string
+	// Failed are the subsystems that failed to, keeping their settings.
+	Failed map[string]error
+	// Restart are the keys changed since startup that no subsystem applies,
+	// taking effect on the next restart.
+	Restart []string
+}
+
+type subsystem struct {
+	name  string
+	keys  []string
+	apply Func
+	// files makes every reload apply the subsystem.
+	files bool
+}
+
+// covers reports whether key is one of the keys of s or below them.
+func (s subsystem) covers(key string) bool {
+	for _, k := range s.keys {
+		if key == k || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the reloadable subsystems and the configuration they run
+// with.
+type Registry struct {
+	mu         sync.Mutex
+	subsystems []subsystem
+	started    *fingerprint.Snapshot
+	applied    map[string]string
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a subsystem applying the configuration keys, dotted paths of
+// the proto field names such as "server.quota", to the reloads.
+func (r *Registry) Register(name string, keys []string, apply Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subsystems = append(r.subsystems, subsystem{name: name, keys: keys, apply: apply})
+}
+
+// RegisterFiles is Register for a subsystem reading files the keys name,
+// such as certificates: it is applied on every reload, as the files may
+// change without the configuration.
+func (r *Registry) RegisterFiles(name string, keys []string, apply Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subsystems = append(r.subsystems, subsystem{name: name, keys: keys, apply: apply, files: true})
+}
+
+// Start records c as the configuration the service started with.
+func (r *Registry) Start(c proto.Message) error {
+	s, err := fingerprint.New(c, "")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started, r.applied = s, s.Values
+	return nil
+}
+
+// Reload applies next to the subsystems whose keys changed since the last
+// reload, and reports the other keys changed since Start.
+func (r *Registry) Reload(ctx context.Context, next proto.Message) (*Report, error) {
+	s, err := fingerprint.New(next, "")
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started == nil {
+		return nil, errors.New("reload before start")
+	}
+
+	report := &Report{Failed: make(map[string]error)}
+	applied := s.Values
+	changed := s.Diff(&fingerprint.Snapshot{Values: r.applied})
+	for _, sub := range r.subsystems {
+		if !sub.files && !coversAny(sub, changed) {
+			continue
+		}
+		if err := sub.apply(ctx, next); err != nil {
+			report.Failed[sub.name] = err
+			// Retried on the next reload.
+			applied = restore(applied, r.applied, sub)
+			continue
+		}
+		report.Reloaded = append(report.Reloaded, sub.name)
+	}
+	r.applied = applied
+
+	for _, c := range s.Diff(r.started) {
+		if !slices.ContainsFunc(r.subsystems, func(sub subsystem) bool { return sub.covers(c.Key) }) {
+			report.Restart = append(report.Restart, c.Key)
+		}
+	}
+	sort.Strings(report.Reloaded)
+	return report, nil
+}
+
+func coversAny(sub subsystem, changes []fingerprint.Change) bool {
+	for _, c := range changes {
+		if sub.covers(c.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// restore returns values with the keys of sub set back as in prev.
+func restore(values, prev map[string]string, sub subsystem) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if !sub.covers(k) {
+			out[k] = v
+		}
+	}
+	for k, v := range prev {
+		if sub.covers(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process-wide registry used by Register, Start and
+// Reload.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a subsystem to the default registry.
+func Register(name string, keys []string, apply Func) {
+	defaultRegistry.Register(name, keys, apply)
+}
+
+// RegisterFiles adds a subsystem reading files to the default registry.
+func RegisterFiles(name string, keys []string, apply Func) {
+	defaultRegistry.RegisterFiles(name, keys, apply)
+}
+
+// Start records c as the startup configuration of the default registry.
+func Start(c proto.Message) error {
+	return defaultRegistry.Start(c)
+}
+
+// Reload reloads the default registry with next.
+func Reload(ctx context.Context, next proto.Message) (*Report, error) {
+	return defaultRegistry.Reload(ctx, next)
+}
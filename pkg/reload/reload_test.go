@@ -0,0 +1,68 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+func config(level string, requests int64, addr string) *conf.Bootstrap {
+	return &conf.Bootstrap{
+		Log: &conf.Log{Level: level},
+		Server: &conf.Server{Quota: &conf.Server_Quota{
+			DefaultLimits: []*conf.Server_Quota_Limit{{Window: "daily", Requests: requests}},
+		}},
+		Data: &conf.Data{Redis: &conf.Data_Redis{Addr: addr}},
+	}
+}
+
+func TestRegistry_Reload(t *testing.T) {
+	r := NewRegistry()
+	var levels []string
+	r.Register("log", []string{"log.level"}, func(_ context.Context, c proto.Message) error {
+		levels = append(levels, c.(*conf.Bootstrap).GetLog().GetLevel())
+		return nil
+	})
+	quotaErr := errors.New("invalid window")
+	r.Register("quota", []string{"server.quota"}, func(context.Context, proto.Message) error {
+		return quotaErr
+	})
+	var certs int
+	r.RegisterFiles("tls", []string{"data.database.tls"}, func(context.Context, proto.Message) error {
+		certs++
+		return nil
+	})
+	ctx := context.Background()
+
+	_, err := r.Reload(ctx, config("info", 10, "127.0.0.1:6379"))
+	assert.Error(t, err)
+	require.NoError(t, r.Start(config("info", 10, "127.0.0.1:6379")))
+
+	report, err := r.Reload(ctx, config("debug", 20, "10.0.0.1:6379"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log", "tls"}, report.Reloaded)
+	assert.Equal(t, map[string]error{"quota": quotaErr}, report.Failed)
+	assert.Equal(t, []string{"data.redis.addr"}, report.Restart)
+	assert.Equal(t, []string{"debug"}, levels)
+	assert.Equal(t, 1, certs)
+
+	// Unchanged keys are not applied again, failed ones are retried.
+	report, err = r.Reload(ctx, config("debug", 20, "10.0.0.1:6379"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tls"}, report.Reloaded)
+	assert.Contains(t, report.Failed, "quota")
+	assert.Equal(t, []string{"debug"}, levels)
+	assert.Equal(t, 2, certs)
+
+	// Keys reverted to the startup values no longer need a restart.
+	report, err = r.Reload(ctx, config("info", 20, "127.0.0.1:6379"))
+	require.NoError(t, err)
+	assert.Empty(t, report.Restart)
+	assert.Equal(t, []string{"debug", "info"}, levels)
+}
@@ -0,0 +1,195 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Overflow is what SendAsync does when the queue of an AsyncQueueConfig is
+// full.
+type Overflow string
+
+const (
+	// OverflowBlock waits for room until the context of SendAsync is done.
+	OverflowBlock Overflow = "block"
+	// OverflowDrop drops the oldest queued message to make room, for
+	// messages superseded by the newer ones, e.g. state snapshots.
+	OverflowDrop Overflow = "drop"
+	// OverflowError fails the new message right away.
+	OverflowError Overflow = "error"
+)
+
+var (
+	// ErrQueueFull is passed to the callback of a message not queued, or
+	// dropped, because the send queue was full.
+	ErrQueueFull = errors.New("rocketmq: send queue full")
+	// ErrProducerClosed is passed to the callback of a message sent after
+	// the producer was closed, or not flushed in time.
+	ErrProducerClosed = errors.New("rocketmq: producer closed")
+)
+
+const (
+	defaultQueueSize    = 1024
+	defaultQueueWorkers = 4
+	defaultFlushTimeout = 10 * time.Second
+)
+
+// AsyncQueueConfig makes SendAsync queue the messages in memory, sent by a
+// fixed number of workers, instead of a goroutine of the SDK each: bursts
+// are smoothed and bounded. The queued messages are flushed on close.
+type AsyncQueueConfig struct {
+	Size     int      // Queue capacity, defaults to 1024
+	Workers  int      // Sending workers, defaults to 4
+	Overflow Overflow // Defaults to OverflowBlock
+	// FlushTimeout bounds the sending of the queued messages on close,
+	// 10s by default; the callbacks of the ones left get ErrProducerClosed.
+	FlushTimeout time.Duration
+}
+
+func (c AsyncQueueConfig) validate() error {
+	switch c.Overflow {
+	case "", OverflowBlock, OverflowDrop, OverflowError:
+		return nil
+	}
+	return fmt.Errorf("rocketmq: unknown send queue overflow %q: want block, drop or error", c.Overflow)
+}
+
+// asyncJob is a message queued by SendAsync.
+type asyncJob struct {
+	ctx      context.Context
+	msg      *Message
+	callback func(context.Context, *SendReceipt, error)
+}
+
+// asyncQueue feeds the messages of SendAsync to the workers sending them.
+type asyncQueue struct {
+	cfg  AsyncQueueConfig
+	jobs chan asyncJob
+
+	mu      sync.RWMutex // guards closed
+	closed  bool
+	closing chan struct{}
+	// enqueuing are the SendAsync calls between the closed check and the
+	// queue, awaited before the queue is closed.
+	enqueuing sync.WaitGroup
+	workers   sync.WaitGroup
+	// flushed is closed when the flush timeout expires, failing the
+	// messages left.
+	flushed chan struct{}
+}
+
+// newAsyncQueue starts the workers of cfg sending with send.
+func newAsyncQueue(cfg AsyncQueueConfig, send func(asyncJob)) *asyncQueue {
+	if cfg.Size <= 0 {
+		cfg.Size = defaultQueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultQueueWorkers
+	}
+	if cfg.Overflow == "" {
+		cfg.Overflow = OverflowBlock
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = defaultFlushTimeout
+	}
+	q := &asyncQueue{
+		cfg:     cfg,
+		jobs:    make(chan asyncJob, cfg.Size),
+		closing: make(chan struct{}),
+		flushed: make(chan struct{}),
+	}
+	for range cfg.Workers {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for j := range q.jobs {
+				select {
+				case <-q.flushed:
+					j.callback(j.ctx, nil, ErrProducerClosed)
+				default:
+					send(j)
+				}
+			}
+		}()
+	}
+	return q
+}
+
+// enqueue queues j, or calls its callback with the reason it cannot.
+func (q *asyncQueue) enqueue(j asyncJob) {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		j.callback(j.ctx, nil, ErrProducerClosed)
+		return
+	}
+	q.enqueuing.Add(1)
+	q.mu.RUnlock()
+	defer q.enqueuing.Done()
+
+	switch q.cfg.Overflow {
+	case OverflowError:
+		select {
+		case q.jobs <- j:
+		default:
+			j.callback(j.ctx, nil, ErrQueueFull)
+		}
+	case OverflowDrop:
+		for {
+			select {
+			case q.jobs <- j:
+				return
+			default:
+			}
+			select {
+			case old := <-q.jobs:
+				old.callback(old.ctx, nil, ErrQueueFull)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.jobs <- j:
+		case <-j.ctx.Done():
+			j.callback(j.ctx, nil, j.ctx.Err())
+		case <-q.closing:
+			j.callback(j.ctx, nil, ErrProducerClosed)
+		}
+	}
+}
+
+// close stops accepting messages and waits for the queued ones to be sent,
+// at most the flush timeout. It reports the messages left unsent.
+func (q *asyncQueue) close() int {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return 0
+	}
+	q.closed = true
+	close(q.closing)
+	q.mu.Unlock()
+	q.enqueuing.Wait()
+	close(q.jobs)
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(q.cfg.FlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return 0
+	case <-timer.C:
+	}
+	left := len(q.jobs)
+	close(q.flushed)
+	// The sends in flight are bounded by the send timeout.
+	<-done
+	return left
+}
@@ -0,0 +1,137 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// results collects the outcome of the messages sent with SendAsync by body.
+type results struct {
+	mu   sync.Mutex
+	errs map[string]error
+	wg   sync.WaitGroup
+}
+
+func newResults() *results {
+	return &results{errs: make(map[string]error)}
+}
+
+func (r *results) callback(body string) func(context.Context, *SendReceipt, error) {
+	r.wg.Add(1)
+	return func(_ context.Context, _ *SendReceipt, err error) {
+		r.mu.Lock()
+		r.errs[body] = err
+		r.mu.Unlock()
+		r.wg.Done()
+	}
+}
+
+func job(r *results, body string) asyncJob {
+	return asyncJob{ctx: context.Background(), msg: &Message{Body: []byte(body)}, callback: r.callback(body)}
+}
+
+// gatedSend sends the jobs once release is closed.
+func gatedSend(release <-chan struct{}) func(asyncJob) {
+	return func(j asyncJob) {
+		<-release
+		j.callback(j.ctx, &SendReceipt{MessageID: string(j.msg.Body)}, nil)
+	}
+}
+
+func TestProducer_SendAsyncQueue(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger), stop: func() {}}
+	p.queue = newAsyncQueue(AsyncQueueConfig{Workers: 2}, p.sendQueued)
+
+	r := newResults()
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, body := range []string{"a", "b", "fail", "c"} {
+		p.SendAsync(ctx, &Message{Topic: "orders", Body: []byte(body)}, r.callback(body))
+	}
+	// Queued messages are sent after the caller is done.
+	cancel()
+	p.close()
+	r.wg.Wait()
+
+	assert.NoError(t, r.errs["a"])
+	assert.ErrorContains(t, r.errs["fail"], "broker busy")
+	assert.Len(t, f.sent, 3)
+
+	p.SendAsync(context.Background(), &Message{Topic: "orders"}, r.callback("late"))
+	r.wg.Wait()
+	assert.ErrorIs(t, r.errs["late"], ErrProducerClosed)
+}
+
+func TestAsyncQueue_Overflow(t *testing.T) {
+	tests := []struct {
+		overflow Overflow
+		failed   string
+	}{
+		{OverflowError, "c"},
+		{OverflowDrop, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.overflow), func(t *testing.T) {
+			release := make(chan struct{})
+			q := newAsyncQueue(AsyncQueueConfig{Size: 1, Workers: 1, Overflow: tt.overflow}, gatedSend(release))
+			r := newResults()
+			q.enqueue(job(r, "a"))
+			// The worker holds a, b waits in the queue.
+			require.Eventually(t, func() bool { return len(q.jobs) == 0 }, time.Second, time.Millisecond)
+			q.enqueue(job(r, "b"))
+			q.enqueue(job(r, "c"))
+			close(release)
+			q.close()
+			r.wg.Wait()
+
+			for body, err := range r.errs {
+				if body == tt.failed {
+					assert.ErrorIs(t, err, ErrQueueFull, body)
+				} else {
+					assert.NoError(t, err, body)
+				}
+			}
+		})
+	}
+}
+
+func TestAsyncQueue_Block(t *testing.T) {
+	release := make(chan struct{})
+	q := newAsyncQueue(AsyncQueueConfig{Size: 1, Workers: 1}, gatedSend(release))
+	r := newResults()
+	q.enqueue(job(r, "a"))
+	require.Eventually(t, func() bool { return len(q.jobs) == 0 }, time.Second, time.Millisecond)
+	q.enqueue(job(r, "b"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	q.enqueue(asyncJob{ctx: ctx, msg: &Message{}, callback: r.callback("c")})
+	close(release)
+	q.close()
+	r.wg.Wait()
+	assert.NoError(t, r.errs["b"])
+	assert.ErrorIs(t, r.errs["c"], context.DeadlineExceeded)
+}
+
+func TestAsyncQueue_FlushTimeout(t *testing.T) {
+	release := make(chan struct{})
+	q := newAsyncQueue(AsyncQueueConfig{Size: 2, Workers: 1, FlushTimeout: 10 * time.Millisecond}, gatedSend(release))
+	r := newResults()
+	q.enqueue(job(r, "a"))
+	require.Eventually(t, func() bool { return len(q.jobs) == 0 }, time.Second, time.Millisecond)
+	q.enqueue(job(r, "b"))
+	q.enqueue(job(r, "c"))
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	assert.Equal(t, 2, q.close())
+	r.wg.Wait()
+	assert.NoError(t, r.errs["a"])
+	assert.ErrorIs(t, r.errs["b"], ErrProducerClosed)
+	assert.ErrorIs(t, r.errs["c"], ErrProducerClosed)
+}
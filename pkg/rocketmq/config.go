@@ -1,7 +1,6 @@
 package rocketmq
 
 import (
-	"strings"
 	"sync"
 	"time"
 
@@ -9,6 +8,7 @@ import (
 	"github.com/apache/rocketmq-clients/golang/v5/credentials"
 
 	"github.com/go-kratos/kratos-layout/internal/conf"
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
 )
 
 var sslOnce sync.Once
@@ -30,10 +30,36 @@ type Config struct {
 	SendTimeout   time.Duration                   // Message send timeout
 	MaxAttempts   int32                           // Max retry attempts for producer
 	EnableSSL     bool                            // Whether to enable SSL
+	// Endpoints are all the gRPC proxy endpoints, Endpoint being the first.
+	// The SDK fails over between IP:port endpoints itself; with host names,
+	// clients connect to the first healthy one, and only Producer moves to
+	// the next after repeated send failures: consumers and
+	// TransactionProducer keep their endpoint.
+	Endpoints []string
+	// MaxDeliveryAttempts dead-letters the messages of the event bus failing
+	// that many deliveries to DeadLetterTopic; see DeadLetter. 0 leaves them
+	// to the broker's retry policy.
+	MaxDeliveryAttempts int32
+	DeadLetterTopic     string // Defaults to DeadLetterTopic(ConsumerGroup)
+	// Workers scales the handlers of the event bus instead of a fixed
+	// consumption thread count when set.
+	Workers *concurrency.AdaptiveConfig
+	// Retry delays the redelivery of the messages consumers fail when set;
+	// see RetryPolicy.
+	Retry *RetryPolicy
+	// AsyncQueue bounds the messages of SendAsync in a queue when set.
+	AsyncQueue *AsyncQueueConfig
+	// RateLimit bounds the messages push consumers handle per second when
+	// set; see RateLimitConfig.
+	RateLimit *RateLimitConfig
+	// Topics tunes the push consumers per topic; see
+	// PushConsumerConfig.Topics.
+	Topics map[string]TopicSettings
 }
 
 // NewConfigFromProto creates a Config from proto configuration.
-// For v5 SDK, name_servers is treated as the gRPC endpoint.
+// For v5 SDK, name_servers are the gRPC proxy endpoints, separated by ";"
+// or ",".
 func NewConfigFromProto(c *conf.RocketMQ) *Config {
 	cfg := &Config{
 		ConsumerGroup: c.ProducerGroup,
@@ -46,12 +72,9 @@ func NewConfigFromProto(c *conf.RocketMQ) *Config {
 		},
 	}
 
-	// Use name_servers as endpoint for v5 SDK
-	// v5 uses gRPC protocol with a single endpoint
-	servers := strings.ReplaceAll(c.NameServers, ";", ",")
-	parts := strings.Split(servers, ",")
-	if len(parts) > 0 {
-		cfg.Endpoint = strings.TrimSpace(parts[0])
+	cfg.Endpoints = parseEndpoints(c.NameServers)
+	if len(cfg.Endpoints) > 0 {
+		cfg.Endpoint = cfg.Endpoints[0]
 	}
 
 	if c.SendTimeout != nil {
@@ -60,6 +83,52 @@ func NewConfigFromProto(c *conf.RocketMQ) *Config {
 	if c.RetryTimes > 0 {
 		cfg.MaxAttempts = c.RetryTimes
 	}
+	cfg.MaxDeliveryAttempts = c.MaxDeliveryAttempts
+	cfg.DeadLetterTopic = c.DeadLetterTopic
+	if c.MaxWorkers > 0 {
+		cfg.Workers = &concurrency.AdaptiveConfig{
+			Min:           int(c.MinWorkers),
+			Max:           int(c.MaxWorkers),
+			TargetLatency: c.TargetLatency.AsDuration(),
+		}
+	}
+
+	if r := c.Retry; r != nil {
+		cfg.Retry = &RetryPolicy{
+			MaxAttempts:    r.MaxAttempts,
+			InitialBackoff: r.InitialBackoff.AsDuration(),
+			MaxBackoff:     r.MaxBackoff.AsDuration(),
+			Multiplier:     r.Multiplier,
+			Jitter:         r.Jitter,
+		}
+	}
+
+	if q := c.AsyncQueue; q != nil {
+		cfg.AsyncQueue = &AsyncQueueConfig{
+			Size:         int(q.Size),
+			Workers:      int(q.Workers),
+			Overflow:     Overflow(q.Overflow),
+			FlushTimeout: q.FlushTimeout.AsDuration(),
+		}
+	}
+
+	if l := c.RateLimit; l != nil {
+		cfg.RateLimit = &RateLimitConfig{
+			PerSecond: l.PerSecond,
+			Burst:     int(l.Burst),
+		}
+	}
+
+	if len(c.Topics) > 0 {
+		cfg.Topics = make(map[string]TopicSettings, len(c.Topics))
+		for topic, t := range c.Topics {
+			cfg.Topics[topic] = TopicSettings{
+				ConsumptionThreadCount:     t.ConsumptionThreads,
+				MaxCacheMessageCount:       t.MaxCacheMessages,
+				MaxCacheMessageSizeInBytes: t.MaxCacheBytes,
+			}
+		}
+	}
 
 	return cfg
 }
@@ -67,7 +136,7 @@ func NewConfigFromProto(c *conf.RocketMQ) *Config {
 // ToRMQConfig converts Config to RocketMQ v5 SDK Config.
 func (c *Config) ToRMQConfig() *rmq.Config {
 	return &rmq.Config{
-		Endpoint:      c.Endpoint,
+		Endpoint:      c.target(),
 		NameSpace:     c.NameSpace,
 		ConsumerGroup: c.ConsumerGroup,
 		Credentials:   c.Credentials,
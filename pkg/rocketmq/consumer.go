@@ -3,10 +3,13 @@ package rocketmq
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	rmq "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
 )
 
 // ConsumerResult represents the result of message consumption.
@@ -46,9 +49,30 @@ type MessageHandler func(msg *MessageView) ConsumerResult
 
 // PushConsumer wraps RocketMQ v5 push consumer for receiving messages.
 type PushConsumer struct {
+	log      *log.Helper
+	cfg      *Config
+	push     *PushConsumerConfig
+	consume  func(*MessageView) ConsumerResult
+	adaptive *concurrency.Adaptive
+	ctx      context.Context // ends on cleanup
+	// connect is the config of the endpoint the clients connect to, picked
+	// once: consumers do not fail over, see Config.Endpoints.
+	connect *Config
+	// threads is the consumption thread count of the shared client.
+	threads int32
+	// create creates an SDK client; newClient but in tests.
+	create func(settings TopicSettings, subscriptions map[string]*FilterExpression) (rmq.PushConsumer, error)
+
+	mu sync.Mutex // guards the clients and started
+	// client consumes the topics not in PushConsumerConfig.Topics; nil
+	// until one is subscribed.
 	client rmq.PushConsumer
-	log    *log.Helper
-	cfg    *Config
+	// topics are the clients of the topics of PushConsumerConfig.Topics.
+	topics map[string]rmq.PushConsumer
+	// running are the clients started and not stopped yet. SDK clients
+	// hold nothing until started, and cannot be stopped before.
+	running map[rmq.PushConsumer]struct{}
+	started bool
 }
 
 // PushConsumerConfig holds configuration for push consumer.
@@ -58,6 +82,63 @@ type PushConsumerConfig struct {
 	MaxCacheMessageCount       int32
 	MaxCacheMessageSizeInBytes int64
 	ConsumptionThreadCount     int32
+	// Adaptive, when set, scales the handlers running at once within its
+	// bounds instead of running ConsumptionThreadCount of them; the thread
+	// count is raised to its max. It is adjusted while the consumer runs.
+	Adaptive *concurrency.Adaptive
+	// Retry, when set, delays the redelivery of failed messages with its
+	// backoff instead of the broker's retry policy.
+	Retry *RetryPolicy
+	// RateLimit, when set, bounds the messages handled per second, ahead of
+	// Adaptive and the other middlewares.
+	RateLimit *RateLimitConfig
+	// Topics overrides the consumption threads and cache limits above per
+	// topic, e.g. fewer threads for a heavy report topic than for a light
+	// notification one. The SDK applies them to a whole client, so each
+	// topic listed is consumed by a client of its own, in a group of its
+	// own: the clients of a group must all subscribe to the same topics.
+	// Adaptive, Retry and RateLimit are shared by all the topics, and
+	// Adaptive does not raise the threads of a listed topic.
+	Topics map[string]TopicSettings
+}
+
+// TopicSettings tunes the consumption of a topic of a PushConsumerConfig;
+// zero fields keep the values of the config.
+type TopicSettings struct {
+	// ConsumerGroup consumes the topic, TopicGroup(ConsumerGroup, topic) by
+	// default. It must not be shared with any other subscription.
+	ConsumerGroup              string
+	ConsumptionThreadCount     int32
+	MaxCacheMessageCount       int32
+	MaxCacheMessageSizeInBytes int64
+}
+
+// TopicGroup returns the consumer group of a topic listed in
+// PushConsumerConfig.Topics, e.g. "orders-reports" for group "orders".
+func TopicGroup(group, topic string) string {
+	return group + "-" + topic
+}
+
+// settings returns the settings of topic, filled in from c; those of the
+// shared client for a topic not in c.Topics.
+func (c *PushConsumerConfig) settings(topic string) TopicSettings {
+	s, listed := c.Topics[topic]
+	if s.ConsumerGroup == "" {
+		s.ConsumerGroup = c.ConsumerGroup
+		if listed {
+			s.ConsumerGroup = TopicGroup(c.ConsumerGroup, topic)
+		}
+	}
+	if s.ConsumptionThreadCount <= 0 {
+		s.ConsumptionThreadCount = c.ConsumptionThreadCount
+	}
+	if s.MaxCacheMessageCount <= 0 {
+		s.MaxCacheMessageCount = c.MaxCacheMessageCount
+	}
+	if s.MaxCacheMessageSizeInBytes <= 0 {
+		s.MaxCacheMessageSizeInBytes = c.MaxCacheMessageSizeInBytes
+	}
+	return s
 }
 
 // NewPushConsumerConfigFromConfig creates a PushConsumerConfig from base Config.
@@ -68,17 +149,24 @@ func NewPushConsumerConfigFromConfig(cfg *Config) *PushConsumerConfig {
 		MaxCacheMessageCount:       1024,
 		MaxCacheMessageSizeInBytes: 64 * 1024 * 1024,
 		ConsumptionThreadCount:     20,
+		Retry:                      cfg.Retry,
+		RateLimit:                  cfg.RateLimit,
+		Topics:                     cfg.Topics,
 	}
 }
 
 // NewPushConsumer creates a new RocketMQ v5 push consumer.
 // subscriptions maps topic to filter expression.
-// handler is called for each received message.
+// handler is called for each received message, through Logging,
+// middlewares and Recovery, then cfg.Retry on failure, after waiting for
+// cfg.RateLimit. The topics of cfg.Topics are consumed by clients of their
+// own, each in its own group, see TopicSettings.ConsumerGroup.
 func NewPushConsumer(
 	cfg *PushConsumerConfig,
 	subscriptions map[string]*FilterExpression,
 	handler MessageHandler,
 	logger log.Logger,
+	middlewares ...ConsumerMiddleware,
 ) (*PushConsumer, func(), error) {
 	logHelper := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
 
@@ -91,60 +179,235 @@ func NewPushConsumer(
 
 	configureSSL(cfg.EnableSSL)
 
-	opts := []rmq.PushConsumerOption{
-		rmq.WithPushAwaitDuration(cfg.AwaitDuration),
-		rmq.WithPushSubscriptionExpressions(subscriptions),
-		rmq.WithPushMessageListener(&rmq.FuncMessageListener{
-			Consume: handler,
-		}),
-		rmq.WithPushConsumptionThreadCount(cfg.ConsumptionThreadCount),
-		rmq.WithPushMaxCacheMessageCount(cfg.MaxCacheMessageCount),
-		rmq.WithPushMaxCacheMessageSizeInBytes(cfg.MaxCacheMessageSizeInBytes),
+	ctx, stop := context.WithCancel(context.Background())
+	pc := &PushConsumer{
+		log:      logHelper,
+		cfg:      cfg.Config,
+		push:     cfg,
+		adaptive: cfg.Adaptive,
+		ctx:      ctx,
+		threads:  cfg.ConsumptionThreadCount,
+		topics:   make(map[string]rmq.PushConsumer),
+		running:  make(map[rmq.PushConsumer]struct{}),
 	}
+	pc.create = pc.newClient
+	consume := wrapHandler(handler, logger, middlewares...)
+	if cfg.Retry != nil {
+		// The clients are set below, before any message is delivered.
+		change := func(msg *MessageView, d time.Duration) error {
+			client := pc.clientOf(msg.GetTopic())
+			if client == nil {
+				return fmt.Errorf("no consumer of topic %s", msg.GetTopic())
+			}
+			return client.ChangeInvisibleDuration(msg, d)
+		}
+		consume = retry(*cfg.Retry, pushDelay(change), logger)(consume)
+	}
+	if cfg.Adaptive != nil {
+		consume = Concurrency(cfg.Adaptive)(consume)
+		pc.threads = max(pc.threads, int32(cfg.Adaptive.Max()))
+	}
+	if cfg.RateLimit != nil {
+		consume = RateLimit(ctx, *cfg.RateLimit)(consume)
+	}
+	pc.consume = consume
 
-	c, err := rmq.NewPushConsumer(cfg.ToRMQConfig(), opts...)
+	connect, _, err := cfg.connect()
 	if err != nil {
-		return nil, nil, fmt.Errorf("create rocketmq push consumer: %w", err)
+		logHelper.Warnf("%v, connecting to %s", err, connect.target())
+	}
+	pc.connect = connect
+	if err := pc.createClients(subscriptions); err != nil {
+		stop()
+		return nil, nil, err
 	}
 
-	logHelper.Infof("rocketmq push consumer created, endpoint=%s, group=%s",
-		cfg.Endpoint, cfg.ConsumerGroup)
+	logHelper.Infof("rocketmq push consumer created, endpoint=%s, group=%s, dedicated topics=%d",
+		connect.target(), cfg.ConsumerGroup, len(pc.topics))
 
 	cleanup := func() {
 		logHelper.Info("shutting down rocketmq push consumer")
-		if err := c.GracefulStop(); err != nil {
-			logHelper.Errorf("shutdown rocketmq push consumer: %v", err)
+		stop()
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		pc.stopClients()
+	}
+
+	return pc, cleanup, nil
+}
+
+// createClients creates the shared client of the subscriptions not in
+// PushConsumerConfig.Topics, if any, and a client of each one in it. The
+// clients created are dropped when one fails.
+func (c *PushConsumer) createClients(subscriptions map[string]*FilterExpression) error {
+	shared := make(map[string]*FilterExpression, len(subscriptions))
+	own := make(map[string]*FilterExpression, len(subscriptions))
+	for topic, filter := range subscriptions {
+		if _, ok := c.push.Topics[topic]; ok {
+			own[topic] = filter
+		} else {
+			shared[topic] = filter
+		}
+	}
+	if len(shared) > 0 {
+		client, err := c.create(c.sharedSettings(), shared)
+		if err != nil {
+			return err
 		}
+		c.client = client
 	}
+	for topic, filter := range own {
+		client, err := c.create(c.push.settings(topic), map[string]*FilterExpression{topic: filter})
+		if err != nil {
+			c.client, c.topics = nil, make(map[string]rmq.PushConsumer)
+			return err
+		}
+		c.topics[topic] = client
+	}
+	return nil
+}
 
-	return &PushConsumer{
-		client: c,
-		log:    logHelper,
-		cfg:    cfg.Config,
-	}, cleanup, nil
+// sharedSettings returns the settings of the shared client.
+func (c *PushConsumer) sharedSettings() TopicSettings {
+	s := c.push.settings("")
+	s.ConsumptionThreadCount = c.threads
+	return s
 }
 
-// Start starts the push consumer.
+// startClient starts client and records it running. A client failing to
+// start stops itself.
+func (c *PushConsumer) startClient(client rmq.PushConsumer) error {
+	if err := client.Start(); err != nil {
+		return err
+	}
+	c.running[client] = struct{}{}
+	return nil
+}
+
+// stopClient stops client if it is running.
+func (c *PushConsumer) stopClient(client rmq.PushConsumer) error {
+	if _, ok := c.running[client]; !ok {
+		return nil
+	}
+	delete(c.running, client)
+	return client.GracefulStop()
+}
+
+// stopClients stops the running clients.
+func (c *PushConsumer) stopClients() {
+	for client := range c.running {
+		if err := c.stopClient(client); err != nil {
+			c.log.Errorf("shutdown rocketmq push consumer: %v", err)
+		}
+	}
+	c.started = false
+}
+
+// newClient creates an SDK consumer of subscriptions with settings.
+func (c *PushConsumer) newClient(settings TopicSettings, subscriptions map[string]*FilterExpression) (rmq.PushConsumer, error) {
+	opts := []rmq.PushConsumerOption{
+		rmq.WithPushAwaitDuration(c.push.AwaitDuration),
+		rmq.WithPushSubscriptionExpressions(subscriptions),
+		rmq.WithPushMessageListener(&rmq.FuncMessageListener{
+			Consume: c.consume,
+		}),
+		rmq.WithPushConsumptionThreadCount(settings.ConsumptionThreadCount),
+		rmq.WithPushMaxCacheMessageCount(settings.MaxCacheMessageCount),
+		rmq.WithPushMaxCacheMessageSizeInBytes(settings.MaxCacheMessageSizeInBytes),
+	}
+	rc := c.connect.ToRMQConfig()
+	rc.ConsumerGroup = settings.ConsumerGroup
+	client, err := rmq.NewPushConsumer(rc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create rocketmq push consumer: %w", err)
+	}
+	return client, nil
+}
+
+// clientOf returns the client consuming topic, nil when none.
+func (c *PushConsumer) clientOf(topic string) rmq.PushConsumer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.topics[topic]; ok {
+		return client
+	}
+	return c.client
+}
+
+// Start starts the push consumer. The clients started are stopped when one
+// fails.
 func (c *PushConsumer) Start() error {
-	if err := c.client.Start(); err != nil {
-		return fmt.Errorf("start rocketmq push consumer: %w", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		if err := c.startClient(c.client); err != nil {
+			return fmt.Errorf("start rocketmq push consumer: %w", err)
+		}
+	}
+	for topic, client := range c.topics {
+		if err := c.startClient(client); err != nil {
+			c.stopClients()
+			return fmt.Errorf("start rocketmq push consumer of %s: %w", topic, err)
+		}
+	}
+	c.started = true
+	if c.adaptive != nil {
+		go c.adaptive.Run(c.ctx)
 	}
 	c.log.Info("rocketmq push consumer started")
 	return nil
 }
 
 // Subscribe subscribes to a topic with filter expression.
-// Can be called after Start to dynamically add subscriptions.
+// Can be called after Start to dynamically add subscriptions; a topic of
+// PushConsumerConfig.Topics then gets its client started, as do the other
+// topics when none was subscribed before.
 func (c *PushConsumer) Subscribe(topic string, filter *FilterExpression) error {
-	if err := c.client.Subscribe(topic, filter); err != nil {
-		return fmt.Errorf("subscribe to %s: %w", topic, err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, own := c.push.Topics[topic]
+	client, settings := c.client, c.sharedSettings()
+	if own {
+		client, settings = c.topics[topic], c.push.settings(topic)
 	}
-	c.log.Infof("subscribed to topic: %s", topic)
+	if client != nil {
+		if err := client.Subscribe(topic, filter); err != nil {
+			return fmt.Errorf("subscribe to %s: %w", topic, err)
+		}
+		c.log.Infof("subscribed to topic: %s", topic)
+		return nil
+	}
+
+	client, err := c.create(settings, map[string]*FilterExpression{topic: filter})
+	if err != nil {
+		return err
+	}
+	if c.started {
+		if err := c.startClient(client); err != nil {
+			return fmt.Errorf("subscribe to %s: %w", topic, err)
+		}
+	}
+	if own {
+		c.topics[topic] = client
+	} else {
+		c.client = client
+	}
+	c.log.Infof("subscribed to topic: %s, with a new consumer", topic)
 	return nil
 }
 
-// Unsubscribe unsubscribes from a topic.
+// Unsubscribe unsubscribes from a topic. The client of a topic of
+// PushConsumerConfig.Topics is stopped.
 func (c *PushConsumer) Unsubscribe(topic string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.topics[topic]; ok {
+		delete(c.topics, topic)
+		return c.stopClient(client)
+	}
+	if c.client == nil {
+		return nil
+	}
 	return c.client.Unsubscribe(topic)
 }
 
@@ -187,13 +450,17 @@ func NewSimpleConsumer(
 		opts = append(opts, rmq.WithSimpleSubscriptionExpressions(subscriptions))
 	}
 
-	c, err := rmq.NewSimpleConsumer(cfg.ToRMQConfig(), opts...)
+	connect, _, err := cfg.connect()
+	if err != nil {
+		logHelper.Warnf("%v, connecting to %s", err, connect.target())
+	}
+	c, err := rmq.NewSimpleConsumer(connect.ToRMQConfig(), opts...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("create rocketmq simple consumer: %w", err)
 	}
 
 	logHelper.Infof("rocketmq simple consumer created, endpoint=%s, group=%s",
-		cfg.Endpoint, cfg.ConsumerGroup)
+		connect.target(), cfg.ConsumerGroup)
 
 	cleanup := func() {
 		logHelper.Info("shutting down rocketmq simple consumer")
@@ -0,0 +1,174 @@
+package rocketmq
+
+import (
+	"errors"
+	"testing"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+func TestPushConsumerConfig_Settings(t *testing.T) {
+	cfg := NewConfigFromProto(&conf.RocketMQ{ProducerGroup: "g", Topics: map[string]*conf.RocketMQ_Topic{
+		"reports":       {ConsumptionThreads: 2, MaxCacheMessages: 16, MaxCacheBytes: 1 << 20},
+		"notifications": {ConsumptionThreads: 64},
+	}})
+	push := NewPushConsumerConfigFromConfig(cfg)
+
+	assert.Equal(t, TopicSettings{
+		ConsumerGroup:          "g-reports",
+		ConsumptionThreadCount: 2, MaxCacheMessageCount: 16, MaxCacheMessageSizeInBytes: 1 << 20,
+	}, push.settings("reports"))
+	assert.Equal(t, TopicSettings{
+		ConsumerGroup:          "g-notifications",
+		ConsumptionThreadCount: 64, MaxCacheMessageCount: 1024, MaxCacheMessageSizeInBytes: 64 * 1024 * 1024,
+	}, push.settings("notifications"))
+	assert.Equal(t, TopicSettings{
+		ConsumerGroup:          "g",
+		ConsumptionThreadCount: 20, MaxCacheMessageCount: 1024, MaxCacheMessageSizeInBytes: 64 * 1024 * 1024,
+	}, push.settings("orders"))
+	assert.Equal(t, "g", push.settings("").ConsumerGroup)
+
+	push.Topics = map[string]TopicSettings{"reports": {ConsumerGroup: "reporting"}}
+	assert.Equal(t, "reporting", push.settings("reports").ConsumerGroup)
+}
+
+// fakePushConsumer records the calls of a PushConsumer.
+type fakePushConsumer struct {
+	rmq.PushConsumer
+	settings      TopicSettings
+	subscriptions map[string]*FilterExpression
+	startErr      error
+	started       int
+	stopped       int
+}
+
+func (f *fakePushConsumer) Start() error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	f.started++
+	return nil
+}
+
+func (f *fakePushConsumer) GracefulStop() error {
+	f.stopped++
+	return nil
+}
+
+func (f *fakePushConsumer) Subscribe(topic string, filter *FilterExpression) error {
+	f.subscriptions[topic] = filter
+	return nil
+}
+
+// newFakePushConsumer returns a PushConsumer of topics creating fake
+// clients, keyed by the first topic they subscribe.
+func newFakePushConsumer(topics map[string]TopicSettings) (*PushConsumer, map[string]*fakePushConsumer) {
+	cfg := NewPushConsumerConfigFromConfig(&Config{ConsumerGroup: "g", Topics: topics})
+	fakes := make(map[string]*fakePushConsumer)
+	c := &PushConsumer{
+		log:     log.NewHelper(log.DefaultLogger),
+		push:    cfg,
+		threads: cfg.ConsumptionThreadCount,
+		topics:  make(map[string]rmq.PushConsumer),
+		running: make(map[rmq.PushConsumer]struct{}),
+	}
+	c.create = func(settings TopicSettings, subscriptions map[string]*FilterExpression) (rmq.PushConsumer, error) {
+		f := &fakePushConsumer{settings: settings, subscriptions: make(map[string]*FilterExpression)}
+		for topic, filter := range subscriptions {
+			f.subscriptions[topic] = filter
+			fakes[topic] = f
+		}
+		return f, nil
+	}
+	return c, fakes
+}
+
+func TestPushConsumer_TopicClients(t *testing.T) {
+	c, fakes := newFakePushConsumer(map[string]TopicSettings{
+		"reports":       {ConsumptionThreadCount: 2},
+		"notifications": {ConsumptionThreadCount: 64},
+	})
+	require.NoError(t, c.createClients(map[string]*FilterExpression{
+		"orders": SubAll, "payments": SubAll, "reports": SubAll, "notifications": SubAll,
+	}))
+	require.Len(t, c.topics, 2)
+	shared := fakes["orders"]
+	assert.Same(t, shared, fakes["payments"], "unlisted topics share a client")
+	assert.Len(t, shared.subscriptions, 2)
+	assert.Equal(t, int32(20), shared.settings.ConsumptionThreadCount)
+	assert.Equal(t, int32(2), fakes["reports"].settings.ConsumptionThreadCount)
+	assert.Equal(t, int32(64), fakes["notifications"].settings.ConsumptionThreadCount)
+	assert.Equal(t, int32(1024), fakes["reports"].settings.MaxCacheMessageCount)
+	// The clients of a group subscribe to the same topics.
+	assert.Equal(t, "g", shared.settings.ConsumerGroup)
+	assert.Equal(t, "g-reports", fakes["reports"].settings.ConsumerGroup)
+	assert.Equal(t, "g-notifications", fakes["notifications"].settings.ConsumerGroup)
+
+	require.NoError(t, c.Start())
+	for topic, f := range fakes {
+		assert.Equal(t, 1, f.started, topic)
+	}
+	require.NoError(t, c.Subscribe("refunds", SubAll))
+	assert.Contains(t, shared.subscriptions, "refunds")
+	assert.Same(t, shared, c.clientOf("refunds"))
+	assert.Same(t, fakes["reports"], c.clientOf("reports"))
+
+	require.NoError(t, c.Unsubscribe("reports"))
+	assert.Equal(t, 1, fakes["reports"].stopped)
+	c.stopClients()
+	for topic, f := range fakes {
+		assert.Equal(t, 1, f.stopped, topic)
+	}
+}
+
+func TestPushConsumer_AllTopicsListed(t *testing.T) {
+	c, fakes := newFakePushConsumer(map[string]TopicSettings{"reports": {ConsumptionThreadCount: 2}})
+	require.NoError(t, c.createClients(map[string]*FilterExpression{"reports": SubAll}))
+	assert.Nil(t, c.client, "no shared client without its topics")
+	assert.Nil(t, c.clientOf("orders"))
+	require.NoError(t, c.Unsubscribe("orders"))
+
+	require.NoError(t, c.Start())
+	require.NoError(t, c.Subscribe("orders", SubAll))
+	require.Contains(t, fakes, "orders")
+	assert.Equal(t, 1, fakes["orders"].started, "subscribing after Start starts the new client")
+	assert.Equal(t, int32(20), fakes["orders"].settings.ConsumptionThreadCount)
+	assert.Equal(t, "g", fakes["orders"].settings.ConsumerGroup)
+
+	c.stopClients()
+	assert.Equal(t, 1, fakes["orders"].stopped)
+	assert.Equal(t, 1, fakes["reports"].stopped)
+}
+
+func TestPushConsumer_CreateError(t *testing.T) {
+	c, fakes := newFakePushConsumer(map[string]TopicSettings{"reports": {}})
+	create := c.create
+	createErr := errors.New("invalid endpoint")
+	c.create = func(settings TopicSettings, subscriptions map[string]*FilterExpression) (rmq.PushConsumer, error) {
+		if _, ok := subscriptions["reports"]; ok {
+			return nil, createErr
+		}
+		return create(settings, subscriptions)
+	}
+	assert.ErrorIs(t, c.createClients(map[string]*FilterExpression{"orders": SubAll, "reports": SubAll}), createErr)
+	assert.Nil(t, c.client)
+	assert.Empty(t, c.topics)
+	assert.Zero(t, fakes["orders"].started)
+}
+
+func TestPushConsumer_StartError(t *testing.T) {
+	c, fakes := newFakePushConsumer(map[string]TopicSettings{"reports": {}})
+	require.NoError(t, c.createClients(map[string]*FilterExpression{"orders": SubAll, "reports": SubAll}))
+	startErr := errors.New("unreachable")
+	fakes["reports"].startErr = startErr
+
+	assert.ErrorIs(t, c.Start(), startErr)
+	assert.Equal(t, 1, fakes["orders"].stopped, "the clients started are stopped")
+	assert.Zero(t, fakes["reports"].stopped, "a client failing to start stops itself")
+	assert.Empty(t, c.running)
+}
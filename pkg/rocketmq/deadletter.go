@@ -0,0 +1,77 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// deadLetterTimeout bounds a DeadLetterHandler call.
+const deadLetterTimeout = 5 * time.Second
+
+// deliveryAttempt returns the delivery attempt of msg, starting at 1.
+// Tests replace it, as a MessageView cannot be built with attempts.
+var deliveryAttempt = (*MessageView).GetDeliveryAttempt
+
+// DeadLetterHandler takes over a message that failed its last delivery
+// attempt, e.g. to park it for inspection. A nil error acknowledges the
+// message; an error leaves it to the broker's redelivery.
+type DeadLetterHandler func(ctx context.Context, msg *MessageView) error
+
+// DeadLetterTopic returns the dead-letter topic of a consumer group,
+// following the broker's %DLQ%<group> naming.
+func DeadLetterTopic(consumerGroup string) string {
+	return "%DLQ%" + consumerGroup
+}
+
+// DeadLetter hands a message that fails its maxAttempts-th delivery to dlq
+// instead of letting it be redelivered again, and logs it at error level so
+// poisoned messages never retry silently. Use RepublishDeadLetter as dlq to
+// move them to a dead-letter topic.
+func DeadLetter(maxAttempts int32, dlq DeadLetterHandler, logger log.Logger) ConsumerMiddleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			result := h(msg)
+			attempt := deliveryAttempt(msg)
+			if result == ConsumeSuccess || maxAttempts <= 0 || attempt < maxAttempts {
+				return result
+			}
+			ctx, cancel := context.WithTimeout(MessageContext(msg), deadLetterTimeout)
+			defer cancel()
+			if err := dlq(ctx, msg); err != nil {
+				l.Errorw("msg", "dead-letter message failed, left for redelivery", "topic", msg.GetTopic(),
+					"message_id", msg.GetMessageId(), "attempt", attempt, "error", err)
+				return ConsumeFailure
+			}
+			l.Errorw("msg", "message dead-lettered", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(),
+				"keys", msg.GetKeys(), "attempt", attempt)
+			return ConsumeSuccess
+		}
+	}
+}
+
+// RepublishDeadLetter returns a DeadLetterHandler sending dead messages to
+// topic with p, with their body, tag and keys plus the original message ID
+// as a key to look them up. An empty topic is the DeadLetterTopic of p's
+// consumer group.
+func RepublishDeadLetter(p *Producer, topic string) DeadLetterHandler {
+	if topic == "" {
+		topic = DeadLetterTopic(p.cfg.ConsumerGroup)
+	}
+	return func(ctx context.Context, msg *MessageView) error {
+		dead := &Message{Topic: topic, Body: msg.GetBody(), Keys: append(slices.Clone(msg.GetKeys()), msg.GetMessageId()),
+			Properties: maps.Clone(messageProperties(msg))}
+		if tag := msg.GetTag(); tag != nil {
+			dead.Tag = *tag
+		}
+		if _, err := p.SendMessage(ctx, dead); err != nil {
+			return fmt.Errorf("republish %s to %s: %w", msg.GetMessageId(), topic, err)
+		}
+		return nil
+	}
+}
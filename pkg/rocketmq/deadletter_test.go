@@ -0,0 +1,44 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetter(t *testing.T) {
+	var attempt int32
+	deliveryAttempt = func(*MessageView) int32 { return attempt }
+	t.Cleanup(func() { deliveryAttempt = (*MessageView).GetDeliveryAttempt })
+
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger), cfg: &Config{ConsumerGroup: "billing"}}
+	failing := func(*MessageView) ConsumerResult { return ConsumeFailure }
+	h := DeadLetter(3, RepublishDeadLetter(p, ""), log.DefaultLogger)(failing)
+
+	msg := &MessageView{}
+	msg.SetTag("created")
+	msg.SetKeys("o-1")
+	attempt = 2
+	assert.Equal(t, ConsumeFailure, h(msg), "retried before the last attempt")
+	assert.Empty(t, f.sent)
+
+	attempt = 3
+	assert.Equal(t, ConsumeSuccess, h(msg), "acknowledged once dead-lettered")
+	require.Len(t, f.sent, 1)
+	assert.Equal(t, "%DLQ%billing", f.sent[0].Topic)
+	assert.Equal(t, "created", *f.sent[0].GetTag())
+	assert.Equal(t, []string{"o-1", ""}, f.sent[0].GetKeys())
+	assert.Equal(t, []string{"o-1"}, msg.GetKeys())
+
+	h = DeadLetter(3, func(context.Context, *MessageView) error { return errors.New("broker down") }, log.DefaultLogger)(failing)
+	assert.Equal(t, ConsumeFailure, h(msg), "redelivered when the dead letter fails")
+
+	h = DeadLetter(0, RepublishDeadLetter(p, "orders-dlq"), log.DefaultLogger)(failing)
+	assert.Equal(t, ConsumeFailure, h(msg), "disabled without max attempts")
+	assert.Len(t, f.sent, 1)
+}
@@ -0,0 +1,219 @@
+package rocketmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+const (
+	// dedupTimeout bounds each DedupStore call.
+	dedupTimeout       = 2 * time.Second
+	defaultDedupLease  = time.Minute
+	dedupProcessedMark = "done"
+	// dedupClaimAttempts bounds the SetNX retries of a claim whose key
+	// keeps being released or expiring in between.
+	dedupClaimAttempts = 3
+)
+
+// DedupState is the state of a message key in a DedupStore.
+type DedupState int
+
+const (
+	// DedupClaimed means the key was claimed for processing by the caller.
+	DedupClaimed DedupState = iota
+	// DedupInFlight means another delivery is processing the key.
+	DedupInFlight
+	// DedupProcessed means the key was processed.
+	DedupProcessed
+)
+
+// DedupStore records the messages processed by a consumer group, see Dedup.
+type DedupStore interface {
+	// Claim reserves key for processing for lease, unless it is already
+	// claimed or processed, as reported by the state. A DedupClaimed key
+	// comes with the token identifying the claim.
+	Claim(ctx context.Context, key string, lease time.Duration) (DedupState, string, error)
+	// Done records key as processed for ttl, unless another delivery
+	// claimed it since the lease of token expired.
+	Done(ctx context.Context, key, token string, ttl time.Duration) error
+	// Release drops the claim token of key, whose processing failed. It is
+	// a no-op once the claim expired or another delivery holds the key.
+	Release(ctx context.Context, key, token string) error
+}
+
+// DedupOption configures Dedup.
+type DedupOption func(*dedupOptions)
+
+type dedupOptions struct {
+	key   func(msg *MessageView) string
+	lease time.Duration
+}
+
+// WithDedupKey identifies messages by key instead of their message ID, e.g.
+// BusinessKey when a producer may send the same business event twice. An
+// empty key disables deduplication of the message.
+func WithDedupKey(key func(msg *MessageView) string) DedupOption {
+	return func(o *dedupOptions) { o.key = key }
+}
+
+// WithDedupLease sets how long a delivery holds the claim of its key while
+// the handler runs, 1m by default. Once it expires, e.g. after a crash, a
+// redelivery claims the key again, so it must exceed the handling time.
+func WithDedupLease(d time.Duration) DedupOption {
+	return func(o *dedupOptions) { o.lease = d }
+}
+
+// BusinessKey returns the topic and first key of msg, or "" when it has
+// no keys.
+func BusinessKey(msg *MessageView) string {
+	keys := msg.GetKeys()
+	if len(keys) == 0 || keys[0] == "" {
+		return ""
+	}
+	return msg.GetTopic() + ":" + keys[0]
+}
+
+// Dedup skips the messages h already processed, as RocketMQ delivers at
+// least once: redeliveries after a lost acknowledgement, or the same message
+// resent by a producer retry. Processed messages are recorded in store for
+// ttl, so duplicates arriving later are handled again.
+//
+// A delivery claims its message before running h. A duplicate arriving while
+// it runs fails and is redelivered later, and a message h fails is released
+// for its redelivery. A failing store fails the message too, rather than
+// risking a second processing.
+func Dedup(h MessageHandler, store DedupStore, ttl time.Duration, logs *zapLog.Scoped, opts ...DedupOption) MessageHandler {
+	o := dedupOptions{key: (*MessageView).GetMessageId, lease: defaultDedupLease}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	l := logs.For("pkg/rocketmq/consumer")
+	return func(msg *MessageView) ConsumerResult {
+		key := o.key(msg)
+		if key == "" {
+			return h(msg)
+		}
+		ctx, cancel := context.WithTimeout(MessageContext(msg), dedupTimeout)
+		state, token, err := store.Claim(ctx, key, o.lease)
+		cancel()
+		if err != nil {
+			l.Warnw("msg", "claim message failed, left for redelivery", "topic", msg.GetTopic(),
+				"message_id", msg.GetMessageId(), "key", key, "error", err)
+			return ConsumeFailure
+		}
+		switch state {
+		case DedupProcessed:
+			l.Debugw("msg", "duplicate message skipped", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(), "key", key)
+			return ConsumeSuccess
+		case DedupInFlight:
+			l.Debugw("msg", "message in flight, left for redelivery", "topic", msg.GetTopic(),
+				"message_id", msg.GetMessageId(), "key", key)
+			return ConsumeFailure
+		}
+
+		result := h(msg)
+		ctx, cancel = context.WithTimeout(MessageContext(msg), dedupTimeout)
+		defer cancel()
+		if result != ConsumeSuccess {
+			if err := store.Release(ctx, key, token); err != nil {
+				l.Warnw("msg", "release message failed, redelivery waits for the lease", "topic", msg.GetTopic(),
+					"message_id", msg.GetMessageId(), "key", key, "error", err)
+			}
+			return result
+		}
+		if err := store.Done(ctx, key, token, ttl); err != nil {
+			l.Warnw("msg", "record processed message failed", "topic", msg.GetTopic(),
+				"message_id", msg.GetMessageId(), "key", key, "error", err)
+		}
+		return result
+	}
+}
+
+// RedisDedupStore is a DedupStore shared by the instances of a consumer group.
+type RedisDedupStore struct {
+	client func() redis.Cmdable
+	prefix string
+}
+
+// NewRedisDedupStore creates a store keeping message keys under prefix:<key>.
+// Consumer groups of the same topics process each message once per group,
+// so each needs its own prefix, e.g. "dedup:billing". client is called for
+// every operation so a reconnected client is picked up.
+func NewRedisDedupStore(client func() redis.Cmdable, prefix string) *RedisDedupStore {
+	return &RedisDedupStore{client: client, prefix: prefix}
+}
+
+// releaseScript deletes the key if it still holds the claim token.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// doneScript marks the key processed for ARGV[3] ms, forever for 0, if it
+// still holds the claim token or, its lease expired, nobody claimed it since.
+var doneScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v == false or v == ARGV[1] then
+	if ARGV[3] == '0' then
+		redis.call('SET', KEYS[1], ARGV[2])
+	else
+		redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	end
+	return 1
+end
+return 0
+`)
+
+func newClaimToken() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Claim implements DedupStore. The key holds the claim token while the
+// message is processed, and dedupProcessedMark once it was.
+func (s *RedisDedupStore) Claim(ctx context.Context, key string, lease time.Duration) (DedupState, string, error) {
+	k := s.prefix + ":" + key
+	token := newClaimToken()
+	for range dedupClaimAttempts {
+		ok, err := s.client().SetNX(ctx, k, token, lease).Result()
+		if err != nil {
+			return 0, "", err
+		}
+		if ok {
+			return DedupClaimed, token, nil
+		}
+		v, err := s.client().Get(ctx, k).Result()
+		if errors.Is(err, redis.Nil) {
+			// Released or expired since: claim it again.
+			continue
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		if v == dedupProcessedMark {
+			return DedupProcessed, "", nil
+		}
+		return DedupInFlight, "", nil
+	}
+	return DedupInFlight, "", nil
+}
+
+// Done implements DedupStore.
+func (s *RedisDedupStore) Done(ctx context.Context, key, token string, ttl time.Duration) error {
+	return doneScript.Run(ctx, s.client(), []string{s.prefix + ":" + key}, token, dedupProcessedMark, ttl.Milliseconds()).Err()
+}
+
+// Release implements DedupStore.
+func (s *RedisDedupStore) Release(ctx context.Context, key, token string) error {
+	return releaseScript.Run(ctx, s.client(), []string{s.prefix + ":" + key}, token).Err()
+}
@@ -0,0 +1,129 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+// memoryDedup is a DedupStore of states and claim tokens by key.
+type memoryDedup struct {
+	states map[string]DedupState
+	tokens map[string]string
+	seq    int
+	err    error
+}
+
+func newMemoryDedup() *memoryDedup {
+	return &memoryDedup{states: map[string]DedupState{}, tokens: map[string]string{}}
+}
+
+func (m *memoryDedup) Claim(_ context.Context, key string, _ time.Duration) (DedupState, string, error) {
+	if m.err != nil {
+		return 0, "", m.err
+	}
+	if s, ok := m.states[key]; ok {
+		return s, "", nil
+	}
+	m.seq++
+	m.states[key], m.tokens[key] = DedupInFlight, strconv.Itoa(m.seq)
+	return DedupClaimed, m.tokens[key], nil
+}
+
+// expire drops the claim of key as if its lease expired.
+func (m *memoryDedup) expire(key string) {
+	delete(m.states, key)
+	delete(m.tokens, key)
+}
+
+func (m *memoryDedup) Done(_ context.Context, key, token string, _ time.Duration) error {
+	if t, ok := m.tokens[key]; ok && t != token {
+		return nil
+	}
+	m.states[key] = DedupProcessed
+	delete(m.tokens, key)
+	return nil
+}
+
+func (m *memoryDedup) Release(_ context.Context, key, token string) error {
+	if m.tokens[key] == token {
+		m.expire(key)
+	}
+	return nil
+}
+
+func TestDedup(t *testing.T) {
+	store := newMemoryDedup()
+	calls, result := 0, ConsumeFailure
+	h := Dedup(func(*MessageView) ConsumerResult {
+		calls++
+		return result
+	}, store, time.Hour, zapLog.NewScoped(log.DefaultLogger), WithDedupKey(BusinessKey))
+
+	msg := &MessageView{}
+	msg.SetKeys("o-1")
+	assert.Equal(t, ConsumeFailure, h(msg))
+	assert.Empty(t, store.states, "failed messages are released")
+
+	result = ConsumeSuccess
+	assert.Equal(t, ConsumeSuccess, h(msg))
+	assert.Equal(t, ConsumeSuccess, h(msg), "duplicate acknowledged")
+	assert.Equal(t, 2, calls)
+
+	other := &MessageView{}
+	other.SetKeys("o-2")
+	store.states[BusinessKey(other)] = DedupInFlight
+	assert.Equal(t, ConsumeFailure, h(other), "redelivered while another delivery runs")
+	assert.Equal(t, 2, calls)
+
+	assert.Equal(t, ConsumeSuccess, h(&MessageView{}), "messages without a key are processed")
+	assert.Equal(t, 3, calls)
+
+	store.err = errors.New("redis down")
+	third := &MessageView{}
+	third.SetKeys("o-3")
+	assert.Equal(t, ConsumeFailure, h(third))
+	assert.Equal(t, 3, calls)
+
+	store.err = nil
+
+	// A delivery whose lease expired while it ran does not release, nor
+	// mark, the claim of the redelivery that took the key over.
+	slow := &MessageView{}
+	slow.SetKeys("o-4")
+	key := BusinessKey(slow)
+	var takeover string
+	var release func()
+	h = Dedup(func(*MessageView) ConsumerResult {
+		if release != nil {
+			release()
+			release = nil
+		}
+		return result
+	}, store, time.Hour, zapLog.NewScoped(log.DefaultLogger), WithDedupKey(BusinessKey))
+	release = func() {
+		store.expire(key)
+		_, takeover, _ = store.Claim(context.Background(), key, time.Minute)
+	}
+	result = ConsumeFailure
+	assert.Equal(t, ConsumeFailure, h(slow))
+	assert.Equal(t, DedupInFlight, store.states[key], "the redelivery keeps its claim")
+	assert.Equal(t, takeover, store.tokens[key])
+
+	store.expire(key)
+	result = ConsumeSuccess
+	release = func() {
+		store.expire(key)
+		_, takeover, _ = store.Claim(context.Background(), key, time.Minute)
+	}
+	assert.Equal(t, ConsumeSuccess, h(slow))
+	assert.Equal(t, DedupInFlight, store.states[key], "the redelivery records the key when it is done")
+	assert.Equal(t, takeover, store.tokens[key])
+}
@@ -0,0 +1,186 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+const (
+	// endpointProbeTimeout bounds the health check of an endpoint.
+	endpointProbeTimeout = time.Second
+	// failoverThreshold is the consecutive send failures moving a producer
+	// to another endpoint.
+	failoverThreshold = 3
+	// failoverInterval is the least time between two failovers, so a
+	// broker-wide outage does not restart the producer on every send.
+	failoverInterval = 10 * time.Second
+)
+
+// parseEndpoints splits a list of endpoints separated by ";" or ",".
+func parseEndpoints(s string) []string {
+	var endpoints []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		if e := strings.TrimSpace(part); e != "" {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// ipEndpoints reports whether all endpoints are IP:port. The SDK dials
+// those as one gRPC target, failing over between them natively.
+func ipEndpoints(endpoints []string) bool {
+	for _, e := range endpoints {
+		if _, err := netip.ParseAddrPort(e); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// target returns the endpoint the SDK dials: all the endpoints when they are
+// IP:port, the current one otherwise.
+func (c *Config) target() string {
+	if len(c.Endpoints) > 1 && ipEndpoints(c.Endpoints) {
+		return strings.Join(c.Endpoints, ";")
+	}
+	return c.Endpoint
+}
+
+// failover reports whether the clients of c pick and switch their endpoint
+// themselves: the SDK spreads the calls over several host names at random,
+// the ones down included.
+func (c *Config) failover() bool {
+	return len(c.Endpoints) > 1 && !ipEndpoints(c.Endpoints)
+}
+
+// probeEndpoint checks that endpoint accepts connections.
+var probeEndpoint = func(ctx context.Context, endpoint string) error {
+	d := net.Dialer{Timeout: endpointProbeTimeout}
+	conn, err := d.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// healthyEndpoint returns the index of the first endpoint of c accepting
+// connections, from start on and wrapping around; start and an error when
+// none does.
+func (c *Config) healthyEndpoint(ctx context.Context, start int) (int, error) {
+	n := len(c.Endpoints)
+	var err error
+	for i := range n {
+		j := (start + i) % n
+		if err = probeEndpoint(ctx, c.Endpoints[j]); err == nil {
+			return j, nil
+		}
+	}
+	return start % n, fmt.Errorf("no rocketmq endpoint accepts connections, last: %w", err)
+}
+
+// withEndpoint returns a copy of c connecting to its endpoint i.
+func (c *Config) withEndpoint(i int) *Config {
+	cp := *c
+	cp.Endpoint = c.Endpoints[i]
+	return &cp
+}
+
+// connect returns the config clients of c connect with: on its first
+// healthy endpoint when they pick it themselves, with its index. When none
+// is healthy, it is the first one along with the error; the SDK keeps
+// reconnecting to it.
+func (c *Config) connect() (*Config, int, error) {
+	if !c.failover() {
+		return c, 0, nil
+	}
+	i, err := c.healthyEndpoint(context.Background(), 0)
+	return c.withEndpoint(i), i, err
+}
+
+// producerFailover moves a Producer to the next healthy endpoint after
+// failoverThreshold consecutive send failures, at most every
+// failoverInterval.
+type producerFailover struct {
+	// start starts a producer on the endpoint of cfg.
+	start func(cfg *Config) (rmq.Producer, func(), error)
+
+	mu        sync.Mutex
+	index     int // of the current endpoint
+	failures  int
+	switching bool
+	switched  time.Time
+}
+
+// observe records the result of a send, failing over in the background when
+// the current endpoint looks down. Failures of the caller's context say
+// nothing about the endpoint.
+func (p *Producer) observe(ctx context.Context, err error) {
+	f := p.failover
+	if f == nil || (err != nil && ctx.Err() != nil) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		f.failures = 0
+		return
+	}
+	f.failures++
+	if f.failures < failoverThreshold || f.switching || time.Since(f.switched) < failoverInterval {
+		return
+	}
+	f.switching = true
+	go p.switchEndpoint(f.index)
+}
+
+// switchEndpoint starts a producer on the next healthy endpoint after from
+// and stops the current one once it is swapped in.
+func (p *Producer) switchEndpoint(from int) {
+	f := p.failover
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(p.cfg.Endpoints))*endpointProbeTimeout)
+	next, probeErr := p.cfg.healthyEndpoint(ctx, from+1)
+	cancel()
+
+	var (
+		client rmq.Producer
+		stop   func()
+		err    error
+	)
+	if next != from {
+		client, stop, err = f.start(p.cfg.withEndpoint(next))
+	}
+
+	f.mu.Lock()
+	f.switching, f.switched, f.failures = false, time.Now(), 0
+	if next == from || err != nil {
+		f.mu.Unlock()
+		switch {
+		case err != nil:
+			p.log.Errorf("fail over to %s: %v", p.cfg.Endpoints[next], err)
+		case probeErr != nil:
+			p.log.Warnf("no healthy endpoint to fail over to from %s: %v", p.cfg.Endpoints[from], probeErr)
+		default:
+			p.log.Warnf("no other healthy endpoint than %s to fail over to", p.cfg.Endpoints[from])
+		}
+		return
+	}
+	f.index = next
+	f.mu.Unlock()
+
+	p.mu.Lock()
+	old := p.stop
+	p.client, p.stop = client, stop
+	p.mu.Unlock()
+	p.log.Warnf("rocketmq producer failed over from %s to %s", p.cfg.Endpoints[from], p.cfg.Endpoints[next])
+	if old != nil {
+		old()
+	}
+}
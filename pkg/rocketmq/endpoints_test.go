@@ -0,0 +1,114 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/conf"
+)
+
+func TestConfig_Endpoints(t *testing.T) {
+	cfg := NewConfigFromProto(&conf.RocketMQ{NameServers: "10.0.0.1:8081; 10.0.0.2:8081,,10.0.0.3:8081"})
+	assert.Equal(t, []string{"10.0.0.1:8081", "10.0.0.2:8081", "10.0.0.3:8081"}, cfg.Endpoints)
+	assert.Equal(t, "10.0.0.1:8081", cfg.Endpoint)
+	assert.Equal(t, "10.0.0.1:8081;10.0.0.2:8081;10.0.0.3:8081", cfg.ToRMQConfig().Endpoint)
+	assert.False(t, cfg.failover())
+
+	cfg = NewConfigFromProto(&conf.RocketMQ{NameServers: "proxy-a:8081;proxy-b:8081"})
+	assert.Equal(t, "proxy-a:8081", cfg.ToRMQConfig().Endpoint)
+	assert.True(t, cfg.failover())
+
+	cfg = NewConfigFromProto(&conf.RocketMQ{NameServers: "proxy:8081"})
+	assert.Equal(t, "proxy:8081", cfg.ToRMQConfig().Endpoint)
+	assert.False(t, cfg.failover())
+}
+
+func TestConfig_HealthyEndpoint(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := closed.Addr().String()
+	require.NoError(t, closed.Close())
+
+	cfg := &Config{Endpoints: []string{down, ln.Addr().String(), down}}
+	ctx := context.Background()
+	i, err := cfg.healthyEndpoint(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+	i, err = cfg.healthyEndpoint(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i, "wraps around")
+
+	cfg = &Config{Endpoints: []string{down, down}}
+	i, err = cfg.healthyEndpoint(ctx, 1)
+	assert.Error(t, err, "none healthy")
+	assert.Equal(t, 1, i)
+
+	probe := probeEndpoint
+	defer func() { probeEndpoint = probe }()
+	probeEndpoint = func(context.Context, string) error { return errors.New("connection refused") }
+	cfg = &Config{Endpoint: "proxy-a:8081", Endpoints: []string{"proxy-a:8081", "proxy-b:8081"}}
+	connect, _, err := cfg.connect()
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, "proxy-a:8081", connect.Endpoint, "the first endpoint when none is healthy")
+}
+
+func TestProducer_Failover(t *testing.T) {
+	probe := probeEndpoint
+	defer func() { probeEndpoint = probe }()
+	probeEndpoint = func(_ context.Context, endpoint string) error {
+		if endpoint == "proxy-a:8081" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	var stopped atomic.Bool
+	first, second := &fakeProducer{}, &fakeProducer{}
+	cfg := &Config{Endpoint: "proxy-a:8081", Endpoints: []string{"proxy-a:8081", "proxy-b:8081"}}
+	var started *Config
+	p := &Producer{
+		client: first,
+		stop:   func() { stopped.Store(true) },
+		log:    log.NewHelper(log.DefaultLogger),
+		cfg:    cfg,
+		failover: &producerFailover{
+			start: func(cfg *Config) (rmq.Producer, func(), error) {
+				started = cfg
+				return second, func() {}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for range failoverThreshold {
+		_, err := p.SendMessage(canceled, &Message{Topic: "orders", Body: []byte("fail")})
+		require.Error(t, err)
+	}
+	assert.Same(t, first, p.current(), "failures of the caller's context are ignored")
+
+	for range failoverThreshold {
+		_, err := p.SendMessage(ctx, &Message{Topic: "orders", Body: []byte("fail")})
+		require.Error(t, err)
+	}
+	require.Eventually(t, func() bool { return p.current() == rmq.Producer(second) }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "proxy-b:8081", started.Endpoint)
+	assert.True(t, stopped.Load(), "previous producer stopped")
+
+	_, err := p.SendMessage(ctx, &Message{Topic: "orders", Body: []byte("ok")})
+	require.NoError(t, err)
+	assert.Len(t, second.sent, 1)
+}
@@ -0,0 +1,111 @@
+package rocketmq
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+)
+
+// ConsumerMiddleware wraps a MessageHandler, e.g. to observe or guard every
+// message of a consumer.
+type ConsumerMiddleware func(MessageHandler) MessageHandler
+
+// ChainConsumer composes middlewares into one; the first is the outermost.
+func ChainConsumer(m ...ConsumerMiddleware) ConsumerMiddleware {
+	return func(h MessageHandler) MessageHandler {
+		for i := len(m) - 1; i >= 0; i-- {
+			h = m[i](h)
+		}
+		return h
+	}
+}
+
+// wrapHandler runs h through Logging, Tracing, then m, then Recovery,
+// innermost so that the other middlewares see a panic as a failure.
+// Consumers created by this package run their handlers through it.
+func wrapHandler(h MessageHandler, logger log.Logger, m ...ConsumerMiddleware) MessageHandler {
+	chain := append(append([]ConsumerMiddleware{Logging(logger), Tracing()}, m...), Recovery(logger))
+	return ChainConsumer(chain...)(h)
+}
+
+// Recovery turns a handler panic into ConsumeFailure, logging the stack, so
+// the message is redelivered instead of the consumer crashing.
+func Recovery(logger log.Logger) ConsumerMiddleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) (result ConsumerResult) {
+			defer func() {
+				if r := recover(); r != nil {
+					l.Errorw("msg", "consumer handler panic", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(),
+						"panic", r, "stack", string(debug.Stack()))
+					result = ConsumeFailure
+				}
+			}()
+			return h(msg)
+		}
+	}
+}
+
+// Logging logs every handled message with its topic, ID, tag, keys,
+// delivery attempt, result and latency: failures at warn level, the others
+// at debug level.
+func Logging(logger log.Logger) ConsumerMiddleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			start := time.Now()
+			result := h(msg)
+			kv := []any{"msg", "consumed", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(),
+				"keys", msg.GetKeys(), "attempt", msg.GetDeliveryAttempt(), "result", resultName(result), "elapsed", time.Since(start)}
+			if tag := msg.GetTag(); tag != nil {
+				kv = append(kv, "tag", *tag)
+			}
+			if result != ConsumeSuccess {
+				l.Warnw(kv...)
+			} else {
+				l.Debugw(kv...)
+			}
+			return result
+		}
+	}
+}
+
+// Timing reports the handling time of every message to observe, e.g. to
+// feed a histogram by topic and result.
+func Timing(observe func(topic string, result ConsumerResult, elapsed time.Duration)) ConsumerMiddleware {
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			start := time.Now()
+			result := h(msg)
+			observe(msg.GetTopic(), result, time.Since(start))
+			return result
+		}
+	}
+}
+
+// Concurrency runs at most a's current limit of handlers at once; the
+// others wait for a slot. A consumer using it needs at least a.Max()
+// consumption threads, see PushConsumerConfig.Adaptive.
+func Concurrency(a *concurrency.Adaptive) ConsumerMiddleware {
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			release, err := a.Acquire(context.Background())
+			if err != nil {
+				return ConsumeFailure
+			}
+			defer release()
+			return h(msg)
+		}
+	}
+}
+
+func resultName(r ConsumerResult) string {
+	if r == ConsumeSuccess {
+		return "success"
+	}
+	return "failure"
+}
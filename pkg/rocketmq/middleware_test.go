@@ -0,0 +1,51 @@
+package rocketmq
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainConsumer(t *testing.T) {
+	var order []string
+	mark := func(name string) ConsumerMiddleware {
+		return func(h MessageHandler) MessageHandler {
+			return func(msg *MessageView) ConsumerResult {
+				order = append(order, name)
+				return h(msg)
+			}
+		}
+	}
+	h := ChainConsumer(mark("outer"), mark("inner"))(func(*MessageView) ConsumerResult {
+		order = append(order, "handler")
+		return ConsumeSuccess
+	})
+	assert.Equal(t, ConsumeSuccess, h(&MessageView{}))
+	assert.Equal(t, []string{"outer", "inner", "handler"}, order)
+}
+
+func TestWrapHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewStdLogger(&buf)
+	var observed []ConsumerResult
+	timing := Timing(func(_ string, result ConsumerResult, elapsed time.Duration) {
+		observed = append(observed, result)
+		assert.GreaterOrEqual(t, elapsed, time.Duration(0))
+	})
+
+	msg := &MessageView{}
+	msg.SetTag("created")
+	h := wrapHandler(func(*MessageView) ConsumerResult { panic("boom") }, logger, timing)
+	assert.Equal(t, ConsumeFailure, h(msg), "a panic is a failure")
+	assert.Contains(t, buf.String(), "consumer handler panic")
+	assert.Contains(t, buf.String(), "result=failure")
+	assert.Contains(t, buf.String(), "tag=created")
+
+	h = wrapHandler(func(*MessageView) ConsumerResult { return ConsumeSuccess }, logger, timing)
+	assert.Equal(t, ConsumeSuccess, h(msg))
+	assert.Equal(t, []ConsumerResult{ConsumeFailure, ConsumeSuccess}, observed,
+		"middlewares see panics as failures")
+}
@@ -2,8 +2,11 @@ package rocketmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	rmq "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/go-kratos/kratos/v2/log"
@@ -29,25 +32,111 @@ type Message struct {
 	Body  []byte
 	Keys  []string // Message keys for filtering/lookup
 	Tag   string   // Message tag for filtering
+	// MessageGroup routes the message to a FIFO message group.
+	// Messages in the same group are delivered in order to one consumer.
+	// Use a GroupRouter to derive it from an entity ID.
+	MessageGroup string
+	// Properties are user properties carried with the message, e.g. a
+	// correlation ID, read by consumers with Property.
+	Properties map[string]string
+}
+
+// toRMQ converts msg to a rmq.Message.
+func (msg *Message) toRMQ() *rmq.Message {
+	m := &rmq.Message{
+		Topic: msg.Topic,
+		Body:  msg.Body,
+	}
+	if len(msg.Keys) > 0 {
+		m.SetKeys(msg.Keys...)
+	}
+	if msg.Tag != "" {
+		m.SetTag(msg.Tag)
+	}
+	if msg.MessageGroup != "" {
+		m.SetMessageGroup(msg.MessageGroup)
+	}
+	for k, v := range msg.Properties {
+		m.AddProperty(k, v)
+	}
+	return m
 }
 
 // Producer wraps RocketMQ v5 producer for sending messages.
 type Producer struct {
+	mu     sync.RWMutex // guards client and stop, swapped on failover
 	client rmq.Producer
+	stop   func()
 	log    *log.Helper
 	cfg    *Config
+	// failover switches the endpoint of client, nil when the SDK does.
+	failover *producerFailover
+	// queue sends the messages of SendAsync, nil when the SDK does.
+	queue *asyncQueue
 }
 
-// NewProducer creates a new RocketMQ v5 producer.
+// NewProducer creates a new RocketMQ v5 producer. With several host name
+// endpoints, it connects to the first healthy one and fails over to the
+// next after repeated send failures.
 func NewProducer(cfg *Config, topics []string, logger log.Logger) (*Producer, func(), error) {
 	logHelper := log.NewHelper(log.With(logger, "module", "pkg/rocketmq"))
+	if cfg.AsyncQueue != nil {
+		if err := cfg.AsyncQueue.validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	connect, index, err := cfg.connect()
+	if err != nil {
+		logHelper.Warnf("%v, connecting to %s", err, connect.target())
+	}
+	client, stop, err := startProducer(connect, topics, logHelper)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &Producer{
+		client: client,
+		stop:   stop,
+		log:    logHelper,
+		cfg:    cfg,
+	}
+	if cfg.failover() {
+		p.failover = &producerFailover{
+			start: func(cfg *Config) (rmq.Producer, func(), error) { return startProducer(cfg, topics, logHelper) },
+			index: index,
+		}
+	}
+	if cfg.AsyncQueue != nil {
+		p.queue = newAsyncQueue(*cfg.AsyncQueue, p.sendQueued)
+	}
+	return p, p.close, nil
+}
 
-	configureSSL(cfg.EnableSSL)
+// current returns the client sends go through.
+func (p *Producer) current() rmq.Producer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.client
+}
 
-	opts := []rmq.ProducerOption{
-		rmq.WithMaxAttempts(cfg.MaxAttempts),
+// close flushes the send queue and stops the current client.
+func (p *Producer) close() {
+	if p.queue != nil {
+		if left := p.queue.close(); left > 0 {
+			p.log.Warnf("%d queued messages not sent before shutdown", left)
+		}
 	}
+	p.mu.RLock()
+	stop := p.stop
+	p.mu.RUnlock()
+	stop()
+}
 
+// startProducer creates and starts a v5 producer with the options of cfg
+// and opts, returning it with its cleanup.
+func startProducer(cfg *Config, topics []string, logHelper *log.Helper, opts ...rmq.ProducerOption) (rmq.Producer, func(), error) {
+	configureSSL(cfg.EnableSSL)
+
+	opts = append(opts, rmq.WithMaxAttempts(cfg.MaxAttempts))
 	if len(topics) > 0 {
 		opts = append(opts, rmq.WithTopics(topics...))
 	}
@@ -61,7 +150,7 @@ func NewProducer(cfg *Config, topics []string, logger log.Logger) (*Producer, fu
 		return nil, nil, fmt.Errorf("start rocketmq producer: %w", err)
 	}
 
-	logHelper.Infof("rocketmq producer started, endpoint=%s", cfg.Endpoint)
+	logHelper.Infof("rocketmq producer started, endpoint=%s", cfg.target())
 
 	cleanup := func() {
 		logHelper.Info("shutting down rocketmq producer")
@@ -69,12 +158,7 @@ func NewProducer(cfg *Config, topics []string, logger log.Logger) (*Producer, fu
 			logHelper.Errorf("shutdown rocketmq producer: %v", err)
 		}
 	}
-
-	return &Producer{
-		client: p,
-		log:    logHelper,
-		cfg:    cfg,
-	}, cleanup, nil
+	return p, cleanup, nil
 }
 
 // SendSync sends a message synchronously and returns only error.
@@ -97,23 +181,104 @@ func (p *Producer) SendSyncWithResult(ctx context.Context, topic string, body []
 // SendMessage sends a message with custom keys and tags.
 // Keys are used for message lookup and filtering.
 // Tags are used for message filtering on consumer side.
+// A non-empty MessageGroup makes it a FIFO message.
 func (p *Producer) SendMessage(ctx context.Context, msg *Message) (*SendReceipt, error) {
-	m := &rmq.Message{
-		Topic: msg.Topic,
-		Body:  msg.Body,
+	return p.sendMessage(ctx, msg.toRMQ())
+}
+
+// SendDelay sends msg to be delivered after delay, e.g. to schedule
+// follow-up work. The topic must be a DELAY topic, and the broker bounds
+// the delay (timerMaxDelaySec, 3 days by default).
+func (p *Producer) SendDelay(ctx context.Context, msg *Message, delay time.Duration) (*SendReceipt, error) {
+	return p.SendAt(ctx, msg, time.Now().Add(delay))
+}
+
+// SendAt sends msg to be delivered at t; a past t delivers it right away.
+// See SendDelay. FIFO messages cannot be scheduled.
+func (p *Producer) SendAt(ctx context.Context, msg *Message, t time.Time) (*SendReceipt, error) {
+	if msg.MessageGroup != "" {
+		return nil, fmt.Errorf("send message: FIFO messages cannot be scheduled")
 	}
-	if len(msg.Keys) > 0 {
-		m.SetKeys(msg.Keys...)
+	m := msg.toRMQ()
+	m.SetDelayTimestamp(t)
+	return p.sendMessage(ctx, m)
+}
+
+// maxBatchInFlight bounds the messages a SendBatch has in flight.
+const maxBatchInFlight = 32
+
+// SendBatch sends msgs to topic concurrently and returns their receipts in
+// the order of msgs, nil for the ones that failed; the error lists those.
+// The messages of a FIFO message group are sent one after another in
+// order, and the ones after a failure are not sent. A message's Topic must
+// be empty or topic.
+func (p *Producer) SendBatch(ctx context.Context, topic string, msgs []*Message) ([]*SendReceipt, error) {
+	for i, msg := range msgs {
+		if msg.Topic != "" && msg.Topic != topic {
+			return nil, fmt.Errorf("send batch: message %d is for topic %s, not %s", i, msg.Topic, topic)
+		}
 	}
-	if msg.Tag != "" {
-		m.SetTag(msg.Tag)
+	// Each unit is a message, or the messages of a FIFO group.
+	var units [][]int
+	groups := make(map[string]int)
+	for i, msg := range msgs {
+		if msg.MessageGroup == "" {
+			units = append(units, []int{i})
+			continue
+		}
+		u, ok := groups[msg.MessageGroup]
+		if !ok {
+			u = len(units)
+			groups[msg.MessageGroup] = u
+			units = append(units, nil)
+		}
+		units[u] = append(units[u], i)
 	}
-	return p.sendMessage(ctx, m)
+
+	receipts := make([]*SendReceipt, len(msgs))
+	errs := make([]error, len(msgs))
+	sem := make(chan struct{}, maxBatchInFlight)
+	var wg sync.WaitGroup
+	for _, unit := range units {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			for n, i := range unit {
+				m := msgs[i].toRMQ()
+				m.Topic = topic
+				receipts[i], errs[i] = p.sendMessage(ctx, m)
+				if errs[i] != nil {
+					for _, j := range unit[n+1:] {
+						errs[j] = fmt.Errorf("not sent after message %d of group %s failed", i, msgs[i].MessageGroup)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("message %d: %w", i, err))
+		}
+	}
+	if len(failed) > 0 {
+		return receipts, fmt.Errorf("send batch: %d of %d messages failed: %w", len(failed), len(msgs), errors.Join(failed...))
+	}
+	return receipts, nil
 }
 
 // sendMessage is the internal method that sends a rmq.Message.
-func (p *Producer) sendMessage(ctx context.Context, msg *rmq.Message) (*SendReceipt, error) {
-	receipts, err := p.client.Send(ctx, msg)
+// It runs in a producer span propagated to the consumers, see Tracing.
+func (p *Producer) sendMessage(ctx context.Context, msg *rmq.Message) (receipt *SendReceipt, err error) {
+	ctx, span := startPublish(ctx, msg)
+	defer func() { endPublish(span, receipt, err) }()
+
+	receipts, err := p.current().Send(ctx, msg)
+	p.observe(ctx, err)
 	if err != nil {
 		p.log.WithContext(ctx).Errorf("send to %s failed: %v", msg.Topic, err)
 		return nil, fmt.Errorf("send message: %w", err)
@@ -133,34 +298,45 @@ func (p *Producer) sendMessage(ctx context.Context, msg *rmq.Message) (*SendRece
 	}, nil
 }
 
-// SendAsync sends a message asynchronously.
+// SendAsync sends a message asynchronously. With Config.AsyncQueue it is
+// queued, the callback getting ErrQueueFull or ErrProducerClosed when it
+// cannot be; the send outlives the cancellation of ctx once queued.
 func (p *Producer) SendAsync(ctx context.Context, msg *Message, callback func(context.Context, *SendReceipt, error)) {
-	m := &rmq.Message{
-		Topic: msg.Topic,
-		Body:  msg.Body,
-	}
-	if len(msg.Keys) > 0 {
-		m.SetKeys(msg.Keys...)
-	}
-	if msg.Tag != "" {
-		m.SetTag(msg.Tag)
+	if p.queue != nil {
+		p.queue.enqueue(asyncJob{ctx: ctx, msg: msg, callback: callback})
+		return
 	}
+	m := msg.toRMQ()
+	ctx, span := startPublish(ctx, m)
 
-	p.client.SendAsync(ctx, m, func(ctx context.Context, receipts []*rmq.SendReceipt, err error) {
+	p.current().SendAsync(ctx, m, func(ctx context.Context, receipts []*rmq.SendReceipt, err error) {
+		p.observe(ctx, err)
 		if err != nil {
 			p.log.WithContext(ctx).Errorf("send async to %s failed: %v", msg.Topic, err)
+			endPublish(span, nil, err)
 			callback(ctx, nil, err)
 			return
 		}
 		if len(receipts) == 0 {
-			callback(ctx, nil, fmt.Errorf("send async: no receipt returned"))
+			err := fmt.Errorf("send async: no receipt returned")
+			endPublish(span, nil, err)
+			callback(ctx, nil, err)
 			return
 		}
 		result := receipts[0]
-		callback(ctx, &SendReceipt{
+		receipt := &SendReceipt{
 			MessageID:     result.MessageID,
 			TransactionID: result.TransactionId,
 			Offset:        result.Offset,
-		}, nil)
+		}
+		endPublish(span, receipt, nil)
+		callback(ctx, receipt, nil)
 	})
 }
+
+// sendQueued sends a message of the send queue.
+func (p *Producer) sendQueued(j asyncJob) {
+	ctx := context.WithoutCancel(j.ctx)
+	receipt, err := p.sendMessage(ctx, j.msg.toRMQ())
+	j.callback(ctx, receipt, err)
+}
@@ -0,0 +1,128 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProducer records the messages sent by a Producer, failing the ones
+// whose body is "fail".
+type fakeProducer struct {
+	rmq.Producer
+	mu   sync.Mutex
+	sent []*rmq.Message
+}
+
+func (f *fakeProducer) Send(_ context.Context, msg *rmq.Message) ([]*rmq.SendReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if string(msg.Body) == "fail" {
+		return nil, errors.New("broker busy")
+	}
+	f.sent = append(f.sent, msg)
+	return []*rmq.SendReceipt{{MessageID: "m" + fmt.Sprint(len(f.sent))}}, nil
+}
+
+func TestProducer_SendAt(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger)}
+	ctx := context.Background()
+
+	at := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	receipt, err := p.SendAt(ctx, &Message{Topic: "reminders", Body: []byte("x"), Keys: []string{"order-1"}}, at)
+	require.NoError(t, err)
+	assert.Equal(t, "m1", receipt.MessageID)
+	require.Len(t, f.sent, 1)
+	assert.Equal(t, at, *f.sent[0].GetDeliveryTimestamp())
+	assert.Equal(t, []string{"order-1"}, f.sent[0].GetKeys())
+
+	before := time.Now()
+	_, err = p.SendDelay(ctx, &Message{Topic: "reminders"}, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, before.Add(time.Hour), *f.sent[1].GetDeliveryTimestamp(), time.Second)
+
+	_, err = p.SendDelay(ctx, &Message{Topic: "reminders", MessageGroup: "g"}, time.Hour)
+	assert.ErrorContains(t, err, "FIFO messages cannot be scheduled")
+	assert.Len(t, f.sent, 2)
+}
+
+func TestProducer_SendBatch(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger)}
+	ctx := context.Background()
+
+	var msgs []*Message
+	for i := range 50 {
+		msgs = append(msgs, &Message{Body: []byte(fmt.Sprint(i))})
+	}
+	for _, body := range []string{"a1", "a2", "a3"} {
+		msgs = append(msgs, &Message{Body: []byte(body), MessageGroup: "a"})
+	}
+	receipts, err := p.SendBatch(ctx, "events", msgs)
+	require.NoError(t, err)
+	require.Len(t, receipts, len(msgs))
+	seen := make(map[string]bool)
+	for _, r := range receipts {
+		require.NotNil(t, r)
+		seen[r.MessageID] = true
+	}
+	assert.Len(t, seen, len(msgs), "every message has its own receipt")
+	var group []string
+	for _, m := range f.sent {
+		assert.Equal(t, "events", m.Topic)
+		if m.GetMessageGroup() != nil {
+			group = append(group, string(m.Body))
+		}
+	}
+	assert.Equal(t, []string{"a1", "a2", "a3"}, group, "FIFO groups are sent in order")
+
+	f.sent = nil
+	receipts, err = p.SendBatch(ctx, "events", []*Message{
+		{Body: []byte("ok")},
+		{Body: []byte("fail"), MessageGroup: "b"},
+		{Body: []byte("after"), MessageGroup: "b"},
+		{Body: []byte("fail")},
+	})
+	assert.ErrorContains(t, err, "3 of 4 messages failed")
+	assert.ErrorContains(t, err, "message 2: not sent after message 1 of group b failed")
+	require.Len(t, receipts, 4)
+	assert.NotNil(t, receipts[0])
+	assert.Nil(t, receipts[1])
+	assert.Nil(t, receipts[2])
+	assert.Len(t, f.sent, 1)
+
+	_, err = p.SendBatch(ctx, "events", []*Message{{Topic: "orders"}})
+	assert.ErrorContains(t, err, "message 0 is for topic orders")
+}
+
+func TestProducer_Properties(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger)}
+
+	_, err := p.SendMessage(context.Background(), &Message{Topic: "orders", Body: []byte("x"), Properties: map[string]string{
+		CorrelationIDProperty: "req-1",
+		TenantIDProperty:      "acme",
+		SchemaVersionProperty: "2",
+	}})
+	require.NoError(t, err)
+	require.Len(t, f.sent, 1)
+	properties := f.sent[0].GetProperties()
+	assert.Equal(t, "req-1", properties[CorrelationIDProperty])
+
+	messageProperties = func(*MessageView) map[string]string { return properties }
+	t.Cleanup(func() { messageProperties = (*MessageView).GetProperties })
+	msg := &MessageView{}
+	assert.Equal(t, "req-1", CorrelationID(msg))
+	assert.Equal(t, "acme", TenantID(msg))
+	assert.Equal(t, "2", SchemaVersion(msg))
+	assert.Empty(t, Property(msg, "missing"))
+}
@@ -0,0 +1,32 @@
+package rocketmq
+
+// Well-known user properties of messages, set in Message.Properties.
+const (
+	// CorrelationIDProperty ties a message to the request or workflow that
+	// caused it.
+	CorrelationIDProperty = "correlation-id"
+	// TenantIDProperty is the tenant a message belongs to.
+	TenantIDProperty = "tenant-id"
+	// SchemaVersionProperty is the version of the schema of the body.
+	SchemaVersionProperty = "schema-version"
+)
+
+// Property returns the user property key of msg, "" when it is not set.
+func Property(msg *MessageView, key string) string {
+	return messageProperties(msg)[key]
+}
+
+// CorrelationID returns the CorrelationIDProperty of msg.
+func CorrelationID(msg *MessageView) string {
+	return Property(msg, CorrelationIDProperty)
+}
+
+// TenantID returns the TenantIDProperty of msg.
+func TenantID(msg *MessageView) string {
+	return Property(msg, TenantIDProperty)
+}
+
+// SchemaVersion returns the SchemaVersionProperty of msg.
+func SchemaVersion(msg *MessageView) string {
+	return Property(msg, SchemaVersionProperty)
+}
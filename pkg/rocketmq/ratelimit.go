@@ -0,0 +1,34 @@
+package rocketmq
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig bounds the messages a consumer hands to its handler, with
+// a token bucket, e.g. so that replaying a large backlog doesn't overwhelm
+// the database behind the handler. Messages beyond the rate wait in the
+// consumption threads; the broker holds the rest.
+type RateLimitConfig struct {
+	PerSecond float64 // Messages per second, 0 disables the limit
+	Burst     int     // Messages handled at once beyond the rate, defaults to 1
+}
+
+// RateLimit hands at most cfg.PerSecond messages per second to the handler,
+// waiting for the rate until ctx is done; the messages still waiting then
+// fail, to be redelivered.
+func RateLimit(ctx context.Context, cfg RateLimitConfig) ConsumerMiddleware {
+	if cfg.PerSecond <= 0 {
+		return func(h MessageHandler) MessageHandler { return h }
+	}
+	l := rate.NewLimiter(rate.Limit(cfg.PerSecond), max(cfg.Burst, 1))
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			if err := l.Wait(ctx); err != nil {
+				return ConsumeFailure
+			}
+			return h(msg)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package rocketmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	var handled int
+	handler := func(*MessageView) ConsumerResult {
+		handled++
+		return ConsumeSuccess
+	}
+
+	h := RateLimit(context.Background(), RateLimitConfig{PerSecond: 20, Burst: 2})(handler)
+	start := time.Now()
+	for range 4 {
+		assert.Equal(t, ConsumeSuccess, h(&MessageView{}))
+	}
+	// The burst passes at once, the other two wait 50ms each.
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, 4, handled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h = RateLimit(ctx, RateLimitConfig{PerSecond: 0.001})(handler)
+	assert.Equal(t, ConsumeSuccess, h(&MessageView{}))
+	cancel()
+	assert.Equal(t, ConsumeFailure, h(&MessageView{}), "messages waiting on shutdown are redelivered")
+	assert.Equal(t, 5, handled)
+
+	h = RateLimit(ctx, RateLimitConfig{})(handler)
+	assert.Equal(t, ConsumeSuccess, h(&MessageView{}), "no rate is no limit")
+}
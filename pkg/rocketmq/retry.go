@@ -0,0 +1,104 @@
+package rocketmq
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 5 * time.Minute
+	defaultMultiplier     = 2
+)
+
+// RetryPolicy delays the redelivery of failed messages with an exponential
+// backoff, making them invisible with ChangeInvisibleDuration, instead of
+// the broker redelivering them right away, so a handler failing on a
+// transient downstream error does not retry in a hot loop.
+type RetryPolicy struct {
+	// MaxAttempts is the number of deliveries failing with the policy;
+	// later failures are left to the broker's retry policy. 0 is unbounded.
+	MaxAttempts    int32
+	InitialBackoff time.Duration // delay after the first failure, 1s when zero
+	MaxBackoff     time.Duration // 5m when zero
+	Multiplier     float64       // growth per failure, 2 when zero
+	// Jitter randomizes each delay by up to that fraction, e.g. 0.2 for
+	// ±20%, so messages failing together are not redelivered together.
+	Jitter float64
+}
+
+// Backoff returns the delay before redelivering a message that failed its
+// attempt-th delivery, starting at 1.
+func (p RetryPolicy) Backoff(attempt int32) time.Duration {
+	initial, maxBackoff, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultMultiplier
+	}
+	d := math.Min(float64(initial)*math.Pow(multiplier, float64(max(attempt, 1)-1)), float64(maxBackoff))
+	if p.Jitter > 0 {
+		d *= 1 + min(p.Jitter, 1)*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// retry delays the redelivery of the messages h fails with p, before the
+// broker's retry policy takes over. delay makes msg invisible for d and
+// returns the result to report for it; when it fails, the failure is left
+// to the broker's redelivery. FIFO messages are redelivered in order by the
+// client, so they are never delayed.
+func retry(p RetryPolicy, delay func(msg *MessageView, d time.Duration) (ConsumerResult, error), logger log.Logger) ConsumerMiddleware {
+	l := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			result := h(msg)
+			attempt := deliveryAttempt(msg)
+			if result == ConsumeSuccess || msg.GetMessageGroup() != nil || (p.MaxAttempts > 0 && attempt >= p.MaxAttempts) {
+				return result
+			}
+			d := p.Backoff(attempt)
+			delayed, err := delay(msg, d)
+			if err != nil {
+				l.Warnw("msg", "delay redelivery failed, left to the broker", "topic", msg.GetTopic(),
+					"message_id", msg.GetMessageId(), "attempt", attempt, "error", err)
+				return result
+			}
+			l.Debugw("msg", "redelivery delayed", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(),
+				"attempt", attempt, "backoff", d)
+			return delayed
+		}
+	}
+}
+
+// changeInvisible is the ChangeInvisibleDuration of a consumer.
+type changeInvisible func(msg *MessageView, d time.Duration) error
+
+// pushDelay delays a message of a push consumer. The client nacks a failed
+// message with the broker's retry policy, which would override the delay,
+// so the invisibility is changed on a copy of msg and msg is reported as
+// consumed: the acknowledgement of its former receipt handle, already
+// settled by the change, leaves the delayed delivery in place.
+func pushDelay(change changeInvisible) func(msg *MessageView, d time.Duration) (ConsumerResult, error) {
+	return func(msg *MessageView, d time.Duration) (ConsumerResult, error) {
+		cp := *msg
+		if err := change(&cp, d); err != nil {
+			return ConsumeFailure, err
+		}
+		return ConsumeSuccess, nil
+	}
+}
+
+// simpleDelay delays a message of a simple consumer, left unacknowledged.
+func simpleDelay(change changeInvisible) func(msg *MessageView, d time.Duration) (ConsumerResult, error) {
+	return func(msg *MessageView, d time.Duration) (ConsumerResult, error) {
+		return ConsumeFailure, change(msg, d)
+	}
+}
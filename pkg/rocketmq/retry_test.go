@@ -0,0 +1,75 @@
+package rocketmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 900*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(4), "capped at the max backoff")
+	assert.Equal(t, time.Second, RetryPolicy{}.Backoff(1), "defaults")
+	assert.Equal(t, 2*time.Second, RetryPolicy{}.Backoff(2))
+
+	p.Jitter = 0.5
+	for range 100 {
+		d := p.Backoff(2)
+		assert.GreaterOrEqual(t, d, 150*time.Millisecond)
+		assert.LessOrEqual(t, d, 450*time.Millisecond)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempt int32
+	deliveryAttempt = func(*MessageView) int32 { return attempt }
+	t.Cleanup(func() { deliveryAttempt = (*MessageView).GetDeliveryAttempt })
+
+	var changed []*MessageView
+	var delays []time.Duration
+	var changeErr error
+	change := func(msg *MessageView, d time.Duration) error {
+		if changeErr != nil {
+			return changeErr
+		}
+		msg.ReceiptHandle = "renewed"
+		changed, delays = append(changed, msg), append(delays, d)
+		return nil
+	}
+	result := ConsumeFailure
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	h := retry(p, pushDelay(change), log.DefaultLogger)(func(*MessageView) ConsumerResult { return result })
+
+	msg := &MessageView{ReceiptHandle: "h1"}
+	attempt = 2
+	assert.Equal(t, ConsumeSuccess, h(msg), "the former receipt handle is acknowledged")
+	require.Len(t, changed, 1)
+	assert.NotSame(t, msg, changed[0])
+	assert.Equal(t, "h1", msg.ReceiptHandle, "the client acknowledges the former handle")
+	assert.Equal(t, []time.Duration{2 * time.Second}, delays)
+
+	attempt = 3
+	assert.Equal(t, ConsumeFailure, h(msg), "left to the broker after max attempts")
+	assert.Len(t, changed, 1)
+
+	attempt, changeErr = 1, errors.New("broker down")
+	assert.Equal(t, ConsumeFailure, h(msg), "left to the broker when the change fails")
+
+	result = ConsumeSuccess
+	assert.Equal(t, ConsumeSuccess, h(msg))
+	assert.Len(t, changed, 1)
+
+	changeErr, result = nil, ConsumeFailure
+	h = retry(p, simpleDelay(change), log.DefaultLogger)(func(*MessageView) ConsumerResult { return result })
+	assert.Equal(t, ConsumeFailure, h(msg), "simple consumer messages stay unacknowledged")
+	require.Len(t, changed, 2)
+	assert.Same(t, msg, changed[1])
+	assert.Equal(t, time.Second, delays[1])
+}
@@ -0,0 +1,253 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+)
+
+const (
+	defaultReceiveBatch   = 16
+	defaultInvisibleTime  = 30 * time.Second
+	defaultReceiveWorkers = 16
+	receiveErrorBackoff   = time.Second
+	simpleAckTimeout      = 5 * time.Second
+)
+
+var _ transport.Server = (*Server)(nil)
+
+// Server runs a consumer as a kratos transport.Server, so it is registered
+// in newApp next to the gRPC and HTTP servers and starts and stops with the
+// app. It uses a PushConsumer by default, or a SimpleConsumer polling loop
+// with WithSimpleConsumer.
+type Server struct {
+	cfg           *Config
+	subscriptions map[string]*FilterExpression
+	handler       MessageHandler
+	opts          serverOptions
+	logger        log.Logger
+	log           *log.Helper
+	stopCh        chan struct{}
+	stopOnce      sync.Once
+}
+
+type serverOptions struct {
+	push        *PushConsumerConfig
+	middlewares []ConsumerMiddleware
+	simple      bool
+	batch       int32
+	invisible   time.Duration
+	workers     int
+	adaptive    *concurrency.Adaptive
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*serverOptions)
+
+// WithPushConfig tunes the push consumer; by default it is
+// NewPushConsumerConfigFromConfig of the server's Config.
+func WithPushConfig(c *PushConsumerConfig) ServerOption {
+	return func(o *serverOptions) { o.push = c }
+}
+
+// WithConsumerMiddleware adds middlewares run between Logging and Recovery.
+func WithConsumerMiddleware(m ...ConsumerMiddleware) ServerOption {
+	return func(o *serverOptions) { o.middlewares = append(o.middlewares, m...) }
+}
+
+// WithSimpleConsumer polls with a SimpleConsumer instead: each receive
+// takes up to batch messages (16), invisible to other consumers for
+// invisible (30s) while up to workers (16) handlers run. A message that is
+// not acknowledged in time is redelivered, or after the backoff of
+// Config.Retry when set. Zero values keep the defaults.
+func WithSimpleConsumer(batch int32, invisible time.Duration, workers int) ServerOption {
+	return func(o *serverOptions) {
+		o.simple = true
+		if batch > 0 {
+			o.batch = batch
+		}
+		if invisible > 0 {
+			o.invisible = invisible
+		}
+		if workers > 0 {
+			o.workers = workers
+		}
+	}
+}
+
+// WithAdaptiveWorkers scales the handlers running at once with a, within its
+// bounds, instead of the fixed workers of WithSimpleConsumer or consumption
+// threads of the push consumer. The server adjusts a while it runs.
+func WithAdaptiveWorkers(a *concurrency.Adaptive) ServerOption {
+	return func(o *serverOptions) { o.adaptive = a }
+}
+
+// NewServer creates a server consuming the subscriptions of the consumer
+// group cfg.ConsumerGroup with handler. The consumer is created on Start.
+func NewServer(cfg *Config, subscriptions map[string]*FilterExpression, handler MessageHandler, logger log.Logger, opts ...ServerOption) *Server {
+	o := serverOptions{batch: defaultReceiveBatch, invisible: defaultInvisibleTime, workers: defaultReceiveWorkers}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Server{
+		cfg:           cfg,
+		subscriptions: subscriptions,
+		handler:       handler,
+		opts:          o,
+		logger:        logger,
+		log:           log.NewHelper(log.With(logger, "module", "pkg/rocketmq/server")),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start implements transport.Server. It creates and starts the consumer and
+// blocks until Stop is called or ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	if s.opts.simple {
+		return s.runSimple(ctx)
+	}
+	return s.runPush(ctx)
+}
+
+// Stop implements transport.Server. Start returns once the consumer is
+// shut down. Safe to call multiple times.
+func (s *Server) Stop(_ context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	return nil
+}
+
+func (s *Server) runPush(ctx context.Context) error {
+	cfg := s.opts.push
+	if cfg == nil {
+		cfg = NewPushConsumerConfigFromConfig(s.cfg)
+	}
+	if s.opts.adaptive != nil {
+		cp := *cfg
+		cp.Adaptive = s.opts.adaptive
+		cfg = &cp
+	}
+	c, cleanup, err := NewPushConsumer(cfg, s.subscriptions, s.handler, s.logger, s.opts.middlewares...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	s.log.Infof("rocketmq server consuming %d topics as %s", len(s.subscriptions), s.cfg.ConsumerGroup)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.stopCh:
+		return nil
+	}
+}
+
+func (s *Server) runSimple(ctx context.Context) error {
+	c, cleanup, err := NewSimpleConsumer(NewSimpleConsumerConfigFromConfig(s.cfg), s.subscriptions, s.logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	s.log.Infof("rocketmq server polling %d topics as %s", len(s.subscriptions), s.cfg.ConsumerGroup)
+	if s.opts.adaptive != nil {
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go s.opts.adaptive.Run(runCtx)
+	}
+	handler := wrapHandler(s.handler, s.logger, s.opts.middlewares...)
+	if s.cfg.Retry != nil {
+		handler = retry(*s.cfg.Retry, simpleDelay(c.client.ChangeInvisibleDuration), s.logger)(handler)
+	}
+	return s.poll(ctx, c, handler)
+}
+
+// poll receives and handles messages until Stop is called or ctx is done.
+// A receive in progress is cancelled by Stop; handlers already running
+// finish and their messages are acknowledged.
+func (s *Server) poll(ctx context.Context, c *SimpleConsumer, handler MessageHandler) error {
+	recvCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-recvCtx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stopCh:
+			return nil
+		default:
+		}
+		msgs, err := c.client.Receive(recvCtx, s.opts.batch, s.opts.invisible)
+		if err != nil {
+			if st, ok := rmq.AsErrRpcStatus(err); ok && st.GetCode() == int32(v2.Code_MESSAGE_NOT_FOUND) {
+				continue
+			}
+			if recvCtx.Err() == nil {
+				s.log.Errorf("receive messages: %v", err)
+				select {
+				case <-time.After(receiveErrorBackoff):
+				case <-recvCtx.Done():
+				}
+			}
+			continue
+		}
+		s.handle(context.WithoutCancel(ctx), c, handler, msgs)
+	}
+}
+
+// handle runs handler on msgs with up to the configured workers and
+// acknowledges the ones it consumed. The others are redelivered once their
+// invisible duration expires.
+func (s *Server) handle(ctx context.Context, c *SimpleConsumer, handler MessageHandler, msgs []*MessageView) {
+	acquire := s.workerSlots()
+	var wg sync.WaitGroup
+	for _, msg := range msgs {
+		release, err := acquire(ctx)
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { release(); wg.Done() }()
+			if handler(msg) != ConsumeSuccess {
+				return
+			}
+			ackCtx, cancel := context.WithTimeout(ctx, simpleAckTimeout)
+			defer cancel()
+			_ = c.Ack(ackCtx, msg)
+		}()
+	}
+	wg.Wait()
+}
+
+// workerSlots returns the function acquiring a handler slot of a handle
+// call: from the adaptive limit when set, else among the fixed workers.
+func (s *Server) workerSlots() func(ctx context.Context) (func(), error) {
+	if s.opts.adaptive != nil {
+		return s.opts.adaptive.Acquire
+	}
+	sem := make(chan struct{}, s.opts.workers)
+	return func(context.Context) (func(), error) {
+		sem <- struct{}{}
+		return func() { <-sem }, nil
+	}
+}
@@ -0,0 +1,111 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/concurrency"
+)
+
+// fakeSimpleConsumer returns its batches, then no messages until ctx is
+// cancelled.
+type fakeSimpleConsumer struct {
+	rmq.SimpleConsumer
+	mu      sync.Mutex
+	batches [][]*MessageView
+	acked   []*MessageView
+	drained chan struct{}
+}
+
+func (f *fakeSimpleConsumer) Receive(ctx context.Context, _ int32, _ time.Duration) ([]*MessageView, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.batches) == 0 {
+		select {
+		case <-f.drained:
+		default:
+			close(f.drained)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &rmq.ErrRpcStatus{Code: int32(v2.Code_MESSAGE_NOT_FOUND)}
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func (f *fakeSimpleConsumer) Ack(_ context.Context, msg *MessageView) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, msg)
+	return nil
+}
+
+func TestServer_Poll(t *testing.T) {
+	ok1, ok2, failed, panics := &MessageView{}, &MessageView{}, &MessageView{}, &MessageView{}
+	f := &fakeSimpleConsumer{batches: [][]*MessageView{{ok1, failed}, {panics, ok2}}, drained: make(chan struct{})}
+	var mu sync.Mutex
+	var handled int
+	s := NewServer(&Config{ConsumerGroup: "g"}, nil, func(msg *MessageView) ConsumerResult {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		switch msg {
+		case failed:
+			return ConsumeFailure
+		case panics:
+			panic("boom")
+		}
+		return ConsumeSuccess
+	}, log.DefaultLogger, WithSimpleConsumer(2, time.Minute, 2))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.poll(context.Background(), &SimpleConsumer{client: f, log: s.log}, wrapHandler(s.handler, log.DefaultLogger))
+	}()
+	<-f.drained
+	require.NoError(t, s.Stop(context.Background()))
+	require.NoError(t, s.Stop(context.Background()), "Stop is idempotent")
+	require.NoError(t, <-done)
+
+	assert.Equal(t, 4, handled)
+	assert.ElementsMatch(t, []*MessageView{ok1, ok2}, f.acked, "failed and panicking messages are redelivered")
+}
+
+func TestServer_PollAdaptive(t *testing.T) {
+	msgs := []*MessageView{{}, {}, {}, {}}
+	f := &fakeSimpleConsumer{batches: [][]*MessageView{msgs}, drained: make(chan struct{})}
+	a := concurrency.NewAdaptive(concurrency.AdaptiveConfig{Min: 1, Max: 4})
+	var running, peak atomic.Int32
+	s := NewServer(&Config{ConsumerGroup: "g"}, nil, func(*MessageView) ConsumerResult {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		time.Sleep(time.Millisecond)
+		return ConsumeSuccess
+	}, log.DefaultLogger, WithSimpleConsumer(4, time.Minute, 4), WithAdaptiveWorkers(a))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.poll(context.Background(), &SimpleConsumer{client: f, log: s.log}, s.handler)
+	}()
+	<-f.drained
+	require.NoError(t, s.Stop(context.Background()))
+	require.NoError(t, <-done)
+
+	assert.Len(t, f.acked, 4)
+	assert.Equal(t, int32(1), peak.Load(), "handlers run within the adaptive limit, not the fixed workers")
+}
@@ -0,0 +1,161 @@
+package rocketmq
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodes is the number of ring points per message group.
+const defaultVirtualNodes = 160
+
+// GroupRouter maps entity IDs onto a fixed number of FIFO message groups
+// using consistent hashing.
+// Events about the same entity always share a message group, so RocketMQ
+// delivers them in order to a single consumer. When the group count changes
+// only about 1/n of the entities move to another group.
+type GroupRouter struct {
+	prefix       string
+	groups       int
+	virtualNodes int
+	ring         []uint64 // sorted ring points
+	owners       []int    // owners[i] is the group index of ring[i]
+}
+
+// GroupRouterOption configures a GroupRouter.
+type GroupRouterOption func(*GroupRouter)
+
+// WithVirtualNodes sets the number of ring points per group.
+// More points give a more even distribution at the cost of memory.
+func WithVirtualNodes(n int) GroupRouterOption {
+	return func(r *GroupRouter) {
+		if n > 0 {
+			r.virtualNodes = n
+		}
+	}
+}
+
+// NewGroupRouter creates a router over groups message groups named
+// "<prefix>-0" .. "<prefix>-<groups-1>". A group count below 1 is treated as 1.
+func NewGroupRouter(prefix string, groups int, opts ...GroupRouterOption) *GroupRouter {
+	if groups < 1 {
+		groups = 1
+	}
+	r := &GroupRouter{
+		prefix:       prefix,
+		groups:       groups,
+		virtualNodes: defaultVirtualNodes,
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	r.build()
+	return r
+}
+
+func (r *GroupRouter) build() {
+	type point struct {
+		hash  uint64
+		owner int
+	}
+	points := make([]point, 0, r.groups*r.virtualNodes)
+	for g := 0; g < r.groups; g++ {
+		name := r.GroupName(g)
+		for v := 0; v < r.virtualNodes; v++ {
+			points = append(points, point{hash: hashKey(name + "#" + strconv.Itoa(v)), owner: g})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash == points[j].hash {
+			return points[i].owner < points[j].owner
+		}
+		return points[i].hash < points[j].hash
+	})
+	r.ring = make([]uint64, len(points))
+	r.owners = make([]int, len(points))
+	for i, p := range points {
+		r.ring[i] = p.hash
+		r.owners[i] = p.owner
+	}
+}
+
+// Groups returns the number of message groups.
+func (r *GroupRouter) Groups() int {
+	return r.groups
+}
+
+// GroupName returns the message group name for index i.
+func (r *GroupRouter) GroupName(i int) string {
+	return r.prefix + "-" + strconv.Itoa(i)
+}
+
+// Index returns the group index entityID is routed to.
+func (r *GroupRouter) Index(entityID string) int {
+	h := hashKey(entityID)
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i] >= h })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+// Group returns the message group name entityID is routed to.
+func (r *GroupRouter) Group(entityID string) string {
+	return r.GroupName(r.Index(entityID))
+}
+
+// Assign sets the message group of msg from entityID and returns msg.
+// entityID is also added to the message keys so the message can be looked up by it.
+func (r *GroupRouter) Assign(msg *Message, entityID string) *Message {
+	msg.MessageGroup = r.Group(entityID)
+	for _, k := range msg.Keys {
+		if k == entityID {
+			return msg
+		}
+	}
+	msg.Keys = append(msg.Keys, entityID)
+	return msg
+}
+
+// Resize returns a router with the same prefix and virtual nodes but a new group count.
+func (r *GroupRouter) Resize(groups int) *GroupRouter {
+	return NewGroupRouter(r.prefix, groups, WithVirtualNodes(r.virtualNodes))
+}
+
+// Move describes an entity whose message group changes after a rebalance.
+type Move struct {
+	EntityID string
+	From     string
+	To       string
+}
+
+// Rebalance reports which of entityIDs change group when switching from one
+// router to another. Drain in-flight messages of the moved entities before
+// switching producers, otherwise ordering across the switch is not guaranteed.
+func Rebalance(from, to *GroupRouter, entityIDs []string) []Move {
+	var moves []Move
+	for _, id := range entityIDs {
+		f, t := from.Group(id), to.Group(id)
+		if f != t {
+			moves = append(moves, Move{EntityID: id, From: f, To: t})
+		}
+	}
+	return moves
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return mix64(h.Sum64())
+}
+
+// mix64 finalizes a hash so that keys differing only in trailing characters
+// spread over the ring (fnv alone clusters such keys).
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,73 @@
+package rocketmq
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entityIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = "order-" + strconv.Itoa(i)
+	}
+	return ids
+}
+
+func TestGroupRouter_Stable(t *testing.T) {
+	r1 := NewGroupRouter("orders", 8)
+	r2 := NewGroupRouter("orders", 8)
+	for _, id := range entityIDs(1000) {
+		assert.Equal(t, r1.Group(id), r2.Group(id))
+	}
+}
+
+func TestGroupRouter_Distribution(t *testing.T) {
+	r := NewGroupRouter("orders", 8)
+	counts := make(map[string]int)
+	ids := entityIDs(8000)
+	for _, id := range ids {
+		counts[r.Group(id)]++
+	}
+	require.Len(t, counts, 8)
+	for g, c := range counts {
+		assert.InDelta(t, 1000, c, 350, "group %s", g)
+	}
+}
+
+func TestGroupRouter_InvalidGroups(t *testing.T) {
+	r := NewGroupRouter("orders", 0)
+	assert.Equal(t, 1, r.Groups())
+	assert.Equal(t, "orders-0", r.Group("anything"))
+}
+
+func TestGroupRouter_Assign(t *testing.T) {
+	r := NewGroupRouter("orders", 4)
+
+	msg := r.Assign(&Message{Topic: "t", Keys: []string{"k"}}, "order-1")
+	assert.Equal(t, r.Group("order-1"), msg.MessageGroup)
+	assert.Equal(t, []string{"k", "order-1"}, msg.Keys)
+
+	msg = r.Assign(&Message{Topic: "t", Keys: []string{"order-1"}}, "order-1")
+	assert.Equal(t, []string{"order-1"}, msg.Keys)
+}
+
+func TestRebalance(t *testing.T) {
+	from := NewGroupRouter("orders", 8)
+	to := from.Resize(9)
+	require.Equal(t, 9, to.Groups())
+
+	ids := entityIDs(9000)
+	moves := Rebalance(from, to, ids)
+
+	// Ideally 1/9 of the entities move, all of them to the new group.
+	assert.InDelta(t, 1000, len(moves), 400)
+	for _, m := range moves {
+		assert.Equal(t, "orders-8", m.To)
+		assert.Equal(t, from.Group(m.EntityID), m.From)
+	}
+
+	assert.Empty(t, Rebalance(from, from.Resize(8), ids))
+}
@@ -0,0 +1,92 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContext propagates spans across messages as W3C traceparent and
+// tracestate properties, whatever the global propagator.
+var traceContext propagation.TextMapPropagator = propagation.TraceContext{}
+
+// tracer emits the messaging spans to the global OpenTelemetry tracer
+// provider.
+var tracer = otel.Tracer("github.com/go-kratos/kratos-layout/pkg/rocketmq")
+
+// startPublish starts the producer span of sending msg and injects it into
+// the message properties, so the consumer span becomes its child.
+func startPublish(ctx context.Context, msg *rmq.Message) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rocketmq"),
+			attribute.String("messaging.destination.name", msg.Topic),
+		))
+	traceContext.Inject(ctx, propagation.MapCarrier(msg.GetProperties()))
+	return ctx, span
+}
+
+// endPublish ends the span of startPublish with the result of the send.
+func endPublish(span trace.Span, receipt *SendReceipt, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if receipt != nil {
+		span.SetAttributes(attribute.String("messaging.message.id", receipt.MessageID))
+	}
+	span.End()
+}
+
+// messageProperties returns the properties of msg; a variable so that tests
+// can set the properties of a MessageView.
+var messageProperties = (*MessageView).GetProperties
+
+// handled holds the context of the messages being handled, for
+// MessageContext.
+var handled sync.Map // *MessageView -> context.Context
+
+// MessageContext returns the context of a message being handled, carrying
+// the consumer span started by Tracing, e.g. to pass to the calls of a
+// handler so they join the trace of the producer. It is
+// context.Background() for other messages.
+func MessageContext(msg *MessageView) context.Context {
+	if ctx, ok := handled.Load(msg); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// Tracing starts a consumer span for every message, a child of the span
+// that sent it when the message carries a traceparent property. Consumers
+// created by this package run their handlers through it.
+func Tracing() ConsumerMiddleware {
+	return func(h MessageHandler) MessageHandler {
+		return func(msg *MessageView) ConsumerResult {
+			ctx := traceContext.Extract(context.Background(), propagation.MapCarrier(messageProperties(msg)))
+			ctx, span := tracer.Start(ctx, msg.GetTopic()+" process",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.system", "rocketmq"),
+					attribute.String("messaging.destination.name", msg.GetTopic()),
+					attribute.String("messaging.message.id", msg.GetMessageId()),
+					attribute.Int("messaging.rocketmq.message.delivery_attempt", int(msg.GetDeliveryAttempt())),
+				))
+			defer span.End()
+			handled.Store(msg, ctx)
+			defer handled.Delete(msg)
+
+			result := h(msg)
+			if result != ConsumeSuccess {
+				span.SetStatus(codes.Error, "consume failure")
+			}
+			return result
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package rocketmq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger)}
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	_, err := p.SendMessage(ctx, &Message{Topic: "orders", Body: []byte("x")})
+	require.NoError(t, err)
+	require.Len(t, f.sent, 1)
+	properties := f.sent[0].GetProperties()
+	assert.Equal(t, "00-01020300000000000000000000000000-0405060000000000-01", properties["traceparent"])
+
+	messageProperties = func(*MessageView) map[string]string { return properties }
+	t.Cleanup(func() { messageProperties = (*MessageView).GetProperties })
+	msg := &MessageView{}
+	var handlerCtx context.Context
+	h := wrapHandler(func(msg *MessageView) ConsumerResult {
+		handlerCtx = MessageContext(msg)
+		return ConsumeSuccess
+	}, log.DefaultLogger)
+	assert.Equal(t, ConsumeSuccess, h(msg))
+	assert.Equal(t, parent.TraceID(), trace.SpanContextFromContext(handlerCtx).TraceID(), "the handler joins the producer trace")
+	assert.Equal(t, context.Background(), MessageContext(msg), "forgotten once handled")
+}
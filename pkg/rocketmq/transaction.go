@@ -0,0 +1,125 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// TransactionResolution is the outcome of a transactional message.
+type TransactionResolution = rmq.TransactionResolution
+
+// TransactionResolution constants.
+const (
+	TransactionCommit   = rmq.COMMIT
+	TransactionRollback = rmq.ROLLBACK
+	// TransactionUnknown asks the broker to check again later.
+	TransactionUnknown = rmq.UNKNOWN
+)
+
+// TransactionChecker resolves a half message whose local transaction
+// outcome the broker does not know, e.g. because the process stopped
+// between the local commit and the message commit. It should look up the
+// local state by the message keys: commit when the local transaction
+// committed, roll back when it did not happen, and return
+// TransactionUnknown while it is still running.
+type TransactionChecker func(msg *MessageView) TransactionResolution
+
+// TransactionProducer sends transactional messages, which consumers only
+// see once the local transaction they belong to committed.
+type TransactionProducer struct {
+	client rmq.Producer
+	log    *log.Helper
+	cfg    *Config
+}
+
+// NewTransactionProducer creates a new RocketMQ v5 producer of
+// transactional messages. topics must be TRANSACTION topics.
+func NewTransactionProducer(cfg *Config, topics []string, checker TransactionChecker, logger log.Logger) (*TransactionProducer, func(), error) {
+	if checker == nil {
+		return nil, nil, fmt.Errorf("create rocketmq transaction producer: checker is required")
+	}
+	logHelper := log.NewHelper(log.With(logger, "module", "pkg/rocketmq"))
+	connect, _, err := cfg.connect()
+	if err != nil {
+		logHelper.Warnf("%v, connecting to %s", err, connect.target())
+	}
+	p, cleanup, err := startProducer(connect, topics, logHelper, rmq.WithTransactionChecker(&rmq.TransactionChecker{
+		Check: func(msg *MessageView) TransactionResolution {
+			resolution := checker(msg)
+			logHelper.Infof("checked transaction of %s, msgId=%s: %s", msg.GetTopic(), msg.GetMessageId(), resolutionName(resolution))
+			return resolution
+		},
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TransactionProducer{
+		client: p,
+		log:    logHelper,
+		cfg:    cfg,
+	}, cleanup, nil
+}
+
+// SendInTransaction sends msg as a half message, invisible to consumers,
+// runs localTx, e.g. a database transaction, and then commits msg, or rolls
+// it back and returns the error when localTx fails or panics. localTx does
+// not run when the half message cannot be sent.
+//
+// A failed commit is only logged: the local transaction is done, and the
+// broker resolves the message with the TransactionChecker.
+func (p *TransactionProducer) SendInTransaction(ctx context.Context, msg *Message, localTx func() error) (*SendReceipt, error) {
+	tx := p.client.BeginTransaction()
+	m := msg.toRMQ()
+	ctx, span := startPublish(ctx, m)
+	receipts, err := p.client.SendWithTransaction(ctx, m, tx)
+	if err != nil {
+		p.log.WithContext(ctx).Errorf("send half message to %s failed: %v", msg.Topic, err)
+		endPublish(span, nil, err)
+		return nil, fmt.Errorf("send half message: %w", err)
+	}
+	if len(receipts) == 0 {
+		err := fmt.Errorf("send half message: no receipt returned")
+		endPublish(span, nil, err)
+		return nil, err
+	}
+	receipt := &SendReceipt{
+		MessageID:     receipts[0].MessageID,
+		TransactionID: receipts[0].TransactionId,
+		Offset:        receipts[0].Offset,
+	}
+	endPublish(span, receipt, nil)
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if err := tx.RollBack(); err != nil {
+			p.log.WithContext(ctx).Errorf("roll back message %s: %v", receipt.MessageID, err)
+		}
+	}()
+	if err := localTx(); err != nil {
+		return nil, err
+	}
+	committed = true
+	if err := tx.Commit(); err != nil {
+		p.log.WithContext(ctx).Warnf("commit message %s, left to the checker: %v", receipt.MessageID, err)
+		return receipt, nil
+	}
+	p.log.WithContext(ctx).Debugf("sent transactional message to %s, msgId=%s", msg.Topic, receipt.MessageID)
+	return receipt, nil
+}
+
+func resolutionName(r TransactionResolution) string {
+	switch r {
+	case TransactionCommit:
+		return "commit"
+	case TransactionRollback:
+		return "rollback"
+	default:
+		return "unknown"
+	}
+}
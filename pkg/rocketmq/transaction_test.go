@@ -0,0 +1,82 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTxProducer records the transactional calls of a TransactionProducer.
+type fakeTxProducer struct {
+	rmq.Producer
+	sendErr   error
+	commitErr error
+	calls     []string
+}
+
+func (f *fakeTxProducer) BeginTransaction() rmq.Transaction { return f }
+
+func (f *fakeTxProducer) SendWithTransaction(_ context.Context, msg *rmq.Message, _ rmq.Transaction) ([]*rmq.SendReceipt, error) {
+	f.calls = append(f.calls, "send "+msg.Topic)
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	return []*rmq.SendReceipt{{MessageID: "m1", TransactionId: "t1"}}, nil
+}
+
+func (f *fakeTxProducer) Commit() error {
+	f.calls = append(f.calls, "commit")
+	return f.commitErr
+}
+
+func (f *fakeTxProducer) RollBack() error {
+	f.calls = append(f.calls, "rollback")
+	return nil
+}
+
+func newFakeTxProducer(f *fakeTxProducer) *TransactionProducer {
+	return &TransactionProducer{client: f, log: log.NewHelper(log.DefaultLogger)}
+}
+
+func TestSendInTransaction(t *testing.T) {
+	ctx := context.Background()
+	msg := &Message{Topic: "orders", Body: []byte("created")}
+
+	f := &fakeTxProducer{}
+	receipt, err := newFakeTxProducer(f).SendInTransaction(ctx, msg, func() error {
+		f.calls = append(f.calls, "local")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &SendReceipt{MessageID: "m1", TransactionID: "t1"}, receipt)
+	assert.Equal(t, []string{"send orders", "local", "commit"}, f.calls)
+
+	f = &fakeTxProducer{}
+	errLocal := errors.New("insert failed")
+	_, err = newFakeTxProducer(f).SendInTransaction(ctx, msg, func() error { return errLocal })
+	assert.ErrorIs(t, err, errLocal)
+	assert.Equal(t, []string{"send orders", "rollback"}, f.calls)
+
+	f = &fakeTxProducer{}
+	assert.Panics(t, func() {
+		_, _ = newFakeTxProducer(f).SendInTransaction(ctx, msg, func() error { panic("boom") })
+	})
+	assert.Equal(t, []string{"send orders", "rollback"}, f.calls, "panics roll back")
+
+	f = &fakeTxProducer{sendErr: errors.New("broker down")}
+	_, err = newFakeTxProducer(f).SendInTransaction(ctx, msg, func() error {
+		t.Fatal("local transaction ran without a half message")
+		return nil
+	})
+	assert.ErrorContains(t, err, "send half message: broker down")
+
+	f = &fakeTxProducer{commitErr: errors.New("timeout")}
+	receipt, err = newFakeTxProducer(f).SendInTransaction(ctx, msg, func() error { return nil })
+	require.NoError(t, err, "the checker resolves failed commits")
+	assert.Equal(t, "m1", receipt.MessageID)
+}
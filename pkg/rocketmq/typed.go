@@ -0,0 +1,134 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+// ContentTypeProperty is the message property naming the codec of a typed
+// message body, e.g. application/json.
+const ContentTypeProperty = "content-type"
+
+// ContentType returns the content type of bodies encoded with c.
+func ContentType(c codec.Codec) string {
+	return "application/" + c.Name()
+}
+
+// TypedProducer sends values of T to a topic, encoded with a codec.
+type TypedProducer[T any] struct {
+	producer *Producer
+	topic    string
+	codec    codec.Codec
+}
+
+// NewTypedProducer creates a producer of T for topic, e.g. with the JSON
+// codec or, for proto messages, the protobuf one.
+func NewTypedProducer[T any](p *Producer, topic string, c codec.Codec) *TypedProducer[T] {
+	return &TypedProducer[T]{producer: p, topic: topic, codec: c}
+}
+
+// Send sends v with keys to look it up.
+func (tp *TypedProducer[T]) Send(ctx context.Context, v T, keys ...string) (*SendReceipt, error) {
+	return tp.SendMessage(ctx, &Message{Keys: keys}, v)
+}
+
+// SendMessage sends v as the body of msg, which sets the keys, tag or
+// message group. An empty msg.Topic is the producer's topic.
+func (tp *TypedProducer[T]) SendMessage(ctx context.Context, msg *Message, v T) (*SendReceipt, error) {
+	body, err := tp.codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s message: %w", tp.codec.Name(), err)
+	}
+	m := *msg
+	m.Body = body
+	if m.Topic == "" {
+		m.Topic = tp.topic
+	}
+	r := m.toRMQ()
+	r.AddProperty(ContentTypeProperty, ContentType(tp.codec))
+	return tp.producer.sendMessage(ctx, r)
+}
+
+// DecodeError is a message body that cannot be decoded: unlike a handler
+// error, redelivering the message does not help.
+type DecodeError struct {
+	ContentType string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s message body: %v", e.ContentType, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// TypedOption configures a TypedHandler.
+type TypedOption func(*typedOptions)
+
+type typedOptions struct {
+	onDecodeError func(msg *MessageView, err *DecodeError) ConsumerResult
+}
+
+// OnDecodeError handles the messages whose body cannot be decoded, e.g. to
+// park them and return ConsumeSuccess. By default they are logged at error
+// level and fail, so they end up dead-lettered, see DeadLetter.
+func OnDecodeError(f func(msg *MessageView, err *DecodeError) ConsumerResult) TypedOption {
+	return func(o *typedOptions) { o.onDecodeError = f }
+}
+
+// TypedHandler decodes message bodies into T and calls h with the
+// MessageContext. Bodies are decoded with the codec of their content-type
+// property, or c for messages without one. A handler error fails the
+// message, which is redelivered.
+func TypedHandler[T any](c codec.Codec, h func(ctx context.Context, msg *MessageView, v T) error, logger log.Logger, opts ...TypedOption) MessageHandler {
+	l := log.NewHelper(log.With(logger, "module", "pkg/rocketmq/consumer"))
+	o := typedOptions{onDecodeError: func(msg *MessageView, err *DecodeError) ConsumerResult {
+		l.Errorw("msg", "undecodable message", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(), "error", err)
+		return ConsumeFailure
+	}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(msg *MessageView) ConsumerResult {
+		v, de := decodeBody[T](msg.GetBody(), msg.GetProperties(), c)
+		if de != nil {
+			return o.onDecodeError(msg, de)
+		}
+		if err := h(MessageContext(msg), msg, v); err != nil {
+			l.Warnw("msg", "handle message", "topic", msg.GetTopic(), "message_id", msg.GetMessageId(),
+				"attempt", msg.GetDeliveryAttempt(), "error", err)
+			return ConsumeFailure
+		}
+		return ConsumeSuccess
+	}
+}
+
+// decodeBody decodes a message body with the given properties into a T,
+// allocating the value when T is a pointer such as a proto message.
+func decodeBody[T any](body []byte, properties map[string]string, fallback codec.Codec) (T, *DecodeError) {
+	var v T
+	c := fallback
+	contentType := ContentType(fallback)
+	if ct := properties[ContentTypeProperty]; ct != "" {
+		contentType = ct
+		var err error
+		if c, err = codec.Get(strings.TrimPrefix(ct, "application/")); err != nil {
+			return v, &DecodeError{ContentType: ct, Err: err}
+		}
+	}
+	target := any(&v)
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		v = reflect.New(t.Elem()).Interface().(T)
+		target = v
+	}
+	if err := c.Unmarshal(body, target); err != nil {
+		return v, &DecodeError{ContentType: contentType, Err: err}
+	}
+	return v, nil
+}
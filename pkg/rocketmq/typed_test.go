@@ -0,0 +1,97 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+type orderCreated struct {
+	ID     string `json:"id"`
+	Amount int64  `json:"amount"`
+}
+
+func mustCodec(t *testing.T, name string) codec.Codec {
+	t.Helper()
+	c, err := codec.Get(name)
+	require.NoError(t, err)
+	return c
+}
+
+func TestTypedProducer(t *testing.T) {
+	f := &fakeProducer{}
+	p := &Producer{client: f, log: log.NewHelper(log.DefaultLogger)}
+	ctx := context.Background()
+	jsonCodec, protoCodec := mustCodec(t, codec.JSON), mustCodec(t, codec.Protobuf)
+
+	orders := NewTypedProducer[orderCreated](p, "orders", jsonCodec)
+	_, err := orders.Send(ctx, orderCreated{ID: "o-1", Amount: 1250}, "o-1")
+	require.NoError(t, err)
+	timeouts := NewTypedProducer[*durationpb.Duration](p, "timeouts", protoCodec)
+	_, err = timeouts.SendMessage(ctx, &Message{Tag: "set"}, durationpb.New(90*time.Second))
+	require.NoError(t, err)
+
+	require.Len(t, f.sent, 2)
+	assert.Equal(t, "orders", f.sent[0].Topic)
+	assert.Equal(t, []string{"o-1"}, f.sent[0].GetKeys())
+	assert.Equal(t, "application/json", f.sent[0].GetProperties()[ContentTypeProperty])
+	assert.Equal(t, "set", *f.sent[1].GetTag())
+	assert.Equal(t, "application/protobuf", f.sent[1].GetProperties()[ContentTypeProperty])
+
+	order, de := decodeBody[orderCreated](f.sent[0].Body, f.sent[0].GetProperties(), protoCodec)
+	require.Nil(t, de, "the content type wins over the fallback codec")
+	assert.Equal(t, orderCreated{ID: "o-1", Amount: 1250}, order)
+	d, de := decodeBody[*durationpb.Duration](f.sent[1].Body, f.sent[1].GetProperties(), jsonCodec)
+	require.Nil(t, de)
+	assert.True(t, proto.Equal(durationpb.New(90*time.Second), d))
+
+	_, de = decodeBody[orderCreated](f.sent[0].Body, map[string]string{ContentTypeProperty: "application/avro"}, jsonCodec)
+	require.NotNil(t, de)
+	assert.Equal(t, "application/avro", de.ContentType)
+
+	bad := NewTypedProducer[orderCreated](p, "orders", protoCodec)
+	_, err = bad.Send(ctx, orderCreated{ID: "o-2"})
+	assert.ErrorContains(t, err, "encode protobuf message")
+	assert.Len(t, f.sent, 2)
+}
+
+func TestTypedHandler(t *testing.T) {
+	var got *durationpb.Duration
+	fail := false
+	h := TypedHandler(mustCodec(t, codec.Protobuf), func(_ context.Context, _ *MessageView, d *durationpb.Duration) error {
+		got = d
+		if fail {
+			return errors.New("db down")
+		}
+		return nil
+	}, log.DefaultLogger)
+
+	msg := &MessageView{}
+	assert.Equal(t, ConsumeSuccess, h(msg), "falls back to the handler codec")
+	assert.NotNil(t, got, "proto pointers are allocated")
+	fail = true
+	assert.Equal(t, ConsumeFailure, h(msg))
+
+	var decodeErr *DecodeError
+	called := false
+	h = TypedHandler(mustCodec(t, codec.JSON), func(context.Context, *MessageView, orderCreated) error {
+		called = true
+		return nil
+	}, log.DefaultLogger, OnDecodeError(func(_ *MessageView, err *DecodeError) ConsumerResult {
+		decodeErr = err
+		return ConsumeSuccess
+	}))
+	assert.Equal(t, ConsumeSuccess, h(msg), "decode errors go to OnDecodeError")
+	assert.False(t, called)
+	require.NotNil(t, decodeErr)
+	assert.Equal(t, "application/json", decodeErr.ContentType)
+}
@@ -0,0 +1,190 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// interruptCheckFrequency is how many comprehension iterations run between
+// checks of the context of an evaluation.
+const interruptCheckFrequency = 100
+
+// Option configures an Engine.
+type Option func(*options)
+
+type options struct {
+	input      string
+	envOptions []cel.EnvOption
+}
+
+// WithInputName names the variable holding the input in expressions,
+// "input" by default, e.g. "order" for "order.total >= 100".
+func WithInputName(name string) Option {
+	return func(o *options) { o.input = name }
+}
+
+// WithEnvOptions adds CEL environment options, e.g. ext.Strings() or
+// custom functions.
+func WithEnvOptions(opts ...cel.EnvOption) Option {
+	return func(o *options) { o.envOptions = append(o.envOptions, opts...) }
+}
+
+// Engine evaluates the rules of a Set against inputs of the struct type In.
+// Expressions see the input as one variable, its fields named by their
+// json tags, and the evaluation time as now, e.g.
+// "input.total >= 100 && now.getHours() < 12".
+type Engine[In any] struct {
+	set  *Set
+	env  *cel.Env
+	opts options
+	log  *log.Helper
+
+	mu       sync.Mutex // serializes compilation
+	programs atomic.Pointer[programs]
+}
+
+// programs are the compiled rules of a version of a Set.
+type programs struct {
+	version uint64
+	byName  map[string]program
+}
+
+type program struct {
+	expr string // the current expression of the rule
+	prg  cel.Program
+	err  error // of compiling expr when there is no program
+}
+
+// New creates an engine of the rules of set. Rules are compiled on first
+// use and again when set changes; a rule that no longer compiles keeps its
+// previous program, while one that never compiled fails its evaluations.
+func New[In any](set *Set, logger log.Logger, opts ...Option) (*Engine[In], error) {
+	o := options{input: "input"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t := reflect.TypeFor[In]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("rules: input %v is not a struct", t)
+	}
+	env, err := cel.NewEnv(append([]cel.EnvOption{
+		ext.NativeTypes(t, ext.ParseStructTag("json")),
+		cel.Variable(o.input, cel.ObjectType(path.Base(t.PkgPath())+"."+t.Name())),
+		cel.Variable("now", cel.TimestampType),
+	}, o.envOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("rules: environment: %w", err)
+	}
+	return &Engine[In]{
+		set:  set,
+		env:  env,
+		opts: o,
+		log:  log.NewHelper(log.With(logger, "module", "pkg/rules")),
+	}, nil
+}
+
+// Validate compiles expr as a rule would be, e.g. to check it before
+// saving it to the rules table.
+func (e *Engine[In]) Validate(expr string) error {
+	_, err := e.compile(expr)
+	return err
+}
+
+func (e *Engine[In]) compile(expr string) (cel.Program, error) {
+	ast, iss := e.env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	return e.env.Program(ast, cel.InterruptCheckFrequency(interruptCheckFrequency))
+}
+
+// program returns the program of rule name, compiling the rules of the set
+// if they changed since last time.
+func (e *Engine[In]) program(name string) (program, bool) {
+	exprs, version := e.set.snapshot()
+	cur := e.programs.Load()
+	if cur == nil || cur.version != version {
+		cur = e.recompile(exprs, version)
+	}
+	p, ok := cur.byName[name]
+	return p, ok
+}
+
+func (e *Engine[In]) recompile(exprs map[string]string, version uint64) *programs {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	prev := e.programs.Load()
+	if prev != nil && prev.version == version {
+		return prev
+	}
+	next := &programs{version: version, byName: make(map[string]program, len(exprs))}
+	for name, expr := range exprs {
+		old, ok := program{}, false
+		if prev != nil {
+			old, ok = prev.byName[name]
+		}
+		if ok && old.expr == expr {
+			next.byName[name] = old
+			continue
+		}
+		prg, err := e.compile(expr)
+		if err != nil {
+			e.log.Errorw("msg", "compile rule", "rule", name, "expression", expr, "error", err)
+			if ok && old.prg != nil {
+				// Keep the program of the previous expression.
+				next.byName[name] = program{expr: expr, prg: old.prg}
+				continue
+			}
+			next.byName[name] = program{expr: expr, err: err}
+			continue
+		}
+		next.byName[name] = program{expr: expr, prg: prg}
+	}
+	e.programs.Store(next)
+	return next
+}
+
+// Match evaluates a boolean rule, e.g. an eligibility check.
+func (e *Engine[In]) Match(ctx context.Context, name string, in In) (bool, error) {
+	return Eval[bool](ctx, e, name, in)
+}
+
+// Eval evaluates rule name against in and converts its result to Out, e.g.
+// float64 for a price or string for a route.
+func Eval[Out, In any](ctx context.Context, e *Engine[In], name string, in In) (Out, error) {
+	var zero Out
+	p, ok := e.program(name)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrUnknownRule, name)
+	}
+	if p.prg == nil {
+		return zero, fmt.Errorf("rules: %s: %w", name, p.err)
+	}
+	val, _, err := p.prg.ContextEval(ctx, map[string]any{e.opts.input: in, "now": time.Now()})
+	if err != nil {
+		return zero, fmt.Errorf("rules: %s: %w", name, err)
+	}
+	t := reflect.TypeFor[Out]()
+	if t.Kind() == reflect.Interface {
+		if out, ok := val.Value().(Out); ok {
+			return out, nil
+		}
+	}
+	native, err := val.ConvertToNative(t)
+	if err != nil {
+		return zero, fmt.Errorf("rules: %s: result %v: %w", name, val.Type(), err)
+	}
+	return native.(Out), nil
+}
@@ -0,0 +1,142 @@
+// Package rules evaluates business rules written as CEL expressions, e.g.
+// "order.total >= 100 && order.country == 'CN'", so that pricing,
+// eligibility and routing decisions change without a redeploy.
+//
+// A Set holds the rules loaded from a Source, the config, the rules table
+// or both, and reloads them with Reload. An Engine compiles them once
+// against an input type and recompiles them when the Set changes.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownRule is returned when evaluating a rule that is not defined.
+var ErrUnknownRule = errors.New("rules: unknown rule")
+
+// Rule is a named expression.
+type Rule struct {
+	Name        string `gorm:"primaryKey;size:128"`
+	Expression  string `gorm:"type:text;not null"`
+	Description string `gorm:"size:255"`
+	UpdatedAt   time.Time
+}
+
+// TableName implements gorm's tabler.
+func (Rule) TableName() string { return "rules" }
+
+// Source loads rules.
+type Source interface {
+	Load(ctx context.Context) ([]Rule, error)
+}
+
+// SourceFunc is a function Source.
+type SourceFunc func(ctx context.Context) ([]Rule, error)
+
+// Load implements Source.
+func (f SourceFunc) Load(ctx context.Context) ([]Rule, error) { return f(ctx) }
+
+// Static returns a Source of fixed rules, e.g. from the config.
+func Static(rules ...Rule) Source {
+	return SourceFunc(func(context.Context) ([]Rule, error) { return rules, nil })
+}
+
+// FromDB returns a Source reading the rules table of the database of db.
+func FromDB(db func(ctx context.Context) *gorm.DB) Source {
+	return SourceFunc(func(ctx context.Context) ([]Rule, error) {
+		var rules []Rule
+		if err := db(ctx).Order("name").Find(&rules).Error; err != nil {
+			return nil, fmt.Errorf("rules: load: %w", err)
+		}
+		return rules, nil
+	})
+}
+
+// Layered returns a Source merging sources, the rules of later sources
+// replacing those of the same name, e.g. defaults from the config
+// overridden in the database.
+func Layered(sources ...Source) Source {
+	return SourceFunc(func(ctx context.Context) ([]Rule, error) {
+		byName := make(map[string]Rule)
+		var order []string
+		for _, src := range sources {
+			rules, err := src.Load(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rules {
+				if _, ok := byName[r.Name]; !ok {
+					order = append(order, r.Name)
+				}
+				byName[r.Name] = r
+			}
+		}
+		merged := make([]Rule, 0, len(order))
+		for _, name := range order {
+			merged = append(merged, byName[name])
+		}
+		return merged, nil
+	})
+}
+
+// Set holds the current expressions of the rules of a Source by name.
+type Set struct {
+	src Source
+
+	mu      sync.Mutex // serializes Reload
+	rules   atomic.Pointer[map[string]string]
+	version atomic.Uint64
+}
+
+// NewSet creates a set of the rules of src, empty until Reload.
+func NewSet(src Source) *Set {
+	s := &Set{src: src}
+	s.rules.Store(&map[string]string{})
+	return s
+}
+
+// Reload loads the rules of the source and reports whether they changed.
+// On error the current rules are kept.
+func (s *Set) Reload(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules, err := s.src.Load(ctx)
+	if err != nil {
+		return false, err
+	}
+	next := make(map[string]string, len(rules))
+	for _, r := range rules {
+		if r.Name == "" {
+			return false, errors.New("rules: rule without a name")
+		}
+		next[r.Name] = r.Expression
+	}
+	if maps.Equal(next, *s.rules.Load()) {
+		return false, nil
+	}
+	s.rules.Store(&next)
+	s.version.Add(1)
+	return true, nil
+}
+
+// Expressions returns the current expressions by rule name.
+func (s *Set) Expressions() map[string]string {
+	return maps.Clone(*s.rules.Load())
+}
+
+// snapshot returns the current expressions and their version, which
+// changes whenever they do.
+func (s *Set) snapshot() (map[string]string, uint64) {
+	// The version is read first, so a concurrent Reload makes it stale
+	// rather than the expressions.
+	v := s.version.Load()
+	return *s.rules.Load(), v
+}
@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+)
+
+type order struct {
+	Total   float64  `json:"total"`
+	Country string   `json:"country"`
+	Tags    []string `json:"tags"`
+}
+
+func TestEngine(t *testing.T) {
+	set := NewSet(Static(
+		Rule{Name: "free_shipping", Expression: "order.total >= 100.0 && order.country == 'CN'"},
+		Rule{Name: "price", Expression: "'vip' in order.tags ? order.total * 0.9 : order.total"},
+		Rule{Name: "broken", Expression: "order.missing >"},
+	))
+	_, err := set.Reload(context.Background())
+	require.NoError(t, err)
+	e, err := New[order](set, log.DefaultLogger, WithInputName("order"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ok, err := e.Match(ctx, "free_shipping", order{Total: 120, Country: "CN"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = e.Match(ctx, "free_shipping", order{Total: 120, Country: "US"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	price, err := Eval[float64](ctx, e, "price", order{Total: 200, Tags: []string{"vip"}})
+	require.NoError(t, err)
+	assert.InDelta(t, 180, price, 1e-9)
+	v, err := Eval[any](ctx, e, "price", order{Total: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, v)
+
+	_, err = Eval[string](ctx, e, "price", order{})
+	assert.Error(t, err, "result of another type")
+	_, err = e.Match(ctx, "broken", order{})
+	assert.Error(t, err, "never compiled")
+	_, err = e.Match(ctx, "missing", order{})
+	assert.ErrorIs(t, err, ErrUnknownRule)
+
+	assert.NoError(t, e.Validate("order.total > 1.0"))
+	assert.Error(t, e.Validate("order.nope"))
+	assert.Error(t, e.Validate("order.total +"))
+
+	_, err = New[string](set, log.DefaultLogger)
+	assert.Error(t, err, "inputs are structs")
+}
+
+func TestEngine_Reload(t *testing.T) {
+	expr := "input.total > 10.0"
+	set := NewSet(SourceFunc(func(context.Context) ([]Rule, error) {
+		return []Rule{{Name: "big", Expression: expr}}, nil
+	}))
+	e, err := New[*order](set, log.DefaultLogger)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = e.Match(ctx, "big", &order{Total: 20})
+	assert.ErrorIs(t, err, ErrUnknownRule, "empty until reloaded")
+
+	changed, err := set.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	ok, err := e.Match(ctx, "big", &order{Total: 20})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	changed, err = set.Reload(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	expr = "input.total > 50.0"
+	_, err = set.Reload(ctx)
+	require.NoError(t, err)
+	ok, err = e.Match(ctx, "big", &order{Total: 20})
+	require.NoError(t, err)
+	assert.False(t, ok, "recompiled after the change")
+
+	expr = "input.total >"
+	_, err = set.Reload(ctx)
+	require.NoError(t, err)
+	ok, err = e.Match(ctx, "big", &order{Total: 60})
+	require.NoError(t, err)
+	assert.True(t, ok, "keeps the previous program when the new expression is invalid")
+	assert.Equal(t, map[string]string{"big": "input.total >"}, set.Expressions())
+
+	ok, err = Eval[bool](ctx, mustEngine(t, "now > timestamp('2020-01-01T00:00:00Z')"), "r", order{})
+	require.NoError(t, err)
+	assert.True(t, ok, "now is the evaluation time")
+}
+
+func mustEngine(t *testing.T, expr string) *Engine[order] {
+	set := NewSet(Static(Rule{Name: "r", Expression: expr}))
+	_, err := set.Reload(context.Background())
+	require.NoError(t, err)
+	e, err := New[order](set, log.DefaultLogger)
+	require.NoError(t, err)
+	return e
+}
+
+func TestLayeredFromDB(t *testing.T) {
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&Rule{}))
+	require.NoError(t, db.Create(&Rule{Name: "limit", Expression: "input.total < 500.0", UpdatedAt: time.Now()}).Error)
+
+	src := Layered(Static(
+		Rule{Name: "limit", Expression: "input.total < 100.0"},
+		Rule{Name: "eligible", Expression: "true"},
+	), FromDB(db.WithContext))
+	rules, err := src.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "limit", rules[0].Name)
+	assert.Equal(t, "input.total < 500.0", rules[0].Expression, "the database overrides the config")
+	assert.Equal(t, "eligible", rules[1].Name)
+
+	set := NewSet(Layered(src, SourceFunc(func(context.Context) ([]Rule, error) { return nil, errors.New("down") })))
+	set.rules.Store(&map[string]string{"kept": "true"})
+	_, err = set.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, map[string]string{"kept": "true"}, set.Expressions(), "kept on error")
+}
@@ -0,0 +1,87 @@
+// Package selftest runs one-off dependency checks and prints a PASS/FAIL
+// report, e.g. as a smoke test after a deployment.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrSkipped marks a check that did not apply, e.g. a dependency compiled
+// out of the binary. Return it wrapped with Skip.
+var ErrSkipped = errors.New("skipped")
+
+// Skip returns an error reporting the check as skipped for reason.
+func Skip(reason string) error {
+	return fmt.Errorf("%w: %s", ErrSkipped, reason)
+}
+
+// Statuses of Result.
+const (
+	StatusPass = "PASS"
+	StatusFail = "FAIL"
+	StatusSkip = "SKIP"
+)
+
+// Check exercises one dependency, e.g. a database round-trip.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a Check.
+type Result struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Err      error
+}
+
+// Run runs checks one after the other, each within timeout, and returns
+// their results in order.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := c.Run(cctx)
+		cancel()
+		r := Result{Name: c.Name, Status: StatusPass, Duration: time.Since(start), Err: err}
+		switch {
+		case errors.Is(err, ErrSkipped):
+			r.Status = StatusSkip
+		case err != nil:
+			r.Status = StatusFail
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Report writes one line per result to w and returns the number of failed
+// checks.
+func Report(w io.Writer, results []Result) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := 0
+	for _, r := range results {
+		detail := ""
+		if r.Err != nil {
+			detail = r.Err.Error()
+		}
+		if r.Status == StatusFail {
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Status, r.Name, r.Duration.Round(time.Millisecond), detail)
+	}
+	tw.Flush()
+	if failed > 0 {
+		fmt.Fprintf(w, "self-test failed: %d of %d checks failed\n", failed, len(results))
+	} else {
+		fmt.Fprintln(w, "self-test passed")
+	}
+	return failed
+}
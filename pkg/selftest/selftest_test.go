@@ -0,0 +1,40 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	results := Run(context.Background(), 20*time.Millisecond,
+		Check{Name: "db", Run: func(context.Context) error { return nil }},
+		Check{Name: "redis", Run: func(context.Context) error { return errors.New("connection refused") }},
+		Check{Name: "nacos", Run: func(context.Context) error { return Skip("compiled out") }},
+		Check{Name: "mq", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+	require.Len(t, results, 4)
+	assert.Equal(t, []string{StatusPass, StatusFail, StatusSkip, StatusFail},
+		[]string{results[0].Status, results[1].Status, results[2].Status, results[3].Status})
+	assert.ErrorIs(t, results[3].Err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, results[3].Duration, 20*time.Millisecond)
+
+	var out bytes.Buffer
+	assert.Equal(t, 2, Report(&out, results))
+	assert.Contains(t, out.String(), "FAIL  redis")
+	assert.Contains(t, out.String(), "connection refused")
+	assert.Contains(t, out.String(), "skipped: compiled out")
+	assert.Contains(t, out.String(), "self-test failed: 2 of 4 checks failed\n")
+
+	out.Reset()
+	assert.Zero(t, Report(&out, results[:1]))
+	assert.Contains(t, out.String(), "self-test passed\n")
+}
@@ -0,0 +1,177 @@
+// Package session keeps user sessions, such as a login or a checkout in
+// progress, shared by the instances of the service: a session is a value
+// of T stored under a random ID handed to the client, valid for a TTL
+// renewed on every read.
+//
+//	carts := session.New[Cart](session.NewRedisBackend(rdb, "session:cart"), c, 30*time.Minute)
+//	id, err := carts.Create(ctx, Cart{UserID: uid})
+//	cart, err := carts.Get(ctx, id) // ErrNotFound once expired
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+// ErrNotFound is returned for an unknown or expired session.
+var ErrNotFound = errors.New("session not found or expired")
+
+// Backend keeps encoded sessions by key. RedisBackend and MemoryBackend
+// implement it.
+type Backend interface {
+	// Get returns the session of key, or ErrNotFound, and renews its ttl.
+	Get(ctx context.Context, key string, ttl time.Duration) ([]byte, error)
+	// Set saves the session of key for ttl.
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// Delete removes the session of key. Deleting an unknown one is a no-op.
+	Delete(ctx context.Context, key string) error
+}
+
+// Store keeps sessions of T in a Backend, encoded with a codec, e.g. the
+// one of codec.UseSession.
+type Store[T any] struct {
+	backend Backend
+	codec   codec.Codec
+	ttl     time.Duration
+}
+
+// New creates a store of sessions valid for ttl since their last use.
+func New[T any](b Backend, c codec.Codec, ttl time.Duration) *Store[T] {
+	return &Store[T]{backend: b, codec: c, ttl: ttl}
+}
+
+// Create saves v as a new session and returns its ID.
+func (s *Store[T]) Create(ctx context.Context, v T) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("session id: %w", err)
+	}
+	id := hex.EncodeToString(b)
+	if err := s.Save(ctx, id, v); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Get returns the session of id, or ErrNotFound.
+func (s *Store[T]) Get(ctx context.Context, id string) (T, error) {
+	var v T
+	data, err := s.backend.Get(ctx, key(id), s.ttl)
+	if err != nil {
+		return v, err
+	}
+	if err := s.codec.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decode %s session: %w", s.codec.Name(), err)
+	}
+	return v, nil
+}
+
+// Save replaces the session of id with v.
+func (s *Store[T]) Save(ctx context.Context, id string, v T) error {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s session: %w", s.codec.Name(), err)
+	}
+	return s.backend.Set(ctx, key(id), data, s.ttl)
+}
+
+// Delete ends the session of id, e.g. on logout.
+func (s *Store[T]) Delete(ctx context.Context, id string) error {
+	return s.backend.Delete(ctx, key(id))
+}
+
+// key hashes a session ID, so stored keys do not reveal sessions.
+func key(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisBackend keeps sessions as Redis strings expiring with them.
+type RedisBackend struct {
+	client func() redis.Cmdable
+	prefix string
+}
+
+// NewRedisBackend creates a Redis backend. client is resolved on every
+// call so the caller may swap the underlying connection. Keys are prefixed
+// with prefix + ":".
+func NewRedisBackend(client func() redis.Cmdable, prefix string) *RedisBackend {
+	return &RedisBackend{client: client, prefix: prefix}
+}
+
+// Get implements Backend.
+func (b *RedisBackend) Get(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	data, err := b.client().GetEx(ctx, b.prefix+":"+key, ttl).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Set implements Backend.
+func (b *RedisBackend) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.client().Set(ctx, b.prefix+":"+key, data, ttl).Err()
+}
+
+// Delete implements Backend.
+func (b *RedisBackend) Delete(ctx context.Context, key string) error {
+	return b.client().Del(ctx, b.prefix+":"+key).Err()
+}
+
+type memorySession struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// MemoryBackend keeps sessions in process memory. It suits tests and single
+// instance development setups; expired sessions are reclaimed when read.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	sessions map[string]memorySession
+	now      func() time.Time
+}
+
+// NewMemoryBackend creates an in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{sessions: make(map[string]memorySession), now: time.Now}
+}
+
+// Get implements Backend.
+func (b *MemoryBackend) Get(_ context.Context, key string, ttl time.Duration) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.sessions[key]
+	if !ok || !b.now().Before(s.expiresAt) {
+		delete(b.sessions, key)
+		return nil, ErrNotFound
+	}
+	s.expiresAt = b.now().Add(ttl)
+	b.sessions[key] = s
+	return s.data, nil
+}
+
+// Set implements Backend.
+func (b *MemoryBackend) Set(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sessions[key] = memorySession{data: data, expiresAt: b.now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Backend.
+func (b *MemoryBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.sessions, key)
+	return nil
+}
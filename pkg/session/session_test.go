@@ -0,0 +1,52 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+type cart struct {
+	UserID string
+	Items  map[string]int
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewMemoryBackend()
+	b.now = func() time.Time { return now }
+	avro, err := codec.Get(codec.Avro)
+	require.NoError(t, err)
+	carts := New[cart](b, avro, 30*time.Minute)
+
+	id, err := carts.Create(ctx, cart{UserID: "u-1"})
+	require.NoError(t, err)
+	assert.Len(t, id, 64)
+	assert.NotContains(t, b.sessions, id, "keys are hashed")
+
+	require.NoError(t, carts.Save(ctx, id, cart{UserID: "u-1", Items: map[string]int{"sku-1": 2}}))
+	now = now.Add(20 * time.Minute)
+	got, err := carts.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, cart{UserID: "u-1", Items: map[string]int{"sku-1": 2}}, got)
+
+	now = now.Add(20 * time.Minute)
+	_, err = carts.Get(ctx, id)
+	require.NoError(t, err, "reads renew the ttl")
+
+	now = now.Add(31 * time.Minute)
+	_, err = carts.Get(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	id, err = carts.Create(ctx, cart{UserID: "u-2"})
+	require.NoError(t, err)
+	require.NoError(t, carts.Delete(ctx, id))
+	_, err = carts.Get(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
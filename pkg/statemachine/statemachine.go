@@ -0,0 +1,381 @@
+// Package statemachine persists the lifecycle of entities such as orders or
+// workflows as state machines: a Definition lists the states, the
+// transitions between them with their guards and actions, and timeouts
+// firing an event on instances left in a state for too long.
+//
+// Instances live in the state_machines table, one row per machine and
+// entity. Transitions are optimistic: a transition of an instance that was
+// changed since it was loaded fails with orm.ErrStaleObject.
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+// ErrInvalidTransition is returned when an event has no transition from the
+// current state of an instance.
+var ErrInvalidTransition = errors.New("statemachine: invalid transition")
+
+// DefaultTimeoutBatch is the number of timeouts a machine fires per call
+// of FireTimeouts by default.
+const DefaultTimeoutBatch = 100
+
+// State is a state of a machine, e.g. "pending".
+type State string
+
+// Event triggers transitions, e.g. "pay".
+type Event string
+
+// Transition moves an instance in one of From to To on Event.
+type Transition struct {
+	Event Event
+	From  []State
+	To    State
+	// Guard vetoes the transition by returning an error, which Fire returns.
+	Guard func(ctx context.Context, inst *Instance) error
+	// Action runs after the new state is saved, e.g. to release the stock
+	// of an expired order. With WithTx it runs in the transaction of the
+	// change, and its error rolls the change back.
+	Action func(ctx context.Context, inst *Instance, from State) error
+}
+
+// Timeout fires Event on instances that stayed in State for After. The
+// event needs a transition from State.
+type Timeout struct {
+	State State
+	After time.Duration
+	Event Event
+}
+
+// Definition describes a machine.
+type Definition struct {
+	// Name identifies the machine in the state_machines table.
+	Name        string
+	Initial     State
+	Transitions []Transition
+	Timeouts    []Timeout
+}
+
+// Instance is the persisted state of one entity.
+type Instance struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"`
+	Machine   string `gorm:"size:64;not null;uniqueIndex:uk_state_machines_entity;index:idx_state_machines_expires_at,priority:1"`
+	EntityID  string `gorm:"size:128;not null;uniqueIndex:uk_state_machines_entity"`
+	State     State  `gorm:"size:64;not null"`
+	EnteredAt time.Time
+	// ExpiresAt is when the timeout of State fires; nil without one.
+	ExpiresAt *time.Time `gorm:"index:idx_state_machines_expires_at,priority:2"`
+	Version   orm.Version
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName implements gorm's tabler.
+func (Instance) TableName() string { return "state_machines" }
+
+// DBFunc returns the database session for ctx. Pass a transaction-aware
+// accessor (such as data.Data.DB) so machines join the caller's transaction.
+type DBFunc func(ctx context.Context) *gorm.DB
+
+// TxFunc runs fn in a transaction carried by ctx, such as data.Data.InTx.
+type TxFunc func(ctx context.Context, fn func(ctx context.Context) error) error
+
+// Option configures a Machine.
+type Option func(*options)
+
+type options struct {
+	inTx TxFunc
+	now  func() time.Time
+}
+
+// WithTx runs every transition and its Action in a transaction of fn.
+// Without it Action runs after the state change was saved.
+func WithTx(fn TxFunc) Option {
+	return func(o *options) { o.inTx = fn }
+}
+
+// WithClock sets the clock of entry times and timeouts, e.g. a fake one
+// in tests. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+type transitionKey struct {
+	from  State
+	event Event
+}
+
+// Machine runs the transitions of a Definition on persisted instances.
+type Machine struct {
+	name        string
+	initial     State
+	transitions map[transitionKey]*Transition
+	timeouts    map[State]Timeout
+	db          DBFunc
+	opts        options
+}
+
+// New validates def and returns its machine.
+func New(def Definition, db DBFunc, opts ...Option) (*Machine, error) {
+	o := options{
+		inTx: func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) },
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if def.Name == "" || def.Initial == "" {
+		return nil, errors.New("statemachine: name and initial state are required")
+	}
+	m := &Machine{
+		name:        def.Name,
+		initial:     def.Initial,
+		transitions: make(map[transitionKey]*Transition),
+		timeouts:    make(map[State]Timeout),
+		db:          db,
+		opts:        o,
+	}
+	for i := range def.Transitions {
+		t := &def.Transitions[i]
+		if t.Event == "" || t.To == "" || len(t.From) == 0 {
+			return nil, fmt.Errorf("statemachine %s: transition %d needs an event, a source and a target", def.Name, i)
+		}
+		for _, from := range t.From {
+			k := transitionKey{from, t.Event}
+			if _, ok := m.transitions[k]; ok {
+				return nil, fmt.Errorf("statemachine %s: two transitions on %s from %s", def.Name, t.Event, from)
+			}
+			m.transitions[k] = t
+		}
+	}
+	for _, to := range def.Timeouts {
+		if to.After <= 0 {
+			return nil, fmt.Errorf("statemachine %s: timeout of %s must be positive", def.Name, to.State)
+		}
+		if _, ok := m.timeouts[to.State]; ok {
+			return nil, fmt.Errorf("statemachine %s: two timeouts of %s", def.Name, to.State)
+		}
+		if _, ok := m.transitions[transitionKey{to.State, to.Event}]; !ok {
+			return nil, fmt.Errorf("statemachine %s: timeout event %s has no transition from %s", def.Name, to.Event, to.State)
+		}
+		m.timeouts[to.State] = to
+	}
+	return m, nil
+}
+
+// Name returns the name of the machine.
+func (m *Machine) Name() string { return m.name }
+
+// Can reports whether event has a transition from state. Guards are not run.
+func (m *Machine) Can(state State, event Event) bool {
+	_, ok := m.transitions[transitionKey{state, event}]
+	return ok
+}
+
+// expiry returns when the timeout of state fires after entering it at t.
+func (m *Machine) expiry(state State, t time.Time) *time.Time {
+	to, ok := m.timeouts[state]
+	if !ok {
+		return nil
+	}
+	at := t.Add(to.After)
+	return &at
+}
+
+// Create stores the instance of entityID in the initial state.
+func (m *Machine) Create(ctx context.Context, entityID string) (*Instance, error) {
+	now := m.opts.now()
+	inst := &Instance{
+		Machine:   m.name,
+		EntityID:  entityID,
+		State:     m.initial,
+		EnteredAt: now,
+		ExpiresAt: m.expiry(m.initial, now),
+		Version:   1,
+	}
+	if err := m.db(ctx).Create(inst).Error; err != nil {
+		return nil, fmt.Errorf("create %s %s: %w", m.name, entityID, err)
+	}
+	return inst, nil
+}
+
+// Get loads the instance of entityID; gorm.ErrRecordNotFound when there is
+// none.
+func (m *Machine) Get(ctx context.Context, entityID string) (*Instance, error) {
+	var inst Instance
+	if err := m.db(ctx).Where("machine = ? AND entity_id = ?", m.name, entityID).Take(&inst).Error; err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// Fire runs the transition of event from the current state of entityID and
+// returns the changed instance. It fails with ErrInvalidTransition when
+// there is none, with the error of the guard when it vetoes, and with
+// orm.ErrStaleObject when the instance changed concurrently: reload and
+// decide again.
+func (m *Machine) Fire(ctx context.Context, entityID string, event Event) (*Instance, error) {
+	var inst *Instance
+	err := m.opts.inTx(ctx, func(ctx context.Context) error {
+		var err error
+		if inst, err = m.Get(ctx, entityID); err != nil {
+			return err
+		}
+		return m.fire(ctx, inst, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// fire moves inst with event, checking it was not changed since loaded.
+func (m *Machine) fire(ctx context.Context, inst *Instance, event Event) error {
+	t, ok := m.transitions[transitionKey{inst.State, event}]
+	if !ok {
+		return fmt.Errorf("%w: %s of %s %s in %s", ErrInvalidTransition, event, m.name, inst.EntityID, inst.State)
+	}
+	if t.Guard != nil {
+		if err := t.Guard(ctx, inst); err != nil {
+			return err
+		}
+	}
+	now := m.opts.now()
+	from, expiresAt := inst.State, m.expiry(t.To, now)
+	res := m.db(ctx).Model(&Instance{}).
+		Where("id = ? AND version = ?", inst.ID, inst.Version).
+		Updates(map[string]any{"state": t.To, "entered_at": now, "expires_at": expiresAt, "version": inst.Version + 1})
+	if res.Error != nil {
+		return fmt.Errorf("save %s %s: %w", m.name, inst.EntityID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return orm.ErrStaleObject
+	}
+	inst.State, inst.EnteredAt, inst.ExpiresAt, inst.Version = t.To, now, expiresAt, inst.Version+1
+	if t.Action != nil {
+		return t.Action(ctx, inst, from)
+	}
+	return nil
+}
+
+// FireTimeouts fires the timeout events of at most limit expired instances,
+// oldest first, DefaultTimeoutBatch when limit is not positive, and returns
+// how many moved. Instances changed meanwhile are
+// skipped. When the transition fails, e.g. its guard vetoes, the timeout
+// is re-armed for another After and the error returned with the others.
+func (m *Machine) FireTimeouts(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		limit = DefaultTimeoutBatch
+	}
+	var due []Instance
+	err := m.db(ctx).Where("machine = ? AND expires_at <= ?", m.name, m.opts.now()).
+		Order("expires_at").Limit(limit).Find(&due).Error
+	if err != nil {
+		return 0, fmt.Errorf("find expired %s: %w", m.name, err)
+	}
+	var (
+		fired int
+		errs  []error
+	)
+	for i := range due {
+		inst := &due[i]
+		to, ok := m.timeouts[inst.State]
+		if !ok {
+			// The definition no longer has a timeout for the state.
+			m.rearm(ctx, inst, nil)
+			continue
+		}
+		loaded := *inst
+		err := m.opts.inTx(ctx, func(ctx context.Context) error { return m.fire(ctx, inst, to.Event) })
+		switch {
+		case err == nil:
+			fired++
+		case errors.Is(err, orm.ErrStaleObject):
+		default:
+			at := m.opts.now().Add(to.After)
+			m.rearm(ctx, &loaded, &at)
+			errs = append(errs, fmt.Errorf("timeout of %s %s in %s: %w", m.name, loaded.EntityID, loaded.State, err))
+		}
+	}
+	return fired, errors.Join(errs...)
+}
+
+// rearm moves the timeout of inst to at, unless inst changed meanwhile.
+func (m *Machine) rearm(ctx context.Context, inst *Instance, at *time.Time) {
+	m.db(ctx).Model(&Instance{}).
+		Where("id = ? AND version = ?", inst.ID, inst.Version).
+		Update("expires_at", at)
+}
+
+// Registry holds the machines of a service so their timeouts can be fired
+// together, e.g. by a job.
+type Registry struct {
+	db   DBFunc
+	opts []Option
+
+	mu       sync.Mutex
+	machines map[string]*Machine
+}
+
+// NewRegistry creates a registry whose machines use db and opts.
+func NewRegistry(db DBFunc, opts ...Option) *Registry {
+	return &Registry{db: db, opts: opts, machines: make(map[string]*Machine)}
+}
+
+// Define creates the machine of def, with extra opts, and registers it.
+func (r *Registry) Define(def Definition, opts ...Option) (*Machine, error) {
+	m, err := New(def, r.db, slices.Concat(r.opts, opts)...)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.machines[m.name]; ok {
+		return nil, fmt.Errorf("statemachine %s is already defined", m.name)
+	}
+	r.machines[m.name] = m
+	return m, nil
+}
+
+// Machine returns the registered machine named name.
+func (r *Registry) Machine(name string) (*Machine, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.machines[name]
+	return m, ok
+}
+
+// FireTimeouts fires the expired timeouts of every machine, at most limit
+// per machine, and returns how many instances moved.
+func (r *Registry) FireTimeouts(ctx context.Context, limit int) (int, error) {
+	r.mu.Lock()
+	machines := make([]*Machine, 0, len(r.machines))
+	for _, m := range r.machines {
+		machines = append(machines, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(machines, func(i, j int) bool { return machines[i].name < machines[j].name })
+
+	var (
+		fired int
+		errs  []error
+	)
+	for _, m := range machines {
+		n, err := m.FireTimeouts(ctx, limit)
+		fired += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return fired, errors.Join(errs...)
+}
@@ -0,0 +1,193 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+	"github.com/go-kratos/kratos-layout/pkg/orm"
+)
+
+type txKey struct{}
+
+// store mimics data.Data: DB joins the transaction InTx stores in ctx.
+type store struct{ db *gorm.DB }
+
+func (s store) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s store) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+type clock struct{ now time.Time }
+
+func newClock() *clock                   { return &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)} }
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newStore(t *testing.T) store {
+	db := ormtest.Open(t)
+	require.NoError(t, db.Use(orm.OptimisticLock{}))
+	require.NoError(t, db.AutoMigrate(&Instance{}, &released{}))
+	return store{db: db}
+}
+
+// released records the orders whose stock was released by an action.
+type released struct {
+	ID      uint64 `gorm:"primaryKey"`
+	OrderID string
+}
+
+var errNotPaid = errors.New("payment not captured")
+
+func orderDefinition(s store) Definition {
+	return Definition{
+		Name:    "order",
+		Initial: "pending",
+		Transitions: []Transition{
+			{Event: "pay", From: []State{"pending"}, To: "paid", Guard: func(_ context.Context, inst *Instance) error {
+				if inst.EntityID == "unpaid" {
+					return errNotPaid
+				}
+				return nil
+			}},
+			{Event: "ship", From: []State{"paid"}, To: "shipped"},
+			{Event: "expire", From: []State{"pending"}, To: "cancelled", Action: func(ctx context.Context, inst *Instance, _ State) error {
+				if inst.EntityID == "broken" {
+					return errors.New("stock service down")
+				}
+				return s.DB(ctx).Create(&released{OrderID: inst.EntityID}).Error
+			}},
+		},
+		Timeouts: []Timeout{{State: "pending", After: 30 * time.Minute, Event: "expire"}},
+	}
+}
+
+func TestMachine(t *testing.T) {
+	ctx := context.Background()
+	s, c := newStore(t), newClock()
+	m, err := New(orderDefinition(s), s.DB, WithTx(s.InTx), WithClock(c.Now))
+	require.NoError(t, err)
+
+	inst, err := m.Create(ctx, "o1")
+	require.NoError(t, err)
+	assert.Equal(t, State("pending"), inst.State)
+	assert.Equal(t, c.now.Add(30*time.Minute), *inst.ExpiresAt)
+
+	_, err = m.Fire(ctx, "o1", "ship")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	c.Advance(time.Minute)
+	inst, err = m.Fire(ctx, "o1", "pay")
+	require.NoError(t, err)
+	assert.Equal(t, State("paid"), inst.State)
+	assert.Equal(t, c.now, inst.EnteredAt)
+	assert.Nil(t, inst.ExpiresAt, "paid orders do not expire")
+	assert.Equal(t, orm.Version(2), inst.Version)
+
+	_, err = m.Create(ctx, "unpaid")
+	require.NoError(t, err)
+	_, err = m.Fire(ctx, "unpaid", "pay")
+	assert.ErrorIs(t, err, errNotPaid)
+	got, err := m.Get(ctx, "unpaid")
+	require.NoError(t, err)
+	assert.Equal(t, State("pending"), got.State, "guards veto transitions")
+
+	_, err = m.Get(ctx, "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestMachine_Stale(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+	m, err := New(orderDefinition(s), s.DB)
+	require.NoError(t, err)
+	_, err = m.Create(ctx, "o1")
+	require.NoError(t, err)
+
+	stale, err := m.Get(ctx, "o1")
+	require.NoError(t, err)
+	_, err = m.Fire(ctx, "o1", "pay")
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.fire(ctx, stale, "expire"), orm.ErrStaleObject)
+
+	got, err := m.Get(ctx, "o1")
+	require.NoError(t, err)
+	assert.Equal(t, State("paid"), got.State)
+}
+
+func TestRegistry_FireTimeouts(t *testing.T) {
+	ctx := context.Background()
+	s, c := newStore(t), newClock()
+	r := NewRegistry(s.DB, WithTx(s.InTx), WithClock(c.Now))
+	m, err := r.Define(orderDefinition(s))
+	require.NoError(t, err)
+	_, err = r.Define(orderDefinition(s))
+	assert.ErrorContains(t, err, "already defined")
+
+	for _, id := range []string{"o1", "o2", "broken"} {
+		_, err := m.Create(ctx, id)
+		require.NoError(t, err)
+	}
+	c.Advance(10 * time.Minute)
+	_, err = m.Create(ctx, "o3")
+	require.NoError(t, err)
+	_, err = m.Fire(ctx, "o2", "pay")
+	require.NoError(t, err)
+
+	c.Advance(25 * time.Minute)
+	fired, err := r.FireTimeouts(ctx, 0)
+	assert.Equal(t, 1, fired)
+	assert.ErrorContains(t, err, "timeout of order broken in pending: stock service down")
+
+	var orders []released
+	require.NoError(t, s.db.Find(&orders).Error)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "o1", orders[0].OrderID)
+	for id, want := range map[string]State{"o1": "cancelled", "o2": "paid", "o3": "pending", "broken": "pending"} {
+		got, err := m.Get(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.State, id)
+	}
+	broken, err := m.Get(ctx, "broken")
+	require.NoError(t, err)
+	assert.Equal(t, c.now.Add(30*time.Minute), *broken.ExpiresAt, "failed timeouts are re-armed")
+
+	c.Advance(10 * time.Minute)
+	fired, err = r.FireTimeouts(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired, "o3 expires")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := map[string]Definition{
+		"name and initial state are required": {Initial: "a"},
+		"needs an event":                      {Name: "m", Initial: "a", Transitions: []Transition{{Event: "go", To: "b"}}},
+		"two transitions on go from a": {Name: "m", Initial: "a", Transitions: []Transition{
+			{Event: "go", From: []State{"a"}, To: "b"}, {Event: "go", From: []State{"a"}, To: "c"},
+		}},
+		"timeout event stop has no transition from a": {Name: "m", Initial: "a",
+			Transitions: []Transition{{Event: "go", From: []State{"a"}, To: "b"}},
+			Timeouts:    []Timeout{{State: "a", After: time.Minute, Event: "stop"}}},
+		"timeout of a must be positive": {Name: "m", Initial: "a",
+			Transitions: []Transition{{Event: "go", From: []State{"a"}, To: "b"}},
+			Timeouts:    []Timeout{{State: "a", Event: "go"}}},
+	}
+	for want, def := range tests {
+		_, err := New(def, nil)
+		assert.ErrorContains(t, err, want)
+	}
+}
@@ -0,0 +1,157 @@
+// Package stream writes large HTTP list responses item by item, as NDJSON or
+// a JSON array, so handlers never buffer the whole result set.
+//
+// Items are encoded as the iterator produces them and written to the client
+// synchronously: a slow client blocks the writer, which stops pulling from the
+// iterator (e.g. orm.StreamRows), so memory stays bounded by one item and the
+// socket buffers.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+// ErrAborted wraps errors of items that occur after the response started.
+// The status is already sent, so the client sees a truncated body: NDJSON
+// ends with an error line, a JSON array is left unterminated.
+var ErrAborted = errors.New("stream: aborted after response started")
+
+// Content types.
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeJSON   = "application/json"
+)
+
+// flushInterval is how long written items may sit in buffers before the
+// response is flushed.
+const flushInterval = 100 * time.Millisecond
+
+// errorLine is the last NDJSON line of a stream that failed midway.
+type errorLine struct {
+	Error struct {
+		Code    int32  `json:"code"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// format describes the framing of a response.
+type format struct {
+	contentType      string
+	open, sep, close []byte
+	// newline ends every item.
+	newline bool
+	// trailer is written when items fail after the response started.
+	trailer func(err error) []byte
+}
+
+var (
+	ndjson = format{
+		contentType: ContentTypeNDJSON,
+		newline:     true,
+		trailer: func(err error) []byte {
+			var line errorLine
+			se := kerrors.FromError(err)
+			line.Error.Code, line.Error.Reason, line.Error.Message = se.Code, se.Reason, se.Message
+			data, _ := json.Marshal(line)
+			return append(data, '\n')
+		},
+	}
+	jsonArray = format{
+		contentType: ContentTypeJSON,
+		open:        []byte("["),
+		sep:         []byte(","),
+		close:       []byte("]\n"),
+	}
+)
+
+// NDJSON writes items as newline-delimited JSON. An error of items before
+// the first item is returned as is with nothing written, so the caller can
+// render it as a normal error response.
+func NDJSON[T any](w http.ResponseWriter, items iter.Seq2[T, error]) error {
+	return write(w, ndjson, items)
+}
+
+// JSONArray writes items as a JSON array. An error of items before the first
+// item is returned as is with nothing written, so the caller can render it as
+// a normal error response.
+func JSONArray[T any](w http.ResponseWriter, items iter.Seq2[T, error]) error {
+	return write(w, jsonArray, items)
+}
+
+func write[T any](w http.ResponseWriter, f format, items iter.Seq2[T, error]) error {
+	rc := http.NewResponseController(w)
+	flush := func() error {
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", f.contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write(f.open)
+		return err
+	}
+
+	lastFlush := time.Now()
+	n := 0
+	for item, err := range items {
+		if err != nil {
+			if !started {
+				return err
+			}
+			if f.trailer != nil {
+				_, _ = w.Write(f.trailer(err))
+				_ = flush()
+			}
+			return fmt.Errorf("%w: %w", ErrAborted, err)
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			if !started {
+				return fmt.Errorf("stream: encode item: %w", err)
+			}
+			return fmt.Errorf("%w: encode item %d: %w", ErrAborted, n, err)
+		}
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		} else if _, err := w.Write(f.sep); err != nil {
+			return err
+		}
+		if f.newline {
+			data = append(data, '\n')
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		n++
+		if time.Since(lastFlush) >= flushInterval {
+			if err := flush(); err != nil {
+				return err
+			}
+			lastFlush = time.Now()
+		}
+	}
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(f.close); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"errors"
+	"iter"
+	"net/http/httptest"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+// items yields n items, then err when it is not nil.
+func items(n int, err error) iter.Seq2[item, error] {
+	return func(yield func(item, error) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(item{ID: i}, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(item{}, err)
+		}
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, NDJSON(w, items(3, nil)))
+	assert.Equal(t, ContentTypeNDJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", w.Body.String())
+	assert.True(t, w.Flushed)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, NDJSON(w, items(0, nil)))
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestJSONArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, JSONArray(w, items(2, nil)))
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":1},{"id":2}]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	require.NoError(t, JSONArray(w, items(0, nil)))
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestErrors(t *testing.T) {
+	boom := kerrors.InternalServer("DB_FAILED", "query failed")
+
+	// Before the first item nothing is written.
+	w := httptest.NewRecorder()
+	err := NDJSON(w, items(0, boom))
+	assert.ErrorIs(t, err, boom)
+	assert.NotErrorIs(t, err, ErrAborted)
+	assert.False(t, w.Flushed)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = NDJSON(w, items(1, boom))
+	assert.ErrorIs(t, err, ErrAborted)
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, "{\"id\":1}\n{\"error\":{\"code\":500,\"reason\":\"DB_FAILED\",\"message\":\"query failed\"}}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = JSONArray(w, items(2, errors.New("boom")))
+	assert.ErrorIs(t, err, ErrAborted)
+	assert.Equal(t, `[{"id":1},{"id":2}`, w.Body.String(), "the array is left unterminated")
+}
+
+func TestStopsPulling(t *testing.T) {
+	pulled := 0
+	seq := func(yield func(item, error) bool) {
+		for i := 1; i <= 100; i++ {
+			pulled++
+			if !yield(item{ID: i}, nil) {
+				return
+			}
+		}
+	}
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder(), after: 3}
+	assert.Error(t, NDJSON(w, seq))
+	assert.Equal(t, 4, pulled, "the write of the 4th item fails and stops the iterator")
+}
+
+// failingWriter fails writes after the given number, like a client that went away.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	after int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.after == 0 {
+		return 0, errors.New("broken pipe")
+	}
+	if len(p) > 0 {
+		w.after--
+	}
+	return w.ResponseRecorder.Write(p)
+}
@@ -0,0 +1,157 @@
+// Package support collects a snapshot of the service's runtime state into a
+// single archive that can be attached to incident tickets.
+package support
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/go-kratos/kratos-layout/pkg/admin"
+)
+
+// Permission is the admin permission required to download a bundle.
+const Permission = "support.bundle"
+
+// Collector writes one file of the bundle.
+type Collector func(ctx context.Context, w io.Writer) error
+
+type entry struct {
+	name    string
+	collect Collector
+}
+
+// Bundle is an ordered set of collectors.
+type Bundle struct {
+	service string
+	timeout time.Duration
+	log     *log.Helper
+
+	mu      sync.RWMutex
+	entries []entry
+}
+
+// New creates an empty bundle for service, which prefixes the archive name.
+// Every collector runs with the given timeout.
+func New(service string, timeout time.Duration, logger log.Logger) *Bundle {
+	return &Bundle{
+		service: service,
+		timeout: timeout,
+		log:     log.NewHelper(log.With(logger, "module", "pkg/support")),
+	}
+}
+
+// Add registers c to produce the file name, e.g. "goroutines.txt".
+func (b *Bundle) Add(name string, c Collector) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, entry{name: name, collect: c})
+}
+
+// Manifest describes the content of a bundle. It is written last as
+// manifest.json so that it records collector failures.
+type Manifest struct {
+	Created time.Time         `json:"created"`
+	Files   []string          `json:"files"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// Write collects every file into a gzip-compressed tarball written to w.
+// A failing collector doesn't abort the bundle; its error is recorded in
+// the manifest and the partial output is kept.
+func (b *Bundle) Write(ctx context.Context, w io.Writer) error {
+	b.mu.RLock()
+	entries := append([]entry(nil), b.entries...)
+	b.mu.RUnlock()
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	m := Manifest{Created: time.Now().UTC(), Errors: map[string]string{}}
+	for _, e := range entries {
+		var buf bytes.Buffer
+		if err := b.collect(ctx, e.collect, &buf); err != nil {
+			m.Errors[e.name] = err.Error()
+		}
+		if err := writeFile(tw, e.name, buf.Bytes(), m.Created); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, e.name)
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, "manifest.json", manifest, m.Created); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (b *Bundle) collect(ctx context.Context, c Collector, w io.Writer) (err error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c(ctx, w)
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, mtime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: mtime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Handler serves the bundle as a download and writes the request to the
+// audit log. Callers need the support.bundle permission.
+//
+//	GET /admin/support-bundle
+func (b *Bundle) Handler() nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		if req.Method != nethttp.MethodGet {
+			admin.WriteJSON(w, nethttp.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		op, _ := admin.OperatorFromContext(req.Context())
+		if !op.Can(Permission) {
+			b.log.Warnw("audit", "support", "operator", op.Name, "action", Permission, "result", "failed", "error", admin.ErrForbidden)
+			admin.WriteJSON(w, nethttp.StatusForbidden, map[string]string{"error": admin.ErrForbidden.Error()})
+			return
+		}
+
+		start := time.Now()
+		name := fmt.Sprintf("%s-support-%s.tar.gz", b.service, start.UTC().Format("20060102T150405Z"))
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		if err := b.Write(req.Context(), w); err != nil {
+			// Headers are already sent; the truncated archive fails to extract.
+			b.log.Warnw("audit", "support", "operator", op.Name, "action", Permission,
+				"duration", time.Since(start), "result", "failed", "error", err)
+			return
+		}
+		b.log.Infow("audit", "support", "operator", op.Name, "action", Permission,
+			"duration", time.Since(start), "result", "ok")
+	}
+}
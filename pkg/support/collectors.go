@@ -0,0 +1,105 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos-layout/pkg/lifecycle"
+	zapLog "github.com/go-kratos/kratos-layout/pkg/log"
+)
+
+// Config writes c as JSON with secrets masked, see Mask.
+func Config(c proto.Message) Collector {
+	return func(_ context.Context, w io.Writer) error {
+		raw, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(c)
+		if err != nil {
+			return fmt.Errorf("marshal config: %w", err)
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return fmt.Errorf("decode config: %w", err)
+		}
+		return writeJSON(w, Mask(v))
+	}
+}
+
+// Events writes the lifecycle history, which records dependency outages,
+// job failures and reconciler runs.
+func Events(h *lifecycle.History) Collector {
+	return func(_ context.Context, w io.Writer) error {
+		return writeJSON(w, h.Records())
+	}
+}
+
+// Logs writes the most recent log lines.
+func Logs(r *zapLog.Ring) Collector {
+	return func(_ context.Context, w io.Writer) error {
+		for _, line := range r.Lines() {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Goroutines writes the stack of every goroutine.
+func Goroutines() Collector {
+	return func(_ context.Context, w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	}
+}
+
+// Runtime describes the running binary.
+type Runtime struct {
+	Service   string            `json:"service"`
+	Version   string            `json:"version"`
+	Instance  string            `json:"instance"`
+	Started   time.Time         `json:"started"`
+	GoVersion string            `json:"go_version"`
+	GOOS      string            `json:"goos"`
+	GOARCH    string            `json:"goarch"`
+	NumCPU    int               `json:"num_cpu"`
+	Module    string            `json:"module,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"` // build settings, e.g. vcs.revision
+	Deps      map[string]string `json:"deps,omitempty"`     // module path to version
+}
+
+// BuildInfo writes rt completed with the Go runtime and the versions of
+// all dependencies compiled into the binary.
+func BuildInfo(rt Runtime) Collector {
+	return func(_ context.Context, w io.Writer) error {
+		rt.GoVersion = runtime.Version()
+		rt.GOOS, rt.GOARCH, rt.NumCPU = runtime.GOOS, runtime.GOARCH, runtime.NumCPU()
+		if info, ok := debug.ReadBuildInfo(); ok {
+			rt.Module = info.Main.Path
+			rt.Settings = make(map[string]string, len(info.Settings))
+			for _, s := range info.Settings {
+				rt.Settings[s.Key] = s.Value
+			}
+			rt.Deps = make(map[string]string, len(info.Deps))
+			for _, d := range info.Deps {
+				if d.Replace != nil {
+					d = d.Replace
+				}
+				rt.Deps[d.Path] = d.Version
+			}
+		}
+		return writeJSON(w, rt)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
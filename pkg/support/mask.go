@@ -0,0 +1,74 @@
+package support
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Masked replaces secret values.
+const Masked = "REDACTED"
+
+// sensitiveKeys are substrings of field and query parameter names whose
+// values are secrets.
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "credential", "private_key", "access_key", "api_key", "sign"}
+
+// dsnPassword matches the password of a user:password@ prefix, as in MySQL DSNs.
+var dsnPassword = regexp.MustCompile(`^([^:@/\s]+):([^@\s]*)@`)
+
+// Mask returns v, a decoded JSON document, with secrets replaced: values of
+// sensitive keys, passwords embedded in URLs and DSNs, and sensitive URL
+// query parameters such as webhook access tokens.
+func Mask(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if sensitive(k) && val != "" {
+				out[k] = Masked
+				continue
+			}
+			out[k] = Mask(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = Mask(val)
+		}
+		return out
+	case string:
+		return maskString(t)
+	default:
+		return v
+	}
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskString(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Masked)
+		}
+		if u.RawQuery != "" {
+			q := u.Query()
+			for k := range q {
+				if sensitive(k) {
+					q.Set(k, Masked)
+				}
+			}
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(s, "${1}:"+Masked+"@")
+}
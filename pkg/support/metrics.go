@@ -0,0 +1,28 @@
+//go:build !nometrics
+
+package support
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metrics writes the current value of every metric in the text exposition format.
+func Metrics(g prometheus.Gatherer) Collector {
+	return func(_ context.Context, w io.Writer) error {
+		families, err := g.Gather()
+		for _, mf := range families {
+			if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("gather metrics: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !nometrics
+
+package support
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "test"})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	b := New("test", time.Second, log.DefaultLogger)
+	b.Add("metrics.txt", Metrics(reg))
+
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(context.Background(), &buf))
+	files := readBundle(t, &buf)
+
+	assert.Contains(t, files["metrics.txt"], "test_total 1")
+}
@@ -0,0 +1,60 @@
+package timer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+)
+
+// Tasks is a queue of tasks of T on the timers of a kind: a task runs once
+// at its time, or right away, in the handler of the kind, with its value
+// encoded by a codec, e.g. the one of codec.UseTaskQueue:
+//
+//	receipts := timer.NewTasks[Receipt](timers, "receipt.send", codecs.For(codec.UseTaskQueue))
+//	receipts.Handle(uc.sendReceipt)
+//	err := receipts.Enqueue(ctx, orderID, Receipt{OrderID: orderID})
+type Tasks[T any] struct {
+	s     *Service
+	kind  string
+	codec codec.Codec
+}
+
+// NewTasks creates the queue of the tasks of kind on s.
+func NewTasks[T any](s *Service, kind string, c codec.Codec) *Tasks[T] {
+	return &Tasks[T]{s: s, kind: kind, codec: c}
+}
+
+// Handle registers h for the tasks, called with the key they were queued
+// under.
+func (q *Tasks[T]) Handle(h func(ctx context.Context, key string, v T) error) error {
+	return q.s.Handle(q.kind, func(ctx context.Context, id string, payload []byte) error {
+		var v T
+		if err := q.codec.Unmarshal(payload, &v); err != nil {
+			return fmt.Errorf("decode %s task %s: %w", q.codec.Name(), id, err)
+		}
+		return h(ctx, strings.TrimPrefix(id, q.kind+":"), v)
+	})
+}
+
+// Enqueue queues v under key to run as soon as possible, replacing the
+// task queued before under key.
+func (q *Tasks[T]) Enqueue(ctx context.Context, key string, v T) error {
+	return q.EnqueueAt(ctx, key, q.s.opts.now(), v)
+}
+
+// EnqueueAt queues v under key to run at at.
+func (q *Tasks[T]) EnqueueAt(ctx context.Context, key string, at time.Time, v T) error {
+	payload, err := q.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s task: %w", q.codec.Name(), err)
+	}
+	return q.s.ScheduleAt(ctx, q.kind+":"+key, at, payload)
+}
+
+// Cancel removes the task queued under key, if it has not run yet.
+func (q *Tasks[T]) Cancel(ctx context.Context, key string) error {
+	return q.s.Cancel(ctx, q.kind+":"+key)
+}
@@ -0,0 +1,303 @@
+// Package timer provides durable timers for business flows, e.g. to expire
+// an order left unpaid for 30 minutes, without a scheduler of their own.
+// A flow schedules a timer by ID and a handler registered for the kind of
+// the ID is called at its time, once, unless it was cancelled meanwhile:
+//
+//	timers.Handle("order.expire", uc.expire)
+//	err := timers.ScheduleAt(ctx, "order.expire:"+id, time.Now().Add(30*time.Minute), nil)
+//
+// Timers live in the timers table. With a Sender they are delivered by
+// delay messages of the broker, sent when they are scheduled or, when
+// their time is beyond the longest delay of the broker, once they get
+// close; Poll sends those and fires the timers whose message is late.
+// Without a Sender, Poll fires them all.
+package timer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+// DefaultBatch is the number of timers Poll handles per call by default.
+const DefaultBatch = 100
+
+// deliveryGrace is how late the message of a timer may be before Poll
+// fires the timer itself, e.g. when the message was lost or sent before
+// the transaction scheduling the timer committed.
+const deliveryGrace = time.Minute
+
+// Scheduler sets and cancels timers; *Service implements it.
+type Scheduler interface {
+	// ScheduleAt sets the timer id to fire at at with payload, replacing
+	// the one set before under id.
+	ScheduleAt(ctx context.Context, id string, at time.Time, payload []byte) error
+	// Cancel removes the timer id. Cancelling a fired or unknown timer is
+	// a no-op.
+	Cancel(ctx context.Context, id string) error
+}
+
+// Handler is called when the timer id fires. With WithTx it runs in the
+// transaction removing the timer, and its error rolls the removal back so
+// the timer fires again later.
+type Handler func(ctx context.Context, id string, payload []byte) error
+
+// SendFunc sends a delay message of body to be delivered at at, keyed by
+// the timer ID, e.g. with rocketmq.Producer.SendAt to a DELAY topic whose
+// messages are handed to Service.Deliver.
+type SendFunc func(ctx context.Context, key string, body []byte, at time.Time) error
+
+// DBFunc returns the database of ctx, e.g. Data.DB.
+type DBFunc func(ctx context.Context) *gorm.DB
+
+// TxFunc runs fn in a transaction, e.g. Data.InTx.
+type TxFunc func(ctx context.Context, fn func(ctx context.Context) error) error
+
+// Timer is a timer not fired yet.
+type Timer struct {
+	ID      string    `gorm:"primaryKey;size:191"`
+	FireAt  time.Time `gorm:"not null;index:idx_timers_due,priority:2"`
+	Payload []byte
+	// Token identifies the schedule of the timer in its message, so that
+	// the message of a timer scheduled again or cancelled is ignored.
+	Token int64 `gorm:"not null"`
+	// Sent reports whether the delay message of the timer was sent.
+	Sent bool `gorm:"not null;index:idx_timers_due,priority:1"`
+}
+
+// TableName implements gorm's tabler.
+func (Timer) TableName() string { return "timers" }
+
+// message is the body of the delay message of a timer.
+type message struct {
+	ID    string `json:"id"`
+	Token int64  `json:"token"`
+}
+
+type options struct {
+	send     SendFunc
+	maxDelay time.Duration
+	inTx     TxFunc
+	now      func() time.Time
+}
+
+// Option configures a Service.
+type Option func(*options)
+
+// WithSender delivers the timers with the delay messages of send, whose
+// broker accepts delays up to maxDelay, e.g. the 3 days of the
+// timerMaxDelaySec of RocketMQ.
+func WithSender(send SendFunc, maxDelay time.Duration) Option {
+	return func(o *options) { o.send, o.maxDelay = send, maxDelay }
+}
+
+// WithTx runs every handler in a transaction of fn, with the removal of
+// its timer.
+func WithTx(fn TxFunc) Option {
+	return func(o *options) { o.inTx = fn }
+}
+
+// WithClock sets the clock of the timers, e.g. a fake one in tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+var _ Scheduler = (*Service)(nil)
+
+// Service stores the timers and fires them into the registered handlers.
+type Service struct {
+	db   DBFunc
+	opts options
+	log  *log.Helper
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// New creates a service storing the timers in db.
+func New(db DBFunc, logger log.Logger, opts ...Option) *Service {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Service{
+		db:       db,
+		opts:     o,
+		log:      log.NewHelper(log.With(logger, "module", "pkg/timer")),
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Kind returns the kind of a timer ID, the part before the first ':', e.g.
+// "order.expire" for "order.expire:42".
+func Kind(id string) string {
+	kind, _, _ := strings.Cut(id, ":")
+	return kind
+}
+
+// Handle registers h for the timers of kind. Only one handler per kind is
+// allowed.
+func (s *Service) Handle(kind string, h Handler) error {
+	if h == nil {
+		return errors.New("timer: handler cannot be nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.handlers[kind]; ok {
+		return fmt.Errorf("timer: kind %s already has a handler", kind)
+	}
+	s.handlers[kind] = h
+	return nil
+}
+
+// ScheduleAt implements Scheduler. A time in the past fires the timer right
+// away. The delay message is sent before the transaction of ctx, if any,
+// commits; a message arriving before it is ignored and Poll fires the
+// timer instead.
+func (s *Service) ScheduleAt(ctx context.Context, id string, at time.Time, payload []byte) error {
+	if id == "" {
+		return errors.New("timer: id is required")
+	}
+	t := Timer{ID: id, FireAt: at, Payload: payload, Token: rand.Int64()}
+	if err := s.db(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&t).Error; err != nil {
+		return fmt.Errorf("schedule timer %s: %w", id, err)
+	}
+	if s.opts.send != nil && at.Sub(s.opts.now()) <= s.opts.maxDelay {
+		if err := s.send(ctx, &t); err != nil {
+			// Poll sends it again.
+			s.log.WithContext(ctx).Warnf("send timer %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Cancel implements Scheduler.
+func (s *Service) Cancel(ctx context.Context, id string) error {
+	if err := s.db(ctx).Where("id = ?", id).Delete(&Timer{}).Error; err != nil {
+		return fmt.Errorf("cancel timer %s: %w", id, err)
+	}
+	return nil
+}
+
+// send sends the delay message of t and records it.
+func (s *Service) send(ctx context.Context, t *Timer) error {
+	body, err := json.Marshal(message{ID: t.ID, Token: t.Token})
+	if err != nil {
+		return err
+	}
+	if err := s.opts.send(ctx, t.ID, body, t.FireAt); err != nil {
+		return err
+	}
+	return s.db(ctx).Model(&Timer{}).Where("id = ? AND token = ?", t.ID, t.Token).Update("sent", true).Error
+}
+
+// Deliver fires the timer of a delay message sent by the SendFunc of
+// WithSender; subscribe it to the topic of the messages. An error has the
+// message redelivered.
+func (s *Service) Deliver(ctx context.Context, e *eventbus.Event) error {
+	var m message
+	if err := json.Unmarshal(e.Body, &m); err != nil {
+		s.log.WithContext(ctx).Errorf("drop undecodable timer message %q: %v", e.Body, err)
+		return nil
+	}
+	_, err := s.fire(ctx, m.ID, m.Token)
+	return err
+}
+
+// fire runs the handler of the timer id scheduled with token and removes
+// the timer, and reports whether it did. A timer cancelled, scheduled
+// again or fired meanwhile is skipped.
+func (s *Service) fire(ctx context.Context, id string, token int64) (bool, error) {
+	var fired bool
+	run := func(ctx context.Context, db *gorm.DB) error {
+		var t Timer
+		err := db.Where("id = ? AND token = ?", id, token).Take(&t).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.RLock()
+		h, ok := s.handlers[Kind(id)]
+		s.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("no handler for timer kind %s", Kind(id))
+		}
+		res := db.Where("id = ? AND token = ?", id, token).Delete(&Timer{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		fired = true
+		return h(ctx, id, t.Payload)
+	}
+	var err error
+	if s.opts.inTx != nil {
+		err = s.opts.inTx(ctx, func(ctx context.Context) error { return run(ctx, s.db(ctx)) })
+	} else {
+		err = s.db(ctx).Transaction(func(tx *gorm.DB) error { return run(ctx, tx) })
+	}
+	if err != nil {
+		return false, fmt.Errorf("fire timer %s: %w", id, err)
+	}
+	return fired, nil
+}
+
+// Poll sends the delay messages of at most limit timers entering the
+// longest delay of the broker, and fires at most limit timers whose
+// message is late, or that are due without a Sender, oldest first.
+// DefaultBatch is used when limit is not positive. It returns the number
+// of timers fired.
+func (s *Service) Poll(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		limit = DefaultBatch
+	}
+	var (
+		fired int
+		errs  []error
+	)
+	now := s.opts.now()
+	due := now
+	if s.opts.send != nil {
+		due = now.Add(-deliveryGrace)
+		var pending []Timer
+		err := s.db(ctx).Where("sent = ? AND fire_at <= ?", false, now.Add(s.opts.maxDelay)).
+			Order("fire_at").Limit(limit).Find(&pending).Error
+		if err != nil {
+			return 0, fmt.Errorf("find timers to send: %w", err)
+		}
+		for i := range pending {
+			if err := s.send(ctx, &pending[i]); err != nil {
+				// The broker is likely down: fire the late timers still.
+				errs = append(errs, fmt.Errorf("send timer %s: %w", pending[i].ID, err))
+				break
+			}
+		}
+	}
+
+	var late []Timer
+	if err := s.db(ctx).Where("fire_at <= ?", due).Order("fire_at").Limit(limit).Find(&late).Error; err != nil {
+		return 0, errors.Join(append(errs, fmt.Errorf("find due timers: %w", err))...)
+	}
+	for _, t := range late {
+		ok, err := s.fire(ctx, t.ID, t.Token)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ok && err == nil {
+			fired++
+		}
+	}
+	return fired, errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+package timer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/go-kratos/kratos-layout/internal/ormtest"
+	"github.com/go-kratos/kratos-layout/pkg/codec"
+	"github.com/go-kratos/kratos-layout/pkg/eventbus"
+)
+
+func openDB(t *testing.T) DBFunc {
+	t.Helper()
+	db := ormtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&Timer{}))
+	return func(ctx context.Context) *gorm.DB { return db.WithContext(ctx) }
+}
+
+type sent struct {
+	key  string
+	body []byte
+	at   time.Time
+}
+
+func TestService_Deliver(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var messages []sent
+	send := func(_ context.Context, key string, body []byte, at time.Time) error {
+		messages = append(messages, sent{key, body, at})
+		return nil
+	}
+	s := New(db, log.DefaultLogger, WithSender(send, 24*time.Hour), WithClock(func() time.Time { return now }))
+	var fired []string
+	require.NoError(t, s.Handle("order.expire", func(_ context.Context, id string, payload []byte) error {
+		fired = append(fired, id+"="+string(payload))
+		return nil
+	}))
+	assert.Error(t, s.Handle("order.expire", func(context.Context, string, []byte) error { return nil }))
+
+	require.NoError(t, s.ScheduleAt(ctx, "order.expire:1", now.Add(time.Hour), []byte("a")))
+	require.NoError(t, s.ScheduleAt(ctx, "order.expire:2", now.Add(72*time.Hour), []byte("b")))
+	require.Len(t, messages, 1, "timers beyond the max delay wait in the table")
+	assert.Equal(t, "order.expire:1", messages[0].key)
+	assert.Equal(t, now.Add(time.Hour), messages[0].at)
+
+	// Scheduling again invalidates the message sent before.
+	require.NoError(t, s.ScheduleAt(ctx, "order.expire:1", now.Add(2*time.Hour), []byte("c")))
+	require.Len(t, messages, 2)
+	require.NoError(t, s.Deliver(ctx, &eventbus.Event{Body: messages[0].body}))
+	assert.Empty(t, fired)
+	require.NoError(t, s.Deliver(ctx, &eventbus.Event{Body: messages[1].body}))
+	require.NoError(t, s.Deliver(ctx, &eventbus.Event{Body: messages[1].body}), "redelivery")
+	assert.Equal(t, []string{"order.expire:1=c"}, fired)
+
+	// Poll sends the message once the timer gets within the max delay.
+	now = now.Add(49 * time.Hour)
+	n, err := s.Poll(ctx, 0)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "order.expire:2", messages[2].key)
+
+	// Cancelled timers are not fired.
+	require.NoError(t, s.Cancel(ctx, "order.expire:2"))
+	require.NoError(t, s.Deliver(ctx, &eventbus.Event{Body: messages[2].body}))
+	assert.Len(t, fired, 1)
+}
+
+func TestService_Poll(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(db, log.DefaultLogger, WithClock(func() time.Time { return now }))
+	handleErr := errors.New("downstream unavailable")
+	var fired []string
+	require.NoError(t, s.Handle("report", func(_ context.Context, id string, _ []byte) error {
+		fired = append(fired, id)
+		if len(fired) == 1 {
+			return handleErr
+		}
+		return nil
+	}))
+
+	require.NoError(t, s.ScheduleAt(ctx, "report:daily", now.Add(time.Minute), nil))
+	require.NoError(t, s.ScheduleAt(ctx, "unknown:1", now.Add(time.Minute), nil))
+	n, err := s.Poll(ctx, 0)
+	require.NoError(t, err)
+	assert.Zero(t, n, "not due yet")
+
+	now = now.Add(time.Minute)
+	n, err = s.Poll(ctx, 0)
+	assert.ErrorIs(t, err, handleErr)
+	assert.ErrorContains(t, err, "no handler for timer kind unknown")
+	assert.Zero(t, n)
+
+	n, err = s.Poll(ctx, 0)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n, "a failed handler fires again")
+	assert.Equal(t, []string{"report:daily", "report:daily"}, fired)
+
+	var left []Timer
+	require.NoError(t, db(ctx).Find(&left).Error)
+	require.Len(t, left, 1)
+	assert.Equal(t, "unknown:1", left[0].ID)
+}
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, "order.expire", Kind("order.expire:42:a"))
+	assert.Equal(t, "plain", Kind("plain"))
+}
+
+type receipt struct {
+	OrderID string
+	Amount  int64
+}
+
+func TestTasks(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := New(db, log.DefaultLogger, WithClock(func() time.Time { return now }))
+	msgpack, err := codec.Get(codec.Msgpack)
+	require.NoError(t, err)
+	receipts := NewTasks[receipt](s, "receipt.send", msgpack)
+	got := map[string]receipt{}
+	require.NoError(t, receipts.Handle(func(_ context.Context, key string, v receipt) error {
+		got[key] = v
+		return nil
+	}))
+
+	require.NoError(t, receipts.Enqueue(ctx, "o-1", receipt{OrderID: "o-1", Amount: 1250}))
+	require.NoError(t, receipts.EnqueueAt(ctx, "o-2", now.Add(time.Hour), receipt{OrderID: "o-2"}))
+	require.NoError(t, receipts.EnqueueAt(ctx, "o-3", now.Add(time.Hour), receipt{OrderID: "o-3"}))
+	require.NoError(t, receipts.Cancel(ctx, "o-3"))
+
+	n, err := s.Poll(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, map[string]receipt{"o-1": {OrderID: "o-1", Amount: 1250}}, got)
+
+	now = now.Add(time.Hour)
+	n, err = s.Poll(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, receipt{OrderID: "o-2"}, got["o-2"])
+	assert.NotContains(t, got, "o-3")
+}
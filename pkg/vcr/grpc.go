@@ -0,0 +1,61 @@
+package vcr
+
+import (
+	"context"
+	"fmt"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientInterceptor records unary gRPC calls to the cassette, or
+// answers them from it in replay mode. Metadata is not recorded.
+func (c *Cassette) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		reqMsg, ok1 := req.(proto.Message)
+		replyMsg, ok2 := reply.(proto.Message)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("vcr: %s does not use proto messages", method)
+		}
+		body, err := protojson.Marshal(reqMsg)
+		if err != nil {
+			return err
+		}
+		r := Request{Method: method, Body: string(body)}
+
+		if c.mode == ModeReplay {
+			i, err := c.replay(KindGRPC, r)
+			if err != nil {
+				return err
+			}
+			if e := i.Response.Error; e != nil {
+				return grpcError(e)
+			}
+			return protojson.Unmarshal([]byte(i.Response.Body), replyMsg)
+		}
+
+		callErr := invoker(ctx, method, req, reply, cc, opts...)
+		i := Interaction{Kind: KindGRPC, Request: r}
+		if callErr != nil {
+			s := status.Convert(callErr)
+			p, _ := proto.Marshal(s.Proto())
+			i.Response.Error = &Status{Code: uint32(s.Code()), Message: s.Message(), Proto: p}
+		} else if out, err := protojson.Marshal(replyMsg); err == nil {
+			i.Response.Body = string(out)
+		}
+		c.record(i)
+		return callErr
+	}
+}
+
+func grpcError(e *Status) error {
+	var p spb.Status
+	if len(e.Proto) > 0 && proto.Unmarshal(e.Proto, &p) == nil {
+		return status.ErrorProto(&p)
+	}
+	return status.Error(codes.Code(e.Code), e.Message)
+}
@@ -0,0 +1,71 @@
+package vcr
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Transport records the HTTP requests made through next, or answers them
+// from the cassette in replay mode. A nil next uses
+// http.DefaultTransport.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{c: c, next: next}
+}
+
+type transport struct {
+	c    *Cassette
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	r := Request{Method: req.Method, URL: req.URL.String(), Headers: req.Header.Clone(), Body: string(body)}
+
+	if t.c.mode == ModeReplay {
+		i, err := t.c.replay(KindHTTP, r)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        http.StatusText(i.Response.Status),
+			StatusCode:    i.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(i.Response.Body))),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	t.c.record(Interaction{Kind: KindHTTP, Request: r, Response: Response{
+		Status:  resp.StatusCode,
+		Headers: resp.Header.Clone(),
+		Body:    string(respBody),
+	}})
+	return resp, nil
+}
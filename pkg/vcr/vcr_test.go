@@ -0,0 +1,148 @@
+package vcr
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	v1 "github.com/go-kratos/kratos-layout/api/helloworld/v1"
+)
+
+type greeter struct {
+	v1.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, req *v1.HelloRequest) (*v1.HelloReply, error) {
+	if req.GetName() == "missing" {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user "+req.GetName()+" not found")
+	}
+	return &v1.HelloReply{Message: "hello " + req.GetName()}, nil
+}
+
+// greet calls SayHello through c on addr.
+func greet(t *testing.T, c *Cassette, addr, name string) (*v1.HelloReply, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(c.UnaryClientInterceptor()))
+	require.NoError(t, err)
+	defer conn.Close()
+	return v1.NewGreeterClient(conn).SayHello(context.Background(), &v1.HelloRequest{Name: name})
+}
+
+func TestCassette_GRPC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greeter.yaml")
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	v1.RegisterGreeterServer(srv, greeter{})
+	go srv.Serve(lis)
+	addr := lis.Addr().String()
+
+	rec, err := New(path, ModeRecord)
+	require.NoError(t, err)
+	reply, err := greet(t, rec, addr, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hello alice", reply.GetMessage())
+	_, err = greet(t, rec, addr, "missing")
+	require.Error(t, err)
+	require.NoError(t, rec.Save())
+	srv.Stop()
+
+	play, err := New(path, ModeReplay)
+	require.NoError(t, err)
+	_, err = greet(t, play, addr, "missing")
+	assert.Equal(t, "USER_NOT_FOUND", errors.Reason(err), "the error reason is replayed")
+	reply, err = greet(t, play, addr, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hello alice", reply.GetMessage())
+	_, err = greet(t, play, addr, "alice")
+	assert.ErrorIs(t, err, ErrNoInteraction, "each interaction answers once")
+	_, err = greet(t, play, addr, "bob")
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
+
+func TestCassette_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(body) + `,"token":"t0ps3cret"}`))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "http.yaml")
+	opts := []Option{WithScrubber(ScrubFields("token", "user.phone", "echo.user.phone")), WithMatcher(IgnoreFields("request_id"))}
+
+	post := func(c *Cassette, body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/users", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := (&http.Client{Transport: c.Transport(nil)}).Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(data)
+	}
+
+	rec, err := New(path, ModeRecord, opts...)
+	require.NoError(t, err)
+	resp, body := post(rec, `{"request_id":"1","user":{"name":"a","phone":"138"}}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Contains(t, body, "t0ps3cret", "the caller gets the real response")
+	require.NoError(t, rec.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"Bearer secret", "session=secret", "t0ps3cret", "138"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	play, err := New(path, ModeReplay, opts...)
+	require.NoError(t, err)
+	resp, body = post(play, `{"request_id":"2","user":{"name":"a","phone":"139"}}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `"token":"[scrubbed]"`)
+
+	_, err = (&http.Client{Transport: play.Transport(nil)}).Get(srv.URL + "/users")
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
+
+func TestMatchers(t *testing.T) {
+	a := &Request{Method: "POST", URL: "/u", Body: `{"a":1,"b":{"c":2,"d":3}}`}
+	b := &Request{Method: "POST", URL: "/u", Body: `{"b":{"d":3,"c":2},"a":1}`}
+	c := &Request{Method: "POST", URL: "/u", Body: `{"a":1,"b":{"c":5,"d":3}}`}
+	assert.True(t, DefaultMatcher(a, b), "JSON bodies compare semantically")
+	assert.False(t, DefaultMatcher(a, c))
+	assert.True(t, IgnoreFields("b.c")(a, c))
+	assert.True(t, MatchMethod(a, c))
+	assert.False(t, MatchMethod(a, &Request{Method: "GET", URL: "/u"}))
+	assert.False(t, DefaultMatcher(&Request{Body: "x"}, &Request{Body: "y"}))
+}
+
+func TestForTest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	_, err := New(filepath.Join("testdata", "cassettes", "missing.yaml"), ModeReplay)
+	assert.ErrorContains(t, err, "VCR_MODE=record")
+
+	t.Setenv("VCR_MODE", "record")
+	t.Run("record", func(t *testing.T) {
+		c := ForTest(t, "recorded")
+		assert.Equal(t, ModeRecord, c.Mode())
+		c.record(Interaction{Kind: KindHTTP, Request: Request{Method: "GET", URL: "/"}})
+	})
+	_, err = os.Stat(filepath.Join("testdata", "cassettes", "recorded.yaml"))
+	assert.NoError(t, err, "saved on cleanup")
+}